@@ -0,0 +1,47 @@
+package aitelemetry
+
+import "maps"
+
+type tee []TelemetryHandle
+
+// NewTee returns a TelemetryHandle which sends the telemetry to all of the handles, e.g. to Application Insights
+// and to an OTLP receiver. The handles may add to the maps of the telemetry, so each handle gets its own copy.
+func NewTee(handles ...TelemetryHandle) TelemetryHandle {
+	return tee(handles)
+}
+
+func (t tee) TrackLog(report Report) {
+	for _, th := range t {
+		r := report
+		r.CustomDimensions = maps.Clone(report.CustomDimensions)
+		th.TrackLog(r)
+	}
+}
+
+func (t tee) TrackMetric(metric Metric) {
+	for _, th := range t {
+		m := metric
+		m.CustomDimensions = maps.Clone(metric.CustomDimensions)
+		th.TrackMetric(m)
+	}
+}
+
+func (t tee) TrackEvent(event Event) {
+	for _, th := range t {
+		e := event
+		e.Properties = maps.Clone(event.Properties)
+		th.TrackEvent(e)
+	}
+}
+
+func (t tee) Close(timeout int) {
+	for _, th := range t {
+		th.Close(timeout)
+	}
+}
+
+func (t tee) Flush() {
+	for _, th := range t {
+		th.Flush()
+	}
+}
@@ -0,0 +1,51 @@
+package aitelemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHandle struct {
+	reports []Report
+	metrics []Metric
+	events  []Event
+	flushed int
+	closed  int
+}
+
+func (r *recordingHandle) TrackLog(report Report) {
+	report.CustomDimensions["handle"] = "recorded"
+	r.reports = append(r.reports, report)
+}
+
+func (r *recordingHandle) TrackMetric(metric Metric) { r.metrics = append(r.metrics, metric) }
+
+func (r *recordingHandle) TrackEvent(event Event) { r.events = append(r.events, event) }
+
+func (r *recordingHandle) Close(int) { r.closed++ }
+
+func (r *recordingHandle) Flush() { r.flushed++ }
+
+func TestTee(t *testing.T) {
+	first, second := &recordingHandle{}, &recordingHandle{}
+	th := NewTee(first, second)
+
+	dims := map[string]string{"OperationType": "ADD"}
+	th.TrackLog(Report{Message: "msg", CustomDimensions: dims})
+	th.TrackMetric(Metric{Name: "metric", Value: 1})
+	th.TrackEvent(Event{EventName: "event"})
+	th.Flush()
+	th.Close(1)
+
+	for _, h := range []*recordingHandle{first, second} {
+		require.Len(t, h.reports, 1)
+		require.Equal(t, "msg", h.reports[0].Message)
+		require.Len(t, h.metrics, 1)
+		require.Len(t, h.events, 1)
+		require.Equal(t, 1, h.flushed)
+		require.Equal(t, 1, h.closed)
+	}
+	// the handles don't see each others changes to the dimensions
+	require.Equal(t, map[string]string{"OperationType": "ADD"}, dims)
+}
@@ -18,3 +18,6 @@ const (
 	ErrRequestIPConfigFromCNS
 	ErrProcessIPConfigResponse
 )
+
+// ErrPluginNotAvailable is the well-known CNI error code returned by STATUS when the plugin cannot service ADD requests.
+const ErrPluginNotAvailable uint = 50
@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns"
 	cnscli "github.com/Azure/azure-container-networking/cns/client"
 	"github.com/Azure/azure-container-networking/cns/fsnotify"
+	"github.com/Azure/azure-container-networking/cns/types"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	types100 "github.com/containernetworking/cni/pkg/types/100"
@@ -26,12 +27,28 @@ const (
 // IPAMPlugin is the struct for the delegated azure-ipam plugin
 // https://www.cni.dev/docs/spec/#section-4-plugin-delegation
 type IPAMPlugin struct {
-	Name      string
-	Version   string
-	Options   map[string]interface{}
-	logger    *zap.Logger
-	cnsClient cnsClient
-	out       io.Writer // indicate the output channel for the plugin
+	Name         string
+	Version      string
+	Options      map[string]interface{}
+	logger       *zap.Logger
+	cnsClient    cnsClient
+	newCNSClient func(baseURL, grpcAddress string) (cnsClient, error) // client of the CNS of the IPAM config
+	out          io.Writer                                            // indicate the output channel for the plugin
+}
+
+// netConf is the network config of the plugin, whose IPAM section may set the URL of CNS.
+type netConf struct {
+	cniTypes.NetConf
+	IPAM ipamConf `json:"ipam,omitempty"`
+}
+
+type ipamConf struct {
+	cniTypes.IPAM
+	// CNSUrl is the URL of CNS e.g. unix:///var/run/azure-cns/cns.sock. Unset, the default CNS URL is used.
+	CNSUrl string `json:"cnsurl,omitempty"`
+	// CNSGrpcAddress is the address of the CNS gRPC service e.g. unix:///var/run/azure-cns/cns-grpc.sock.
+	// Set, the IPAM APIs are called over gRPC instead of HTTP.
+	CNSGrpcAddress string `json:"cnsGrpcAddress,omitempty"`
 }
 
 type cnsClient interface {
@@ -39,6 +56,7 @@ type cnsClient interface {
 	RequestIPs(context.Context, cns.IPConfigsRequest) (*cns.IPConfigsResponse, error)
 	ReleaseIPs(context.Context, cns.IPConfigsRequest) error
 	ReleaseIPAddress(context.Context, cns.IPConfigRequest) error
+	GetIPAddressesMatchingStates(context.Context, ...types.IPState) ([]cns.IPConfigurationStatus, error)
 }
 
 // NewPlugin constructs a new IPAM plugin instance with given logger and CNS client
@@ -49,10 +67,28 @@ func NewPlugin(logger *zap.Logger, c cnsClient, out io.Writer) (*IPAMPlugin, err
 		logger:    logger,
 		out:       out,
 		cnsClient: c,
+		newCNSClient: func(baseURL, grpcAddress string) (cnsClient, error) {
+			if grpcAddress != "" {
+				return cnscli.NewGRPC(baseURL, grpcAddress, cnsReqTimeout) //nolint:wrapcheck // wrapped by the caller
+			}
+			return cnscli.New(baseURL, cnsReqTimeout) //nolint:wrapcheck // wrapped by the caller
+		},
 	}
 	return plugin, nil
 }
 
+// client returns the client of the CNS of the IPAM config, or else the client of the plugin.
+func (p *IPAMPlugin) client(nwCfg *netConf) (cnsClient, error) {
+	if nwCfg == nil || (nwCfg.IPAM.CNSUrl == "" && nwCfg.IPAM.CNSGrpcAddress == "") {
+		return p.cnsClient, nil
+	}
+	c, err := p.newCNSClient(nwCfg.IPAM.CNSUrl, nwCfg.IPAM.CNSGrpcAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create CNS client for %s %s", nwCfg.IPAM.CNSUrl, nwCfg.IPAM.CNSGrpcAddress)
+	}
+	return c, nil
+}
+
 //
 // CNI implementation
 // https://github.com/containernetworking/cni/blob/master/SPEC.md
@@ -70,6 +106,12 @@ func (p *IPAMPlugin) CmdAdd(args *cniSkel.CmdArgs) error {
 	}
 	p.logger.Debug("Parsed network config", zap.Any("netconf", nwCfg))
 
+	client, err := p.client(nwCfg)
+	if err != nil {
+		p.logger.Error("Failed to create CNS client", zap.Error(err))
+		return cniTypes.NewError(cniTypes.ErrInvalidNetworkConfig, err.Error(), "failed to create CNS client")
+	}
+
 	// Create ip config request from args
 	req, err := ipconfig.CreateIPConfigsReq(args)
 	if err != nil {
@@ -81,7 +123,9 @@ func (p *IPAMPlugin) CmdAdd(args *cniSkel.CmdArgs) error {
 	p.logger.Debug("Making request to CNS")
 	// if this fails, the caller plugin should execute again with cmdDel before returning error.
 	// https://www.cni.dev/docs/spec/#delegated-plugin-execution-procedure
-	resp, err := p.cnsClient.RequestIPs(context.TODO(), req)
+	ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+	defer cancel()
+	resp, err := client.RequestIPs(ctx, req)
 	if err != nil {
 		if cnscli.IsUnsupportedAPI(err) {
 			p.logger.Error("Failed to request IPs using RequestIPs from CNS, going to try RequestIPAddress", zap.Error(err), zap.Any("request", req))
@@ -93,7 +137,9 @@ func (p *IPAMPlugin) CmdAdd(args *cniSkel.CmdArgs) error {
 			p.logger.Debug("Created CNS IP config request", zap.Any("request", ipconfigReq))
 
 			p.logger.Debug("Making request to CNS")
-			res, err := p.cnsClient.RequestIPAddress(context.TODO(), ipconfigReq)
+			ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+			defer cancel()
+			res, err := client.RequestIPAddress(ctx, ipconfigReq)
 
 			// if the old API fails as well then we just return the error
 			if err != nil {
@@ -187,6 +233,14 @@ func (p *IPAMPlugin) CmdDel(args *cniSkel.CmdArgs) error {
 	var connectionErr *cnscli.ConnectionFailureErr
 	p.logger.Info("DEL called", zap.Any("args", args))
 
+	// a network config which fails to parse falls back to the default CNS, so that the IPs are still released
+	nwCfg, _ := parseNetConf(args.StdinData)
+	client, err := p.client(nwCfg)
+	if err != nil {
+		p.logger.Error("Failed to create CNS client", zap.Error(err))
+		return cniTypes.NewError(cniTypes.ErrInvalidNetworkConfig, err.Error(), "failed to create CNS client")
+	}
+
 	// Create ip config request from args
 	req, err := ipconfig.CreateIPConfigsReq(args)
 	if err != nil {
@@ -196,8 +250,9 @@ func (p *IPAMPlugin) CmdDel(args *cniSkel.CmdArgs) error {
 	p.logger.Debug("Created CNS IP config request", zap.Any("request", req))
 
 	p.logger.Debug("Making request to CNS")
-	// cnsClient enforces it own timeout
-	if err := p.cnsClient.ReleaseIPs(context.TODO(), req); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+	defer cancel()
+	if err := client.ReleaseIPs(ctx, req); err != nil {
 		// if we fail a request with a 404 error try using the old API
 		if cnscli.IsUnsupportedAPI(err) {
 			p.logger.Error("Failed to release IPs using ReleaseIPs from CNS, going to try ReleaseIPAddress", zap.Error(err), zap.Any("request", req))
@@ -209,7 +264,9 @@ func (p *IPAMPlugin) CmdDel(args *cniSkel.CmdArgs) error {
 			p.logger.Debug("Created CNS IP config request", zap.Any("request", ipconfigReq))
 
 			p.logger.Debug("Making request to CNS")
-			err = p.cnsClient.ReleaseIPAddress(context.TODO(), ipconfigReq)
+			ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+			defer cancel()
+			err = client.ReleaseIPAddress(ctx, ipconfigReq)
 
 			if err != nil {
 				if errors.As(err, &connectionErr) {
@@ -252,9 +309,117 @@ func (p *IPAMPlugin) CmdCheck(args *cniSkel.CmdArgs) error {
 	return nil
 }
 
+// CmdGC handles CNI garbage collection commands.
+// IPs which azure-ipam requested from CNS for containers that are not in the runtime's list of valid attachments are
+// released. Other plugins using the same CNS, such as azure-vnet, record another interface ID for their IPs, which are
+// left alone. CNS doesn't record the network, so the networks which use azure-ipam with the same CNS share the GC.
+func (p *IPAMPlugin) CmdGC(args *cniSkel.CmdArgs) error {
+	p.logger.Info("GC called", zap.Any("args", args))
+
+	nwCfg, err := parseNetConf(args.StdinData)
+	if err != nil {
+		p.logger.Error("Failed to parse CNI network config from stdin", zap.Error(err), zap.Any("argStdinData", args.StdinData))
+		return cniTypes.NewError(cniTypes.ErrDecodingFailure, err.Error(), "failed to parse CNI network config from stdin")
+	}
+
+	client, err := p.client(nwCfg)
+	if err != nil {
+		p.logger.Error("Failed to create CNS client", zap.Error(err))
+		return cniTypes.NewError(cniTypes.ErrInvalidNetworkConfig, err.Error(), "failed to create CNS client")
+	}
+
+	validContainerIDs := make(map[string]struct{}, len(nwCfg.ValidAttachments))
+	for _, attachment := range nwCfg.ValidAttachments {
+		validContainerIDs[attachment.ContainerID] = struct{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+	ipConfigs, err := client.GetIPAddressesMatchingStates(ctx, types.Assigned)
+	cancel()
+	if err != nil {
+		p.logger.Error("Failed to get assigned IPs from CNS", zap.Error(err))
+		return cniTypes.NewError(cniTypes.ErrTryAgainLater, err.Error(), "failed to get assigned IPs from CNS")
+	}
+
+	released := map[string]struct{}{}
+	for i := range ipConfigs {
+		podInfo := ipConfigs[i].PodInfo
+		// IPs without an infra container ID were not assigned through a CNI ADD
+		if podInfo == nil || podInfo.InfraContainerID() == "" {
+			continue
+		}
+		// azure-ipam requests IPs with the container ID as the interface ID, see ipconfig.CreateIPConfigsReq
+		if podInfo.InterfaceID() != podInfo.InfraContainerID() {
+			continue
+		}
+		if _, ok := validContainerIDs[podInfo.InfraContainerID()]; ok {
+			continue
+		}
+		if _, ok := released[podInfo.Key()]; ok {
+			continue
+		}
+
+		orchestratorContext, err := podInfo.OrchestratorContext()
+		if err != nil {
+			p.logger.Error("Failed to create orchestrator context", zap.Error(err), zap.String("podInfo", podInfo.String()))
+			continue
+		}
+
+		req := cns.IPConfigsRequest{
+			PodInterfaceID:      podInfo.InterfaceID(),
+			InfraContainerID:    podInfo.InfraContainerID(),
+			OrchestratorContext: orchestratorContext,
+		}
+		p.logger.Info("Releasing leaked IP", zap.String("ip", ipConfigs[i].IPAddress), zap.Any("request", req))
+		ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+		err = client.ReleaseIPs(ctx, req)
+		cancel()
+		if err != nil {
+			p.logger.Error("Failed to release leaked IP", zap.Error(err), zap.Any("request", req))
+			return cniTypes.NewError(cniTypes.ErrTryAgainLater, err.Error(), "failed to release leaked IP addresses from CNS")
+		}
+		released[podInfo.Key()] = struct{}{}
+	}
+
+	p.logger.Info("GC success", zap.Int("released", len(released)))
+
+	return nil
+}
+
+// CmdStatus handles CNI status commands.
+// The plugin is not available when CNS is unreachable or has no free IPs.
+func (p *IPAMPlugin) CmdStatus(args *cniSkel.CmdArgs) error {
+	p.logger.Info("STATUS called")
+
+	var nwCfg *netConf
+	if args != nil {
+		nwCfg, _ = parseNetConf(args.StdinData)
+	}
+	client, err := p.client(nwCfg)
+	if err != nil {
+		p.logger.Error("Failed to create CNS client", zap.Error(err))
+		return cniTypes.NewError(ErrPluginNotAvailable, err.Error(), "failed to create CNS client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cnsReqTimeout)
+	defer cancel()
+	ipConfigs, err := client.GetIPAddressesMatchingStates(ctx, types.Available)
+	if err != nil {
+		p.logger.Error("Failed to get available IPs from CNS", zap.Error(err))
+		return cniTypes.NewError(ErrPluginNotAvailable, err.Error(), "CNS is unreachable")
+	}
+
+	if len(ipConfigs) == 0 {
+		p.logger.Error("No free IPs in the CNS pool")
+		return cniTypes.NewError(ErrPluginNotAvailable, "no free IPs in the CNS pool", "")
+	}
+
+	return nil
+}
+
 // Parse network config from given byte array
-func parseNetConf(b []byte) (*cniTypes.NetConf, error) {
-	netConf := &cniTypes.NetConf{}
+func parseNetConf(b []byte) (*netConf, error) {
+	netConf := &netConf{}
 	err := json.Unmarshal(b, netConf)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to unmarshal net conf")
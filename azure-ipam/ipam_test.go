@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
 	"github.com/Azure/azure-container-networking/azure-ipam/logger"
@@ -25,7 +29,11 @@ var (
 )
 
 // MOckCNSClient is a mock implementation of the CNSClient interface
-type MockCNSClient struct{}
+type MockCNSClient struct {
+	ipConfigs    []cns.IPConfigurationStatus
+	ipConfigsErr error
+	released     []string
+}
 
 func (c *MockCNSClient) RequestIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
 	switch ipconfig.InfraContainerID {
@@ -319,10 +327,15 @@ func (c *MockCNSClient) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRe
 		e.Err = errUnsupportedAPI
 		return e
 	default:
+		c.released = append(c.released, ipconfig.InfraContainerID)
 		return nil
 	}
 }
 
+func (c *MockCNSClient) GetIPAddressesMatchingStates(context.Context, ...types.IPState) ([]cns.IPConfigurationStatus, error) {
+	return c.ipConfigs, c.ipConfigsErr
+}
+
 // cniResultsWriter is a helper struct to write CNI results to a byte array
 type cniResultsWriter struct {
 	result *types100.Result
@@ -577,3 +590,184 @@ func TestCmdCheck(t *testing.T) {
 	err = ipamPlugin.CmdCheck(nil)
 	require.NoError(t, err)
 }
+
+func TestCmdGC(t *testing.T) {
+	gcNetConf := &cniTypes.NetConf{
+		CNIVersion: "1.1.0",
+		Name:       "gcnetconf",
+		ValidAttachments: []cniTypes.GCAttachment{
+			{ContainerID: "validContainer", IfName: "eth0"},
+		},
+	}
+	gcNetConfByteArr, err := json.Marshal(gcNetConf)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		ipConfigs    []cns.IPConfigurationStatus
+		ipConfigsErr error
+		wantReleased []string
+		wantErr      bool
+	}{
+		{
+			name: "Release only leaked IPs",
+			ipConfigs: []cns.IPConfigurationStatus{
+				{IPAddress: "10.0.1.10", PodInfo: cns.NewPodInfo("validContainer", "validContainer", "valid", "testns")},
+				{IPAddress: "10.0.1.11", PodInfo: cns.NewPodInfo("leakedContainer", "leakedContainer", "leaked", "testns")},
+				{IPAddress: "fd00::11", PodInfo: cns.NewPodInfo("leakedContainer", "leakedContainer", "leaked", "testns")},
+				{IPAddress: "10.0.1.12", PodInfo: cns.NewPodInfo("", "", "noinfra", "testns")},
+				{IPAddress: "10.0.1.13", PodInfo: cns.NewPodInfo("otherPluginContainer", "otherPlu-eth0", "other", "testns")},
+			},
+			wantReleased: []string{"leakedContainer"},
+		},
+		{
+			name:         "Fail to get assigned IPs from CNS",
+			ipConfigsErr: errFoo,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockCNSClient := &MockCNSClient{ipConfigs: tt.ipConfigs, ipConfigsErr: tt.ipConfigsErr}
+			testLogger, cleanup, err := logger.New(loggerCfg)
+			if err != nil {
+				return
+			}
+			defer cleanup()
+			ipamPlugin, _ := NewPlugin(testLogger, mockCNSClient, nil)
+			err = ipamPlugin.CmdGC(buildArgs("", "", gcNetConfByteArr))
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantReleased, mockCNSClient.released)
+			}
+		})
+	}
+}
+
+func TestCmdStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		ipConfigs    []cns.IPConfigurationStatus
+		ipConfigsErr error
+		wantErr      bool
+	}{
+		{
+			name:      "Free IPs available",
+			ipConfigs: []cns.IPConfigurationStatus{{IPAddress: "10.0.1.10"}},
+		},
+		{
+			name:    "No free IPs",
+			wantErr: true,
+		},
+		{
+			name:         "CNS unreachable",
+			ipConfigsErr: errFoo,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockCNSClient := &MockCNSClient{ipConfigs: tt.ipConfigs, ipConfigsErr: tt.ipConfigsErr}
+			testLogger, cleanup, err := logger.New(loggerCfg)
+			if err != nil {
+				return
+			}
+			defer cleanup()
+			ipamPlugin, _ := NewPlugin(testLogger, mockCNSClient, nil)
+			err = ipamPlugin.CmdStatus(nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCNSClientFromNetConf(t *testing.T) {
+	defaultClient := &MockCNSClient{}
+	configClient := &MockCNSClient{ipConfigs: []cns.IPConfigurationStatus{{IPAddress: "10.0.1.10"}}}
+	tests := []struct {
+		name     string
+		stdin    string
+		wantURL  string
+		wantGRPC string
+		want     cnsClient
+	}{
+		{
+			name:  "no cnsurl",
+			stdin: `{"cniVersion":"1.1.0","name":"net","ipam":{"type":"azure-ipam"}}`,
+			want:  defaultClient,
+		},
+		{
+			name:    "unix socket cnsurl",
+			stdin:   `{"cniVersion":"1.1.0","name":"net","ipam":{"type":"azure-ipam","cnsurl":"unix:///var/run/azure-cns/cns.sock"}}`,
+			wantURL: "unix:///var/run/azure-cns/cns.sock",
+			want:    configClient,
+		},
+		{
+			name:     "grpc address",
+			stdin:    `{"cniVersion":"1.1.0","name":"net","ipam":{"type":"azure-ipam","cnsGrpcAddress":"unix:///var/run/azure-cns/cns-grpc.sock"}}`,
+			wantGRPC: "unix:///var/run/azure-cns/cns-grpc.sock",
+			want:     configClient,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, cleanup, err := logger.New(loggerCfg)
+			require.NoError(t, err)
+			defer cleanup()
+			ipamPlugin, _ := NewPlugin(testLogger, defaultClient, nil)
+			var gotURL, gotGRPC string
+			ipamPlugin.newCNSClient = func(baseURL, grpcAddress string) (cnsClient, error) {
+				gotURL, gotGRPC = baseURL, grpcAddress
+				return configClient, nil
+			}
+
+			nwCfg, err := parseNetConf([]byte(tt.stdin))
+			require.NoError(t, err)
+			got, err := ipamPlugin.client(nwCfg)
+			require.NoError(t, err)
+			require.Same(t, tt.want, got)
+			require.Equal(t, tt.wantURL, gotURL)
+			require.Equal(t, tt.wantGRPC, gotGRPC)
+		})
+	}
+}
+
+// TestCmdStatusOverUnixSocket checks that the plugin reaches a CNS serving on the unix socket of its cnsurl.
+func TestCmdStatusOverUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cns") // short, as socket paths are limited to 108 bytes
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "cns.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+	srv := &http.Server{ //nolint:gosec // test server
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			require.Equal(t, cns.PathDebugIPAddresses, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(cns.GetIPAddressStatusResponse{
+				IPConfigurationStatus: []cns.IPConfigurationStatus{{IPAddress: "10.0.1.10"}},
+			})
+		}),
+	}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	testLogger, cleanup, err := logger.New(loggerCfg)
+	require.NoError(t, err)
+	defer cleanup()
+	ipamPlugin, _ := NewPlugin(testLogger, &MockCNSClient{ipConfigsErr: errFoo}, nil)
+
+	stdin := fmt.Sprintf(`{"cniVersion":"1.1.0","name":"net","ipam":{"type":"azure-ipam","cnsurl":"unix://%s"}}`, socket)
+	require.NoError(t, ipamPlugin.CmdStatus(&cniSkel.CmdArgs{StdinData: []byte(stdin)}))
+	require.Equal(t, int32(1), requests.Load())
+}
@@ -52,7 +52,14 @@ func executePlugin() error {
 	bv.BuildVersion = buildinfo.Version
 
 	// Execute CNI plugin
-	cniErr := skel.PluginMainWithError(plugin.CmdAdd, plugin.CmdCheck, plugin.CmdDel, version.All, bv.BuildString(pluginName))
+	cniFuncs := skel.CNIFuncs{
+		Add:    plugin.CmdAdd,
+		Check:  plugin.CmdCheck,
+		Del:    plugin.CmdDel,
+		GC:     plugin.CmdGC,
+		Status: plugin.CmdStatus,
+	}
+	cniErr := skel.PluginMainFuncsWithError(cniFuncs, version.All, bv.BuildString(pluginName))
 	if cniErr != nil {
 		cniErr.Print()
 		return cniErr
@@ -38,3 +38,44 @@ func (a *AzureCNIState) PrintResult() error {
 
 	return nil
 }
+
+// EndpointRepair is a piece of the endpoint datapath which was found missing and re-applied.
+type EndpointRepair struct {
+	PodName       string
+	PodNamespace  string
+	ContainerID   string
+	PodEndpointId string
+	Kind          string
+	Detail        string
+}
+
+// EndpointReconcileError is an endpoint whose datapath could not be checked or repaired.
+type EndpointReconcileError struct {
+	PodName       string
+	PodNamespace  string
+	ContainerID   string
+	PodEndpointId string
+	Error         string
+}
+
+type EndpointReconcileResult struct {
+	Endpoints int
+	Repairs   []EndpointRepair
+	Errors    []EndpointReconcileError
+}
+
+func (r *EndpointReconcileResult) PrintResult() error {
+	b, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		logger.Error("Failed to marshal endpoint reconcile result", zap.Error(err))
+	}
+
+	// write result to stdout to be captured by caller
+	_, err = os.Stdout.Write(b)
+	if err != nil {
+		logger.Error("Failed to write response to stdout", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -50,6 +50,30 @@ func (c *client) GetEndpointState() (*api.AzureCNIState, error) {
 	return state, nil
 }
 
+// ReconcileEndpoint runs the CNI binary to repair the datapath of one endpoint. The CNI holds its store lock
+// for the duration, so the repairs don't race with ADD and DEL, and other endpoints aren't blocked for long.
+func (c *client) ReconcileEndpoint(endpointID string) (*api.EndpointReconcileResult, error) {
+	cmd := c.exec.Command(platform.CNIBinaryPath)
+	cmd.SetDir(CNIExecDir)
+	envs := os.Environ()
+	cmdenv := fmt.Sprintf("%s=%s", cni.Cmd, cni.CmdReconcileEndpoints)
+	logger.Info("Setting cmd to", zap.String("cmdenv", cmdenv), zap.String("endpointID", endpointID))
+	envs = append(envs, cmdenv, fmt.Sprintf("%s=%s", cni.EnvReconcileEndpointID, endpointID))
+	cmd.SetEnv(envs)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Azure CNI bin with err: [%w], output: [%s]", err, string(output))
+	}
+
+	result := &api.EndpointReconcileResult{}
+	if err := json.Unmarshal(output, result); err != nil {
+		return nil, fmt.Errorf("failed to decode response from Azure CNI when reconciling endpoints: [%w], response from CNI: [%s]", err, string(output))
+	}
+
+	return result, nil
+}
+
 func (c *client) GetVersion() (*semver.Version, error) {
 	cmd := c.exec.Command(platform.CNIBinaryPath, "-v")
 	cmd.SetDir(CNIExecDir)
@@ -33,6 +33,27 @@ func TestGetState(t *testing.T) {
 	require.Equal(t, res, state)
 }
 
+func TestReconcileEndpoint(t *testing.T) {
+	calls := []testutils.TestCmd{
+		{Cmd: []string{"/opt/cni/bin/azure-vnet"}, Stdout: `{"Endpoints":1,"Repairs":[{"PodName":"coredns-0","PodNamespace":"kube-system","ContainerID":"3f813b02","PodEndpointId":"3f813b02-eth0","Kind":"neighbor","Detail":"neighbor 169.254.1.1 on container interface eth0"}]}`},
+	}
+
+	fakeexec := testutils.GetFakeExecWithScripts(calls)
+
+	c := New(fakeexec)
+	result, err := c.ReconcileEndpoint("3f813b02-eth0")
+	require.NoError(t, err)
+
+	res := &api.EndpointReconcileResult{
+		Endpoints: 1,
+		Repairs: []api.EndpointRepair{
+			{PodName: "coredns-0", PodNamespace: "kube-system", ContainerID: "3f813b02", PodEndpointId: "3f813b02-eth0", Kind: "neighbor", Detail: "neighbor 169.254.1.1 on container interface eth0"},
+		},
+	}
+
+	require.Equal(t, res, result)
+}
+
 func TestGetVersion(t *testing.T) {
 	calls := []testutils.TestCmd{
 		{Cmd: []string{"/opt/cni/bin/azure-vnet", "-v"}, Stdout: `Azure CNI Version v1.4.0-2-g984c5a5e-dirty`},
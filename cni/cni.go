@@ -18,21 +18,37 @@ const (
 	CmdDel = "DEL"
 	// CmdUpdate - CNI UPDATE command.
 	CmdUpdate = "UPDATE"
+	// CmdGC - CNI GC command.
+	CmdGC = "GC"
+	// CmdStatus - CNI STATUS command.
+	CmdStatus = "STATUS"
 	// CmdVersion - CNI VERSION command.
 	CmdVersion = "VERSION"
 
 	// nonstandard CNI spec command, used to dump CNI state to stdout
 	CmdGetEndpointsState = "GET_ENDPOINT_STATE"
+	// nonstandard CNI spec command, used to repair the datapath of the endpoints and dump the repairs to stdout
+	CmdReconcileEndpoints = "RECONCILE_ENDPOINTS"
+
+	// EnvReconcileEndpointID limits RECONCILE_ENDPOINTS to the endpoint with this ID, so that the store lock
+	// is held for one endpoint at a time.
+	EnvReconcileEndpointID = "AZURE_CNI_RECONCILE_ENDPOINT_ID"
 
 	// CNI errors.
 	ErrRuntime = 100
+	// ErrDatapathInvalid is returned by CHECK when the datapath of the container
+	// or its IP assignment in CNS doesn't match the endpoint state.
+	ErrDatapathInvalid = 101
+	// ErrPluginNotAvailable is the well-known CNI error code returned by STATUS
+	// when the plugin cannot service ADD requests.
+	ErrPluginNotAvailable = 50
 
 	// DefaultVersion is the CNI version used when no version is specified in a network config file.
 	defaultVersion = "0.2.0"
 )
 
 // Supported CNI versions.
-var supportedVersions = []string{"0.1.0", "0.2.0", "0.3.0", "0.3.1", "0.4.0", "1.0.0"}
+var supportedVersions = []string{"0.1.0", "0.2.0", "0.3.0", "0.3.1", "0.4.0", "1.0.0", "1.1.0"}
 
 // CNI contract.
 type PluginApi interface {
@@ -40,4 +56,6 @@ type PluginApi interface {
 	Get(args *cniSkel.CmdArgs) error
 	Delete(args *cniSkel.CmdArgs) error
 	Update(args *cniSkel.CmdArgs) error
+	GC(args *cniSkel.CmdArgs) error
+	Status(args *cniSkel.CmdArgs) error
 }
@@ -320,3 +320,13 @@ func (plugin *ipamPlugin) Delete(args *cniSkel.CmdArgs) error {
 func (plugin *ipamPlugin) Update(args *cniSkel.CmdArgs) error {
 	return nil
 }
+
+// GC handles CNI GC commands.
+func (plugin *ipamPlugin) GC(args *cniSkel.CmdArgs) error {
+	return nil
+}
+
+// Status handles CNI STATUS commands.
+func (plugin *ipamPlugin) Status(args *cniSkel.CmdArgs) error {
+	return nil
+}
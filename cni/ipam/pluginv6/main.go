@@ -28,6 +28,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if config.StoreBackend, err = cni.ReadStoreBackend(); err != nil {
+		fmt.Printf("Failed to read the store backend of ipam plugin, err:%v.\n", err)
+		os.Exit(1)
+	}
+
 	if err := ipamPlugin.Plugin.InitializeKeyValueStore(&config); err != nil {
 		fmt.Printf("Failed to initialize key-value store of ipam plugin, err:%v.\n", err)
 		os.Exit(1)
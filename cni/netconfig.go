@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-container-networking/network/policy"
+	"github.com/Azure/azure-container-networking/tracing"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 )
 
@@ -72,14 +73,20 @@ type NetworkConfig struct {
 	EnableExactMatchForPodName    bool            `json:"enableExactMatchForPodName,omitempty"`
 	DisableHairpinOnHostInterface bool            `json:"disableHairpinOnHostInterface,omitempty"`
 	DisableIPTableLock            bool            `json:"disableIPTableLock,omitempty"`
+	SNATBackend                   string          `json:"snatBackend,omitempty"`
 	DisableAsyncDelete            bool            `json:"disableAsyncDelete,omitempty"`
 	CNSUrl                        string          `json:"cnsurl,omitempty"`
+	CNSGrpcAddress                string          `json:"cnsGrpcAddress,omitempty"`
 	ExecutionMode                 string          `json:"executionMode,omitempty"`
+	StoreBackend                  string          `json:"storeBackend,omitempty"`
+	Tracing                       *tracing.Config `json:"tracing,omitempty"`
 	IPAM                          IPAM            `json:"ipam,omitempty"`
 	DNS                           cniTypes.DNS    `json:"dns,omitempty"`
 	RuntimeConfig                 RuntimeConfig   `json:"runtimeConfig,omitempty"`
 	WindowsSettings               WindowsSettings `json:"windowsSettings,omitempty"`
 	AdditionalArgs                []KVPair        `json:"AdditionalArgs,omitempty"`
+	// ValidAttachments is only supplied by the runtime when executing a GC operation.
+	ValidAttachments []cniTypes.GCAttachment `json:"cni.dev/valid-attachments,omitempty"`
 }
 
 type WindowsSettings struct {
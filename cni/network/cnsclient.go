@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
 )
 
 type cnsclient interface {
@@ -11,6 +12,8 @@ type cnsclient interface {
 	ReleaseIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) error
 	RequestIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error)
 	ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) error
+	GetIPConfigs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error)
 	GetNetworkContainer(ctx context.Context, orchestratorContext []byte) (*cns.GetNetworkContainerResponse, error)
 	GetAllNetworkContainers(ctx context.Context, orchestratorContext []byte) ([]cns.GetNetworkContainerResponse, error)
+	GetIPAddressesMatchingStates(ctx context.Context, stateFilter ...types.IPState) ([]cns.IPConfigurationStatus, error)
 }
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -50,7 +51,7 @@ func NewAzureIpamInvoker(plugin *NetPlugin, nwInfo *network.EndpointInfo) *Azure
 	}
 }
 
-func (invoker *AzureIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, error) {
+func (invoker *AzureIPAMInvoker) Add(ctx context.Context, addConfig IPAMAddConfig) (IPAMAddResult, error) {
 	addResult := IPAMAddResult{interfaceInfo: make(map[string]network.InterfaceInfo)}
 
 	if addConfig.nwCfg == nil {
@@ -77,7 +78,7 @@ func (invoker *AzureIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, er
 	defer func() {
 		if err != nil {
 			if len(addResult.interfaceInfo) > 0 && len(addResult.interfaceInfo[invoker.getInterfaceInfoKey(cns.InfraNIC)].IPConfigs) > 0 {
-				if er := invoker.Delete(&addResult.interfaceInfo[invoker.getInterfaceInfoKey(cns.InfraNIC)].IPConfigs[0].Address, addConfig.nwCfg, nil, addConfig.options); er != nil {
+				if er := invoker.Delete(ctx, &addResult.interfaceInfo[invoker.getInterfaceInfoKey(cns.InfraNIC)].IPConfigs[0].Address, addConfig.nwCfg, nil, addConfig.options); er != nil {
 					err = invoker.plugin.Errorf("Failed to clean up IP's during Delete with error %v, after Add failed with error %w", er, err)
 				}
 			} else {
@@ -136,34 +137,39 @@ func (invoker *AzureIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, er
 	return addResult, err
 }
 
+// deleteIpamState deletes the IPAM state when there is no CNI state, in either store backend.
 func (invoker *AzureIPAMInvoker) deleteIpamState() {
-	cniStateExists, err := platform.CheckIfFileExists(platform.CNIStateFilePath)
-	if err != nil {
-		logger.Error("Error checking CNI state exist", zap.Error(err))
-		return
-	}
-
-	if cniStateExists {
-		return
-	}
+	for _, cniStatePath := range []string{platform.CNIStateFilePath, platform.CNIBoltStateFilePath} {
+		cniStateExists, err := platform.CheckIfFileExists(cniStatePath)
+		if err != nil {
+			logger.Error("Error checking CNI state exist", zap.Error(err))
+			return
+		}
 
-	ipamStateExists, err := platform.CheckIfFileExists(platform.CNIIpamStatePath)
-	if err != nil {
-		logger.Error("Error checking IPAM state exist", zap.Error(err))
-		return
+		if cniStateExists {
+			return
+		}
 	}
 
-	if ipamStateExists {
-		logger.Info("Deleting IPAM state file")
-		err = os.Remove(platform.CNIIpamStatePath)
+	for _, ipamStatePath := range []string{platform.CNIIpamStatePath, platform.CNIIpamBoltStatePath} {
+		ipamStateExists, err := platform.CheckIfFileExists(ipamStatePath)
 		if err != nil {
-			logger.Error("Error deleting state file", zap.Error(err))
+			logger.Error("Error checking IPAM state exist", zap.Error(err))
 			return
 		}
+
+		if ipamStateExists {
+			logger.Info("Deleting IPAM state file", zap.String("path", ipamStatePath))
+			err = os.Remove(ipamStatePath)
+			if err != nil {
+				logger.Error("Error deleting state file", zap.Error(err))
+				return
+			}
+		}
 	}
 }
 
-func (invoker *AzureIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConfig, _ *cniSkel.CmdArgs, options map[string]interface{}) error { //nolint
+func (invoker *AzureIPAMInvoker) Delete(_ context.Context, address *net.IPNet, nwCfg *cni.NetworkConfig, _ *cniSkel.CmdArgs, options map[string]interface{}) error { //nolint
 	if nwCfg == nil {
 		return invoker.plugin.Errorf("nil nwCfg passed to CNI DEL: %v", errNilNetworkConfig)
 	}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -244,7 +245,7 @@ func TestAzureIPAMInvoker_Add(t *testing.T) {
 				nwInfo: tt.fields.nwInfo,
 			}
 
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.in1, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.in1, options: tt.args.options})
 			if tt.wantErr {
 				require.NotNil(err) // use NotNil since *cniTypes.Error is not of type Error
 			} else {
@@ -385,7 +386,7 @@ func TestAzureIPAMInvoker_Delete(t *testing.T) {
 				plugin: tt.fields.plugin,
 				nwInfo: tt.fields.nwInfo,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.in2, tt.args.options)
+			err := invoker.Delete(context.Background(), tt.args.address, tt.args.nwCfg, tt.args.in2, tt.args.options)
 			if tt.wantErr {
 				require.NotNil(err)
 				return
@@ -447,7 +448,7 @@ func TestRemoveIpamState_Add(t *testing.T) {
 				nwInfo: tt.fields.nwInfo,
 			}
 
-			_, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.in1, options: tt.args.options})
+			_, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.in1, options: tt.args.options})
 			if tt.wantErr {
 				requires.NotNil(err) // use NotNil since *cniTypes.Error is not of type Error
 				requires.ErrorContains(err, tt.wantErrMsg)
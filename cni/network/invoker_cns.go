@@ -135,7 +135,7 @@ func NewCNSInvoker(podName, namespace string, cnsClient cnsclient, executionMode
 }
 
 // Add uses the requestipconfig API in cns, and returns ipv4 and a nil ipv6 as CNS doesn't support IPv6 yet
-func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, error) {
+func (invoker *CNSIPAMInvoker) Add(ctx context.Context, addConfig IPAMAddConfig) (IPAMAddResult, error) {
 	// Parse Pod arguments.
 	podInfo := cns.KubernetesPodInfo{
 		PodName:      invoker.podName,
@@ -161,7 +161,7 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 	logger.Info("Requesting IP for pod using ipconfig",
 		zap.Any("pod", podInfo),
 		zap.Any("ipconfig", ipconfigs))
-	response, err := invoker.cnsClient.RequestIPs(context.TODO(), ipconfigs)
+	response, err := invoker.cnsClient.RequestIPs(ctx, ipconfigs)
 	if err != nil {
 		if cnscli.IsUnsupportedAPI(err) {
 			// If RequestIPs is not supported by CNS, use RequestIPAddress API
@@ -173,7 +173,7 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 				InfraContainerID:    addConfig.args.ContainerID,
 			}
 
-			res, errRequestIP := invoker.cnsClient.RequestIPAddress(context.TODO(), ipconfig)
+			res, errRequestIP := invoker.cnsClient.RequestIPAddress(ctx, ipconfig)
 			if errRequestIP != nil {
 				// if the old API fails as well then we just return the error
 				logger.Error("Failed to request IP address from CNS using RequestIPAddress",
@@ -310,35 +310,21 @@ func setHostOptions(ncSubnetPrefix *net.IPNet, options map[string]interface{}, i
 	// we need to snat IMDS traffic to node IP, this sets up snat '--to'
 	snatHostIPJump := fmt.Sprintf("%s --to %s", iptables.Snat, info.hostPrimaryIP)
 
-	iptablesClient := iptables.NewClient()
-	var iptableCmds []iptables.IPTableEntry
-	if !iptablesClient.ChainExists(iptables.V4, iptables.Nat, iptables.Swift) {
-		iptableCmds = append(iptableCmds, iptablesClient.GetCreateChainCmd(iptables.V4, iptables.Nat, iptables.Swift))
-	}
-
-	if !iptablesClient.RuleExists(iptables.V4, iptables.Nat, iptables.Postrouting, "", iptables.Swift) {
-		iptableCmds = append(iptableCmds, iptablesClient.GetAppendIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Postrouting, "", iptables.Swift))
-	}
-
-	if !iptablesClient.RuleExists(iptables.V4, iptables.Nat, iptables.Swift, azureDNSUDPMatch, snatPrimaryIPJump) {
-		iptableCmds = append(iptableCmds, iptablesClient.GetInsertIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Swift, azureDNSUDPMatch, snatPrimaryIPJump))
-	}
-
-	if !iptablesClient.RuleExists(iptables.V4, iptables.Nat, iptables.Swift, azureDNSTCPMatch, snatPrimaryIPJump) {
-		iptableCmds = append(iptableCmds, iptablesClient.GetInsertIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Swift, azureDNSTCPMatch, snatPrimaryIPJump))
-	}
-
-	if !iptablesClient.RuleExists(iptables.V4, iptables.Nat, iptables.Swift, azureIMDSMatch, snatHostIPJump) {
-		iptableCmds = append(iptableCmds, iptablesClient.GetInsertIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Swift, azureIMDSMatch, snatHostIPJump))
-	}
+	// the rules are applied idempotently by the network manager in a single transaction
+	tx := iptables.NewTransaction().
+		CreateChain(iptables.V4, iptables.Nat, iptables.Swift).
+		AppendRule(iptables.V4, iptables.Nat, iptables.Postrouting, "", iptables.Swift).
+		InsertRule(iptables.V4, iptables.Nat, iptables.Swift, azureDNSUDPMatch, snatPrimaryIPJump).
+		InsertRule(iptables.V4, iptables.Nat, iptables.Swift, azureDNSTCPMatch, snatPrimaryIPJump).
+		InsertRule(iptables.V4, iptables.Nat, iptables.Swift, azureIMDSMatch, snatHostIPJump)
 
-	options[network.IPTablesKey] = iptableCmds
+	options[network.IPTablesKey] = tx.Operations()
 
 	return nil
 }
 
 // Delete calls into the releaseipconfiguration API in CNS
-func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, _ map[string]interface{}) error { //nolint
+func (invoker *CNSIPAMInvoker) Delete(ctx context.Context, address *net.IPNet, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, _ map[string]interface{}) error { //nolint
 	var connectionErr *cnscli.ConnectionFailureErr
 	// Parse Pod arguments.
 	podInfo := cns.KubernetesPodInfo{
@@ -367,7 +353,7 @@ func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConf
 		logger.Info("CNS invoker called with empty IP address")
 	}
 
-	if err := invoker.cnsClient.ReleaseIPs(context.TODO(), ipConfigs); err != nil {
+	if err := invoker.cnsClient.ReleaseIPs(ctx, ipConfigs); err != nil {
 		if cnscli.IsUnsupportedAPI(err) {
 			// If ReleaseIPs is not supported by CNS, use ReleaseIPAddress API
 			logger.Error("ReleaseIPs not supported by CNS. Invoking ReleaseIPAddress API",
@@ -379,7 +365,7 @@ func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConf
 				InfraContainerID:    args.ContainerID,
 			}
 
-			if err = invoker.cnsClient.ReleaseIPAddress(context.TODO(), ipConfig); err != nil {
+			if err = invoker.cnsClient.ReleaseIPAddress(ctx, ipConfig); err != nil {
 				if errors.As(err, &connectionErr) {
 					addErr := fsnotify.AddFile(ipConfigs.PodInterfaceID, args.ContainerID, watcherPath)
 					if addErr != nil {
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -488,7 +489,7 @@ func TestCNSIPAMInvoker_Add_Overlay(t *testing.T) {
 			if tt.fields.ipamMode != "" {
 				invoker.ipamMode = tt.fields.ipamMode
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if tt.wantErr {
 				require.Error(err)
 			} else {
@@ -839,7 +840,7 @@ func TestCNSIPAMInvoker_Add(t *testing.T) {
 			if tt.fields.ipamMode != "" {
 				invoker.ipamMode = tt.fields.ipamMode
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if tt.wantErr {
 				require.Equalf([]policy.Policy(nil), ipamAddResult.interfaceInfo[string(cns.InfraNIC)].EndpointPolicies, "There was an error requesting IP addresses from cns")
 				require.Error(err)
@@ -1017,7 +1018,7 @@ func TestCNSIPAMInvoker_Add_podsubnetv6(t *testing.T) {
 				},
 			}
 
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{
 				nwCfg: &cni.NetworkConfig{},
 				args: &cniSkel.CmdArgs{
 					ContainerID: "testcontainerid",
@@ -1154,7 +1155,7 @@ func TestCNSIPAMInvoker_Add_UnsupportedAPI(t *testing.T) {
 			if tt.fields.ipamMode != "" {
 				invoker.ipamMode = tt.fields.ipamMode
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if err != nil && tt.wantErr {
 				t.Fatalf("expected an error %+v but none received", err)
 			}
@@ -1274,7 +1275,7 @@ func TestRequestIPAPIsFail(t *testing.T) {
 			if tt.fields.ipamMode != "" {
 				invoker.ipamMode = tt.fields.ipamMode
 			}
-			_, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			_, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if err == nil && tt.wantErr {
 				t.Fatalf("expected an error %+v but none received", err)
 			}
@@ -1349,7 +1350,7 @@ func TestCNSIPAMInvoker_Delete(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
+			err := invoker.Delete(context.Background(), tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
 			if tt.wantErr {
 				require.Error(err)
 			} else {
@@ -1440,7 +1441,7 @@ func TestCNSIPAMInvoker_Delete_Overlay(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
+			err := invoker.Delete(context.Background(), tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
 			if tt.wantErr {
 				require.Error(err)
 			} else {
@@ -1505,7 +1506,7 @@ func TestCNSIPAMInvoker_Delete_NotSupportedAPI(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
+			err := invoker.Delete(context.Background(), tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
 			if tt.wantErr {
 				require.Error(err)
 			} else {
@@ -1565,7 +1566,7 @@ func TestReleaseIPAPIsFail(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
+			err := invoker.Delete(context.Background(), tt.args.address, tt.args.nwCfg, tt.args.args, tt.args.options)
 			if !errors.Is(err, errNoReleaseIPFound) {
 				t.Fatalf("expected an error %s but %v received", errNoReleaseIPFound, err)
 			}
@@ -1604,26 +1605,46 @@ func Test_setHostOptions(t *testing.T) {
 				},
 			},
 			wantOptions: map[string]interface{}{
-				network.IPTablesKey: []iptables.IPTableEntry{
+				network.IPTablesKey: []iptables.Operation{
 					{
+						Action:  iptables.NewChain,
 						Version: "4",
-						Params:  "-t nat -N SWIFT",
+						Table:   "nat",
+						Chain:   "SWIFT",
 					},
 					{
+						Action:  iptables.Append,
 						Version: "4",
-						Params:  "-t nat -A POSTROUTING  -j SWIFT",
+						Table:   "nat",
+						Chain:   "POSTROUTING",
+						Target:  "SWIFT",
 					},
 					{
-						Version: "4",
-						Params:  "-t nat -I SWIFT 1  -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p udp --dport 53 -j SNAT --to 10.0.1.20",
+						Action:   iptables.Insert,
+						Version:  "4",
+						Table:    "nat",
+						Chain:    "SWIFT",
+						Position: 1,
+						Match:    " -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p udp --dport 53",
+						Target:   "SNAT --to 10.0.1.20",
 					},
 					{
-						Version: "4",
-						Params:  "-t nat -I SWIFT 1  -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p tcp --dport 53 -j SNAT --to 10.0.1.20",
+						Action:   iptables.Insert,
+						Version:  "4",
+						Table:    "nat",
+						Chain:    "SWIFT",
+						Position: 1,
+						Match:    " -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p tcp --dport 53",
+						Target:   "SNAT --to 10.0.1.20",
 					},
 					{
-						Version: "4",
-						Params:  "-t nat -I SWIFT 1  -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 169.254.169.254 -p tcp --dport 80 -j SNAT --to 10.0.0.3",
+						Action:   iptables.Insert,
+						Version:  "4",
+						Table:    "nat",
+						Chain:    "SWIFT",
+						Position: 1,
+						Match:    " -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 169.254.169.254 -p tcp --dport 80",
+						Target:   "SNAT --to 10.0.0.3",
 					},
 				},
 				network.RoutesKey: []network.RouteInfo{
@@ -2044,7 +2065,7 @@ func TestCNSIPAMInvoker_Add_SwiftV2(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if tt.wantErr {
 				require.Error(err)
 			} else {
@@ -2345,7 +2366,7 @@ func TestAddNICsToCNIResult(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if err != nil {
 				t.Fatalf("Failed to create ipamAddResult due to error: %v", err)
 			}
@@ -2530,7 +2551,7 @@ func TestMultipleIBNICsToResult(t *testing.T) {
 				podNamespace: tt.fields.podNamespace,
 				cnsClient:    tt.fields.cnsClient,
 			}
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{nwCfg: tt.args.nwCfg, args: tt.args.args, options: tt.args.options})
 			if err != nil {
 				t.Fatalf("Failed to create ipamAddResult due to error: %v", err)
 			}
@@ -2811,7 +2832,7 @@ func TestCNSIPAMInvoker_Add_ApipaNIC(t *testing.T) {
 				invoker.ipamMode = tt.fields.ipamMode
 			}
 
-			ipamAddResult, err := invoker.Add(IPAMAddConfig{
+			ipamAddResult, err := invoker.Add(context.Background(), IPAMAddConfig{
 				nwCfg:   tt.args.nwCfg,
 				args:    tt.args.args,
 				options: tt.args.options,
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"net"
 
@@ -56,7 +57,7 @@ func NewCustomMockIpamInvoker(customReturn map[string]network.InterfaceInfo) *Mo
 	}
 }
 
-func (invoker *MockIpamInvoker) Add(opt IPAMAddConfig) (ipamAddResult IPAMAddResult, err error) {
+func (invoker *MockIpamInvoker) Add(_ context.Context, opt IPAMAddConfig) (ipamAddResult IPAMAddResult, err error) {
 	if invoker.v4Fail {
 		return ipamAddResult, errV4
 	}
@@ -122,7 +123,7 @@ func (invoker *MockIpamInvoker) Add(opt IPAMAddConfig) (ipamAddResult IPAMAddRes
 	return ipamAddResult, nil
 }
 
-func (invoker *MockIpamInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConfig, _ *skel.CmdArgs, options map[string]interface{}) error {
+func (invoker *MockIpamInvoker) Delete(_ context.Context, address *net.IPNet, nwCfg *cni.NetworkConfig, _ *skel.CmdArgs, options map[string]interface{}) error {
 	if invoker.v4Fail || invoker.v6Fail {
 		return errDeleteIpam
 	}
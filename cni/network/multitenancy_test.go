@@ -50,7 +50,8 @@ type releaseIPsHandler struct {
 	ipconfigArgument cns.IPConfigsRequest // this will return the IPConfigsResponse which contains a slice of IPs as opposed to one IP
 
 	// results
-	err error
+	err   error
+	calls int // number of matching requests
 }
 
 type getNetworkContainerConfigurationHandler struct {
@@ -66,12 +67,27 @@ type getAllNetworkContainersConfigurationHandler struct {
 	err                 error
 }
 
+type getIPConfigsHandler struct {
+	// arguments
+	ipconfigArgument cns.IPConfigsRequest
+
+	// results
+	result *cns.IPConfigsResponse
+	err    error
+}
+
+type getIPAddressesMatchingStatesHandler struct {
+	result []cns.IPConfigurationStatus
+	err    error
+}
+
 type cnsAPIName string
 
 const (
 	GetAllNetworkContainers cnsAPIName = "GetAllNetworkContainers"
 	RequestIPs              cnsAPIName = "RequestIPs"
 	ReleaseIPs              cnsAPIName = "ReleaseIPs"
+	GetIPConfigs            cnsAPIName = "GetIPConfigs"
 )
 
 var (
@@ -90,6 +106,8 @@ type MockCNSClient struct {
 	releaseIPs                           releaseIPsHandler
 	getNetworkContainerConfiguration     getNetworkContainerConfigurationHandler
 	getAllNetworkContainersConfiguration getAllNetworkContainersConfigurationHandler
+	getIPConfigs                         getIPConfigsHandler
+	getIPAddressesMatchingStates         getIPAddressesMatchingStatesHandler
 }
 
 func (c *MockCNSClient) RequestIPAddress(_ context.Context, ipconfig cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
@@ -131,6 +149,7 @@ func (c *MockCNSClient) ReleaseIPs(_ context.Context, ipconfig cns.IPConfigsRequ
 	if !cmp.Equal(c.releaseIPs.ipconfigArgument, ipconfig) {
 		return errNoReleaseIPFound
 	}
+	c.releaseIPs.calls++
 	return c.releaseIPs.err
 }
 
@@ -155,6 +174,24 @@ func (c *MockCNSClient) GetAllNetworkContainers(ctx context.Context, orchestrato
 	return c.getAllNetworkContainersConfiguration.returnResponse, c.getAllNetworkContainersConfiguration.err
 }
 
+func (c *MockCNSClient) GetIPConfigs(_ context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	if _, isUnsupported := c.unsupportedAPIs[GetIPConfigs]; isUnsupported {
+		e := &client.CNSClientError{}
+		e.Code = types.UnsupportedAPI
+		e.Err = errUnsupportedAPI
+		return nil, e
+	}
+
+	if !cmp.Equal(c.getIPConfigs.ipconfigArgument, ipconfig) {
+		return nil, errNoRequestIPFound
+	}
+	return c.getIPConfigs.result, c.getIPConfigs.err
+}
+
+func (c *MockCNSClient) GetIPAddressesMatchingStates(_ context.Context, _ ...types.IPState) ([]cns.IPConfigurationStatus, error) {
+	return c.getIPAddressesMatchingStates.result, c.getIPAddressesMatchingStates.err
+}
+
 func defaultIPNet() *net.IPNet {
 	_, defaultIPNet, _ := net.ParseCIDR("0.0.0.0/0")
 	return defaultIPNet
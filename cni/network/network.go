@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/Azure/azure-container-networking/cni/util"
 	"github.com/Azure/azure-container-networking/cns"
 	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/dhcp"
 	"github.com/Azure/azure-container-networking/iptables"
@@ -29,10 +31,14 @@ import (
 	nnscontracts "github.com/Azure/azure-container-networking/proto/nodenetworkservice/3.302.0.744"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -40,6 +46,7 @@ import (
 var (
 	allowedInput    = regexp.MustCompile(`^[a-zA-Z0-9._\-\(\) ]*$`)
 	telemetryClient = telemetry.AIClient
+	tracer          = otel.Tracer("github.com/Azure/azure-container-networking/cni/network")
 )
 
 const (
@@ -52,6 +59,8 @@ const (
 	ipv6FullMask          = 128
 	ibInterfacePrefix     = "ib"
 	apipaInterfacePrefix  = "apipa"
+	// the plugin is on the pod startup path, so a slow receiver loses the trace rather than delay the command
+	traceFlushTimeout = 500 * time.Millisecond
 )
 
 // CNI Operation Types
@@ -84,6 +93,7 @@ type NetPlugin struct {
 	nnsClient          NnsClient
 	multitenancyClient MultitenancyClient
 	netClient          InterfaceGetter
+	cnsClient          cnsclient
 }
 
 type PolicyArgs struct {
@@ -201,6 +211,62 @@ func (plugin *NetPlugin) GetAllEndpointState(networkid string) (*api.AzureCNISta
 	return &st, nil
 }
 
+// ReconcileEndpoints re-applies the missing parts of the datapath of the endpoint with the ID, or of all endpoints
+// in the network if the ID is empty. An endpoint which no longer exists e.g. because it was deleted since CNS
+// listed it is skipped. An endpoint which can't be repaired e.g. because its container interface is gone is
+// reported, and the remaining endpoints are still reconciled.
+func (plugin *NetPlugin) ReconcileEndpoints(networkid, endpointID string) (*api.EndpointReconcileResult, error) {
+	result := api.EndpointReconcileResult{}
+
+	eps, err := plugin.nm.GetAllEndpoints(networkid)
+	if err == store.ErrStoreEmpty {
+		logger.Error("failed to retrieve endpoint state", zap.Error(err))
+	} else if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(eps))
+	for id := range eps {
+		if endpointID == "" || id == endpointID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		ep := eps[id]
+		result.Endpoints++
+
+		repairs, err := plugin.nm.ReconcileEndpoint(networkid, ep)
+		for _, repair := range repairs {
+			logger.Info("Repaired endpoint datapath",
+				zap.String("endpointID", ep.EndpointID),
+				zap.String("kind", repair.Kind),
+				zap.String("detail", repair.Detail))
+			result.Repairs = append(result.Repairs, api.EndpointRepair{
+				PodName:       ep.PODName,
+				PodNamespace:  ep.PODNameSpace,
+				ContainerID:   ep.ContainerID,
+				PodEndpointId: ep.EndpointID,
+				Kind:          repair.Kind,
+				Detail:        repair.Detail,
+			})
+		}
+		if err != nil {
+			logger.Error("Failed to reconcile endpoint datapath", zap.String("endpointID", ep.EndpointID), zap.Error(err))
+			result.Errors = append(result.Errors, api.EndpointReconcileError{
+				PodName:       ep.PODName,
+				PodNamespace:  ep.PODNameSpace,
+				ContainerID:   ep.ContainerID,
+				PodEndpointId: ep.EndpointID,
+				Error:         err.Error(),
+			})
+		}
+	}
+
+	return &result, nil
+}
+
 // Stops the plugin.
 func (plugin *NetPlugin) Stop() {
 	plugin.nm.Uninitialize()
@@ -301,6 +367,31 @@ func (plugin *NetPlugin) setCNIReportDetails(containerID, opType, msg string) {
 	telemetryClient.Settings().Version = plugin.Version
 }
 
+// startTrace starts the span of a CNI command, and exports the trace to the receiver of the tracing section of the
+// network config if it is set. The returned func ends the span with the error of the command and exports the trace
+// before the plugin exits.
+func (plugin *NetPlugin) startTrace(nwCfg *cni.NetworkConfig, name string, args *cniSkel.CmdArgs) (context.Context, func(error)) {
+	shutdown := func(context.Context) error { return nil }
+	if nwCfg.Tracing != nil {
+		tracingShutdown, err := tracing.Init(context.Background(), *nwCfg.Tracing, plugin.Name, plugin.Version)
+		if err != nil {
+			logger.Error("Failed to initialize tracing", zap.Error(err))
+		} else {
+			shutdown = tracingShutdown
+		}
+	}
+
+	ctx, span := tracer.Start(context.Background(), name, trace.WithAttributes(semconv.ContainerID(args.ContainerID)))
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+		defer cancel()
+		if shutdownErr := shutdown(shutdownCtx); shutdownErr != nil {
+			logger.Error("Failed to export traces", zap.Error(shutdownErr))
+		}
+	}
+}
+
 func addNatIPV6SubnetInfo(nwCfg *cni.NetworkConfig,
 	resultV6 *cniTypesCurr.Result,
 	nwInfo *network.NetworkInfo,
@@ -319,8 +410,11 @@ func addNatIPV6SubnetInfo(nwCfg *cni.NetworkConfig,
 	}
 }
 
-func (plugin *NetPlugin) addIpamInvoker(ipamAddConfig IPAMAddConfig) (IPAMAddResult, error) {
-	ipamAddResult, err := plugin.ipamInvoker.Add(ipamAddConfig)
+func (plugin *NetPlugin) addIpamInvoker(ctx context.Context, ipamAddConfig IPAMAddConfig) (_ IPAMAddResult, err error) {
+	ctx, span := tracer.Start(ctx, "cni.IPAM")
+	defer func() { tracing.End(span, err) }()
+
+	ipamAddResult, err := plugin.ipamInvoker.Add(ctx, ipamAddConfig)
 	if err != nil {
 		return IPAMAddResult{}, errors.Wrap(err, "failed to add ipam invoker")
 	}
@@ -374,6 +468,9 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	ctx, endTrace := plugin.startTrace(nwCfg, "cni.Add", args)
+	defer func() { endTrace(err) }()
+
 	if argErr := plugin.validateArgs(args, nwCfg); argErr != nil {
 		err = argErr
 		return err
@@ -385,6 +482,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return err
 	}
 	telemetryClient.Settings().ContainerName = k8sPodName + ":" + k8sNamespace
+	trace.SpanFromContext(ctx).SetAttributes(semconv.K8SPodName(k8sPodName), semconv.K8SNamespaceName(k8sNamespace))
 
 	plugin.setCNIReportDetails(args.ContainerID, CNI_ADD, "")
 	telemetryClient.SendEvent(fmt.Sprintf("[cni-net] Processing ADD command with args {ContainerID:%v Netns:%v IfName:%v Args:%v Path:%v StdinData:%s}.",
@@ -464,7 +562,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 	if nwCfg.ExecutionMode == string(util.Baremetal) {
 		var res *nnscontracts.ConfigureContainerNetworkingResponse
 		logger.Info("Baremetal mode. Calling vnet agent for ADD")
-		res, err = plugin.nnsClient.AddContainerNetworking(context.Background(), k8sPodName, args.Netns)
+		res, err = plugin.nnsClient.AddContainerNetworking(ctx, k8sPodName, args.Netns)
 
 		if err == nil {
 			ipamAddResult.interfaceInfo[string(cns.InfraNIC)] = network.InterfaceInfo{
@@ -485,7 +583,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		}
 	}
 
-	cnsClient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+	cnsClient, err := newCNSClient(nwCfg.CNSUrl, nwCfg.CNSGrpcAddress)
 	if err != nil {
 		return fmt.Errorf("failed to create cns client with error: %w", err)
 	}
@@ -506,7 +604,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			return fmt.Errorf("%w", err)
 		}
 
-		ipamAddResult, err = plugin.multitenancyClient.GetAllNetworkContainers(context.TODO(), nwCfg, k8sPodName, k8sNamespace, args.IfName)
+		ipamAddResult, err = plugin.multitenancyClient.GetAllNetworkContainers(ctx, nwCfg, k8sPodName, k8sNamespace, args.IfName)
 		if err != nil {
 			err = fmt.Errorf("GetAllNetworkContainers failed for podname %s namespace %s. error: %w", k8sPodName, k8sNamespace, err)
 			logger.Error("GetAllNetworkContainers failed",
@@ -535,7 +633,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			}
 		}
 
-		ipamAddResult, err = plugin.addIpamInvoker(ipamAddConfig)
+		ipamAddResult, err = plugin.addIpamInvoker(ctx, ipamAddConfig)
 		if err != nil {
 			return fmt.Errorf("IPAM Invoker Add failed with error: %w", err)
 		}
@@ -550,7 +648,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 					// This used to only be called for infraNIC, test if this breaks scenarios
 					// If it does then will have to search for infraNIC
 					if ifInfo.NICType == cns.InfraNIC {
-						plugin.cleanupAllocationOnError(ifInfo.IPConfigs, nwCfg, args, options)
+						plugin.cleanupAllocationOnError(ctx, ifInfo.IPConfigs, nwCfg, args, options)
 					}
 				}
 			}
@@ -625,7 +723,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		}
 	}()
 
-	err = plugin.nm.EndpointCreate(cnsclient, epInfos)
+	err = plugin.nm.EndpointCreate(ctx, cnsclient, epInfos)
 	if err != nil {
 		return errors.Wrap(err, "failed to create endpoint") // behavior can change if you don't assign to err prior to returning
 	}
@@ -745,6 +843,7 @@ func (plugin *NetPlugin) createEpInfo(opt *createEpInfoOpt) (*network.EndpointIn
 		EnableMultiTenancy: opt.nwCfg.MultiTenancy,
 		EnableInfraVnet:    opt.enableInfraVnet,
 		EnableSnatForDns:   opt.enableSnatForDNS,
+		SNATBackend:        opt.nwCfg.SNATBackend,
 		PODName:            opt.k8sPodName,
 		PODNameSpace:       opt.k8sNamespace,
 		SkipHotAttachEp:    false, // Hot attach at the time of endpoint creation
@@ -822,6 +921,7 @@ func (plugin *NetPlugin) createEpInfo(opt *createEpInfoOpt) (*network.EndpointIn
 
 // cleanup allocated ipv4 and ipv6 addresses if they exist
 func (plugin *NetPlugin) cleanupAllocationOnError(
+	ctx context.Context,
 	result []*network.IPConfig,
 	nwCfg *cni.NetworkConfig,
 	args *cniSkel.CmdArgs,
@@ -829,7 +929,7 @@ func (plugin *NetPlugin) cleanupAllocationOnError(
 ) {
 	if result != nil {
 		for i := 0; i < len(result); i++ {
-			if er := plugin.ipamInvoker.Delete(&result[i].Address, nwCfg, args, options); er != nil {
+			if er := plugin.ipamInvoker.Delete(ctx, &result[i].Address, nwCfg, args, options); er != nil {
 				logger.Error("Failed to cleanup ip allocation on failure", zap.Error(er))
 			}
 		}
@@ -936,6 +1036,23 @@ func (plugin *NetPlugin) Get(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	// Validate that the datapath still matches the endpoint state.
+	if err = plugin.nm.ValidateEndpoint(networkID, epInfo); err != nil {
+		logger.Error("Failed to validate endpoint", zap.String("endpointID", endpointID), zap.Error(err))
+		if errors.Is(err, network.ErrDatapathInvalid) {
+			err = cniTypes.NewError(cni.ErrDatapathInvalid, err.Error(), "")
+		} else {
+			err = plugin.Errorf("Failed to validate endpoint: %v", err)
+		}
+		return err
+	}
+
+	if nwCfg.IPAM.Type == network.AzureCNS && !nwCfg.MultiTenancy {
+		if err = plugin.validateCNSIPs(nwCfg, args, epInfo); err != nil {
+			return err
+		}
+	}
+
 	for _, ipAddresses := range epInfo.IPAddresses {
 		ipConfig := &cniTypesCurr.IPConfig{
 			Interface: &epInfo.IfIndex,
@@ -959,6 +1076,60 @@ func (plugin *NetPlugin) Get(args *cniSkel.CmdArgs) error {
 	return nil
 }
 
+// validateCNSIPs checks that CNS still has the IPs of the endpoint assigned to the container.
+// Only the IPs of the container are queried, in the same way they are released on DEL.
+func (plugin *NetPlugin) validateCNSIPs(nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, epInfo *network.EndpointInfo) error {
+	k8sPodName, k8sNamespace, err := plugin.getPodInfo(args.Args)
+	if err != nil {
+		return err
+	}
+
+	orchestratorContext, err := json.Marshal(cns.KubernetesPodInfo{
+		PodName:      k8sPodName,
+		PodNamespace: k8sNamespace,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal orchestrator context")
+	}
+
+	cnsClient, err := plugin.getCNSClient(nwCfg)
+	if err != nil {
+		logger.Error("failed to create cns client", zap.Error(err))
+		return errors.Wrap(err, "failed to create cns client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	res, err := cnsClient.GetIPConfigs(ctx, cns.IPConfigsRequest{
+		OrchestratorContext: orchestratorContext,
+		PodInterfaceID:      GetEndpointID(args),
+		InfraContainerID:    args.ContainerID,
+	})
+	if err != nil {
+		if cnscli.IsUnsupportedAPI(err) {
+			logger.Info("CNS doesn't support GetIPConfigs, skipping the validation of the IPs of the container")
+			return nil
+		}
+		logger.Error("Failed to get the IPs of the container from CNS", zap.Error(err))
+		return plugin.RetriableError(fmt.Errorf("failed to get the IPs of the container from CNS: %w", err))
+	}
+
+	assigned := make(map[string]struct{}, len(res.PodIPInfo))
+	for i := range res.PodIPInfo {
+		assigned[res.PodIPInfo[i].PodIPConfig.IPAddress] = struct{}{}
+	}
+
+	for _, ipAddr := range epInfo.IPAddresses {
+		if _, ok := assigned[ipAddr.IP.String()]; !ok {
+			return cniTypes.NewError(cni.ErrDatapathInvalid,
+				fmt.Sprintf("IP %s is not assigned to container %s in CNS", ipAddr.IP, args.ContainerID), "")
+		}
+	}
+
+	return nil
+}
+
 // Delete handles CNI delete commands.
 func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 	var (
@@ -993,6 +1164,9 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	ctx, endTrace := plugin.startTrace(nwCfg, "cni.Delete", args)
+	defer func() { endTrace(err) }()
+
 	if argErr := plugin.validateArgs(args, nwCfg); argErr != nil {
 		err = argErr
 		return err
@@ -1003,6 +1177,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		logger.Error("Failed to get POD info", zap.Error(err))
 	}
 	telemetryClient.Settings().ContainerName = k8sPodName + ":" + k8sNamespace
+	trace.SpanFromContext(ctx).SetAttributes(semconv.K8SPodName(k8sPodName), semconv.K8SNamespaceName(k8sNamespace))
 
 	plugin.setCNIReportDetails(args.ContainerID, CNI_DEL, "")
 	telemetryClient.SendEvent(fmt.Sprintf("[cni-net] Processing DEL command with args {ContainerID:%v Netns:%v IfName:%v Args:%v Path:%v, StdinData:%s}.",
@@ -1014,7 +1189,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 
 	logger.Info("Execution mode", zap.String("mode", nwCfg.ExecutionMode))
 	if nwCfg.ExecutionMode == string(util.Baremetal) {
-		_, err = plugin.nnsClient.DeleteContainerNetworking(context.Background(), k8sPodName, args.Netns)
+		_, err = plugin.nnsClient.DeleteContainerNetworking(ctx, k8sPodName, args.Netns)
 		if err != nil {
 			return fmt.Errorf("nnsClient.DeleteContainerNetworking failed with err %w", err)
 		}
@@ -1023,7 +1198,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 	if plugin.ipamInvoker == nil {
 		switch nwCfg.IPAM.Type {
 		case network.AzureCNS:
-			cnsClient, cnsErr := cnscli.New("", defaultRequestTimeout)
+			cnsClient, cnsErr := newCNSClient(nwCfg.CNSUrl, nwCfg.CNSGrpcAddress)
 			if cnsErr != nil {
 				logger.Error("failed to create cns client", zap.Error(cnsErr))
 				return errors.Wrap(cnsErr, "failed to create cns client")
@@ -1102,7 +1277,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 
 			logger.Warn("Release ip by ContainerID (endpoint not found)",
 				zap.String("containerID", args.ContainerID))
-			if err = plugin.ipamInvoker.Delete(nil, nwCfg, args, nwInfo.Options); err != nil {
+			if err = plugin.ipamInvoker.Delete(ctx, nil, nwCfg, args, nwInfo.Options); err != nil {
 				return plugin.RetriableError(fmt.Errorf("failed to release address(no endpoint): %w", err))
 			}
 		}
@@ -1146,7 +1321,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 			for i := range epInfo.IPAddresses {
 				logger.Info("Release ip", zap.String("ip", epInfo.IPAddresses[i].IP.String()))
 				telemetryClient.SendEvent(fmt.Sprintf("Release ip: %s container id: %s endpoint id: %s", epInfo.IPAddresses[i].IP.String(), args.ContainerID, epInfo.EndpointID))
-				err = plugin.ipamInvoker.Delete(&epInfo.IPAddresses[i], nwCfg, args, nwInfo.Options)
+				err = plugin.ipamInvoker.Delete(ctx, &epInfo.IPAddresses[i], nwCfg, args, nwInfo.Options)
 				if err != nil {
 					return plugin.RetriableError(fmt.Errorf("failed to release address: %w", err))
 				}
@@ -1154,7 +1329,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		} else if epInfo.EnableInfraVnet { // remove in future PR
 			nwCfg.IPAM.Subnet = nwInfo.Subnets[0].Prefix.String()
 			nwCfg.IPAM.Address = epInfo.InfraVnetIP.IP.String()
-			err = plugin.ipamInvoker.Delete(nil, nwCfg, args, nwInfo.Options)
+			err = plugin.ipamInvoker.Delete(ctx, nil, nwCfg, args, nwInfo.Options)
 			if err != nil {
 				return plugin.RetriableError(fmt.Errorf("failed to release address: %w", err))
 			}
@@ -1355,6 +1530,247 @@ func (plugin *NetPlugin) Update(args *cniSkel.CmdArgs) error {
 	return nil
 }
 
+// GC handles CNI GC commands.
+// Endpoints and CNS IP assignments that belong to containers which are not in the runtime's list of
+// valid attachments are considered leaked and are cleaned up.
+func (plugin *NetPlugin) GC(args *cniSkel.CmdArgs) error {
+	var (
+		err   error
+		nwCfg *cni.NetworkConfig
+	)
+
+	logger.Info("Processing GC command",
+		zap.String("path", args.Path),
+		zap.ByteString("stdinData", args.StdinData))
+
+	defer func() {
+		logger.Info("GC command completed", zap.Error(log.NewErrorWithoutStackTrace(err)))
+	}()
+
+	// Parse network configuration from stdin.
+	if nwCfg, err = cni.ParseNetworkConfig(args.StdinData); err != nil {
+		err = plugin.Errorf("Failed to parse network configuration: %v", err)
+		return err
+	}
+
+	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+
+	validContainerIDs := make(map[string]struct{}, len(nwCfg.ValidAttachments))
+	for _, attachment := range nwCfg.ValidAttachments {
+		validContainerIDs[attachment.ContainerID] = struct{}{}
+	}
+
+	staleEndpoints, err := plugin.gcEndpoints(args, nwCfg, validContainerIDs)
+	if err != nil {
+		return err
+	}
+
+	if nwCfg.IPAM.Type == network.AzureCNS && !nwCfg.MultiTenancy {
+		if err = plugin.gcCNSIPs(nwCfg, staleEndpoints); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// gcEndpoints deletes the endpoints in the statefile that belong to containers which are no longer valid attachments.
+// Each stale container goes through the regular DEL flow so that its addresses are released and its state removed.
+// It returns the stale endpoints by container ID.
+func (plugin *NetPlugin) gcEndpoints(args *cniSkel.CmdArgs, nwCfg *cni.NetworkConfig, validContainerIDs map[string]struct{}) (map[string]*network.EndpointInfo, error) {
+	eps, err := plugin.nm.GetAllEndpoints(nwCfg.Name)
+	if err != nil && !errors.Is(err, store.ErrStoreEmpty) {
+		logger.Error("Failed to retrieve endpoints for GC", zap.String("network", nwCfg.Name), zap.Error(err))
+		return nil, plugin.RetriableError(fmt.Errorf("failed to retrieve endpoints: %w", err))
+	}
+
+	staleEndpoints := make(map[string]*network.EndpointInfo)
+	for _, ep := range eps {
+		if ep.ContainerID == "" {
+			continue
+		}
+		if _, ok := validContainerIDs[ep.ContainerID]; ok {
+			continue
+		}
+		staleEndpoints[ep.ContainerID] = ep
+	}
+
+	// DEL lazily creates an IPAM invoker scoped to the pod being deleted, so make sure each stale
+	// container gets its own and restore the original one once we are done.
+	ipamInvoker := plugin.ipamInvoker
+	defer func() {
+		plugin.ipamInvoker = ipamInvoker
+	}()
+
+	for containerID, ep := range staleEndpoints {
+		logger.Info("Deleting leaked endpoint",
+			zap.String("containerID", containerID),
+			zap.String("endpointID", ep.EndpointID),
+			zap.String("pod", ep.PODName),
+			zap.String("namespace", ep.PODNameSpace))
+		telemetryClient.SendEvent(fmt.Sprintf("[cni-net] GC deleting leaked endpoint %s for container %s", ep.EndpointID, containerID))
+
+		plugin.ipamInvoker = ipamInvoker
+		delArgs := &cniSkel.CmdArgs{
+			ContainerID: containerID,
+			Netns:       ep.NetNsPath,
+			IfName:      ep.IfName,
+			Args:        fmt.Sprintf("K8S_POD_NAME=%s;K8S_POD_NAMESPACE=%s", ep.PODName, ep.PODNameSpace),
+			Path:        args.Path,
+			StdinData:   args.StdinData,
+		}
+		if err := plugin.Delete(delArgs); err != nil {
+			logger.Error("Failed to delete leaked endpoint", zap.String("containerID", containerID), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return staleEndpoints, nil
+}
+
+// gcCNSIPs releases the IPs that CNS still has assigned to the stale endpoints of the network, in case their DEL
+// did not release them. CNS doesn't record the network an IP was assigned for and other networks or plugins may share
+// it, so an IP is only released if it was assigned for the interface this plugin created for a stale endpoint.
+func (plugin *NetPlugin) gcCNSIPs(nwCfg *cni.NetworkConfig, staleEndpoints map[string]*network.EndpointInfo) error {
+	if len(staleEndpoints) == 0 {
+		return nil
+	}
+
+	cnsClient, err := plugin.getCNSClient(nwCfg)
+	if err != nil {
+		logger.Error("failed to create cns client", zap.Error(err))
+		return errors.Wrap(err, "failed to create cns client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	ipConfigs, err := cnsClient.GetIPAddressesMatchingStates(ctx, types.Assigned)
+	if err != nil {
+		logger.Error("Failed to get assigned IPs from CNS", zap.Error(err))
+		return plugin.RetriableError(fmt.Errorf("failed to get assigned IPs from CNS: %w", err))
+	}
+
+	released := make(map[string]struct{})
+	for i := range ipConfigs {
+		podInfo := ipConfigs[i].PodInfo
+		// IPs without an infra container ID were not assigned through a CNI ADD, so there is nothing
+		// the runtime can tell us about them.
+		if podInfo == nil || podInfo.InfraContainerID() == "" {
+			continue
+		}
+		ep, ok := staleEndpoints[podInfo.InfraContainerID()]
+		if !ok {
+			continue
+		}
+		if interfaceID, _ := network.ConstructEndpointID(ep.ContainerID, ep.NetNsPath, ep.IfName); podInfo.InterfaceID() != interfaceID {
+			continue
+		}
+		if _, ok := released[podInfo.Key()]; ok {
+			continue
+		}
+
+		orchestratorContext, err := podInfo.OrchestratorContext()
+		if err != nil {
+			logger.Error("Failed to build orchestrator context", zap.String("pod", podInfo.String()), zap.Error(err))
+			continue
+		}
+
+		logger.Info("Releasing leaked IP",
+			zap.String("ip", ipConfigs[i].IPAddress),
+			zap.String("ncID", ipConfigs[i].NCID),
+			zap.String("pod", podInfo.String()))
+		telemetryClient.SendEvent(fmt.Sprintf("[cni-net] GC releasing leaked IP %s for container %s", ipConfigs[i].IPAddress, podInfo.InfraContainerID()))
+
+		req := cns.IPConfigsRequest{
+			PodInterfaceID:      podInfo.InterfaceID(),
+			InfraContainerID:    podInfo.InfraContainerID(),
+			OrchestratorContext: orchestratorContext,
+		}
+		if err := cnsClient.ReleaseIPs(ctx, req); err != nil {
+			logger.Error("Failed to release leaked IP", zap.String("pod", podInfo.String()), zap.Error(err))
+			return plugin.RetriableError(fmt.Errorf("failed to release leaked IPs for container %s: %w", podInfo.InfraContainerID(), err))
+		}
+		released[podInfo.Key()] = struct{}{}
+	}
+
+	return nil
+}
+
+// Status handles CNI STATUS commands.
+// The plugin reports itself as not available when it is configured for CNS IPAM and CNS is either
+// unreachable or has no free IPs left in the pool.
+func (plugin *NetPlugin) Status(args *cniSkel.CmdArgs) error {
+	var (
+		err   error
+		nwCfg *cni.NetworkConfig
+	)
+
+	logger.Info("Processing STATUS command", zap.ByteString("stdinData", args.StdinData))
+
+	defer func() {
+		logger.Info("STATUS command completed", zap.Error(log.NewErrorWithoutStackTrace(err)))
+	}()
+
+	// Parse network configuration from stdin.
+	if nwCfg, err = cni.ParseNetworkConfig(args.StdinData); err != nil {
+		err = plugin.Errorf("Failed to parse network configuration: %v", err)
+		return err
+	}
+
+	if nwCfg.IPAM.Type != network.AzureCNS || nwCfg.MultiTenancy {
+		return nil
+	}
+
+	cnsClient, err := plugin.getCNSClient(nwCfg)
+	if err != nil {
+		err = cniTypes.NewError(cni.ErrPluginNotAvailable, err.Error(), "failed to create cns client")
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	ipConfigs, err := cnsClient.GetIPAddressesMatchingStates(ctx, types.Available)
+	if err != nil {
+		err = cniTypes.NewError(cni.ErrPluginNotAvailable, err.Error(), "CNS is unreachable")
+		return err
+	}
+
+	if len(ipConfigs) == 0 {
+		err = cniTypes.NewError(cni.ErrPluginNotAvailable, "no free IPs in the CNS pool", "")
+		return err
+	}
+
+	return nil
+}
+
+// getCNSClient returns the CNS client used by the plugin, creating one from the network config if none is set.
+func (plugin *NetPlugin) getCNSClient(nwCfg *cni.NetworkConfig) (cnsclient, error) {
+	if plugin.cnsClient != nil {
+		return plugin.cnsClient, nil
+	}
+
+	return newCNSClient(nwCfg.CNSUrl, nwCfg.CNSGrpcAddress)
+}
+
+// newCNSClient returns a CNS client for the CNS at baseURL.
+// If a gRPC address is configured, the IPAM APIs are called over the CNS gRPC service instead of HTTP.
+func newCNSClient(baseURL, grpcAddress string) (cnsclient, error) {
+	if grpcAddress != "" {
+		c, err := cnscli.NewGRPC(baseURL, grpcAddress, defaultRequestTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create cns grpc client")
+		}
+		return c, nil
+	}
+	c, err := cnscli.New(baseURL, defaultRequestTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cns client")
+	}
+	return c, nil
+}
+
 func convertNnsToIPConfigs(
 	netRes *nnscontracts.ConfigureContainerNetworkingResponse,
 	ifName string,
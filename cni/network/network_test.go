@@ -18,6 +18,8 @@ import (
 	"github.com/Azure/azure-container-networking/network/policy"
 	"github.com/Azure/azure-container-networking/nns"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -528,12 +530,36 @@ func TestAddDualStack(t *testing.T) {
 func TestPluginGet(t *testing.T) {
 	plugin, _ := cni.NewPlugin("name", "0.3.0")
 
+	// the mock ipam invoker assigns 10.240.0.5 to the first container
+	getIPConfigsRequest := cns.IPConfigsRequest{
+		OrchestratorContext: marshallPodInfo(cns.KubernetesPodInfo{PodName: "test-pod", PodNamespace: "test-pod-namespace"}),
+		PodInterfaceID:      GetEndpointID(args),
+		InfraContainerID:    args.ContainerID,
+	}
+	assignedCNSClient := func(ips ...string) *MockCNSClient {
+		podIPInfo := make([]cns.PodIpInfo, len(ips))
+		for i, ip := range ips {
+			podIPInfo[i].PodIPConfig = cns.IPSubnet{IPAddress: ip, PrefixLength: 24}
+		}
+		return &MockCNSClient{
+			getIPConfigs: getIPConfigsHandler{
+				ipconfigArgument: getIPConfigsRequest,
+				result:           &cns.IPConfigsResponse{PodIPInfo: podIPInfo},
+			},
+		}
+	}
+	invalidDatapathNM := acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil))
+	invalidDatapathNM.ValidateEndpointErr = fmt.Errorf("%w: host interface azv1 not found", acnnetwork.ErrDatapathInvalid)
+	failingValidationNM := acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil))
+	failingValidationNM.ValidateEndpointErr = errors.New("netlink socket error")
+
 	tests := []struct {
-		name       string
-		methods    []string
-		plugin     *NetPlugin
-		wantErr    bool
-		wantErrMsg string
+		name        string
+		methods     []string
+		plugin      *NetPlugin
+		wantErr     bool
+		wantErrMsg  string
+		wantErrCode uint
 	}{
 		{
 			name:    "CNI Get happy path",
@@ -542,6 +568,20 @@ func TestPluginGet(t *testing.T) {
 				Plugin:      plugin,
 				nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
 				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient:   assignedCNSClient("10.240.0.5"),
+			},
+			wantErr: false,
+		},
+		{
+			name:    "CNI Get skips the IPs when CNS doesn't support GetIPConfigs",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient: &MockCNSClient{
+					unsupportedAPIs: map[cnsAPIName]struct{}{GetIPConfigs: {}},
+				},
 			},
 			wantErr: false,
 		},
@@ -567,6 +607,76 @@ func TestPluginGet(t *testing.T) {
 			wantErr:    true,
 			wantErrMsg: "Endpoint not found",
 		},
+		{
+			name:    "CNI Get fail with invalid datapath",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          invalidDatapathNM,
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient:   assignedCNSClient("10.240.0.5"),
+			},
+			wantErr:     true,
+			wantErrMsg:  "host interface azv1 not found",
+			wantErrCode: cni.ErrDatapathInvalid,
+		},
+		{
+			name:    "CNI Get fail when the datapath can't be validated",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          failingValidationNM,
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient:   assignedCNSClient("10.240.0.5"),
+			},
+			wantErr:     true,
+			wantErrMsg:  "netlink socket error",
+			wantErrCode: cni.ErrRuntime,
+		},
+		{
+			name:    "CNI Get fail when CNS has another IP assigned to the container",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient:   assignedCNSClient("10.240.0.6"),
+			},
+			wantErr:     true,
+			wantErrMsg:  "IP 10.240.0.5 is not assigned to container test-container in CNS",
+			wantErrCode: cni.ErrDatapathInvalid,
+		},
+		{
+			name:    "CNI Get fail when CNS has no IP assigned to the container",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient:   assignedCNSClient(),
+			},
+			wantErr:     true,
+			wantErrMsg:  "IP 10.240.0.5 is not assigned to container test-container in CNS",
+			wantErrCode: cni.ErrDatapathInvalid,
+		},
+		{
+			name:    "CNI Get retries when CNS is unreachable",
+			methods: []string{CNI_ADD, "GET"},
+			plugin: &NetPlugin{
+				Plugin:      plugin,
+				nm:          acnnetwork.NewMockNetworkmanager(acnnetwork.NewMockEndpointClient(nil)),
+				ipamInvoker: NewMockIpamInvoker(false, false, false, false, false),
+				cnsClient: &MockCNSClient{
+					getIPConfigs: getIPConfigsHandler{
+						ipconfigArgument: getIPConfigsRequest,
+						err:              errors.New("connection refused"),
+					},
+				},
+			},
+			wantErr:     true,
+			wantErrMsg:  "connection refused",
+			wantErrCode: cniTypes.ErrTryAgainLater,
+		},
 	}
 
 	for _, tt := range tests {
@@ -588,6 +698,11 @@ func TestPluginGet(t *testing.T) {
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				if tt.wantErrCode != 0 {
+					var cniErr *cniTypes.Error
+					require.ErrorAs(t, err, &cniErr)
+					require.Equal(t, tt.wantErrCode, cniErr.Code)
+				}
 			} else {
 				require.NoError(t, err)
 			}
@@ -1138,6 +1253,116 @@ func TestPluginUpdate(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPluginGC(t *testing.T) {
+	plugin := GetTestResources()
+
+	validArgs := &cniSkel.CmdArgs{
+		ContainerID: "valid-container",
+		Netns:       "valid-container",
+		StdinData:   nwCfg.Serialize(),
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "valid-pod", "valid-pod-ns"),
+		IfName:      eth0IfName,
+	}
+	staleArgs := &cniSkel.CmdArgs{
+		ContainerID: "stale-container",
+		Netns:       "stale-container",
+		StdinData:   nwCfg.Serialize(),
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "stale-pod", "stale-pod-ns"),
+		IfName:      eth0IfName,
+	}
+	require.NoError(t, plugin.Add(validArgs))
+	require.NoError(t, plugin.Add(staleArgs))
+
+	// only the IP still assigned to the interface of the stale endpoint is expected to be released, any other request
+	// makes the mock fail. the IPs of containers this network doesn't know, e.g. of another network sharing CNS, and
+	// of interfaces another plugin created for the stale container are left alone.
+	staleInterfaceID, _ := acnnetwork.ConstructEndpointID(staleArgs.ContainerID, staleArgs.Netns, staleArgs.IfName)
+	stalePodInfo := cns.NewPodInfo("stale-container", staleInterfaceID, "stale-pod", "stale-pod-ns")
+	otherPluginPodInfo := cns.NewPodInfo("stale-container", "stale-container", "stale-pod", "stale-pod-ns")
+	unknownPodInfo := cns.NewPodInfo("unknown-container", "unknown-container-eth0", "unknown-pod", "unknown-pod-ns")
+	validPodInfo := cns.NewPodInfo("valid-container", "valid-container-eth0", "valid-pod", "valid-pod-ns")
+	plugin.cnsClient = &MockCNSClient{
+		getIPAddressesMatchingStates: getIPAddressesMatchingStatesHandler{
+			result: []cns.IPConfigurationStatus{
+				{IPAddress: "10.0.0.4", NCID: "nc", PodInfo: stalePodInfo},
+				{IPAddress: "10.0.0.5", NCID: "nc", PodInfo: unknownPodInfo},
+				{IPAddress: "10.0.0.6", NCID: "nc", PodInfo: validPodInfo},
+				{IPAddress: "10.0.0.7", NCID: "nc", PodInfo: otherPluginPodInfo},
+			},
+		},
+		releaseIPs: releaseIPsHandler{
+			ipconfigArgument: cns.IPConfigsRequest{
+				PodInterfaceID:      staleInterfaceID,
+				InfraContainerID:    "stale-container",
+				OrchestratorContext: marshallPodInfo(cns.KubernetesPodInfo{PodName: "stale-pod", PodNamespace: "stale-pod-ns"}),
+			},
+		},
+	}
+
+	gcCfg := nwCfg
+	gcCfg.ValidAttachments = []cniTypes.GCAttachment{{ContainerID: "valid-container", IfName: eth0IfName}}
+	err := plugin.GC(&cniSkel.CmdArgs{StdinData: gcCfg.Serialize()})
+	require.NoError(t, err)
+	require.Equal(t, 1, plugin.cnsClient.(*MockCNSClient).releaseIPs.calls)
+
+	endpoints, _ := plugin.nm.GetAllEndpoints(nwCfg.Name)
+	require.Len(t, endpoints, 1)
+	for _, ep := range endpoints {
+		require.Equal(t, "valid-container", ep.ContainerID)
+	}
+}
+
+func TestPluginStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		cnsClient *MockCNSClient
+		wantErr   bool
+	}{
+		{
+			name: "CNS has free IPs",
+			cnsClient: &MockCNSClient{
+				getIPAddressesMatchingStates: getIPAddressesMatchingStatesHandler{
+					result: []cns.IPConfigurationStatus{{IPAddress: "10.0.0.5"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "CNS pool is exhausted",
+			cnsClient: &MockCNSClient{
+				getIPAddressesMatchingStates: getIPAddressesMatchingStatesHandler{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "CNS is unreachable",
+			cnsClient: &MockCNSClient{
+				getIPAddressesMatchingStates: getIPAddressesMatchingStatesHandler{
+					err: errors.New("connection refused"),
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := GetTestResources()
+			plugin.cnsClient = tt.cnsClient
+			err := plugin.Status(&cniSkel.CmdArgs{StdinData: nwCfg.Serialize()})
+			if tt.wantErr {
+				require.Error(t, err)
+				var cniErr *cniTypes.Error
+				require.ErrorAs(t, err, &cniErr)
+				require.Equal(t, uint(cni.ErrPluginNotAvailable), cniErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func getTestEndpoint(podname, podnamespace, ipwithcidr, podinterfaceid, infracontainerid string) *acnnetwork.EndpointInfo {
 	ip, ipnet, _ := net.ParseCIDR(ipwithcidr)
 	ipnet.IP = ip
@@ -1203,6 +1428,66 @@ func TestGetAllEndpointState(t *testing.T) {
 	require.Exactly(t, res, state)
 }
 
+func TestReconcileEndpoints(t *testing.T) {
+	plugin := GetTestResources()
+	networkid := "azure"
+
+	ep1 := getTestEndpoint("podname1", "podnamespace1", "10.0.0.1/24", "podinterfaceid1", "testcontainerid1")
+	ep2 := getTestEndpoint("podname2", "podnamespace2", "10.0.0.2/24", "podinterfaceid2", "testcontainerid2")
+	ep3 := getTestEndpoint("podname3", "podnamespace3", "10.0.0.3/24", "podinterfaceid3", "testcontainerid3")
+	for _, ep := range []*acnnetwork.EndpointInfo{ep1, ep2, ep3} {
+		require.NoError(t, plugin.nm.CreateEndpoint(nil, networkid, ep))
+	}
+
+	plugin.nm.(*acnnetwork.MockNetworkManager).ReconcileEndpointFn = func(_ string, epInfo *acnnetwork.EndpointInfo) ([]acnnetwork.EndpointRepair, error) {
+		switch epInfo.EndpointID {
+		case ep2.EndpointID:
+			return []acnnetwork.EndpointRepair{{Kind: acnnetwork.RepairNeighbor, Detail: "neighbor 169.254.1.1 on container interface eth0"}}, nil
+		case ep3.EndpointID:
+			return nil, fmt.Errorf("%w: missing container interface eth0", acnnetwork.ErrDatapathInvalid)
+		default:
+			return nil, nil
+		}
+	}
+
+	result, err := plugin.ReconcileEndpoints(networkid, "")
+	require.NoError(t, err)
+
+	res := &api.EndpointReconcileResult{
+		Endpoints: 3,
+		Repairs: []api.EndpointRepair{
+			{
+				PodName:       ep2.PODName,
+				PodNamespace:  ep2.PODNameSpace,
+				ContainerID:   ep2.ContainerID,
+				PodEndpointId: ep2.EndpointID,
+				Kind:          acnnetwork.RepairNeighbor,
+				Detail:        "neighbor 169.254.1.1 on container interface eth0",
+			},
+		},
+		Errors: []api.EndpointReconcileError{
+			{
+				PodName:       ep3.PODName,
+				PodNamespace:  ep3.PODNameSpace,
+				ContainerID:   ep3.ContainerID,
+				PodEndpointId: ep3.EndpointID,
+				Error:         "endpoint datapath is invalid: missing container interface eth0",
+			},
+		},
+	}
+
+	require.Exactly(t, res, result)
+
+	// a single endpoint is reconciled on its own, and a deleted endpoint is skipped
+	result, err = plugin.ReconcileEndpoints(networkid, ep2.EndpointID)
+	require.NoError(t, err)
+	require.Exactly(t, &api.EndpointReconcileResult{Endpoints: 1, Repairs: res.Repairs}, result)
+
+	result, err = plugin.ReconcileEndpoints(networkid, "deleted-eth0")
+	require.NoError(t, err)
+	require.Exactly(t, &api.EndpointReconcileResult{}, result)
+}
+
 func TestEndpointsWithEmptyState(t *testing.T) {
 	plugin := GetTestResources()
 	networkid := "azure"
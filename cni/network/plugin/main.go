@@ -91,6 +91,14 @@ func rootExecute() error {
 			cniReport.VMUptime = upTime.Format("2006-01-02 15:04:05")
 		}
 
+		// the nonstandard commands are exec'd without a network configuration on stdin
+		if cniCmd != cni.CmdGetEndpointsState && cniCmd != cni.CmdReconcileEndpoints {
+			if config.StoreBackend, err = cni.ReadStoreBackend(); err != nil {
+				network.PrintCNIError(fmt.Sprintf("Failed to read the store backend of network plugin: %v", err))
+				return errors.Wrap(err, "read store backend error")
+			}
+		}
+
 		// CNI attempts to acquire lock
 		if err = netPlugin.Plugin.InitializeKeyValueStore(&config); err != nil {
 			// Error acquiring lock
@@ -150,6 +158,24 @@ func rootExecute() error {
 
 			return errors.Wrap(err, "Get cni state printresult error")
 		}
+
+		// used by CNS to repair the endpoint datapath while the store lock is held
+		if cniCmd == cni.CmdReconcileEndpoints {
+			logger.Debug("Reconciling endpoints")
+			var result *api.EndpointReconcileResult
+			result, err = netPlugin.ReconcileEndpoints("azure", os.Getenv(cni.EnvReconcileEndpointID))
+			if err != nil {
+				logger.Error("Failed to reconcile Azure CNI endpoints", zap.Error(err))
+				return errors.Wrap(err, "Reconcile endpoints error")
+			}
+
+			err = result.PrintResult()
+			if err != nil {
+				logger.Error("Failed to print reconcile result to stdout", zap.Error(err))
+			}
+
+			return errors.Wrap(err, "Reconcile endpoints printresult error")
+		}
 	}
 
 	handled, _ := network.HandleIfCniUpdate(netPlugin.Update)
@@ -132,6 +132,18 @@ func rootExecute() error {
 		return errors.Wrap(err, "Get cni state printresult error")
 	}
 
+	// the endpoint state is owned by CNS in stateless mode, so there is nothing to reconcile here
+	if cniCmd == cni.CmdReconcileEndpoints {
+		logger.Debug("returning an empty reconcile result")
+		result := api.EndpointReconcileResult{}
+		err = result.PrintResult()
+		if err != nil {
+			logger.Error("Failed to print reconcile result to stdout", zap.Error(err))
+		}
+
+		return errors.Wrap(err, "Reconcile endpoints printresult error")
+	}
+
 	if cniCmd == cni.CmdVersion {
 		return errors.Wrap(err, "Execute netplugin failure")
 	}
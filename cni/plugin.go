@@ -6,6 +6,7 @@ package cni
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"time"
@@ -90,7 +91,14 @@ func (plugin *Plugin) Execute(api PluginApi) (err error) {
 	pluginInfo := cniVers.PluginSupports(supportedVersions...)
 
 	// Parse args and call the appropriate cmd handler.
-	cniErr := cniSkel.PluginMainWithError(api.Add, api.Get, api.Delete, pluginInfo, plugin.version)
+	cniFuncs := cniSkel.CNIFuncs{
+		Add:    api.Add,
+		Check:  api.Get,
+		Del:    api.Delete,
+		GC:     api.GC,
+		Status: api.Status,
+	}
+	cniErr := cniSkel.PluginMainFuncsWithError(cniFuncs, pluginInfo, plugin.version)
 	if cniErr != nil {
 		cniErr.Print()
 		return cniErr
@@ -181,6 +189,33 @@ func (plugin *Plugin) RetriableError(err error) *cniTypes.Error {
 	return tryAgainErr
 }
 
+// ReadStoreBackend returns the persistence backend of the plugin state set by storeBackend in the network configuration
+// on stdin, one of "json" or "bolt". When unset, the bolt backend is used if its database exists and JSON otherwise.
+// stdin is replaced with a pipe holding the same network configuration, for the CNI command to parse it again.
+func ReadStoreBackend() (store.Backend, error) {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read network configuration from stdin")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create stdin pipe")
+	}
+	go func() {
+		_, _ = w.Write(b)
+		w.Close()
+	}()
+	os.Stdin = r
+
+	// an invalid network configuration is reported by the CNI command.
+	nwCfg, err := ParseNetworkConfig(b)
+	if err != nil {
+		return "", nil
+	}
+	return store.Backend(nwCfg.StoreBackend), nil
+}
+
 // Initialize key-value store
 func (plugin *Plugin) InitializeKeyValueStore(config *common.PluginConfig) error {
 	// Create the key value store.
@@ -191,7 +226,7 @@ func (plugin *Plugin) InitializeKeyValueStore(config *common.PluginConfig) error
 			return errors.Wrap(err, "error creating new filelock")
 		}
 
-		plugin.Store, err = store.NewJsonFileStore(platform.CNIRuntimePath+plugin.Name+".json", lockclient, storeLogger)
+		plugin.Store, err = store.New(config.StoreBackend, platform.CNIRuntimePath+plugin.Name, lockclient, storeLogger)
 		if err != nil {
 			logger.Error("Failed to create store", zap.Error(err))
 			return err
@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cni/log"
 	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
 	"github.com/Azure/azure-container-networking/telemetry"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -100,6 +101,25 @@ func setTelemetryDefaults(config *telemetry.TelemetryConfig) {
 	}
 }
 
+type telemetryHandleCreator interface {
+	CreateAITelemetryHandle(aiConfig aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error
+	CreateOTLPTelemetryHandle(otlpConfig otlptelemetry.Config, aiConfig aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error
+}
+
+// createTelemetryHandles creates the Application Insights and OTLP handles the config enables.
+func createTelemetryHandles(tb telemetryHandleCreator, config *telemetry.TelemetryConfig, aiConfig aitelemetry.AIConfig, logger *zap.Logger) {
+	if !config.DisableAppInsights {
+		if err := tb.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableMetric, config.DisableTrace); err != nil { // nolint
+			logger.Error("AI Handle creation error:", zap.Error(err))
+		}
+	}
+	if config.OTLP != nil {
+		if err := tb.CreateOTLPTelemetryHandle(*config.OTLP, aiConfig, config.DisableAll, config.DisableMetric, config.DisableTrace); err != nil {
+			logger.Error("OTLP Handle creation error:", zap.Error(err))
+		}
+	}
+}
+
 func main() {
 	var tb *telemetry.TelemetryBuffer
 	var config telemetry.TelemetryConfig
@@ -167,9 +187,7 @@ func main() {
 		GetEnvRetryWaitTimeInSecs:    config.GetEnvRetryWaitTimeInSecs,
 	}
 
-	if err := tb.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric); err != nil { // nolint
-		logger.Error("AI Handle creation error:", zap.Error(err))
-	}
+	createTelemetryHandles(tb, &config, aiConfig, logger)
 	logger.Info("Report to host interval", zap.Duration("seconds", config.ReportToHostIntervalInSeconds))
 	tb.PushData(context.Background())
 	telemetry.CloseAITelemetryHandle()
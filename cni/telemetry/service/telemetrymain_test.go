@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type handleFlags struct {
+	disableAll, disableMetric, disableTrace bool
+}
+
+type fakeHandleCreator struct {
+	ai, otlp *handleFlags
+}
+
+func (f *fakeHandleCreator) CreateAITelemetryHandle(_ aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error {
+	f.ai = &handleFlags{disableAll, disableMetric, disableTrace}
+	return nil
+}
+
+func (f *fakeHandleCreator) CreateOTLPTelemetryHandle(_ otlptelemetry.Config, _ aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error {
+	f.otlp = &handleFlags{disableAll, disableMetric, disableTrace}
+	return nil
+}
+
+func TestCreateTelemetryHandles(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   telemetry.TelemetryConfig
+		wantAI   *handleFlags
+		wantOTLP *handleFlags
+	}{
+		{
+			name:   "metrics disabled",
+			config: telemetry.TelemetryConfig{DisableMetric: true},
+			wantAI: &handleFlags{disableMetric: true},
+		},
+		{
+			name:   "traces disabled",
+			config: telemetry.TelemetryConfig{DisableTrace: true},
+			wantAI: &handleFlags{disableTrace: true},
+		},
+		{
+			name:     "app insights disabled with otlp",
+			config:   telemetry.TelemetryConfig{DisableAppInsights: true, DisableMetric: true, OTLP: &otlptelemetry.Config{}},
+			wantOTLP: &handleFlags{disableMetric: true},
+		},
+		{
+			name:     "app insights and otlp",
+			config:   telemetry.TelemetryConfig{DisableTrace: true, OTLP: &otlptelemetry.Config{}},
+			wantAI:   &handleFlags{disableTrace: true},
+			wantOTLP: &handleFlags{disableTrace: true},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeHandleCreator{}
+			createTelemetryHandles(f, &tt.config, aitelemetry.AIConfig{}, zap.NewNop())
+			assert.Equal(t, tt.wantAI, f.ai)
+			assert.Equal(t, tt.wantOTLP, f.otlp)
+		})
+	}
+}
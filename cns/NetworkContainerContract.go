@@ -6,6 +6,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
@@ -35,9 +36,12 @@ const (
 	RequestIPConfigs                         = "/network/requestipconfigs"
 	ReleaseIPConfig                          = "/network/releaseipconfig"
 	ReleaseIPConfigs                         = "/network/releaseipconfigs"
+	GetIPConfigs                             = "/network/getipconfigs"
 	PathDebugIPAddresses                     = "/debug/ipaddresses"
 	PathDebugPodContext                      = "/debug/podcontext"
 	PathDebugRestData                        = "/debug/restdata"
+	PathDebugIPHistory                       = "/debug/iphistory"
+	PathDebugConfig                          = "/debug/config"
 	NumberOfCPUCores                         = NumberOfCPUCoresPath
 	NMAgentSupportedAPIs                     = NmAgentSupportedApisPath
 	EndpointAPI                              = EndpointPath
@@ -591,6 +595,84 @@ type GetIPAddressStatusResponse struct {
 	Response              Response
 }
 
+// GetIPHistoryRequest is used in CNS IPAM mode to get the pods which held an IP address.
+type GetIPHistoryRequest struct {
+	IPAddress string
+}
+
+// IPOwner is a pod which held an IP address. ReleasedAt is zero if the pod still holds it.
+type IPOwner struct {
+	PodKey           string
+	PodName          string
+	PodNamespace     string
+	InfraContainerID string
+	InterfaceID      string
+	NCID             string
+	IPConfigID       string
+	AssignedAt       time.Time
+	ReleasedAt       time.Time
+}
+
+// GetIPHistoryResponse is used in CNS IPAM mode as a response to get the pods which held an IP address,
+// most recent first.
+type GetIPHistoryResponse struct {
+	IPAddress string
+	Owners    []IPOwner
+	Response  Response
+}
+
+// ConfigReloadResult is the outcome of reloading the CNS config file.
+type ConfigReloadResult struct {
+	Time time.Time
+	// Applied are the config fields which changed and were applied without restarting CNS.
+	Applied []string
+	// RequiresRestart are the config fields which differ from the config CNS started with, and only take effect
+	// once CNS is restarted.
+	RequiresRestart []string
+	// Error is why the config file was rejected. The effective config is unchanged if it is set.
+	Error string `json:",omitempty"`
+}
+
+// GetConfigResponse is the config CNS is running with, along with the result of the last reload of the config file,
+// if there was one.
+type GetConfigResponse struct {
+	Config     json.RawMessage
+	LastReload *ConfigReloadResult
+	Response   Response
+}
+
+// StateEventKind is the kind of CNS state a StateEvent describes.
+type StateEventKind string
+
+const (
+	// IPConfigStateEvent is emitted when an IPConfig in the IP pool is added, changes state or is removed.
+	IPConfigStateEvent StateEventKind = "IPConfig"
+	// EndpointStateEvent is emitted when the endpoint state of a container is updated or deleted.
+	EndpointStateEvent StateEventKind = "Endpoint"
+)
+
+// StateEvent is a change to the CNS IP pool or endpoint state, as streamed by the CNS state watch.
+// ResourceVersion increases monotonically across events and can be used to resume a watch.
+type StateEvent struct {
+	ResourceVersion uint64
+	Kind            StateEventKind
+	Timestamp       time.Time
+	// PodKey is the key of the pod interface the IP is or was assigned to, or the infra container ID of the endpoint.
+	PodKey       string
+	PodName      string
+	PodNamespace string
+	// Deleted is set when the IPConfig or endpoint was removed from CNS.
+	Deleted bool
+	// IPConfig events
+	IPConfigID    string
+	IPAddress     string
+	NCID          string
+	PreviousState types.IPState
+	State         types.IPState
+	// Endpoint events
+	EndpointID string
+}
+
 // GetPodContextResponse is used in CNS Client debug mode to get mapping of Orchestrator Context to Pod IP UUIDs
 type GetPodContextResponse struct {
 	PodContext map[string][]string // Can have multiple Pod IP UUIDs in the case of dualstack
@@ -0,0 +1,313 @@
+// Package authz authorizes the callers of the CNS REST API per route, by the identity they connect with: the
+// subject of their mTLS client certificate, the credentials of their process over a unix socket, or a local token.
+package authz
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Mode is how the policy is applied.
+type Mode string
+
+const (
+	// Disabled serves every caller, and is the default.
+	Disabled Mode = ""
+	// Audit logs the calls which the policy denies, and serves them.
+	Audit Mode = "audit"
+	// Enforce logs and rejects the calls which the policy denies.
+	Enforce Mode = "enforce"
+)
+
+const bearerPrefix = "Bearer "
+
+var (
+	ErrInvalidMode  = errors.New("invalid authorization mode")
+	ErrUnknownToken = errors.New("rule references an unknown token")
+	ErrEmptyToken   = errors.New("token file is empty")
+)
+
+// Config is the authorization policy of the CNS REST API.
+type Config struct {
+	// Mode is audit, enforce, or empty to disable authorization.
+	Mode Mode
+	// Rules allow callers per route. A route without a rule is open to every caller. Unset, the DefaultRules apply.
+	Rules []Rule
+	// Tokens maps the name of a token, which rules refer to, to the file holding it. Callers present a token in the
+	// Authorization header as a bearer token.
+	Tokens map[string]string
+}
+
+// Rule allows the callers which match any of its identities to call its routes.
+type Rule struct {
+	// Routes are the patterns of the routes, as registered with the CNS mux e.g. /network/requestipconfigs.
+	Routes []string
+	// Subjects match the DNS SANs or the common name of a verified mTLS client certificate.
+	Subjects []string
+	// UIDs match the user of a caller connected over a unix socket.
+	UIDs []uint32
+	// GIDs match the group of a caller connected over a unix socket.
+	GIDs []uint32
+	// Tokens match the name of the token presented by the caller.
+	Tokens []string
+}
+
+// ipamRoutes are the paths of cns.RequestIPConfig(s) and cns.ReleaseIPConfig(s), and the full methods of the
+// gRPC RequestIPConfigs and ReleaseIPConfigs. The cns packages serve the Authorizer, so they can't be referenced
+// from here.
+var ipamRoutes = []string{
+	"/network/requestipconfig",
+	"/network/requestipconfigs",
+	"/network/releaseipconfig",
+	"/network/releaseipconfigs",
+	"/cns.CNS/RequestIPConfigs",
+	"/cns.CNS/ReleaseIPConfigs",
+}
+
+// rootUID is the user of the CNI plugin and azure-ipam, which the container runtime runs as root.
+const rootUID = 0
+
+// DefaultRules limit the IPAM routes to root callers over the CNS unix socket, which are the CNI plugin and
+// azure-ipam. The socket file is only accessible to root by default, so this also holds for callers whose
+// credentials can't be read.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Routes: ipamRoutes,
+			UIDs:   []uint32{rootUID},
+		},
+	}
+}
+
+// PeerCred are the credentials of the process at the other end of a unix socket. The PID is in the pid namespace
+// of the peer, which CNS doesn't share, so it is only logged.
+type PeerCred struct {
+	UID uint32
+	GID uint32
+	PID int32
+}
+
+// Identity is who a request comes from.
+type Identity struct {
+	// Cert is the verified mTLS client certificate.
+	Cert *x509.Certificate
+	// PeerCred are set for a caller connected over a unix socket.
+	PeerCred *PeerCred
+	// Token is the name of the token presented by the caller.
+	Token string
+}
+
+func (id Identity) String() string {
+	var parts []string
+	if id.Cert != nil {
+		parts = append(parts, fmt.Sprintf("cert cn=%s sans=%v", id.Cert.Subject.CommonName, id.Cert.DNSNames))
+	}
+	if id.PeerCred != nil {
+		parts = append(parts, fmt.Sprintf("uid=%d gid=%d pid=%d", id.PeerCred.UID, id.PeerCred.GID, id.PeerCred.PID))
+	}
+	if id.Token != "" {
+		parts = append(parts, "token="+id.Token)
+	}
+	if len(parts) == 0 {
+		return "anonymous"
+	}
+	return strings.Join(parts, ", ")
+}
+
+type logger interface {
+	Printf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// Authorizer applies a Config to the requests to CNS.
+type Authorizer struct {
+	mode   Mode
+	rules  map[string][]Rule
+	tokens map[string][]byte
+	logger logger
+}
+
+// New returns an Authorizer of the config, reading the tokens it refers to.
+func New(cfg Config, l logger) (*Authorizer, error) {
+	switch cfg.Mode {
+	case Disabled, Audit, Enforce:
+	default:
+		return nil, errors.Wrap(ErrInvalidMode, string(cfg.Mode))
+	}
+
+	a := &Authorizer{
+		mode:   cfg.Mode,
+		rules:  map[string][]Rule{},
+		tokens: map[string][]byte{},
+		logger: l,
+	}
+	for name, path := range cfg.Tokens {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read token %s", name)
+		}
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return nil, errors.Wrap(ErrEmptyToken, name)
+		}
+		a.tokens[name] = []byte(token)
+	}
+
+	rules := cfg.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	for i := range rules {
+		for _, token := range rules[i].Tokens {
+			if _, ok := a.tokens[token]; !ok {
+				return nil, errors.Wrap(ErrUnknownToken, token)
+			}
+		}
+		for _, route := range rules[i].Routes {
+			a.rules[route] = append(a.rules[route], rules[i])
+		}
+	}
+	return a, nil
+}
+
+// Authorize returns whether the identity may call the route.
+func (a *Authorizer) Authorize(route string, id Identity) bool {
+	rules, ok := a.rules[route]
+	if !ok {
+		return true
+	}
+	for i := range rules {
+		if rules[i].allows(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware authorizes the requests before they reach the handler, for the route returned by route e.g. the
+// pattern of the mux. Denied requests are logged, and in Enforce mode rejected with 403 Forbidden.
+func (a *Authorizer) Middleware(route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if a.mode == Disabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.serve(route(r), r.Method+" "+r.URL.Path, r.RemoteAddr, a.identify(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+}
+
+// serve returns whether to serve a call to the route, and logs and counts the denied calls.
+func (a *Authorizer) serve(route, call, remote string, id Identity) bool {
+	if a.Authorize(route, id) {
+		return true
+	}
+	deniedRequests.WithLabelValues(route, string(a.mode)).Inc()
+	if a.mode == Audit {
+		a.logger.Printf("[Azure CNS] authz audit: would deny %s from %s (%s)", call, remote, id)
+		return true
+	}
+	a.logger.Errorf("[Azure CNS] authz: denied %s from %s (%s)", call, remote, id)
+	return false
+}
+
+// Path is the route of a request to a server which registers its routes by exact path.
+func Path(r *http.Request) string {
+	return r.URL.Path
+}
+
+type peerCredKey struct{}
+
+// ConnContext adds the peer credentials of a unix socket connection to the context of its requests. It is the
+// http.Server.ConnContext of the CNS listeners.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	cred, err := peerCredentials(uc)
+	if err != nil {
+		// the caller is then anonymous, which the policy may still allow
+		return ctx
+	}
+	return context.WithValue(ctx, peerCredKey{}, cred)
+}
+
+func (a *Authorizer) identify(r *http.Request) Identity {
+	var id Identity
+	if cred, ok := r.Context().Value(peerCredKey{}).(*PeerCred); ok {
+		id.PeerCred = cred
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		id.Cert = r.TLS.VerifiedChains[0][0]
+	}
+	id.Token = a.token(r.Header.Get("Authorization"))
+	return id
+}
+
+// token returns the name of the bearer token in the Authorization header, if it is known.
+func (a *Authorizer) token(auth string) string {
+	if !strings.HasPrefix(auth, bearerPrefix) {
+		return ""
+	}
+	presented := []byte(strings.TrimPrefix(auth, bearerPrefix))
+	for name, token := range a.tokens {
+		if subtle.ConstantTimeCompare(presented, token) == 1 {
+			return name
+		}
+	}
+	return ""
+}
+
+func (r *Rule) allows(id Identity) bool {
+	if id.Cert != nil {
+		for _, subject := range r.Subjects {
+			if MatchSubject(id.Cert, subject) {
+				return true
+			}
+		}
+	}
+	if id.PeerCred != nil {
+		for _, uid := range r.UIDs {
+			if id.PeerCred.UID == uid {
+				return true
+			}
+		}
+		for _, gid := range r.GIDs {
+			if id.PeerCred.GID == gid {
+				return true
+			}
+		}
+	}
+	if id.Token != "" {
+		for _, token := range r.Tokens {
+			if id.Token == token {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchSubject returns whether the DNS SANs, or else the common name, of the certificate match the subject name
+// (case-insensitive).
+func MatchSubject(cert *x509.Certificate, subject string) bool {
+	for _, dns := range cert.DNSNames {
+		if strings.EqualFold(dns, subject) {
+			return true
+		}
+	}
+	return cert.Subject.CommonName != "" && strings.EqualFold(cert.Subject.CommonName, subject)
+}
@@ -0,0 +1,209 @@
+package authz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	t      *testing.T
+	denied int
+}
+
+func (l *testLogger) Printf(format string, args ...any) {
+	l.denied++
+	l.t.Logf(format, args...)
+}
+
+func (l *testLogger) Errorf(format string, args ...any) {
+	l.denied++
+	l.t.Logf(format, args...)
+}
+
+func writeToken(t *testing.T, token string) string {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte(token+"\n"), 0o600))
+	return path
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:    "invalid mode",
+			cfg:     Config{Mode: "deny"},
+			wantErr: ErrInvalidMode,
+		},
+		{
+			name: "unknown token",
+			cfg: Config{
+				Mode:  Enforce,
+				Rules: []Rule{{Routes: []string{"/a"}, Tokens: []string{"cni"}}},
+			},
+			wantErr: ErrUnknownToken,
+		},
+		{
+			name: "empty token",
+			cfg: Config{
+				Mode:   Enforce,
+				Tokens: map[string]string{"cni": writeToken(t, "")},
+			},
+			wantErr: ErrEmptyToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg, &testLogger{t: t})
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, errors.Is(err, tt.wantErr), "got %v, want %v", err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a, err := New(Config{
+		Mode: Enforce,
+		Rules: []Rule{
+			{Routes: []string{"/subject"}, Subjects: []string{"dnc.azure.com"}},
+			{Routes: []string{"/uid"}, UIDs: []uint32{0}},
+			{Routes: []string{"/gid"}, GIDs: []uint32{1000}},
+			{Routes: []string{"/token"}, Tokens: []string{"cni"}},
+		},
+		Tokens: map[string]string{"cni": writeToken(t, "secret")},
+	}, &testLogger{t: t})
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, DNSNames: []string{"DNC.azure.com"}}
+	tests := []struct {
+		name  string
+		route string
+		id    Identity
+		want  bool
+	}{
+		{name: "route without rules", route: "/open", want: true},
+		{name: "san", route: "/subject", id: Identity{Cert: cert}, want: true},
+		{name: "other subject", route: "/subject", id: Identity{Cert: &x509.Certificate{Subject: pkix.Name{CommonName: "x"}}}},
+		{name: "anonymous", route: "/subject"},
+		{name: "uid", route: "/uid", id: Identity{PeerCred: &PeerCred{UID: 0}}, want: true},
+		{name: "other uid", route: "/uid", id: Identity{PeerCred: &PeerCred{UID: 1000}}},
+		{name: "gid", route: "/gid", id: Identity{PeerCred: &PeerCred{UID: 1001, GID: 1000}}, want: true},
+		{name: "other gid", route: "/gid", id: Identity{PeerCred: &PeerCred{UID: 1000, GID: 1001}}},
+		{name: "token", route: "/token", id: Identity{Token: "cni"}, want: true},
+		{name: "identity of another rule", route: "/token", id: Identity{Cert: cert}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, a.Authorize(tt.route, tt.id))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	token := writeToken(t, "secret")
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "dnc.azure.com"}}
+	tests := []struct {
+		name       string
+		mode       Mode
+		req        func() *http.Request
+		wantStatus int
+		wantDenied int
+	}{
+		{
+			name: "enforce denies anonymous",
+			mode: Enforce,
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+			},
+			wantStatus: http.StatusForbidden,
+			wantDenied: 1,
+		},
+		{
+			name: "audit serves anonymous",
+			mode: Audit,
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+			},
+			wantStatus: http.StatusOK,
+			wantDenied: 1,
+		},
+		{
+			name: "disabled serves anonymous",
+			mode: Disabled,
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "token",
+			mode: Enforce,
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+				r.Header.Set("Authorization", "Bearer secret")
+				return r
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "wrong token",
+			mode: Enforce,
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+				r.Header.Set("Authorization", "Bearer guess")
+				return r
+			},
+			wantStatus: http.StatusForbidden,
+			wantDenied: 1,
+		},
+		{
+			name: "mtls subject",
+			mode: Enforce,
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/network/requestipconfigs", http.NoBody)
+				r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+				return r
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "route without rules",
+			mode:       Enforce,
+			req:        func() *http.Request { return httptest.NewRequest(http.MethodGet, "/debug/ipaddresses", http.NoBody) },
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &testLogger{t: t}
+			rules := append(DefaultRules(), Rule{Routes: ipamRoutes, Subjects: []string{"dnc.azure.com"}, Tokens: []string{"cni"}})
+			a, err := New(Config{Mode: tt.mode, Rules: rules, Tokens: map[string]string{"cni": token}}, l)
+			require.NoError(t, err)
+
+			h := a.Middleware(Path)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.req())
+			require.Equal(t, tt.wantStatus, w.Code)
+			require.Equal(t, tt.wantDenied, l.denied)
+		})
+	}
+}
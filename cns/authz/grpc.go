@@ -0,0 +1,107 @@
+package authz
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// PeerCredInfo is the credentials.AuthInfo of a gRPC connection over a unix socket.
+type PeerCredInfo struct {
+	credentials.CommonAuthInfo
+	PeerCred *PeerCred
+}
+
+// AuthType implements credentials.AuthInfo.
+func (PeerCredInfo) AuthType() string {
+	return "peercred"
+}
+
+type transportCredentials struct {
+	credentials.TransportCredentials
+}
+
+// TransportCredentials wraps the credentials of a gRPC server to add the peer credentials of the connections over
+// a unix socket to their peer, which the interceptors identify the callers by. The handshake of the wrapped
+// credentials must return the connection as is, e.g. insecure credentials.
+func TransportCredentials(creds credentials.TransportCredentials) credentials.TransportCredentials {
+	return &transportCredentials{TransportCredentials: creds}
+}
+
+func (c *transportCredentials) ServerHandshake(raw net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ServerHandshake(raw)
+	if err != nil {
+		return conn, info, err //nolint:wrapcheck // the handshake error is returned to grpc as is
+	}
+	uc, ok := raw.(*net.UnixConn)
+	if !ok {
+		return conn, info, nil
+	}
+	cred, err := peerCredentials(uc)
+	if err != nil {
+		// the caller is then anonymous, which the policy may still allow
+		return conn, info, nil
+	}
+	return conn, PeerCredInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}, PeerCred: cred}, nil
+}
+
+func (c *transportCredentials) Clone() credentials.TransportCredentials {
+	return &transportCredentials{TransportCredentials: c.TransportCredentials.Clone()}
+}
+
+// UnaryServerInterceptor authorizes the unary RPCs by their full method e.g. /cns.CNS/RequestIPConfigs. Denied
+// RPCs are logged, and in Enforce mode rejected with PermissionDenied.
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authorizeRPC(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authorizes the streaming RPCs as UnaryServerInterceptor does.
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorizeRPC(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (a *Authorizer) authorizeRPC(ctx context.Context, method string) error {
+	if a.mode == Disabled {
+		return nil
+	}
+	var id Identity
+	remote := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			remote = p.Addr.String()
+		}
+		switch info := p.AuthInfo.(type) {
+		case PeerCredInfo:
+			id.PeerCred = info.PeerCred
+		case credentials.TLSInfo:
+			if len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+				id.Cert = info.State.VerifiedChains[0][0]
+			}
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if auth := md.Get("authorization"); len(auth) > 0 {
+			id.Token = a.token(auth[0])
+		}
+	}
+	if a.serve(method, method, remote, id) {
+		return nil
+	}
+	return status.Error(codes.PermissionDenied, "permission denied") //nolint:wrapcheck // grpc status error
+}
@@ -0,0 +1,93 @@
+package authz
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	token := writeToken(t, "secret")
+	const method = "/cns.CNS/RequestIPConfigs"
+	tests := []struct {
+		name     string
+		mode     Mode
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{
+			name:     "enforce denies anonymous",
+			mode:     Enforce,
+			ctx:      context.Background(),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "audit serves anonymous",
+			mode: Audit,
+			ctx:  context.Background(),
+		},
+		{
+			name: "root over unix socket",
+			mode: Enforce,
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				Addr:     &net.UnixAddr{Name: "cns-grpc.sock", Net: "unix"},
+				AuthInfo: PeerCredInfo{PeerCred: &PeerCred{UID: 0}},
+			}),
+		},
+		{
+			name: "other user over unix socket",
+			mode: Enforce,
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				AuthInfo: PeerCredInfo{PeerCred: &PeerCred{UID: 1000, GID: 1000}},
+			}),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "token",
+			mode: Enforce,
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := append(DefaultRules(), Rule{Routes: ipamRoutes, Tokens: []string{"cni"}})
+			a, err := New(Config{Mode: tt.mode, Rules: rules, Tokens: map[string]string{"cni": token}}, &testLogger{t: t})
+			require.NoError(t, err)
+
+			served := false
+			_, err = a.UnaryServerInterceptor()(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+				served = true
+				return nil, nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+			require.Equal(t, tt.wantCode == codes.OK, served)
+		})
+	}
+}
+
+func TestTransportCredentialsTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	creds := TransportCredentials(insecure.NewCredentials())
+	_, info, err := creds.ServerHandshake(conn)
+	require.NoError(t, err)
+	_, ok := info.(PeerCredInfo)
+	require.False(t, ok)
+}
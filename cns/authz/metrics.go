@@ -0,0 +1,20 @@
+package authz
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// deniedRequests is a monotonic counter of the requests which the policy denies. In audit mode, a positive rate of
+// change means that enforcing the policy would break a caller.
+var deniedRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cns_authz_denied_requests_total",
+		Help: "Number of requests denied by the authorization policy, by route and mode.",
+	},
+	[]string{"route", "mode"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(deniedRequests)
+}
@@ -0,0 +1,27 @@
+package authz
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials returns the SO_PEERCRED of the connection.
+func peerCredentials(c *net.UnixConn) (*PeerCred, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get raw conn")
+	}
+	var ucred *unix.Ucred
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		ucred, sockErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not control raw conn")
+	}
+	if sockErr != nil {
+		return nil, errors.Wrap(sockErr, "could not get peer credentials")
+	}
+	return &PeerCred{UID: ucred.Uid, GID: ucred.Gid, PID: ucred.Pid}, nil
+}
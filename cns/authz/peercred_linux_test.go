@@ -0,0 +1,50 @@
+package authz
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestConnContextUnixPeerCred(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "cns.sock"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	cred, ok := ConnContext(context.Background(), conn).Value(peerCredKey{}).(*PeerCred)
+	require.True(t, ok)
+	require.Equal(t, uint32(os.Getuid()), cred.UID) //nolint:gosec // uid is non-negative
+	require.Equal(t, uint32(os.Getgid()), cred.GID) //nolint:gosec // gid is non-negative
+	require.Equal(t, int32(os.Getpid()), cred.PID)  //nolint:gosec // pid fits in int32
+}
+
+func TestTransportCredentialsUnix(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "cns-grpc.sock"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, info, err := TransportCredentials(insecure.NewCredentials()).ServerHandshake(conn)
+	require.NoError(t, err)
+	cred, ok := info.(PeerCredInfo)
+	require.True(t, ok)
+	require.Equal(t, uint32(os.Getuid()), cred.PeerCred.UID) //nolint:gosec // uid is non-negative
+}
@@ -0,0 +1,14 @@
+package authz
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+var errPeerCredUnsupported = errors.New("unix socket peer credentials are not supported on windows")
+
+// peerCredentials is not supported on Windows, so callers over a unix socket are identified by mTLS or token only.
+func peerCredentials(*net.UnixConn) (*PeerCred, error) {
+	return nil, errPeerCredUnsupported
+}
@@ -0,0 +1,20 @@
+package authz_test
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
+	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultRulesRoutes checks that the default policy covers the IPAM routes and RPCs which CNS registers.
+func TestDefaultRulesRoutes(t *testing.T) {
+	rules := authz.DefaultRules()
+	require.Len(t, rules, 1)
+	require.ElementsMatch(t, []string{
+		cns.RequestIPConfig, cns.RequestIPConfigs, cns.ReleaseIPConfig, cns.ReleaseIPConfigs,
+		pb.CNS_RequestIPConfigs_FullMethodName, pb.CNS_ReleaseIPConfigs_FullMethodName,
+	}, rules[0].Routes)
+}
@@ -6,19 +6,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
 const (
 	contentTypeJSON = "application/json"
 	defaultBaseURL  = "http://localhost:10090"
+	unixScheme      = "unix://"
+	// unixBaseURL is the base of the routes to a unix socket, as HTTP needs a host.
+	unixBaseURL = "http://localhost"
 	// DefaultTimeout default timeout duration for CNS Client.
 	DefaultTimeout    = 5 * time.Second
 	headerContentType = "Content-Type"
@@ -33,9 +39,12 @@ var clientPaths = []string{
 	cns.RequestIPConfigs,
 	cns.ReleaseIPConfig,
 	cns.ReleaseIPConfigs,
+	cns.GetIPConfigs,
 	cns.PathDebugIPAddresses,
 	cns.PathDebugPodContext,
 	cns.PathDebugRestData,
+	cns.PathDebugIPHistory,
+	cns.PathDebugConfig,
 	cns.UnpublishNetworkContainer,
 	cns.PublishNetworkContainer,
 	cns.CreateOrUpdateNetworkContainer,
@@ -67,11 +76,18 @@ func (e *ConnectionFailureErr) Error() string {
 }
 
 // New returns a new CNS client configured with the passed URL and timeout.
+// A unix:// URL e.g. unix:///var/run/azure-cns/cns.sock connects to the CNS unix socket at its path.
 func New(baseURL string, requestTimeout time.Duration) (*Client, error) {
 	if baseURL == "" {
 		baseURL = defaultBaseURL
 	}
 
+	transport := http.DefaultTransport
+	if socketPath, ok := unixSocketPath(baseURL); ok {
+		baseURL = unixBaseURL
+		transport = unixTransport(socketPath)
+	}
+
 	routes, err := buildRoutes(baseURL, clientPaths)
 	if err != nil {
 		return nil, err
@@ -80,11 +96,31 @@ func New(baseURL string, requestTimeout time.Duration) (*Client, error) {
 	return &Client{
 		client: &http.Client{
 			Timeout: requestTimeout,
+			// each request is a span of the caller's trace, which CNS continues from the traceparent header
+			Transport: tracing.NewTransport(transport),
 		},
 		routes: routes,
 	}, nil
 }
 
+// unixSocketPath returns the socket path of a unix:// URL.
+func unixSocketPath(baseURL string) (string, bool) {
+	if !strings.HasPrefix(baseURL, unixScheme) {
+		return "", false
+	}
+	return strings.TrimPrefix(baseURL, unixScheme), true
+}
+
+// unixTransport dials the unix socket for every request, whatever the host of its URL.
+func unixTransport(socketPath string) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // the default transport is an *http.Transport
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socketPath)
+	}
+	return transport
+}
+
 func buildRoutes(baseURL string, paths []string) (map[string]url.URL, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
@@ -488,6 +524,52 @@ func (c *Client) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRequest)
 	return nil
 }
 
+// GetIPConfigs calls GetIPConfigs in CNS, which returns the IPs assigned to the pod without assigning any
+func (c *Client) GetIPConfigs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(ipconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode IPConfigsRequest")
+	}
+
+	u := c.routes[cns.GetIPConfigs]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, &ConnectionFailureErr{
+			cause: err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, &CNSClientError{
+			Code: types.UnsupportedAPI,
+			Err:  errors.Errorf("Unsupported API"),
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var response cns.IPConfigsResponse
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode IPConfigsResponse")
+	}
+
+	if response.Response.ReturnCode != 0 {
+		return nil, errors.New(response.Response.Message)
+	}
+
+	return &response, nil
+}
+
 // GetIPAddressesMatchingStates takes a variadic number of string parameters, to get all IP Addresses matching a number of states
 // usage GetIPAddressesWithStates(ctx, types.Available...)
 func (c *Client) GetIPAddressesMatchingStates(ctx context.Context, stateFilter ...types.IPState) ([]cns.IPConfigurationStatus, error) {
@@ -533,6 +615,75 @@ func (c *Client) GetIPAddressesMatchingStates(ctx context.Context, stateFilter .
 	return resp.IPConfigurationStatus, nil
 }
 
+// GetIPHistory returns the pods which held the IP address, most recent first.
+func (c *Client) GetIPHistory(ctx context.Context, ipAddress string) ([]cns.IPOwner, error) {
+	payload := cns.GetIPHistoryRequest{
+		IPAddress: ipAddress,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(payload); err != nil {
+		return nil, errors.Wrap(err, "failed to encode GetIPHistoryRequest")
+	}
+
+	u := c.routes[cns.PathDebugIPHistory]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.GetIPHistoryResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode GetIPHistoryResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, errors.New(resp.Response.Message)
+	}
+
+	return resp.Owners, nil
+}
+
+// GetConfig returns the effective CNS config and the result of its last reload.
+func (c *Client) GetConfig(ctx context.Context) (*cns.GetConfigResponse, error) {
+	u := c.routes[cns.PathDebugConfig]
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.GetConfigResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode GetConfigResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, errors.New(resp.Response.Message)
+	}
+
+	return &resp, nil
+}
+
 // GetPodOrchestratorContext calls GetPodIpOrchestratorContext API on CNS
 func (c *Client) GetPodOrchestratorContext(ctx context.Context) (map[string][]string, error) {
 	u := c.routes[cns.PathDebugPodContext]
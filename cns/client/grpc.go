@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	cnsgrpc "github.com/Azure/azure-container-networking/cns/grpc"
+	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCClient is a CNS client which calls the IPAM and endpoint state APIs over the CNS gRPC service.
+// All other APIs are served by the embedded HTTP Client.
+type GRPCClient struct {
+	*Client
+	conn           *grpc.ClientConn
+	cns            pb.CNSClient
+	requestTimeout time.Duration
+}
+
+// NewGRPC returns a new CNS client which calls the IPAM and endpoint state APIs on the gRPC
+// service at grpcAddress, and all other APIs on the HTTP service at baseURL.
+func NewGRPC(baseURL, grpcAddress string, requestTimeout time.Duration) (*GRPCClient, error) {
+	c, err := New(baseURL, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create gRPC client for %s", grpcAddress)
+	}
+	return &GRPCClient{
+		Client:         c,
+		conn:           conn,
+		cns:            pb.NewCNSClient(conn),
+		requestTimeout: requestTimeout,
+	}, nil
+}
+
+// Close closes the underlying gRPC connection.
+func (c *GRPCClient) Close() error {
+	return errors.Wrap(c.conn.Close(), "failed to close gRPC connection")
+}
+
+// withTimeout applies the client request timeout to the context, as the http.Client does for the HTTP APIs.
+func (c *GRPCClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// RequestIPs calls the RequestIPConfigs RPC in CNS
+func (c *GRPCClient) RequestIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	var err error
+	defer func() {
+		if err != nil {
+			if e := c.ReleaseIPs(ctx, ipconfig); e != nil {
+				err = errors.Wrap(e, err.Error())
+			}
+		}
+	}()
+
+	rctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.RequestIPConfigs(rctx, cnsgrpc.IPConfigsRequestToPB(ipconfig))
+	if err != nil {
+		err = grpcError(err)
+		return nil, err
+	}
+
+	response := cnsgrpc.IPConfigsResponseFromPB(res)
+	if response.Response.ReturnCode != 0 {
+		err = errors.New(response.Response.Message)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ReleaseIPs calls the ReleaseIPConfigs RPC in CNS
+func (c *GRPCClient) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.ReleaseIPConfigs(ctx, cnsgrpc.IPConfigsRequestToPB(ipconfig))
+	if err != nil {
+		return grpcError(err)
+	}
+
+	if res.GetResponse().GetReturnCode() != 0 {
+		return errors.New(res.GetResponse().GetMessage())
+	}
+
+	return nil
+}
+
+// GetIPAddressesMatchingStates calls the GetIPAddressesMatchingStates RPC in CNS
+func (c *GRPCClient) GetIPAddressesMatchingStates(ctx context.Context, stateFilter ...types.IPState) ([]cns.IPConfigurationStatus, error) {
+	if len(stateFilter) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.GetIPAddressesMatchingStates(ctx, &pb.GetIPAddressesRequest{IpConfigStateFilter: cnsgrpc.IPStatesToPB(stateFilter)})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if res.GetResponse().GetReturnCode() != 0 {
+		return nil, errors.New(res.GetResponse().GetMessage())
+	}
+
+	ipConfigs := make([]cns.IPConfigurationStatus, len(res.GetIpConfigurationStatus()))
+	for i, s := range res.GetIpConfigurationStatus() {
+		ipConfigs[i] = cnsgrpc.IPConfigurationStatusFromPB(s)
+	}
+	return ipConfigs, nil
+}
+
+// GetEndpoint calls the GetEndpoint RPC in CNS to retrieve the state of a given EndpointID
+func (c *GRPCClient) GetEndpoint(ctx context.Context, endpointID string) (*restserver.GetEndpointResponse, error) {
+	var response restserver.GetEndpointResponse
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.GetEndpoint(ctx, &pb.GetEndpointRequest{EndpointID: endpointID})
+	if err != nil {
+		response.Response.ReturnCode = types.UnexpectedError
+		if status.Code(err) == codes.Unavailable {
+			response.Response.ReturnCode = types.ConnectionError
+		}
+		return &response, grpcError(err)
+	}
+
+	r := cnsgrpc.ResponseFromPB(res.GetResponse())
+	response.Response = restserver.Response{ReturnCode: r.ReturnCode, Message: r.Message}
+	if response.Response.ReturnCode != 0 {
+		return &response, errors.New(response.Response.Message)
+	}
+
+	if response.EndpointInfo, err = cnsgrpc.EndpointInfoFromPB(res.GetEndpointInfo()); err != nil {
+		response.Response.ReturnCode = types.UnexpectedError
+		return &response, errors.Wrap(err, "failed to decode GetEndpointResponse")
+	}
+
+	return &response, nil
+}
+
+// UpdateEndpoint calls the UpdateEndpoint RPC in CNS
+// to update the state of a given EndpointID with either HNSEndpointID or HostVethName
+func (c *GRPCClient) UpdateEndpoint(ctx context.Context, endpointID string, ipInfo map[string]*restserver.IPInfo) (*cns.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.UpdateEndpoint(ctx, &pb.UpdateEndpointRequest{
+		EndpointID:    endpointID,
+		IfnameToIPMap: cnsgrpc.IPInfoMapToPB(ipInfo),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response := cnsgrpc.ResponseFromPB(res.GetResponse())
+	if response.ReturnCode != 0 {
+		return nil, errors.New(response.Message)
+	}
+
+	return &response, nil
+}
+
+// DeleteEndpointState calls the DeleteEndpoint RPC in CNS to delete the state of a given EndpointID(containerID)
+func (c *GRPCClient) DeleteEndpointState(ctx context.Context, endpointID string) (*cns.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res, err := c.cns.DeleteEndpoint(ctx, &pb.DeleteEndpointRequest{EndpointID: endpointID})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response := cnsgrpc.ResponseFromPB(res.GetResponse())
+	if response.ReturnCode != 0 {
+		return nil, errors.New(response.Message)
+	}
+
+	return &response, nil
+}
+
+// ErrResourceVersionExpired is returned by WatchState when CNS no longer retains the events after the requested
+// resource version. The caller should list the current state and watch again from resource version 0.
+var ErrResourceVersionExpired = errors.New("resource version expired")
+
+// WatchState calls the WatchState RPC in CNS and invokes handler with every IP pool and endpoint state change after
+// resourceVersion, until the context is cancelled, the handler returns an error or the stream ends.
+// A resourceVersion of 0 starts with an event for every IP and endpoint currently in CNS.
+// To resume after an error, call WatchState again with the ResourceVersion of the last handled event.
+func (c *GRPCClient) WatchState(ctx context.Context, resourceVersion uint64, handler func(cns.StateEvent) error) error {
+	// cancel the stream when returning because of the handler.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.cns.WatchState(ctx, &pb.WatchStateRequest{ResourceVersion: resourceVersion})
+	if err != nil {
+		return grpcError(err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err() //nolint:wrapcheck // context error
+			}
+			if status.Code(err) == codes.OutOfRange {
+				return errors.Wrap(ErrResourceVersionExpired, status.Convert(err).Message())
+			}
+			return grpcError(err)
+		}
+		if err := handler(cnsgrpc.StateEventFromPB(event)); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcError maps gRPC transport errors to the errors returned by the HTTP client,
+// so that callers can handle both clients the same way.
+func grpcError(err error) error {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return &ConnectionFailureErr{cause: err}
+	case codes.Unimplemented:
+		return &CNSClientError{
+			Code: types.UnsupportedAPI,
+			Err:  errors.Errorf("Unsupported API"),
+		}
+	default:
+		return errors.Wrap(err, "grpc request failed")
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	cnsgrpc "github.com/Azure/azure-container-networking/cns/grpc"
+	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient serves the passed CNS gRPC service over an in-memory listener and returns a GRPCClient connected to it.
+func newTestGRPCClient(t *testing.T, srv pb.CNSServer) *GRPCClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pb.RegisterCNSServer(server, srv)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	c, err := New("", 2*time.Hour)
+	require.NoError(t, err)
+	client := &GRPCClient{Client: c, conn: conn, cns: pb.NewCNSClient(conn), requestTimeout: 2 * time.Hour}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestGRPCClientRequestAndRelease(t *testing.T) {
+	cnsClient := newTestGRPCClient(t, &cnsgrpc.CNS{Logger: zap.NewNop(), State: svc})
+
+	addTestStateToRestServer(t, []string{primaryIP})
+
+	orchestratorContext, err := json.Marshal(cns.KubernetesPodInfo{PodName: testpodname, PodNamespace: testpodnamespace})
+	require.NoError(t, err)
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:      "grpc-eth0",
+		InfraContainerID:    "grpc",
+		OrchestratorContext: orchestratorContext,
+	}
+
+	// no IP reservation found with that context, expect no failure.
+	require.NoError(t, cnsClient.ReleaseIPs(context.TODO(), req), "Release ip idempotent call failed")
+
+	resp, err := cnsClient.RequestIPs(context.TODO(), req)
+	require.NoError(t, err, "get IP from CNS failed")
+	require.Len(t, resp.PodIPInfo, 1)
+	podIPInfo := resp.PodIPInfo[0]
+	assert.Equal(t, primaryIP, podIPInfo.PodIPConfig.IPAddress)
+	assert.Equal(t, primaryIP, podIPInfo.NetworkContainerPrimaryIPConfig.IPSubnet.IPAddress)
+	assert.EqualValues(t, subnetPrfixLength, podIPInfo.NetworkContainerPrimaryIPConfig.IPSubnet.PrefixLength)
+	assert.Equal(t, dnsServers, podIPInfo.NetworkContainerPrimaryIPConfig.DNSServers)
+	assert.Equal(t, gatewayIP, podIPInfo.NetworkContainerPrimaryIPConfig.GatewayIPAddress)
+
+	ipaddresses, err := cnsClient.GetIPAddressesMatchingStates(context.TODO(), types.Assigned)
+	require.NoError(t, err)
+	require.Len(t, ipaddresses, 1)
+	assert.Equal(t, primaryIP, ipaddresses[0].IPAddress)
+	assert.Equal(t, types.Assigned, ipaddresses[0].GetState())
+	require.NotNil(t, ipaddresses[0].PodInfo)
+	assert.Equal(t, testpodname, ipaddresses[0].PodInfo.Name())
+	assert.Equal(t, "grpc", ipaddresses[0].PodInfo.InfraContainerID())
+
+	require.NoError(t, cnsClient.ReleaseIPs(context.TODO(), req))
+
+	ipaddresses, err = cnsClient.GetIPAddressesMatchingStates(context.TODO(), types.Assigned)
+	require.NoError(t, err)
+	assert.Empty(t, ipaddresses)
+}
+
+func TestGRPCClientEndpointState(t *testing.T) {
+	// the test service does not manage the endpoint state, so the endpoint RPCs are rejected.
+	cnsClient := newTestGRPCClient(t, &cnsgrpc.CNS{Logger: zap.NewNop(), State: svc})
+
+	resp, err := cnsClient.GetEndpoint(context.TODO(), "0123456789abcdef")
+	require.Error(t, err)
+	assert.Equal(t, types.UnexpectedError, resp.Response.ReturnCode)
+
+	_, err = cnsClient.DeleteEndpointState(context.TODO(), "0123456789abcdef")
+	require.Error(t, err)
+}
+
+func TestGRPCClientUnsupportedAPI(t *testing.T) {
+	cnsClient := newTestGRPCClient(t, &pb.UnimplementedCNSServer{})
+
+	_, err := cnsClient.RequestIPs(context.TODO(), cns.IPConfigsRequest{})
+	assert.True(t, IsUnsupportedAPI(err), "expected unsupported API error, got %v", err)
+
+	err = cnsClient.ReleaseIPs(context.TODO(), cns.IPConfigsRequest{})
+	assert.True(t, IsUnsupportedAPI(err), "expected unsupported API error, got %v", err)
+}
+
+func TestGRPCClientWatchState(t *testing.T) {
+	cnsClient := newTestGRPCClient(t, &cnsgrpc.CNS{Logger: zap.NewNop(), State: svc})
+
+	addTestStateToRestServer(t, []string{primaryIP})
+
+	// the watch starts with the current state, stop after the first event.
+	errStop := errors.New("stop")
+	var first cns.StateEvent
+	err := cnsClient.WatchState(context.TODO(), 0, func(e cns.StateEvent) error {
+		first = e
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.NotZero(t, first.ResourceVersion)
+	assert.NotEmpty(t, first.Kind)
+
+	// resource versions which are not retained can't be resumed from.
+	err = cnsClient.WatchState(context.TODO(), 1, func(cns.StateEvent) error { return nil })
+	require.ErrorIs(t, err, ErrResourceVersionExpired)
+}
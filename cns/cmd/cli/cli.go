@@ -1,30 +1,45 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/platform"
 )
 
 const (
 	envCNSIPAddress = "CNSIpAddress"
 	envCNSPort      = "CNSPort"
+	envCNSURL       = "CNSUrl" // e.g. unix:///var/run/azure-cns/cns.sock, in place of the IP address and port
+	configCmdArg    = "config"
 	getCmdArg       = "get"
 	getInMemoryData = "getInMemory"
 	getPodCmdArg    = "getPodContexts"
+	historyCmdArg   = "history"
+	journalCmdArg   = "journal"
+
+	defaultJournalPath = platform.CNMRuntimePath + "azure-cns.journal"
 )
 
 func HandleCNSClientCommands(ctx context.Context, cmd string, arg string) error {
 	cnsIPAddress := os.Getenv(envCNSIPAddress)
 	cnsPort := os.Getenv(envCNSPort)
+	cnsURL := os.Getenv(envCNSURL)
+	if cnsURL == "" {
+		cnsURL = "http://" + cnsIPAddress + ":" + cnsPort
+	}
 
-	cnsClient, err := client.New("http://"+cnsIPAddress+":"+cnsPort, client.DefaultTimeout)
+	cnsClient, err := client.New(cnsURL, client.DefaultTimeout)
 	if err != nil {
 		return err
 	}
@@ -36,8 +51,14 @@ func HandleCNSClientCommands(ctx context.Context, cmd string, arg string) error
 		return getPodCmd(ctx, cnsClient)
 	case strings.EqualFold(getInMemoryData, cmd):
 		return getInMemory(ctx, cnsClient)
+	case strings.EqualFold(historyCmdArg, cmd):
+		return historyCmd(ctx, cnsClient, arg)
+	case strings.EqualFold(journalCmdArg, cmd):
+		return journalCmd(arg)
+	case strings.EqualFold(configCmdArg, cmd):
+		return configCmd(ctx, cnsClient)
 	default:
-		return fmt.Errorf("No debug cmd supplied, options are: %v", getCmdArg)
+		return fmt.Errorf("No debug cmd supplied, options are: %v", []string{getCmdArg, getPodCmdArg, getInMemoryData, historyCmdArg, journalCmdArg, configCmdArg})
 	}
 }
 
@@ -53,8 +74,10 @@ func getCmd(ctx context.Context, client *client.Client, arg string) error {
 		states = append(states, types.PendingProgramming)
 	case types.PendingRelease:
 		states = append(states, types.PendingRelease)
+	case types.Cooling:
+		states = append(states, types.Cooling)
 	default:
-		states = append(states, types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease)
+		states = append(states, types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease, types.Cooling)
 	}
 
 	addr, err := client.GetIPAddressesMatchingStates(ctx, states...)
@@ -99,3 +122,69 @@ func getInMemory(ctx context.Context, client *client.Client) error {
 		data.HTTPRestServiceData.PodIPIDByPodInterfaceKey, data.HTTPRestServiceData.PodIPConfigState)
 	return nil
 }
+
+// historyCmd prints the pods which held the IP address, most recent first.
+func historyCmd(ctx context.Context, client *client.Client, ip string) error {
+	if ip == "" {
+		return fmt.Errorf("%s requires an IP address", historyCmdArg)
+	}
+
+	owners, err := client.GetIPHistory(ctx, ip)
+	if err != nil {
+		return err
+	}
+
+	if len(owners) == 0 {
+		fmt.Printf("No pod has held %s since CNS started\n", ip)
+		return nil
+	}
+	for _, owner := range owners {
+		released := "now"
+		if !owner.ReleasedAt.IsZero() {
+			released = owner.ReleasedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%s - %s %s/%s infra %s interface %s (%s in %s)\n", owner.AssignedAt.Format(time.RFC3339), released,
+			owner.PodNamespace, owner.PodName, owner.InfraContainerID, owner.InterfaceID, owner.IPConfigID, owner.NCID)
+	}
+	return nil
+}
+
+// journalCmd prints the IPAM journal at path, or at the default location, as the history of the IPAM mutations.
+func journalCmd(path string) error {
+	if path == "" {
+		path = defaultJournalPath
+	}
+	entries, err := journal.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		fmt.Println(entries[i].String())
+	}
+	return nil
+}
+
+// configCmd prints the effective CNS config and the result of its last reload.
+func configCmd(ctx context.Context, client *client.Client) error {
+	resp, err := client.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+	var config bytes.Buffer
+	if err = json.Indent(&config, resp.Config, "", "  "); err != nil {
+		return fmt.Errorf("failed to format config: %w", err)
+	}
+	fmt.Println(config.String())
+
+	if resp.LastReload == nil {
+		fmt.Println("Config not reloaded since CNS started")
+		return nil
+	}
+	reload := resp.LastReload
+	if reload.Error != "" {
+		fmt.Printf("Last reload at %s rejected: %s\n", reload.Time.Format(time.RFC3339), reload.Error)
+		return nil
+	}
+	fmt.Printf("Last reload at %s applied %v, requires restart %v\n", reload.Time.Format(time.RFC3339), reload.Applied, reload.RequiresRestart)
+	return nil
+}
@@ -0,0 +1,97 @@
+// poolsim simulates the CNS IPAM pool monitors against a pod trace, to compare Scaler settings and scaling
+// strategies offline.
+//
+//	poolsim -trace rollout.txt -batch 16 -request-threshold 50 -release-threshold 150 -mode all
+//
+// The trace has one event per line, formatted as "<offset> <+N|-N>" for N pods arriving or departing at the offset
+// from the start of the simulation.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/ipampool/sim"
+	v2 "github.com/Azure/azure-container-networking/cns/ipampool/v2"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		cfg    sim.Config
+		trace  = flag.String("trace", "", "path to the pod trace, or - for stdin")
+		modes  = flag.String("mode", "all", "comma separated monitors to simulate: v1, v2, v2-predictive or all")
+		logDir = flag.String("log-dir", os.TempDir(), "directory of the v1 monitor log file")
+	)
+	flag.Int64Var(&cfg.Scaler.BatchSize, "batch", 16, "scaler batch size")
+	flag.Int64Var(&cfg.Scaler.RequestThresholdPercent, "request-threshold", 50, "scaler request threshold percent")
+	flag.Int64Var(&cfg.Scaler.ReleaseThresholdPercent, "release-threshold", 150, "scaler release threshold percent")
+	flag.Int64Var(&cfg.Scaler.MaxIPCount, "max-ips", 250, "scaler max IP count")
+	flag.Int64Var(&cfg.InitialIPs, "initial-ips", 0, "IPs allocated to the node at the start, defaults to a batch")
+	flag.DurationVar(&cfg.AllocationLatency, "latency", sim.DefaultAllocationLatency, "delay for the control plane to allocate or release IPs")
+	flag.DurationVar(&cfg.Tick, "tick", sim.DefaultTick, "step of the simulation clock")
+	flag.DurationVar(&cfg.Duration, "duration", 0, "simulated time, defaults to the end of the trace plus 10m")
+	flag.DurationVar(&cfg.Predictive.Window, "predictive-window", v2.DefaultPredictiveWindow, "predictive strategy growth rate window")
+	flag.DurationVar(&cfg.Predictive.Horizon, "predictive-horizon", v2.DefaultPredictiveHorizon, "predictive strategy horizon")
+	flag.DurationVar(&cfg.Predictive.ReleaseDelay, "predictive-release-delay", v2.DefaultPredictiveReleaseDelay, "predictive strategy release delay")
+	flag.Parse()
+
+	if *trace == "" {
+		return errors.New("-trace is required")
+	}
+	events, err := readTrace(*trace)
+	if err != nil {
+		return err
+	}
+
+	selected := sim.Modes
+	if *modes != "all" {
+		selected = nil
+		for _, mode := range strings.Split(*modes, ",") {
+			selected = append(selected, sim.Mode(strings.TrimSpace(mode)))
+		}
+	}
+
+	// the v1 monitor logs every reconcile to the CNS logger, keep it out of the report.
+	logger.InitLogger("poolsim", log.LevelInfo, log.TargetLogfile, *logDir)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODE\tPODS\tWITHOUT IP\tTIME-TO-IP MEAN\tP50\tP99\tMAX\tNNC PATCHES\tPEAK OVER-ALLOCATION\tEXHAUSTION EVENTS\tRECONCILE ERRORS")
+	for _, mode := range selected {
+		cfg.Mode = mode
+		report, simErr := sim.Run(context.Background(), cfg, events)
+		if simErr != nil {
+			return errors.Wrapf(simErr, "failed to simulate %s", mode)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n", report.Mode, report.Pods, report.PodsWithoutIP,
+			report.TimeToIPMean.Round(time.Millisecond), report.TimeToIPP50, report.TimeToIPP99, report.TimeToIPMax,
+			report.NNCPatches, report.PeakOverAllocation, report.ExhaustionEvents, report.ReconcileErrors)
+	}
+	return errors.Wrap(w.Flush(), "failed to write report")
+}
+
+func readTrace(path string) ([]sim.Event, error) {
+	if path == "-" {
+		return sim.ParseTrace(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open trace %s", path)
+	}
+	defer f.Close()
+	return sim.ParseTrace(f)
+}
@@ -92,6 +92,8 @@ func (s *TelemetrySidecar) buildTelemetryConfig(cnsConfig *configuration.CNSConf
 		DebugMode:                     ts.DebugMode,
 		GetEnvRetryCount:              defaultGetEnvRetryCount,
 		GetEnvRetryWaitTimeInSecs:     defaultGetEnvRetryWaitTimeInSecs,
+		DisableAppInsights:            ts.DisableAppInsights,
+		OTLP:                          ts.OTLP,
 	}
 }
 
@@ -142,25 +144,32 @@ func (s *TelemetrySidecar) startTelemetryService(ctx context.Context, config tel
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	if telemetry.GetAIMetadata() != "" {
-		aiConfig := aitelemetry.AIConfig{
-			AppName:                      pluginName,
-			AppVersion:                   s.version,
-			BatchSize:                    config.BatchSizeInBytes,
-			BatchInterval:                config.BatchIntervalInSecs,
-			RefreshTimeout:               config.RefreshTimeoutInSecs,
-			DisableMetadataRefreshThread: config.DisableMetadataThread,
-			DebugMode:                    config.DebugMode,
-			GetEnvRetryCount:             config.GetEnvRetryCount,
-			GetEnvRetryWaitTimeInSecs:    config.GetEnvRetryWaitTimeInSecs,
-		}
+	aiConfig := aitelemetry.AIConfig{
+		AppName:                      pluginName,
+		AppVersion:                   s.version,
+		BatchSize:                    config.BatchSizeInBytes,
+		BatchInterval:                config.BatchIntervalInSecs,
+		RefreshTimeout:               config.RefreshTimeoutInSecs,
+		DisableMetadataRefreshThread: config.DisableMetadataThread,
+		DebugMode:                    config.DebugMode,
+		GetEnvRetryCount:             config.GetEnvRetryCount,
+		GetEnvRetryWaitTimeInSecs:    config.GetEnvRetryWaitTimeInSecs,
+	}
+	appInsightsEnabled := telemetry.GetAIMetadata() != "" && !config.DisableAppInsights
+	if appInsightsEnabled {
 		if err := s.telemetryBuffer.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric); err != nil {
 			s.logger.Warn("AppInsights initialization failed, continuing without it", zap.Error(err))
 		}
 	}
+	if config.OTLP != nil {
+		if err := s.telemetryBuffer.CreateOTLPTelemetryHandle(*config.OTLP, aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric); err != nil {
+			s.logger.Warn("OTLP initialization failed, continuing without it", zap.Error(err))
+		}
+	}
 
 	s.logger.Info("Telemetry service started",
-		zap.Bool("appInsightsEnabled", telemetry.GetAIMetadata() != ""))
+		zap.Bool("appInsightsEnabled", appInsightsEnabled),
+		zap.Bool("otlpEnabled", config.OTLP != nil))
 
 	go s.telemetryBuffer.PushData(ctx)
 	return nil
@@ -4,8 +4,11 @@
 package common
 
 import (
+	"context"
 	"errors"
+	"os"
 
+	"github.com/Azure/azure-container-networking/cns/authz"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	acn "github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/server/tls"
@@ -44,6 +47,12 @@ type ServiceConfig struct {
 	ChannelMode string
 	TLSSettings tls.TlsSettings
 	Logger      *zap.Logger
+	Authorizer  *authz.Authorizer
+	// UnixSocketPath, if set, also serves the API on a unix socket with UnixSocketMode.
+	UnixSocketPath string
+	UnixSocketMode os.FileMode
+	// Ctx ends the background work of the service, e.g. reloading the TLS certificate, when it is done.
+	Ctx context.Context
 }
 
 // server struct to store primaryInterfaceIP from VM, port where customer provides by -p and temporary flag EnableLocalServer
@@ -7,12 +7,16 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	loggerv2 "github.com/Azure/azure-container-networking/cns/logger/v2"
 	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -25,12 +29,14 @@ const (
 type CNSConfig struct {
 	AZRSettings                     AZRSettings
 	AsyncPodDeletePath              string
+	Authorization                   authz.Config
 	CNIConflistFilepath             string
 	CNIConflistScenario             string
 	ChannelMode                     string
 	EnableAPIServerHealthPing       bool
 	EnableAsyncPodDelete            bool
 	EnableCNIConflistGeneration     bool
+	EnableIPAMJournal               bool
 	EnableIPAMv2                    bool
 	EnableK8sDevicePlugin           bool
 	EnableLoggerV2                  bool
@@ -40,6 +46,8 @@ type CNSConfig struct {
 	EnableStaleHNSCleanupOnNCCreate bool
 	EnableSwiftV1DualStack          bool
 	EnableSwiftV2                   bool
+	EndpointReconcileIntervalSecs   int
+	IPQuarantineSecs                int
 	IPv6PrefixClamp                 int
 	InitializeFromCNI               bool
 	KeyVaultSettings                KeyVaultSettings
@@ -49,7 +57,10 @@ type CNSConfig struct {
 	ManagedSettings                 ManagedSettings
 	MellanoxMonitorIntervalSecs     int
 	MetricsBindAddress              string
+	PredictiveScalingSettings       PredictiveScalingSettings
 	ProgramSNATIPTables             bool
+	SNATBackend                     string
+	StoreBackend                    string
 	SyncHostNCTimeoutMs             int
 	SyncHostNCVersionIntervalMs     int
 	TLSCertificatePath              string
@@ -57,6 +68,7 @@ type CNSConfig struct {
 	TLSPort                         string
 	TLSSubjectName                  string
 	TelemetrySettings               TelemetrySettings
+	UnixSocketSettings              UnixSocketSettings
 	UseHTTPS                        bool
 	UseMTLS                         bool
 	WatchPods                       bool `json:"-"`
@@ -64,6 +76,7 @@ type CNSConfig struct {
 	GRPCSettings                    GRPCSettings
 	MinTLSVersion                   string
 	MtlsClientCertSubjectName       string
+	MtlsClientCAPath                string
 }
 
 type TelemetrySettings struct {
@@ -93,6 +106,12 @@ type TelemetrySettings struct {
 	ConfigSnapshotIntervalInMins int
 	// AppInsightsInstrumentationKey allows the user to override the default appinsights ikey
 	AppInsightsInstrumentationKey string
+	// DisableAppInsights stops sending telemetry to Application Insights e.g. when it is only sent to OTLP
+	DisableAppInsights bool
+	// OTLP exports the telemetry to an OpenTelemetry receiver, in place of or alongside Application Insights
+	OTLP *otlptelemetry.Config `json:",omitempty"`
+	// Tracing exports the spans of the CNS requests, continuing the traces of the CNI plugin, to an OpenTelemetry receiver
+	Tracing *tracing.Config `json:",omitempty"`
 }
 
 type ManagedSettings struct {
@@ -116,12 +135,42 @@ type KeyVaultSettings struct {
 	RefreshIntervalInHrs int
 }
 
+// PredictiveScalingSettings configures the IPAMv2 pool to request IPs ahead of a growing pod demand.
+// Unset durations take the pool monitor defaults.
+type PredictiveScalingSettings struct {
+	Enable           bool
+	WindowSecs       int
+	HorizonSecs      int
+	ReleaseDelaySecs int
+}
+
 type GRPCSettings struct {
 	Enable    bool
 	IPAddress string
 	Port      uint16
 }
 
+// UnixSocketSettings serve the CNS API on unix sockets, in addition to TCP. Only local callers with the permissions
+// of FileMode can connect to them, and their peer credentials identify them to the authorization policy.
+type UnixSocketSettings struct {
+	Enable bool
+	// Path is the socket of the REST API.
+	Path string
+	// GRPCPath is the socket of the gRPC API, when GRPCSettings.Enable is set.
+	GRPCPath string
+	// FileMode is the octal file mode of the sockets.
+	FileMode string
+}
+
+// Mode returns the FileMode of the sockets.
+func (s *UnixSocketSettings) Mode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s.FileMode, 8, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid unix socket file mode %s", s.FileMode)
+	}
+	return os.FileMode(mode), nil
+}
+
 func getConfigFilePath(cmdPath string) (string, error) {
 	// If config path is set from cmd line, return that.
 	if strings.TrimSpace(cmdPath) != "" {
@@ -210,12 +259,25 @@ func setKeyVaultSettingsDefaults(kvs *KeyVaultSettings) {
 	}
 }
 
+func setUnixSocketSettingsDefaults(uss *UnixSocketSettings) {
+	if uss.Path == "" {
+		uss.Path = "/var/run/azure-cns/cns.sock"
+	}
+	if uss.GRPCPath == "" {
+		uss.GRPCPath = "/var/run/azure-cns/cns-grpc.sock"
+	}
+	if uss.FileMode == "" {
+		uss.FileMode = "0600"
+	}
+}
+
 // SetCNSConfigDefaults set default values of CNS config if not specified
 func SetCNSConfigDefaults(config *CNSConfig) {
 	setTelemetrySettingDefaults(&config.TelemetrySettings)
 	setManagedSettingDefaults(&config.ManagedSettings)
 	setKeyVaultSettingsDefaults(&config.KeyVaultSettings)
 	setAZRSettingsDefaults(&config.AZRSettings)
+	setUnixSocketSettingsDefaults(&config.UnixSocketSettings)
 
 	if config.ChannelMode == "" {
 		config.ChannelMode = cns.Direct
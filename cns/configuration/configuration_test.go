@@ -226,6 +226,11 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				},
 				MinTLSVersion:             "TLS 1.2",
 				MtlsClientCertSubjectName: "",
+				UnixSocketSettings: UnixSocketSettings{
+					Path:     "/var/run/azure-cns/cns.sock",
+					GRPCPath: "/var/run/azure-cns/cns-grpc.sock",
+					FileMode: "0600",
+				},
 			},
 		},
 		{
@@ -259,6 +264,12 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				},
 				MinTLSVersion:             "TLS 1.3",
 				MtlsClientCertSubjectName: "example.com",
+				UnixSocketSettings: UnixSocketSettings{
+					Enable:   true,
+					Path:     "/run/cns/api.sock",
+					GRPCPath: "/run/cns/grpc.sock",
+					FileMode: "0660",
+				},
 			},
 			want: CNSConfig{
 				ChannelMode: "Other",
@@ -291,6 +302,12 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				},
 				MinTLSVersion:             "TLS 1.3",
 				MtlsClientCertSubjectName: "example.com",
+				UnixSocketSettings: UnixSocketSettings{
+					Enable:   true,
+					Path:     "/run/cns/api.sock",
+					GRPCPath: "/run/cns/grpc.sock",
+					FileMode: "0660",
+				},
 			},
 		},
 	}
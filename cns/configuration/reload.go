@@ -0,0 +1,240 @@
+package configuration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	acnfs "github.com/Azure/azure-container-networking/internal/fs"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ReloadableFields are the CNSConfig fields, by their path in the config, which are applied without restarting CNS
+// when the config file changes. Changes to any other field are reported as requiring a restart.
+var ReloadableFields = []string{
+	"EnablePprof",
+	"Logger.Level",
+	"MtlsClientCertSubjectName",
+	"SyncHostNCVersionIntervalMs",
+	"TelemetrySettings.ConfigSnapshotIntervalInMins",
+	"TelemetrySettings.HeartBeatIntervalInMins",
+	"TelemetrySettings.SnapshotIntervalInMins",
+}
+
+// Reloader watches the CNS config file and applies the changes to the ReloadableFields.
+//
+// The Reloader reads the config file itself when it is created, rather than sharing the CNSConfig CNS started with,
+// as parts of that config are modified by CNS while it starts.
+type Reloader struct {
+	sync.RWMutex
+	path string
+	// overrides sets the fields which CNS takes from its command line rather than the config file.
+	overrides func(*CNSConfig)
+	// content is the content of the config file last read.
+	content []byte
+	// startup is the config CNS started with.
+	startup *CNSConfig
+	// file is the config last read from the file and validated.
+	file *CNSConfig
+	// effective is the startup config with the reloadable fields of the file config applied.
+	effective *CNSConfig
+	last      *cns.ConfigReloadResult
+	hooks     []func(old, updated *CNSConfig)
+	now       func() time.Time
+}
+
+// NewReloader returns a Reloader of the config file at the same path ReadConfig reads it from.
+// A config file which does not exist is read as an empty config. If overrides is not nil, it is applied to every
+// config read from the file, so that a field CNS takes from its command line is neither reloaded nor reported as
+// requiring a restart when it changes in the file.
+func NewReloader(cmdLineConfigPath string, overrides func(*CNSConfig)) (*Reloader, error) {
+	path, err := getConfigFilePath(cmdLineConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if overrides == nil {
+		overrides = func(*CNSConfig) {}
+	}
+	content, config, err := readReloadableConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	overrides(config)
+	effective := *config
+	file := *config
+	return &Reloader{
+		path:      path,
+		overrides: overrides,
+		content:   content,
+		startup:   config,
+		file:      &file,
+		effective: &effective,
+		now:       time.Now,
+	}, nil
+}
+
+// Config returns the effective config: the config CNS started with, with the reloadable fields of the config file.
+// It must not be modified.
+func (r *Reloader) Config() *CNSConfig {
+	r.RLock()
+	defer r.RUnlock()
+	return r.effective
+}
+
+// LastReload returns the result of the last reload of the config file, or nil if the file did not change since CNS
+// started.
+func (r *Reloader) LastReload() *cns.ConfigReloadResult {
+	r.RLock()
+	defer r.RUnlock()
+	return r.last
+}
+
+// EffectiveConfig returns the effective config encoded as JSON.
+func (r *Reloader) EffectiveConfig() (json.RawMessage, error) {
+	b, err := json.Marshal(r.Config()) //nolint:musttag // no tag needed for config
+	return b, errors.Wrap(err, "failed to marshal config")
+}
+
+// OnReload registers fn to be called with the effective configs before and after each reload which applies changes.
+// Hooks are called in the order they are registered, and must not block.
+func (r *Reloader) OnReload(fn func(old, updated *CNSConfig)) {
+	r.Lock()
+	defer r.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload reads the config file and applies the changes to the reloadable fields, if the config is valid.
+// It returns the result of the reload, or nil if the file content did not change since it was last read.
+func (r *Reloader) Reload() *cns.ConfigReloadResult {
+	r.Lock()
+	content, updated, err := readReloadableConfig(r.path)
+	if err == nil && bytes.Equal(content, r.content) {
+		r.Unlock()
+		return nil
+	}
+	result := &cns.ConfigReloadResult{Time: r.now()}
+	if err == nil {
+		r.overrides(updated)
+		err = validateReloadable(updated)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		r.last = result
+		r.Unlock()
+		logger.Errorf("[Configuration] rejected config reload, keeping the effective config: %v", err)
+		return result
+	}
+	r.content = content
+
+	result.Applied = []string{}
+	for _, path := range Diff(r.file, updated) {
+		if slices.Contains(ReloadableFields, path) {
+			result.Applied = append(result.Applied, path)
+		}
+	}
+	result.RequiresRestart = []string{}
+	for _, path := range Diff(r.startup, updated) {
+		if !slices.Contains(ReloadableFields, path) {
+			result.RequiresRestart = append(result.RequiresRestart, path)
+		}
+	}
+
+	old := r.effective
+	effective := *old
+	for _, path := range ReloadableFields {
+		copyField(&effective, updated, path)
+	}
+	r.file, r.effective, r.last = updated, &effective, result
+	hooks := r.hooks
+	r.Unlock()
+
+	logger.Printf("[Configuration] reloaded config, applied %v, requires restart %v", result.Applied, result.RequiresRestart)
+	if len(result.Applied) > 0 {
+		for _, hook := range hooks {
+			hook(old, &effective)
+		}
+	}
+	return result
+}
+
+// Start reloads the config whenever the config file changes, until the context is closed.
+func (r *Reloader) Start(ctx context.Context, z *zap.Logger) error {
+	return acnfs.WatchFile(ctx, r.path, z, func() { r.Reload() }) //nolint:wrapcheck // ignore
+}
+
+// readReloadableConfig reads the config file at path, and sets the defaults of the config.
+// A config file which does not exist is read as an empty config.
+func readReloadableConfig(path string) ([]byte, *CNSConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, errors.Wrapf(err, "failed to read config file %s", path)
+	}
+	var config CNSConfig
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to unmarshal config")
+		}
+	}
+	SetCNSConfigDefaults(&config)
+	return content, &config, nil
+}
+
+// validateReloadable checks the values of the reloadable fields which can't be defaulted.
+func validateReloadable(config *CNSConfig) error {
+	if _, err := zapcore.ParseLevel(config.Logger.Level); err != nil {
+		return errors.Wrapf(err, "invalid Logger.Level %s", config.Logger.Level)
+	}
+	if config.SyncHostNCVersionIntervalMs < 0 {
+		return errors.Errorf("invalid SyncHostNCVersionIntervalMs %d", config.SyncHostNCVersionIntervalMs)
+	}
+	ts := config.TelemetrySettings
+	if ts.HeartBeatIntervalInMins < 0 || ts.SnapshotIntervalInMins < 0 || ts.ConfigSnapshotIntervalInMins < 0 {
+		return errors.Errorf("invalid TelemetrySettings intervals: HeartBeatIntervalInMins %d SnapshotIntervalInMins %d ConfigSnapshotIntervalInMins %d",
+			ts.HeartBeatIntervalInMins, ts.SnapshotIntervalInMins, ts.ConfigSnapshotIntervalInMins)
+	}
+	return nil
+}
+
+// Diff returns the paths of the exported fields which differ between the configs. The fields of nested structs are
+// compared one by one, and their paths joined with dots, such as "TelemetrySettings.HeartBeatIntervalInMins".
+func Diff(a, b *CNSConfig) []string {
+	return diff("", reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), nil)
+}
+
+func diff(prefix string, a, b reflect.Value, paths []string) []string {
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + field.Name
+		if field.Type.Kind() == reflect.Struct {
+			paths = diff(path+".", a.Field(i), b.Field(i), paths)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// copyField sets the field of dst at path to its value in src.
+func copyField(dst, src *CNSConfig, path string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, name := range strings.Split(path, ".") {
+		d, s = d.FieldByName(name), s.FieldByName(name)
+	}
+	d.Set(s)
+}
@@ -0,0 +1,149 @@
+package configuration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	os.Exit(m.Run())
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestDiff(t *testing.T) {
+	a := &CNSConfig{}
+	SetCNSConfigDefaults(a)
+	b := *a
+	assert.Empty(t, Diff(a, &b))
+
+	b.EnablePprof = true
+	b.TelemetrySettings.HeartBeatIntervalInMins = 5
+	b.Logger.Level = "debug"
+	b.ManagedSettings.NodeID = "node"
+	assert.Equal(t, []string{"EnablePprof", "Logger.Level", "ManagedSettings.NodeID", "TelemetrySettings.HeartBeatIntervalInMins"}, Diff(a, &b))
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	writeConfig(t, path, `{"EnablePprof": false, "ChannelMode": "Direct", "TelemetrySettings": {"HeartBeatIntervalInMins": 30}}`)
+
+	r, err := NewReloader(path, nil)
+	require.NoError(t, err)
+	assert.Nil(t, r.LastReload())
+	assert.Equal(t, 30, r.Config().TelemetrySettings.HeartBeatIntervalInMins)
+
+	var hookOld, hookUpdated *CNSConfig
+	r.OnReload(func(old, updated *CNSConfig) { hookOld, hookUpdated = old, updated })
+
+	// unchanged file is not reloaded.
+	assert.Nil(t, r.Reload())
+
+	// reloadable and non-reloadable changes.
+	writeConfig(t, path, `{"EnablePprof": true, "ChannelMode": "CRD", "TelemetrySettings": {"HeartBeatIntervalInMins": 10}}`)
+	result := r.Reload()
+	require.NotNil(t, result)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, []string{"EnablePprof", "TelemetrySettings.HeartBeatIntervalInMins"}, result.Applied)
+	assert.Equal(t, []string{"ChannelMode"}, result.RequiresRestart)
+	assert.Equal(t, result, r.LastReload())
+
+	effective := r.Config()
+	assert.True(t, effective.EnablePprof)
+	assert.Equal(t, 10, effective.TelemetrySettings.HeartBeatIntervalInMins)
+	assert.Equal(t, "Direct", effective.ChannelMode, "non-reloadable fields keep their startup value")
+	require.NotNil(t, hookUpdated)
+	assert.False(t, hookOld.EnablePprof)
+	assert.Equal(t, effective, hookUpdated)
+
+	// invalid configs are rejected and the effective config is kept.
+	hookUpdated = nil
+	writeConfig(t, path, `{"EnablePprof": false, "SyncHostNCVersionIntervalMs": -1}`)
+	result = r.Reload()
+	require.NotNil(t, result)
+	assert.NotEmpty(t, result.Error)
+	assert.Same(t, effective, r.Config())
+	assert.Nil(t, hookUpdated)
+
+	writeConfig(t, path, `{"Logger": {"level": "loud"}}`)
+	result = r.Reload()
+	require.NotNil(t, result)
+	assert.NotEmpty(t, result.Error)
+	assert.Same(t, effective, r.Config())
+
+	// reverting the non-reloadable change no longer requires a restart, and the unchanged reloadable fields are not
+	// applied again.
+	writeConfig(t, path, `{"EnablePprof": true, "ChannelMode": "Direct", "TelemetrySettings": {"HeartBeatIntervalInMins": 10}}`)
+	result = r.Reload()
+	require.NotNil(t, result)
+	assert.Empty(t, result.Error)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.RequiresRestart)
+	assert.Nil(t, hookUpdated, "hooks are not called when nothing is applied")
+}
+
+func TestReloadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	writeConfig(t, path, `{"CNIConflistScenario": "v4overlay", "Logger": {"level": "info"}}`)
+
+	r, err := NewReloader(path, func(c *CNSConfig) { c.CNIConflistScenario = "cilium" })
+	require.NoError(t, err)
+	assert.Equal(t, "cilium", r.Config().CNIConflistScenario)
+
+	// a change to a field overridden on the command line doesn't require a restart.
+	writeConfig(t, path, `{"CNIConflistScenario": "swift", "Logger": {"level": "debug"}}`)
+	result := r.Reload()
+	require.NotNil(t, result)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, []string{"Logger.Level"}, result.Applied)
+	assert.Empty(t, result.RequiresRestart)
+	assert.Equal(t, "cilium", r.Config().CNIConflistScenario)
+}
+
+func TestValidateReloadable(t *testing.T) {
+	config := &CNSConfig{}
+	SetCNSConfigDefaults(config)
+	require.NoError(t, validateReloadable(config))
+
+	config.Logger.Level = "loud"
+	require.Error(t, validateReloadable(config))
+}
+
+func TestReloaderStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	writeConfig(t, path, `{"EnablePprof": false}`)
+	r, err := NewReloader(path, nil)
+	require.NoError(t, err)
+
+	reloaded := make(chan *CNSConfig, 10)
+	r.OnReload(func(_, updated *CNSConfig) { reloaded <- updated })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() { errs <- r.Start(ctx, zap.NewNop()) }()
+
+	// give the watcher time to start before changing the file.
+	time.Sleep(100 * time.Millisecond)
+	writeConfig(t, path, `{"EnablePprof": true}`)
+	select {
+	case updated := <-reloaded:
+		assert.True(t, updated.EnablePprof)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	cancel()
+	require.Error(t, <-errs)
+}
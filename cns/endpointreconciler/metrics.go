@@ -0,0 +1,44 @@
+package endpointreconciler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "endpoint_reconcile_total",
+			Help: "Count of endpoint datapath reconcile runs by success or failure",
+		},
+		[]string{"ok"},
+	)
+	repairCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "endpoint_reconcile_repairs_total",
+			Help: "Count of endpoint datapath repairs by the kind of state which was re-applied",
+		},
+		[]string{"kind"},
+	)
+	reconcileErrorCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "endpoint_reconcile_errors_total",
+			Help: "Count of endpoints whose datapath could not be checked or repaired",
+		},
+	)
+	reconciledEndpoints = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "endpoint_reconcile_endpoints",
+			Help: "Count of endpoints checked by the last reconcile run",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileCount,
+		repairCount,
+		reconcileErrorCount,
+		reconciledEndpoints,
+	)
+}
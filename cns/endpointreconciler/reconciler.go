@@ -0,0 +1,113 @@
+// Package endpointreconciler periodically repairs the datapath of the CNI endpoints on the node.
+//
+// The CNI owns the endpoint state and serializes ADD and DEL with its store lock, so the reconciler execs the CNI
+// with the RECONCILE_ENDPOINTS command rather than programming the datapath from CNS. The CNI is exec'd once per
+// endpoint, so that the store lock is only held while one endpoint is repaired. It compares the endpoint in its state
+// with the live host and container interfaces, addresses, routes, neighbor entries, and host snat rules, and re-applies
+// whatever is missing with the same endpoint clients which programmed it on ADD.
+//
+// The CNI runs with the privileges of CNS, so CNS must run in the host network and pid namespaces with NET_ADMIN,
+// NET_RAW, SYS_ADMIN, and SYS_PTRACE, and mount /var/run/netns and /run/xtables.lock from the host. These are not
+// granted by cns/azure-cns.yaml; patch them in with cns/azure-cns-endpoint-reconciler.yaml when enabling the reconciler.
+package endpointreconciler
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/Azure/azure-container-networking/cni/client"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/pkg/errors"
+	kexec "k8s.io/utils/exec"
+)
+
+type cniClient interface {
+	GetEndpointState() (*api.AzureCNIState, error)
+	ReconcileEndpoint(endpointID string) (*api.EndpointReconcileResult, error)
+}
+
+type Reconciler struct {
+	cli cniClient
+}
+
+// New returns a Reconciler which execs out to the CNI binary.
+func New() *Reconciler {
+	return &Reconciler{cli: client.New(kexec.New())}
+}
+
+// Run reconciles the endpoints every interval until the context is closed.
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reconcile(); err != nil {
+				logger.Errorf("[Azure CNS] Failed to reconcile endpoints: %v", err)
+			}
+		}
+	}
+}
+
+// Reconcile runs a single reconcile pass over the endpoints in the CNI state and reports every repair as a metric
+// and an event. An endpoint which the CNI fails to reconcile is counted as an error, and the pass goes on.
+func (r *Reconciler) Reconcile() error {
+	state, err := r.cli.GetEndpointState()
+	if err != nil {
+		reconcileCount.WithLabelValues("false").Inc()
+		return errors.Wrap(err, "failed to list endpoints via CNI")
+	}
+
+	ids := make([]string, 0, len(state.ContainerInterfaces))
+	for id := range state.ContainerInterfaces {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	endpoints := 0
+	for _, id := range ids {
+		result, err := r.cli.ReconcileEndpoint(id)
+		if err != nil {
+			reconcileErrorCount.Inc()
+			logger.Errorf("[Azure CNS] Failed to reconcile endpoint %s via CNI: %v", id, err)
+			continue
+		}
+		endpoints += result.Endpoints
+		report(result)
+	}
+
+	reconcileCount.WithLabelValues("true").Inc()
+	reconciledEndpoints.Set(float64(endpoints))
+	return nil
+}
+
+func report(result *api.EndpointReconcileResult) {
+	for i := range result.Repairs {
+		repair := &result.Repairs[i]
+		repairCount.WithLabelValues(repair.Kind).Inc()
+		logger.Printf("[Azure CNS] Repaired %s of endpoint %s of pod %s/%s: %s",
+			repair.Kind, repair.PodEndpointId, repair.PodNamespace, repair.PodName, repair.Detail)
+		logger.LogEvent(aitelemetry.Event{
+			EventName:  logger.EndpointRepairEventStr,
+			ResourceID: repair.PodEndpointId,
+			Properties: map[string]string{
+				logger.EndpointRepairKindStr:   repair.Kind,
+				logger.EndpointRepairDetailStr: repair.Detail,
+				logger.PodNameStr:              repair.PodName,
+				logger.PodNamespaceStr:         repair.PodNamespace,
+				logger.ContainerIDStr:          repair.ContainerID,
+			},
+		})
+	}
+
+	for _, e := range result.Errors {
+		reconcileErrorCount.Inc()
+		logger.Errorf("[Azure CNS] Failed to reconcile endpoint %s of pod %s/%s: %s", e.PodEndpointId, e.PodNamespace, e.PodName, e.Error)
+	}
+}
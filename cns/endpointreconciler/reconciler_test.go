@@ -0,0 +1,97 @@
+package endpointreconciler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCNIClient struct {
+	stateErr error
+	results  map[string]*api.EndpointReconcileResult
+	errs     map[string]error
+	calls    []string
+}
+
+func (f *fakeCNIClient) GetEndpointState() (*api.AzureCNIState, error) {
+	if f.stateErr != nil {
+		return nil, f.stateErr
+	}
+	state := &api.AzureCNIState{ContainerInterfaces: make(map[string]api.PodNetworkInterfaceInfo)}
+	for id := range f.results {
+		state.ContainerInterfaces[id] = api.PodNetworkInterfaceInfo{PodEndpointId: id}
+	}
+	for id := range f.errs {
+		state.ContainerInterfaces[id] = api.PodNetworkInterfaceInfo{PodEndpointId: id}
+	}
+	return state, nil
+}
+
+func (f *fakeCNIClient) ReconcileEndpoint(endpointID string) (*api.EndpointReconcileResult, error) {
+	f.calls = append(f.calls, endpointID)
+	if err := f.errs[endpointID]; err != nil {
+		return nil, err
+	}
+	return f.results[endpointID], nil
+}
+
+func TestReconcile(t *testing.T) {
+	logger.InitLogger("testlogs", 0, 0, t.TempDir()+"/")
+
+	cli := &fakeCNIClient{
+		results: map[string]*api.EndpointReconcileResult{
+			"3f813b02-eth0": {
+				Endpoints: 1,
+				Repairs: []api.EndpointRepair{
+					{PodName: "coredns-0", PodNamespace: "kube-system", PodEndpointId: "3f813b02-eth0", Kind: "neighbor", Detail: "neighbor 169.254.1.1 on container interface eth0"},
+					{PodName: "coredns-0", PodNamespace: "kube-system", PodEndpointId: "3f813b02-eth0", Kind: "route", Detail: "route 169.254.1.1/32 on container interface eth0"},
+				},
+			},
+			"6e688597-eth0": {
+				Endpoints: 1,
+				Repairs: []api.EndpointRepair{
+					{PodName: "coredns-1", PodNamespace: "kube-system", PodEndpointId: "6e688597-eth0", Kind: "snat-rules", Detail: "snat rules iptables -A FORWARD -j ACCEPT"},
+				},
+			},
+			"9a1b2c3d-eth0": {
+				Endpoints: 1,
+				Errors: []api.EndpointReconcileError{
+					{PodName: "tunnelfront-0", PodNamespace: "kube-system", PodEndpointId: "9a1b2c3d-eth0", Error: "endpoint datapath is invalid"},
+				},
+			},
+			// deleted since the endpoints were listed
+			"d2e3f4a5-eth0": {},
+		},
+		errs: map[string]error{"a4c5d6e7-eth0": errors.New("exit status 1")},
+	}
+	r := &Reconciler{cli: cli}
+
+	neighbors := testutil.ToFloat64(repairCount.WithLabelValues("neighbor"))
+	routes := testutil.ToFloat64(repairCount.WithLabelValues("route"))
+	snatRules := testutil.ToFloat64(repairCount.WithLabelValues("snat-rules"))
+	errs := testutil.ToFloat64(reconcileErrorCount)
+	runs := testutil.ToFloat64(reconcileCount.WithLabelValues("true"))
+
+	require.NoError(t, r.Reconcile())
+	require.Equal(t, []string{"3f813b02-eth0", "6e688597-eth0", "9a1b2c3d-eth0", "a4c5d6e7-eth0", "d2e3f4a5-eth0"}, cli.calls)
+	require.InDelta(t, neighbors+1, testutil.ToFloat64(repairCount.WithLabelValues("neighbor")), 0)
+	require.InDelta(t, routes+1, testutil.ToFloat64(repairCount.WithLabelValues("route")), 0)
+	require.InDelta(t, snatRules+1, testutil.ToFloat64(repairCount.WithLabelValues("snat-rules")), 0)
+	require.InDelta(t, errs+2, testutil.ToFloat64(reconcileErrorCount), 0)
+	require.InDelta(t, runs+1, testutil.ToFloat64(reconcileCount.WithLabelValues("true")), 0)
+	require.InDelta(t, 3, testutil.ToFloat64(reconciledEndpoints), 0)
+}
+
+func TestReconcileCNIFailure(t *testing.T) {
+	logger.InitLogger("testlogs", 0, 0, t.TempDir()+"/")
+
+	r := &Reconciler{cli: &fakeCNIClient{stateErr: errors.New("exit status 1")}}
+
+	failures := testutil.ToFloat64(reconcileCount.WithLabelValues("false"))
+	require.Error(t, r.Reconcile())
+	require.InDelta(t, failures+1, testutil.ToFloat64(reconcileCount.WithLabelValues("false")), 0)
+}
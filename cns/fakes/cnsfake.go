@@ -141,8 +141,8 @@ func (ipm *IPStateManager) MarkIPAsPendingRelease(numberOfIPsToMark int) (map[st
 	}()
 
 	for i := 0; i < numberOfIPsToMark; i++ {
-		id, err := ipm.AvailableIPIDStack.Pop()
-		if err != nil {
+		var id string
+		if id, err = ipm.AvailableIPIDStack.Pop(); err != nil {
 			return ipm.PendingReleaseIPConfigState, err
 		}
 
@@ -246,6 +246,11 @@ func (fake *HTTPServiceFake) GetPendingReleaseIPConfigs() []cns.IPConfigurationS
 	return ipconfigs
 }
 
+// GetCoolingIPConfigs returns no IPs, released IPs are not quarantined by the fake.
+func (fake *HTTPServiceFake) GetCoolingIPConfigs() []cns.IPConfigurationStatus {
+	return []cns.IPConfigurationStatus{}
+}
+
 // Return union of all state maps
 func (fake *HTTPServiceFake) GetPodIPConfigState() map[string]cns.IPConfigurationStatus {
 	ipconfigs := make(map[string]cns.IPConfigurationStatus)
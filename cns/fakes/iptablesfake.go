@@ -207,3 +207,40 @@ func (c *IPTablesMock) Delete(table, chain string, rulespec ...string) error {
 func (c *IPTablesMock) ClearChainCallCount() int {
 	return c.clearChainCallCount
 }
+
+// Apply replays the transaction's operations against the mock with the transaction's check semantics:
+// existing chains and rules aren't added again, and missing rules aren't deleted.
+func (c *IPTablesMock) Apply(tx *iptables.Transaction) error {
+	for _, op := range tx.Operations() {
+		rule := strings.TrimSpace(op.Match + " -j " + op.Target)
+		exists, _ := c.Exists(op.Table, op.Chain, rule)
+
+		var err error
+		switch op.Action {
+		case iptables.NewChain:
+			if chainExists, _ := c.ChainExists(op.Table, op.Chain); !chainExists {
+				err = c.NewChain(op.Table, op.Chain)
+			}
+		case iptables.Flush:
+			err = c.ClearChain(op.Table, op.Chain)
+		case iptables.Insert:
+			if !exists {
+				err = c.Insert(op.Table, op.Chain, op.Position, rule)
+			}
+		case iptables.Append:
+			if !exists {
+				err = c.Append(op.Table, op.Chain, rule)
+			}
+		case iptables.Delete:
+			if exists {
+				err = c.Delete(op.Table, op.Chain, rule)
+			}
+		default:
+			err = fmt.Errorf("unknown iptables action %s", op.Action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
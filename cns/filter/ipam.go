@@ -12,6 +12,8 @@ var (
 	StateAssigned = ipConfigStatePredicate(types.Assigned)
 	// StateAvailable is a preset filter for types.Available.
 	StateAvailable = ipConfigStatePredicate(types.Available)
+	// StateCooling is a preset filter for types.Cooling.
+	StateCooling = ipConfigStatePredicate(types.Cooling)
 	// StatePendingProgramming is a preset filter for types.PendingProgramming.
 	StatePendingProgramming = ipConfigStatePredicate(types.PendingProgramming)
 	// StatePendingRelease is a preset filter for types.PendingRelease.
@@ -21,6 +23,7 @@ var (
 var filters = map[types.IPState]IPConfigStatePredicate{
 	types.Assigned:           StateAssigned,
 	types.Available:          StateAvailable,
+	types.Cooling:            StateCooling,
 	types.PendingProgramming: StatePendingProgramming,
 	types.PendingRelease:     StatePendingRelease,
 }
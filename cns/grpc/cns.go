@@ -2,10 +2,17 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/Azure/azure-container-networking/cns"
 	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
 	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/common"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // CNSService defines the CNS gRPC service.
@@ -26,3 +33,146 @@ func (s *CNS) GetNodeInfo(_ context.Context, req *pb.NodeInfoRequest) (*pb.NodeI
 	// todo: Implement the logic
 	return &pb.NodeInfoResponse{}, nil
 }
+
+// RequestIPConfigs assigns IPs to a pod through the same path as the RequestIPConfigs HTTP API.
+func (s *CNS) RequestIPConfigs(ctx context.Context, req *pb.IPConfigsRequest) (*pb.IPConfigsResponse, error) {
+	defer s.State.PublishIPStateMetrics()
+	ipconfigsRequest := IPConfigsRequestFromPB(req)
+	resp, err := s.State.RequestIPConfigsHelper(ctx, ipconfigsRequest)
+	if err != nil {
+		s.Logger.Error("RequestIPConfigs failed", zap.String("infraContainerID", ipconfigsRequest.InfraContainerID), zap.Error(err))
+	}
+	if resp == nil {
+		return nil, status.Error(codes.Internal, "RequestIPConfigs returned no response") //nolint:wrapcheck // gRPC status error
+	}
+	return IPConfigsResponseToPB(resp), nil
+}
+
+// ReleaseIPConfigs releases the IPs assigned to a pod through the same path as the ReleaseIPConfigs HTTP API.
+func (s *CNS) ReleaseIPConfigs(ctx context.Context, req *pb.IPConfigsRequest) (*pb.ReleaseIPConfigsResponse, error) {
+	defer s.State.PublishIPStateMetrics()
+	ipconfigsRequest := IPConfigsRequestFromPB(req)
+	resp, err := s.State.ReleaseIPConfigHandlerHelper(ctx, ipconfigsRequest)
+	if err != nil {
+		s.Logger.Error("ReleaseIPConfigs failed", zap.String("infraContainerID", ipconfigsRequest.InfraContainerID), zap.Error(err))
+	}
+	return &pb.ReleaseIPConfigsResponse{Response: ResponseToPB(resp.Response)}, nil
+}
+
+// GetIPAddressesMatchingStates returns the IPs in the pool which are in any of the requested states.
+func (s *CNS) GetIPAddressesMatchingStates(_ context.Context, req *pb.GetIPAddressesRequest) (*pb.GetIPAddressesResponse, error) {
+	ipConfigs := s.State.GetIPConfigsMatchingStates(IPStatesFromPB(req.GetIpConfigStateFilter())...)
+	resp := &pb.GetIPAddressesResponse{
+		Response:              ResponseToPB(cns.Response{ReturnCode: types.Success}),
+		IpConfigurationStatus: make([]*pb.IPConfigurationStatus, len(ipConfigs)),
+	}
+	for i := range ipConfigs {
+		resp.IpConfigurationStatus[i] = IPConfigurationStatusToPB(&ipConfigs[i])
+	}
+	return resp, nil
+}
+
+// GetEndpoint returns the endpoint state of a container.
+func (s *CNS) GetEndpoint(_ context.Context, req *pb.GetEndpointRequest) (*pb.GetEndpointResponse, error) {
+	s.State.Lock()
+	defer s.State.Unlock()
+	if r, ok := s.checkManageEndpointState(); !ok {
+		return &pb.GetEndpointResponse{Response: r}, nil
+	}
+	endpointInfo, err := s.State.GetEndpointHelper(req.GetEndpointID())
+	if err != nil {
+		return &pb.GetEndpointResponse{Response: ResponseToPB(endpointErrorResponse("GetEndpoint", err))}, nil
+	}
+	return &pb.GetEndpointResponse{
+		Response:     ResponseToPB(cns.Response{ReturnCode: types.Success}),
+		EndpointInfo: EndpointInfoToPB(endpointInfo),
+	}, nil
+}
+
+// UpdateEndpoint creates or updates the endpoint state of a container.
+func (s *CNS) UpdateEndpoint(_ context.Context, req *pb.UpdateEndpointRequest) (*pb.UpdateEndpointResponse, error) {
+	ipInfo, err := IPInfoMapFromPB(req.GetIfnameToIPMap())
+	if err != nil {
+		return &pb.UpdateEndpointResponse{Response: ResponseToPB(cns.Response{ReturnCode: types.InvalidRequest, Message: err.Error()})}, nil
+	}
+	s.State.Lock()
+	defer s.State.Unlock()
+	if r, ok := s.checkManageEndpointState(); !ok {
+		return &pb.UpdateEndpointResponse{Response: r}, nil
+	}
+	if err := restserver.VerifyUpdateEndpointStateRequest(ipInfo); err != nil {
+		return &pb.UpdateEndpointResponse{Response: ResponseToPB(cns.Response{ReturnCode: types.InvalidRequest, Message: err.Error()})}, nil
+	}
+	if err := s.State.UpdateEndpointHelper(req.GetEndpointID(), ipInfo); err != nil {
+		return &pb.UpdateEndpointResponse{Response: ResponseToPB(endpointErrorResponse("UpdateEndpoint", err))}, nil
+	}
+	return &pb.UpdateEndpointResponse{Response: ResponseToPB(cns.Response{ReturnCode: types.Success})}, nil
+}
+
+// DeleteEndpoint deletes the endpoint state of a container.
+func (s *CNS) DeleteEndpoint(_ context.Context, req *pb.DeleteEndpointRequest) (*pb.DeleteEndpointResponse, error) {
+	s.State.Lock()
+	defer s.State.Unlock()
+	if r, ok := s.checkManageEndpointState(); !ok {
+		return &pb.DeleteEndpointResponse{Response: r}, nil
+	}
+	if err := s.State.DeleteEndpointStateHelper(req.GetEndpointID()); err != nil {
+		return &pb.DeleteEndpointResponse{Response: ResponseToPB(endpointErrorResponse("DeleteEndpoint", err))}, nil
+	}
+	return &pb.DeleteEndpointResponse{Response: ResponseToPB(cns.Response{ReturnCode: types.Success})}, nil
+}
+
+// WatchState streams the changes to the IP pool and endpoint state after the requested resource version.
+// The stream is aborted if the watcher falls too far behind, and can be resumed from the last received resource version.
+func (s *CNS) WatchState(req *pb.WatchStateRequest, stream pb.CNS_WatchStateServer) error {
+	watch, err := s.State.WatchState(req.GetResourceVersion())
+	if err != nil {
+		if errors.Is(err, restserver.ErrResourceVersionExpired) {
+			return status.Error(codes.OutOfRange, err.Error()) //nolint:wrapcheck // gRPC status error
+		}
+		return status.Error(codes.Internal, err.Error()) //nolint:wrapcheck // gRPC status error
+	}
+	defer watch.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-watch.Events():
+			if !ok {
+				s.Logger.Info("WatchState closed by CNS", zap.Error(watch.Err()))
+				return status.Errorf(codes.Aborted, "watch closed: %v", watch.Err()) //nolint:wrapcheck // gRPC status error
+			}
+			if err := stream.Send(StateEventToPB(&event)); err != nil {
+				return err //nolint:wrapcheck // returned to the gRPC server
+			}
+		}
+	}
+}
+
+// checkManageEndpointState mirrors the EndpointHandlerAPI check that CNS is managing the endpoint state.
+// The caller must hold the State lock.
+func (s *CNS) checkManageEndpointState() (*pb.Response, bool) {
+	if s.State.Options[common.OptManageEndpointState] == true {
+		return nil, true
+	}
+	return ResponseToPB(cns.Response{
+		ReturnCode: types.UnexpectedError,
+		Message:    restserver.ErrOptManageEndpointState.Error(),
+	}), false
+}
+
+// endpointErrorResponse maps an endpoint state helper error to a CNS response.
+func endpointErrorResponse(op string, err error) cns.Response {
+	resp := cns.Response{
+		ReturnCode: types.UnexpectedError,
+		Message:    fmt.Sprintf("%s failed with error: %s", op, err.Error()),
+	}
+	switch {
+	case errors.Is(err, restserver.ErrEndpointStateNotFound):
+		resp.ReturnCode = types.NotFound
+	case errors.Is(err, restserver.ErrStoreEmpty):
+		resp.ReturnCode = types.NilEndpointStateStore
+	}
+	return resp
+}
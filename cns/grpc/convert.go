@@ -0,0 +1,393 @@
+package grpc
+
+import (
+	"net"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/network/policy"
+	"github.com/pkg/errors"
+)
+
+// The conversions in this file map between the CNS API types and their gRPC messages.
+// They are shared by the gRPC server and the gRPC CNS client so that both ends agree on the wire format.
+
+// ResponseToPB converts a cns.Response to its gRPC message.
+func ResponseToPB(r cns.Response) *pb.Response {
+	return &pb.Response{
+		ReturnCode: int32(r.ReturnCode),
+		Message:    r.Message,
+	}
+}
+
+// ResponseFromPB converts a gRPC Response message to a cns.Response.
+func ResponseFromPB(r *pb.Response) cns.Response {
+	return cns.Response{
+		ReturnCode: types.ResponseCode(r.GetReturnCode()),
+		Message:    r.GetMessage(),
+	}
+}
+
+// IPConfigsRequestToPB converts a cns.IPConfigsRequest to its gRPC message.
+func IPConfigsRequestToPB(req cns.IPConfigsRequest) *pb.IPConfigsRequest { //nolint:gocritic // ignore hugeparam
+	return &pb.IPConfigsRequest{
+		DesiredIPAddresses:           req.DesiredIPAddresses,
+		PodInterfaceID:               req.PodInterfaceID,
+		InfraContainerID:             req.InfraContainerID,
+		OrchestratorContext:          req.OrchestratorContext,
+		Ifname:                       req.Ifname,
+		SecondaryInterfacesExist:     req.SecondaryInterfacesExist,
+		BackendInterfaceExist:        req.BackendInterfaceExist,
+		BackendInterfaceMacAddresses: req.BackendInterfaceMacAddresses,
+	}
+}
+
+// IPConfigsRequestFromPB converts a gRPC IPConfigsRequest message to a cns.IPConfigsRequest.
+func IPConfigsRequestFromPB(req *pb.IPConfigsRequest) cns.IPConfigsRequest {
+	return cns.IPConfigsRequest{
+		DesiredIPAddresses:           req.GetDesiredIPAddresses(),
+		PodInterfaceID:               req.GetPodInterfaceID(),
+		InfraContainerID:             req.GetInfraContainerID(),
+		OrchestratorContext:          req.GetOrchestratorContext(),
+		Ifname:                       req.GetIfname(),
+		SecondaryInterfacesExist:     req.GetSecondaryInterfacesExist(),
+		BackendInterfaceExist:        req.GetBackendInterfaceExist(),
+		BackendInterfaceMacAddresses: req.GetBackendInterfaceMacAddresses(),
+	}
+}
+
+// IPConfigsResponseToPB converts a cns.IPConfigsResponse to its gRPC message.
+func IPConfigsResponseToPB(resp *cns.IPConfigsResponse) *pb.IPConfigsResponse {
+	podIPInfo := make([]*pb.PodIPInfo, len(resp.PodIPInfo))
+	for i := range resp.PodIPInfo {
+		podIPInfo[i] = podIPInfoToPB(&resp.PodIPInfo[i])
+	}
+	return &pb.IPConfigsResponse{
+		Response:  ResponseToPB(resp.Response),
+		PodIPInfo: podIPInfo,
+	}
+}
+
+// IPConfigsResponseFromPB converts a gRPC IPConfigsResponse message to a cns.IPConfigsResponse.
+func IPConfigsResponseFromPB(resp *pb.IPConfigsResponse) *cns.IPConfigsResponse {
+	podIPInfo := make([]cns.PodIpInfo, len(resp.GetPodIPInfo()))
+	for i, info := range resp.GetPodIPInfo() {
+		podIPInfo[i] = podIPInfoFromPB(info)
+	}
+	return &cns.IPConfigsResponse{
+		Response:  ResponseFromPB(resp.GetResponse()),
+		PodIPInfo: podIPInfo,
+	}
+}
+
+func podIPInfoToPB(info *cns.PodIpInfo) *pb.PodIPInfo {
+	routes := make([]*pb.Route, len(info.Routes))
+	for i := range info.Routes {
+		routes[i] = &pb.Route{
+			IpAddress:        info.Routes[i].IPAddress,
+			GatewayIPAddress: info.Routes[i].GatewayIPAddress,
+			InterfaceToUse:   info.Routes[i].InterfaceToUse,
+		}
+	}
+	policies := make([]*pb.Policy, len(info.EndpointPolicies))
+	for i := range info.EndpointPolicies {
+		policies[i] = &pb.Policy{
+			Type: string(info.EndpointPolicies[i].Type),
+			Data: info.EndpointPolicies[i].Data,
+		}
+	}
+	return &pb.PodIPInfo{
+		PodIPConfig:                     ipSubnetToPB(info.PodIPConfig),
+		NetworkContainerPrimaryIPConfig: ipConfigurationToPB(&info.NetworkContainerPrimaryIPConfig),
+		NetworkContainerIPv6Config:      ipConfigurationToPB(&info.NetworkContainerIPv6Config),
+		HostPrimaryIPInfo: &pb.HostIPInfo{
+			Gateway:   info.HostPrimaryIPInfo.Gateway,
+			PrimaryIP: info.HostPrimaryIPInfo.PrimaryIP,
+			Subnet:    info.HostPrimaryIPInfo.Subnet,
+		},
+		NicType:                    string(info.NICType),
+		InterfaceName:              info.InterfaceName,
+		MacAddress:                 info.MacAddress,
+		SkipDefaultRoutes:          info.SkipDefaultRoutes,
+		Routes:                     routes,
+		PnpID:                      info.PnPID,
+		EndpointPolicies:           policies,
+		AllowHostToNCCommunication: info.AllowHostToNCCommunication,
+		AllowNCToHostCommunication: info.AllowNCToHostCommunication,
+		NetworkContainerID:         info.NetworkContainerID,
+	}
+}
+
+func podIPInfoFromPB(info *pb.PodIPInfo) cns.PodIpInfo {
+	var routes []cns.Route
+	for _, r := range info.GetRoutes() {
+		routes = append(routes, cns.Route{
+			IPAddress:        r.GetIpAddress(),
+			GatewayIPAddress: r.GetGatewayIPAddress(),
+			InterfaceToUse:   r.GetInterfaceToUse(),
+		})
+	}
+	var policies []policy.Policy
+	for _, p := range info.GetEndpointPolicies() {
+		policies = append(policies, policy.Policy{
+			Type: policy.CNIPolicyType(p.GetType()),
+			Data: p.GetData(),
+		})
+	}
+	return cns.PodIpInfo{
+		PodIPConfig:                     ipSubnetFromPB(info.GetPodIPConfig()),
+		NetworkContainerPrimaryIPConfig: ipConfigurationFromPB(info.GetNetworkContainerPrimaryIPConfig()),
+		NetworkContainerIPv6Config:      ipConfigurationFromPB(info.GetNetworkContainerIPv6Config()),
+		HostPrimaryIPInfo: cns.HostIPInfo{
+			Gateway:   info.GetHostPrimaryIPInfo().GetGateway(),
+			PrimaryIP: info.GetHostPrimaryIPInfo().GetPrimaryIP(),
+			Subnet:    info.GetHostPrimaryIPInfo().GetSubnet(),
+		},
+		NICType:                    cns.NICType(info.GetNicType()),
+		InterfaceName:              info.GetInterfaceName(),
+		MacAddress:                 info.GetMacAddress(),
+		SkipDefaultRoutes:          info.GetSkipDefaultRoutes(),
+		Routes:                     routes,
+		PnPID:                      info.GetPnpID(),
+		EndpointPolicies:           policies,
+		AllowHostToNCCommunication: info.GetAllowHostToNCCommunication(),
+		AllowNCToHostCommunication: info.GetAllowNCToHostCommunication(),
+		NetworkContainerID:         info.GetNetworkContainerID(),
+	}
+}
+
+func ipSubnetToPB(s cns.IPSubnet) *pb.IPSubnet {
+	return &pb.IPSubnet{
+		IpAddress:    s.IPAddress,
+		PrefixLength: uint32(s.PrefixLength),
+	}
+}
+
+func ipSubnetFromPB(s *pb.IPSubnet) cns.IPSubnet {
+	return cns.IPSubnet{
+		IPAddress:    s.GetIpAddress(),
+		PrefixLength: uint8(s.GetPrefixLength()), //nolint:gosec // prefix length is at most 128
+	}
+}
+
+func ipConfigurationToPB(c *cns.IPConfiguration) *pb.IPConfiguration {
+	return &pb.IPConfiguration{
+		IpSubnet:           ipSubnetToPB(c.IPSubnet),
+		IpSubnetV6:         ipSubnetToPB(c.IPSubnetV6),
+		DnsServers:         c.DNSServers,
+		GatewayIPAddress:   c.GatewayIPAddress,
+		GatewayIPv6Address: c.GatewayIPv6Address,
+	}
+}
+
+func ipConfigurationFromPB(c *pb.IPConfiguration) cns.IPConfiguration {
+	return cns.IPConfiguration{
+		IPSubnet:           ipSubnetFromPB(c.GetIpSubnet()),
+		IPSubnetV6:         ipSubnetFromPB(c.GetIpSubnetV6()),
+		DNSServers:         c.GetDnsServers(),
+		GatewayIPAddress:   c.GetGatewayIPAddress(),
+		GatewayIPv6Address: c.GetGatewayIPv6Address(),
+	}
+}
+
+// IPStatesToPB converts a list of IP states to their gRPC representation.
+func IPStatesToPB(states []types.IPState) []string {
+	out := make([]string, len(states))
+	for i := range states {
+		out[i] = string(states[i])
+	}
+	return out
+}
+
+// IPStatesFromPB converts the gRPC representation of a list of IP states to types.IPState.
+func IPStatesFromPB(states []string) []types.IPState {
+	out := make([]types.IPState, len(states))
+	for i := range states {
+		out[i] = types.IPState(states[i])
+	}
+	return out
+}
+
+// IPConfigurationStatusToPB converts a cns.IPConfigurationStatus to its gRPC message.
+func IPConfigurationStatusToPB(s *cns.IPConfigurationStatus) *pb.IPConfigurationStatus {
+	out := &pb.IPConfigurationStatus{
+		Id:        s.ID,
+		IpAddress: s.IPAddress,
+		NcID:      s.NCID,
+		State:     string(s.GetState()),
+	}
+	if !s.LastStateTransition.IsZero() {
+		out.LastStateTransition = s.LastStateTransition.UnixNano()
+	}
+	if s.PodInfo != nil {
+		out.PodInfo = &pb.PodInfo{
+			InfraContainerID: s.PodInfo.InfraContainerID(),
+			InterfaceID:      s.PodInfo.InterfaceID(),
+			Name:             s.PodInfo.Name(),
+			Namespace:        s.PodInfo.Namespace(),
+		}
+	}
+	return out
+}
+
+// IPConfigurationStatusFromPB converts a gRPC IPConfigurationStatus message to a cns.IPConfigurationStatus.
+func IPConfigurationStatusFromPB(s *pb.IPConfigurationStatus) cns.IPConfigurationStatus {
+	out := cns.IPConfigurationStatus{
+		ID:        s.GetId(),
+		IPAddress: s.GetIpAddress(),
+		NCID:      s.GetNcID(),
+	}
+	out.SetState(types.IPState(s.GetState()))
+	// SetState stamps the transition time, so restore the one reported by CNS.
+	out.LastStateTransition = time.Time{}
+	if s.GetLastStateTransition() != 0 {
+		out.LastStateTransition = time.Unix(0, s.GetLastStateTransition())
+	}
+	if p := s.GetPodInfo(); p != nil {
+		out.PodInfo = cns.NewPodInfo(p.GetInfraContainerID(), p.GetInterfaceID(), p.GetName(), p.GetNamespace())
+	}
+	return out
+}
+
+// EndpointInfoToPB converts a restserver.EndpointInfo to its gRPC message.
+func EndpointInfoToPB(info *restserver.EndpointInfo) *pb.EndpointInfo {
+	return &pb.EndpointInfo{
+		PodName:       info.PodName,
+		PodNamespace:  info.PodNamespace,
+		IfnameToIPMap: IPInfoMapToPB(info.IfnameToIPMap),
+	}
+}
+
+// EndpointInfoFromPB converts a gRPC EndpointInfo message to a restserver.EndpointInfo.
+func EndpointInfoFromPB(info *pb.EndpointInfo) (restserver.EndpointInfo, error) {
+	ipInfo, err := IPInfoMapFromPB(info.GetIfnameToIPMap())
+	if err != nil {
+		return restserver.EndpointInfo{}, err
+	}
+	return restserver.EndpointInfo{
+		PodName:       info.GetPodName(),
+		PodNamespace:  info.GetPodNamespace(),
+		IfnameToIPMap: ipInfo,
+	}, nil
+}
+
+// IPInfoMapToPB converts a map of interface name to restserver.IPInfo to its gRPC representation.
+func IPInfoMapToPB(m map[string]*restserver.IPInfo) map[string]*pb.IPInfo {
+	out := make(map[string]*pb.IPInfo, len(m))
+	for ifname, info := range m {
+		if info == nil {
+			continue
+		}
+		out[ifname] = &pb.IPInfo{
+			Ipv4:               ipNetsToPB(info.IPv4),
+			Ipv6:               ipNetsToPB(info.IPv6),
+			HnsEndpointID:      info.HnsEndpointID,
+			HnsNetworkID:       info.HnsNetworkID,
+			HostVethName:       info.HostVethName,
+			MacAddress:         info.MacAddress,
+			NetworkContainerID: info.NetworkContainerID,
+			NicType:            string(info.NICType),
+		}
+	}
+	return out
+}
+
+// IPInfoMapFromPB converts the gRPC representation of a map of interface name to IPInfo to restserver.IPInfo.
+func IPInfoMapFromPB(m map[string]*pb.IPInfo) (map[string]*restserver.IPInfo, error) {
+	out := make(map[string]*restserver.IPInfo, len(m))
+	for ifname, info := range m {
+		ipv4, err := ipNetsFromPB(info.GetIpv4())
+		if err != nil {
+			return nil, err
+		}
+		ipv6, err := ipNetsFromPB(info.GetIpv6())
+		if err != nil {
+			return nil, err
+		}
+		out[ifname] = &restserver.IPInfo{
+			IPv4:               ipv4,
+			IPv6:               ipv6,
+			HnsEndpointID:      info.GetHnsEndpointID(),
+			HnsNetworkID:       info.GetHnsNetworkID(),
+			HostVethName:       info.GetHostVethName(),
+			MacAddress:         info.GetMacAddress(),
+			NetworkContainerID: info.GetNetworkContainerID(),
+			NICType:            cns.NICType(info.GetNicType()),
+		}
+	}
+	return out, nil
+}
+
+func ipNetsToPB(ipNets []net.IPNet) []string {
+	if len(ipNets) == 0 {
+		return nil
+	}
+	out := make([]string, len(ipNets))
+	for i := range ipNets {
+		out[i] = ipNets[i].String()
+	}
+	return out
+}
+
+func ipNetsFromPB(cidrs []string) ([]net.IPNet, error) {
+	if len(cidrs) == 0 {
+		return nil, nil
+	}
+	out := make([]net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", cidr)
+		}
+		// keep the host address, as the endpoint state stores the assigned IP and not the network.
+		out[i] = net.IPNet{IP: ip, Mask: ipNet.Mask}
+	}
+	return out, nil
+}
+
+// StateEventToPB converts a cns.StateEvent to its gRPC message.
+func StateEventToPB(e *cns.StateEvent) *pb.StateEvent {
+	out := &pb.StateEvent{
+		ResourceVersion: e.ResourceVersion,
+		Kind:            string(e.Kind),
+		PodKey:          e.PodKey,
+		PodName:         e.PodName,
+		PodNamespace:    e.PodNamespace,
+		Deleted:         e.Deleted,
+		IpConfigID:      e.IPConfigID,
+		IpAddress:       e.IPAddress,
+		NcID:            e.NCID,
+		PreviousState:   string(e.PreviousState),
+		State:           string(e.State),
+		EndpointID:      e.EndpointID,
+	}
+	if !e.Timestamp.IsZero() {
+		out.Timestamp = e.Timestamp.UnixNano()
+	}
+	return out
+}
+
+// StateEventFromPB converts a gRPC StateEvent message to a cns.StateEvent.
+func StateEventFromPB(e *pb.StateEvent) cns.StateEvent {
+	out := cns.StateEvent{
+		ResourceVersion: e.GetResourceVersion(),
+		Kind:            cns.StateEventKind(e.GetKind()),
+		PodKey:          e.GetPodKey(),
+		PodName:         e.GetPodName(),
+		PodNamespace:    e.GetPodNamespace(),
+		Deleted:         e.GetDeleted(),
+		IPConfigID:      e.GetIpConfigID(),
+		IPAddress:       e.GetIpAddress(),
+		NCID:            e.GetNcID(),
+		PreviousState:   types.IPState(e.GetPreviousState()),
+		State:           types.IPState(e.GetState()),
+		EndpointID:      e.GetEndpointID(),
+	}
+	if e.GetTimestamp() != 0 {
+		out.Timestamp = time.Unix(0, e.GetTimestamp())
+	}
+	return out
+}
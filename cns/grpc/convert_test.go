@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/network/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPConfigsRequestRoundTrip(t *testing.T) {
+	req := cns.IPConfigsRequest{
+		DesiredIPAddresses:           []string{"10.0.0.5"},
+		PodInterfaceID:               "abcd-eth0",
+		InfraContainerID:             "abcd",
+		OrchestratorContext:          json.RawMessage(`{"PodName":"pod","PodNamespace":"ns"}`),
+		Ifname:                       "eth0",
+		SecondaryInterfacesExist:     true,
+		BackendInterfaceExist:        true,
+		BackendInterfaceMacAddresses: []string{"00:11:22:33:44:55"},
+	}
+	assert.Equal(t, req, IPConfigsRequestFromPB(IPConfigsRequestToPB(req)))
+}
+
+func TestIPConfigsResponseRoundTrip(t *testing.T) {
+	resp := &cns.IPConfigsResponse{
+		Response: cns.Response{ReturnCode: types.Success, Message: "ok"},
+		PodIPInfo: []cns.PodIpInfo{
+			{
+				PodIPConfig: cns.IPSubnet{IPAddress: "10.0.0.5", PrefixLength: 24},
+				NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+					IPSubnet:         cns.IPSubnet{IPAddress: "10.0.0.0", PrefixLength: 24},
+					DNSServers:       []string{"8.8.8.8"},
+					GatewayIPAddress: "10.0.0.1",
+				},
+				NetworkContainerIPv6Config: cns.IPConfiguration{
+					IPSubnetV6:         cns.IPSubnet{IPAddress: "fd00::", PrefixLength: 64},
+					GatewayIPv6Address: "fd00::1",
+				},
+				HostPrimaryIPInfo:          cns.HostIPInfo{Gateway: "10.224.0.1", PrimaryIP: "10.224.0.4", Subnet: "10.224.0.0/16"},
+				NICType:                    cns.DelegatedVMNIC,
+				InterfaceName:              "eth1",
+				MacAddress:                 "00:11:22:33:44:55",
+				SkipDefaultRoutes:          true,
+				Routes:                     []cns.Route{{IPAddress: "0.0.0.0/0", GatewayIPAddress: "10.0.0.1", InterfaceToUse: "eth1"}},
+				PnPID:                      "PCI\\VEN_15B3",
+				EndpointPolicies:           []policy.Policy{{Type: policy.ACLPolicy, Data: json.RawMessage(`{"Action":"Block"}`)}},
+				AllowHostToNCCommunication: true,
+				AllowNCToHostCommunication: true,
+				NetworkContainerID:         "nc",
+			},
+		},
+	}
+	assert.Equal(t, resp, IPConfigsResponseFromPB(IPConfigsResponseToPB(resp)))
+}
+
+func TestIPConfigurationStatusRoundTrip(t *testing.T) {
+	status := cns.IPConfigurationStatus{
+		ID:        "id",
+		IPAddress: "10.0.0.5",
+		NCID:      "nc",
+		PodInfo:   cns.NewPodInfo("abcd", "abcd-eth0", "pod", "ns"),
+	}
+	status.SetState(types.Assigned)
+	status.LastStateTransition = time.Unix(0, status.LastStateTransition.UnixNano())
+
+	got := IPConfigurationStatusFromPB(IPConfigurationStatusToPB(&status))
+	assert.True(t, status.Equals(got))
+	assert.Equal(t, types.Assigned, got.GetState())
+	assert.True(t, status.LastStateTransition.Equal(got.LastStateTransition))
+}
+
+func TestEndpointInfoRoundTrip(t *testing.T) {
+	_, v4, _ := net.ParseCIDR("10.0.0.0/24")
+	_, v6, _ := net.ParseCIDR("fd00::/64")
+	info := &restserver.EndpointInfo{
+		PodName:      "pod",
+		PodNamespace: "ns",
+		IfnameToIPMap: map[string]*restserver.IPInfo{
+			"eth0": {
+				IPv4:          []net.IPNet{{IP: net.ParseIP("10.0.0.5"), Mask: v4.Mask}},
+				IPv6:          []net.IPNet{{IP: net.ParseIP("fd00::5"), Mask: v6.Mask}},
+				HnsEndpointID: "hnsid",
+				HostVethName:  "azv1",
+				NICType:       cns.InfraNIC,
+			},
+		},
+	}
+	got, err := EndpointInfoFromPB(EndpointInfoToPB(info))
+	require.NoError(t, err)
+	assert.Equal(t, *info, got)
+}
+
+func TestIPInfoMapFromPBInvalidIP(t *testing.T) {
+	m := IPInfoMapToPB(map[string]*restserver.IPInfo{"eth0": {HostVethName: "azv1"}})
+	m["eth0"].Ipv4 = []string{"not-an-ip"}
+	_, err := IPInfoMapFromPB(m)
+	require.Error(t, err)
+}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"strconv"
 
+	"github.com/Azure/azure-container-networking/cns/authz"
 	pb "github.com/Azure/azure-container-networking/cns/grpc/v1alpha"
+	acn "github.com/Azure/azure-container-networking/common"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -24,6 +28,11 @@ type Server struct {
 type ServerSettings struct {
 	IPAddress string
 	Port      uint16
+	// SocketPath, if set, also serves the gRPC API on a unix socket with SocketMode.
+	SocketPath string
+	SocketMode os.FileMode
+	// Authorizer, if set, authorizes the RPCs on every listener.
+	Authorizer *authz.Authorizer
 }
 
 // NewServer initializes a new gRPC server instance.
@@ -52,12 +61,34 @@ func (s *Server) Start() error {
 	}
 	log.Printf("[Listener] Started listening on gRPC endpoint %s.", address)
 
-	grpcServer := grpc.NewServer()
+	var opts []grpc.ServerOption
+	if a := s.Settings.Authorizer; a != nil {
+		opts = append(opts,
+			grpc.Creds(authz.TransportCredentials(insecure.NewCredentials())),
+			grpc.ChainUnaryInterceptor(a.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(a.StreamServerInterceptor()),
+		)
+	}
+	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterCNSServer(grpcServer, s.CnsService)
 
 	// Register reflection service on gRPC server.
 	reflection.Register(grpcServer)
 
+	if s.Settings.SocketPath != "" {
+		unixLis, err := acn.ListenUnix(s.Settings.SocketPath, s.Settings.SocketMode)
+		if err != nil {
+			_ = lis.Close()
+			return fmt.Errorf("failed to listen on unix socket %s: %w", s.Settings.SocketPath, err)
+		}
+		log.Printf("[Listener] Started listening on gRPC unix socket %s.", s.Settings.SocketPath)
+		go func() {
+			if err := grpcServer.Serve(unixLis); err != nil {
+				s.Logger.Error("failed to serve gRPC server on unix socket", zap.Error(err))
+			}
+		}()
+	}
+
 	if err := grpcServer.Serve(lis); err != nil {
 		return fmt.Errorf("failed to serve gRPC server: %w", err)
 	}
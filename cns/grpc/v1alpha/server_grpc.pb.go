@@ -19,8 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	CNS_SetOrchestratorInfo_FullMethodName = "/cns.CNS/SetOrchestratorInfo"
-	CNS_GetNodeInfo_FullMethodName         = "/cns.CNS/GetNodeInfo"
+	CNS_SetOrchestratorInfo_FullMethodName          = "/cns.CNS/SetOrchestratorInfo"
+	CNS_GetNodeInfo_FullMethodName                  = "/cns.CNS/GetNodeInfo"
+	CNS_RequestIPConfigs_FullMethodName             = "/cns.CNS/RequestIPConfigs"
+	CNS_ReleaseIPConfigs_FullMethodName             = "/cns.CNS/ReleaseIPConfigs"
+	CNS_GetIPAddressesMatchingStates_FullMethodName = "/cns.CNS/GetIPAddressesMatchingStates"
+	CNS_GetEndpoint_FullMethodName                  = "/cns.CNS/GetEndpoint"
+	CNS_UpdateEndpoint_FullMethodName               = "/cns.CNS/UpdateEndpoint"
+	CNS_DeleteEndpoint_FullMethodName               = "/cns.CNS/DeleteEndpoint"
+	CNS_WatchState_FullMethodName                   = "/cns.CNS/WatchState"
 )
 
 // CNSClient is the client API for CNS service.
@@ -32,6 +39,20 @@ type CNSClient interface {
 	// Retrieves detailed information about a specific node.
 	// Primarily used for health checks.
 	GetNodeInfo(ctx context.Context, in *NodeInfoRequest, opts ...grpc.CallOption) (*NodeInfoResponse, error)
+	// Assigns IPs to a pod from the CNS IP pool.
+	RequestIPConfigs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*IPConfigsResponse, error)
+	// Releases the IPs assigned to a pod back to the CNS IP pool.
+	ReleaseIPConfigs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*ReleaseIPConfigsResponse, error)
+	// Retrieves the IPs in the CNS IP pool which are in any of the requested states.
+	GetIPAddressesMatchingStates(ctx context.Context, in *GetIPAddressesRequest, opts ...grpc.CallOption) (*GetIPAddressesResponse, error)
+	// Retrieves the endpoint state of a container.
+	GetEndpoint(ctx context.Context, in *GetEndpointRequest, opts ...grpc.CallOption) (*GetEndpointResponse, error)
+	// Creates or updates the endpoint state of a container.
+	UpdateEndpoint(ctx context.Context, in *UpdateEndpointRequest, opts ...grpc.CallOption) (*UpdateEndpointResponse, error)
+	// Deletes the endpoint state of a container.
+	DeleteEndpoint(ctx context.Context, in *DeleteEndpointRequest, opts ...grpc.CallOption) (*DeleteEndpointResponse, error)
+	// Streams the changes to the IP pool and endpoint state after a resource version.
+	WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (CNS_WatchStateClient, error)
 }
 
 type cNSClient struct {
@@ -60,6 +81,92 @@ func (c *cNSClient) GetNodeInfo(ctx context.Context, in *NodeInfoRequest, opts .
 	return out, nil
 }
 
+func (c *cNSClient) RequestIPConfigs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*IPConfigsResponse, error) {
+	out := new(IPConfigsResponse)
+	err := c.cc.Invoke(ctx, CNS_RequestIPConfigs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) ReleaseIPConfigs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*ReleaseIPConfigsResponse, error) {
+	out := new(ReleaseIPConfigsResponse)
+	err := c.cc.Invoke(ctx, CNS_ReleaseIPConfigs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) GetIPAddressesMatchingStates(ctx context.Context, in *GetIPAddressesRequest, opts ...grpc.CallOption) (*GetIPAddressesResponse, error) {
+	out := new(GetIPAddressesResponse)
+	err := c.cc.Invoke(ctx, CNS_GetIPAddressesMatchingStates_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) GetEndpoint(ctx context.Context, in *GetEndpointRequest, opts ...grpc.CallOption) (*GetEndpointResponse, error) {
+	out := new(GetEndpointResponse)
+	err := c.cc.Invoke(ctx, CNS_GetEndpoint_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) UpdateEndpoint(ctx context.Context, in *UpdateEndpointRequest, opts ...grpc.CallOption) (*UpdateEndpointResponse, error) {
+	out := new(UpdateEndpointResponse)
+	err := c.cc.Invoke(ctx, CNS_UpdateEndpoint_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) DeleteEndpoint(ctx context.Context, in *DeleteEndpointRequest, opts ...grpc.CallOption) (*DeleteEndpointResponse, error) {
+	out := new(DeleteEndpointResponse)
+	err := c.cc.Invoke(ctx, CNS_DeleteEndpoint_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNSClient) WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (CNS_WatchStateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CNS_ServiceDesc.Streams[0], CNS_WatchState_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cNSWatchStateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CNS_WatchStateClient interface {
+	Recv() (*StateEvent, error)
+	grpc.ClientStream
+}
+
+type cNSWatchStateClient struct {
+	grpc.ClientStream
+}
+
+func (x *cNSWatchStateClient) Recv() (*StateEvent, error) {
+	m := new(StateEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CNSServer is the server API for CNS service.
 // All implementations must embed UnimplementedCNSServer
 // for forward compatibility
@@ -69,6 +176,20 @@ type CNSServer interface {
 	// Retrieves detailed information about a specific node.
 	// Primarily used for health checks.
 	GetNodeInfo(context.Context, *NodeInfoRequest) (*NodeInfoResponse, error)
+	// Assigns IPs to a pod from the CNS IP pool.
+	RequestIPConfigs(context.Context, *IPConfigsRequest) (*IPConfigsResponse, error)
+	// Releases the IPs assigned to a pod back to the CNS IP pool.
+	ReleaseIPConfigs(context.Context, *IPConfigsRequest) (*ReleaseIPConfigsResponse, error)
+	// Retrieves the IPs in the CNS IP pool which are in any of the requested states.
+	GetIPAddressesMatchingStates(context.Context, *GetIPAddressesRequest) (*GetIPAddressesResponse, error)
+	// Retrieves the endpoint state of a container.
+	GetEndpoint(context.Context, *GetEndpointRequest) (*GetEndpointResponse, error)
+	// Creates or updates the endpoint state of a container.
+	UpdateEndpoint(context.Context, *UpdateEndpointRequest) (*UpdateEndpointResponse, error)
+	// Deletes the endpoint state of a container.
+	DeleteEndpoint(context.Context, *DeleteEndpointRequest) (*DeleteEndpointResponse, error)
+	// Streams the changes to the IP pool and endpoint state after a resource version.
+	WatchState(*WatchStateRequest, CNS_WatchStateServer) error
 	mustEmbedUnimplementedCNSServer()
 }
 
@@ -82,6 +203,27 @@ func (UnimplementedCNSServer) SetOrchestratorInfo(context.Context, *SetOrchestra
 func (UnimplementedCNSServer) GetNodeInfo(context.Context, *NodeInfoRequest) (*NodeInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetNodeInfo not implemented")
 }
+func (UnimplementedCNSServer) RequestIPConfigs(context.Context, *IPConfigsRequest) (*IPConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestIPConfigs not implemented")
+}
+func (UnimplementedCNSServer) ReleaseIPConfigs(context.Context, *IPConfigsRequest) (*ReleaseIPConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseIPConfigs not implemented")
+}
+func (UnimplementedCNSServer) GetIPAddressesMatchingStates(context.Context, *GetIPAddressesRequest) (*GetIPAddressesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIPAddressesMatchingStates not implemented")
+}
+func (UnimplementedCNSServer) GetEndpoint(context.Context, *GetEndpointRequest) (*GetEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEndpoint not implemented")
+}
+func (UnimplementedCNSServer) UpdateEndpoint(context.Context, *UpdateEndpointRequest) (*UpdateEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEndpoint not implemented")
+}
+func (UnimplementedCNSServer) DeleteEndpoint(context.Context, *DeleteEndpointRequest) (*DeleteEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEndpoint not implemented")
+}
+func (UnimplementedCNSServer) WatchState(*WatchStateRequest, CNS_WatchStateServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchState not implemented")
+}
 func (UnimplementedCNSServer) mustEmbedUnimplementedCNSServer() {}
 
 // UnsafeCNSServer may be embedded to opt out of forward compatibility for this service.
@@ -131,6 +273,135 @@ func _CNS_GetNodeInfo_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CNS_RequestIPConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).RequestIPConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_RequestIPConfigs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).RequestIPConfigs(ctx, req.(*IPConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_ReleaseIPConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).ReleaseIPConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_ReleaseIPConfigs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).ReleaseIPConfigs(ctx, req.(*IPConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_GetIPAddressesMatchingStates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIPAddressesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).GetIPAddressesMatchingStates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_GetIPAddressesMatchingStates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).GetIPAddressesMatchingStates(ctx, req.(*GetIPAddressesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_GetEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).GetEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_GetEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).GetEndpoint(ctx, req.(*GetEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_UpdateEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).UpdateEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_UpdateEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).UpdateEndpoint(ctx, req.(*UpdateEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_DeleteEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNSServer).DeleteEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNS_DeleteEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNSServer).DeleteEndpoint(ctx, req.(*DeleteEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNS_WatchState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CNSServer).WatchState(m, &cNSWatchStateServer{stream})
+}
+
+type CNS_WatchStateServer interface {
+	Send(*StateEvent) error
+	grpc.ServerStream
+}
+
+type cNSWatchStateServer struct {
+	grpc.ServerStream
+}
+
+func (x *cNSWatchStateServer) Send(m *StateEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CNS_ServiceDesc is the grpc.ServiceDesc for CNS service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -146,7 +417,37 @@ var CNS_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetNodeInfo",
 			Handler:    _CNS_GetNodeInfo_Handler,
 		},
+		{
+			MethodName: "RequestIPConfigs",
+			Handler:    _CNS_RequestIPConfigs_Handler,
+		},
+		{
+			MethodName: "ReleaseIPConfigs",
+			Handler:    _CNS_ReleaseIPConfigs_Handler,
+		},
+		{
+			MethodName: "GetIPAddressesMatchingStates",
+			Handler:    _CNS_GetIPAddressesMatchingStates_Handler,
+		},
+		{
+			MethodName: "GetEndpoint",
+			Handler:    _CNS_GetEndpoint_Handler,
+		},
+		{
+			MethodName: "UpdateEndpoint",
+			Handler:    _CNS_UpdateEndpoint_Handler,
+		},
+		{
+			MethodName: "DeleteEndpoint",
+			Handler:    _CNS_DeleteEndpoint_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchState",
+			Handler:       _CNS_WatchState_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "cns/grpc/proto/server.proto",
 }
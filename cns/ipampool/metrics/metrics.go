@@ -41,6 +41,14 @@ var (
 		},
 		[]string{SubnetLabel, SubnetCIDRLabel, PodnetARMIDLabel},
 	)
+	IpamCoolingIPCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cx_ipam_cooling_ips",
+			Help:        "IPs recently released by Pods and quarantined before they are available again (Cooling).",
+			ConstLabels: prometheus.Labels{customerMetricLabel: customerMetricLabelValue},
+		},
+		[]string{SubnetLabel, SubnetCIDRLabel, PodnetARMIDLabel},
+	)
 	IpamCurrentAvailableIPcount = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cx_ipam_current_available_ips",
@@ -135,6 +143,7 @@ func init() {
 		IpamAllocatedIPCount,
 		IpamAvailableIPCount,
 		IpamBatchSize,
+		IpamCoolingIPCount,
 		IpamCurrentAvailableIPcount,
 		IpamExpectedAvailableIPCount,
 		IpamMaxIPCount,
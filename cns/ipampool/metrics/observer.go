@@ -22,9 +22,11 @@ type ipPoolState struct {
 	allocatedToPods int64
 	// available are the IPs in state "Available".
 	available int64
-	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease.
+	// cooling are the IPs in state "Cooling".
+	cooling int64
+	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease - cooling.
 	currentAvailableIPs int64
-	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned.
+	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned - cooling.
 	expectedAvailableIPs int64
 	// pendingProgramming are the IPs in state "PendingProgramming".
 	pendingProgramming int64
@@ -158,6 +160,8 @@ func (o *observer) observeMetrics(ctx context.Context) error {
 					state.allocatedToPods++
 				case types.Available:
 					state.available++
+				case types.Cooling:
+					state.cooling++
 				case types.PendingProgramming:
 					state.pendingProgramming++
 				case types.PendingRelease:
@@ -170,14 +174,15 @@ func (o *observer) observeMetrics(ctx context.Context) error {
 
 	err := g.Wait()
 
-	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease
-	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods
+	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease - state.cooling
+	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods - state.cooling
 
 	// Update the metrics.
 	labels := []string{meta.subnet, meta.subnetCIDR, meta.subnetARMID}
 	IpamAllocatedIPCount.WithLabelValues(labels...).Set(float64(state.allocatedToPods))
 	IpamAvailableIPCount.WithLabelValues(labels...).Set(float64(state.available))
 	IpamBatchSize.WithLabelValues(labels...).Set(float64(meta.batch))
+	IpamCoolingIPCount.WithLabelValues(labels...).Set(float64(state.cooling))
 	IpamCurrentAvailableIPcount.WithLabelValues(labels...).Set(float64(state.currentAvailableIPs))
 	IpamExpectedAvailableIPCount.WithLabelValues(labels...).Set(float64(state.expectedAvailableIPs))
 	IpamMaxIPCount.WithLabelValues(labels...).Set(float64(meta.max))
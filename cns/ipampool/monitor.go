@@ -118,39 +118,9 @@ func (pm *Monitor) Start(ctx context.Context) error {
 				// if we have initialized and enter this case, we proceed out of the select and continue to reconcile.
 			}
 		case nnc := <-pm.nncSource: // received a new NodeNetworkConfig, extract the data from it and re-reconcile.
-			if len(nnc.Status.NetworkContainers) > 0 {
-				// Set SubnetName, SubnetAddressSpace and Pod Network ARM ID values to the global subnet, subnetCIDR and subnetARM variables.
-				pm.metastate.subnet = nnc.Status.NetworkContainers[0].SubnetName
-				pm.metastate.subnetCIDR = nnc.Status.NetworkContainers[0].SubnetAddressSpace
-				pm.metastate.subnetARMID = GenerateARMID(&nnc.Status.NetworkContainers[0])
+			if err := pm.applyNodeNetworkConfig(&nnc); err != nil {
+				return err
 			}
-			pm.metastate.primaryIPAddresses = make(map[string]struct{})
-			// Add Primary IP to Map, if not present.
-			// This is only for Swift i.e. if NC Type is vnet.
-			for i := 0; i < len(nnc.Status.NetworkContainers); i++ {
-				nc := nnc.Status.NetworkContainers[i]
-				if nc.Type == "" || nc.Type == v1alpha.VNET {
-					pm.metastate.primaryIPAddresses[nc.PrimaryIP] = struct{}{}
-				}
-
-				if nc.Type == v1alpha.VNETBlock {
-					primaryPrefix, err := netip.ParsePrefix(nc.PrimaryIP)
-					if err != nil {
-						return errors.Wrapf(err, "unable to parse ip prefix: %s", nc.PrimaryIP)
-					}
-					pm.metastate.primaryIPAddresses[primaryPrefix.Addr().String()] = struct{}{}
-				}
-			}
-
-			scaler := nnc.Status.Scaler
-			pm.metastate.batch = scaler.BatchSize
-			pm.metastate.max = scaler.MaxIPCount
-			pm.metastate.minFreeCount, pm.metastate.maxFreeCount = CalculateMinFreeIPs(scaler), CalculateMaxFreeIPs(scaler)
-			pm.once.Do(func() {
-				pm.spec = nnc.Spec // set the spec from the NNC initially (afterwards we write the Spec so we know target state).
-				logger.Printf("[ipam-pool-monitor] set initial pool spec %+v", pm.spec)
-				close(pm.started) // close the init channel the first time we fully receive a NodeNetworkConfig.
-			})
 		}
 		// if control has flowed through the select(s) to this point, we can now reconcile.
 		err := pm.reconcile(ctx)
@@ -160,15 +130,56 @@ func (pm *Monitor) Start(ctx context.Context) error {
 	}
 }
 
+// applyNodeNetworkConfig extracts the pool configuration from the NodeNetworkConfig.
+// The first NodeNetworkConfig received also sets the initial pool spec and starts the Monitor.
+func (pm *Monitor) applyNodeNetworkConfig(nnc *v1alpha.NodeNetworkConfig) error {
+	if len(nnc.Status.NetworkContainers) > 0 {
+		// Set SubnetName, SubnetAddressSpace and Pod Network ARM ID values to the global subnet, subnetCIDR and subnetARM variables.
+		pm.metastate.subnet = nnc.Status.NetworkContainers[0].SubnetName
+		pm.metastate.subnetCIDR = nnc.Status.NetworkContainers[0].SubnetAddressSpace
+		pm.metastate.subnetARMID = GenerateARMID(&nnc.Status.NetworkContainers[0])
+	}
+	pm.metastate.primaryIPAddresses = make(map[string]struct{})
+	// Add Primary IP to Map, if not present.
+	// This is only for Swift i.e. if NC Type is vnet.
+	for i := 0; i < len(nnc.Status.NetworkContainers); i++ {
+		nc := nnc.Status.NetworkContainers[i]
+		if nc.Type == "" || nc.Type == v1alpha.VNET {
+			pm.metastate.primaryIPAddresses[nc.PrimaryIP] = struct{}{}
+		}
+
+		if nc.Type == v1alpha.VNETBlock {
+			primaryPrefix, err := netip.ParsePrefix(nc.PrimaryIP)
+			if err != nil {
+				return errors.Wrapf(err, "unable to parse ip prefix: %s", nc.PrimaryIP)
+			}
+			pm.metastate.primaryIPAddresses[primaryPrefix.Addr().String()] = struct{}{}
+		}
+	}
+
+	scaler := nnc.Status.Scaler
+	pm.metastate.batch = scaler.BatchSize
+	pm.metastate.max = scaler.MaxIPCount
+	pm.metastate.minFreeCount, pm.metastate.maxFreeCount = CalculateMinFreeIPs(scaler), CalculateMaxFreeIPs(scaler)
+	pm.once.Do(func() {
+		pm.spec = nnc.Spec // set the spec from the NNC initially (afterwards we write the Spec so we know target state).
+		logger.Printf("[ipam-pool-monitor] set initial pool spec %+v", pm.spec)
+		close(pm.started) // close the init channel the first time we fully receive a NodeNetworkConfig.
+	})
+	return nil
+}
+
 // ipPoolState is the current actual state of the CNS IP pool.
 type ipPoolState struct {
 	// allocatedToPods are the IPs CNS gives to Pods.
 	allocatedToPods int64
 	// available are the IPs in state "Available".
 	available int64
-	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease.
+	// cooling are the IPs in state "Cooling".
+	cooling int64
+	// currentAvailableIPs are the current available IPs: allocated - assigned - pendingRelease - cooling.
 	currentAvailableIPs int64
-	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned.
+	// expectedAvailableIPs are the "future" available IPs, if the requested IP count is honored: requested - assigned - cooling.
 	expectedAvailableIPs int64
 	// pendingProgramming are the IPs in state "PendingProgramming".
 	pendingProgramming int64
@@ -192,14 +203,16 @@ func buildIPPoolState(ips map[string]cns.IPConfigurationStatus, spec v1alpha.Nod
 			state.allocatedToPods++
 		case types.Available:
 			state.available++
+		case types.Cooling:
+			state.cooling++
 		case types.PendingProgramming:
 			state.pendingProgramming++
 		case types.PendingRelease:
 			state.pendingRelease++
 		}
 	}
-	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease
-	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods
+	state.currentAvailableIPs = state.secondaryIPs - state.allocatedToPods - state.pendingRelease - state.cooling
+	state.expectedAvailableIPs = state.requestedIPs - state.allocatedToPods - state.cooling
 	return state
 }
 
@@ -458,6 +471,24 @@ func (pm *Monitor) Update(nnc *v1alpha.NodeNetworkConfig) error {
 	return nil
 }
 
+// Reconcile synchronously ingests the NodeNetworkConfig, if one is passed, and reconciles the pool once it has
+// received its first NodeNetworkConfig. It drives the Monitor without the Start loop, for callers which step it
+// deterministically such as the pool simulator, and must not be used together with Start.
+func (pm *Monitor) Reconcile(ctx context.Context, nnc *v1alpha.NodeNetworkConfig) error {
+	if nnc != nil {
+		pm.clampScaler(&nnc.Status.Scaler)
+		if err := pm.applyNodeNetworkConfig(nnc); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-pm.started:
+	default:
+		return nil
+	}
+	return pm.reconcile(ctx)
+}
+
 // clampScaler makes sure that the values stored in the scaler are sane.
 // we usually expect these to be correctly set for us, but we could crash
 // without these checks. if they are incorrectly set, there will be some weird
@@ -488,6 +519,7 @@ func observeIPPoolState(state ipPoolState, meta metaState) {
 	metrics.IpamAllocatedIPCount.WithLabelValues(labels...).Set(float64(state.allocatedToPods))
 	metrics.IpamAvailableIPCount.WithLabelValues(labels...).Set(float64(state.available))
 	metrics.IpamBatchSize.WithLabelValues(labels...).Set(float64(meta.batch))
+	metrics.IpamCoolingIPCount.WithLabelValues(labels...).Set(float64(state.cooling))
 	metrics.IpamCurrentAvailableIPcount.WithLabelValues(labels...).Set(float64(state.currentAvailableIPs))
 	metrics.IpamExpectedAvailableIPCount.WithLabelValues(labels...).Set(float64(state.expectedAvailableIPs))
 	metrics.IpamMaxIPCount.WithLabelValues(labels...).Set(float64(meta.max))
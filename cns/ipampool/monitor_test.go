@@ -3,12 +3,14 @@ package ipampool
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/fakes"
 	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/stretchr/testify/assert"
 )
@@ -608,6 +610,27 @@ func TestPoolDecreaseBatchSizeGreaterThanMaxPodIPCount(t *testing.T) {
 	assert.EqualValues(t, initState.max, poolmonitor.spec.RequestedIPCount)
 }
 
+func TestBuildIPPoolStateWithCooling(t *testing.T) {
+	ips := map[string]cns.IPConfigurationStatus{}
+	for i, state := range []types.IPState{
+		types.Assigned, types.Assigned, types.Available, types.Available,
+		types.Cooling, types.Cooling, types.Cooling, types.PendingRelease,
+	} {
+		ip := cns.IPConfigurationStatus{ID: strconv.Itoa(i)}
+		ip.SetState(state)
+		ips[ip.ID] = ip
+	}
+
+	state := buildIPPoolState(ips, v1alpha.NodeNetworkConfigSpec{RequestedIPCount: 7})
+	assert.EqualValues(t, 2, state.allocatedToPods)
+	assert.EqualValues(t, 2, state.available)
+	assert.EqualValues(t, 3, state.cooling)
+	assert.EqualValues(t, 1, state.pendingRelease)
+	// cooling IPs can't be given to pods yet, so they are not free.
+	assert.EqualValues(t, 2, state.currentAvailableIPs)
+	assert.EqualValues(t, 2, state.expectedAvailableIPs)
+}
+
 func TestCalculateIPs(t *testing.T) {
 	tests := []struct {
 		name        string
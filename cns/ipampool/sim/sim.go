@@ -0,0 +1,396 @@
+// Package sim simulates the CNS IPAM pool monitors against a scripted trace of pods arriving on and departing from a
+// node, so that the Scaler settings and scaling strategies can be compared offline before changing them in clusters.
+//
+// The simulation is deterministic: it steps a fake clock in fixed ticks, keeps the CNS IP state in the CNS fakes, and
+// stands in for the control plane with a NodeNetworkConfig updater which allocates and releases the requested IPs
+// after a fixed latency.
+package sim
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/ipampool"
+	v2 "github.com/Azure/azure-container-networking/cns/ipampool/v2"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// Mode is the pool monitor, and scaling strategy, which is simulated.
+type Mode string
+
+const (
+	// ModeV1 simulates the ipampool Monitor.
+	ModeV1 Mode = "v1"
+	// ModeV2 simulates the ipampool v2 Monitor with the reactive scaling strategy.
+	ModeV2 Mode = "v2"
+	// ModeV2Predictive simulates the ipampool v2 Monitor with the predictive scaling strategy.
+	ModeV2Predictive Mode = "v2-predictive"
+)
+
+// Modes are all the simulated Modes.
+var Modes = []Mode{ModeV1, ModeV2, ModeV2Predictive}
+
+const (
+	// DefaultAllocationLatency is the default delay for the control plane to allocate or release the requested IPs.
+	DefaultAllocationLatency = 5 * time.Second
+	// DefaultTick is the default step of the simulation clock.
+	DefaultTick = time.Second
+	// DefaultReconcileDelay is the default longest delay between v2 Monitor reconciles, as in its Start loop.
+	DefaultReconcileDelay = 60 * time.Second
+	// DefaultSettleTime is how long the simulation runs past the last event of the trace by default.
+	DefaultSettleTime = 10 * time.Minute
+)
+
+// Config is the configuration of a simulation.
+type Config struct {
+	Mode Mode
+	// Scaler is the scaler the control plane sets in the NodeNetworkConfig status.
+	Scaler v1alpha.Scaler
+	// InitialIPs are the IPs allocated to the node when the simulation starts. Defaults to a batch.
+	InitialIPs int64
+	// AllocationLatency is the delay from a NodeNetworkConfig spec patch to the IPs being allocated or released.
+	AllocationLatency time.Duration
+	// Tick is the step of the simulation clock. Pods get IPs and the Monitor reconciles on ticks.
+	Tick time.Duration
+	// RefreshDelay is the delay between the v1 Monitor reconciles.
+	RefreshDelay time.Duration
+	// ReconcileDelay is the longest delay between the v2 Monitor reconciles without any demand or NNC updates.
+	ReconcileDelay time.Duration
+	// Duration is the simulated time. Defaults to the last event of the trace plus the DefaultSettleTime.
+	Duration time.Duration
+	// Predictive configures the predictive scaling strategy of ModeV2Predictive.
+	Predictive v2.PredictiveOptions
+}
+
+func (c *Config) setDefaults(trace []Event) {
+	if c.Scaler.BatchSize < 1 {
+		c.Scaler.BatchSize = 1
+	}
+	if c.Scaler.MaxIPCount < 1 {
+		c.Scaler.MaxIPCount = ipampool.DefaultMaxIPs
+	}
+	if c.InitialIPs < 1 {
+		c.InitialIPs = c.Scaler.BatchSize
+	}
+	if c.AllocationLatency <= 0 {
+		c.AllocationLatency = DefaultAllocationLatency
+	}
+	if c.Tick <= 0 {
+		c.Tick = DefaultTick
+	}
+	if c.RefreshDelay <= 0 {
+		c.RefreshDelay = ipampool.DefaultRefreshDelay
+	}
+	if c.ReconcileDelay <= 0 {
+		c.ReconcileDelay = DefaultReconcileDelay
+	}
+	if c.Duration <= 0 {
+		if len(trace) > 0 {
+			c.Duration = trace[len(trace)-1].At
+		}
+		c.Duration += DefaultSettleTime
+	}
+}
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Mode Mode
+	// Pods is the number of pods which arrived.
+	Pods int
+	// PodsWithoutIP is the number of pods which departed, or were still waiting, without ever getting an IP.
+	PodsWithoutIP int
+	// TimeToIP are the statistics of the delay from pod arrival to the pod getting an IP.
+	TimeToIPMean, TimeToIPP50, TimeToIPP99, TimeToIPMax time.Duration
+	// NNCPatches is the number of NodeNetworkConfig spec patches made by the Monitor.
+	NNCPatches int
+	// PeakOverAllocation is the most IPs allocated to the node and not assigned to pods at once.
+	PeakOverAllocation int64
+	// ExhaustionEvents is the number of times the pool ran out of Available IPs with a pod waiting for one.
+	ExhaustionEvents int
+	// ReconcileErrors is the number of Monitor reconciles which failed.
+	ReconcileErrors int
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("%s: pods=%d without-ip=%d time-to-ip mean=%s p50=%s p99=%s max=%s nnc-patches=%d peak-over-allocation=%d exhaustion-events=%d reconcile-errors=%d", //nolint:lll // it's fine
+		r.Mode, r.Pods, r.PodsWithoutIP, r.TimeToIPMean, r.TimeToIPP50, r.TimeToIPP99, r.TimeToIPMax, r.NNCPatches, r.PeakOverAllocation,
+		r.ExhaustionEvents, r.ReconcileErrors)
+}
+
+// monitor adapts the pool monitors to the simulation.
+type monitor interface {
+	// reconcile is called every tick, with the pod IP demand and the NodeNetworkConfig if the control plane updated it.
+	reconcile(ctx context.Context, now time.Time, demand int64, nnc *v1alpha.NodeNetworkConfig) error
+}
+
+type v1Monitor struct {
+	pm           *ipampool.Monitor
+	refreshDelay time.Duration
+	last         time.Time
+}
+
+// reconcile reconciles on every NodeNetworkConfig update and once per RefreshDelay, as the v1 Monitor Start loop.
+func (m *v1Monitor) reconcile(ctx context.Context, now time.Time, _ int64, nnc *v1alpha.NodeNetworkConfig) error {
+	if nnc == nil && now.Sub(m.last) < m.refreshDelay {
+		return nil
+	}
+	m.last = now
+	return errors.Wrap(m.pm.Reconcile(ctx, nnc), "v1 monitor reconcile failed")
+}
+
+type v2Monitor struct {
+	pm             *v2.Monitor
+	reconcileDelay time.Duration
+	demand         int64
+	last           time.Time
+}
+
+// reconcile reconciles on every demand or NodeNetworkConfig update and at least once per ReconcileDelay,
+// as the v2 Monitor Start loop.
+func (m *v2Monitor) reconcile(ctx context.Context, now time.Time, demand int64, nnc *v1alpha.NodeNetworkConfig) error {
+	if nnc == nil && demand == m.demand && now.Sub(m.last) < m.reconcileDelay {
+		return nil
+	}
+	m.demand, m.last = demand, now
+	return errors.Wrap(m.pm.Reconcile(ctx, demand, nnc), "v2 monitor reconcile failed")
+}
+
+// fulfilment is a NodeNetworkConfig spec the control plane will have allocated at a time.
+type fulfilment struct {
+	at   time.Time
+	spec v1alpha.NodeNetworkConfigSpec
+}
+
+// controlPlane is the NodeNetworkConfig updater of the simulation. It allocates and releases IPs to match each
+// patched spec once the allocation latency has passed.
+type controlPlane struct {
+	clock   *clocktesting.FakePassiveClock
+	latency time.Duration
+	cns     *fakes.HTTPServiceFake
+	nnc     v1alpha.NodeNetworkConfig
+	pending []fulfilment
+	patches int
+	nextIP  netip.Addr
+	nextID  int
+}
+
+func (c *controlPlane) PatchSpec(_ context.Context, spec *v1alpha.NodeNetworkConfigSpec, _ string) (*v1alpha.NodeNetworkConfig, error) {
+	c.patches++
+	c.nnc.Spec = *spec.DeepCopy()
+	c.pending = append(c.pending, fulfilment{at: c.clock.Now().Add(c.latency), spec: c.nnc.Spec})
+	return c.nnc.DeepCopy(), nil
+}
+
+// fulfil allocates and releases the IPs of the specs which are due, and returns the updated NodeNetworkConfig if
+// there were any.
+func (c *controlPlane) fulfil() *v1alpha.NodeNetworkConfig {
+	now := c.clock.Now()
+	fulfilled := false
+	for len(c.pending) > 0 && !c.pending[0].at.After(now) {
+		c.allocate(c.pending[0].spec)
+		c.pending = c.pending[1:]
+		fulfilled = true
+	}
+	if !fulfilled {
+		return nil
+	}
+	return c.nnc.DeepCopy()
+}
+
+// allocate releases the IPs not in use by the spec and allocates new IPs up to the requested count.
+func (c *controlPlane) allocate(spec v1alpha.NodeNetworkConfigSpec) {
+	notInUse := map[string]struct{}{}
+	for _, id := range spec.IPsNotInUse {
+		notInUse[id] = struct{}{}
+	}
+	assignments := c.nnc.Status.NetworkContainers[0].IPAssignments[:0]
+	for _, ip := range c.nnc.Status.NetworkContainers[0].IPAssignments {
+		if _, ok := notInUse[ip.Name]; !ok {
+			assignments = append(assignments, ip)
+		}
+	}
+	c.cns.IPStateManager.RemovePendingReleaseIPConfigs(spec.IPsNotInUse)
+
+	var ipconfigs []cns.IPConfigurationStatus
+	for i := int64(len(assignments)); i < spec.RequestedIPCount; i++ {
+		c.nextID++
+		ip := v1alpha.IPAssignment{Name: fmt.Sprintf("ip-%d", c.nextID), IP: c.nextIP.String()}
+		c.nextIP = c.nextIP.Next()
+		assignments = append(assignments, ip)
+		ipconfig := cns.IPConfigurationStatus{ID: ip.Name, IPAddress: ip.IP}
+		ipconfig.SetState(types.Available)
+		ipconfigs = append(ipconfigs, ipconfig)
+	}
+	c.cns.IPStateManager.AddIPConfigs(ipconfigs)
+	c.nnc.Status.NetworkContainers[0].IPAssignments = assignments
+}
+
+// pod is a simulated pod, which holds the IP config ipID once it is assigned one.
+type pod struct {
+	arrived time.Time
+	ipID    string
+}
+
+// Run simulates the pool monitor of the Mode against the trace.
+// The v1 Monitor logs to the CNS logger, which must be initialized.
+func Run(ctx context.Context, cfg Config, trace []Event) (*Report, error) {
+	cfg.setDefaults(trace)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(start)
+	cnsfake := fakes.NewHTTPServiceFake()
+	cp := &controlPlane{
+		clock:   clock,
+		latency: cfg.AllocationLatency,
+		cns:     cnsfake,
+		nnc: v1alpha.NodeNetworkConfig{
+			Status: v1alpha.NodeNetworkConfigStatus{
+				Scaler: cfg.Scaler,
+				NetworkContainers: []v1alpha.NetworkContainer{
+					{
+						ID:                 "sim",
+						PrimaryIP:          "10.240.0.4",
+						SubnetName:         "sim",
+						SubnetAddressSpace: "10.240.0.0/16",
+						Type:               v1alpha.VNET,
+					},
+				},
+			},
+		},
+		nextIP: netip.MustParseAddr("10.240.0.5"),
+	}
+	cp.allocate(v1alpha.NodeNetworkConfigSpec{RequestedIPCount: cfg.InitialIPs})
+	cp.nnc.Spec.RequestedIPCount = cfg.InitialIPs
+
+	var m monitor
+	switch cfg.Mode {
+	case ModeV1:
+		pm := ipampool.NewMonitor(cnsfake, cp, nil, &ipampool.Options{RefreshDelay: cfg.RefreshDelay, MaxIPs: cfg.Scaler.MaxIPCount})
+		m = &v1Monitor{pm: pm, refreshDelay: cfg.RefreshDelay}
+	case ModeV2, ModeV2Predictive:
+		pm := v2.NewMonitor(zap.NewNop(), cnsfake, cp, nil, nil, nil)
+		pm.WithClock(clock)
+		if cfg.Mode == ModeV2Predictive {
+			pm.WithScalingStrategy(v2.NewPredictiveStrategy(cfg.Predictive))
+		}
+		m = &v2Monitor{pm: pm, reconcileDelay: cfg.ReconcileDelay}
+	default:
+		return nil, errors.Errorf("unknown mode %q", cfg.Mode)
+	}
+
+	s := &simulation{report: &Report{Mode: cfg.Mode}}
+	nnc := cp.nnc.DeepCopy()
+	for elapsed := time.Duration(0); elapsed <= cfg.Duration; elapsed += cfg.Tick {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "simulation cancelled")
+		}
+		clock.SetTime(start.Add(elapsed))
+		now := clock.Now()
+		for len(trace) > 0 && trace[0].At <= elapsed {
+			if err := s.schedule(cnsfake, now, trace[0].Pods); err != nil {
+				return nil, err
+			}
+			trace = trace[1:]
+		}
+		if updated := cp.fulfil(); updated != nil {
+			nnc = updated
+		}
+		s.assign(cnsfake, now)
+		if err := m.reconcile(ctx, now, int64(len(s.pods)), nnc); err != nil {
+			s.report.ReconcileErrors++
+		}
+		nnc = nil
+		if over := int64(len(cnsfake.GetPodIPConfigState()) - s.running); over > s.report.PeakOverAllocation {
+			s.report.PeakOverAllocation = over
+		}
+	}
+	s.finish(cp.patches)
+	return s.report, nil
+}
+
+// simulation is the state of the pods on the node.
+type simulation struct {
+	// pods are the pods on the node in arrival order. IPs are assigned in arrival order, so the pods with IPs are
+	// always before the pods waiting for one.
+	pods     []pod
+	running  int
+	waiting  bool
+	timeToIP []time.Duration
+	report   *Report
+}
+
+// schedule adds arriving pods to the node, or removes the oldest pods, releasing their IPs.
+func (s *simulation) schedule(cnsfake *fakes.HTTPServiceFake, now time.Time, n int) error {
+	for ; n > 0; n-- {
+		s.pods = append(s.pods, pod{arrived: now})
+		s.report.Pods++
+	}
+	for ; n < 0 && len(s.pods) > 0; n++ {
+		p := s.pods[0]
+		s.pods = s.pods[1:]
+		if p.ipID == "" {
+			s.report.PodsWithoutIP++
+			continue
+		}
+		if _, err := cnsfake.IPStateManager.ReleaseIPConfig(p.ipID); err != nil {
+			return errors.Wrapf(err, "failed to release IP %s", p.ipID)
+		}
+		s.running--
+	}
+	return nil
+}
+
+// assign assigns Available IPs to the waiting pods in arrival order.
+func (s *simulation) assign(cnsfake *fakes.HTTPServiceFake, now time.Time) {
+	for s.running < len(s.pods) {
+		ipconfig, err := cnsfake.IPStateManager.ReserveIPConfig()
+		if err != nil {
+			// the pool is exhausted, count it once until the waiting pods get IPs.
+			if !s.waiting {
+				s.waiting = true
+				s.report.ExhaustionEvents++
+			}
+			return
+		}
+		s.pods[s.running].ipID = ipconfig.ID
+		s.timeToIP = append(s.timeToIP, now.Sub(s.pods[s.running].arrived))
+		s.running++
+	}
+	s.waiting = false
+}
+
+// finish summarizes the simulation in the report.
+func (s *simulation) finish(patches int) {
+	s.report.NNCPatches = patches
+	s.report.PodsWithoutIP += len(s.pods) - s.running
+	if len(s.timeToIP) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), s.timeToIP...)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.report.TimeToIPMean = total / time.Duration(len(sorted))
+	s.report.TimeToIPP50 = percentile(sorted, 50)
+	s.report.TimeToIPP99 = percentile(sorted, 99)
+	s.report.TimeToIPMax = sorted[len(sorted)-1]
+}
+
+// percentile returns the nearest-rank p-th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
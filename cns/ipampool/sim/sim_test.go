@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	os.Exit(m.Run())
+}
+
+func TestParseTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		trace   string
+		want    []Event
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			trace: `# a deployment scaled up and down
+0s +10
+
+1m30s +5
+1m30s -3
+10m -12
+`,
+			want: []Event{{0, 10}, {90 * time.Second, 5}, {90 * time.Second, -3}, {10 * time.Minute, -12}},
+		},
+		{
+			name:    "unsigned pods",
+			trace:   "0s 10",
+			wantErr: true,
+		},
+		{
+			name:    "decreasing offset",
+			trace:   "1m +1\n30s +1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid offset",
+			trace:   "soon +1",
+			wantErr: true,
+		},
+		{
+			name:    "missing pods",
+			trace:   "1m",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseTrace(strings.NewReader(tt.trace))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// rollout is a deployment rolling out 100 pods, 10 every 10 seconds, which is deleted 5 minutes later.
+func rollout() []Event {
+	var trace []Event
+	for i := 0; i < 10; i++ {
+		trace = append(trace, Event{At: 30*time.Second + time.Duration(i)*10*time.Second, Pods: 10})
+	}
+	return append(trace, Event{At: 5 * time.Minute, Pods: -100})
+}
+
+var testScaler = v1alpha.Scaler{
+	BatchSize:               16,
+	RequestThresholdPercent: 50,
+	ReleaseThresholdPercent: 150,
+	MaxIPCount:              250,
+}
+
+func TestRun(t *testing.T) {
+	reports := map[Mode]*Report{}
+	for _, mode := range Modes {
+		cfg := Config{Mode: mode, Scaler: testScaler}
+		report, err := Run(context.Background(), cfg, rollout())
+		require.NoError(t, err)
+		assert.Equal(t, mode, report.Mode)
+		assert.Equal(t, 100, report.Pods)
+		assert.Zero(t, report.PodsWithoutIP, "%s", report)
+		assert.Zero(t, report.ReconcileErrors, "%s", report)
+		assert.Positive(t, report.NNCPatches, "%s", report)
+		assert.GreaterOrEqual(t, report.PeakOverAllocation, int64(100), "all the IPs are unused once the pods are deleted")
+
+		// the simulation is deterministic.
+		again, err := Run(context.Background(), cfg, rollout())
+		require.NoError(t, err)
+		assert.Equal(t, report, again)
+		reports[mode] = report
+	}
+
+	// the reactive monitors run out of IPs during the rollout, while the predictive one stays ahead of it.
+	for _, mode := range []Mode{ModeV1, ModeV2} {
+		assert.Positive(t, reports[mode].ExhaustionEvents, "%s", reports[mode])
+		assert.Equal(t, DefaultAllocationLatency, reports[mode].TimeToIPMax, "%s", reports[mode])
+	}
+	predictive := reports[ModeV2Predictive]
+	assert.Zero(t, predictive.ExhaustionEvents, "%s", predictive)
+	assert.Zero(t, predictive.TimeToIPMax, "%s", predictive)
+	assert.Greater(t, predictive.PeakOverAllocation, reports[ModeV2].PeakOverAllocation)
+}
+
+func TestRunMaxIPs(t *testing.T) {
+	scaler := testScaler
+	scaler.MaxIPCount = 64
+	for _, mode := range Modes {
+		report, err := Run(context.Background(), Config{Mode: mode, Scaler: scaler}, rollout())
+		require.NoError(t, err)
+		// the pods over the max IPs never get one.
+		assert.Equal(t, 36, report.PodsWithoutIP, "%s", report)
+		assert.Positive(t, report.ExhaustionEvents, "%s", report)
+	}
+}
+
+func TestRunUnknownMode(t *testing.T) {
+	_, err := Run(context.Background(), Config{Mode: "v3"}, rollout())
+	require.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(5), percentile(sorted, 50))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 99))
+	assert.Equal(t, time.Duration(1), percentile(sorted[:1], 50))
+}
@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Event is a change in the pods scheduled on the node, at an offset from the start of the simulation.
+// Positive Pods are pod arrivals, negative Pods are pod departures.
+type Event struct {
+	At   time.Duration
+	Pods int
+}
+
+// ParseTrace reads a pod trace, one Event per line formatted as "<offset> <+N|-N>", for example "1m30s +20".
+// Blank lines and lines starting with '#' are ignored. The offsets must not decrease.
+func ParseTrace(r io.Reader) ([]Event, error) {
+	var (
+		trace []Event
+		line  int
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("line %d: expected \"<offset> <+N|-N>\", got %q", line, text)
+		}
+		at, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid offset", line)
+		}
+		if at < 0 {
+			return nil, errors.Errorf("line %d: negative offset %s", line, at)
+		}
+		if len(trace) > 0 && at < trace[len(trace)-1].At {
+			return nil, errors.Errorf("line %d: offset %s is before the previous event", line, at)
+		}
+		if !strings.HasPrefix(fields[1], "+") && !strings.HasPrefix(fields[1], "-") {
+			return nil, errors.Errorf("line %d: pod count %q must be signed", line, fields[1])
+		}
+		pods, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid pod count", line)
+		}
+		trace = append(trace, Event{At: at, Pods: pods})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read trace")
+	}
+	return trace, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"k8s.io/utils/clock"
 )
 
 const (
@@ -25,6 +26,7 @@ type nodeNetworkConfigSpecUpdater interface {
 }
 
 type ipStateStore interface {
+	GetCoolingIPConfigs() []cns.IPConfigurationStatus
 	GetPendingReleaseIPConfigs() []cns.IPConfigurationStatus
 	MarkNIPsPendingRelease(n int) (map[string]cns.IPConfigurationStatus, error)
 }
@@ -39,6 +41,9 @@ type scaler struct {
 type Monitor struct {
 	z                     *zap.Logger
 	scaler                scaler
+	strategy              ScalingStrategy    // defaults to the ReactiveStrategy
+	strategyBypassed      bool               // the strategy was not used while the subnet was exhausted
+	clock                 clock.PassiveClock // defaults to the real clock
 	nnccli                nodeNetworkConfigSpecUpdater
 	store                 ipStateStore
 	demand                int64
@@ -82,15 +87,7 @@ func (pm *Monitor) Start(ctx context.Context) error {
 			pm.scaler.exhausted = css.Status.Exhausted
 			pm.z.Info("exhaustion update", zap.Bool("exhausted", pm.scaler.exhausted))
 		case nnc := <-pm.nncSource: // received a new NodeNetworkConfig, extract the data from it and recalculate request
-			pm.scaler.max = int64(math.Min(float64(nnc.Status.Scaler.MaxIPCount), DefaultMaxIPs))
-			pm.scaler.batch = int64(math.Min(math.Max(float64(nnc.Status.Scaler.BatchSize), 1), float64(pm.scaler.max)))
-			pm.scaler.buffer = math.Abs(float64(nnc.Status.Scaler.RequestThresholdPercent)) / 100 //nolint:gomnd // it's a percentage
-			pm.once.Do(func() {
-				pm.request = nnc.Spec.RequestedIPCount
-				close(pm.started) // close the init channel the first time we fully receive a NodeNetworkConfig.
-				pm.z.Debug("started", zap.Int64("initial request", pm.request))
-			})
-			pm.z.Info("scaler update", zap.Int64("batch", pm.scaler.batch), zap.Float64("buffer", pm.scaler.buffer), zap.Int64("max", pm.scaler.max), zap.Int64("request", pm.request))
+			pm.applyNodeNetworkConfig(&nnc)
 		case <-maxReconcileDelay.C: // try to reconcile the pool every maxReconcileDelay to prevent drift or lockups.
 		}
 		select {
@@ -109,6 +106,39 @@ func (pm *Monitor) Start(ctx context.Context) error {
 	}
 }
 
+// applyNodeNetworkConfig extracts the scaler from the NodeNetworkConfig.
+// The first NodeNetworkConfig received also sets the initial request and starts the Monitor.
+func (pm *Monitor) applyNodeNetworkConfig(nnc *v1alpha.NodeNetworkConfig) {
+	pm.scaler.max = int64(math.Min(float64(nnc.Status.Scaler.MaxIPCount), DefaultMaxIPs))
+	pm.scaler.batch = int64(math.Min(math.Max(float64(nnc.Status.Scaler.BatchSize), 1), float64(pm.scaler.max)))
+	pm.scaler.buffer = math.Abs(float64(nnc.Status.Scaler.RequestThresholdPercent)) / 100 //nolint:gomnd // it's a percentage
+	pm.once.Do(func() {
+		pm.request = nnc.Spec.RequestedIPCount
+		if pm.strategy != nil {
+			pm.strategy.Reset(pm.now(), pm.request)
+		}
+		close(pm.started) // close the init channel the first time we fully receive a NodeNetworkConfig.
+		pm.z.Debug("started", zap.Int64("initial request", pm.request))
+	})
+	pm.z.Info("scaler update", zap.Int64("batch", pm.scaler.batch), zap.Float64("buffer", pm.scaler.buffer), zap.Int64("max", pm.scaler.max), zap.Int64("request", pm.request))
+}
+
+// Reconcile synchronously sets the demand, ingests the NodeNetworkConfig if one is passed, and reconciles the pool
+// once it has received its first NodeNetworkConfig. It drives the Monitor without the Start loop, for callers which
+// step it deterministically such as the pool simulator, and must not be used together with Start.
+func (pm *Monitor) Reconcile(ctx context.Context, demand int64, nnc *v1alpha.NodeNetworkConfig) error {
+	pm.demand = demand
+	if nnc != nil {
+		pm.applyNodeNetworkConfig(nnc)
+	}
+	select {
+	case <-pm.started:
+	default:
+		return nil
+	}
+	return pm.reconcile(ctx)
+}
+
 func (pm *Monitor) reconcile(ctx context.Context) error {
 	// if the subnet is exhausted, locally overwrite the batch/minfree/maxfree in the meta copy for this iteration
 	// (until the controlplane owns this and modifies the scaler values for us directly instead of writing "exhausted")
@@ -119,9 +149,28 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 		s.buffer = 1
 	}
 
-	// calculate the target state from the current pool state and scaler
-	target := calculateTargetIPCountOrMax(pm.demand, s.batch, s.max, s.buffer)
-	pm.z.Info("calculated new request", zap.Int64("demand", pm.demand), zap.Int64("batch", s.batch), zap.Int64("max", s.max), zap.Float64("buffer", s.buffer), zap.Int64("target", target))
+	// IPs released by pods are quarantined in Cooling before they can be reused, so they are still in demand
+	// until they become Available.
+	cooling := int64(len(pm.store.GetCoolingIPConfigs()))
+	demand := pm.demand + cooling
+
+	// calculate the target state from the current pool state and scaler.
+	// if the subnet is exhausted, don't request IPs ahead of the demand. once it is no longer exhausted, the
+	// strategy resumes from the request made meanwhile instead of its state from before the exhaustion.
+	now := pm.now()
+	strategy := pm.strategy
+	switch {
+	case strategy == nil:
+		strategy = ReactiveStrategy{}
+	case s.exhausted:
+		strategy = ReactiveStrategy{}
+		pm.strategyBypassed = true
+	case pm.strategyBypassed:
+		strategy.Reset(now, pm.request)
+		pm.strategyBypassed = false
+	}
+	target := strategy.Target(now, demand, s.batch, s.max, s.buffer)
+	pm.z.Info("calculated new request", zap.Int64("demand", pm.demand), zap.Int64("cooling", cooling), zap.Int64("batch", s.batch), zap.Int64("max", s.max), zap.Float64("buffer", s.buffer), zap.Int64("target", target)) //nolint:lll // it's fine
 	delta := target - pm.request
 	if delta == 0 {
 		pm.z.Info("NNC already at target IPs, no scaling required")
@@ -159,6 +208,23 @@ func (pm *Monitor) WithLegacyMetricsObserver(observer func(context.Context) erro
 	pm.legacyMetricsObserver = observer
 }
 
+// WithClock sets the clock the Monitor reads the time of each reconcile from.
+func (pm *Monitor) WithClock(c clock.PassiveClock) {
+	pm.clock = c
+}
+
+func (pm *Monitor) now() time.Time {
+	if pm.clock == nil {
+		return time.Now()
+	}
+	return pm.clock.Now()
+}
+
+// WithScalingStrategy sets the strategy used to calculate the IP count request from the demand.
+func (pm *Monitor) WithScalingStrategy(strategy ScalingStrategy) {
+	pm.strategy = strategy
+}
+
 // calculateTargetIPCountOrMax calculates the target IP count request
 // using the scaling function and clamps the result at the max IPs.
 func calculateTargetIPCountOrMax(demand, batch, max int64, buffer float64) int64 {
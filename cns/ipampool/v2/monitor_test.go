@@ -18,10 +18,15 @@ import (
 )
 
 type ipStateStoreMock struct {
+	coolingIPConfigs        map[string]cns.IPConfigurationStatus
 	pendingReleaseIPConfigs map[string]cns.IPConfigurationStatus
 	err                     error
 }
 
+func (m *ipStateStoreMock) GetCoolingIPConfigs() []cns.IPConfigurationStatus {
+	return maps.Values(m.coolingIPConfigs)
+}
+
 func (m *ipStateStoreMock) GetPendingReleaseIPConfigs() []cns.IPConfigurationStatus {
 	return maps.Values(m.pendingReleaseIPConfigs)
 }
@@ -330,6 +335,41 @@ func TestReconcile(t *testing.T) {
 			wantRequest:        16,
 			wantPendingRelease: 32,
 		},
+		// cooling IPs can't be reused yet, so they hold the pool up
+		{
+			name:    "no scale down with cooling",
+			demand:  5,
+			request: 32,
+			scaler: scaler{
+				batch:  16,
+				buffer: .5,
+				max:    250,
+			},
+			nnccli: nncClientMock{
+				req: v1alpha.NodeNetworkConfigSpec{
+					RequestedIPCount: 32,
+				},
+			},
+			store: ipStateStoreMock{
+				coolingIPConfigs: pendingReleaseGenerator(10),
+			},
+			wantRequest: 32,
+		},
+		{
+			name:    "single scale up with cooling",
+			demand:  6,
+			request: 16,
+			scaler: scaler{
+				batch:  16,
+				buffer: .5,
+				max:    250,
+			},
+			nnccli: nncClientMock{},
+			store: ipStateStoreMock{
+				coolingIPConfigs: pendingReleaseGenerator(8),
+			},
+			wantRequest: 32,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,138 @@
+package v2
+
+import (
+	"math"
+	"time"
+)
+
+// ScalingStrategy calculates the IP count the Monitor requests for the pod IP demand.
+type ScalingStrategy interface {
+	// Target returns the IP count to request for the demand observed at now, for the passed scaler values.
+	// It is called on every reconcile, with non-decreasing times.
+	Target(now time.Time, demand, batch, maxIPs int64, buffer float64) int64
+	// Reset drops the state the strategy kept from previous calls to Target, and takes request as the current
+	// IP count request. It is called when the Monitor starts, and when it resumes using the strategy after
+	// requesting IPs without it.
+	Reset(now time.Time, request int64)
+}
+
+// ReactiveStrategy sizes the pool for the current demand only.
+// It is the default ScalingStrategy.
+type ReactiveStrategy struct{}
+
+func (ReactiveStrategy) Target(_ time.Time, demand, batch, maxIPs int64, buffer float64) int64 {
+	return calculateTargetIPCountOrMax(demand, batch, maxIPs, buffer)
+}
+
+func (ReactiveStrategy) Reset(time.Time, int64) {}
+
+const (
+	// DefaultPredictiveWindow is the default duration of the demand samples the growth rate is estimated over.
+	DefaultPredictiveWindow = 2 * time.Minute
+	// DefaultPredictiveHorizon is the default duration ahead of the current demand that IPs are requested for.
+	DefaultPredictiveHorizon = 30 * time.Second
+	// DefaultPredictiveReleaseDelay is the default duration a lower target must hold before the pool is scaled down.
+	DefaultPredictiveReleaseDelay = 5 * time.Minute
+)
+
+// PredictiveOptions configures a PredictiveStrategy. Unset values take the defaults.
+type PredictiveOptions struct {
+	// Window is the duration of the demand samples the growth rate is estimated over.
+	Window time.Duration
+	// Horizon is how far ahead the demand is extrapolated at the estimated growth rate.
+	Horizon time.Duration
+	// ReleaseDelay is how long the target must stay below the current one before the pool is scaled down,
+	// and the delay between each following scale down.
+	ReleaseDelay time.Duration
+}
+
+type demandSample struct {
+	at     time.Time
+	demand int64
+}
+
+// PredictiveStrategy requests IPs ahead of the demand while the demand is growing, so that bursts of pods such as
+// deployment rollouts or jobs don't wait on the pool to scale up, and releases them slowly once the demand drops.
+//
+// The growth rate is the change in demand across a sliding window of samples, averaged over at least the full window
+// so that a single step in the demand is not extrapolated as a steep ramp. The demand is extrapolated at that rate over
+// the horizon, and the pool is sized for it as by the ReactiveStrategy.
+// Scaling down is hysteretic: a lower target must hold for the release delay, and the pool then shrinks by at most a
+// batch per release delay.
+type PredictiveStrategy struct {
+	opts    PredictiveOptions
+	samples []demandSample
+	target  int64
+	// held is the last time the current target was needed.
+	held time.Time
+}
+
+// NewPredictiveStrategy returns a PredictiveStrategy configured with opts.
+func NewPredictiveStrategy(opts PredictiveOptions) *PredictiveStrategy {
+	if opts.Window <= 0 {
+		opts.Window = DefaultPredictiveWindow
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = DefaultPredictiveHorizon
+	}
+	if opts.ReleaseDelay <= 0 {
+		opts.ReleaseDelay = DefaultPredictiveReleaseDelay
+	}
+	return &PredictiveStrategy{opts: opts}
+}
+
+func (p *PredictiveStrategy) Target(now time.Time, demand, batch, maxIPs int64, buffer float64) int64 {
+	p.observe(now, demand)
+	target := calculateTargetIPCountOrMax(p.predict(), batch, maxIPs, buffer)
+	// the max IPs may have been lowered below the target held from before.
+	p.target = min(p.target, maxIPs)
+
+	if target >= p.target {
+		p.target = target
+		p.held = now
+		return p.target
+	}
+	if now.Sub(p.held) < p.opts.ReleaseDelay {
+		return p.target
+	}
+	// release at most one batch, and hold the new target for another release delay.
+	p.target = max(target, p.target-batch)
+	p.held = now
+	return p.target
+}
+
+// Reset forgets the demand samples and holds request as the current target, so that the pool is not scaled
+// down before the release delay from a target the Monitor never requested.
+func (p *PredictiveStrategy) Reset(now time.Time, request int64) {
+	p.samples = nil
+	p.target = request
+	p.held = now
+}
+
+// observe records the demand sample and drops the samples which fell out of the window.
+// The newest sample older than the window is kept as the baseline the growth is measured from.
+func (p *PredictiveStrategy) observe(now time.Time, demand int64) {
+	p.samples = append(p.samples, demandSample{at: now, demand: demand})
+	start := now.Add(-p.opts.Window)
+	i := 0
+	for i < len(p.samples)-1 && !p.samples[i+1].at.After(start) {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
+
+// predict returns the demand extrapolated over the horizon at the growth rate across the window.
+// A shrinking demand is not extrapolated, scaling down is left to the release hysteresis.
+func (p *PredictiveStrategy) predict() int64 {
+	first, last := p.samples[0], p.samples[len(p.samples)-1]
+	growth := last.demand - first.demand
+	if growth <= 0 {
+		return last.demand
+	}
+	span := last.at.Sub(first.at)
+	if span < p.opts.Window {
+		span = p.opts.Window
+	}
+	rate := float64(growth) / span.Seconds()
+	return last.demand + int64(math.Ceil(rate*p.opts.Horizon.Seconds()))
+}
@@ -0,0 +1,199 @@
+package v2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// traceStep is a demand sample of a recorded trace, along with the targets the strategies should calculate for it.
+type traceStep struct {
+	at         time.Duration
+	demand     int64
+	predictive int64
+	reactive   int64
+}
+
+func TestScalingStrategyTraces(t *testing.T) {
+	const (
+		batch  = 16
+		buffer = .5
+		maxIPs = 250
+	)
+	opts := PredictiveOptions{
+		Window:       time.Minute,
+		Horizon:      30 * time.Second,
+		ReleaseDelay: 2 * time.Minute,
+	}
+	tests := []struct {
+		name  string
+		trace []traceStep
+	}{
+		{
+			name: "steady demand",
+			trace: []traceStep{
+				{0, 20, 32, 32},
+				{10 * time.Second, 20, 32, 32},
+				{20 * time.Second, 20, 32, 32},
+				{60 * time.Second, 20, 32, 32},
+				{120 * time.Second, 20, 32, 32},
+			},
+		},
+		{
+			// a deployment rolling out 10 pods every 10 seconds: the predictive strategy stays ahead of the demand.
+			name: "deployment rollout",
+			trace: []traceStep{
+				{0, 10, 32, 32},
+				{10 * time.Second, 10, 32, 32},
+				{20 * time.Second, 10, 32, 32},
+				{30 * time.Second, 20, 48, 32},
+				{40 * time.Second, 30, 48, 48},
+				{50 * time.Second, 40, 64, 48},
+				{60 * time.Second, 50, 80, 64},
+				{70 * time.Second, 60, 96, 80},
+				{80 * time.Second, 70, 112, 80},
+				{90 * time.Second, 80, 128, 96},
+				{100 * time.Second, 80, 128, 96},
+				{110 * time.Second, 80, 128, 96},
+			},
+		},
+		{
+			// a cronjob starting 40 pods at once, which complete two minutes later.
+			name: "cronjob",
+			trace: []traceStep{
+				{0, 5, 16, 16},
+				{60 * time.Second, 5, 16, 16},
+				{120 * time.Second, 45, 80, 64},
+				{130 * time.Second, 45, 80, 64},
+				{180 * time.Second, 45, 80, 64},
+				{240 * time.Second, 5, 80, 16},
+				{300 * time.Second, 5, 64, 16},
+				{360 * time.Second, 5, 64, 16},
+				{420 * time.Second, 5, 48, 16},
+				{540 * time.Second, 5, 32, 16},
+				{660 * time.Second, 5, 16, 16},
+				{720 * time.Second, 5, 16, 16},
+			},
+		},
+		{
+			// a node being drained is released a batch at a time.
+			name: "drain",
+			trace: []traceStep{
+				{0, 100, 112, 112},
+				{30 * time.Second, 60, 112, 80},
+				{60 * time.Second, 20, 112, 32},
+				{90 * time.Second, 20, 112, 32},
+				{120 * time.Second, 20, 96, 32},
+				{180 * time.Second, 20, 96, 32},
+				{240 * time.Second, 20, 80, 32},
+				{360 * time.Second, 20, 64, 32},
+			},
+		},
+		{
+			name: "clamped at max",
+			trace: []traceStep{
+				{0, 100, 112, 112},
+				{30 * time.Second, 200, 250, 208},
+				{60 * time.Second, 240, 250, 250},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			predictive := NewPredictiveStrategy(opts)
+			reactive := ReactiveStrategy{}
+			for _, step := range tt.trace {
+				now := start.Add(step.at)
+				assert.Equal(t, step.predictive, predictive.Target(now, step.demand, batch, maxIPs, buffer), "predictive target at %s", step.at)
+				assert.Equal(t, step.reactive, reactive.Target(now, step.demand, batch, maxIPs, buffer), "reactive target at %s", step.at)
+			}
+		})
+	}
+}
+
+func TestNewPredictiveStrategyDefaults(t *testing.T) {
+	p := NewPredictiveStrategy(PredictiveOptions{Horizon: time.Minute})
+	assert.Equal(t, DefaultPredictiveWindow, p.opts.Window)
+	assert.Equal(t, time.Minute, p.opts.Horizon)
+	assert.Equal(t, DefaultPredictiveReleaseDelay, p.opts.ReleaseDelay)
+}
+
+func TestReconcileWithScalingStrategy(t *testing.T) {
+	nnccli := &nncClientMock{}
+	pm := &Monitor{
+		z:       zap.NewNop(),
+		request: 16,
+		scaler: scaler{
+			batch:  16,
+			buffer: .5,
+			max:    250,
+		},
+		nnccli: nnccli,
+		store:  &ipStateStoreMock{},
+	}
+	predictive := NewPredictiveStrategy(PredictiveOptions{})
+	pm.WithScalingStrategy(predictive)
+
+	// the predictive strategy requests ahead of a growing demand.
+	predictive.Target(time.Now().Add(-time.Minute), 0, 16, 250, .5)
+	pm.demand = 40
+	require.NoError(t, pm.reconcile(context.Background()))
+	assert.EqualValues(t, 64, pm.request)
+	assert.EqualValues(t, 64, nnccli.req.RequestedIPCount)
+
+	// unless the subnet is exhausted.
+	pm.scaler.exhausted = true
+	nnccli.req = v1alpha.NodeNetworkConfigSpec{}
+	require.NoError(t, pm.reconcile(context.Background()))
+	assert.EqualValues(t, 41, pm.request)
+	assert.EqualValues(t, 41, nnccli.req.RequestedIPCount)
+}
+
+func TestPredictiveStrategyReset(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewPredictiveStrategy(PredictiveOptions{Window: time.Minute, Horizon: 30 * time.Second, ReleaseDelay: 5 * time.Minute})
+	p.Target(start, 0, 16, 250, .5)
+	assert.EqualValues(t, 80, p.Target(start.Add(time.Minute), 40, 16, 250, .5))
+
+	// the request made meanwhile is held for the release delay, and the growth from before is forgotten.
+	p.Reset(start.Add(2*time.Minute), 112)
+	assert.EqualValues(t, 112, p.Target(start.Add(2*time.Minute), 40, 16, 250, .5))
+	assert.EqualValues(t, 112, p.Target(start.Add(6*time.Minute), 40, 16, 250, .5))
+	assert.EqualValues(t, 96, p.Target(start.Add(7*time.Minute), 40, 16, 250, .5))
+}
+
+func TestMonitorSeedsScalingStrategy(t *testing.T) {
+	nnccli := &nncClientMock{}
+	pm := NewMonitor(zap.NewNop(), &ipStateStoreMock{}, nnccli, nil, nil, nil)
+	predictive := NewPredictiveStrategy(PredictiveOptions{})
+	pm.WithScalingStrategy(predictive)
+
+	// the first NodeNetworkConfig seeds the strategy with its request, which is held instead of scaled down at once.
+	nnc := &v1alpha.NodeNetworkConfig{
+		Spec: v1alpha.NodeNetworkConfigSpec{RequestedIPCount: 64},
+		Status: v1alpha.NodeNetworkConfigStatus{
+			Scaler: v1alpha.Scaler{BatchSize: 16, RequestThresholdPercent: 50, MaxIPCount: 250},
+		},
+	}
+	require.NoError(t, pm.Reconcile(context.Background(), 0, nnc))
+	assert.EqualValues(t, 64, pm.request)
+
+	// the Monitor requests IPs without the strategy while the subnet is exhausted.
+	pm.scaler.exhausted = true
+	require.NoError(t, pm.Reconcile(context.Background(), 100, nil))
+	assert.EqualValues(t, 101, pm.request)
+
+	// and the strategy resumes from that request once the subnet is no longer exhausted.
+	pm.scaler.exhausted = false
+	require.NoError(t, pm.Reconcile(context.Background(), 0, nil))
+	assert.EqualValues(t, 101, pm.request)
+	assert.EqualValues(t, 101, predictive.target)
+}
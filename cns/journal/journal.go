@@ -0,0 +1,314 @@
+// Package journal implements a crash-consistent, append-only journal of the CNS IPAM mutations.
+//
+// Every record is written on its own line as the CRC32 of its JSON encoding followed by the JSON encoding, and is
+// synced to disk before Append returns. A record torn by a crash is detected by its checksum when the journal is
+// opened, and the journal is truncated to the last complete record.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/pkg/errors"
+)
+
+// Op is the IPAM mutation recorded by an Entry.
+type Op string
+
+const (
+	// OpAssign records an IP assigned to a pod.
+	OpAssign Op = "Assign"
+	// OpRelease records an IP released by a pod.
+	OpRelease Op = "Release"
+	// OpMarkPendingRelease records an IP marked to be released back to the subnet.
+	OpMarkPendingRelease Op = "MarkPendingRelease"
+	// OpCreateNC records a network container created or updated.
+	OpCreateNC Op = "CreateNC"
+	// OpDeleteNC records a network container deleted, along with all its IPs.
+	OpDeleteNC Op = "DeleteNC"
+)
+
+// DefaultCompactThreshold is the number of entries appended after which the journal compacts itself.
+const DefaultCompactThreshold = 16384
+
+// Entry is a single IPAM mutation.
+type Entry struct {
+	Seq              uint64    `json:"seq"`
+	Timestamp        time.Time `json:"ts"`
+	Op               Op        `json:"op"`
+	NCID             string    `json:"nc,omitempty"`
+	IPConfigID       string    `json:"id,omitempty"`
+	IPAddress        string    `json:"ip,omitempty"`
+	PodName          string    `json:"pod,omitempty"`
+	PodNamespace     string    `json:"ns,omitempty"`
+	InfraContainerID string    `json:"infra,omitempty"`
+	InterfaceID      string    `json:"iface,omitempty"`
+}
+
+func (e *Entry) String() string {
+	switch e.Op {
+	case OpCreateNC, OpDeleteNC:
+		return fmt.Sprintf("%s #%d %s %s", e.Timestamp.Format(time.RFC3339Nano), e.Seq, e.Op, e.NCID)
+	case OpAssign:
+		return fmt.Sprintf("%s #%d %s %s (%s) in %s to pod %s/%s infra %s interface %s", e.Timestamp.Format(time.RFC3339Nano), e.Seq,
+			e.Op, e.IPAddress, e.IPConfigID, e.NCID, e.PodNamespace, e.PodName, e.InfraContainerID, e.InterfaceID)
+	case OpRelease:
+		return fmt.Sprintf("%s #%d %s %s (%s) in %s from pod %s/%s infra %s interface %s", e.Timestamp.Format(time.RFC3339Nano), e.Seq,
+			e.Op, e.IPAddress, e.IPConfigID, e.NCID, e.PodNamespace, e.PodName, e.InfraContainerID, e.InterfaceID)
+	default:
+		return fmt.Sprintf("%s #%d %s %s (%s) in %s", e.Timestamp.Format(time.RFC3339Nano), e.Seq, e.Op, e.IPAddress, e.IPConfigID, e.NCID)
+	}
+}
+
+// Journal is an append-only file of IPAM mutations, along with the IPAM state they describe.
+type Journal struct {
+	sync.Mutex
+	path             string
+	file             *os.File
+	seq              uint64
+	appended         int
+	state            State
+	compactThreshold int
+	now              func() time.Time
+}
+
+// Open opens the journal at path, creating it if it does not exist, and replays its entries.
+// A torn or corrupt tail, left by a crash while appending, is truncated.
+func Open(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644) //nolint:gomnd // same mode as the CNS store
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open journal %s", path)
+	}
+
+	entries, valid, err := decode(file)
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "failed to read journal %s", path)
+	}
+
+	if size, err := file.Seek(0, io.SeekEnd); err == nil && size > valid {
+		logger.Errorf("[journal] truncating %d bytes of torn or corrupt records at offset %d of %s", size-valid, valid, path)
+		if err := file.Truncate(valid); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to truncate journal %s", path)
+		}
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "failed to seek journal %s", path)
+	}
+
+	j := &Journal{
+		path:             path,
+		file:             file,
+		state:            NewState(),
+		compactThreshold: DefaultCompactThreshold,
+		now:              time.Now,
+	}
+	for i := range entries {
+		j.state.Apply(&entries[i])
+		j.seq = entries[i].Seq
+	}
+	j.appended = len(entries)
+	logger.Printf("[journal] replayed %d entries from %s: %d NCs, %d assigned IPs", len(entries), path, len(j.state.NCs), len(j.state.Assigned))
+	return j, nil
+}
+
+// ReadFile returns the entries of the journal at path, up to the first torn or corrupt record.
+// It does not modify the journal, so it is safe to use while CNS is running.
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open journal %s", path)
+	}
+	defer file.Close()
+	entries, _, err := decode(file)
+	return entries, errors.Wrapf(err, "failed to read journal %s", path)
+}
+
+// State returns a copy of the IPAM state described by the journal.
+func (j *Journal) State() State {
+	j.Lock()
+	defer j.Unlock()
+	return j.state.Copy()
+}
+
+// Append stamps the entries with the next sequence numbers and the current time, and durably appends them to the
+// journal. The journal is compacted once enough entries have been appended since the last compaction.
+func (j *Journal) Append(entries ...Entry) error {
+	j.Lock()
+	defer j.Unlock()
+
+	var buf bytes.Buffer
+	now := j.now().UTC().Round(0)
+	for i := range entries {
+		j.seq++
+		entries[i].Seq = j.seq
+		entries[i].Timestamp = now
+		if err := encode(&buf, &entries[i]); err != nil {
+			return err
+		}
+	}
+	if err := j.append(buf.Bytes()); err != nil {
+		return err
+	}
+	for i := range entries {
+		j.state.Apply(&entries[i])
+	}
+
+	j.appended += len(entries)
+	if j.compactThreshold > 0 && j.appended >= j.compactThreshold {
+		// the entries are durable, so a failed compaction is retried on a later append rather than failing this one
+		if err := j.compact(); err != nil {
+			logger.Errorf("[journal] failed to compact %s: %v", j.path, err)
+		}
+	}
+	return nil
+}
+
+// Compact atomically replaces the journal with the minimal entries describing its current state:
+// the network containers and the assigned IPs.
+func (j *Journal) Compact() error {
+	j.Lock()
+	defer j.Unlock()
+	return j.compact()
+}
+
+func (j *Journal) compact() error {
+	entries := j.state.Entries()
+
+	var buf bytes.Buffer
+	for i := range entries {
+		if err := encode(&buf, &entries[i]); err != nil {
+			return err
+		}
+	}
+
+	dir, name := filepath.Split(j.path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to create compacted journal")
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to write compacted journal")
+	}
+
+	// the journal can't be replaced while it is open on windows.
+	j.file.Close()
+	replaceErr := platform.ReplaceFile(tmp.Name(), j.path)
+	if replaceErr != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	if j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o644); err != nil { //nolint:gomnd // same mode as the CNS store
+		return errors.Wrapf(err, "failed to reopen journal %s", j.path)
+	}
+	if replaceErr != nil {
+		return errors.Wrap(replaceErr, "failed to replace journal with compacted journal")
+	}
+
+	logger.Printf("[journal] compacted %d entries to %d", j.appended, len(entries))
+	j.appended = len(entries)
+	return nil
+}
+
+// append writes b at the end of the journal and syncs it, so that the entries are durable once it returns.
+// If the write fails, the journal is truncated back so that a partial record does not hide the ones appended later.
+func (j *Journal) append(b []byte) error {
+	offset, err := j.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrapf(err, "failed to seek journal %s", j.path)
+	}
+	if _, err = j.file.Write(b); err == nil {
+		err = j.file.Sync()
+	}
+	if err != nil {
+		_ = j.file.Truncate(offset)
+		return errors.Wrapf(err, "failed to append to journal %s", j.path)
+	}
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	j.Lock()
+	defer j.Unlock()
+	return errors.Wrap(j.file.Close(), "failed to close journal")
+}
+
+// encode writes the record of e to buf.
+func encode(buf *bytes.Buffer, e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode journal entry")
+	}
+	fmt.Fprintf(buf, "%08x %s\n", crc32.ChecksumIEEE(b), b)
+	return nil
+}
+
+// decode reads the records from r up to the first torn or corrupt one, and returns their entries along with the
+// length of the valid records.
+func decode(r io.Reader) ([]Entry, int64, error) {
+	var (
+		entries []Entry
+		valid   int64
+	)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a record without its newline was torn while being written.
+			return entries, valid, nil
+		}
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to read record")
+		}
+		e, ok := decodeRecord(line[:len(line)-1])
+		if !ok {
+			return entries, valid, nil
+		}
+		entries = append(entries, e)
+		valid += int64(len(line))
+	}
+}
+
+func decodeRecord(record []byte) (Entry, bool) {
+	const crcLen = 8
+	if len(record) < crcLen+1 || record[crcLen] != ' ' {
+		return Entry{}, false
+	}
+	crc := make([]byte, 4) //nolint:gomnd // crc32 size
+	if _, err := hex.Decode(crc, record[:crcLen]); err != nil {
+		return Entry{}, false
+	}
+	b := record[crcLen+1:]
+	if crc32.ChecksumIEEE(b) != uint32(crc[0])<<24|uint32(crc[1])<<16|uint32(crc[2])<<8|uint32(crc[3]) {
+		return Entry{}, false
+	}
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return Entry{}, false
+	}
+	return e, true
+}
@@ -0,0 +1,173 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	createNC = Entry{Op: OpCreateNC, NCID: "nc1"}
+	assign1  = Entry{Op: OpAssign, NCID: "nc1", IPConfigID: "id1", IPAddress: "10.0.0.1", PodName: "pod1", PodNamespace: "ns", InfraContainerID: "infra1", InterfaceID: "infra1-eth0"}
+	assign2  = Entry{Op: OpAssign, NCID: "nc1", IPConfigID: "id2", IPAddress: "10.0.0.2", PodName: "pod2", PodNamespace: "ns", InfraContainerID: "infra2", InterfaceID: "infra2-eth0"}
+	release1 = Entry{Op: OpRelease, NCID: "nc1", IPConfigID: "id1", IPAddress: "10.0.0.1", PodName: "pod1", PodNamespace: "ns", InfraContainerID: "infra1", InterfaceID: "infra1-eth0"}
+)
+
+func openTestJournal(t *testing.T, path string) *Journal {
+	t.Helper()
+	j, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = j.Close() })
+	return j
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	require.NoError(t, j.Append(createNC))
+	require.NoError(t, j.Append(assign1, assign2))
+	require.NoError(t, j.Append(release1))
+	require.NoError(t, j.Close())
+
+	entries, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for i := range entries {
+		assert.EqualValues(t, i+1, entries[i].Seq)
+		assert.False(t, entries[i].Timestamp.IsZero())
+	}
+
+	reopened := openTestJournal(t, path)
+	s := reopened.State()
+	assert.Contains(t, s.NCs, "nc1")
+	require.Len(t, s.Assigned, 1)
+	assert.Equal(t, "10.0.0.2", s.Assigned["id2"].IPAddress)
+	assert.Equal(t, Replay(entries), s)
+
+	// sequence numbers continue after a replay
+	require.NoError(t, reopened.Append(Entry{Op: OpDeleteNC, NCID: "nc1"}))
+	entries, err = ReadFile(path)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, entries[4].Seq)
+	assert.Empty(t, reopened.State().Assigned, "deleting an NC releases its IPs")
+}
+
+func TestJournalTruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	require.NoError(t, j.Append(createNC, assign1))
+	require.NoError(t, j.Close())
+
+	valid, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	for name, tail := range map[string]string{
+		"torn":    `1234abcd {"seq":3,"op":"Ass`,
+		"corrupt": "00000000 {\"seq\":3,\"op\":\"Assign\"}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, append(append([]byte{}, valid...), tail...), 0o600))
+
+			// reading doesn't modify the journal
+			entries, err := ReadFile(path)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2)
+
+			reopened, err := Open(path)
+			require.NoError(t, err)
+			defer reopened.Close()
+			assert.Len(t, reopened.State().Assigned, 1)
+
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, valid, b, "the torn record should be truncated")
+
+			require.NoError(t, reopened.Append(assign2))
+			entries, err = ReadFile(path)
+			require.NoError(t, err)
+			assert.Len(t, entries, 3)
+		})
+	}
+}
+
+func TestJournalCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	require.NoError(t, j.Append(createNC, assign1, assign2, release1))
+	before := j.State()
+
+	require.NoError(t, j.Compact())
+	entries, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, OpCreateNC, entries[0].Op)
+	assert.Equal(t, "id2", entries[1].IPConfigID)
+	assert.Equal(t, before, Replay(entries))
+
+	// the compacted journal keeps being appended to
+	require.NoError(t, j.Append(release1))
+	entries, err = ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.EqualValues(t, 5, entries[2].Seq)
+}
+
+func TestJournalCompactsAfterThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	j.compactThreshold = 4
+
+	require.NoError(t, j.Append(createNC, assign1, release1))
+	require.NoError(t, j.Append(assign2))
+	entries, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestPodInfoByIPProvider(t *testing.T) {
+	cns.GlobalPodInfoScheme = cns.InterfaceIDPodInfoScheme
+	s := Replay([]Entry{createNC, assign1, assign2, {Op: OpAssign, NCID: "nc1", IPConfigID: "id3", IPAddress: "10.0.0.3", InterfaceID: "infra3-eth0"}})
+
+	provider := cns.PodInfoByIPProviderFunc(func() (map[string]cns.PodInfo, error) {
+		return map[string]cns.PodInfo{
+			// the provider knows the first IP, assigned to another pod.
+			"10.0.0.1": cns.NewPodInfo("infra4", "infra4-eth0", "pod4", "ns"),
+			// the provider knows the pod of the third IP, with another IP.
+			"10.0.0.4": cns.NewPodInfo("infra3", "infra3-eth0", "pod3", "ns"),
+		}, nil
+	})
+
+	podInfoByIP, err := PodInfoByIPProvider(s, provider, nil).PodInfoByIP()
+	require.NoError(t, err)
+	require.Len(t, podInfoByIP, 3)
+	assert.Equal(t, "pod4", podInfoByIP["10.0.0.1"].Name())
+	assert.Equal(t, "pod2", podInfoByIP["10.0.0.2"].Name(), "the IP missing from the provider is restored")
+	assert.Equal(t, "infra2-eth0", podInfoByIP["10.0.0.2"].InterfaceID())
+	assert.Equal(t, "pod3", podInfoByIP["10.0.0.4"].Name())
+
+	podInfoByIP, err = PodInfoByIPProvider(s, nil, nil).PodInfoByIP()
+	require.NoError(t, err)
+	assert.Len(t, podInfoByIP, 3)
+
+	// the assignments of the pods deleted while CNS was down are not restored
+	livePods := func() (map[string]struct{}, error) {
+		return map[string]struct{}{"ns/pod1": {}}, nil
+	}
+	podInfoByIP, err = PodInfoByIPProvider(s, nil, livePods).PodInfoByIP()
+	require.NoError(t, err)
+	require.Len(t, podInfoByIP, 1)
+	assert.Equal(t, "pod1", podInfoByIP["10.0.0.1"].Name())
+
+	_, err = PodInfoByIPProvider(s, nil, func() (map[string]struct{}, error) { return nil, errors.New("unreachable") }).PodInfoByIP()
+	require.Error(t, err)
+}
+
+func init() {
+	logger.InitLogger("testlogs", 0, 0, "./")
+}
@@ -0,0 +1,67 @@
+package journal
+
+import (
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/pkg/errors"
+)
+
+// LivePodsFunc returns the namespace/name of the pods which currently run on the node.
+type LivePodsFunc func() (map[string]struct{}, error)
+
+// PodInfoByIPProvider returns a cns.PodInfoByIPProvider which adds the IP assignments recorded in the journal State
+// to the ones of the passed provider.
+//
+// The provider is the source of truth for the pods it knows about, but it is persisted after CNS assigns an IP, so an
+// IP CNS assigned just before it restarted may be missing from it. Without the journal, such an IP would be handed out
+// again. An assignment from the journal is only added if neither its IP nor its pod are known to the provider.
+//
+// The journal also holds the assignments of pods which were deleted while CNS was down, whose IPs would then never be
+// released. If livePods is set, an assignment from the journal is only added if its pod still runs on the node.
+func PodInfoByIPProvider(s State, provider cns.PodInfoByIPProvider, livePods LivePodsFunc) cns.PodInfoByIPProvider {
+	return cns.PodInfoByIPProviderFunc(func() (map[string]cns.PodInfo, error) {
+		podInfoByIP := map[string]cns.PodInfo{}
+		if provider != nil {
+			var err error
+			if podInfoByIP, err = provider.PodInfoByIP(); err != nil {
+				return nil, err //nolint:wrapcheck // passthrough
+			}
+		}
+
+		pods := make(map[string]struct{}, len(podInfoByIP))
+		for _, podInfo := range podInfoByIP {
+			pods[podInfo.Key()] = struct{}{}
+		}
+
+		journaled := s.PodInfoByIP()
+		var live map[string]struct{}
+		if livePods != nil && len(journaled) > 0 {
+			var err error
+			if live, err = livePods(); err != nil {
+				return nil, errors.Wrap(err, "failed to list the pods to reconcile the IPAM journal with")
+			}
+		}
+
+		merged := make(map[string]cns.PodInfo, len(podInfoByIP)+len(journaled))
+		for ip, podInfo := range podInfoByIP {
+			merged[ip] = podInfo
+		}
+		for ip, podInfo := range journaled {
+			if _, ok := merged[ip]; ok {
+				continue
+			}
+			if _, ok := pods[podInfo.Key()]; ok {
+				continue
+			}
+			if live != nil {
+				if _, ok := live[podInfo.Namespace()+"/"+podInfo.Name()]; !ok {
+					logger.Printf("[journal] not restoring IP %s assigned to pod %s/%s which no longer exists", ip, podInfo.Namespace(), podInfo.Name())
+					continue
+				}
+			}
+			logger.Printf("[journal] restoring IP %s assigned to pod %s/%s missing from pod state", ip, podInfo.Namespace(), podInfo.Name())
+			merged[ip] = podInfo
+		}
+		return merged, nil
+	})
+}
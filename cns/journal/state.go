@@ -0,0 +1,85 @@
+package journal
+
+import (
+	"sort"
+
+	"github.com/Azure/azure-container-networking/cns"
+)
+
+// State is the IPAM state described by a sequence of journal entries.
+type State struct {
+	// NCs are the last CreateNC entries of the network containers which have not been deleted, by NC ID.
+	NCs map[string]Entry
+	// Assigned are the Assign entries of the IPs which are currently assigned, by IPConfig ID.
+	Assigned map[string]Entry
+}
+
+// NewState returns an empty State.
+func NewState() State {
+	return State{
+		NCs:      map[string]Entry{},
+		Assigned: map[string]Entry{},
+	}
+}
+
+// Replay returns the State described by the entries.
+func Replay(entries []Entry) State {
+	s := NewState()
+	for i := range entries {
+		s.Apply(&entries[i])
+	}
+	return s
+}
+
+// Apply updates the State with the mutation recorded by e.
+func (s *State) Apply(e *Entry) {
+	switch e.Op {
+	case OpCreateNC:
+		s.NCs[e.NCID] = *e
+	case OpDeleteNC:
+		delete(s.NCs, e.NCID)
+		for id := range s.Assigned {
+			if s.Assigned[id].NCID == e.NCID {
+				delete(s.Assigned, id)
+			}
+		}
+	case OpAssign:
+		s.Assigned[e.IPConfigID] = *e
+	case OpRelease, OpMarkPendingRelease:
+		delete(s.Assigned, e.IPConfigID)
+	}
+}
+
+// Copy returns a deep copy of the State.
+func (s *State) Copy() State {
+	c := NewState()
+	for k, v := range s.NCs {
+		c.NCs[k] = v
+	}
+	for k, v := range s.Assigned {
+		c.Assigned[k] = v
+	}
+	return c
+}
+
+// Entries returns the minimal entries describing the State, in the order they were originally appended.
+func (s *State) Entries() []Entry {
+	entries := make([]Entry, 0, len(s.NCs)+len(s.Assigned))
+	for _, e := range s.NCs {
+		entries = append(entries, e)
+	}
+	for _, e := range s.Assigned {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+	return entries
+}
+
+// PodInfoByIP returns the pods the assigned IPs are assigned to, by IP address.
+func (s *State) PodInfoByIP() map[string]cns.PodInfo {
+	podInfoByIP := make(map[string]cns.PodInfo, len(s.Assigned))
+	for _, e := range s.Assigned {
+		podInfoByIP[e.IPAddress] = cns.NewPodInfo(e.InfraContainerID, e.InterfaceID, e.PodName, e.PodNamespace)
+	}
+	return podInfoByIP
+}
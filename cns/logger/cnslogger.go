@@ -9,6 +9,7 @@ import (
 	ai "github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -71,6 +72,24 @@ func (c *logger) InitAIWithIKey(aiConfig ai.AIConfig, instrumentationKey string,
 	c.disableEventLogging = disableEventLogging
 }
 
+// InitOTLP exports the telemetry to an OTLP receiver, alongside Application Insights if it was initialized first.
+func (c *logger) InitOTLP(otlpConfig otlptelemetry.Config, aiConfig ai.AIConfig, disableTraceLogging, disableMetricLogging, disableEventLogging bool) {
+	oth, err := otlptelemetry.NewOTLPTelemetry(otlpConfig, aiConfig)
+	if err != nil {
+		c.logger.Errorf("Error initializing OTLP Telemetry:%v", err)
+		return
+	}
+	if c.th != nil {
+		c.th = ai.NewTee(c.th, oth)
+	} else {
+		c.th = oth
+	}
+	c.logger.Printf("OTLP Telemetry Handle created")
+	c.disableMetricLogging = disableMetricLogging
+	c.disableTraceLogging = disableTraceLogging
+	c.disableEventLogging = disableEventLogging
+}
+
 func (c *logger) Close() {
 	c.logger.Close()
 	if c.th != nil {
@@ -78,6 +97,22 @@ func (c *logger) Close() {
 	}
 }
 
+// SetLevel sets the level of the v1 logger to the closest of its levels.
+func (c *logger) SetLevel(level zapcore.Level) {
+	switch {
+	case level <= zapcore.DebugLevel:
+		c.logger.SetLevel(log.LevelDebug)
+	case level == zapcore.InfoLevel:
+		c.logger.SetLevel(log.LevelInfo)
+	case level == zapcore.WarnLevel:
+		c.logger.SetLevel(log.LevelWarning)
+	case level == zapcore.ErrorLevel:
+		c.logger.SetLevel(log.LevelError)
+	default:
+		c.logger.SetLevel(log.LevelAlert)
+	}
+}
+
 func (c *logger) SetContextDetails(orchestrator, nodeID string) {
 	c.logger.Logf("SetContext details called with: %v orchestrator nodeID %v", orchestrator, nodeID)
 	c.m.Lock()
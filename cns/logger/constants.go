@@ -7,6 +7,9 @@ const (
 	ConfigSnapshotMetricsStr = "ConfigSnapshot"
 	StaleHNSCleanupMetricStr = "StaleHNSResourceCleanup"
 
+	// Events
+	EndpointRepairEventStr = "EndpointDatapathRepair"
+
 	// Dimensions
 	orchestratorTypeKey             = "OrchestratorType"
 	nodeIDKey                       = "NodeID"
@@ -30,4 +33,11 @@ const (
 	AllowHostToNCCommunicationStr = "AllowHostToNCCommunication"
 	NetworkContainerTypeStr       = "NetworkContainerType"
 	OrchestratorContextStr        = "OrchestratorContext"
+
+	// Endpoint repair properties
+	EndpointRepairKindStr   = "RepairKind"
+	EndpointRepairDetailStr = "RepairDetail"
+	PodNameStr              = "PodName"
+	PodNamespaceStr         = "PodNamespace"
+	ContainerIDStr          = "ContainerID"
 )
@@ -4,13 +4,17 @@ package logger
 import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"go.uber.org/zap/zapcore"
 )
 
 type loggershim interface {
 	Close()
 	InitAI(aitelemetry.AIConfig, bool, bool, bool)
 	InitAIWithIKey(aitelemetry.AIConfig, string, bool, bool, bool)
+	InitOTLP(otlptelemetry.Config, aitelemetry.AIConfig, bool, bool, bool)
 	SetContextDetails(string, string)
+	SetLevel(zapcore.Level)
 	SetAPIServer(string)
 	Printf(string, ...any)
 	Debugf(string, ...any)
@@ -49,11 +53,23 @@ func InitAIWithIKey(aiConfig aitelemetry.AIConfig, instrumentationKey string, di
 	Log.InitAIWithIKey(aiConfig, instrumentationKey, disableTraceLogging, disableMetricLogging, disableEventLogging)
 }
 
+// Deprecated: The global logger is deprecated. Migrate to zap using the cns/logger/v2 package and pass the logger instead.
+func InitOTLP(otlpConfig otlptelemetry.Config, aiConfig aitelemetry.AIConfig, disableTraceLogging, disableMetricLogging, disableEventLogging bool) {
+	Log.InitOTLP(otlpConfig, aiConfig, disableTraceLogging, disableMetricLogging, disableEventLogging)
+}
+
 // Deprecated: The global logger is deprecated. Migrate to zap using the cns/logger/v2 package and pass the logger instead.
 func SetContextDetails(orchestrator, nodeID string) {
 	Log.SetContextDetails(orchestrator, nodeID)
 }
 
+// SetLevel changes the level of the global logger, e.g. when the config is reloaded.
+//
+// Deprecated: The global logger is deprecated. Migrate to zap using the cns/logger/v2 package and pass the logger instead.
+func SetLevel(level zapcore.Level) {
+	Log.SetLevel(level)
+}
+
 // Deprecated: The global logger is deprecated. Migrate to zap using the cns/logger/v2 package and pass the logger instead.
 func Printf(format string, args ...any) {
 	Log.Printf(format, args...)
@@ -6,6 +6,7 @@ import (
 	loggerv1 "github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/internal/time"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -16,6 +17,9 @@ const (
 	defaultMaxBatchInterval = 30 * time.Second
 	defaultMaxBatchSize     = 32000
 	defaultGracePeriod      = 30 * time.Second
+	defaultOTLPTimeout      = 10 * time.Second
+	defaultOTLPBatchSize    = 512
+	defaultOTLPInterval     = 5 * time.Second
 )
 
 //nolint:unused // will be used
@@ -42,6 +46,20 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// SetLevel changes the general logging Level of the logger built from the Config, or of the Config if no logger was
+// built from it yet.
+func (c *Config) SetLevel(level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse Config Level")
+	}
+	c.Level, c.level = level, lvl
+	if c.atomicLevel != (zap.AtomicLevel{}) {
+		c.atomicLevel.SetLevel(lvl)
+	}
+	return nil
+}
+
 // Normalize checks the Config for missing/default values and sets them
 // if appropriate.
 func (c *Config) Normalize() {
@@ -70,5 +88,16 @@ func (c *Config) Normalize() {
 			c.AppInsights.MaxBatchSize = defaultMaxBatchSize
 		}
 	}
+	if c.OTLP != nil {
+		if c.OTLP.Timeout.Duration == 0 {
+			c.OTLP.Timeout.Duration = defaultOTLPTimeout
+		}
+		if c.OTLP.MaxBatchInterval.Duration == 0 {
+			c.OTLP.MaxBatchInterval.Duration = defaultOTLPInterval
+		}
+		if c.OTLP.MaxBatchSize == 0 {
+			c.OTLP.MaxBatchSize = defaultOTLPBatchSize
+		}
+	}
 	c.normalize()
 }
@@ -2,6 +2,7 @@ package logger
 
 import (
 	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -11,8 +12,10 @@ type Config struct {
 	// Level is the general logging Level. If cores have more specific config it will override this.
 	Level       string                   `json:"level"`
 	level       zapcore.Level            `json:"-"`
+	atomicLevel zap.AtomicLevel          `json:"-"`
 	AppInsights *cores.AppInsightsConfig `json:"appInsights,omitempty"`
 	File        *cores.FileConfig        `json:"file,omitempty"`
+	OTLP        *cores.OTLPConfig        `json:"otlp,omitempty"`
 }
 
 func (c *Config) normalize() {}
@@ -6,6 +6,7 @@ import (
 
 	cores "github.com/Azure/azure-container-networking/cns/logger/v2/cores"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestUnmarshalJSON(t *testing.T) {
@@ -53,3 +54,18 @@ func TestUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	c := &Config{}
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"info"}`), c))
+	z, closer, err := New(c)
+	require.NoError(t, err)
+	defer closer()
+	require.False(t, z.Core().Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, c.SetLevel("debug"))
+	require.True(t, z.Core().Enabled(zapcore.DebugLevel))
+
+	require.Error(t, c.SetLevel("invalid"))
+	require.Equal(t, "debug", c.Level)
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/internal/time"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+type OTLPConfig struct {
+	level            zapcore.Level     `json:"-"` // Zero value is default Info level.
+	Level            string            `json:"level"`
+	Endpoint         string            `json:"endpoint"`
+	Protocol         string            `json:"protocol"`
+	Insecure         bool              `json:"insecure"`
+	Headers          map[string]string `json:"headers"`
+	Timeout          time.Duration     `json:"timeout"`
+	MaxBatchInterval time.Duration     `json:"max_batch_interval"`
+	MaxBatchSize     int               `json:"max_batch_size"`
+	Fields           []zapcore.Field   `json:"fields"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the Config.
+// It only differs from the default by parsing the
+// Level string into a zapcore.Level and setting the level field.
+func (c *OTLPConfig) UnmarshalJSON(data []byte) error {
+	type Alias OTLPConfig
+	aux := &struct {
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return errors.Wrap(err, "failed to unmarshal OTLPConfig")
+	}
+	lvl, err := zapcore.ParseLevel(c.Level)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse OTLPConfig Level")
+	}
+	c.level = lvl
+	return nil
+}
+
+// OTLPCore builds a zapcore.Core that exports logs to an OTLP receiver.
+// The first return is the core, the second is a function to close the exporter.
+func OTLPCore(cfg *OTLPConfig) (zapcore.Core, func(), error) {
+	t, err := otlptelemetry.NewOTLPTelemetry(otlptelemetry.Config{
+		Endpoint:            cfg.Endpoint,
+		Protocol:            cfg.Protocol,
+		Insecure:            cfg.Insecure,
+		Headers:             cfg.Headers,
+		TimeoutInSecs:       int(cfg.Timeout.Seconds()),
+		MaxBatchSize:        cfg.MaxBatchSize,
+		BatchIntervalInSecs: int(cfg.MaxBatchInterval.Seconds()),
+	}, aitelemetry.AIConfig{AppName: "azure-cns"})
+	if err != nil {
+		return nil, func() {}, errors.Wrap(err, "failed to create OTLP exporter")
+	}
+	closer := func() {
+		t.Close(int(cfg.Timeout.Seconds()))
+	}
+	return otlptelemetry.NewCore(cfg.level, t).With(cfg.Fields), closer, nil
+}
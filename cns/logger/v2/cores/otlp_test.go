@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/internal/time"
+	"github.com/Azure/azure-container-networking/otlptelemetry/otlptest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOTLPConfigUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		have    []byte
+		want    *OTLPConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			have: []byte(`{"level":"warn","endpoint":"http://collector:4318","protocol":"http/protobuf","headers":{"x-tenant":"acn"},"timeout":"5s","max_batch_interval":"1s","max_batch_size":100}`),
+			want: &OTLPConfig{
+				Level:            "warn",
+				level:            zapcore.WarnLevel,
+				Endpoint:         "http://collector:4318",
+				Protocol:         "http/protobuf",
+				Headers:          map[string]string{"x-tenant": "acn"},
+				Timeout:          time.Duration{Duration: 5 * time.Second},
+				MaxBatchInterval: time.Duration{Duration: 1 * time.Second},
+				MaxBatchSize:     100,
+			},
+		},
+		{
+			name:    "invalid level",
+			have:    []byte(`{"level":"invalid"}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &OTLPConfig{}
+			err := json.Unmarshal(tt.have, c)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, c)
+		})
+	}
+}
+
+func TestOTLPCore(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+	core, closer, err := OTLPCore(&OTLPConfig{
+		Endpoint: collector.GRPCEndpoint,
+		Insecure: true,
+		Timeout:  time.Duration{Duration: time.Second},
+		Fields:   []zapcore.Field{zap.String("node", "node-1")},
+	})
+	require.NoError(t, err)
+
+	zap.New(core).Info("reconciled nc", zap.String("nc", "nc-1"))
+	closer()
+
+	records := collector.LogRecords()
+	require.Len(t, records, 1)
+	require.Equal(t, "reconciled nc", records[0].GetBody().GetStringValue())
+	require.Len(t, records[0].GetAttributes(), 2)
+}
+
+func TestOTLPCoreInvalidConfig(t *testing.T) {
+	_, closer, err := OTLPCore(&OTLPConfig{})
+	require.Error(t, err)
+	closer()
+}
@@ -39,7 +39,7 @@ func (cfg *StdoutConfig) UnmarshalJSON(data []byte) error {
 }
 
 // StdoutCore builds a zapcore.Core that writes to stdout.
-func StdoutCore(l zapcore.Level) zapcore.Core {
+func StdoutCore(l zapcore.LevelEnabler) zapcore.Core {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	return zapcore.NewCore(&ctrlzap.KubeAwareEncoder{Encoder: logfmt.NewEncoder(encoderConfig)}, os.Stdout, l)
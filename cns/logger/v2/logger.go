@@ -1,5 +1,5 @@
 // Package logger provides an opinionated logger for CNS which knows how to
-// log to Application Insights, file, stdout and ETW (based on platform).
+// log to Application Insights, an OTLP receiver, file, stdout and ETW (based on platform).
 package logger
 
 import (
@@ -19,7 +19,8 @@ func (c compoundCloser) Close() {
 // New creates a v2 CNS logger built with Zap.
 func New(cfg *Config) (*zap.Logger, func(), error) {
 	cfg.Normalize()
-	core := cores.StdoutCore(cfg.level)
+	cfg.atomicLevel = zap.NewAtomicLevelAt(cfg.level)
+	core := cores.StdoutCore(cfg.atomicLevel)
 	closer := compoundCloser{}
 	if cfg.File != nil {
 		fileCore, fileCloser, err := cores.FileCore(cfg.File)
@@ -37,6 +38,14 @@ func New(cfg *Config) (*zap.Logger, func(), error) {
 		}
 		core = zapcore.NewTee(core, aiCore)
 	}
+	if cfg.OTLP != nil {
+		otlpCore, otlpCloser, err := cores.OTLPCore(cfg.OTLP)
+		closer = append(closer, otlpCloser)
+		if err != nil {
+			return nil, closer.Close, err //nolint:wrapcheck // it's an internal pkg
+		}
+		core = zapcore.NewTee(core, otlpCore)
+	}
 	platformCore, platformCloser, err := platformCore(cfg)
 	closer = append(closer, platformCloser)
 	if err != nil {
@@ -3,7 +3,9 @@ package logger
 import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // shim wraps the Zap logger to provide a compatible interface to the
@@ -51,10 +53,15 @@ func (*shim) InitAI(aitelemetry.AIConfig, bool, bool, bool) {}
 
 func (*shim) InitAIWithIKey(aitelemetry.AIConfig, string, bool, bool, bool) {}
 
+func (*shim) InitOTLP(otlptelemetry.Config, aitelemetry.AIConfig, bool, bool, bool) {}
+
 func (s *shim) SetContextDetails(string, string) {}
 
 func (s *shim) SetAPIServer(string) {}
 
+// SetLevel is a no-op, the level of the zap logger is set with the Config it was built from.
+func (*shim) SetLevel(zapcore.Level) {}
+
 func (s *shim) SendMetric(aitelemetry.Metric) {}
 
 func (s *shim) LogEvent(aitelemetry.Event) {}
@@ -9,7 +9,10 @@ import (
 	"github.com/Azure/azure-container-networking/cns/middlewares/utils"
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/multitenancy/api/v1alpha1"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	v1 "k8s.io/api/core/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,6 +26,8 @@ const (
 	NetworkNotReadyErrorMsg = "network is not ready"
 )
 
+var tracer = otel.Tracer("github.com/Azure/azure-container-networking/cns/middlewares")
+
 var (
 	errMTPNCNotReady            = errors.New(NetworkNotReadyErrorMsg + " - mtpnc is not ready")
 	errGetMTPNC                 = errors.New(NetworkNotReadyErrorMsg + " - failed to get MTPNC")
@@ -39,6 +44,14 @@ type K8sSWIFTv2Middleware struct {
 var _ cns.IPConfigsHandlerMiddleware = (*K8sSWIFTv2Middleware)(nil)
 
 func (k *K8sSWIFTv2Middleware) GetPodInfoForIPConfigsRequest(ctx context.Context, req *cns.IPConfigsRequest) (podInfo cns.PodInfo, respCode types.ResponseCode, message string) {
+	ctx, span := tracer.Start(ctx, "cns.K8sSWIFTv2Middleware.GetPodInfo")
+	defer func() {
+		if respCode != types.Success {
+			span.SetStatus(codes.Error, message)
+		}
+		span.End()
+	}()
+
 	// gets pod info for the specified request
 	podInfo, pod, respCode, message := k.GetPodInfo(ctx, req)
 	if respCode != types.Success {
@@ -71,7 +84,10 @@ func (k *K8sSWIFTv2Middleware) GetPodInfoForIPConfigsRequest(ctx context.Context
 }
 
 // getIPConfig returns the pod's SWIFT V2 IP configuration.
-func (k *K8sSWIFTv2Middleware) getIPConfig(ctx context.Context, podInfo cns.PodInfo) ([]cns.PodIpInfo, error) {
+func (k *K8sSWIFTv2Middleware) getIPConfig(ctx context.Context, podInfo cns.PodInfo) (podIPInfos []cns.PodIpInfo, err error) {
+	ctx, span := tracer.Start(ctx, "cns.K8sSWIFTv2Middleware.getIPConfig")
+	defer func() { tracing.End(span, err) }()
+
 	// Check if the MTPNC CRD exists for the pod, if not, return error
 	mtpnc := v1alpha1.MultitenantPodNetworkConfig{}
 	mtpncNamespacedName := k8stypes.NamespacedName{Namespace: podInfo.Namespace(), Name: podInfo.Name()}
@@ -85,8 +101,6 @@ func (k *K8sSWIFTv2Middleware) getIPConfig(ctx context.Context, podInfo cns.PodI
 	}
 	logger.Printf("[SWIFTv2Middleware] mtpnc for pod %s is : %+v", podInfo.Name(), mtpnc)
 
-	var podIPInfos []cns.PodIpInfo
-
 	if len(mtpnc.Status.InterfaceInfos) == 0 {
 		// Use fields from mtpnc.Status if InterfaceInfos is empty
 		ip, prefixSize, err := utils.ParseIPAndPrefix(mtpnc.Status.PrimaryIP)
@@ -17,6 +17,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/hnsclient"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/cns/wireserver"
@@ -731,6 +732,12 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 		service.Lock()
 		defer service.Unlock()
 
+		if err := service.journalNetworkContainerChange(journal.OpDeleteNC, ncid); err != nil {
+			returnMessage = fmt.Sprintf("[Azure CNS] Error. DeleteNetworkContainer failed %v", err.Error())
+			returnCode = types.UnexpectedError
+			break
+		}
+
 		if service.state.ContainerStatus != nil {
 			delete(service.state.ContainerStatus, ncid)
 		}
@@ -14,6 +14,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -33,9 +34,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const (
-	cnsJsonFileName = "azure-cns.json"
-)
+// cnsJsonFileName is the state file of the test service, in a temporary directory so that the tests don't write to the tree.
+var cnsJsonFileName string
 
 type IPAddress struct {
 	XMLName   xml.Name `xml:"IPAddress"`
@@ -175,6 +175,13 @@ func TestMain(m *testing.M) {
 	var err error
 	logger.InitLogger("testlogs", 0, 0, "./")
 
+	stateDir, err := os.MkdirTemp("", "azure-cns")
+	if err != nil {
+		fmt.Printf("Failed to create the CNS state directory. Error: %v", err)
+		os.Exit(1)
+	}
+	cnsJsonFileName = filepath.Join(stateDir, "azure-cns.json")
+
 	// Create the service. If CRD channel mode is needed, then at the start of the test,
 	// it can stop the service (service.Stop), invoke startService again with new ServiceConfig (with CRD mode)
 	// perform the test and then restore the service again.
@@ -209,6 +216,7 @@ func TestMain(m *testing.M) {
 	// Cleanup.
 	service.Stop()
 	nmAgentServer.Stop()
+	os.RemoveAll(stateDir)
 
 	os.Exit(exitCode)
 }
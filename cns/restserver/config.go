@@ -0,0 +1,62 @@
+package restserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/common"
+)
+
+// ConfigReporter reports the effective CNS config and the result of its last reload.
+type ConfigReporter interface {
+	EffectiveConfig() (json.RawMessage, error)
+	LastReload() *cns.ConfigReloadResult
+}
+
+// SetPProfEnabled enables or disables the pprof endpoints registered by RegisterPProfEndpoints.
+func (service *HTTPRestService) SetPProfEnabled(enabled bool) {
+	service.pprofEnabled.Store(enabled)
+}
+
+// pprofGate serves the pprof handler only while pprof is enabled, and 404s otherwise.
+func (service *HTTPRestService) pprofGate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !service.pprofEnabled.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// HandleDebugConfig returns the effective CNS config and the result of its last reload.
+func (service *HTTPRestService) HandleDebugConfig(w http.ResponseWriter, _ *http.Request) {
+	opName := "handleDebugConfig"
+	var resp cns.GetConfigResponse
+	if service.ConfigReporter == nil {
+		resp.Response = cns.Response{
+			ReturnCode: types.UnsupportedAPI,
+			Message:    "config reporting is not enabled",
+		}
+		err := common.Encode(w, &resp)
+		logger.Response(opName, resp, resp.Response.ReturnCode, err)
+		return
+	}
+	config, err := service.ConfigReporter.EffectiveConfig()
+	if err != nil {
+		resp.Response = cns.Response{
+			ReturnCode: types.UnexpectedError,
+			Message:    err.Error(),
+		}
+		err = common.Encode(w, &resp)
+		logger.Response(opName, resp, resp.Response.ReturnCode, err)
+		return
+	}
+	resp.Config = config
+	resp.LastReload = service.ConfigReporter.LastReload()
+	err = common.Encode(w, &resp)
+	logger.Response(opName, resp, resp.Response.ReturnCode, err)
+}
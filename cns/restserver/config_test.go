@@ -0,0 +1,68 @@
+package restserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfigReporter struct {
+	config json.RawMessage
+	last   *cns.ConfigReloadResult
+}
+
+func (f *fakeConfigReporter) EffectiveConfig() (json.RawMessage, error) { return f.config, nil }
+
+func (f *fakeConfigReporter) LastReload() *cns.ConfigReloadResult { return f.last }
+
+func TestHandleDebugConfig(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+
+	get := func() cns.GetConfigResponse {
+		w := httptest.NewRecorder()
+		svc.HandleDebugConfig(w, httptest.NewRequest(http.MethodGet, cns.PathDebugConfig, http.NoBody))
+		var resp cns.GetConfigResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+
+	assert.Equal(t, types.UnsupportedAPI, get().Response.ReturnCode)
+
+	reporter := &fakeConfigReporter{config: json.RawMessage(`{"EnablePprof":true}`)}
+	svc.ConfigReporter = reporter
+	resp := get()
+	assert.Equal(t, types.Success, resp.Response.ReturnCode)
+	assert.JSONEq(t, `{"EnablePprof":true}`, string(resp.Config))
+	assert.Nil(t, resp.LastReload)
+
+	reporter.last = &cns.ConfigReloadResult{
+		Time:            time.Now().UTC().Truncate(time.Second),
+		Applied:         []string{"EnablePprof"},
+		RequiresRestart: []string{"ChannelMode"},
+	}
+	resp = get()
+	assert.Equal(t, reporter.last, resp.LastReload)
+}
+
+func TestPProfGate(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	h := svc.pprofGate(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", http.NoBody))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNotFound, serve())
+	svc.SetPProfEnabled(true)
+	assert.Equal(t, http.StatusOK, serve())
+	svc.SetPProfEnabled(false)
+	assert.Equal(t, http.StatusNotFound, serve())
+}
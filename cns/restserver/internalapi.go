@@ -18,11 +18,13 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/nodesubnet"
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -174,7 +176,9 @@ func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMo
 	service.Lock()
 	defer service.Unlock()
 	start := time.Now()
+	ctx, span := tracer.Start(ctx, "cns.SyncHostNCVersion")
 	programmedNCCount, err := service.syncHostNCVersion(ctx, channelMode)
+	tracing.End(span, err)
 	// even if we get an error, we want to write the CNI conflist if we have any NC programmed to any version
 	if programmedNCCount > 0 {
 		// This will only be done once per lifetime of the CNS process. This function is threadsafe and will panic
@@ -531,6 +535,9 @@ func (service *HTTPRestService) DeleteNetworkContainerInternal(
 
 	service.Lock()
 	defer service.Unlock()
+	if err := service.journalNetworkContainerChange(journal.OpDeleteNC, ncid); err != nil {
+		return types.UnexpectedError
+	}
 	if service.state.ContainerStatus != nil {
 		delete(service.state.ContainerStatus, ncid)
 	}
@@ -578,6 +585,10 @@ func (service *HTTPRestService) MustEnsureNoStaleNCs(validNCIDs []string) {
 			}
 
 			logger.Errorf("[Azure CNS] Found stale NC ID %s in CNS state. Removing...", ncID)
+			if err := service.journalNetworkContainerChange(journal.OpDeleteNC, ncID); err != nil {
+				// the NC is removed on the next pass
+				continue
+			}
 			delete(service.state.ContainerStatus, ncID)
 			mutated = true
 		}
@@ -653,7 +664,7 @@ func (service *HTTPRestService) CreateOrUpdateNetworkContainerInternal(req *cns.
 	// If the NC was created successfully, log NC snapshot.
 	if returnCode == 0 {
 		logNCSnapshot(*req)
-		service.publishIPStateMetrics()
+		service.PublishIPStateMetrics()
 	} else {
 		logger.Errorf(returnMessage)
 	}
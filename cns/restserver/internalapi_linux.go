@@ -4,25 +4,66 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
-	"strconv"
+	"strings"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/snat"
+	"github.com/Azure/azure-container-networking/nftables"
 	goiptables "github.com/coreos/go-iptables/iptables"
 	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
 )
 
 const SWIFTPOSTROUTING = "SWIFT-POSTROUTING"
 
+// the table, chain, and set of the SNAT rules in nftables. CNS has its own table, so that it can delete
+// its rules when iptables is selected again without touching the CNI rules in the azure table.
+const (
+	swiftNftTable            = "azure-swift"
+	swiftPostroutingNftChain = "swift-postrouting"
+	swiftPodCIDRsNftSet      = "swift-pod-cidrs"
+)
+
+// nftablesClient applies batches to the CNS nftables table.
+type nftablesClient interface {
+	Apply(b *nftables.Batch) error
+	DeleteTable() error
+}
+
+// nftablesGetter is implemented by iptables providers which can also program nftables.
+type nftablesGetter interface {
+	GetNftables() (nftablesClient, error)
+}
+
 type IPtablesProvider struct{}
 
 func (c *IPtablesProvider) GetIPTables() (iptablesClient, error) {
 	client, err := goiptables.New()
-	return client, errors.Wrap(err, "failed to get iptables client")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get iptables client")
+	}
+	return &iptablesTransactor{IPTables: client, client: iptables.NewClient()}, nil
+}
+
+// iptablesTransactor reads rules with go-iptables and applies transactions with the shared iptables client.
+type iptablesTransactor struct {
+	*goiptables.IPTables
+	client *iptables.Client
 }
+
+func (c *iptablesTransactor) Apply(tx *iptables.Transaction) error {
+	return errors.Wrap(c.client.Apply(tx), "failed to apply iptables transaction")
+}
+
+func (c *IPtablesProvider) GetNftables() (nftablesClient, error) {
+	return nftables.NewClient(swiftNftTable), nil
+}
+
 func (c *IPtablesProvider) GetIPTablesLegacy() (iptablesLegacyClient, error) {
 	return &iptablesLegacy{}, nil
 }
@@ -50,21 +91,28 @@ func (service *HTTPRestService) programSNATRules(req *cns.CreateNetworkContainer
 		logger.Printf("[Azure CNS] Could not create iptables legacy interface, continuing : %v", err)
 	}
 
+	if service.Options[common.OptSNATBackend] == snat.BackendNftables {
+		return service.programSNATRulesNftables(req)
+	}
+
+	// the nftables chain runs before SWIFT-POSTROUTING, so it must not outlive a switch back to iptables
+	service.deleteSNATRulesNftables()
+
 	ipt, err := service.iptables.GetIPTables()
 	if err != nil {
 		return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to create iptables interface : %v", err)
 	}
 
+	// all changes are collected in one transaction so that the chain is never left partially programmed
+	tx := iptables.NewTransaction()
+
 	chainExist, err := ipt.ChainExists(iptables.Nat, SWIFTPOSTROUTING)
 	if err != nil {
 		return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to check for existence of SWIFT-POSTROUTING chain: %v", err)
 	}
 	if !chainExist { // create and append chain if it doesn't exist
 		logger.Printf("[Azure CNS] Creating SWIFT-POSTROUTING Chain ...")
-		err = ipt.NewChain(iptables.Nat, SWIFTPOSTROUTING)
-		if err != nil {
-			return types.FailedToRunIPTableCmd, "[Azure CNS] failed to create SWIFT-POSTROUTING chain : " + err.Error()
-		}
+		tx.CreateChain(iptables.V4, iptables.Nat, SWIFTPOSTROUTING)
 	}
 
 	// reconcile jump to SWIFT-POSTROUTING chain
@@ -96,10 +144,7 @@ func (service *HTTPRestService) programSNATRules(req *cns.CreateNetworkContainer
 			return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to check for existence of SWIFT-POSTROUTING rule: %v", err)
 		}
 		if swiftPostroutingExists {
-			err = ipt.Delete(iptables.Nat, iptables.Postrouting, "-j", SWIFTPOSTROUTING)
-			if err != nil {
-				return types.FailedToRunIPTableCmd, "[Azure CNS] failed to delete existing SWIFT-POSTROUTING rule : " + err.Error()
-			}
+			tx.DeleteRule(iptables.V4, iptables.Nat, iptables.Postrouting, "", SWIFTPOSTROUTING)
 		}
 
 		// slice index is 0-based, iptables insert is 1-based, but list also gives us the -P POSTROUTING ACCEPT
@@ -108,10 +153,7 @@ func (service *HTTPRestService) programSNATRules(req *cns.CreateNetworkContainer
 		// -P POSTROUTING ACCEPT is at swiftRuleIndex 0
 		// -A POSTROUTING -j SWIFT is at swiftRuleIndex 1, and iptables index 1
 		logger.Printf("[Azure CNS] Inserting SWIFT-POSTROUTING Chain at iptables position %d", swiftRuleIndex)
-		err = ipt.Insert(iptables.Nat, iptables.Postrouting, swiftRuleIndex, "-j", SWIFTPOSTROUTING)
-		if err != nil {
-			return types.FailedToRunIPTableCmd, "[Azure CNS] failed to insert SWIFT-POSTROUTING chain : " + err.Error()
-		}
+		tx.InsertRuleAt(iptables.V4, iptables.Nat, iptables.Postrouting, swiftRuleIndex, "", SWIFTPOSTROUTING)
 	}
 
 	// use any secondary ip + the nnc prefix length to get an iptables rule to allow dns and imds traffic from the pods
@@ -126,29 +168,34 @@ func (service *HTTPRestService) programSNATRules(req *cns.CreateNetworkContainer
 		_, podSubnet, _ := net.ParseCIDR(v.IPAddress + "/" + fmt.Sprintf("%d", req.IPConfiguration.IPSubnet.PrefixLength))
 
 		// define all rules we want in the chain
-		rules := [][]string{
-			{"-m", "addrtype", "!", "--dst-type", "local", "-s", podSubnet.String(), "-d", networkutils.AzureDNS, "-p", iptables.UDP, "--dport", strconv.Itoa(iptables.DNSPort), "-j", iptables.Snat, "--to", req.HostPrimaryIP},
-			{"-m", "addrtype", "!", "--dst-type", "local", "-s", podSubnet.String(), "-d", networkutils.AzureDNS, "-p", iptables.TCP, "--dport", strconv.Itoa(iptables.DNSPort), "-j", iptables.Snat, "--to", req.HostPrimaryIP},
-			{"-m", "addrtype", "!", "--dst-type", "local", "-s", podSubnet.String(), "-d", networkutils.AzureIMDS, "-p", iptables.TCP, "--dport", strconv.Itoa(iptables.HTTPPort), "-j", iptables.Snat, "--to", req.HostPrimaryIP},
+		snatTarget := fmt.Sprintf("%s --to %s", iptables.Snat, req.HostPrimaryIP)
+		rules := []struct{ match, target string }{
+			{fmt.Sprintf("-m addrtype ! --dst-type local -s %s -d %s -p %s --dport %d", podSubnet.String(), networkutils.AzureDNS, iptables.UDP, iptables.DNSPort), snatTarget},
+			{fmt.Sprintf("-m addrtype ! --dst-type local -s %s -d %s -p %s --dport %d", podSubnet.String(), networkutils.AzureDNS, iptables.TCP, iptables.DNSPort), snatTarget},
+			{fmt.Sprintf("-m addrtype ! --dst-type local -s %s -d %s -p %s --dport %d", podSubnet.String(), networkutils.AzureIMDS, iptables.TCP, iptables.HTTPPort), snatTarget},
 		}
 
-		// check if all rules exist
-		allRulesExist := true
-		for _, rule := range rules {
-			exists, err := ipt.Exists(iptables.Nat, SWIFTPOSTROUTING, rule...)
-			if err != nil {
-				return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to check for existence of rule: %v", err)
-			}
-			if !exists {
-				allRulesExist = false
-				break
+		// a chain created in this transaction has only the starting rule and none of ours
+		allRulesExist := chainExist
+		currentRules := []string{"-N " + SWIFTPOSTROUTING}
+		if chainExist {
+			// check if all rules exist
+			for _, rule := range rules {
+				exists, err := ipt.Exists(iptables.Nat, SWIFTPOSTROUTING, strings.Fields(rule.match+" -j "+rule.target)...)
+				if err != nil {
+					return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to check for existence of rule: %v", err)
+				}
+				if !exists {
+					allRulesExist = false
+					break
+				}
 			}
-		}
 
-		// get current rule count in SWIFT-POSTROUTING chain
-		currentRules, err := ipt.List(iptables.Nat, SWIFTPOSTROUTING)
-		if err != nil {
-			return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to list rules in SWIFT-POSTROUTING chain: %v", err)
+			// get current rule count in SWIFT-POSTROUTING chain
+			currentRules, err = ipt.List(iptables.Nat, SWIFTPOSTROUTING)
+			if err != nil {
+				return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to list rules in SWIFT-POSTROUTING chain: %v", err)
+			}
 		}
 
 		// if rule count doesn't match or not all rules exist, reconcile
@@ -156,24 +203,109 @@ func (service *HTTPRestService) programSNATRules(req *cns.CreateNetworkContainer
 		if len(currentRules) != len(rules)+1 || !allRulesExist {
 			logger.Printf("[Azure CNS] Reconciling SWIFT-POSTROUTING chain rules to SNAT Azure DNS and IMDS to Host IP")
 
-			err = ipt.ClearChain(iptables.Nat, SWIFTPOSTROUTING)
-			if err != nil {
-				return types.FailedToRunIPTableCmd, "[Azure CNS] failed to flush SWIFT-POSTROUTING chain : " + err.Error()
-			}
-
+			tx.FlushChain(iptables.V4, iptables.Nat, SWIFTPOSTROUTING)
 			for _, rule := range rules {
-				err = ipt.Append(iptables.Nat, SWIFTPOSTROUTING, rule...)
-				if err != nil {
-					return types.FailedToRunIPTableCmd, "[Azure CNS] failed to append rule to SWIFT-POSTROUTING chain : " + err.Error()
-				}
+				tx.AppendRule(iptables.V4, iptables.Nat, SWIFTPOSTROUTING, rule.match, rule.target)
 			}
-			logger.Printf("[Azure CNS] Finished reconciling SWIFT-POSTROUTING chain")
 		}
 
 		// we only need to run this code once as the iptable rule applies to all secondary ip configs in the same subnet
 		break
 	}
 
+	if tx.Len() > 0 {
+		if err = ipt.Apply(tx); err != nil {
+			return types.FailedToRunIPTableCmd, "[Azure CNS] failed to program SWIFT-POSTROUTING chain : " + err.Error()
+		}
+		logger.Printf("[Azure CNS] Finished reconciling SWIFT-POSTROUTING chain")
+	}
+
+	return types.Success, ""
+}
+
+// deleteSNATRulesNftables deletes the CNS nftables table if it exists. Failures are only logged, since hosts
+// which never used the nftables backend may not support nftables at all.
+// The caller must hold the service lock.
+func (service *HTTPRestService) deleteSNATRulesNftables() {
+	nftg, ok := service.iptables.(nftablesGetter)
+	if !ok {
+		return
+	}
+	nft, err := nftg.GetNftables()
+	if err != nil {
+		logger.Printf("[Azure CNS] Could not create nftables interface, continuing : %v", err)
+		return
+	}
+	if err := nft.DeleteTable(); err != nil {
+		logger.Errorf("[Azure CNS] Failed to delete the %s nftables table, continuing : %v", swiftNftTable, err)
+	}
+}
+
+// programSNATRulesNftables programs the same SNAT rules as the SWIFT-POSTROUTING chain in the CNS nftables table.
+// The chain runs before the iptables nat POSTROUTING chain, so it takes precedence over an existing SWIFT-POSTROUTING chain.
+// The caller must hold the service lock.
+func (service *HTTPRestService) programSNATRulesNftables(req *cns.CreateNetworkContainerRequest) (types.ResponseCode, string) {
+	nftg, ok := service.iptables.(nftablesGetter)
+	if !ok {
+		return types.UnexpectedError, "[Azure CNS] Error. nftables is not supported by the iptables provider"
+	}
+	nft, err := nftg.GetNftables()
+	if err != nil {
+		return types.UnexpectedError, fmt.Sprintf("[Azure CNS] Error. Failed to create nftables interface : %v", err)
+	}
+
+	b := nftables.NewBatch().
+		AddChain(nftables.Chain{
+			Name: swiftPostroutingNftChain,
+			Hook: &nftables.Hook{Type: nftables.ChainTypeNAT, Hooknum: unix.NF_INET_POST_ROUTING, Priority: nftables.PrioritySNAT},
+		}).
+		AddSet(nftables.Set{Name: swiftPodCIDRsNftSet, Interval: true})
+
+	// the rules match the pod subnet in a set, so only the set changes when the subnet does
+	for _, v := range req.SecondaryIPConfigs {
+		// DNS and IMDS do not have IPv6 addresses
+		if net.ParseIP(v.IPAddress).To4() == nil {
+			continue
+		}
+		_, podSubnet, _ := net.ParseCIDR(v.IPAddress + "/" + fmt.Sprintf("%d", req.IPConfiguration.IPSubnet.PrefixLength))
+		hostPrimaryIP := net.ParseIP(req.HostPrimaryIP)
+		if podSubnet == nil || hostPrimaryIP.To4() == nil {
+			return types.InvalidParameter, fmt.Sprintf("[Azure CNS] Error. Invalid pod subnet or host primary ip %s", req.HostPrimaryIP)
+		}
+
+		rules := []struct {
+			dest  string
+			proto uint8
+			port  int
+		}{
+			{networkutils.AzureDNS, unix.IPPROTO_UDP, iptables.DNSPort},
+			{networkutils.AzureDNS, unix.IPPROTO_TCP, iptables.DNSPort},
+			{networkutils.AzureIMDS, unix.IPPROTO_TCP, iptables.HTTPPort},
+		}
+
+		b.FlushSet(swiftPodCIDRsNftSet).
+			AddElements(swiftPodCIDRsNftSet, nftables.CIDRElements(*podSubnet)...).
+			FlushChain(swiftPostroutingNftChain)
+		for _, rule := range rules {
+			b.AddRule(swiftPostroutingNftChain, nftables.Rule(
+				nftables.SourceInSet(swiftPodCIDRsNftSet),
+				nftables.DestNotLocal(),
+				nftables.DestIP(net.ParseIP(rule.dest)),
+				nftables.L4Proto(rule.proto),
+				nftables.DestPort(uint16(rule.port)),
+				nftables.SNAT(hostPrimaryIP),
+			)...)
+		}
+
+		// the rules apply to all secondary ip configs in the same subnet
+		break
+	}
+
+	if err := nft.Apply(b); err != nil {
+		return types.FailedToRunIPTableCmd, "[Azure CNS] failed to program swift-postrouting nftables chain : " + err.Error()
+	}
+	logger.Printf("[Azure CNS] Finished reconciling swift-postrouting nftables chain")
+
 	return types.Success, ""
 }
 
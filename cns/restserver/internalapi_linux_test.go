@@ -4,19 +4,26 @@
 package restserver
 
 import (
+	"net"
 	"strconv"
 	"testing"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/fakes"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/snat"
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 )
 
 type FakeIPTablesProvider struct {
 	iptables       *fakes.IPTablesMock
 	iptablesLegacy *fakes.IPTablesLegacyMock
+	nftables       *nftables.FakeConn
 }
 
 func (c *FakeIPTablesProvider) GetIPTables() (iptablesClient, error) {
@@ -27,6 +34,13 @@ func (c *FakeIPTablesProvider) GetIPTables() (iptablesClient, error) {
 	return c.iptables, nil
 }
 
+func (c *FakeIPTablesProvider) GetNftables() (nftablesClient, error) {
+	if c.nftables == nil {
+		c.nftables = nftables.NewFakeConn()
+	}
+	return nftables.NewClientWithConn(swiftNftTable, c.nftables), nil
+}
+
 func (c *FakeIPTablesProvider) GetIPTablesLegacy() (iptablesLegacyClient, error) {
 	if c.iptablesLegacy == nil {
 		c.iptablesLegacy = &fakes.IPTablesLegacyMock{}
@@ -379,3 +393,70 @@ func TestAddSNATRules(t *testing.T) {
 		})
 	}
 }
+
+func TestAddSNATRulesNftables(t *testing.T) {
+	service := getTestService(cns.KubernetesCRD)
+	service.Options[common.OptSNATBackend] = snat.BackendNftables
+	ipt := fakes.NewIPTablesMock()
+	provider := &FakeIPTablesProvider{
+		iptables:       ipt,
+		iptablesLegacy: &fakes.IPTablesLegacyMock{},
+		nftables:       nftables.NewFakeConn(),
+	}
+	service.iptables = provider
+
+	req := &cns.CreateNetworkContainerRequest{
+		NetworkContainerid: ncID,
+		IPConfiguration: cns.IPConfiguration{
+			IPSubnet: cns.IPSubnet{
+				IPAddress:    "240.1.2.1",
+				PrefixLength: 24,
+			},
+		},
+		SecondaryIPConfigs: map[string]cns.SecondaryIPConfig{
+			"abc": {
+				IPAddress: "240.1.2.7",
+			},
+		},
+		HostPrimaryIP: "10.0.0.4",
+	}
+	resp, msg := service.programSNATRules(req)
+	require.Equal(t, types.Success, resp, msg)
+
+	hostPrimaryIP := net.ParseIP("10.0.0.4")
+	expected := [][]nftables.Expr{
+		nftables.Rule(nftables.SourceInSet(swiftPodCIDRsNftSet), nftables.DestNotLocal(), nftables.DestIP(net.ParseIP(networkutils.AzureDNS)),
+			nftables.L4Proto(unix.IPPROTO_UDP), nftables.DestPort(iptables.DNSPort), nftables.SNAT(hostPrimaryIP)),
+		nftables.Rule(nftables.SourceInSet(swiftPodCIDRsNftSet), nftables.DestNotLocal(), nftables.DestIP(net.ParseIP(networkutils.AzureDNS)),
+			nftables.L4Proto(unix.IPPROTO_TCP), nftables.DestPort(iptables.DNSPort), nftables.SNAT(hostPrimaryIP)),
+		nftables.Rule(nftables.SourceInSet(swiftPodCIDRsNftSet), nftables.DestNotLocal(), nftables.DestIP(net.ParseIP(networkutils.AzureIMDS)),
+			nftables.L4Proto(unix.IPPROTO_TCP), nftables.DestPort(iptables.HTTPPort), nftables.SNAT(hostPrimaryIP)),
+	}
+	require.Equal(t, expected, provider.nftables.Rules(swiftNftTable, swiftPostroutingNftChain))
+	require.True(t, provider.nftables.SetContains(swiftNftTable, swiftPodCIDRsNftSet, net.ParseIP("240.1.2.200").To4()))
+	require.False(t, provider.nftables.SetContains(swiftNftTable, swiftPodCIDRsNftSet, net.ParseIP("240.1.3.1").To4()))
+
+	// the iptables rules are untouched
+	chainExists, err := ipt.ChainExists(iptables.Nat, SWIFTPOSTROUTING)
+	require.NoError(t, err)
+	require.False(t, chainExists)
+
+	// a new pod subnet replaces the old one
+	req.IPConfiguration.IPSubnet.PrefixLength = 16
+	resp, msg = service.programSNATRules(req)
+	require.Equal(t, types.Success, resp, msg)
+	require.Equal(t, expected, provider.nftables.Rules(swiftNftTable, swiftPostroutingNftChain))
+	require.Len(t, provider.nftables.Elements(swiftNftTable, swiftPodCIDRsNftSet), 2)
+	require.True(t, provider.nftables.SetContains(swiftNftTable, swiftPodCIDRsNftSet, net.ParseIP("240.1.3.1").To4()))
+
+	// switching back to iptables deletes the nftables table, and leaves the CNI table alone
+	require.NoError(t, nftables.NewClientWithConn(nftables.Table, provider.nftables).Apply(nftables.NewBatch().AddSet(nftables.Set{Name: "cni"})))
+	service.Options[common.OptSNATBackend] = snat.BackendIPTables
+	resp, msg = service.programSNATRules(req)
+	require.Equal(t, types.Success, resp, msg)
+	require.False(t, provider.nftables.HasTable(swiftNftTable))
+	require.True(t, provider.nftables.HasTable(nftables.Table))
+	chainExists, err = ipt.ChainExists(iptables.Nat, SWIFTPOSTROUTING)
+	require.NoError(t, err)
+	require.True(t, chainExists)
+}
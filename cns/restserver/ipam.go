@@ -11,6 +11,7 @@ import (
 	"net/netip"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/filter"
@@ -18,7 +19,11 @@ import (
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/store"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/maps"
 )
 
@@ -37,7 +42,10 @@ const (
 )
 
 // requestIPConfigHandlerHelper validates the request, assign IPs and return the IPConfigs
-func (service *HTTPRestService) requestIPConfigHandlerHelper(ctx context.Context, ipconfigsRequest cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+func (service *HTTPRestService) requestIPConfigHandlerHelper(ctx context.Context, ipconfigsRequest cns.IPConfigsRequest) (resp *cns.IPConfigsResponse, err error) {
+	ctx, span := tracer.Start(ctx, "cns.AssignIPConfigs", trace.WithAttributes(semconv.ContainerID(ipconfigsRequest.InfraContainerID)))
+	defer func() { tracing.End(span, err) }()
+
 	// For SWIFT v2 scenario, the validator function will also modify the ipconfigsRequest.
 	podInfo, returnCode, returnMessage := service.validateIPConfigsRequest(ctx, ipconfigsRequest)
 	if returnCode != types.Success {
@@ -218,7 +226,7 @@ func (service *HTTPRestService) updatePodInfoWithInterfaces(ctx context.Context,
 // RequestIPConfigHandler requests an IPConfig from the CNS state
 func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r *http.Request) {
 	opName := "requestIPConfigHandler"
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	var ipconfigRequest cns.IPConfigRequest
 	err := common.Decode(w, r, &ipconfigRequest)
 	logger.Request(opName, ipconfigRequest, err)
@@ -294,17 +302,32 @@ func (service *HTTPRestService) RequestIPConfigHandler(w http.ResponseWriter, r
 // RequestIPConfigsHandler requests multiple IPConfigs from the CNS state
 func (service *HTTPRestService) RequestIPConfigsHandler(w http.ResponseWriter, r *http.Request) {
 	opName := "requestIPConfigsHandler"
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	var ipconfigsRequest cns.IPConfigsRequest
 	err := common.Decode(w, r, &ipconfigsRequest)
 	logger.Request(opName, ipconfigsRequest, err)
 	if err != nil {
 		return
 	}
-	var ipConfigsResp *cns.IPConfigsResponse
+	ipConfigsResp, err := service.RequestIPConfigsHelper(r.Context(), ipconfigsRequest)
+	if err != nil {
+		w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
+		err = common.Encode(w, &ipConfigsResp)
+		logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+		return
+	}
 
+	w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
+	err = common.Encode(w, &ipConfigsResp)
+	logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+}
+
+// RequestIPConfigsHelper assigns IPs to the pod in the request, dispatching through the IPConfigsHandlerMiddleware if one is set.
+func (service *HTTPRestService) RequestIPConfigsHelper(ctx context.Context, ipconfigsRequest cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
 	// Check if IPConfigsHandlerMiddleware is set
 	if service.IPConfigsHandlerMiddleware != nil {
+		ctx, span := tracer.Start(ctx, "cns.IPConfigsHandlerMiddleware",
+			trace.WithAttributes(attribute.String("cns.swiftv2.mode", string(service.IPConfigsHandlerMiddleware.Type()))))
 		// Wrap the default datapath handlers with the middleware depending on middleware type
 		var wrappedHandler cns.IPConfigsHandlerFunc
 		switch service.IPConfigsHandlerMiddleware.Type() {
@@ -315,21 +338,11 @@ func (service *HTTPRestService) RequestIPConfigsHandler(w http.ResponseWriter, r
 			wrappedHandler = service.IPConfigsHandlerMiddleware.IPConfigsRequestHandlerWrapper(service.requestIPConfigHandlerHelperStandalone, nil)
 		}
 
-		ipConfigsResp, err = wrappedHandler(r.Context(), ipconfigsRequest)
-	} else {
-		ipConfigsResp, err = service.requestIPConfigHandlerHelper(r.Context(), ipconfigsRequest) // nolint:contextcheck // appease linter
+		resp, err := wrappedHandler(ctx, ipconfigsRequest)
+		tracing.End(span, err)
+		return resp, err
 	}
-
-	if err != nil {
-		w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
-		err = common.Encode(w, &ipConfigsResp)
-		logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
-		return
-	}
-
-	w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
-	err = common.Encode(w, &ipConfigsResp)
-	logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+	return service.requestIPConfigHandlerHelper(ctx, ipconfigsRequest)
 }
 
 func (service *HTTPRestService) updateEndpointState(ipconfigsRequest cns.IPConfigsRequest, podInfo cns.PodInfo, podIPInfo []cns.PodIpInfo) error {
@@ -390,6 +403,7 @@ func (service *HTTPRestService) updateEndpointState(ipconfigsRequest cns.IPConfi
 		if err != nil {
 			return fmt.Errorf("failed to write endpoint state to store: %w", err)
 		}
+		service.publishEndpointStateEvent(ipconfigsRequest.InfraContainerID, service.EndpointState[ipconfigsRequest.InfraContainerID], false)
 	}
 	return nil
 }
@@ -438,7 +452,7 @@ func (service *HTTPRestService) ReleaseIPConfigHandlerHelper(ctx context.Context
 // ReleaseIPConfigHandler frees the IP assigned to a pod from CNS
 func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r *http.Request) {
 	opName := "releaseIPConfigHandler"
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	var ipconfigRequest cns.IPConfigRequest
 	err := common.Decode(w, r, &ipconfigRequest)
 	logger.Request(opName, ipconfigRequest, err)
@@ -493,7 +507,7 @@ func (service *HTTPRestService) ReleaseIPConfigHandler(w http.ResponseWriter, r
 // ReleaseIPConfigsHandler frees multiple IPConfigs from the CNS state
 func (service *HTTPRestService) ReleaseIPConfigsHandler(w http.ResponseWriter, r *http.Request) {
 	opName := "releaseIPConfigsHandler"
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	var ipconfigsRequest cns.IPConfigsRequest
 	err := common.Decode(w, r, &ipconfigsRequest)
 	logger.Request("releaseIPConfigsHandler", ipconfigsRequest, err)
@@ -521,6 +535,57 @@ func (service *HTTPRestService) ReleaseIPConfigsHandler(w http.ResponseWriter, r
 	logger.ResponseEx(opName, ipconfigsRequest, resp, resp.Response.ReturnCode, err)
 }
 
+// GetIPConfigsHelper validates the request and returns the IPs assigned to the pod, without assigning any.
+// A pod without IPs gets an empty PodIPInfo.
+func (service *HTTPRestService) GetIPConfigsHelper(ctx context.Context, ipconfigsRequest cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	podInfo, returnCode, returnMessage := service.validateIPConfigsRequest(ctx, ipconfigsRequest)
+	if returnCode != types.Success {
+		return &cns.IPConfigsResponse{
+			Response: cns.Response{
+				ReturnCode: returnCode,
+				Message:    returnMessage,
+			},
+		}, fmt.Errorf("failed to validate ip config request") //nolint:goerr113 // return error
+	}
+
+	podIPInfo, _, err := service.GetExistingIPConfig(podInfo)
+	if err != nil {
+		return &cns.IPConfigsResponse{
+			Response: cns.Response{
+				ReturnCode: types.UnexpectedError,
+				Message:    err.Error(),
+			},
+		}, err
+	}
+
+	return &cns.IPConfigsResponse{
+		Response: cns.Response{
+			ReturnCode: types.Success,
+		},
+		PodIPInfo: podIPInfo,
+	}, nil
+}
+
+// GetIPConfigsHandler returns the IPs assigned to a pod in CNS
+func (service *HTTPRestService) GetIPConfigsHandler(w http.ResponseWriter, r *http.Request) {
+	opName := "getIPConfigsHandler"
+	var ipconfigsRequest cns.IPConfigsRequest
+	err := common.Decode(w, r, &ipconfigsRequest)
+	logger.Request(opName, ipconfigsRequest, err)
+	if err != nil {
+		return
+	}
+
+	ipConfigsResp, err := service.GetIPConfigsHelper(r.Context(), ipconfigsRequest)
+	if err != nil {
+		logger.Errorf("[%s] failed to get the IPs of the pod: %v", opName, err)
+	}
+
+	w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
+	err = common.Encode(w, &ipConfigsResp)
+	logger.ResponseEx(opName, ipconfigsRequest, ipConfigsResp, ipConfigsResp.Response.ReturnCode, err)
+}
+
 func (service *HTTPRestService) removeEndpointState(podInfo cns.PodInfo) error {
 	if service.EndpointStateStore == nil {
 		return ErrStoreEmpty
@@ -528,12 +593,13 @@ func (service *HTTPRestService) removeEndpointState(podInfo cns.PodInfo) error {
 	service.Lock()
 	defer service.Unlock()
 	logger.Printf("[removeEndpointState] Removing endpoint state for infra container %s", podInfo.InfraContainerID())
-	if _, ok := service.EndpointState[podInfo.InfraContainerID()]; ok {
+	if endpointInfo, ok := service.EndpointState[podInfo.InfraContainerID()]; ok {
 		delete(service.EndpointState, podInfo.InfraContainerID())
 		err := service.EndpointStateStore.Write(EndpointStoreKey, service.EndpointState)
 		if err != nil {
 			return fmt.Errorf("failed to write endpoint state to store: %w", err)
 		}
+		service.publishEndpointStateEvent(podInfo.InfraContainerID(), endpointInfo, true)
 	} else { // will not fail if no endpoint state for infra container id is found
 		logger.Printf("[removeEndpointState] No endpoint state found for infra container %s", podInfo.InfraContainerID())
 	}
@@ -543,7 +609,7 @@ func (service *HTTPRestService) removeEndpointState(podInfo cns.PodInfo) error {
 // MarkIPAsPendingRelease will set the IPs which are in PendingProgramming or Available to PendingRelease state
 // It will try to update [totalIpsToRelease]  number of ips.
 func (service *HTTPRestService) MarkIPAsPendingRelease(totalIpsToRelease int) (map[string]cns.IPConfigurationStatus, error) {
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	pendingReleasedIps := make(map[string]cns.IPConfigurationStatus)
 	service.Lock()
 	defer service.Unlock()
@@ -589,7 +655,7 @@ func (service *HTTPRestService) MarkIPAsPendingRelease(totalIpsToRelease int) (m
 // and return an error.
 // MarkNIPsPendingRelease is no-op if [n] is not a positive integer.
 func (service *HTTPRestService) MarkNIPsPendingRelease(n int) (map[string]cns.IPConfigurationStatus, error) {
-	defer service.publishIPStateMetrics()
+	defer service.PublishIPStateMetrics()
 	service.Lock()
 	defer service.Unlock()
 	// try to release from PendingProgramming
@@ -646,9 +712,18 @@ func (service *HTTPRestService) MarkNIPsPendingRelease(n int) (map[string]cns.IP
 func (service *HTTPRestService) updateIPConfigState(ipID string, updatedState types.IPState, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) {
 	if ipConfig, found := service.PodIPConfigState[ipID]; found {
 		logger.Printf("[updateIPConfigState] Changing IpId [%s] state to [%s], podInfo [%+v]. Current config [%+v]", ipID, updatedState, podInfo, ipConfig)
+		previousState, eventPodInfo := ipConfig.GetState(), podInfo
+		if eventPodInfo == nil {
+			// report the pod the IP was released from
+			eventPodInfo = ipConfig.PodInfo
+		}
+		if err := service.journalIPConfigStateChange(previousState, updatedState, &ipConfig, eventPodInfo); err != nil {
+			return cns.IPConfigurationStatus{}, err
+		}
 		ipConfig.SetState(updatedState)
 		ipConfig.PodInfo = podInfo
 		service.PodIPConfigState[ipID] = ipConfig
+		service.publishIPConfigStateEvent(previousState, &ipConfig, eventPodInfo, false)
 		return ipConfig, nil
 	}
 
@@ -741,12 +816,20 @@ func (service *HTTPRestService) HandleDebugIPAddresses(w http.ResponseWriter, r
 	}
 	// Get all IPConfigs matching a state and return in the response
 	resp := cns.GetIPAddressStatusResponse{
-		IPConfigurationStatus: filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.PredicatesForStates(req.IPConfigStateFilter...)...),
+		IPConfigurationStatus: service.GetIPConfigsMatchingStates(req.IPConfigStateFilter...),
 	}
 	err := common.Encode(w, &resp)
 	logger.ResponseEx(opName, req, resp, resp.Response.ReturnCode, err)
 }
 
+// GetIPConfigsMatchingStates returns a filtered list of IPs which are in
+// any of the passed States.
+func (service *HTTPRestService) GetIPConfigsMatchingStates(states ...types.IPState) []cns.IPConfigurationStatus {
+	service.RLock()
+	defer service.RUnlock()
+	return filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.PredicatesForStates(states...)...)
+}
+
 // GetAssignedIPConfigs returns a filtered list of IPs which are in
 // Assigned State.
 func (service *HTTPRestService) GetAssignedIPConfigs() []cns.IPConfigurationStatus {
@@ -779,12 +862,31 @@ func (service *HTTPRestService) GetPendingReleaseIPConfigs() []cns.IPConfigurati
 	return filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.StatePendingRelease)
 }
 
+// GetCoolingIPConfigs returns a filtered list of IPs which are in
+// Cooling State.
+func (service *HTTPRestService) GetCoolingIPConfigs() []cns.IPConfigurationStatus {
+	service.RLock()
+	defer service.RUnlock()
+	return filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.StateCooling)
+}
+
 // assignIPConfig assigns the the ipconfig to the passed Pod, sets the state as Assigned, does not take a lock.
 func (service *HTTPRestService) assignIPConfig(ipconfig cns.IPConfigurationStatus, podInfo cns.PodInfo) error { //nolint:gocritic // ignore hugeparam
-	ipconfig, err := service.updateIPConfigState(ipconfig.ID, types.Assigned, podInfo)
+	ipconfig, err := service.setIPConfigAssigned(ipconfig, podInfo)
 	if err != nil {
 		return err
 	}
+	service.recordIPAssigned(&ipconfig, podInfo)
+	return nil
+}
+
+// setIPConfigAssigned is assignIPConfig without adding the pod to the history of the IP, for the IPs which are
+// assigned back to the pod they could not be released from.
+func (service *HTTPRestService) setIPConfigAssigned(ipconfig cns.IPConfigurationStatus, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) { //nolint:gocritic // ignore hugeparam
+	ipconfig, err := service.updateIPConfigState(ipconfig.ID, types.Assigned, podInfo)
+	if err != nil {
+		return cns.IPConfigurationStatus{}, err
+	}
 
 	if service.PodIPIDByPodInterfaceKey[podInfo.Key()] == nil {
 		logger.Printf("IP config %v initialized", podInfo.Key())
@@ -792,15 +894,17 @@ func (service *HTTPRestService) assignIPConfig(ipconfig cns.IPConfigurationStatu
 	}
 
 	service.PodIPIDByPodInterfaceKey[podInfo.Key()] = append(service.PodIPIDByPodInterfaceKey[podInfo.Key()], ipconfig.ID)
-	return nil
+	return ipconfig, nil
 }
 
-// unassignIPConfig unassigns the ipconfig from the passed Pod, sets the state as Available, does not take a lock.
+// unassignIPConfig unassigns the ipconfig from the passed Pod, sets the state as Available, or Cooling if released IPs
+// are quarantined, does not take a lock.
 func (service *HTTPRestService) unassignIPConfig(ipconfig cns.IPConfigurationStatus, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) { //nolint:gocritic // ignore hugeparam
-	ipconfig, err := service.updateIPConfigState(ipconfig.ID, types.Available, nil)
+	ipconfig, err := service.updateIPConfigState(ipconfig.ID, service.releasedIPState(), nil)
 	if err != nil {
 		return cns.IPConfigurationStatus{}, err
 	}
+	service.recordIPReleased(&ipconfig, podInfo)
 
 	delete(service.PodIPIDByPodInterfaceKey, podInfo.Key())
 	logger.Printf("[setIPConfigAsAvailable] Deleted outdated pod info %s from PodIPIDByOrchestratorContext since IP %s with ID %s will be released and set as Available",
@@ -843,11 +947,7 @@ func (service *HTTPRestService) releaseIPConfigs(podInfo cns.PodInfo) error {
 
 	if failedToReleaseIP {
 		// reassigns all of the released IPs if we aren't able to release all of them
-		for _, ip := range ipsToBeReleased { //nolint:gocritic // ignore copy
-			if err := service.assignIPConfig(ip, podInfo); err != nil {
-				logger.Errorf("[releaseIPConfigs] failed to mark IPConfig [%+v] back to Assigned. err: %v", ip, err)
-			}
-		}
+		service.revertReleaseIPConfigs(ipsToBeReleased, podInfo)
 		//nolint:goerr113 // return error
 		return fmt.Errorf("[releaseIPConfigs] Failed to release one or more IPs. Not releasing any IPs for pod %+v", podInfo)
 	}
@@ -856,6 +956,19 @@ func (service *HTTPRestService) releaseIPConfigs(podInfo cns.PodInfo) error {
 	return nil
 }
 
+// revertReleaseIPConfigs assigns the IPs back to the pod they could not all be released from. The pod is still the
+// owner of the IPs in their history, so it is not recorded again. Does not take a lock.
+func (service *HTTPRestService) revertReleaseIPConfigs(ips []cns.IPConfigurationStatus, podInfo cns.PodInfo) {
+	for _, ip := range ips { //nolint:gocritic // ignore copy
+		ipconfig, err := service.setIPConfigAssigned(ip, podInfo)
+		if err != nil {
+			logger.Errorf("[releaseIPConfigs] failed to mark IPConfig [%+v] back to Assigned. err: %v", ip, err)
+			continue
+		}
+		service.recordIPReleaseReverted(&ipconfig, podInfo)
+	}
+}
+
 // MarkExistingIPsAsPendingRelease is called when CNS is starting up and there are existing ipconfigs in the CRD that are marked as pending.
 func (service *HTTPRestService) MarkExistingIPsAsPendingRelease(pendingIPIDs []string) error {
 	service.Lock()
@@ -868,8 +981,13 @@ func (service *HTTPRestService) MarkExistingIPsAsPendingRelease(pendingIPIDs []s
 			}
 
 			logger.Printf("[MarkExistingIPsAsPending]: Marking IP [%+v] to PendingRelease", ipconfig)
+			previousState := ipconfig.GetState()
+			if err := service.journalIPConfigStateChange(previousState, types.PendingRelease, &ipconfig, ipconfig.PodInfo); err != nil {
+				return err
+			}
 			ipconfig.SetState(types.PendingRelease)
 			service.PodIPConfigState[id] = ipconfig
+			service.publishIPConfigStateEvent(previousState, &ipconfig, ipconfig.PodInfo, false)
 		} else {
 			logger.Errorf("Inconsistent state, ipconfig with ID [%v] marked as pending release, but does not exist in state", id)
 		}
@@ -955,6 +1073,13 @@ func (service *HTTPRestService) AssignDesiredIPConfigs(podInfo cns.PodInfo, desi
 			// This race can happen during restart, where CNS state is lost and thus we have lost the NC programmed version
 			// As part of reconcile, we mark IPs as Assigned which are already assigned to Pods (listed from APIServer)
 			ipConfigsToAssign = append(ipConfigsToAssign, ipConfig)
+		case types.Cooling:
+			// A Cooling IP is not handed out to new pods, but may be desired explicitly by the pod it was released from.
+			if !service.releasedByPod(&ipConfig, podInfo) {
+				//nolint:goerr113 // return error
+				return []cns.PodIpInfo{}, fmt.Errorf("[AssignDesiredIPConfigs] Desired IP is cooling after its release from another pod %+v, requested for pod %+v", ipConfig, podInfo)
+			}
+			ipConfigsToAssign = append(ipConfigsToAssign, ipConfig)
 		default:
 			logger.Errorf("[AssignDesiredIPConfigs] Desired IP is not available %+v", ipConfig)
 			//nolint:goerr113 // return error
@@ -1025,6 +1150,8 @@ func (service *HTTPRestService) AssignAvailableIPConfigs(podInfo cns.PodInfo) ([
 
 	service.Lock()
 	defer service.Unlock()
+	// IPs which finished their quarantine since the last sweep can be assigned now
+	service.promoteCooledIPConfigsUntransacted(time.Now())
 	// Creates a slice of PodIpInfo with the size as number of NCs to hold the result for assigned IP configs
 	podIPInfo := make([]cns.PodIpInfo, numberOfIPs)
 	// This map is used to store whether or not we have found an available IP from an NC when looping through the pool
@@ -1224,7 +1351,7 @@ func (service *HTTPRestService) DeleteEndpointStateHelper(endpointID string) err
 		return ErrStoreEmpty
 	}
 	logger.Printf("[deleteEndpointState] Deleting Endpoint state from state file %s", endpointID) //nolint:staticcheck // reason: using deprecated call until migration to new API
-	_, endpointExist := service.EndpointState[endpointID]
+	endpointInfo, endpointExist := service.EndpointState[endpointID]
 	if !endpointExist {
 		logger.Printf("[deleteEndpointState] endpoint could not be found in the statefile %s", endpointID) //nolint:staticcheck // reason: using deprecated call until migration to new API
 		return fmt.Errorf("[deleteEndpointState] endpoint %s: %w", endpointID, ErrEndpointStateNotFound)
@@ -1238,6 +1365,7 @@ func (service *HTTPRestService) DeleteEndpointStateHelper(endpointID string) err
 	if err != nil {
 		return fmt.Errorf("[deleteEndpointState] failed to write endpoint state to store: %w", err)
 	}
+	service.publishEndpointStateEvent(endpointID, endpointInfo, true)
 	logger.Printf("[deleteEndpointState] successfully deleted endpoint %s from state file", endpointID) //nolint:staticcheck // reason: using deprecated call until migration to new API
 	return nil
 }
@@ -1337,7 +1465,7 @@ func (service *HTTPRestService) UpdateEndpointHandler(w http.ResponseWriter, r *
 		logger.Response(opName, response, response.ReturnCode, err)
 		return
 	}
-	if err = verifyUpdateEndpointStateRequest(req); err != nil {
+	if err = VerifyUpdateEndpointStateRequest(req); err != nil {
 		response := cns.Response{
 			ReturnCode: types.InvalidRequest,
 			Message:    err.Error(),
@@ -1391,6 +1519,7 @@ func (service *HTTPRestService) UpdateEndpointHelper(endpointID string, req map[
 	if err != nil {
 		return fmt.Errorf("[updateEndpoint] failed to write endpoint state to store for pod %s :  %w", endpointInfo.PodName, err)
 	}
+	service.publishEndpointStateEvent(endpointID, endpointInfo, false)
 	logger.Printf("[updateEndpoint] successfully write the state to the file %s", endpointID)
 	return nil
 }
@@ -1441,8 +1570,8 @@ func updateIPInfoMap(iPInfo map[string]*IPInfo, interfaceInfo *IPInfo, ifName, e
 	}
 }
 
-// verifyUpdateEndpointStateRequest verify the CNI request body for the UpdateENdpointState API
-func verifyUpdateEndpointStateRequest(req map[string]*IPInfo) error {
+// VerifyUpdateEndpointStateRequest verify the CNI request body for the UpdateENdpointState API
+func VerifyUpdateEndpointStateRequest(req map[string]*IPInfo) error {
 	for ifName, InterfaceInfo := range req {
 		if InterfaceInfo.HostVethName == "" && InterfaceInfo.HnsEndpointID == "" && InterfaceInfo.NICType == "" && InterfaceInfo.MacAddress == "" {
 			return errors.New("[updateEndpoint] No NicType, MacAddress, HnsEndpointID or HostVethName has been provided")
@@ -1000,6 +1000,40 @@ func ipamRequestThenReleaseThenRequestAgain(t *testing.T, ncStates []ncState) {
 	}
 }
 
+func TestIPAMGetIPConfigs(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+		testIPID2: newPodState(testIP2, testIPID2, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+
+	// a pod without IPs gets none, and none are assigned to it
+	resp, err := svc.GetIPConfigsHelper(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.PodIPInfo)
+	assert.Len(t, svc.GetAvailableIPConfigs(), 2)
+
+	assigned, err := requestIPConfigsForPod(t, svc, testPod1Info)
+	require.NoError(t, err)
+	require.Len(t, assigned, 1)
+	resp, err = svc.GetIPConfigsHelper(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.PodIPInfo, 1)
+	assert.Equal(t, assigned[0].PodIPConfig.IPAddress, resp.PodIPInfo[0].PodIPConfig.IPAddress)
+	assert.Len(t, svc.GetAvailableIPConfigs(), 1)
+
+	// the request is validated as for the assignment
+	_, err = svc.GetIPConfigsHelper(context.Background(), cns.IPConfigsRequest{})
+	require.Error(t, err)
+}
+
 func TestIPAMReleaseIPIdempotency(t *testing.T) {
 	testNcs := [][]ncState{
 		// single stack NC
@@ -0,0 +1,146 @@
+package restserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/common"
+)
+
+// ipHistorySize is the number of previous owners kept per IP address.
+const ipHistorySize = 16
+
+// ipOwnerRing is a bounded history of the pods which held an IP address. Once full, the oldest owner is overwritten.
+type ipOwnerRing struct {
+	owners [ipHistorySize]cns.IPOwner
+	next   int
+	len    int
+}
+
+func (r *ipOwnerRing) push(owner cns.IPOwner) { //nolint:gocritic // ignore hugeparam
+	r.owners[r.next] = owner
+	r.next = (r.next + 1) % ipHistorySize
+	if r.len < ipHistorySize {
+		r.len++
+	}
+}
+
+// latest returns the most recent owner, or nil if the ring is empty.
+func (r *ipOwnerRing) latest() *cns.IPOwner {
+	if r.len == 0 {
+		return nil
+	}
+	return &r.owners[(r.next+ipHistorySize-1)%ipHistorySize]
+}
+
+// list returns the owners, most recent first.
+func (r *ipOwnerRing) list() []cns.IPOwner {
+	owners := make([]cns.IPOwner, r.len)
+	for i := range owners {
+		owners[i] = r.owners[(r.next+ipHistorySize-1-i)%ipHistorySize]
+	}
+	return owners
+}
+
+// recordIPAssigned adds the pod to the owners of the IP.
+// The caller must hold the service lock.
+func (service *HTTPRestService) recordIPAssigned(ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo) {
+	if service.ipHistory == nil {
+		service.ipHistory = map[string]*ipOwnerRing{}
+	}
+	ring, ok := service.ipHistory[ipconfig.IPAddress]
+	if !ok {
+		ring = &ipOwnerRing{}
+		service.ipHistory[ipconfig.IPAddress] = ring
+	}
+	ring.push(cns.IPOwner{
+		PodKey:           podInfo.Key(),
+		PodName:          podInfo.Name(),
+		PodNamespace:     podInfo.Namespace(),
+		InfraContainerID: podInfo.InfraContainerID(),
+		InterfaceID:      podInfo.InterfaceID(),
+		NCID:             ipconfig.NCID,
+		IPConfigID:       ipconfig.ID,
+		AssignedAt:       time.Now(),
+	})
+}
+
+// recordIPReleased marks the pod as no longer holding the IP.
+// The caller must hold the service lock.
+func (service *HTTPRestService) recordIPReleased(ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo) {
+	ring, ok := service.ipHistory[ipconfig.IPAddress]
+	if !ok {
+		return
+	}
+	if owner := ring.latest(); owner != nil && owner.PodKey == podInfo.Key() && owner.ReleasedAt.IsZero() {
+		owner.ReleasedAt = time.Now()
+	}
+}
+
+// recordIPReleaseReverted marks the pod as holding the IP again, after its release failed.
+// The caller must hold the service lock.
+func (service *HTTPRestService) recordIPReleaseReverted(ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo) {
+	ring, ok := service.ipHistory[ipconfig.IPAddress]
+	if !ok {
+		return
+	}
+	if owner := ring.latest(); owner != nil && owner.PodKey == podInfo.Key() {
+		owner.ReleasedAt = time.Time{}
+	}
+}
+
+// releasedByPod returns whether the pod is the last owner of the IP and has released it.
+// The caller must hold the service lock.
+func (service *HTTPRestService) releasedByPod(ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo) bool {
+	ring, ok := service.ipHistory[ipconfig.IPAddress]
+	if !ok {
+		return false
+	}
+	owner := ring.latest()
+	return owner != nil && owner.PodKey == podInfo.Key() && !owner.ReleasedAt.IsZero()
+}
+
+// forgetIPHistory drops the history of an IP address which was removed from the pool, so that the
+// history is bounded by the IPs CNS currently holds.
+// The caller must hold the service lock.
+func (service *HTTPRestService) forgetIPHistory(ipconfig *cns.IPConfigurationStatus) {
+	delete(service.ipHistory, ipconfig.IPAddress)
+}
+
+// GetIPHistory returns the pods which held the IP address since CNS started or the IP was last added
+// to the pool, most recent first, up to the last ipHistorySize of them.
+func (service *HTTPRestService) GetIPHistory(ipAddress string) []cns.IPOwner {
+	service.RLock()
+	defer service.RUnlock()
+	ring, ok := service.ipHistory[ipAddress]
+	if !ok {
+		return []cns.IPOwner{}
+	}
+	return ring.list()
+}
+
+// HandleDebugIPHistory returns the pods which held the requested IP address.
+func (service *HTTPRestService) HandleDebugIPHistory(w http.ResponseWriter, r *http.Request) {
+	opName := "handleDebugIPHistory"
+	var req cns.GetIPHistoryRequest
+	if err := common.Decode(w, r, &req); err != nil {
+		resp := cns.GetIPHistoryResponse{
+			Response: cns.Response{
+				ReturnCode: types.UnexpectedError,
+				Message:    err.Error(),
+			},
+		}
+		err = common.Encode(w, &resp)
+		logger.ResponseEx(opName, req, resp, resp.Response.ReturnCode, err)
+		return
+	}
+	resp := cns.GetIPHistoryResponse{
+		IPAddress: req.IPAddress,
+		Owners:    service.GetIPHistory(req.IPAddress),
+	}
+	err := common.Encode(w, &resp)
+	logger.ResponseEx(opName, req, resp, resp.Response.ReturnCode, err)
+}
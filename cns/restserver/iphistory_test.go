@@ -0,0 +1,122 @@
+package restserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPOwnerRing(t *testing.T) {
+	var r ipOwnerRing
+	assert.Nil(t, r.latest())
+	assert.Empty(t, r.list())
+
+	for i := 0; i < ipHistorySize+2; i++ {
+		r.push(cns.IPOwner{PodKey: fmt.Sprint(i)})
+	}
+	owners := r.list()
+	require.Len(t, owners, ipHistorySize)
+	assert.Equal(t, fmt.Sprint(ipHistorySize+1), owners[0].PodKey, "most recent owner first")
+	assert.Equal(t, "2", owners[ipHistorySize-1].PodKey, "oldest owners are dropped")
+	assert.Equal(t, owners[0], *r.latest())
+}
+
+func TestIPHistory(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+	assert.Empty(t, svc.GetIPHistory(testIP1))
+
+	for _, podInfo := range []cns.PodInfo{testPod1Info, testPod2Info} {
+		req := cns.IPConfigsRequest{
+			PodInterfaceID:   podInfo.InterfaceID(),
+			InfraContainerID: podInfo.InfraContainerID(),
+		}
+		req.OrchestratorContext, _ = podInfo.OrchestratorContext()
+		_, err := requestIPConfigsHelper(svc, req)
+		require.NoError(t, err)
+		if podInfo == testPod1Info {
+			require.NoError(t, svc.releaseIPConfigs(podInfo))
+		}
+	}
+
+	owners := svc.GetIPHistory(testIP1)
+	require.Len(t, owners, 2)
+	assert.Equal(t, testPod2Info.Key(), owners[0].PodKey)
+	assert.Equal(t, testPod2Info.Name(), owners[0].PodName)
+	assert.Equal(t, testIPID1, owners[0].IPConfigID)
+	assert.Equal(t, testNCID, owners[0].NCID)
+	assert.True(t, owners[0].ReleasedAt.IsZero(), "the current owner has not released the IP")
+	assert.Equal(t, testPod1Info.Key(), owners[1].PodKey)
+	assert.False(t, owners[1].ReleasedAt.Before(owners[1].AssignedAt))
+
+	body, err := json.Marshal(cns.GetIPHistoryRequest{IPAddress: testIP1})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, cns.PathDebugIPHistory, bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	svc.HandleDebugIPHistory(w, req)
+	var resp cns.GetIPHistoryResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, types.Success, resp.Response.ReturnCode)
+	assert.Equal(t, testIP1, resp.IPAddress)
+	assert.Len(t, resp.Owners, 2)
+}
+
+func TestIPHistoryReleaseReverted(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+	_, err := requestIPConfigsForPod(t, svc, testPod1Info)
+	require.NoError(t, err)
+
+	// the release of the IP is reverted, as when another IP of the pod fails to be released
+	svc.Lock()
+	ipconfig := svc.PodIPConfigState[testIPID1]
+	_, err = svc.unassignIPConfig(ipconfig, testPod1Info)
+	require.NoError(t, err)
+	svc.revertReleaseIPConfigs([]cns.IPConfigurationStatus{ipconfig}, testPod1Info)
+	svc.Unlock()
+
+	assert.Equal(t, types.Assigned, ipConfigState(svc, testIPID1))
+	owners := svc.GetIPHistory(testIP1)
+	require.Len(t, owners, 1, "the pod is not recorded again")
+	assert.Equal(t, testPod1Info.Key(), owners[0].PodKey)
+	assert.True(t, owners[0].ReleasedAt.IsZero(), "the pod still holds the IP")
+}
+
+func TestIPHistoryForgetsRemovedIP(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+	_, err := requestIPConfigsHelper(svc, req)
+	require.NoError(t, err)
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	require.Len(t, svc.GetIPHistory(testIP1), 1)
+
+	svc.Lock()
+	returnCode, msg := svc.removeToBeDeletedIPStateUntransacted(testIPID1, false)
+	svc.Unlock()
+	require.Zero(t, returnCode, msg)
+	assert.Empty(t, svc.GetIPHistory(testIP1))
+	assert.NotContains(t, svc.ipHistory, testIP1)
+}
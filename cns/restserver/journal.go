@@ -0,0 +1,75 @@
+package restserver
+
+import (
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/journal"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+)
+
+// journalIPConfigStateChange records an IPConfig state transition from previousState to state in the IPAM journal.
+// The journal is written ahead: the transition must be recorded before it is applied to the IPConfig, and not be
+// applied if recording it fails, so that the state restored from the journal is never behind the state served.
+// Transitions which don't change the IP assignment, such as the IPs becoming programmed, are not recorded.
+// The caller must hold the service lock.
+func (service *HTTPRestService) journalIPConfigStateChange(previousState, state types.IPState, ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo) error {
+	if service.IPAMJournal == nil {
+		return nil
+	}
+
+	var op journal.Op
+	switch {
+	case state == types.Assigned:
+		op = journal.OpAssign
+	case state == types.PendingRelease:
+		op = journal.OpMarkPendingRelease
+	case previousState == types.Assigned:
+		op = journal.OpRelease
+	default:
+		return nil
+	}
+
+	entry := journal.Entry{
+		Op:         op,
+		NCID:       ipconfig.NCID,
+		IPConfigID: ipconfig.ID,
+		IPAddress:  ipconfig.IPAddress,
+	}
+	if podInfo != nil {
+		entry.PodName = podInfo.Name()
+		entry.PodNamespace = podInfo.Namespace()
+		entry.InfraContainerID = podInfo.InfraContainerID()
+		entry.InterfaceID = podInfo.InterfaceID()
+	}
+	if err := service.IPAMJournal.Append(entry); err != nil {
+		logger.Errorf("[journal] failed to record %s of IP %s: %v", op, ipconfig.IPAddress, err)
+		return errors.Wrapf(err, "failed to record %s of IP %s in the IPAM journal", op, ipconfig.IPAddress)
+	}
+	return nil
+}
+
+// journalNetworkContainerChange records a network container created, updated or deleted in the IPAM journal. As for
+// the IPConfigs, the change must be recorded before it is applied, and not be applied if recording it fails.
+// The caller must hold the service lock.
+func (service *HTTPRestService) journalNetworkContainerChange(op journal.Op, ncID string) error {
+	if service.IPAMJournal == nil {
+		return nil
+	}
+	if err := service.IPAMJournal.Append(journal.Entry{Op: op, NCID: ncID}); err != nil {
+		logger.Errorf("[journal] failed to record %s of NC %s: %v", op, ncID, err)
+		return errors.Wrapf(err, "failed to record %s of NC %s in the IPAM journal", op, ncID)
+	}
+	return nil
+}
+
+// CompactIPAMJournal compacts the IPAM journal, if there is one, to the current IPAM state.
+// It should be called once the IPAM state has been reconciled on startup.
+func (service *HTTPRestService) CompactIPAMJournal() error {
+	if service.IPAMJournal == nil {
+		return nil
+	}
+	service.Lock()
+	defer service.Unlock()
+	return errors.Wrap(service.IPAMJournal.Compact(), "failed to compact IPAM journal")
+}
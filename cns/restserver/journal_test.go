@@ -0,0 +1,92 @@
+package restserver
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/journal"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAMJournal(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	path := filepath.Join(t.TempDir(), "azure-cns.journal")
+	j, err := journal.Open(path)
+	require.NoError(t, err)
+	defer j.Close()
+	svc.IPAMJournal = j
+
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+		testIPID2: newPodState(testIP2, testIPID2, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+	resp, err := requestIPConfigsHelper(svc, req)
+	require.NoError(t, err)
+	require.Len(t, resp, 1)
+	assigned := resp[0].PodIPConfig.IPAddress
+
+	state := j.State()
+	assert.Contains(t, state.NCs, testNCID)
+	require.Len(t, state.Assigned, 1)
+	for _, e := range state.Assigned {
+		assert.Equal(t, assigned, e.IPAddress)
+		assert.Equal(t, testPod1Info.Name(), e.PodName)
+		assert.Equal(t, testPod1Info.InterfaceID(), e.InterfaceID)
+	}
+
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	_, err = svc.MarkNIPsPendingRelease(1)
+	require.NoError(t, err)
+	assert.Empty(t, j.State().Assigned)
+
+	entries, err := journal.ReadFile(path)
+	require.NoError(t, err)
+	ops := make([]journal.Op, 0, len(entries))
+	for i := range entries {
+		ops = append(ops, entries[i].Op)
+	}
+	assert.Equal(t, []journal.Op{journal.OpCreateNC, journal.OpAssign, journal.OpRelease, journal.OpMarkPendingRelease}, ops)
+
+	// compaction keeps the NC
+	require.NoError(t, svc.CompactIPAMJournal())
+	entries, err = journal.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, journal.OpCreateNC, entries[0].Op)
+}
+
+func TestIPAMJournalAppendFailure(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	j, err := journal.Open(filepath.Join(t.TempDir(), "azure-cns.journal"))
+	require.NoError(t, err)
+	svc.IPAMJournal = j
+
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	// the journal can't be written anymore, so the IP is not assigned
+	require.NoError(t, j.Close())
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+	_, err = requestIPConfigsHelper(svc, req)
+	require.Error(t, err)
+	ipconfig := svc.PodIPConfigState[testIPID1]
+	assert.Equal(t, types.Available, ipconfig.GetState())
+	assert.Empty(t, svc.PodIPIDByPodInterfaceKey[testPod1Info.Key()])
+	assert.Empty(t, j.State().Assigned)
+}
@@ -95,6 +95,14 @@ var (
 		},
 		[]string{},
 	)
+	coolingIPCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cx_cooling_ips_v2",
+			Help:        "Count of IPs in Cooling State",
+			ConstLabels: prometheus.Labels{customerMetricLabel: customerMetricLabelValue},
+		},
+		[]string{},
+	)
 	pendingProgrammingIPCount = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cx_pending_programming_ips_v2",
@@ -124,6 +132,7 @@ func init() {
 		allocatedIPCount,
 		assignedIPCount,
 		availableIPCount,
+		coolingIPCount,
 		pendingProgrammingIPCount,
 		pendingReleaseIPCount,
 	)
@@ -158,6 +167,8 @@ type ipState struct {
 	assignedIPs int64
 	// availableIPs are the IPs in state "Available".
 	availableIPs int64
+	// coolingIPs are the IPs in state "Cooling".
+	coolingIPs int64
 	// programmingIPs are the IPs in state "PendingProgramming".
 	programmingIPs int64
 	// releasingIPs are the IPs in state "PendingReleasr".
@@ -191,6 +202,9 @@ func (a *asyncMetricsRecorder) record() {
 		if ipConfig.GetState() == types.Available {
 			state.availableIPs++
 		}
+		if ipConfig.GetState() == types.Cooling {
+			state.coolingIPs++
+		}
 		if ipConfig.GetState() == types.PendingProgramming {
 			state.programmingIPs++
 		}
@@ -199,10 +213,11 @@ func (a *asyncMetricsRecorder) record() {
 		}
 	}
 
-	logger.Printf("Allocated IPs: %d, Assigned IPs: %d, Available IPs: %d, PendingProgramming IPs: %d, PendingRelease IPs: %d",
+	logger.Printf("Allocated IPs: %d, Assigned IPs: %d, Available IPs: %d, Cooling IPs: %d, PendingProgramming IPs: %d, PendingRelease IPs: %d",
 		state.allocatedIPs,
 		state.assignedIPs,
 		state.availableIPs,
+		state.coolingIPs,
 		state.programmingIPs,
 		state.releasingIPs,
 	)
@@ -211,12 +226,13 @@ func (a *asyncMetricsRecorder) record() {
 	allocatedIPCount.WithLabelValues(labels...).Set(float64(state.allocatedIPs))
 	assignedIPCount.WithLabelValues(labels...).Set(float64(state.assignedIPs))
 	availableIPCount.WithLabelValues(labels...).Set(float64(state.availableIPs))
+	coolingIPCount.WithLabelValues(labels...).Set(float64(state.coolingIPs))
 	pendingProgrammingIPCount.WithLabelValues(labels...).Set(float64(state.programmingIPs))
 	pendingReleaseIPCount.WithLabelValues(labels...).Set(float64(state.releasingIPs))
 }
 
-// publishIPStateMetrics logs and publishes the IP Config state metrics to Prometheus.
-func (service *HTTPRestService) publishIPStateMetrics() {
+// PublishIPStateMetrics logs and publishes the IP Config state metrics to Prometheus.
+func (service *HTTPRestService) PublishIPStateMetrics() {
 	recorder.once.Do(func() {
 		recorder.podIPConfigSrc = service.PodIPConfigStates
 		recorder.sig = make(chan struct{})
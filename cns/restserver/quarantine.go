@@ -0,0 +1,71 @@
+package restserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+)
+
+// minIPQuarantineSweepInterval bounds how often the Cooling IPs are checked for the end of their quarantine.
+const minIPQuarantineSweepInterval = time.Second
+
+// releasedIPState is the state an IP released by a pod moves to: Cooling while released IPs are quarantined,
+// so that stale conntrack, ARP and network policy state for the previous pod can expire before it is reused,
+// and Available otherwise.
+func (service *HTTPRestService) releasedIPState() types.IPState {
+	if service.IPQuarantine > 0 {
+		return types.Cooling
+	}
+	return types.Available
+}
+
+// promoteCooledIPConfigsUntransacted moves the Cooling IPs which have been quarantined for at least IPQuarantine
+// to Available, and returns how many were promoted.
+// The caller must hold the service lock.
+func (service *HTTPRestService) promoteCooledIPConfigsUntransacted(now time.Time) int {
+	promoted := 0
+	for uuid, ipConfig := range service.PodIPConfigState { //nolint:gocritic // intentional value copy
+		if ipConfig.GetState() != types.Cooling || now.Sub(ipConfig.LastStateTransition) < service.IPQuarantine {
+			continue
+		}
+		if _, err := service.updateIPConfigState(uuid, types.Available, nil); err != nil {
+			logger.Errorf("[promoteCooledIPConfigs] failed to mark IP %s Available: %v", ipConfig.IPAddress, err)
+			continue
+		}
+		promoted++
+	}
+	return promoted
+}
+
+// PromoteCooledIPConfigs moves the Cooling IPs whose quarantine is over to Available.
+func (service *HTTPRestService) PromoteCooledIPConfigs() {
+	service.Lock()
+	promoted := service.promoteCooledIPConfigsUntransacted(time.Now())
+	service.Unlock()
+	if promoted > 0 {
+		logger.Printf("[promoteCooledIPConfigs] %d IPs finished their quarantine and are Available", promoted)
+		service.PublishIPStateMetrics()
+	}
+}
+
+// PromoteCooledIPConfigsPeriodically promotes the Cooling IPs whose quarantine is over until the context is
+// cancelled. IPs are also promoted as they are needed to assign to pods, this keeps the pool state current for the
+// pool monitor and the metrics when no pods are being scheduled.
+func (service *HTTPRestService) PromoteCooledIPConfigsPeriodically(ctx context.Context) {
+	interval := service.IPQuarantine / 4 //nolint:gomnd // check a few times per quarantine
+	if interval < minIPQuarantineSweepInterval {
+		interval = minIPQuarantineSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.PromoteCooledIPConfigs()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package restserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestIPConfigsForPod(t *testing.T, svc *HTTPRestService, podInfo cns.PodInfo) ([]cns.PodIpInfo, error) {
+	t.Helper()
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   podInfo.InterfaceID(),
+		InfraContainerID: podInfo.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = podInfo.OrchestratorContext()
+	return requestIPConfigsHelper(svc, req)
+}
+
+func ipConfigState(svc *HTTPRestService, ipID string) types.IPState {
+	svc.RLock()
+	defer svc.RUnlock()
+	ipconfig := svc.PodIPConfigState[ipID]
+	return ipconfig.GetState()
+}
+
+// coolSince moves the start of the quarantine of the IP back by d.
+func coolSince(svc *HTTPRestService, ipID string, d time.Duration) {
+	svc.Lock()
+	defer svc.Unlock()
+	ipconfig := svc.PodIPConfigState[ipID]
+	ipconfig.LastStateTransition = ipconfig.LastStateTransition.Add(-d)
+	svc.PodIPConfigState[ipID] = ipconfig
+}
+
+func TestIPQuarantine(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	svc.IPQuarantine = time.Hour
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	_, err := requestIPConfigsForPod(t, svc, testPod1Info)
+	require.NoError(t, err)
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	assert.Equal(t, types.Cooling, ipConfigState(svc, testIPID1))
+	assert.Len(t, svc.GetCoolingIPConfigs(), 1)
+
+	// a cooling IP is not handed out to another pod, nor released back to the subnet.
+	_, err = requestIPConfigsForPod(t, svc, testPod2Info)
+	require.Error(t, err)
+	_, err = svc.MarkNIPsPendingRelease(1)
+	require.Error(t, err)
+	assert.Equal(t, types.Cooling, ipConfigState(svc, testIPID1))
+
+	// the sweep leaves IPs alone until their quarantine is over.
+	svc.PromoteCooledIPConfigs()
+	assert.Equal(t, types.Cooling, ipConfigState(svc, testIPID1))
+
+	// once it is, the IP is assigned again.
+	coolSince(svc, testIPID1, time.Hour)
+	podIPInfo, err := requestIPConfigsForPod(t, svc, testPod2Info)
+	require.NoError(t, err)
+	require.Len(t, podIPInfo, 1)
+	assert.Equal(t, testIP1, podIPInfo[0].PodIPConfig.IPAddress)
+	assert.Equal(t, types.Assigned, ipConfigState(svc, testIPID1))
+}
+
+func TestIPQuarantineDesiredIP(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	svc.IPQuarantine = time.Hour
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	_, err := requestIPConfigsForPod(t, svc, testPod1Info)
+	require.NoError(t, err)
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	require.Equal(t, types.Cooling, ipConfigState(svc, testIPID1))
+
+	// a cooling IP desired by another pod is rejected, as if it was still assigned.
+	_, err = svc.AssignDesiredIPConfigs(testPod2Info, []string{testIP1})
+	require.Error(t, err)
+	assert.Equal(t, types.Cooling, ipConfigState(svc, testIPID1))
+
+	// while the pod it was released from gets it back.
+	podIPInfo, err := svc.AssignDesiredIPConfigs(testPod1Info, []string{testIP1})
+	require.NoError(t, err)
+	require.Len(t, podIPInfo, 1)
+	assert.Equal(t, testIP1, podIPInfo[0].PodIPConfig.IPAddress)
+	assert.Equal(t, types.Assigned, ipConfigState(svc, testIPID1))
+}
+
+func TestIPQuarantineSweep(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	svc.IPQuarantine = time.Hour
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+		testIPID2: newPodState(testIP2, testIPID2, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	for _, podInfo := range []cns.PodInfo{testPod1Info, testPod2Info} {
+		_, err := requestIPConfigsForPod(t, svc, podInfo)
+		require.NoError(t, err)
+		require.NoError(t, svc.releaseIPConfigs(podInfo))
+	}
+	require.Len(t, svc.GetCoolingIPConfigs(), 2)
+
+	coolSince(svc, testIPID1, time.Hour)
+	svc.PromoteCooledIPConfigs()
+	assert.Equal(t, types.Available, ipConfigState(svc, testIPID1))
+	assert.Equal(t, types.Cooling, ipConfigState(svc, testIPID2))
+}
+
+func TestIPQuarantineDisabled(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	_, err := requestIPConfigsForPod(t, svc, testPod1Info)
+	require.NoError(t, err)
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	assert.Equal(t, types.Available, ipConfigState(svc, testIPID1))
+
+	_, err = requestIPConfigsForPod(t, svc, testPod2Info)
+	require.NoError(t, err)
+}
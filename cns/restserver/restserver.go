@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/dockerclient"
 	"github.com/Azure/azure-container-networking/cns/imds"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/networkcontainers"
 	"github.com/Azure/azure-container-networking/cns/nodesubnet"
@@ -20,9 +22,11 @@ import (
 	"github.com/Azure/azure-container-networking/cns/types/bounded"
 	"github.com/Azure/azure-container-networking/cns/wireserver"
 	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/iptables"
 	nma "github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
 )
 
 // This file contains the initialization of RestServer.
@@ -34,6 +38,8 @@ import (
 // Named Lock for accessing different states in httpRestServiceState
 var namedLock = acn.InitNamedLock()
 
+var tracer = otel.Tracer("github.com/Azure/azure-container-networking/cns/restserver")
+
 type interfaceGetter interface {
 	GetInterfaces(ctx context.Context) (*wireserver.GetInterfacesResult, error)
 }
@@ -57,15 +63,12 @@ type imdsClient interface {
 	GetIMDSVersions(ctx context.Context) (*imds.APIVersionsResponse, error)
 }
 
+// iptablesClient reads rules with go-iptables and programs them in a single iptables transaction.
 type iptablesClient interface {
 	ChainExists(table string, chain string) (bool, error)
-	NewChain(table string, chain string) error
-	Append(table string, chain string, rulespec ...string) error
 	Exists(table string, chain string, rulespec ...string) (bool, error)
-	Insert(table string, chain string, pos int, rulespec ...string) error
 	List(table string, chain string) ([]string, error)
-	ClearChain(table string, chain string) error
-	Delete(table, chain string, rulespec ...string) error
+	Apply(tx *iptables.Transaction) error
 }
 type iptablesLegacyClient interface {
 	Delete(table, chain string, rulespec ...string) error
@@ -96,12 +99,18 @@ type HTTPRestService struct {
 	dncPartitionKey            string
 	EndpointState              map[string]*EndpointInfo // key : container id
 	EndpointStateStore         store.KeyValueStore
+	IPAMJournal                *journal.Journal
+	IPQuarantine               time.Duration // how long IPs released by pods are Cooling before they are reused
 	cniConflistGenerator       CNIConflistGenerator
 	generateCNIConflistOnce    sync.Once
 	IPConfigsHandlerMiddleware cns.IPConfigsHandlerMiddleware
 	PnpIDByMacAddress          map[string]string
 	imdsClient                 imdsClient
 	nodesubnetIPFetcher        *nodesubnet.IPFetcher
+	stateEvents                stateEventBroker
+	ipHistory                  map[string]*ipOwnerRing // IP address is key
+	ConfigReporter             ConfigReporter
+	pprofEnabled               atomic.Bool
 }
 
 type CNIConflistGenerator interface {
@@ -296,10 +305,13 @@ func (service *HTTPRestService) Init(config *common.ServiceConfig) error {
 	listener.AddHandler(cns.RequestIPConfigs, NewHandlerFuncWithHistogram(service.RequestIPConfigsHandler, HTTPRequestLatency))
 	listener.AddHandler(cns.ReleaseIPConfig, NewHandlerFuncWithHistogram(service.ReleaseIPConfigHandler, HTTPRequestLatency))
 	listener.AddHandler(cns.ReleaseIPConfigs, NewHandlerFuncWithHistogram(service.ReleaseIPConfigsHandler, HTTPRequestLatency))
+	listener.AddHandler(cns.GetIPConfigs, NewHandlerFuncWithHistogram(service.GetIPConfigsHandler, HTTPRequestLatency))
 	listener.AddHandler(cns.NmAgentSupportedApisPath, service.nmAgentSupportedApisHandler)
 	listener.AddHandler(cns.PathDebugIPAddresses, service.HandleDebugIPAddresses)
 	listener.AddHandler(cns.PathDebugPodContext, service.HandleDebugPodContext)
 	listener.AddHandler(cns.PathDebugRestData, service.HandleDebugRestData)
+	listener.AddHandler(cns.PathDebugIPHistory, service.HandleDebugIPHistory)
+	listener.AddHandler(cns.PathDebugConfig, service.HandleDebugConfig)
 	listener.AddHandler(cns.NetworkContainersURLPath, service.getOrRefreshNetworkContainers)
 	listener.AddHandler(cns.GetHomeAz, service.getHomeAz)
 	listener.AddHandler(cns.EndpointPath, service.EndpointHandlerAPI)
@@ -347,20 +359,22 @@ func (service *HTTPRestService) Init(config *common.ServiceConfig) error {
 	return nil
 }
 
+// RegisterPProfEndpoints registers the pprof endpoints, which are served only while pprof is enabled by
+// SetPProfEnabled.
 func (service *HTTPRestService) RegisterPProfEndpoints() {
 	if service.Listener != nil {
 		mux := service.Listener.GetMux()
-		mux.Handle("/debug/pprof/allocs", pprof.Handler("allocs"))
-		mux.Handle("/debug/pprof/block", pprof.Handler("block"))
-		mux.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
-		mux.Handle("/debug/pprof/heap", pprof.Handler("heap"))
-		mux.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
-		mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
-		mux.HandleFunc("/debug/pprof/", pprof.Index)
-		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.Handle("/debug/pprof/allocs", service.pprofGate(pprof.Handler("allocs")))
+		mux.Handle("/debug/pprof/block", service.pprofGate(pprof.Handler("block")))
+		mux.Handle("/debug/pprof/goroutine", service.pprofGate(pprof.Handler("goroutine")))
+		mux.Handle("/debug/pprof/heap", service.pprofGate(pprof.Handler("heap")))
+		mux.Handle("/debug/pprof/mutex", service.pprofGate(pprof.Handler("mutex")))
+		mux.Handle("/debug/pprof/threadcreate", service.pprofGate(pprof.Handler("threadcreate")))
+		mux.Handle("/debug/pprof/", service.pprofGate(http.HandlerFunc(pprof.Index)))
+		mux.Handle("/debug/pprof/cmdline", service.pprofGate(http.HandlerFunc(pprof.Cmdline)))
+		mux.Handle("/debug/pprof/profile", service.pprofGate(http.HandlerFunc(pprof.Profile)))
+		mux.Handle("/debug/pprof/symbol", service.pprofGate(http.HandlerFunc(pprof.Symbol)))
+		mux.Handle("/debug/pprof/trace", service.pprofGate(http.HandlerFunc(pprof.Trace)))
 	}
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/dockerclient"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/networkcontainers"
 	"github.com/Azure/azure-container-networking/cns/nodesubnet"
@@ -178,6 +179,9 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(req cns.CreateNetw
 	createNetworkContainerRequest := req
 	createNetworkContainerRequest.AuthorizationToken = ""
 
+	if err := service.journalNetworkContainerChange(journal.OpCreateNC, req.NetworkContainerid); err != nil {
+		return types.UnexpectedError, err.Error()
+	}
 	service.state.ContainerStatus[req.NetworkContainerid] = containerstatus{
 		ID:                            req.NetworkContainerid,
 		VMVersion:                     req.Version,
@@ -349,6 +353,7 @@ func (service *HTTPRestService) addIPConfigStateUntransacted(ncID string, hostVe
 		logger.Printf("[Azure-Cns] Add IP %s as %s", ipconfig.IPAddress, newIPCNSStatus)
 
 		service.PodIPConfigState[ipID] = ipconfigStatus
+		service.publishIPConfigStateEvent("", &ipconfigStatus, nil, false)
 
 		// Todo Update batch API and maintain the count
 	}
@@ -386,7 +391,11 @@ func (service *HTTPRestService) removeToBeDeletedIPStateUntransacted(
 	logger.Printf("[Azure-Cns] Delete the PodIpConfigState, IpId: %s, IPConfigStatus: %v",
 		ipID,
 		service.PodIPConfigState[ipID])
-	delete(service.PodIPConfigState, ipID)
+	if ipConfigStatus, exists := service.PodIPConfigState[ipID]; exists {
+		delete(service.PodIPConfigState, ipID)
+		service.forgetIPHistory(&ipConfigStatus)
+		service.publishIPConfigStateEvent(ipConfigStatus.GetState(), &ipConfigStatus, ipConfigStatus.PodInfo, true)
+	}
 	return 0, ""
 }
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	"github.com/labstack/echo/v4"
@@ -13,22 +14,35 @@ import (
 
 type Server struct {
 	*restserver.HTTPRestService
+	authorizer *authz.Authorizer
 }
 
 func New(s *restserver.HTTPRestService) *Server {
-	return &Server{s}
+	return &Server{HTTPRestService: s}
+}
+
+// WithAuthorizer authorizes the requests to the server with the Authorizer of the CNS listener.
+func (s *Server) WithAuthorizer(a *authz.Authorizer) *Server {
+	s.authorizer = a
+	return s
 }
 
 func (s Server) Start(ctx context.Context, addr string) error {
 	e := echo.New()
 	e.HideBanner = true
+	if s.authorizer != nil {
+		e.Use(echo.WrapMiddleware(s.authorizer.Middleware(authz.Path)))
+	}
 	e.POST(cns.RequestIPConfig, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.RequestIPConfigHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.RequestIPConfigs, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.RequestIPConfigsHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.ReleaseIPConfig, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.ReleaseIPConfigHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.ReleaseIPConfigs, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.ReleaseIPConfigsHandler, restserver.HTTPRequestLatency)))
+	e.POST(cns.GetIPConfigs, echo.WrapHandler(restserver.NewHandlerFuncWithHistogram(s.GetIPConfigsHandler, restserver.HTTPRequestLatency)))
 	e.POST(cns.PathDebugIPAddresses, echo.WrapHandler(http.HandlerFunc(s.HandleDebugIPAddresses)))
 	e.POST(cns.PathDebugPodContext, echo.WrapHandler(http.HandlerFunc(s.HandleDebugPodContext)))
 	e.POST(cns.PathDebugRestData, echo.WrapHandler(http.HandlerFunc(s.HandleDebugRestData)))
+	e.POST(cns.PathDebugIPHistory, echo.WrapHandler(http.HandlerFunc(s.HandleDebugIPHistory)))
+	e.GET(cns.PathDebugConfig, echo.WrapHandler(http.HandlerFunc(s.HandleDebugConfig)))
 	e.POST(cns.GetNetworkContainerByOrchestratorContext, echo.WrapHandler(http.HandlerFunc(s.GetNetworkContainerByOrchestratorContext)))
 	e.POST(cns.GetAllNetworkContainers, echo.WrapHandler(http.HandlerFunc(s.GetAllNetworkContainers)))
 	e.POST(cns.CreateHostNCApipaEndpointPath, echo.WrapHandler(http.HandlerFunc(s.CreateHostNCApipaEndpoint)))
@@ -0,0 +1,218 @@
+package restserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// stateEventHistorySize is the number of past events kept to resume watches from.
+	stateEventHistorySize = 4096
+	// stateEventBufferSize is the number of events a watcher may fall behind before it is closed.
+	stateEventBufferSize = 1024
+)
+
+var (
+	// ErrResourceVersionExpired is returned when a watch is resumed from a resource version which is no longer
+	// retained, or was issued by a previous run of CNS. The watcher should list the current state and watch from 0.
+	ErrResourceVersionExpired = errors.New("resource version is too old or unknown")
+	// ErrWatcherTooSlow is set on a StateWatch closed because its consumer fell too far behind.
+	ErrWatcherTooSlow = errors.New("watcher fell too far behind")
+)
+
+// StateWatch is a subscription to the CNS state events.
+type StateWatch struct {
+	events chan cns.StateEvent
+	err    error
+	broker *stateEventBroker
+}
+
+// Events returns the channel the watch events are delivered on. It is closed when the watch ends,
+// after which Err reports why.
+func (w *StateWatch) Events() <-chan cns.StateEvent {
+	return w.events
+}
+
+// Err returns the reason the watch was closed by CNS, or nil if it is still open or was stopped by the caller.
+func (w *StateWatch) Err() error {
+	w.broker.Lock()
+	defer w.broker.Unlock()
+	return w.err
+}
+
+// Stop ends the watch and releases its resources.
+func (w *StateWatch) Stop() {
+	w.broker.Lock()
+	defer w.broker.Unlock()
+	w.broker.removeLocked(w, nil)
+}
+
+// stateEventBroker fans out the CNS state events to watchers and retains a bounded history of them so that
+// watchers can resume from a resource version. The zero value is ready to use.
+type stateEventBroker struct {
+	sync.Mutex
+	resourceVersion uint64
+	history         []cns.StateEvent
+	watchers        map[*StateWatch]struct{}
+}
+
+// initLocked seeds the resource version from the wall clock on first use, so that versions keep increasing
+// across CNS restarts and a cursor from a previous run is detected as expired instead of being reused.
+func (b *stateEventBroker) initLocked() {
+	if b.resourceVersion == 0 {
+		b.resourceVersion = uint64(time.Now().UnixNano()) //nolint:gosec // the wall clock is positive
+	}
+	if b.watchers == nil {
+		b.watchers = map[*StateWatch]struct{}{}
+	}
+}
+
+// publish stamps the event with the next resource version, records it and delivers it to the watchers.
+// It never blocks: watchers which can't keep up are closed with ErrWatcherTooSlow.
+func (b *stateEventBroker) publish(event cns.StateEvent) { //nolint:gocritic // ignore hugeparam
+	b.Lock()
+	defer b.Unlock()
+	b.initLocked()
+	b.resourceVersion++
+	event.ResourceVersion = b.resourceVersion
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if len(b.history) == stateEventHistorySize {
+		b.history = b.history[1:]
+	}
+	b.history = append(b.history, event)
+	for w := range b.watchers {
+		select {
+		case w.events <- event:
+		default:
+			logger.Errorf("[stateEventBroker] closing watcher which fell behind at resource version %d", event.ResourceVersion)
+			b.removeLocked(w, ErrWatcherTooSlow)
+		}
+	}
+}
+
+// watch subscribes to the events after resourceVersion. If resourceVersion is 0, the events produced by snapshot are
+// delivered first, stamped with the current resource version.
+// The caller must ensure no events are published while snapshot runs.
+func (b *stateEventBroker) watch(resourceVersion uint64, snapshot func() []cns.StateEvent) (*StateWatch, error) {
+	b.Lock()
+	defer b.Unlock()
+	b.initLocked()
+
+	var initial []cns.StateEvent
+	if resourceVersion == 0 {
+		initial = snapshot()
+		for i := range initial {
+			initial[i].ResourceVersion = b.resourceVersion
+		}
+	} else {
+		if resourceVersion > b.resourceVersion {
+			return nil, errors.Wrapf(ErrResourceVersionExpired, "resource version %d is newer than current %d", resourceVersion, b.resourceVersion)
+		}
+		oldest := b.resourceVersion - uint64(len(b.history)) + 1
+		if resourceVersion+1 < oldest {
+			return nil, errors.Wrapf(ErrResourceVersionExpired, "resource version %d is older than oldest retained %d", resourceVersion, oldest)
+		}
+		// history is contiguous, so the events after resourceVersion are its tail.
+		initial = append(initial, b.history[len(b.history)-int(b.resourceVersion-resourceVersion):]...) //nolint:gosec // bounded by len(history)
+	}
+
+	w := &StateWatch{
+		events: make(chan cns.StateEvent, len(initial)+stateEventBufferSize),
+		broker: b,
+	}
+	for i := range initial {
+		w.events <- initial[i]
+	}
+	b.watchers[w] = struct{}{}
+	return w, nil
+}
+
+func (b *stateEventBroker) removeLocked(w *StateWatch, err error) {
+	if _, ok := b.watchers[w]; !ok {
+		return
+	}
+	delete(b.watchers, w)
+	w.err = err
+	close(w.events)
+}
+
+// WatchState subscribes to the CNS IP pool and endpoint state events after resourceVersion.
+// A resourceVersion of 0 starts the watch with an event for every IPConfig and endpoint currently in CNS, all carrying
+// the resource version the watch started at.
+// ErrResourceVersionExpired is returned if the events after resourceVersion are no longer retained.
+func (service *HTTPRestService) WatchState(resourceVersion uint64) (*StateWatch, error) {
+	// events are published with the service lock held, so holding it here keeps the snapshot consistent
+	// with the resource version it is stamped with.
+	service.RLock()
+	defer service.RUnlock()
+	return service.stateEvents.watch(resourceVersion, service.stateSnapshotUntransacted)
+}
+
+// stateSnapshotUntransacted returns an event for every IPConfig and endpoint in the current state.
+// The caller must hold the service lock.
+func (service *HTTPRestService) stateSnapshotUntransacted() []cns.StateEvent {
+	events := make([]cns.StateEvent, 0, len(service.PodIPConfigState)+len(service.EndpointState))
+	for _, ipconfig := range service.PodIPConfigState { //nolint:gocritic // ignore copy
+		events = append(events, ipConfigStateEvent("", &ipconfig, ipconfig.PodInfo, false))
+	}
+	for endpointID, endpointInfo := range service.EndpointState {
+		events = append(events, endpointStateEvent(endpointID, endpointInfo, false))
+	}
+	return events
+}
+
+// publishIPConfigStateEvent publishes a change to an IPConfig to the watchers.
+// podInfo is the pod the IP is or was assigned to.
+// The caller must hold the service lock.
+func (service *HTTPRestService) publishIPConfigStateEvent(previousState types.IPState, ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo, deleted bool) {
+	service.stateEvents.publish(ipConfigStateEvent(previousState, ipconfig, podInfo, deleted))
+}
+
+// publishEndpointStateEvent records a change to the endpoint state of a container.
+// The caller must hold the service lock.
+func (service *HTTPRestService) publishEndpointStateEvent(endpointID string, endpointInfo *EndpointInfo, deleted bool) {
+	service.stateEvents.publish(endpointStateEvent(endpointID, endpointInfo, deleted))
+}
+
+func ipConfigStateEvent(previousState types.IPState, ipconfig *cns.IPConfigurationStatus, podInfo cns.PodInfo, deleted bool) cns.StateEvent {
+	event := cns.StateEvent{
+		Kind:          cns.IPConfigStateEvent,
+		Timestamp:     ipconfig.LastStateTransition,
+		Deleted:       deleted,
+		IPConfigID:    ipconfig.ID,
+		IPAddress:     ipconfig.IPAddress,
+		NCID:          ipconfig.NCID,
+		PreviousState: previousState,
+		State:         ipconfig.GetState(),
+	}
+	if deleted {
+		event.Timestamp = time.Time{}
+	}
+	if podInfo != nil {
+		event.PodKey = podInfo.Key()
+		event.PodName = podInfo.Name()
+		event.PodNamespace = podInfo.Namespace()
+	}
+	return event
+}
+
+func endpointStateEvent(endpointID string, endpointInfo *EndpointInfo, deleted bool) cns.StateEvent {
+	event := cns.StateEvent{
+		Kind:       cns.EndpointStateEvent,
+		Deleted:    deleted,
+		PodKey:     endpointID,
+		EndpointID: endpointID,
+	}
+	if endpointInfo != nil {
+		event.PodName = endpointInfo.PodName
+		event.PodNamespace = endpointInfo.PodNamespace
+	}
+	return event
+}
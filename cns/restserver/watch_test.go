@@ -0,0 +1,134 @@
+package restserver
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noSnapshot() []cns.StateEvent { return nil }
+
+func receive(t *testing.T, w *StateWatch, n int) []cns.StateEvent {
+	t.Helper()
+	events := make([]cns.StateEvent, 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case e, ok := <-w.Events():
+			require.True(t, ok, "watch closed after %d events: %v", i, w.Err())
+			events = append(events, e)
+		default:
+			require.Failf(t, "missing event", "expected %d events, got %d", n, i)
+		}
+	}
+	return events
+}
+
+func TestStateEventBrokerResume(t *testing.T) {
+	var b stateEventBroker
+	w, err := b.watch(0, noSnapshot)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	for _, ip := range []string{testIP1, testIP2, testIP3} {
+		b.publish(cns.StateEvent{Kind: cns.IPConfigStateEvent, IPAddress: ip})
+	}
+	events := receive(t, w, 3)
+	assert.Equal(t, events[0].ResourceVersion+1, events[1].ResourceVersion)
+	assert.Equal(t, events[1].ResourceVersion+1, events[2].ResourceVersion)
+
+	// resume after the first event
+	resumed, err := b.watch(events[0].ResourceVersion, noSnapshot)
+	require.NoError(t, err)
+	defer resumed.Stop()
+	assert.Equal(t, events[1:], receive(t, resumed, 2))
+
+	// resume at the latest event
+	latest, err := b.watch(events[2].ResourceVersion, noSnapshot)
+	require.NoError(t, err)
+	defer latest.Stop()
+	assert.Empty(t, latest.Events())
+
+	// a resource version from the future, e.g. issued before a restart with a skewed clock, is expired
+	_, err = b.watch(events[2].ResourceVersion+1, noSnapshot)
+	require.ErrorIs(t, err, ErrResourceVersionExpired)
+
+	// resource versions which are no longer retained are expired
+	for i := 0; i < stateEventHistorySize; i++ {
+		b.publish(cns.StateEvent{})
+	}
+	_, err = b.watch(events[0].ResourceVersion, noSnapshot)
+	require.ErrorIs(t, err, ErrResourceVersionExpired)
+}
+
+func TestStateEventBrokerSlowWatcher(t *testing.T) {
+	var b stateEventBroker
+	w, err := b.watch(0, noSnapshot)
+	require.NoError(t, err)
+
+	for i := 0; i <= stateEventBufferSize; i++ {
+		b.publish(cns.StateEvent{})
+	}
+	receive(t, w, stateEventBufferSize)
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+	require.ErrorIs(t, w.Err(), ErrWatcherTooSlow)
+
+	// stopping a closed watch is a no-op
+	w.Stop()
+}
+
+func TestWatchStateIPTransitions(t *testing.T) {
+	svc := getTestService(cns.KubernetesCRD)
+	ipconfigs := map[string]cns.IPConfigurationStatus{
+		testIPID1: newPodState(testIP1, testIPID1, testNCID, types.Available, 0),
+	}
+	require.NoError(t, updatePodIPConfigState(t, svc, ipconfigs, testNCID))
+
+	w, err := svc.WatchState(0)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	// the watch starts with the current state
+	snapshot := receive(t, w, 1)[0]
+	assert.Equal(t, cns.IPConfigStateEvent, snapshot.Kind)
+	assert.Equal(t, testIPID1, snapshot.IPConfigID)
+	assert.Equal(t, testNCID, snapshot.NCID)
+	assert.Equal(t, types.Available, snapshot.State)
+
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+	_, err = requestIPConfigsHelper(svc, req)
+	require.NoError(t, err)
+
+	assigned := receive(t, w, 1)[0]
+	assert.Equal(t, types.Available, assigned.PreviousState)
+	assert.Equal(t, types.Assigned, assigned.State)
+	assert.Equal(t, testPod1Info.Key(), assigned.PodKey)
+	assert.Equal(t, testPod1Info.Name(), assigned.PodName)
+	assert.Greater(t, assigned.ResourceVersion, snapshot.ResourceVersion)
+
+	require.NoError(t, svc.releaseIPConfigs(testPod1Info))
+	released := receive(t, w, 1)[0]
+	assert.Equal(t, types.Assigned, released.PreviousState)
+	assert.Equal(t, types.Available, released.State)
+	assert.Equal(t, testPod1Info.Key(), released.PodKey, "release event should carry the pod the IP was released from")
+
+	_, err = svc.MarkNIPsPendingRelease(1)
+	require.NoError(t, err)
+	pending := receive(t, w, 1)[0]
+	assert.Equal(t, types.Available, pending.PreviousState)
+	assert.Equal(t, types.PendingRelease, pending.State)
+	assert.Empty(t, pending.PodKey)
+
+	// a watch resumed after the snapshot replays the transitions
+	resumed, err := svc.WatchState(snapshot.ResourceVersion)
+	require.NoError(t, err)
+	defer resumed.Stop()
+	assert.Equal(t, []cns.StateEvent{assigned, released, pending}, receive(t, resumed, 3))
+}
@@ -12,15 +12,19 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
+	"github.com/Azure/azure-container-networking/cns/authz"
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	acn "github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/keyvault"
 	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/Azure/azure-container-networking/store"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 const (
@@ -101,6 +105,18 @@ func (service *Service) AddListener(config *common.ServiceConfig) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to construct url for node listener")
 	}
+	route := func(r *http.Request) string {
+		_, pattern := nodeListener.GetMux().Handler(r)
+		return pattern
+	}
+	if config.Authorizer != nil {
+		nodeListener.UseConnContext(authz.ConnContext)
+		nodeListener.Use(config.Authorizer.Middleware(route))
+	}
+	// each request is a span, which continues the trace of the caller e.g. of the CNI plugin
+	nodeListener.Use(func(h http.Handler) http.Handler {
+		return tracing.NewHandler(h, route)
+	})
 
 	// only use TLS connection for DNC/CNS listener:
 	if config.TLSSettings.TLSPort != "" {
@@ -111,7 +127,15 @@ func (service *Service) AddListener(config *common.ServiceConfig) error {
 		tlsAddress := net.JoinHostPort(hostParts[0], config.TLSSettings.TLSPort)
 
 		// Start the listener and HTTP and HTTPS server.
-		tlsConfig, err := getTLSConfig(config.TLSSettings, config.ErrChan) //nolint
+		ctx := config.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		z := config.Logger
+		if z == nil {
+			z = zap.NewNop()
+		}
+		tlsConfig, err := getTLSConfig(ctx, config.TLSSettings, config.ErrChan, z) //nolint
 		if err != nil {
 			logger.Printf("Failed to compose Tls Configuration with error: %+v", err)
 			return errors.Wrap(err, "could not get tls config")
@@ -122,6 +146,13 @@ func (service *Service) AddListener(config *common.ServiceConfig) error {
 		}
 	}
 
+	// local callers e.g. the CNI plugin can connect over a unix socket, which identifies them
+	if config.UnixSocketPath != "" {
+		if err := nodeListener.StartUnix(config.ErrChan, config.UnixSocketPath, config.UnixSocketMode); err != nil {
+			return errors.Wrap(err, "could not start unix socket listener")
+		}
+	}
+
 	service.Listener = nodeListener
 	logger.Debugf("[Azure CNS] Successfully initialized a service with config: %+v", config)
 
@@ -144,13 +175,13 @@ func (service *Service) Initialize(config *common.ServiceConfig) error {
 	return nil
 }
 
-func getTLSConfig(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+func getTLSConfig(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error, z *zap.Logger) (*tls.Config, error) {
 	if tlsSettings.TLSCertificatePath != "" {
-		return getTLSConfigFromFile(tlsSettings)
+		return getTLSConfigFromFile(ctx, tlsSettings, z)
 	}
 
 	if tlsSettings.KeyVaultURL != "" {
-		return getTLSConfigFromKeyVault(tlsSettings, errChan)
+		return getTLSConfigFromKeyVault(ctx, tlsSettings, errChan, z)
 	}
 
 	return nil, errors.Errorf("invalid tls settings: %+v", tlsSettings)
@@ -169,19 +200,12 @@ func verifyPeerCertificate(verifiedChains [][]*x509.Certificate, clientSubjectNa
 
 	// Get client leaf certificate
 	clientCert := verifiedChains[0][0]
-	// Match DNS names (case-insensitive)
-	dnsNames := clientCert.DNSNames
-	for _, dns := range dnsNames {
-		if strings.EqualFold(dns, clientSubjectName) {
-			return nil
-		}
-	}
-
-	// If SANs didn't match, fall back to Common Name (CN) match.
-	clientCN := clientCert.Subject.CommonName
-	if clientCN != "" && strings.EqualFold(clientCN, clientSubjectName) {
+	// Match DNS names, or else the Common Name (CN), case-insensitive
+	if authz.MatchSubject(clientCert, clientSubjectName) {
 		return nil
 	}
+	dnsNames := clientCert.DNSNames
+	clientCN := clientCert.Subject.CommonName
 
 	// maskHalf of the DNS names
 	maskedDNS := make([]string, len(dnsNames))
@@ -193,6 +217,14 @@ func verifyPeerCertificate(verifiedChains [][]*x509.Certificate, clientSubjectNa
 		clientSubjectName, maskedDNS, maskHalf(clientCN))
 }
 
+// mtlsClientCertSubjectName returns the client subject name expected during mTLS.
+func mtlsClientCertSubjectName(tlsSettings localtls.TlsSettings) string {
+	if tlsSettings.GetMtlsClientCertSubjectName != nil {
+		return tlsSettings.GetMtlsClientCertSubjectName()
+	}
+	return tlsSettings.MtlsClientCertSubjectName
+}
+
 // maskHalf masks half of the input string with asterisks.
 func maskHalf(s string) string {
 	n := len(s)
@@ -204,31 +236,19 @@ func maskHalf(s string) string {
 	return s[:half] + strings.Repeat("*", n-half)
 }
 
-func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error) {
-	tlsCertRetriever, err := localtls.GetTlsCertificateRetriever(tlsSettings)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate retriever")
-	}
-
-	leafCertificate, err := tlsCertRetriever.GetCertificate()
+// getTLSConfigFromFile serves the certificate at TLSCertificatePath, reloaded whenever the file changes until the context
+// is done.
+func getTLSConfigFromFile(ctx context.Context, tlsSettings localtls.TlsSettings, z *zap.Logger) (*tls.Config, error) {
+	cr, err := localtls.NewFileCertRefresher(tlsSettings, logger.Log)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate")
-	}
-
-	if leafCertificate == nil {
-		return nil, errors.New("certificate retrieval returned empty")
-	}
-
-	privateKey, err := tlsCertRetriever.GetPrivateKey()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate private key")
+		return nil, errors.Wrap(err, "could not create new cert refresher")
 	}
+	go func() {
+		if err := cr.Watch(ctx, z); err != nil && !errors.Is(err, context.Canceled) {
+			z.Error("stopped reloading the TLS certificate", zap.Error(err))
+		}
+	}()
 
-	tlsCert := tls.Certificate{
-		Certificate: [][]byte{leafCertificate.Raw},
-		PrivateKey:  privateKey,
-		Leaf:        leafCertificate,
-	}
 	minTLSVersionNumber, err := parseTLSVersionName(tlsSettings.MinTLSVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing MinTLSVersion from config")
@@ -237,21 +257,17 @@ func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error)
 	tlsConfig := &tls.Config{
 		MaxVersion: tls.VersionTLS13,
 		MinVersion: minTLSVersionNumber,
+		// the server is handed the latest certificate by GetCertificate, the certificate loaded at startup is only
+		// presented when the config is used by a client.
 		Certificates: []tls.Certificate{
-			tlsCert,
+			*cr.Certificate(),
 		},
+		GetCertificate: cr.GetCertificate,
 	}
 
 	if tlsSettings.UseMTLS {
-		rootCAs, err := mtlsRootCAsFromCertificate(&tlsCert)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get root CAs for configuring mTLS")
-		}
-		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		tlsConfig.ClientCAs = rootCAs
-		tlsConfig.RootCAs = rootCAs
-		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
-			return verifyPeerCertificate(verifiedChains, tlsSettings.MtlsClientCertSubjectName)
+		if err := configureMTLS(ctx, tlsConfig, tlsSettings, cr.Certificate, z); err != nil {
+			return nil, err
 		}
 	}
 	logger.Debugf("TLS configured successfully from file: %+v", tlsSettings)
@@ -259,7 +275,7 @@ func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error)
 	return tlsConfig, nil
 }
 
-func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+func getTLSConfigFromKeyVault(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error, z *zap.Logger) (*tls.Config, error) {
 	credOpts := azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ResourceID(tlsSettings.MSIResourceID)}
 	cred, err := azidentity.NewManagedIdentityCredential(&credOpts)
 	if err != nil {
@@ -271,15 +287,15 @@ func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- e
 		return nil, errors.Wrap(err, "could not create new keyvault shim")
 	}
 
-	ctx := context.TODO()
-
 	cr, err := keyvault.NewCertRefresher(ctx, kvs, logger.Log, tlsSettings.KeyVaultCertificateName)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new cert refresher")
 	}
 
 	go func() {
-		errChan <- cr.Refresh(ctx, tlsSettings.KeyVaultCertificateRefreshInterval)
+		if err := cr.Refresh(ctx, tlsSettings.KeyVaultCertificateRefreshInterval); !errors.Is(err, context.Canceled) {
+			errChan <- err
+		}
 	}()
 
 	minTLSVersionNumber, err := parseTLSVersionName(tlsSettings.MinTLSVersion)
@@ -296,16 +312,8 @@ func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- e
 	}
 
 	if tlsSettings.UseMTLS {
-		tlsCert := cr.GetCertificate()
-		rootCAs, err := mtlsRootCAsFromCertificate(tlsCert)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get root CAs for configuring mTLS")
-		}
-		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		tlsConfig.ClientCAs = rootCAs
-		tlsConfig.RootCAs = rootCAs
-		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
-			return verifyPeerCertificate(verifiedChains, tlsSettings.MtlsClientCertSubjectName)
+		if err := configureMTLS(ctx, &tlsConfig, tlsSettings, cr.GetCertificate, z); err != nil {
+			return nil, err
 		}
 	}
 
@@ -314,6 +322,78 @@ func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- e
 	return &tlsConfig, nil
 }
 
+// configureMTLS requires the clients to present a certificate, verified with the CAs of the bundle at MtlsClientCAPath
+// or else with the CAs of the chain of the current server certificate. The CAs are looked up on every handshake, so
+// that rotated CAs are trusted without restarting CNS. The bundle is reloaded until the context is done.
+func configureMTLS(ctx context.Context, tlsConfig *tls.Config, tlsSettings localtls.TlsSettings, currentCert func() *tls.Certificate, z *zap.Logger) error {
+	var clientCAs func() (*x509.CertPool, error)
+	if tlsSettings.MtlsClientCAPath != "" {
+		pr, err := localtls.NewCertPoolRefresher(tlsSettings.MtlsClientCAPath, logger.Log)
+		if err != nil {
+			return errors.Wrap(err, "could not create new cert pool refresher")
+		}
+		go func() {
+			if err := pr.Watch(ctx, z); err != nil && !errors.Is(err, context.Canceled) {
+				z.Error("stopped reloading the mTLS client CAs", zap.Error(err))
+			}
+		}()
+		clientCAs = func() (*x509.CertPool, error) { return pr.CertPool(), nil }
+	} else {
+		var cache certPoolCache
+		clientCAs = func() (*x509.CertPool, error) { return cache.rootCAs(currentCert()) }
+	}
+
+	rootCAs, err := clientCAs()
+	if err != nil {
+		return errors.Wrap(err, "failed to get root CAs for configuring mTLS")
+	}
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientCAs = rootCAs
+	tlsConfig.RootCAs = rootCAs
+	tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		return verifyPeerCertificate(verifiedChains, mtlsClientCertSubjectName(tlsSettings))
+	}
+
+	base := tlsConfig.Clone()
+	tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs()
+		if err != nil {
+			return nil, err
+		}
+		config := base.Clone()
+		config.ClientCAs = pool
+		return config, nil
+	}
+	return nil
+}
+
+// certPoolCache holds the root CAs of a certificate, which are parsed again only when the certificate changes.
+type certPoolCache struct {
+	sync.Mutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// rootCAs returns the root CAs of the certificate. If they cannot be parsed, the root CAs of the previous certificate
+// are kept.
+func (c *certPoolCache) rootCAs(cert *tls.Certificate) (*x509.CertPool, error) {
+	c.Lock()
+	defer c.Unlock()
+	if cert == c.cert {
+		return c.pool, nil
+	}
+	pool, err := mtlsRootCAsFromCertificate(cert)
+	if err != nil {
+		if c.pool == nil {
+			return nil, err
+		}
+		logger.Errorf("failed to get root CAs of the rotated certificate, keeping the previous root CAs: %v", err)
+		pool = c.pool
+	}
+	c.cert, c.pool = cert, pool
+	return pool, nil
+}
+
 // Given a TLS cert, return the root CAs
 func mtlsRootCAsFromCertificate(tlsCert *tls.Certificate) (*x509.CertPool, error) {
 	switch {
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
 	cnsclient "github.com/Azure/azure-container-networking/cns/client"
 	cnscli "github.com/Azure/azure-container-networking/cns/cmd/cli"
 	"github.com/Azure/azure-container-networking/cns/cniconflist"
@@ -27,6 +28,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/configuration"
 	"github.com/Azure/azure-container-networking/cns/deviceplugin"
 	"github.com/Azure/azure-container-networking/cns/endpointmanager"
+	"github.com/Azure/azure-container-networking/cns/endpointreconciler"
 	"github.com/Azure/azure-container-networking/cns/fsnotify"
 	"github.com/Azure/azure-container-networking/cns/grpc"
 	"github.com/Azure/azure-container-networking/cns/healthserver"
@@ -35,6 +37,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/ipampool"
 	"github.com/Azure/azure-container-networking/cns/ipampool/metrics"
 	ipampoolv2 "github.com/Azure/azure-container-networking/cns/ipampool/v2"
+	"github.com/Azure/azure-container-networking/cns/journal"
 	cssctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/clustersubnetstate"
 	mtpncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/multitenantpodnetworkconfig"
 	nncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/nodenetworkconfig"
@@ -66,11 +69,13 @@ import (
 	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/avast/retry-go/v4"
 	"github.com/go-logr/zapr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -115,6 +120,7 @@ const (
 	defaultDevicePluginMaxRetryCount = 5
 	initialVnetNICCount              = 0
 	initialIBNICCount                = 0
+	tracingShutdownTimeout           = 10 * time.Second
 )
 
 type cniConflistScenario string
@@ -307,7 +313,7 @@ var args = acn.ArgumentList{
 	{
 		Name:         acn.OptDebugCmd,
 		Shorthand:    acn.OptDebugCmdAlias,
-		Description:  "Debug flag to retrieve IPconfigs, available values: assigned, available, all",
+		Description:  "Debug flag to retrieve IPconfigs, available values: assigned, available, all, or to print the IPAM journal: journal",
 		Type:         "string",
 		DefaultValue: "",
 	},
@@ -470,21 +476,29 @@ func sendRegisterNodeRequest(ctx context.Context, httpClient httpDoer, httpRestS
 	return nil
 }
 
-func startTelemetryService(ctx context.Context) {
+func startTelemetryService(ctx context.Context, ts configuration.TelemetrySettings) {
 	var config aitelemetry.AIConfig
 
 	tb := telemetry.NewTelemetryBuffer(nil)
-	err := tb.CreateAITelemetryHandle(config, false, false, false)
-	if err != nil {
-		logger.Errorf("AI telemetry handle creation failed: %v", err)
-		return
+	if !ts.DisableAppInsights {
+		if err := tb.CreateAITelemetryHandle(config, false, false, false); err != nil {
+			logger.Errorf("AI telemetry handle creation failed: %v", err)
+			return
+		}
+	}
+	if ts.OTLP != nil {
+		config.AppName = pluginName
+		if err := tb.CreateOTLPTelemetryHandle(*ts.OTLP, config, false, false, false); err != nil {
+			logger.Errorf("OTLP telemetry handle creation failed: %v", err)
+			return
+		}
 	}
 
 	tbtemp := telemetry.NewTelemetryBuffer(nil)
 	//nolint:errcheck // best effort to cleanup leaked pipe/socket before start
 	tbtemp.Cleanup(telemetry.FdName)
 
-	err = tb.StartServer()
+	err := tb.StartServer()
 	logger.Printf("Telemetry service for CNI started")
 	if err != nil {
 		logger.Errorf("Telemetry service failed to start: %v", err)
@@ -538,6 +552,7 @@ func main() {
 	config.Name = name
 	// Create a channel to receive unhandled errors from CNS.
 	config.ErrChan = rootErrCh
+	config.Ctx = rootCtx
 
 	// Create logging provider.
 	logger.InitLogger(name, logLevel, logTarget, logDirectory)
@@ -566,7 +581,25 @@ func main() {
 		}
 	}
 	configuration.SetCNSConfigDefaults(cnsconfig)
+	// the command line args override the config file.
+	cmdLineOverrides := func(c *configuration.CNSConfig) {
+		if cniConflistFilepathArg != "" {
+			c.CNIConflistFilepath = cniConflistFilepathArg
+		}
+		if cniConflistScenarioArg != "" {
+			c.CNIConflistScenario = cniConflistScenarioArg
+		}
+	}
+	cmdLineOverrides(cnsconfig)
+
+	// the reloader applies the reloadable settings of the config file while CNS runs.
+	configReloader, err := configuration.NewReloader(cmdLineConfigPath, cmdLineOverrides)
+	if err != nil {
+		logger.Errorf("fatal: failed to read cns config for reloading: %v", err)
+		os.Exit(1)
+	}
 
+	shutdownTracing := func(context.Context) error { return nil }
 	disableTelemetry := cnsconfig.TelemetrySettings.DisableAll
 	if !disableTelemetry {
 		ts := cnsconfig.TelemetrySettings
@@ -580,39 +613,39 @@ func main() {
 			DebugMode:                    ts.DebugMode,
 		}
 
-		if aiKey := cnsconfig.TelemetrySettings.AppInsightsInstrumentationKey; aiKey != "" {
-			logger.InitAIWithIKey(aiConfig, aiKey, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
-		} else {
-			logger.InitAI(aiConfig, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+		if !ts.DisableAppInsights {
+			if aiKey := cnsconfig.TelemetrySettings.AppInsightsInstrumentationKey; aiKey != "" {
+				logger.InitAIWithIKey(aiConfig, aiKey, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+			} else {
+				logger.InitAI(aiConfig, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+			}
 		}
-
-		if cnsconfig.TelemetrySettings.ConfigSnapshotIntervalInMins > 0 {
-			go metric.SendCNSConfigSnapshot(rootCtx, cnsconfig)
+		if ts.OTLP != nil {
+			logger.InitOTLP(*ts.OTLP, aiConfig, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
 		}
+		if ts.Tracing != nil {
+			if shutdownTracing, err = tracing.Init(rootCtx, *ts.Tracing, name, version); err != nil {
+				logger.Errorf("fatal: failed to initialize tracing: %v", err)
+				os.Exit(1)
+			}
+		}
+
+		go runWithReloadedInterval(rootCtx, configReloader, func(c *configuration.CNSConfig) int {
+			return c.TelemetrySettings.ConfigSnapshotIntervalInMins
+		}, metric.SendCNSConfigSnapshot)
 	}
 	logger.Printf("[Azure CNS] Using config: %+v", cnsconfig)
 
 	_, envEnableConflistGeneration := os.LookupEnv(envVarEnableCNIConflistGeneration)
 	var conflistGenerator restserver.CNIConflistGenerator
 	if cnsconfig.EnableCNIConflistGeneration || envEnableConflistGeneration {
-		conflistFilepath := cnsconfig.CNIConflistFilepath
-		if cniConflistFilepathArg != "" {
-			// allow the filepath to get overidden by command line arg
-			conflistFilepath = cniConflistFilepathArg
-		}
-		writer, newWriterErr := acnfs.NewAtomicWriter(conflistFilepath)
+		writer, newWriterErr := acnfs.NewAtomicWriter(cnsconfig.CNIConflistFilepath)
 		if newWriterErr != nil {
 			logger.Errorf("unable to create atomic writer to generate cni conflist: %v", newWriterErr)
 			os.Exit(1)
 		}
 
-		// allow the scenario to get overridden by command line arg
-		scenarioString := cnsconfig.CNIConflistScenario
-		if cniConflistScenarioArg != "" {
-			scenarioString = cniConflistScenarioArg
-		}
-
-		switch scenario := cniConflistScenario(scenarioString); scenario {
+		switch scenario := cniConflistScenario(cnsconfig.CNIConflistScenario); scenario {
 		case scenarioV4Overlay:
 			conflistGenerator = &cniconflist.V4OverlayGenerator{Writer: writer}
 		case scenarioDualStackOverlay:
@@ -708,7 +741,7 @@ func main() {
 
 	if telemetryDaemonEnabled {
 		logger.Printf("CNI Telemetry is enabled")
-		go startTelemetryService(rootCtx)
+		go startTelemetryService(rootCtx, cnsconfig.TelemetrySettings)
 	}
 
 	// Log platform information.
@@ -727,8 +760,8 @@ func main() {
 	}
 
 	// Create the key value store.
-	storeFileName := storeFileLocation + name + ".json"
-	config.Store, err = store.NewJsonFileStore(storeFileName, lockclient, nil)
+	storeFileName := storeFileLocation + name
+	config.Store, err = store.New(store.Backend(cnsconfig.StoreBackend), storeFileName, lockclient, nil)
 	if err != nil {
 		logger.Errorf("Failed to create store file: %s, due to error %v\n", storeFileName, err)
 		return
@@ -750,9 +783,9 @@ func main() {
 			return
 		}
 		// Create the key value store.
-		storeFileName := endpointStorePath + endpointStoreName + ".json"
+		storeFileName := endpointStorePath + endpointStoreName
 		logger.Printf("EndpointStoreState path is %s", storeFileName)
-		endpointStateStore, err = store.NewJsonFileStore(storeFileName, endpointStoreLock, nil)
+		endpointStateStore, err = store.New(store.Backend(cnsconfig.StoreBackend), storeFileName, endpointStoreLock, nil)
 		if err != nil {
 			logger.Errorf("Failed to create endpoint state store file: %s, due to error %v\n", storeFileName, err)
 			return
@@ -778,6 +811,20 @@ func main() {
 		return
 	}
 
+	// Open the IPAM journal before any IPAM state is created, so that every mutation is recorded.
+	if cnsconfig.EnableIPAMJournal {
+		journalFileName := storeFileLocation + name + ".journal"
+		httpRemoteRestService.IPAMJournal, err = journal.Open(journalFileName)
+		if err != nil {
+			logger.Errorf("Failed to open IPAM journal: %s, due to error %v\n", journalFileName, err)
+			return
+		}
+		defer httpRemoteRestService.IPAMJournal.Close()
+	}
+
+	// Quarantine the IPs released by pods before they are reused.
+	httpRemoteRestService.IPQuarantine = time.Duration(cnsconfig.IPQuarantineSecs) * time.Second
+
 	// Set CNS options.
 	httpRemoteRestService.SetOption(acn.OptCnsURL, cnsURL)
 	httpRemoteRestService.SetOption(acn.OptCnsPort, cnsPort)
@@ -787,6 +834,7 @@ func main() {
 	httpRemoteRestService.SetOption(acn.OptHttpConnectionTimeout, httpConnectionTimeout)
 	httpRemoteRestService.SetOption(acn.OptHttpResponseHeaderTimeout, httpResponseHeaderTimeout)
 	httpRemoteRestService.SetOption(acn.OptProgramSNATIPTables, cnsconfig.ProgramSNATIPTables)
+	httpRemoteRestService.SetOption(acn.OptSNATBackend, cnsconfig.SNATBackend)
 	httpRemoteRestService.SetOption(acn.OptManageEndpointState, cnsconfig.ManageEndpointState)
 	httpRemoteRestService.SetOption(acn.OptEnableStaleHNSCleanupOnNCCreate, cnsconfig.EnableStaleHNSCleanupOnNCCreate)
 
@@ -800,6 +848,23 @@ func main() {
 		}
 	}
 
+	authorizer, err := authz.New(cnsconfig.Authorization, logger.Log)
+	if err != nil {
+		logger.Errorf("Failed to create the authorizer, err:%v.\n", err)
+		return
+	}
+	config.Authorizer = authorizer
+
+	if cnsconfig.UnixSocketSettings.Enable {
+		socketMode, modeErr := cnsconfig.UnixSocketSettings.Mode()
+		if modeErr != nil {
+			logger.Errorf("Failed to configure the unix socket, err:%v.\n", modeErr)
+			return
+		}
+		config.UnixSocketPath = cnsconfig.UnixSocketSettings.Path
+		config.UnixSocketMode = socketMode
+	}
+
 	logger.Printf("[Azure CNS] Initialize HTTPRemoteRestService")
 	if httpRemoteRestService != nil {
 		if cnsconfig.UseHTTPS {
@@ -814,6 +879,10 @@ func main() {
 				UseMTLS:                            cnsconfig.UseMTLS,
 				MinTLSVersion:                      cnsconfig.MinTLSVersion,
 				MtlsClientCertSubjectName:          cnsconfig.MtlsClientCertSubjectName,
+				MtlsClientCAPath:                   cnsconfig.MtlsClientCAPath,
+				GetMtlsClientCertSubjectName: func() string {
+					return configReloader.Config().MtlsClientCertSubjectName
+				},
 			}
 		}
 
@@ -877,7 +946,7 @@ func main() {
 
 		logger.Printf("Set GlobalPodInfoScheme %v (InitializeFromCNI=%t)", cns.GlobalPodInfoScheme, cnsconfig.InitializeFromCNI)
 
-		err = InitializeCRDState(rootCtx, z, httpRemoteRestService, cnsconfig)
+		err = InitializeCRDState(rootCtx, z, httpRemoteRestService, cnsconfig, configReloader)
 		if err != nil {
 			logger.Errorf("Failed to start CRD Controller, err:%v.\n", err)
 			return
@@ -922,12 +991,16 @@ func main() {
 			logger.Errorf("[Azure CNS] Failed to initialize node subnet: %v", err)
 			return
 		}
+
+		if err = httpRemoteRestService.CompactIPAMJournal(); err != nil {
+			logger.Errorf("[Azure CNS] Failed to compact IPAM journal: %v", err)
+		}
 	}
 
 	// Initialize multi-tenant controller if the CNS is running in MultiTenantCRD mode.
 	// It must be started before we start HTTPRemoteRestService.
 	if config.ChannelMode == cns.MultiTenantCRD {
-		err = InitializeMultiTenantController(rootCtx, httpRemoteRestService, *cnsconfig)
+		err = InitializeMultiTenantController(rootCtx, httpRemoteRestService, *cnsconfig, configReloader)
 		if err != nil {
 			logger.Errorf("Failed to start multiTenantController, err:%v.\n", err)
 			return
@@ -982,12 +1055,17 @@ func main() {
 	if cnsconfig.GRPCSettings.Enable {
 		// Define gRPC server settings
 		settings := grpc.ServerSettings{
-			IPAddress: cnsconfig.GRPCSettings.IPAddress,
-			Port:      cnsconfig.GRPCSettings.Port,
+			IPAddress:  cnsconfig.GRPCSettings.IPAddress,
+			Port:       cnsconfig.GRPCSettings.Port,
+			Authorizer: authorizer,
+		}
+		if config.UnixSocketPath != "" {
+			settings.SocketPath = cnsconfig.UnixSocketSettings.GRPCPath
+			settings.SocketMode = config.UnixSocketMode
 		}
 
 		// Initialize CNS service
-		cnsService := &grpc.CNS{Logger: z}
+		cnsService := &grpc.CNS{Logger: z, State: httpRemoteRestService}
 
 		// Create a new gRPC server
 		server, grpcErr := grpc.NewServer(settings, cnsService, z)
@@ -1009,9 +1087,12 @@ func main() {
 
 	logger.Printf("[Azure CNS] Start HTTP Remote server")
 	if httpRemoteRestService != nil {
-		if cnsconfig.EnablePprof {
-			httpRemoteRestService.RegisterPProfEndpoints()
-		}
+		httpRemoteRestService.RegisterPProfEndpoints()
+		httpRemoteRestService.SetPProfEnabled(cnsconfig.EnablePprof)
+		httpRemoteRestService.ConfigReporter = configReloader
+		configReloader.OnReload(func(_, updated *configuration.CNSConfig) {
+			httpRemoteRestService.SetPProfEnabled(updated.EnablePprof)
+		})
 
 		err = httpRemoteRestService.Start(&config)
 		if err != nil {
@@ -1033,7 +1114,7 @@ func main() {
 			localServerURL = fmt.Sprintf("%s:%s", defaultLocalServerIP, defaultLocalServerPort)
 		}
 
-		httpLocalRestService := restserverv2.New(httpRemoteRestService)
+		httpLocalRestService := restserverv2.New(httpRemoteRestService).WithAuthorizer(authorizer)
 		if httpLocalRestService != nil {
 			go func() {
 				err = httpLocalRestService.Start(rootCtx, localServerURL)
@@ -1077,8 +1158,44 @@ func main() {
 	}
 
 	if !disableTelemetry {
-		go metric.SendHeartBeat(rootCtx, time.Minute*time.Duration(cnsconfig.TelemetrySettings.HeartBeatIntervalInMins), homeAzMonitor, cnsconfig.ChannelMode)
-		go httpRemoteRestService.SendNCSnapShotPeriodically(rootCtx, cnsconfig.TelemetrySettings.SnapshotIntervalInMins)
+		go runWithReloadedInterval(rootCtx, configReloader, func(c *configuration.CNSConfig) int {
+			return c.TelemetrySettings.HeartBeatIntervalInMins
+		}, func(ctx context.Context, c *configuration.CNSConfig) {
+			metric.SendHeartBeat(ctx, time.Minute*time.Duration(c.TelemetrySettings.HeartBeatIntervalInMins), homeAzMonitor, cnsconfig.ChannelMode)
+		})
+		go runWithReloadedInterval(rootCtx, configReloader, func(c *configuration.CNSConfig) int {
+			return c.TelemetrySettings.SnapshotIntervalInMins
+		}, func(ctx context.Context, c *configuration.CNSConfig) {
+			httpRemoteRestService.SendNCSnapShotPeriodically(ctx, c.TelemetrySettings.SnapshotIntervalInMins)
+		})
+	}
+
+	// repair the endpoint datapath programmed by the CNI, disabled unless an interval is configured
+	go runWithReloadedInterval(rootCtx, configReloader, func(c *configuration.CNSConfig) int {
+		return c.EndpointReconcileIntervalSecs
+	}, func(ctx context.Context, c *configuration.CNSConfig) {
+		endpointreconciler.New().Run(ctx, time.Second*time.Duration(c.EndpointReconcileIntervalSecs))
+	})
+
+	configReloader.OnReload(func(old, updated *configuration.CNSConfig) {
+		if old.Logger.Level == updated.Logger.Level {
+			return
+		}
+		// the level was validated by the reloader.
+		level, _ := zapcore.ParseLevel(updated.Logger.Level)
+		if err := cnsconfig.Logger.SetLevel(updated.Logger.Level); err != nil {
+			z.Error("failed to set reloaded log level", zap.Error(err))
+		}
+		logger.SetLevel(level) //nolint:staticcheck // ignore new deprecation
+	})
+	go func() {
+		if err := configReloader.Start(rootCtx, z); err != nil && !errors.Is(err, context.Canceled) {
+			z.Error("failed to watch cns config for changes", zap.Error(err))
+		}
+	}()
+
+	if httpRemoteRestService.IPQuarantine > 0 {
+		go httpRemoteRestService.PromoteCooledIPConfigsPeriodically(rootCtx)
 	}
 
 	// If CNS is running on managed DNC mode
@@ -1147,6 +1264,13 @@ func main() {
 		logger.Errorf("lockclient cns unlock error:%v", err)
 	}
 
+	// rootCtx is done, export the remaining spans under a context of their own.
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	if err = shutdownTracing(tracingCtx); err != nil {
+		logger.Errorf("failed to export traces: %v", err)
+	}
+	cancelTracing()
+
 	logger.Printf("CNS exited")
 	logger.Close()
 }
@@ -1236,7 +1360,7 @@ func pollNodeInfoCRDAndUpdatePlugin(ctx context.Context, zlog *zap.Logger, plugi
 	}
 }
 
-func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HTTPService, cnsconfig configuration.CNSConfig) error {
+func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HTTPService, cnsconfig configuration.CNSConfig, configReloader *configuration.Reloader) error {
 	var multiTenantController multitenantcontroller.RequestController
 	kubeConfig, err := ctrl.GetConfig()
 	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
@@ -1291,20 +1415,8 @@ func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HT
 
 	// TODO: do we need this to be running?
 	logger.Printf("Starting SyncHostNCVersion")
-	go func() {
-		// Periodically poll vfp programmed NC version from NMAgent
-		tickerChannel := time.Tick(time.Duration(cnsconfig.SyncHostNCVersionIntervalMs) * time.Millisecond)
-		for {
-			select {
-			case <-tickerChannel:
-				timedCtx, cancel := context.WithTimeout(ctx, time.Duration(cnsconfig.SyncHostNCVersionIntervalMs)*time.Millisecond)
-				httpRestServiceImpl.SyncHostNCVersion(timedCtx, cnsconfig.ChannelMode)
-				cancel()
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	// Periodically poll vfp programmed NC version from NMAgent
+	go syncHostNCVersionPeriodically(ctx, httpRestServiceImpl, configReloader, cnsconfig.ChannelMode)
 
 	return nil
 }
@@ -1365,7 +1477,7 @@ func reconcileInitialCNSState(
 // InitializeCRDState builds and starts the CRD controllers.
 //
 //nolint:gocyclo // legacy
-func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig) error {
+func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig, configReloader *configuration.Reloader) error {
 	// convert interface type to implementation type
 	httpRestServiceImplementation, ok := httpRestService.(*restserver.HTTPRestService)
 	if !ok {
@@ -1441,6 +1553,9 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 		if initErr := reconcileInitialCNSState(nnc, httpRestServiceImplementation, podInfoByIPProvider, cnsconfig.EnableSwiftV2, cnsconfig.IPv6PrefixClamp); initErr != nil {
 			return initErr
 		}
+		if compactErr := httpRestServiceImplementation.CompactIPAMJournal(); compactErr != nil {
+			logger.Errorf("[Azure CNS] Failed to compact IPAM journal: %v", compactErr)
+		}
 		hasNNCInitialized.Set(1)
 		return nil
 	}
@@ -1521,6 +1636,13 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 		pmv2 := ipampoolv2.NewMonitor(z, httpRestServiceImplementation, cachedscopedcli, ipDemandCh, nncCh, cssCh)
 		obs := metrics.NewLegacyMetricsObserver(httpRestService.GetPodIPConfigState, cachedscopedcli.Get, cssSrc)
 		pmv2.WithLegacyMetricsObserver(obs)
+		if ps := cnsconfig.PredictiveScalingSettings; ps.Enable {
+			pmv2.WithScalingStrategy(ipampoolv2.NewPredictiveStrategy(ipampoolv2.PredictiveOptions{
+				Window:       time.Duration(ps.WindowSecs) * time.Second,
+				Horizon:      time.Duration(ps.HorizonSecs) * time.Second,
+				ReleaseDelay: time.Duration(ps.ReleaseDelaySecs) * time.Second,
+			}))
+		}
 		poolMonitor = pmv2.AsV1(nncCh)
 	} else {
 		poolOpts := ipampool.Options{
@@ -1620,18 +1742,8 @@ func InitializeCRDState(ctx context.Context, z *zap.Logger, httpRestService cns.
 	go func() {
 		logger.Printf("Starting SyncHostNCVersion loop.")
 		// Periodically poll vfp programmed NC version from NMAgent
-		tickerChannel := time.Tick(time.Duration(cnsconfig.SyncHostNCVersionIntervalMs) * time.Millisecond)
-		for {
-			select {
-			case <-tickerChannel:
-				timedCtx, cancel := context.WithTimeout(ctx, time.Duration(cnsconfig.SyncHostNCVersionIntervalMs)*time.Millisecond)
-				httpRestServiceImplementation.SyncHostNCVersion(timedCtx, cnsconfig.ChannelMode)
-				cancel()
-			case <-ctx.Done():
-				logger.Printf("Stopping SyncHostNCVersion loop.")
-				return
-			}
-		}
+		syncHostNCVersionPeriodically(ctx, httpRestServiceImplementation, configReloader, cnsconfig.ChannelMode)
+		logger.Printf("Stopping SyncHostNCVersion loop.")
 	}()
 	logger.Printf("Initialized SyncHostNCVersion loop.")
 	return nil
@@ -1663,9 +1775,37 @@ func getPodInfoByIPProvider(
 			return podInfoByIPProvider, errors.Wrap(err, "failed to create CNI PodInfoProvider")
 		}
 	}
+	if httpRestServiceImplementation.IPAMJournal != nil {
+		logger.Printf("Restoring IP assignments missing from the pod state from the IPAM journal")
+		var livePods journal.LivePodsFunc
+		if clientset != nil {
+			livePods = func() (map[string]struct{}, error) {
+				return listNodePods(ctx, clientset, nodeName)
+			}
+		}
+		podInfoByIPProvider = journal.PodInfoByIPProvider(httpRestServiceImplementation.IPAMJournal.State(), podInfoByIPProvider, livePods)
+	}
 	return podInfoByIPProvider, nil
 }
 
+// listNodePods returns the namespace/name of the pods on the node which are not terminated, and so may hold an IP.
+func listNodePods(ctx context.Context, clientset *kubernetes.Clientset, nodeName string) (map[string]struct{}, error) {
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list pods")
+	}
+	live := make(map[string]struct{}, len(pods.Items))
+	for i := range pods.Items {
+		if phase := pods.Items[i].Status.Phase; phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+			continue
+		}
+		live[pods.Items[i].Namespace+"/"+pods.Items[i].Name] = struct{}{}
+	}
+	return live, nil
+}
+
 func createOrUpdateNodeInfoCRD(ctx context.Context, restConfig *rest.Config, node *corev1.Node) error {
 	imdsCli := imds.NewClient()
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -39,4 +40,5 @@ func init() {
 		nncInitFailure,
 		hasNNCInitialized,
 	)
+	metrics.Registry.MustRegister(localtls.Collectors()...)
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+)
+
+// runWithReloadedInterval runs fn with the effective config while the interval returned for it is positive, and
+// restarts fn with the reloaded config whenever a reload changes the interval, until the context is closed.
+func runWithReloadedInterval(ctx context.Context, reloader *configuration.Reloader, interval func(*configuration.CNSConfig) int,
+	fn func(context.Context, *configuration.CNSConfig),
+) {
+	changed := make(chan struct{}, 1)
+	reloader.OnReload(func(old, updated *configuration.CNSConfig) {
+		if interval(old) == interval(updated) {
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	for {
+		config := reloader.Config()
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if interval(config) > 0 {
+				fn(runCtx, config)
+			}
+		}()
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return
+		case <-changed:
+			cancel()
+			<-done
+		}
+	}
+}
+
+// syncHostNCVersionPeriodically polls the NC versions programmed by NMAgent, every SyncHostNCVersionIntervalMs of
+// the effective config, until the context is closed.
+func syncHostNCVersionPeriodically(ctx context.Context, service *restserver.HTTPRestService, reloader *configuration.Reloader, channelMode string) {
+	for {
+		interval := time.Duration(reloader.Config().SyncHostNCVersionIntervalMs) * time.Millisecond
+		select {
+		case <-time.After(interval):
+			timedCtx, cancel := context.WithTimeout(ctx, interval)
+			service.SyncHostNCVersion(timedCtx, channelMode)
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithReloadedInterval(t *testing.T) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	path := filepath.Join(t.TempDir(), "cns_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"TelemetrySettings": {"ConfigSnapshotIntervalInMins": 5}}`), 0o600))
+	reloader, err := configuration.NewReloader(path, nil)
+	require.NoError(t, err)
+
+	started := make(chan int, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runWithReloadedInterval(ctx, reloader, func(c *configuration.CNSConfig) int {
+			return c.TelemetrySettings.ConfigSnapshotIntervalInMins
+		}, func(ctx context.Context, c *configuration.CNSConfig) {
+			started <- c.TelemetrySettings.ConfigSnapshotIntervalInMins
+			<-ctx.Done()
+		})
+	}()
+
+	next := func() int {
+		select {
+		case interval := <-started:
+			return interval
+		case <-time.After(5 * time.Second):
+			t.Fatal("fn was not started")
+			return 0
+		}
+	}
+	assert.Equal(t, 5, next())
+
+	// fn is restarted with the reloaded interval.
+	require.NoError(t, os.WriteFile(path, []byte(`{"TelemetrySettings": {"ConfigSnapshotIntervalInMins": 10}}`), 0o600))
+	require.NotNil(t, reloader.Reload())
+	assert.Equal(t, 10, next())
+
+	// fn is stopped while the interval is not positive, and started again once it is.
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	require.NotNil(t, reloader.Reload())
+	require.NoError(t, os.WriteFile(path, []byte(`{"TelemetrySettings": {"ConfigSnapshotIntervalInMins": 1}}`), 0o600))
+	require.NotNil(t, reloader.Reload())
+	assert.Equal(t, 1, next())
+
+	cancel()
+	<-done
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func TestNewService(t *testing.T) {
@@ -37,6 +38,7 @@ func TestNewService(t *testing.T) {
 		Version:     "1.0",
 		ChannelMode: "Direct",
 		Store:       mockStore,
+		Ctx:         t.Context(),
 	}
 
 	t.Run("NewService", func(t *testing.T) {
@@ -193,7 +195,7 @@ func TestNewService(t *testing.T) {
 				err = svc.StartListener(config)
 				require.NoError(t, err)
 
-				mTLSConfig, err := getTLSConfigFromFile(config.TLSSettings)
+				mTLSConfig, err := getTLSConfigFromFile(t.Context(), config.TLSSettings, zap.NewNop())
 				require.NoError(t, err)
 
 				client := &http.Client{
@@ -410,6 +412,17 @@ func TestTLSVersionNumber(t *testing.T) {
 	})
 }
 
+func TestMtlsClientCertSubjectName(t *testing.T) {
+	settings := serverTLS.TlsSettings{MtlsClientCertSubjectName: "static.example.com"}
+	require.Equal(t, "static.example.com", mtlsClientCertSubjectName(settings))
+
+	subjectName := "reloaded.example.com"
+	settings.GetMtlsClientCertSubjectName = func() string { return subjectName }
+	require.Equal(t, "reloaded.example.com", mtlsClientCertSubjectName(settings))
+	subjectName = ""
+	require.Empty(t, mtlsClientCertSubjectName(settings))
+}
+
 func TestMaskHalf(t *testing.T) {
 	tests := []struct {
 		name string
@@ -13,4 +13,6 @@ const (
 	PendingRelease IPState = "PendingRelease"
 	// PendingProgramming IPConfigState for allocated IPs pending programming.
 	PendingProgramming IPState = "PendingProgramming"
+	// Cooling IPConfigState for allocated IPs recently released by a Pod, quarantined before they become Available again.
+	Cooling IPState = "Cooling"
 )
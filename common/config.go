@@ -89,6 +89,9 @@ const (
 	// Enable CNS to program SNAT iptables rules
 	OptProgramSNATIPTables = "program-snat-iptables"
 
+	// SNAT rule backend of CNS, iptables or nftables
+	OptSNATBackend = "snat-backend"
+
 	// Enable Telemetry service
 	OptTelemetryService      = "telemetry-service"
 	OptTelemetryServiceAlias = "ts"
@@ -4,12 +4,14 @@
 package common
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/pkg/errors"
@@ -24,7 +26,10 @@ type Listener struct {
 	active       bool
 	listener     net.Listener
 	tlsListener  net.Listener
+	unixListener net.Listener
 	mux          *http.ServeMux
+	handler      http.Handler
+	connContext  func(context.Context, net.Conn) context.Context
 }
 
 // NewListener creates a new Listener.
@@ -36,6 +41,7 @@ func NewListener(u *url.URL) (*Listener, error) {
 	}
 
 	listener.mux = http.NewServeMux()
+	listener.handler = listener.mux
 
 	return &listener, nil
 }
@@ -43,8 +49,9 @@ func NewListener(u *url.URL) (*Listener, error) {
 // StartTLS creates the listener socket and starts the HTTPS server.
 func (l *Listener) StartTLS(errChan chan<- error, tlsConfig *tls.Config, address string) error {
 	server := http.Server{
-		TLSConfig: tlsConfig,
-		Handler:   l.mux,
+		TLSConfig:   tlsConfig,
+		Handler:     l.handler,
+		ConnContext: l.connContext,
 	}
 
 	// listen on a separate endpoint for secure tls connections
@@ -66,6 +73,120 @@ func (l *Listener) StartTLS(errChan chan<- error, tlsConfig *tls.Config, address
 	return nil
 }
 
+// StartUnix creates a unix socket at the path, with the file mode, and starts an HTTP server of the same handler on it.
+func (l *Listener) StartUnix(errChan chan<- error, path string, mode os.FileMode) error {
+	server := http.Server{
+		Handler:     l.handler,
+		ConnContext: l.connContext,
+	}
+
+	list, err := ListenUnix(path, mode)
+	if err != nil {
+		log.Printf("[Listener] Failed to listen on unix socket: %+v", err)
+		return err
+	}
+
+	l.unixListener = list
+	log.Printf("[Listener] Started listening on unix socket %s.", path)
+
+	go func() {
+		errChan <- server.Serve(l.unixListener)
+	}()
+
+	l.active = true
+	return nil
+}
+
+// ListenUnix creates a unix socket at the path, with the file mode, replacing the socket of a previous run.
+// Only the owner and group of the mode can connect to it, which is the first check of who calls the server.
+//
+// The socket is bound in a private directory and renamed to the path once its mode is set, so it never has the
+// permissions of the umask. The directory of the path is created if needed, traversable only by those the mode
+// grants access to.
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, socketDirMode(mode)); err != nil {
+			return nil, errors.Wrapf(err, "failed to create the directory of %s", path)
+		}
+	}
+	if err := checkStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp(dir, ".sock-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the bind directory of %s", path)
+	}
+	defer os.RemoveAll(tmp)
+
+	bound := filepath.Join(tmp, "s")
+	list, err := net.Listen("unix", bound)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", path)
+	}
+	// the socket is unlinked by its path once renamed, not by the name it was bound to
+	list.(*net.UnixListener).SetUnlinkOnClose(false) //nolint:forcetypeassert // unix listener
+	if err := os.Chmod(bound, mode); err != nil {
+		_ = list.Close()
+		return nil, errors.Wrapf(err, "failed to set the mode of %s", path)
+	}
+	if err := os.Rename(bound, path); err != nil {
+		_ = list.Close()
+		return nil, errors.Wrapf(err, "failed to move socket to %s", path)
+	}
+	return &unixListener{Listener: list, addr: &net.UnixAddr{Name: path, Net: "unix"}}, nil
+}
+
+// socketDirMode is the mode of the directory of a socket with the mode: private to the owner, and traversable by
+// the group and others if the mode grants them any access.
+func socketDirMode(mode os.FileMode) os.FileMode {
+	dirMode := os.FileMode(0o700)
+	if mode&0o070 != 0 {
+		dirMode |= 0o010
+	}
+	if mode&0o007 != 0 {
+		dirMode |= 0o001
+	}
+	return dirMode
+}
+
+// checkStaleSocket returns an error if the path is anything but a socket which no server listens on, which is
+// then replaced.
+func checkStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", path)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return errors.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return errors.Errorf("%s is in use by another server", path)
+	}
+	return nil
+}
+
+// unixListener is a unix socket listener at the path it was renamed to, which it removes on Close.
+type unixListener struct {
+	net.Listener
+	addr net.Addr
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return l.addr
+}
+
+func (l *unixListener) Close() error {
+	err := l.Listener.Close()
+	_ = os.Remove(l.addr.String())
+	return err //nolint:wrapcheck // close error of the listener
+}
+
 // Start creates the listener socket and starts the HTTP server.
 func (l *Listener) Start(errChan chan<- error) error {
 	list, err := net.Listen(l.protocol, l.localAddress)
@@ -78,8 +199,12 @@ func (l *Listener) Start(errChan chan<- error) error {
 	log.Printf("[Listener] Started listening on %s.", l.localAddress)
 
 	// Launch goroutine for servicing requests.
+	server := http.Server{
+		Handler:     l.handler,
+		ConnContext: l.connContext,
+	}
 	go func() {
-		errChan <- http.Serve(l.listener, l.mux)
+		errChan <- server.Serve(l.listener)
 	}()
 
 	l.active = true
@@ -103,6 +228,12 @@ func (l *Listener) Stop() {
 		log.Printf("[Listener] Stopped listening on tls endpoint %s", l.tlsListener.Addr())
 	}
 
+	if l.unixListener != nil {
+		// Stop servicing requests on the unix socket, which also deletes it
+		_ = l.unixListener.Close()
+		log.Printf("[Listener] Stopped listening on unix socket %s", l.unixListener.Addr())
+	}
+
 	// Delete the unix socket.
 	if l.protocol == "unix" {
 		_ = os.Remove(l.localAddress)
@@ -116,6 +247,18 @@ func (l *Listener) GetMux() *http.ServeMux {
 	return l.mux
 }
 
+// Use wraps the handler of all requests with the middleware, e.g. to instrument them. It must be called before the
+// listener is started.
+func (l *Listener) Use(middleware func(http.Handler) http.Handler) {
+	l.handler = middleware(l.handler)
+}
+
+// UseConnContext sets the function which derives the context of the requests of a connection from the connection,
+// e.g. to identify its peer. It must be called before the listener is started.
+func (l *Listener) UseConnContext(connContext func(context.Context, net.Conn) context.Context) {
+	l.connContext = connContext
+}
+
 // GetEndpoints returns the list of registered protocol endpoints.
 func (l *Listener) GetEndpoints() []string {
 	return l.endpoints
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "azure-cns")
+	path := filepath.Join(dir, "cns.sock")
+
+	l, err := ListenUnix(path, 0o660)
+	require.NoError(t, err)
+	require.Equal(t, path, l.Addr().String())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeSocket|0o660, fi.Mode())
+	fi, err = os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o710), fi.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the bind directory is removed")
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	_, err = ListenUnix(path, 0o600)
+	require.Error(t, err, "the socket is in use")
+
+	// a socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", path+".stale")
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false) //nolint:forcetypeassert // unix listener
+	require.NoError(t, stale.Close())
+	require.NoError(t, l.Close())
+	require.NoError(t, os.Rename(path+".stale", path))
+	l, err = ListenUnix(path, 0o600)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err), "the socket is removed on close")
+
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	_, err = ListenUnix(path, 0o600)
+	require.Error(t, err, "a file which is not a socket is not replaced")
+}
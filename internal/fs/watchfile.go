@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// WatchFile calls onChange whenever the file at path is written, created, renamed or removed, until the context is
+// closed. The directory of the file is watched rather than the file itself, so that the file being replaced is seen
+// too, as when a Kubernetes ConfigMap or Secret volume is updated by swapping its "..data" symlink.
+// onChange may be called several times for a single change to the file.
+func WatchFile(ctx context.Context, path string, logger *zap.Logger, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "error creating fsnotify watcher")
+	}
+	defer watcher.Close()
+
+	path = filepath.Clean(path)
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		return errors.Wrapf(err, "failed to add %s to fsnotify watcher", dir)
+	}
+	logger.Info("watching file", zap.String("path", path))
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "exiting WatchFile")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("fsnotify watcher closed")
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// ConfigMap and Secret volumes link the file through the "..data" symlink, which is swapped on updates.
+			if filepath.Clean(event.Name) != path && !strings.HasPrefix(filepath.Base(event.Name), "..") {
+				continue
+			}
+			logger.Debug("file changed", zap.String("path", path), zap.String("event", event.String()))
+			onChange()
+		case watcherErr, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("fsnotify watcher closed")
+			}
+			logger.Error("fsnotify watcher error", zap.Error(watcherErr))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package fs_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/internal/fs"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cns_config.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 16)
+	done := make(chan error)
+	go func() {
+		done <- fs.WatchFile(ctx, path, zap.NewNop(), func() { changed <- struct{}{} })
+	}()
+
+	// other files in the directory are ignored.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o600))
+	select {
+	case <-changed:
+		t.Fatal("unexpected change of another file")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// replacing the file is seen as a change.
+	tmp := filepath.Join(dir, "tmp.json")
+	require.NoError(t, os.WriteFile(tmp, []byte(`{"EnablePprof":true}`), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the change")
+	}
+
+	cancel()
+	require.Error(t, <-done)
+}
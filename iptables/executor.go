@@ -0,0 +1,74 @@
+package iptables
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-container-networking/platform"
+	"go.uber.org/zap"
+)
+
+const (
+	iptablesSave     = "iptables-save"
+	ip6tablesSave    = "ip6tables-save"
+	iptablesRestore  = "iptables-restore"
+	ip6tablesRestore = "ip6tables-restore"
+	restoreTimeout   = (lockTimeout + 10) * time.Second
+)
+
+// Executor runs iptables-save and iptables-restore for transactions.
+type Executor interface {
+	// Save returns the table in iptables-save format.
+	Save(version, tableName string) (string, error)
+	// Check runs iptables -C, which reports whether the rule is in the chain.
+	Check(version, tableName, chainName, rule string) bool
+	// Restore runs iptables-restore --noflush with the input.
+	Restore(version, input string) error
+}
+
+type restoreExecutor struct {
+	pl platform.ExecClient
+}
+
+func (e *restoreExecutor) Save(version, tableName string) (string, error) {
+	saveCmd := iptablesSave
+	if version == V6 {
+		saveCmd = ip6tablesSave
+	}
+	out, err := e.pl.ExecuteRawCommand(fmt.Sprintf("%s -t %s", saveCmd, tableName))
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", saveCmd, err)
+	}
+	return out, nil
+}
+
+func (e *restoreExecutor) Check(version, tableName, chainName, rule string) bool {
+	return runCmd(e.pl, version, fmt.Sprintf("-t %s -C %s %s", tableName, chainName, rule)) == nil
+}
+
+func (e *restoreExecutor) Restore(version, input string) error {
+	restoreCmd := iptablesRestore
+	if version == V6 {
+		restoreCmd = ip6tablesRestore
+	}
+	args := []string{"--noflush"}
+	if !DisableIPTableLock {
+		args = append(args, "-w", strconv.Itoa(lockTimeout))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+	logger.Info("Running iptables restore", zap.String("command", restoreCmd), zap.String("input", input))
+	cmd := exec.CommandContext(ctx, restoreCmd, args...)
+	cmd.Stdin = bytes.NewBufferString(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w: %s", restoreCmd, err, stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errFakeRestoreInput = errors.New("invalid iptables-restore input")
+	errFakeRuleNotFound = errors.New("rule not found")
+	errFakeChainExists  = errors.New("chain already exists")
+)
+
+var builtinChains = map[string][]string{
+	Filter: {Input, Forward, Output},
+	Nat:    {Prerouting, Input, Output, Postrouting},
+	Mangle: {Prerouting, Input, Forward, Output, Postrouting},
+}
+
+type fakeTable struct {
+	chains []string
+	rules  map[string][]string
+}
+
+func newFakeTable(tableName string) *fakeTable {
+	t := &fakeTable{rules: make(map[string][]string)}
+	for _, chain := range builtinChains[tableName] {
+		t.addChain(chain)
+	}
+	return t
+}
+
+func (t *fakeTable) addChain(chain string) {
+	if _, ok := t.rules[chain]; !ok {
+		t.chains = append(t.chains, chain)
+		t.rules[chain] = make([]string, 0)
+	}
+}
+
+func (t *fakeTable) copy() *fakeTable {
+	c := &fakeTable{chains: append([]string{}, t.chains...), rules: make(map[string][]string, len(t.rules))}
+	for chain, rules := range t.rules {
+		c.rules[chain] = append([]string{}, rules...)
+	}
+	return c
+}
+
+// FakeExecutor is an Executor for tests which keeps the tables in memory.
+// Rules are kept as written, and deleted if they match in canonical form.
+type FakeExecutor struct {
+	tables        map[string]map[string]*fakeTable
+	restoreInputs map[string][]string
+	saveCount     int
+	restoreErr    error
+	checkCount    int
+}
+
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{
+		tables:        map[string]map[string]*fakeTable{V4: {}, V6: {}},
+		restoreInputs: map[string][]string{V4: {}, V6: {}},
+	}
+}
+
+func (e *FakeExecutor) table(version, tableName string) *fakeTable {
+	t, ok := e.tables[version][tableName]
+	if !ok {
+		t = newFakeTable(tableName)
+		e.tables[version][tableName] = t
+	}
+	return t
+}
+
+// AddRule adds a rule to the end of the chain, creating the chain if needed.
+func (e *FakeExecutor) AddRule(version, tableName, chainName, rule string) {
+	t := e.table(version, tableName)
+	t.addChain(chainName)
+	t.rules[chainName] = append(t.rules[chainName], rule)
+}
+
+// Chains returns the chains of the table in order.
+func (e *FakeExecutor) Chains(version, tableName string) []string {
+	return e.table(version, tableName).chains
+}
+
+// Rules returns the rules of the chain in order, or nil if the chain doesn't exist.
+func (e *FakeExecutor) Rules(version, tableName, chainName string) []string {
+	return e.table(version, tableName).rules[chainName]
+}
+
+// RestoreInputs returns the input of each Restore for the version.
+func (e *FakeExecutor) RestoreInputs(version string) []string {
+	return e.restoreInputs[version]
+}
+
+// SaveCount returns the number of calls to Save.
+func (e *FakeExecutor) SaveCount() int {
+	return e.saveCount
+}
+
+// SetRestoreError makes Restore fail with err without changing the tables.
+func (e *FakeExecutor) SetRestoreError(err error) {
+	e.restoreErr = err
+}
+
+// CheckCount returns the number of calls to Check.
+func (e *FakeExecutor) CheckCount() int {
+	return e.checkCount
+}
+
+// Check reports whether the rule is in the chain in canonical form, like iptables -C.
+func (e *FakeExecutor) Check(version, tableName, chainName, rule string) bool {
+	e.checkCount++
+	canonical := canonicalRule(rule)
+	for _, r := range e.table(version, tableName).rules[chainName] {
+		if canonicalRule(r) == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *FakeExecutor) Save(version, tableName string) (string, error) {
+	e.saveCount++
+	t := e.table(version, tableName)
+	var sb strings.Builder
+	sb.WriteString("*" + tableName + "\n")
+	for _, chain := range t.chains {
+		sb.WriteString(fmt.Sprintf(":%s - [0:0]\n", chain))
+	}
+	for _, chain := range t.chains {
+		for _, rule := range t.rules[chain] {
+			sb.WriteString(fmt.Sprintf("-A %s %s\n", chain, rule))
+		}
+	}
+	sb.WriteString("COMMIT\n")
+	return sb.String(), nil
+}
+
+// Restore applies the input like iptables-restore --noflush. Each table is committed only if all its lines succeed.
+func (e *FakeExecutor) Restore(version, input string) error {
+	e.restoreInputs[version] = append(e.restoreInputs[version], input)
+	if e.restoreErr != nil {
+		return e.restoreErr
+	}
+
+	var tableName string
+	var t *fakeTable
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "*"):
+			tableName = line[1:]
+			t = e.table(version, tableName).copy()
+		case t == nil:
+			return fmt.Errorf("%w: %s before table", errFakeRestoreInput, line)
+		case line == "COMMIT":
+			e.tables[version][tableName] = t
+			t = nil
+		case strings.HasPrefix(line, ":"):
+			chain := strings.Fields(line[1:])[0]
+			// declaring an existing chain flushes it
+			t.addChain(chain)
+			t.rules[chain] = make([]string, 0)
+		default:
+			if err := t.apply(line); err != nil {
+				return err
+			}
+		}
+	}
+	if t != nil {
+		return fmt.Errorf("%w: missing COMMIT", errFakeRestoreInput)
+	}
+	return nil
+}
+
+func (t *fakeTable) apply(line string) error {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) < 2 {
+		return fmt.Errorf("%w: %s", errFakeRestoreInput, line)
+	}
+	action, chain := strings.TrimPrefix(fields[0], "-"), fields[1]
+	rule := ""
+	if len(fields) == 3 {
+		rule = fields[2]
+	}
+
+	if action == NewChain {
+		if _, ok := t.rules[chain]; ok {
+			return fmt.Errorf("%w: %s", errFakeChainExists, chain)
+		}
+		t.addChain(chain)
+		return nil
+	}
+	rules, ok := t.rules[chain]
+	if !ok {
+		return fmt.Errorf("%w: %s", errChainNotFound, chain)
+	}
+
+	switch action {
+	case Flush:
+		t.rules[chain] = make([]string, 0)
+	case Append:
+		t.rules[chain] = append(rules, rule)
+	case Insert:
+		position := 1
+		if ruleFields := strings.SplitN(rule, " ", 2); len(ruleFields) == 2 {
+			if p, err := strconv.Atoi(ruleFields[0]); err == nil {
+				position = p
+				rule = ruleFields[1]
+			}
+		}
+		if position < 1 || position > len(rules)+1 {
+			return fmt.Errorf("%w: position %d out of range: %s", errFakeRestoreInput, position, line)
+		}
+		t.rules[chain] = append(rules[:position-1], append([]string{rule}, rules[position-1:]...)...)
+	case Delete:
+		canonical := canonicalRule(rule)
+		for i, r := range rules {
+			if canonicalRule(r) == canonical {
+				t.rules[chain] = append(rules[:i], rules[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", errFakeRuleNotFound, line)
+	default:
+		return fmt.Errorf("%w: %s", errFakeRestoreInput, line)
+	}
+	return nil
+}
@@ -92,17 +92,32 @@ type IPTableEntry struct {
 }
 
 type Client struct {
-	pl platform.ExecClient
+	pl       platform.ExecClient
+	executor Executor
 }
 
 func NewClient() *Client {
+	pl := platform.NewExecClient(logger)
 	return &Client{
-		pl: platform.NewExecClient(logger),
+		pl:       pl,
+		executor: &restoreExecutor{pl: pl},
+	}
+}
+
+// NewClientWithExecutor creates a Client which applies transactions with the executor, e.g. a FakeExecutor in tests.
+func NewClientWithExecutor(executor Executor) *Client {
+	return &Client{
+		pl:       platform.NewExecClient(logger),
+		executor: executor,
 	}
 }
 
 // Run iptables command
 func (c *Client) RunCmd(version, params string) error {
+	return runCmd(c.pl, version, params)
+}
+
+func runCmd(pl platform.ExecClient, version, params string) error {
 	var cmd string
 
 	iptCmd := iptables
@@ -116,7 +131,7 @@ func (c *Client) RunCmd(version, params string) error {
 		cmd = fmt.Sprintf("%s -w %d %s", iptCmd, lockTimeout, params)
 	}
 
-	if _, err := c.pl.ExecuteRawCommand(cmd); err != nil {
+	if _, err := pl.ExecuteRawCommand(cmd); err != nil {
 		return err
 	}
 
@@ -0,0 +1,294 @@
+package iptables
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ruleset is the chains and rules of a table, with rules in canonical form so that
+// a rule written by a caller matches the same rule in iptables-save output.
+type ruleset struct {
+	rules map[string][]string
+}
+
+// parseSave parses the table from iptables-save output.
+func parseSave(saved, table string) *ruleset {
+	rs := &ruleset{rules: make(map[string][]string)}
+	inTable := false
+	for _, line := range strings.Split(saved, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "*"):
+			inTable = line[1:] == table
+		case !inTable:
+			continue
+		case strings.HasPrefix(line, ":"):
+			// e.g. ":POSTROUTING ACCEPT [0:0]"
+			if fields := strings.Fields(line[1:]); len(fields) > 0 {
+				rs.addChain(fields[0])
+			}
+		case strings.HasPrefix(line, "-A "):
+			// e.g. "-A POSTROUTING -j SWIFT"
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) < 3 {
+				continue
+			}
+			rs.addChain(fields[1])
+			rs.addRule(fields[1], fields[2])
+		}
+	}
+	return rs
+}
+
+func (rs *ruleset) hasChain(chain string) bool {
+	_, ok := rs.rules[chain]
+	return ok
+}
+
+func (rs *ruleset) addChain(chain string) {
+	if !rs.hasChain(chain) {
+		rs.rules[chain] = make([]string, 0)
+	}
+}
+
+func (rs *ruleset) flush(chain string) {
+	rs.rules[chain] = make([]string, 0)
+}
+
+func (rs *ruleset) hasRule(chain, rule string) bool {
+	canonical := canonicalRule(rule)
+	for _, r := range rs.rules[chain] {
+		if r == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *ruleset) addRule(chain, rule string) {
+	rs.rules[chain] = append(rs.rules[chain], canonicalRule(rule))
+}
+
+func (rs *ruleset) deleteRule(chain, rule string) {
+	canonical := canonicalRule(rule)
+	for i, r := range rs.rules[chain] {
+		if r == canonical {
+			rs.rules[chain] = append(rs.rules[chain][:i], rs.rules[chain][i+1:]...)
+			return
+		}
+	}
+}
+
+// long options which iptables-save prints in short form
+var shortOptions = map[string]string{
+	"--source":        "-s",
+	"--src":           "-s",
+	"--destination":   "-d",
+	"--dst":           "-d",
+	"--in-interface":  "-i",
+	"--out-interface": "-o",
+	"--protocol":      "-p",
+	"--jump":          "-j",
+	"--match":         "-m",
+}
+
+// options of the protocol match which iptables loads implicitly after -p
+var protocolOptions = map[string]struct{}{
+	"--sport":            {},
+	"--source-port":      {},
+	"--dport":            {},
+	"--destination-port": {},
+	"--tcp-flags":        {},
+	"--syn":              {},
+	"--icmp-type":        {},
+	"--icmpv6-type":      {},
+}
+
+// canonicalRule returns a form of the rule which doesn't depend on how iptables-save prints it:
+//   - generic options (-s, -d, -i, -o, -p) are sorted, and addresses have a prefix length
+//   - each match is grouped with its options, and the implicit protocol match after -p is explicit
+//   - the matches are sorted, since iptables-save prints generic options before matches
+//   - option values which iptables-save normalizes (e.g. address types, conntrack states, marks) are normalized
+func canonicalRule(rule string) string {
+	generic := make([]string, 0)
+	matches := make(map[string][]string)
+	matchOrder := make([]string, 0)
+	var target []string
+	protocol := ""
+	currentMatch := ""
+
+	addToMatch := func(name string, tokens ...string) {
+		if _, ok := matches[name]; !ok {
+			matches[name] = make([]string, 0)
+			matchOrder = append(matchOrder, name)
+		}
+		matches[name] = append(matches[name], tokens...)
+	}
+
+	tokens := splitRule(rule)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		negated := false
+		if token == "!" && i+1 < len(tokens) {
+			negated = true
+			i++
+			token = tokens[i]
+		}
+		if short, ok := shortOptions[token]; ok {
+			token = short
+		}
+		value := ""
+		hasValue := i+1 < len(tokens) && !strings.HasPrefix(tokens[i+1], "-") && tokens[i+1] != "!"
+		if hasValue {
+			value = tokens[i+1]
+		}
+		prefix := ""
+		if negated {
+			prefix = "! "
+		}
+
+		switch {
+		case target != nil:
+			// everything after -j belongs to the target
+			target = append(target, canonicalTargetOption(target[0], token, value, hasValue)...)
+			if hasValue {
+				i++
+			}
+		case token == "-j":
+			target = []string{value}
+			i++
+		case token == "-s" || token == "-d":
+			generic = append(generic, fmt.Sprintf("%s%s %s", prefix, token, canonicalAddress(value)))
+			i++
+		case token == "-i" || token == "-o":
+			generic = append(generic, fmt.Sprintf("%s%s %s", prefix, token, value))
+			i++
+		case token == "-p":
+			protocol = strings.ToLower(value)
+			generic = append(generic, fmt.Sprintf("%s-p %s", prefix, protocol))
+			i++
+		case token == "-m":
+			currentMatch = strings.ToLower(value)
+			addToMatch(currentMatch)
+			i++
+		default:
+			name := currentMatch
+			if _, ok := protocolOptions[token]; ok && protocol != "" {
+				name = protocol
+			}
+			option := prefix + token
+			if hasValue {
+				option += " " + canonicalMatchValue(token, value)
+				i++
+			}
+			addToMatch(name, option)
+		}
+	}
+
+	groups := make([]string, 0, len(matchOrder))
+	for _, name := range matchOrder {
+		groups = append(groups, strings.TrimSpace("-m "+name+" "+strings.Join(matches[name], " ")))
+	}
+	sort.Strings(generic)
+	sort.Strings(groups)
+	parts := append(generic, groups...)
+	if target != nil {
+		parts = append(parts, "-j "+strings.Join(target, " "))
+	}
+	return strings.Join(parts, " ")
+}
+
+func canonicalAddress(address string) string {
+	if strings.Contains(address, "/") {
+		if _, ipNet, err := net.ParseCIDR(address); err == nil {
+			return ipNet.String()
+		}
+		return address
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+func canonicalMatchValue(option, value string) string {
+	switch option {
+	case "--dst-type", "--src-type":
+		return strings.ToUpper(value)
+	case "--state", "--ctstate":
+		states := strings.Split(strings.ToUpper(value), ",")
+		sort.Strings(states)
+		return strings.Join(states, ",")
+	case "--mark":
+		return canonicalMark(value)
+	}
+	return value
+}
+
+func canonicalTargetOption(target, option, value string, hasValue bool) []string {
+	switch {
+	case option == "--to" && target == Snat:
+		option = "--to-source"
+	case option == "--to" && target == "DNAT":
+		option = "--to-destination"
+	case option == "--set-mark" && target == "MARK":
+		// iptables-save prints --set-mark as --set-xmark
+		option = "--set-xmark"
+		if !strings.Contains(value, "/") {
+			value += "/0xffffffff"
+		}
+		value = canonicalMark(value)
+	case option == "--set-xmark":
+		value = canonicalMark(value)
+	}
+	if !hasValue {
+		return []string{option}
+	}
+	return []string{option, value}
+}
+
+// canonicalMark formats a mark or mark/mask in hex.
+func canonicalMark(mark string) string {
+	parts := strings.Split(mark, "/")
+	for i, part := range parts {
+		if n, err := strconv.ParseUint(part, 0, 32); err == nil {
+			parts[i] = fmt.Sprintf("0x%x", n)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// splitRule splits a rule into tokens, keeping quoted strings (e.g. comments) together without their quotes.
+func splitRule(rule string) []string {
+	tokens := make([]string, 0)
+	var sb strings.Builder
+	inQuotes := false
+	hasToken := false
+	for _, r := range rule {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasToken = true
+		case r == ' ' && !inQuotes:
+			if hasToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				hasToken = false
+			}
+		default:
+			sb.WriteRune(r)
+			hasToken = true
+		}
+	}
+	if hasToken {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens
+}
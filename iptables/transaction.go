@@ -0,0 +1,254 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// actions only used in transactions
+const (
+	NewChain = "N"
+	Flush    = "F"
+)
+
+var (
+	errChainNotFound = errors.New("chain not found")
+	errUnknownAction = errors.New("unknown iptables action")
+)
+
+// Operation is a chain or rule operation in a Transaction.
+type Operation struct {
+	// Action is NewChain, Flush, Insert, Append, or Delete
+	Action  string
+	Version string
+	Table   string
+	Chain   string
+	// Position is the 1-based position of an Insert
+	Position int
+	Match    string
+	Target   string
+}
+
+func (op Operation) rule() string {
+	return strings.TrimSpace(fmt.Sprintf("%s -j %s", op.Match, op.Target))
+}
+
+// Transaction collects chain and rule operations so that the Client can apply them together.
+// The operations have the same check semantics as the Client's single-rule methods:
+// existing chains aren't created again, existing rules aren't inserted or appended again, and missing rules aren't deleted.
+// Apply runs one iptables-save per table and one iptables-restore per version,
+// so the operations for each version are applied atomically.
+// A rule which isn't found in the iptables-save output is checked with iptables -C before it is changed,
+// so that a rule which iptables-save prints differently isn't added again.
+type Transaction struct {
+	ops []Operation
+}
+
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+// CreateChain creates the chain if it doesn't exist.
+func (tx *Transaction) CreateChain(version, tableName, chainName string) *Transaction {
+	return tx.Add(Operation{Action: NewChain, Version: version, Table: tableName, Chain: chainName})
+}
+
+// FlushChain deletes all rules in the chain if it exists.
+func (tx *Transaction) FlushChain(version, tableName, chainName string) *Transaction {
+	return tx.Add(Operation{Action: Flush, Version: version, Table: tableName, Chain: chainName})
+}
+
+// InsertRule inserts the rule at the beginning of the chain if it doesn't exist.
+func (tx *Transaction) InsertRule(version, tableName, chainName, match, target string) *Transaction {
+	return tx.InsertRuleAt(version, tableName, chainName, 1, match, target)
+}
+
+// InsertRuleAt inserts the rule at the 1-based position of the chain if it doesn't exist.
+func (tx *Transaction) InsertRuleAt(version, tableName, chainName string, position int, match, target string) *Transaction {
+	return tx.Add(Operation{Action: Insert, Version: version, Table: tableName, Chain: chainName, Position: position, Match: match, Target: target})
+}
+
+// AppendRule appends the rule at the end of the chain if it doesn't exist.
+func (tx *Transaction) AppendRule(version, tableName, chainName, match, target string) *Transaction {
+	return tx.Add(Operation{Action: Append, Version: version, Table: tableName, Chain: chainName, Match: match, Target: target})
+}
+
+// DeleteRule deletes the rule from the chain if it exists.
+func (tx *Transaction) DeleteRule(version, tableName, chainName, match, target string) *Transaction {
+	return tx.Add(Operation{Action: Delete, Version: version, Table: tableName, Chain: chainName, Match: match, Target: target})
+}
+
+// Add adds operations to the transaction in order.
+func (tx *Transaction) Add(ops ...Operation) *Transaction {
+	tx.ops = append(tx.ops, ops...)
+	return tx
+}
+
+// Operations returns the operations in the transaction in order.
+func (tx *Transaction) Operations() []Operation {
+	return tx.ops
+}
+
+func (tx *Transaction) Len() int {
+	return len(tx.ops)
+}
+
+// Apply applies the transaction with one iptables-restore --noflush per version.
+// Nothing is run for a version whose operations are all already satisfied.
+func (c *Client) Apply(tx *Transaction) error {
+	for _, version := range []string{V4, V6} {
+		ops := tx.versionOps(version)
+		if len(ops) == 0 {
+			continue
+		}
+
+		input, err := c.restoreInput(version, ops)
+		if err != nil {
+			return err
+		}
+		if input == "" {
+			logger.Info("iptables rules already exist", zap.String("version", version))
+			continue
+		}
+		if err := c.executor.Restore(version, input); err != nil {
+			return fmt.Errorf("failed to restore iptables version %s with input:\n%s: %w", version, input, err)
+		}
+	}
+	return nil
+}
+
+// Pending returns the iptables-restore input which Apply would run for the transaction, one block per version,
+// or "" if its operations are all already satisfied. Nothing is changed, so it detects rules which went missing.
+func (c *Client) Pending(tx *Transaction) (string, error) {
+	var sb strings.Builder
+	for _, version := range []string{V4, V6} {
+		ops := tx.versionOps(version)
+		if len(ops) == 0 {
+			continue
+		}
+		input, err := c.restoreInput(version, ops)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(input)
+	}
+	return sb.String(), nil
+}
+
+func (tx *Transaction) versionOps(version string) []Operation {
+	var ops []Operation
+	for _, op := range tx.ops {
+		if op.Version == version {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// restoreInput returns the iptables-restore input for the operations which aren't already satisfied by the saved tables.
+func (c *Client) restoreInput(version string, ops []Operation) (string, error) {
+	tables := make([]string, 0)
+	rulesets := make(map[string]*ruleset)
+	chainLines := make(map[string][]string)
+	ruleLines := make(map[string][]string)
+	// the chains and rules which the transaction changes, for which the ruleset is certain
+	changed := make(map[string]struct{})
+	// hasRule reports whether the rule is in the chain. A rule which isn't found in the saved ruleset may only be
+	// printed differently by iptables-save, so iptables -C decides unless the transaction changed it.
+	hasRule := func(rs *ruleset, op Operation, rule string) bool {
+		if rs.hasRule(op.Chain, rule) {
+			return true
+		}
+		if _, ok := changed[op.Table+" "+op.Chain]; ok {
+			return false
+		}
+		if _, ok := changed[op.Table+" "+op.Chain+" "+canonicalRule(rule)]; ok {
+			return false
+		}
+		return c.executor.Check(version, op.Table, op.Chain, rule)
+	}
+
+	for _, op := range ops {
+		rs, ok := rulesets[op.Table]
+		if !ok {
+			saved, err := c.executor.Save(version, op.Table)
+			if err != nil {
+				return "", fmt.Errorf("failed to save iptables version %s table %s: %w", version, op.Table, err)
+			}
+			rs = parseSave(saved, op.Table)
+			rulesets[op.Table] = rs
+			tables = append(tables, op.Table)
+		}
+
+		if op.Action == NewChain {
+			if rs.hasChain(op.Chain) {
+				continue
+			}
+			rs.addChain(op.Chain)
+			changed[op.Table+" "+op.Chain] = struct{}{}
+			chainLines[op.Table] = append(chainLines[op.Table], fmt.Sprintf(":%s - [0:0]", op.Chain))
+			continue
+		}
+
+		if !rs.hasChain(op.Chain) {
+			if op.Action == Flush || op.Action == Delete {
+				continue
+			}
+			return "", fmt.Errorf("%w: version %s table %s chain %s", errChainNotFound, version, op.Table, op.Chain)
+		}
+
+		rule := op.rule()
+		switch op.Action {
+		case Flush:
+			changed[op.Table+" "+op.Chain] = struct{}{}
+			if len(rs.rules[op.Chain]) == 0 {
+				continue
+			}
+			rs.flush(op.Chain)
+			ruleLines[op.Table] = append(ruleLines[op.Table], "-F "+op.Chain)
+		case Insert:
+			if hasRule(rs, op, rule) {
+				continue
+			}
+			rs.addRule(op.Chain, rule)
+			changed[op.Table+" "+op.Chain+" "+canonicalRule(rule)] = struct{}{}
+			ruleLines[op.Table] = append(ruleLines[op.Table], fmt.Sprintf("-I %s %d %s", op.Chain, op.Position, rule))
+		case Append:
+			if hasRule(rs, op, rule) {
+				continue
+			}
+			rs.addRule(op.Chain, rule)
+			changed[op.Table+" "+op.Chain+" "+canonicalRule(rule)] = struct{}{}
+			ruleLines[op.Table] = append(ruleLines[op.Table], fmt.Sprintf("-A %s %s", op.Chain, rule))
+		case Delete:
+			if !hasRule(rs, op, rule) {
+				continue
+			}
+			rs.deleteRule(op.Chain, rule)
+			changed[op.Table+" "+op.Chain+" "+canonicalRule(rule)] = struct{}{}
+			ruleLines[op.Table] = append(ruleLines[op.Table], fmt.Sprintf("-D %s %s", op.Chain, rule))
+		default:
+			return "", fmt.Errorf("%w: %s", errUnknownAction, op.Action)
+		}
+	}
+
+	var sb strings.Builder
+	for _, table := range tables {
+		// new chains are empty, so declaring them before the table's rules is equivalent
+		if len(chainLines[table]) == 0 && len(ruleLines[table]) == 0 {
+			continue
+		}
+		sb.WriteString("*" + table + "\n")
+		for _, line := range chainLines[table] {
+			sb.WriteString(line + "\n")
+		}
+		for _, line := range ruleLines[table] {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("COMMIT\n")
+	}
+	return sb.String(), nil
+}
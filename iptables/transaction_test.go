@@ -0,0 +1,308 @@
+package iptables
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	snatMatch  = " -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p udp --dport 53"
+	snatTarget = "SNAT --to 10.0.1.20"
+)
+
+func TestApplyTransaction(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+
+	tx := NewTransaction().
+		CreateChain(V4, Nat, Swift).
+		AppendRule(V4, Nat, Postrouting, "", Swift).
+		InsertRule(V4, Nat, Swift, snatMatch, snatTarget).
+		InsertRule(V4, Nat, Swift, snatMatch, snatTarget)
+	require.NoError(t, client.Apply(tx))
+
+	require.Equal(t, []string{
+		"*nat\n" +
+			":SWIFT - [0:0]\n" +
+			"-A POSTROUTING -j SWIFT\n" +
+			"-I SWIFT 1 -m addrtype ! --dst-type local -s 10.0.1.0/24 -d 168.63.129.16 -p udp --dport 53 -j SNAT --to 10.0.1.20\n" +
+			"COMMIT\n",
+	}, fake.RestoreInputs(V4))
+	require.Empty(t, fake.RestoreInputs(V6))
+	require.Equal(t, 1, fake.SaveCount())
+	require.Equal(t, []string{"-j SWIFT"}, fake.Rules(V4, Nat, Postrouting))
+
+	// applying again is a no-op
+	require.NoError(t, client.Apply(tx))
+	require.Len(t, fake.RestoreInputs(V4), 1)
+}
+
+func TestApplyTransactionMatchesSavedRules(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+	// rules as printed by iptables-save
+	fake.AddRule(V4, Nat, Swift, "-s 10.0.1.0/24 -d 168.63.129.16/32 -p udp -m addrtype ! --dst-type LOCAL -m udp --dport 53 -j SNAT --to-source 10.0.1.20")
+	fake.AddRule(V4, Filter, Input, "-i azSnatbr -m state --state RELATED,ESTABLISHED -j ACCEPT")
+	fake.AddRule(V6, Mangle, Postrouting, "-j MARK --set-xmark 0x0/0xffffffff")
+
+	tx := NewTransaction().
+		CreateChain(V4, Nat, Swift).
+		InsertRule(V4, Nat, Swift, snatMatch, snatTarget).
+		InsertRule(V4, Filter, Input, " -i azSnatbr -m state --state ESTABLISHED,RELATED", Accept).
+		InsertRule(V6, Mangle, Postrouting, "", "MARK --set-mark 0x0").
+		DeleteRule(V4, Filter, Input, "-p tcp --dport 80", Accept)
+	require.NoError(t, client.Apply(tx))
+	require.Empty(t, fake.RestoreInputs(V4))
+	require.Empty(t, fake.RestoreInputs(V6))
+}
+
+func TestApplyTransactionPerVersion(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+	fake.AddRule(V4, Mangle, Prerouting, "-i eth0.1 -j ACCEPT")
+
+	tx := NewTransaction()
+	for _, version := range []string{V4, V6} {
+		tx.InsertRule(version, Mangle, Prerouting, "", "MARK --set-mark 333").
+			InsertRule(version, Mangle, Prerouting, "-i eth0.1", Accept)
+	}
+	require.NoError(t, client.Apply(tx))
+
+	require.Equal(t, []string{"*mangle\n-I PREROUTING 1 -j MARK --set-mark 333\nCOMMIT\n"}, fake.RestoreInputs(V4))
+	require.Equal(t, []string{"*mangle\n-I PREROUTING 1 -j MARK --set-mark 333\n-I PREROUTING 1 -i eth0.1 -j ACCEPT\nCOMMIT\n"}, fake.RestoreInputs(V6))
+	require.Equal(t, []string{"-i eth0.1 -j ACCEPT", "-j MARK --set-mark 333"}, fake.Rules(V6, Mangle, Prerouting))
+}
+
+func TestApplyTransactionFlushAndDelete(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+	fake.AddRule(V4, Nat, Postrouting, "-j SWIFT")
+	fake.AddRule(V4, Nat, Postrouting, "-j SWIFT-POSTROUTING")
+	fake.AddRule(V4, Nat, "SWIFT-POSTROUTING", "-s 10.0.1.0/24 -j SNAT --to-source 10.0.0.4")
+
+	tx := NewTransaction().
+		DeleteRule(V4, Nat, Postrouting, "", "SWIFT-POSTROUTING").
+		InsertRuleAt(V4, Nat, Postrouting, 1, "", "SWIFT-POSTROUTING").
+		FlushChain(V4, Nat, "SWIFT-POSTROUTING").
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-s 10.0.1.0/24", "SNAT --to 10.0.0.5").
+		// flushing or deleting from a missing chain is a no-op
+		FlushChain(V4, Nat, "MISSING").
+		DeleteRule(V4, Nat, "MISSING", "", Accept)
+	require.NoError(t, client.Apply(tx))
+
+	require.Equal(t, []string{"-j SWIFT-POSTROUTING", "-j SWIFT"}, fake.Rules(V4, Nat, Postrouting))
+	require.Equal(t, []string{"-s 10.0.1.0/24 -j SNAT --to 10.0.0.5"}, fake.Rules(V4, Nat, "SWIFT-POSTROUTING"))
+}
+
+func TestPendingTransaction(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+
+	tx := NewTransaction().
+		CreateChain(V4, Nat, Swift).
+		AppendRule(V4, Nat, Postrouting, "", Swift).
+		InsertRule(V4, Nat, Swift, snatMatch, snatTarget)
+	pending, err := client.Pending(tx)
+	require.NoError(t, err)
+	require.Equal(t, "*nat\n:SWIFT - [0:0]\n-A POSTROUTING -j SWIFT\n-I SWIFT 1"+snatMatch+" -j "+snatTarget+"\nCOMMIT\n", pending)
+	require.Empty(t, fake.RestoreInputs(V4))
+
+	require.NoError(t, client.Apply(tx))
+	pending, err = client.Pending(tx)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// a rule which was deleted behind our back is pending again
+	require.NoError(t, fake.Restore(V4, "*nat\n-D POSTROUTING -j SWIFT\nCOMMIT\n"))
+	pending, err = client.Pending(tx)
+	require.NoError(t, err)
+	require.Equal(t, "*nat\n-A POSTROUTING -j SWIFT\nCOMMIT\n", pending)
+}
+
+func TestApplyTransactionErrors(t *testing.T) {
+	fake := NewFakeExecutor()
+	client := NewClientWithExecutor(fake)
+
+	err := client.Apply(NewTransaction().InsertRule(V4, Filter, CNIInputChain, "", Accept))
+	require.ErrorIs(t, err, errChainNotFound)
+	require.Empty(t, fake.RestoreInputs(V4))
+
+	errRestore := errors.New("restore failed")
+	fake.SetRestoreError(errRestore)
+	err = client.Apply(NewTransaction().CreateChain(V4, Filter, CNIInputChain).InsertRule(V4, Filter, CNIInputChain, "", Accept))
+	require.ErrorIs(t, err, errRestore)
+	require.Nil(t, fake.Rules(V4, Filter, CNIInputChain))
+
+	require.NoError(t, client.Apply(NewTransaction()))
+}
+
+// savedExecutor returns the iptables-save output in testdata for each version.
+// iptables -C finds only the rules in checked.
+type savedExecutor struct {
+	saved   map[string]string
+	checked map[string]bool
+}
+
+func (e *savedExecutor) Check(_, _, chainName, rule string) bool {
+	return e.checked[chainName+" "+rule]
+}
+
+func (e *savedExecutor) Save(version, _ string) (string, error) {
+	return e.saved[version], nil
+}
+
+func (e *savedExecutor) Restore(string, string) error {
+	return nil
+}
+
+// TestPendingIptablesSave checks the rules as the callers write them against the output of iptables-save
+// and ip6tables-save on a node which has them.
+func TestPendingIptablesSave(t *testing.T) {
+	saved := make(map[string]string)
+	for version, file := range map[string]string{V4: "testdata/iptables-save.txt", V6: "testdata/ip6tables-save.txt"} {
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		saved[version] = string(b)
+	}
+	client := NewClientWithExecutor(&savedExecutor{saved: saved})
+
+	swiftSnat := "SNAT --to 10.224.0.4"
+	tx := NewTransaction().
+		// cns
+		CreateChain(V4, Nat, "SWIFT-POSTROUTING").
+		InsertRuleAt(V4, Nat, Postrouting, 2, "", "SWIFT-POSTROUTING").
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 53", swiftSnat).
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p tcp --dport 53", swiftSnat).
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype ! --dst-type local -s 10.224.0.0/16 -d 169.254.169.254 -p tcp --dport 80", swiftSnat).
+		// snat bridge
+		InsertRule(V4, Nat, Postrouting, "-s 169.254.128.0/17", Masquerade).
+		CreateChain(V4, Filter, CNIInputChain).
+		InsertRule(V4, Filter, Input, "", CNIInputChain).
+		InsertRule(V4, Filter, CNIInputChain, "-s 169.254.128.2 -d 169.254.128.1", Accept).
+		InsertRule(V4, Filter, CNIInputChain, " -i azSnatbr -m state --state ESTABLISHED,RELATED", Accept).
+		CreateChain(V4, Filter, CNIOutputChain).
+		InsertRule(V4, Filter, Output, "", CNIOutputChain).
+		InsertRule(V4, Filter, CNIOutputChain, "-s 169.254.128.1 -d 169.254.128.2", Accept).
+		InsertRule(V4, Filter, Forward, "-i azSnatbr -d 10.0.0.0/8", Drop).
+		InsertRule(V4, Filter, Output, "-o azSnatbr -d 10.0.0.0/8", Drop).
+		InsertRule(V4, Filter, Forward, "-i azSnatbr -d 168.63.129.16", Accept).
+		AppendRule(V4, Filter, Forward, "", Accept).
+		// transparent vlan
+		InsertRule(V4, Filter, Forward, "-d 168.63.129.16 -p tcp -m tcp --dport 80", Drop)
+	for _, version := range []string{V4, V6} {
+		tx.InsertRule(version, Mangle, Prerouting, "", "MARK --set-mark 333").
+			InsertRule(version, Mangle, Prerouting, "-i eth0.2", Accept)
+	}
+	tx.InsertRule(V6, Nat, Postrouting, "-s fd00:1234::/64", "SNAT --to fd00:1234::4")
+
+	pending, err := client.Pending(tx)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// rules which differ from the saved ones in any option are pending
+	tx = NewTransaction().
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 53", swiftSnat).
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 54", swiftSnat).
+		AppendRule(V4, Nat, "SWIFT-POSTROUTING", "-m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 53", "SNAT --to 10.224.0.5").
+		InsertRule(V4, Filter, Forward, "-o azSnatbr -d 10.0.0.0/8", Drop).
+		InsertRule(V4, Filter, CNIInputChain, " -i azSnatbr -m state --state ESTABLISHED", Accept).
+		InsertRule(V6, Mangle, Prerouting, "", "MARK --set-mark 334")
+	pending, err = client.Pending(tx)
+	require.NoError(t, err)
+	require.Equal(t, "*nat\n"+
+		"-A SWIFT-POSTROUTING -m addrtype --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 53 -j SNAT --to 10.224.0.4\n"+
+		"-A SWIFT-POSTROUTING -m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 54 -j SNAT --to 10.224.0.4\n"+
+		"-A SWIFT-POSTROUTING -m addrtype ! --dst-type local -s 10.224.0.0/16 -d 168.63.129.16 -p udp --dport 53 -j SNAT --to 10.224.0.5\n"+
+		"COMMIT\n"+
+		"*filter\n"+
+		"-I FORWARD 1 -o azSnatbr -d 10.0.0.0/8 -j DROP\n"+
+		"-I AZURECNIINPUT 1 -i azSnatbr -m state --state ESTABLISHED -j ACCEPT\n"+
+		"COMMIT\n"+
+		"*mangle\n"+
+		"-I PREROUTING 1 -j MARK --set-mark 334\n"+
+		"COMMIT\n", pending)
+}
+
+// TestPendingChecksUnmatchedRules checks that a rule which doesn't match the iptables-save output is checked with
+// iptables -C, unless the transaction changed its chain or the rule.
+func TestPendingChecksUnmatchedRules(t *testing.T) {
+	saved := "*nat\n" +
+		":POSTROUTING ACCEPT [0:0]\n" +
+		":SWIFT - [0:0]\n" +
+		"-A POSTROUTING -j SWIFT\n" +
+		"-A SWIFT -s 10.0.0.0/8 -j SNAT --to-source 10.0.0.4\n" +
+		"COMMIT\n"
+	// a rule which iptables-save prints in a form that the canonical form doesn't match
+	unmatched := "-m comment --comment snat-bridge -s 169.254.128.0/17 -j MASQUERADE"
+	client := NewClientWithExecutor(&savedExecutor{
+		saved:   map[string]string{V4: saved},
+		checked: map[string]bool{Postrouting + " " + unmatched: true, "SWIFT -s 10.1.0.0/16 -j RETURN": true},
+	})
+
+	// a rule which iptables -C finds isn't added again, and one which it finds is deleted
+	pending, err := client.Pending(NewTransaction().
+		InsertRule(V4, Nat, Postrouting, "-m comment --comment snat-bridge -s 169.254.128.0/17", Masquerade).
+		DeleteRule(V4, Nat, Swift, "-s 10.1.0.0/16", Return))
+	require.NoError(t, err)
+	require.Equal(t, "*nat\n-D SWIFT -s 10.1.0.0/16 -j RETURN\nCOMMIT\n", pending)
+
+	// the ruleset is certain for a flushed chain and for rules which the transaction deleted
+	pending, err = client.Pending(NewTransaction().
+		FlushChain(V4, Nat, Swift).
+		AppendRule(V4, Nat, Swift, "-s 10.1.0.0/16", Return).
+		DeleteRule(V4, Nat, Postrouting, "", Swift).
+		InsertRule(V4, Nat, Postrouting, "", Swift))
+	require.NoError(t, err)
+	require.Equal(t, "*nat\n"+
+		"-F SWIFT\n"+
+		"-A SWIFT -s 10.1.0.0/16 -j RETURN\n"+
+		"-D POSTROUTING -j SWIFT\n"+
+		"-I POSTROUTING 1 -j SWIFT\n"+
+		"COMMIT\n", pending)
+}
+
+func TestCanonicalRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		saved string
+	}{
+		{
+			name:  "snat",
+			rule:  "-m addrtype ! --dst-type local -s 10.0.1.0/24 -d 169.254.169.254 -p tcp --dport 80 -j SNAT --to 10.0.0.3",
+			saved: "-s 10.0.1.0/24 -d 169.254.169.254/32 -p tcp -m addrtype ! --dst-type LOCAL -m tcp --dport 80 -j SNAT --to-source 10.0.0.3",
+		},
+		{
+			name:  "ipv6 snat",
+			rule:  "-s fd00::/64 -j SNAT --to fd00::4",
+			saved: "-s fd00::/64 -j SNAT --to-source fd00::4",
+		},
+		{
+			name:  "state",
+			rule:  " -o azSnatbr -m state --state ESTABLISHED,RELATED -j ACCEPT",
+			saved: "-o azSnatbr -m state --state RELATED,ESTABLISHED -j ACCEPT",
+		},
+		{
+			name:  "mark",
+			rule:  "-j MARK --set-mark 333",
+			saved: "-j MARK --set-xmark 0x14d/0xffffffff",
+		},
+		{
+			name:  "comment",
+			rule:  `-m comment --comment "azure cni" -j ACCEPT`,
+			saved: `-m comment --comment "azure cni" -j ACCEPT`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, canonicalRule(tt.saved), canonicalRule(tt.rule))
+		})
+	}
+
+	require.NotEqual(t, canonicalRule("-p tcp --dport 80 -j ACCEPT"), canonicalRule("-p tcp --dport 81 -j ACCEPT"))
+	require.NotEqual(t, canonicalRule("-m addrtype --dst-type LOCAL -j ACCEPT"), canonicalRule("-m addrtype ! --dst-type LOCAL -j ACCEPT"))
+}
@@ -9,6 +9,7 @@ package netlink
 import (
 	"fmt"
 	"net"
+	"unsafe"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/pkg/errors"
@@ -464,3 +465,82 @@ func (Netlink) SetOrRemoveLinkAddress(linkInfo LinkInfo, mode, linkState int) er
 
 	return s.sendAndWaitForAck(req)
 }
+
+// Neighbor represents a netlink neighbor (ARP/NDP) entry.
+type Neighbor struct {
+	LinkIndex    int
+	IP           net.IP
+	HardwareAddr net.HardwareAddr
+	State        int
+}
+
+// GetNeighbors returns the neighbor entries of the given family on the given link.
+// A zero link index returns the entries of all links.
+func (Netlink) GetNeighbors(linkIndex, family int) ([]*Neighbor, error) {
+	s, err := getSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	req := newRequest(unix.RTM_GETNEIGH, unix.NLM_F_DUMP)
+	req.addPayload(&neighMsg{Family: uint8(family), Index: uint32(linkIndex)})
+
+	msgs, err := s.sendAndWaitForResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []*Neighbor
+	for _, msg := range msgs {
+		neigh, err := deserializeNeighbor(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if linkIndex != 0 && neigh.LinkIndex != linkIndex {
+			continue
+		}
+
+		neighbors = append(neighbors, neigh)
+	}
+
+	return neighbors, nil
+}
+
+// deserializeNeighbor decodes a netlink message into a Neighbor struct.
+// The attributes of neighbor messages are not parsed on receive, so they are decoded here.
+func deserializeNeighbor(msg *message) (*Neighbor, error) {
+	if len(msg.data) < unix.SizeofNdMsg {
+		return nil, errors.Errorf("neighbor message too short: %d bytes", len(msg.data))
+	}
+
+	ndmsg := (*unix.NdMsg)(unsafe.Pointer(&msg.data[0]))
+	neigh := Neighbor{
+		LinkIndex: int(ndmsg.Ifindex),
+		State:     int(ndmsg.State),
+	}
+
+	b := msg.data[unix.SizeofNdMsg:]
+	for len(b) >= unix.SizeofRtAttr {
+		attr := (*unix.RtAttr)(unsafe.Pointer(&b[0]))
+		if int(attr.Len) < unix.SizeofRtAttr || int(attr.Len) > len(b) {
+			return nil, errors.Errorf("invalid neighbor attribute length %d", attr.Len)
+		}
+		value := b[unix.SizeofRtAttr:attr.Len]
+
+		switch attr.Type {
+		case NDA_DST:
+			neigh.IP = net.IP(value)
+		case NDA_LLADDR:
+			neigh.HardwareAddr = net.HardwareAddr(value)
+		}
+
+		alignedLen := (int(attr.Len) + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+		if alignedLen > len(b) {
+			break
+		}
+		b = b[alignedLen:]
+	}
+
+	return &neigh, nil
+}
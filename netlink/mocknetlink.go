@@ -24,6 +24,8 @@ type MockNetlink struct {
 	addRouteFn               routeValidateFn
 	DeleteLinkFn             func(name string) error
 	SetOrRemoveLinkAddressFn func(linkInfo LinkInfo, mode, flags int) error
+	GetIPRouteFn             func(filter *Route) ([]*Route, error)
+	GetNeighborsFn           func(linkIndex, family int) ([]*Neighbor, error)
 }
 
 func NewMockNetlink(returnError bool, errorString string) *MockNetlink {
@@ -110,7 +112,10 @@ func (f *MockNetlink) DeleteIPAddress(string, net.IP, *net.IPNet) error {
 	return f.error()
 }
 
-func (f *MockNetlink) GetIPRoute(*Route) ([]*Route, error) {
+func (f *MockNetlink) GetIPRoute(filter *Route) ([]*Route, error) {
+	if f.GetIPRouteFn != nil {
+		return f.GetIPRouteFn(filter)
+	}
 	return nil, f.error()
 }
 
@@ -127,3 +132,10 @@ func (f *MockNetlink) DeleteIPRoute(r *Route) error {
 	}
 	return f.error()
 }
+
+func (f *MockNetlink) GetNeighbors(linkIndex, family int) ([]*Neighbor, error) {
+	if f.GetNeighborsFn != nil {
+		return f.GetNeighborsFn(linkIndex, family)
+	}
+	return nil, f.error()
+}
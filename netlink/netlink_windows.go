@@ -12,6 +12,8 @@ type Link interface {
 
 type Route struct{}
 
+type Neighbor struct{}
+
 // LinkInfo respresents the common properties of all network interfaces.
 type LinkInfo struct {
 	Type string
@@ -85,3 +87,7 @@ func (Netlink) AddIPRoute(route *Route) error {
 func (Netlink) DeleteIPRoute(route *Route) error {
 	return nil
 }
+
+func (Netlink) GetNeighbors(linkIndex, family int) ([]*Neighbor, error) {
+	return nil, nil
+}
@@ -24,4 +24,5 @@ type NetlinkInterface interface {
 	GetIPRoute(filter *Route) ([]*Route, error)
 	AddIPRoute(route *Route) error
 	DeleteIPRoute(route *Route) error
+	GetNeighbors(linkIndex, family int) ([]*Neighbor, error)
 }
@@ -15,7 +15,11 @@ import (
 	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/network/policy"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -23,7 +27,10 @@ const (
 	InfraVnet = 0
 )
 
-var logger = log.CNILogger.With(zap.String("component", "net"))
+var (
+	logger = log.CNILogger.With(zap.String("component", "net"))
+	tracer = otel.Tracer("github.com/Azure/azure-container-networking/network")
+)
 
 type AzureHNSEndpoint struct{}
 
@@ -48,6 +55,7 @@ type endpoint struct {
 	EnableMultitenancy       bool
 	AllowInboundFromHostToNC bool
 	AllowInboundFromNCToHost bool
+	SNATBackend              string `json:",omitempty"`
 	NetworkContainerID       string
 	NetworkNameSpace         string `json:",omitempty"`
 	ContainerID              string
@@ -61,6 +69,22 @@ type endpoint struct {
 	NICType cns.NICType
 }
 
+// Kinds of the endpoint datapath repairs.
+const (
+	RepairHostRoute     = "host-route"
+	RepairAddress       = "address"
+	RepairRoute         = "route"
+	RepairNeighbor      = "neighbor"
+	RepairEndpointRules = "endpoint-rules"
+	RepairSnatRules     = "snat-rules"
+)
+
+// EndpointRepair describes a part of the endpoint datapath which was missing and has been re-applied.
+type EndpointRepair struct {
+	Kind   string
+	Detail string
+}
+
 // EndpointInfo contains read-only information about an endpoint.
 type EndpointInfo struct {
 	EndpointID               string
@@ -84,6 +108,7 @@ type EndpointInfo struct {
 	EnableSnatForDns         bool
 	AllowInboundFromHostToNC bool
 	AllowInboundFromNCToHost bool
+	SNATBackend              string
 	NetworkContainerID       string
 	PODName                  string
 	PODNameSpace             string
@@ -188,6 +213,7 @@ func (ifInfo *InterfaceInfo) PrettyString() string {
 
 // NewEndpoint creates a new endpoint in the network.
 func (nw *network) newEndpoint(
+	ctx context.Context,
 	apipaCli apipaClient,
 	nl netlink.NetlinkInterface,
 	plc platform.ExecClient,
@@ -200,15 +226,21 @@ func (nw *network) newEndpoint(
 	var ep *endpoint
 	var err error
 
+	ctx, span := tracer.Start(ctx, "network.CreateEndpoint", trace.WithAttributes(
+		attribute.String("network.endpoint.id", epInfo.EndpointID),
+		attribute.String("network.nic.type", string(epInfo.NICType)),
+		attribute.String("network.mode", epInfo.Mode),
+	))
 	defer func() {
 		if err != nil {
 			logger.Error("Failed to create endpoint with err", zap.String("id", epInfo.EndpointID), zap.Error(err))
 		}
+		tracing.End(span, err)
 	}()
 
 	// Call the platform implementation.
 	// Pass nil for epClient and will be initialized in newendpointImpl
-	ep, err = nw.newEndpointImpl(apipaCli, nl, plc, netioCli, nil, nsc, iptc, dhcpc, epInfo)
+	ep, err = nw.newEndpointImpl(ctx, apipaCli, nl, plc, netioCli, nil, nsc, iptc, dhcpc, epInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +251,14 @@ func (nw *network) newEndpoint(
 	return ep, nil
 }
 
+// step runs a step of the endpoint creation, e.g. programming the veth pair or the iptables rules, in a span of its own
+// so that the trace of a pod network setup shows which step failed or took the time.
+func step(ctx context.Context, name string, fn func() error) (err error) {
+	_, span := tracer.Start(ctx, name)
+	defer func() { tracing.End(span, err) }()
+	return fn()
+}
+
 // DeleteEndpoint deletes an existing endpoint from the network.
 func (nw *network) deleteEndpoint(nl netlink.NetlinkInterface, plc platform.ExecClient, nioc netio.NetIOInterface, nsc NamespaceClientInterface,
 	iptc ipTablesClient, dhcpc dhcpClient, endpointID string, mode string,
@@ -316,6 +356,7 @@ func (ep *endpoint) getInfo() *EndpointInfo {
 		EnableMultiTenancy:       ep.EnableMultitenancy,
 		AllowInboundFromHostToNC: ep.AllowInboundFromHostToNC,
 		AllowInboundFromNCToHost: ep.AllowInboundFromNCToHost,
+		SNATBackend:              ep.SNATBackend,
 		IfName:                   ep.IfName,
 		ContainerID:              ep.ContainerID,
 		NetNsPath:                ep.NetworkNameSpace,
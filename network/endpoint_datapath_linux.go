@@ -0,0 +1,430 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/snat"
+	"github.com/Azure/azure-container-networking/ovsctl"
+	"go.uber.org/zap"
+)
+
+func newErrDatapathInvalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrDatapathInvalid, fmt.Sprintf(format, args...))
+}
+
+// validateEndpointImpl checks that the host veth and its routes, and the container interface with its
+// addresses, routes, and static ARP entry are still programmed for the endpoint.
+func (nm *networkManager) validateEndpointImpl(nw *network, epInfo *EndpointInfo) error {
+	_, err := nm.checkEndpointDatapath(nw, epInfo, false)
+	return err
+}
+
+// reconcileEndpointImpl re-applies the parts of the endpoint datapath which are missing.
+// The veth pair itself can only be recreated by the container runtime, so a missing interface is returned as ErrDatapathInvalid.
+func (nm *networkManager) reconcileEndpointImpl(nw *network, epInfo *EndpointInfo) ([]EndpointRepair, error) {
+	return nm.checkEndpointDatapath(nw, epInfo, true)
+}
+
+// datapathChecker walks the datapath of an endpoint and either reports or repairs what is missing.
+type datapathChecker struct {
+	nm      *networkManager
+	nw      *network
+	epInfo  *EndpointInfo
+	repair  bool
+	repairs []EndpointRepair
+}
+
+// fix repairs the missing part of the datapath with apply, or returns ErrDatapathInvalid when the checker only validates.
+func (c *datapathChecker) fix(kind, detail string, apply func() error) error {
+	if !c.repair {
+		return newErrDatapathInvalid("missing %s", detail)
+	}
+
+	logger.Info("Repairing endpoint datapath", zap.String("endpointID", c.epInfo.EndpointID),
+		zap.String("kind", kind), zap.String("detail", detail))
+	if err := apply(); err != nil {
+		return fmt.Errorf("failed to repair %s: %w", detail, err)
+	}
+
+	c.repairs = append(c.repairs, EndpointRepair{Kind: kind, Detail: detail})
+	return nil
+}
+
+// Only the infra nic is checked, the other nic types are owned by the VM or the node network.
+func (nm *networkManager) checkEndpointDatapath(nw *network, epInfo *EndpointInfo, repair bool) ([]EndpointRepair, error) {
+	if epInfo.NICType != "" && epInfo.NICType != cns.InfraNIC {
+		return nil, nil
+	}
+
+	c := &datapathChecker{nm: nm, nw: nw, epInfo: epInfo, repair: repair}
+
+	// the host veth of the transparent vlan mode lives in the vnet namespace, not in the host namespace
+	var hostIf *net.Interface
+	if nw.Mode != opModeTransparentVlan && epInfo.HostIfName != "" {
+		var err error
+		if hostIf, err = c.checkHost(); err != nil {
+			return c.repairs, err
+		}
+	}
+
+	if err := c.checkSnat(); err != nil {
+		return c.repairs, err
+	}
+
+	// the vnet rules of the transparent vlan mode aren't observable from the host namespace,
+	// its endpoint client programs them idempotently, so they are re-applied on every pass
+	if repair && nw.Mode == opModeTransparentVlan {
+		if err := c.reapplyTransparentVlanRules(); err != nil {
+			return c.repairs, err
+		}
+	}
+
+	if epInfo.NetNsPath == "" {
+		return c.repairs, nil
+	}
+
+	logger.Info("Opening netns to check endpoint", zap.String("netns", epInfo.NetNsPath))
+	ns, err := nm.nsClient.OpenNamespace(epInfo.NetNsPath)
+	if err != nil {
+		return c.repairs, newErrDatapathInvalid("failed to open netns %s: %v", epInfo.NetNsPath, err)
+	}
+	defer ns.Close()
+
+	if err := ns.Enter(); err != nil {
+		return c.repairs, fmt.Errorf("failed to enter netns %s: %w", epInfo.NetNsPath, err)
+	}
+	defer func() {
+		if err := ns.Exit(); err != nil {
+			logger.Error("Failed to exit netns", zap.String("netns", epInfo.NetNsPath), zap.Error(err))
+		}
+	}()
+
+	err = c.checkContainer(hostIf)
+	return c.repairs, err
+}
+
+// checkHost checks that the host veth exists and, in transparent mode, that the pod IPs are routed to it.
+func (c *datapathChecker) checkHost() (*net.Interface, error) {
+	epInfo := c.epInfo
+	hostIf, err := c.nm.netio.GetNetworkInterfaceByName(epInfo.HostIfName)
+	if err != nil {
+		return nil, newErrDatapathInvalid("host interface %s not found: %v", epInfo.HostIfName, err)
+	}
+
+	if c.nw.Mode == opModeTransparent {
+		// ip route show <podip> dev <hostveth>
+		var missing []string
+		for _, ipAddr := range epInfo.IPAddresses {
+			ipNet := net.IPNet{IP: ipAddr.IP, Mask: net.CIDRMask(ipv6FullMask, ipv6Bits)}
+			if ipAddr.IP.To4() != nil {
+				ipNet = net.IPNet{IP: ipAddr.IP, Mask: net.CIDRMask(ipv4FullMask, ipv4Bits)}
+			}
+
+			found, err := c.nm.hasRoute(hostIf.Index, RouteInfo{Dst: ipNet})
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				missing = append(missing, ipNet.String())
+			}
+		}
+
+		if len(missing) > 0 {
+			detail := fmt.Sprintf("routes to %s on host interface %s", strings.Join(missing, ","), epInfo.HostIfName)
+			err := c.fix(RepairHostRoute, detail, func() error {
+				client := NewTransparentEndpointClient(c.nw.extIf, epInfo.HostIfName, epInfo.IfName, c.nw.Mode, c.nm.netlink, c.nm.netio, c.nm.plClient)
+				return client.AddEndpointRules(epInfo)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return hostIf, nil
+	}
+
+	// the ovs client adds the host veth to the ovs bridge together with the flows of the endpoint
+	if ep := c.nw.Endpoints[epInfo.EndpointID]; ep != nil && ep.VlanID != 0 {
+		ovs := ovsctl.NewOvsctl()
+		if _, err := ovs.GetOVSPortNumber(epInfo.HostIfName); err != nil {
+			detail := fmt.Sprintf("ovs port of host interface %s", epInfo.HostIfName)
+			err := c.fix(RepairEndpointRules, detail, func() error {
+				client := NewOVSEndpointClient(c.nw, epInfo, epInfo.HostIfName, epInfo.IfName, ep.VlanID, ep.LocalIP,
+					c.nm.netlink, ovs, c.nm.plClient, c.nm.iptablesClient)
+				client.containerMac = epInfo.MacAddress.String()
+				return client.AddEndpointRules(epInfo)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return hostIf, nil
+}
+
+// checkSnat checks the host snat rules of the ovs and transparent vlan endpoints, which something else
+// e.g. a firewall reload may have flushed.
+func (c *datapathChecker) checkSnat() error {
+	epInfo := c.epInfo
+	ep := c.nw.Endpoints[epInfo.EndpointID]
+	if ep == nil || ep.VlanID == 0 || c.nw.SnatBridgeIP == "" {
+		return nil
+	}
+	if !epInfo.EnableSnatOnHost && !epInfo.AllowInboundFromHostToNC && !epInfo.AllowInboundFromNCToHost && !epInfo.EnableSnatForDns {
+		return nil
+	}
+
+	// the snat veth and its static arp entries aren't touched, so the mac and proxy arp don't matter
+	client := snat.NewSnatClient(GetSnatHostIfName(epInfo), GetSnatContIfName(epInfo), ep.LocalIP, c.nw.SnatBridgeIP, "",
+		epInfo.EndpointDNS.Servers, false, c.nm.netlink, c.nm.plClient, c.nm.iptablesClient, c.nm.netio, epInfo.SNATBackend)
+	missing, err := client.MissingRules(epInfo.AllowInboundFromHostToNC, epInfo.AllowInboundFromNCToHost)
+	if err != nil {
+		return fmt.Errorf("failed to check snat rules: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	detail := "snat rules " + strings.Join(missing, ", ")
+	return c.fix(RepairSnatRules, detail, func() error {
+		return client.ReapplyRules(epInfo.AllowInboundFromHostToNC, epInfo.AllowInboundFromNCToHost)
+	})
+}
+
+func (c *datapathChecker) reapplyTransparentVlanRules() error {
+	ep := c.nw.Endpoints[c.epInfo.EndpointID]
+	if ep == nil || c.nw.extIf == nil {
+		return nil
+	}
+
+	client := NewTransparentVlanEndpointClient(c.nw, c.epInfo, c.epInfo.HostIfName, c.epInfo.IfName, ep.VlanID, ep.LocalIP,
+		c.nm.netlink, c.nm.plClient, c.nm.nsClient, c.nm.iptablesClient)
+	if err := client.AddEndpointRules(c.epInfo); err != nil {
+		return fmt.Errorf("failed to re-apply transparent vlan endpoint rules: %w", err)
+	}
+	return nil
+}
+
+// checkContainer checks the container side of the endpoint. It must be called in the container netns.
+func (c *datapathChecker) checkContainer(hostIf *net.Interface) error {
+	epInfo := c.epInfo
+	containerIf, err := c.nm.netio.GetNetworkInterfaceByName(epInfo.IfName)
+	if err != nil {
+		return newErrDatapathInvalid("container interface %s not found: %v", epInfo.IfName, err)
+	}
+
+	if len(epInfo.MacAddress) > 0 && !bytes.Equal(containerIf.HardwareAddr, epInfo.MacAddress) {
+		return newErrDatapathInvalid("container interface %s has mac %s, expected %s", epInfo.IfName, containerIf.HardwareAddr, epInfo.MacAddress)
+	}
+
+	addrs, err := c.nm.netio.GetNetworkInterfaceAddrs(containerIf)
+	if err != nil {
+		return fmt.Errorf("failed to get addresses of container interface %s: %w", epInfo.IfName, err)
+	}
+
+	for i := range epInfo.IPAddresses {
+		ipAddr := epInfo.IPAddresses[i]
+		if containsIP(addrs, ipAddr.IP) {
+			continue
+		}
+
+		detail := fmt.Sprintf("address %s on container interface %s", ipAddr.String(), epInfo.IfName)
+		if err := c.fix(RepairAddress, detail, func() error { return c.addContainerAddress(ipAddr) }); err != nil {
+			return err
+		}
+	}
+
+	// the routes of the transparent vlan mode depend on options which aren't kept in the endpoint state
+	if c.nw.Mode == opModeTransparentVlan {
+		return nil
+	}
+
+	routes := epInfo.Routes
+	if c.nw.Mode == opModeTransparent {
+		found, err := c.checkVirtualGateway(containerIf, hostIf)
+		if err != nil {
+			return err
+		}
+		// the transparent mode routes everything via the virtual gateway unless the default routes are skipped
+		if found {
+			routes = nil
+		}
+	}
+
+	for _, route := range routes {
+		ifName := epInfo.IfName
+		ifIndex := containerIf.Index
+		if route.DevName != "" {
+			devIf, err := c.nm.netio.GetNetworkInterfaceByName(route.DevName)
+			if err != nil {
+				return newErrDatapathInvalid("route interface %s not found: %v", route.DevName, err)
+			}
+			ifName = route.DevName
+			ifIndex = devIf.Index
+		}
+
+		found, err := c.nm.hasRoute(ifIndex, route)
+		if err != nil {
+			return err
+		}
+		if found {
+			continue
+		}
+
+		detail := fmt.Sprintf("route to %s via %s on %s", route.Dst.String(), route.Gw.String(), ifName)
+		if err := c.fix(RepairRoute, detail, func() error {
+			return addRoutes(c.nm.netlink, c.nm.netio, epInfo.IfName, []RouteInfo{route})
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addContainerAddress assigns the address to the container interface the way the endpoint clients do,
+// removing the subnet route which the kernel adds with it.
+func (c *datapathChecker) addContainerAddress(ipAddr net.IPNet) error {
+	nu := networkutils.NewNetworkUtils(c.nm.netlink, c.nm.plClient)
+	if err := nu.AssignIPToInterface(c.epInfo.IfName, []net.IPNet{ipAddr}); err != nil {
+		return err
+	}
+
+	if c.nw.Mode != opModeTransparent && c.nw.Mode != opModeTransparentVlan {
+		return nil
+	}
+
+	// ip route del 10.240.0.0/12 dev eth0
+	_, ipnet, _ := net.ParseCIDR(ipAddr.String())
+	routeInfo := RouteInfo{
+		Dst:      *ipnet,
+		Scope:    netlink.RT_SCOPE_LINK,
+		Protocol: netlink.RTPROT_KERNEL,
+	}
+	return deleteRoutes(c.nm.netlink, c.nm.netio, c.epInfo.IfName, []RouteInfo{routeInfo})
+}
+
+// checkVirtualGateway checks the routes via the virtual gateway of the transparent mode and its static ARP entry,
+// which resolves to the mac of the host veth. It returns whether the default route via the virtual gateway exists.
+func (c *datapathChecker) checkVirtualGateway(containerIf, hostIf *net.Interface) (bool, error) {
+	ifName := c.epInfo.IfName
+	virtualGwIP, virtualGwNet, _ := net.ParseCIDR(virtualGwIPString)
+
+	// ip route add 169.254.1.1/32 dev eth0
+	gwRoute := RouteInfo{Dst: *virtualGwNet, Scope: netlink.RT_SCOPE_LINK}
+	found, err := c.nm.hasRoute(containerIf.Index, gwRoute)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		detail := fmt.Sprintf("route to virtual gateway %s on %s", virtualGwNet.String(), ifName)
+		if err := c.fix(RepairRoute, detail, func() error {
+			return addRoutes(c.nm.netlink, c.nm.netio, ifName, []RouteInfo{gwRoute})
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	// ip route add default via 169.254.1.1 dev eth0
+	_, defaultIPNet, _ := net.ParseCIDR(defaultGwCidr)
+	defaultRoute := RouteInfo{Dst: net.IPNet{IP: net.ParseIP(defaultGw), Mask: defaultIPNet.Mask}, Gw: virtualGwIP}
+	hasDefaultRoute, err := c.nm.hasRoute(containerIf.Index, defaultRoute)
+	if err != nil {
+		return false, err
+	}
+	if !hasDefaultRoute && len(c.epInfo.Routes) == 0 {
+		detail := fmt.Sprintf("default route via virtual gateway %s on %s", virtualGwIP.String(), ifName)
+		if err := c.fix(RepairRoute, detail, func() error {
+			return addRoutes(c.nm.netlink, c.nm.netio, ifName, []RouteInfo{defaultRoute})
+		}); err != nil {
+			return false, err
+		}
+		hasDefaultRoute = true
+	}
+
+	// arp -s 169.254.1.1 <hostveth mac>
+	neigh, err := c.nm.findNeighbor(containerIf.Index, virtualGwIP)
+	if err != nil {
+		return false, fmt.Errorf("failed to get neighbors of container interface %s: %w", ifName, err)
+	}
+	if hostIf == nil {
+		if neigh == nil {
+			return false, newErrDatapathInvalid("neighbor entry for %s not found on container interface %s", virtualGwIP.String(), ifName)
+		}
+		return hasDefaultRoute, nil
+	}
+	if neigh == nil || !bytes.Equal(neigh.HardwareAddr, hostIf.HardwareAddr) {
+		detail := fmt.Sprintf("neighbor %s with mac %s on %s", virtualGwIP.String(), hostIf.HardwareAddr, ifName)
+		linkInfo := netlink.LinkInfo{Name: ifName, IPAddr: virtualGwIP, MacAddress: hostIf.HardwareAddr}
+		if err := c.fix(RepairNeighbor, detail, func() error {
+			return c.nm.netlink.SetOrRemoveLinkAddress(linkInfo, netlink.ADD, netlink.NUD_PROBE)
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	return hasDefaultRoute, nil
+}
+
+// findNeighbor returns the neighbor entry for the ip on the link, nil if there is none.
+func (nm *networkManager) findNeighbor(ifIndex int, ip net.IP) (*netlink.Neighbor, error) {
+	neighbors, err := nm.netlink.GetNeighbors(ifIndex, netlink.GetIPAddressFamily(ip))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by the caller
+	}
+
+	for _, neigh := range neighbors {
+		if neigh.IP.Equal(ip) {
+			return neigh, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasRoute returns whether a route to the destination of the route info exists on the link.
+func (nm *networkManager) hasRoute(ifIndex int, route RouteInfo) (bool, error) {
+	family := netlink.GetIPAddressFamily(route.Gw)
+	if route.Gw == nil {
+		family = netlink.GetIPAddressFamily(route.Dst.IP)
+	}
+
+	filter := &netlink.Route{
+		Family:    family,
+		Dst:       &route.Dst,
+		LinkIndex: ifIndex,
+		Protocol:  route.Protocol,
+		Table:     route.Table,
+	}
+
+	routes, err := nm.netlink.GetIPRoute(filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to get routes to %s: %w", route.Dst.String(), err)
+	}
+
+	for _, r := range routes {
+		if route.Gw == nil || route.Gw.Equal(r.Gw) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func containsIP(addrs []net.Addr, ip net.IP) bool {
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
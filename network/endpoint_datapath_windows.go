@@ -0,0 +1,41 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/Microsoft/hcsshim"
+	"github.com/Microsoft/hcsshim/hcn"
+)
+
+// validateEndpointImpl checks that the HNS endpoint of the endpoint still exists.
+// The routes and neighbors of the container are owned by HNS, so they aren't validated.
+func (nm *networkManager) validateEndpointImpl(_ *network, epInfo *EndpointInfo) error {
+	if epInfo.HNSEndpointID == "" {
+		return nil
+	}
+
+	var err error
+	if useHnsV2, _ := UseHnsV2(epInfo.NetNsPath); useHnsV2 {
+		_, err = Hnsv2.GetEndpointByID(epInfo.HNSEndpointID)
+	} else {
+		_, err = Hnsv1.GetHNSEndpointByID(epInfo.HNSEndpointID)
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case hcn.IsNotFoundError(err) || hcsshim.IsNotExist(err):
+		return fmt.Errorf("%w: hns endpoint %s not found: %v", ErrDatapathInvalid, epInfo.HNSEndpointID, err)
+	default:
+		return fmt.Errorf("failed to get hns endpoint %s: %w", epInfo.HNSEndpointID, err)
+	}
+}
+
+// reconcileEndpointImpl only validates the endpoint, HNS owns the datapath of the endpoints and
+// a missing HNS endpoint can only be recreated by the container runtime.
+func (nm *networkManager) reconcileEndpointImpl(nw *network, epInfo *EndpointInfo) ([]EndpointRepair, error) {
+	return nil, nm.validateEndpointImpl(nw, epInfo)
+}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"net"
 	"testing"
 
@@ -166,7 +167,7 @@ var _ = Describe("Test TestEndpointLinux", func() {
 			pl := platform.NewMockExecClient(false)
 			pl.SetExecRawCommand(checkTransparentRun)
 
-			ep, err := nw2.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), pl,
+			ep, err := nw2.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), pl,
 				netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo2)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(ep).NotTo(BeNil())
@@ -183,7 +184,7 @@ var _ = Describe("Test TestEndpointLinux", func() {
 			nl := netlink.NewMockNetlink(false, "")
 			nl.SetDeleteRouteValidationFn(checkTransparentRun)
 
-			ep2, err := nw2.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+			ep2, err := nw2.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 				netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo2)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(ep2).ToNot(BeNil())
@@ -194,4 +195,143 @@ var _ = Describe("Test TestEndpointLinux", func() {
 			Expect(transparentRun).To(BeTrue())
 		})
 	})
+
+	Describe("Test validateEndpointImpl and reconcileEndpointImpl", func() {
+		var (
+			nl    *netlink.MockNetlink
+			nioc  *netio.MockNetIO
+			nm    *networkManager
+			nw    *network
+			ep    *EndpointInfo
+			podIP = net.ParseIP("10.0.0.4")
+			gwIP  = net.ParseIP("169.254.1.1")
+		)
+
+		BeforeEach(func() {
+			nl = netlink.NewMockNetlink(false, "")
+			nl.GetIPRouteFn = func(filter *netlink.Route) ([]*netlink.Route, error) {
+				return []*netlink.Route{{Dst: filter.Dst, Gw: gwIP, LinkIndex: filter.LinkIndex}}, nil
+			}
+			nl.GetNeighborsFn = func(linkIndex, _ int) ([]*netlink.Neighbor, error) {
+				return []*netlink.Neighbor{{LinkIndex: linkIndex, IP: gwIP, HardwareAddr: netio.HwAddr}}, nil
+			}
+			nioc = netio.NewMockNetIO(false, 0)
+			nioc.SetGetInterfaceAddrsFn(func(*net.Interface) ([]net.Addr, error) {
+				return []net.Addr{&net.IPNet{IP: podIP, Mask: net.CIDRMask(24, 32)}}, nil
+			})
+			nm = &networkManager{netlink: nl, netio: nioc, nsClient: NewMockNamespaceClient()}
+			nw = &network{Mode: opModeTransparent, extIf: &externalInterface{Name: "eth0"}}
+			ep = &EndpointInfo{
+				IfName:      eth0IfName,
+				HostIfName:  "azv768e8de",
+				NetNsPath:   "/var/run/netns/test",
+				MacAddress:  netio.HwAddr,
+				IPAddresses: []net.IPNet{{IP: podIP, Mask: net.CIDRMask(24, 32)}},
+				NICType:     cns.InfraNIC,
+			}
+		})
+
+		It("Should succeed when the datapath is programmed", func() {
+			Expect(nm.validateEndpointImpl(nw, ep)).To(Succeed())
+		})
+		It("Should fail when the host route to the pod is missing", func() {
+			nl.GetIPRouteFn = func(filter *netlink.Route) ([]*netlink.Route, error) {
+				if filter.Dst.IP.Equal(podIP) {
+					return nil, nil
+				}
+				return []*netlink.Route{{Dst: filter.Dst, Gw: gwIP}}, nil
+			}
+			Expect(nm.validateEndpointImpl(nw, ep)).To(MatchError(ErrDatapathInvalid))
+		})
+		It("Should fail when the container interface has a different mac", func() {
+			ep.MacAddress = netio.BadHwAddr
+			Expect(nm.validateEndpointImpl(nw, ep)).To(MatchError(ErrDatapathInvalid))
+		})
+		It("Should fail when the container interface doesn't have the pod ip", func() {
+			nioc.SetGetInterfaceAddrsFn(func(*net.Interface) ([]net.Addr, error) {
+				return []net.Addr{}, nil
+			})
+			Expect(nm.validateEndpointImpl(nw, ep)).To(MatchError(ErrDatapathInvalid))
+		})
+		It("Should fail when the virtual gateway resolves to another mac", func() {
+			nl.GetNeighborsFn = func(linkIndex, _ int) ([]*netlink.Neighbor, error) {
+				return []*netlink.Neighbor{{LinkIndex: linkIndex, IP: gwIP, HardwareAddr: netio.BadHwAddr}}, nil
+			}
+			Expect(nm.validateEndpointImpl(nw, ep)).To(MatchError(ErrDatapathInvalid))
+		})
+		It("Should check the endpoint routes in bridge mode", func() {
+			_, dst, _ := net.ParseCIDR("10.1.0.0/16")
+			ep.Routes = []RouteInfo{{Dst: *dst, Gw: net.ParseIP("10.0.0.1")}}
+			nw.Mode = opModeBridge
+			Expect(nm.validateEndpointImpl(nw, ep)).To(MatchError(ErrDatapathInvalid))
+
+			ep.Routes[0].Gw = gwIP
+			Expect(nm.validateEndpointImpl(nw, ep)).To(Succeed())
+		})
+		It("Should not report a netlink failure as an invalid datapath", func() {
+			nm.netlink = netlink.NewMockNetlink(true, "")
+			err := nm.validateEndpointImpl(nw, ep)
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, ErrDatapathInvalid)).To(BeFalse())
+		})
+		It("Should not repair anything when the datapath is programmed", func() {
+			nm.plClient = platform.NewMockExecClient(false)
+			repairs, err := nm.reconcileEndpointImpl(nw, ep)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repairs).To(BeEmpty())
+		})
+		It("Should re-apply the missing host route and neighbor entry", func() {
+			nm.plClient = platform.NewMockExecClient(false)
+			hostRouteMissing := true
+			nl.GetIPRouteFn = func(filter *netlink.Route) ([]*netlink.Route, error) {
+				if filter.Dst.IP.Equal(podIP) && hostRouteMissing {
+					return nil, nil
+				}
+				return []*netlink.Route{{Dst: filter.Dst, Gw: gwIP}}, nil
+			}
+			nl.SetAddRouteValidationFn(func(r *netlink.Route) error {
+				Expect(r.Dst.String()).To(Equal("10.0.0.4/32"))
+				hostRouteMissing = false
+				return nil
+			})
+			nl.GetNeighborsFn = func(int, int) ([]*netlink.Neighbor, error) {
+				return nil, nil
+			}
+			var neighbor netlink.LinkInfo
+			nl.SetOrRemoveLinkAddressFn = func(linkInfo netlink.LinkInfo, mode, _ int) error {
+				Expect(mode).To(Equal(netlink.ADD))
+				neighbor = linkInfo
+				return nil
+			}
+
+			repairs, err := nm.reconcileEndpointImpl(nw, ep)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repairs).To(HaveLen(2))
+			Expect(repairs[0].Kind).To(Equal(RepairHostRoute))
+			Expect(repairs[1].Kind).To(Equal(RepairNeighbor))
+			Expect(hostRouteMissing).To(BeFalse())
+			Expect(neighbor.IPAddr.Equal(gwIP)).To(BeTrue())
+			Expect(neighbor.MacAddress).To(Equal(netio.HwAddr))
+		})
+		It("Should re-assign a missing address", func() {
+			nm.plClient = platform.NewMockExecClient(false)
+			nioc.SetGetInterfaceAddrsFn(func(*net.Interface) ([]net.Addr, error) {
+				return []net.Addr{}, nil
+			})
+			repairs, err := nm.reconcileEndpointImpl(nw, ep)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repairs).To(Equal([]EndpointRepair{{Kind: RepairAddress, Detail: "address 10.0.0.4/24 on container interface eth0"}}))
+		})
+		It("Should not repair a missing container interface", func() {
+			nioc.SetGetInterfaceValidatonFn(func(name string) (*net.Interface, error) {
+				if name == eth0IfName {
+					return nil, netio.ErrMockNetIOFail
+				}
+				return &net.Interface{Name: name, HardwareAddr: netio.HwAddr, Index: 2}, nil
+			})
+			repairs, err := nm.reconcileEndpointImpl(nw, ep)
+			Expect(err).To(MatchError(ErrDatapathInvalid))
+			Expect(repairs).To(BeEmpty())
+		})
+	})
 })
@@ -4,6 +4,7 @@
 package network
 
 import (
+	"context"
 	"net"
 	"testing"
 
@@ -211,7 +212,7 @@ var _ = Describe("Test Endpoint", func() {
 
 			It("Should be added", func() {
 				// Add endpoint with valid id
-				ep, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ep).NotTo(BeNil())
@@ -223,7 +224,7 @@ var _ = Describe("Test Endpoint", func() {
 					Endpoints: map[string]*endpoint{},
 					extIf:     &externalInterface{IPv4Gateway: net.ParseIP("192.168.0.1")},
 				}
-				ep, err := nw2.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw2.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ep).NotTo(BeNil())
@@ -241,7 +242,7 @@ var _ = Describe("Test Endpoint", func() {
 				err := mockCli.AddEndpoints(epInfo)
 				Expect(err).ToNot(HaveOccurred())
 				// Adding endpoint with same id should fail and delete should cleanup the state
-				ep2, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep2, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), mockCli, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).To(HaveOccurred())
 				Expect(ep2).To(BeNil())
@@ -251,7 +252,7 @@ var _ = Describe("Test Endpoint", func() {
 			It("Should be deleted", func() {
 				// Adding an endpoint with an id.
 				mockCli := NewMockEndpointClient(nil)
-				ep2, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep2, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), mockCli, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(ep2).ToNot(BeNil())
@@ -283,7 +284,7 @@ var _ = Describe("Test Endpoint", func() {
 					Endpoints: map[string]*endpoint{},
 					extIf:     &externalInterface{IPv4Gateway: net.ParseIP("192.168.0.1")},
 				}
-				ep, err := nw2.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw2.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ep).NotTo(BeNil())
@@ -305,7 +306,7 @@ var _ = Describe("Test Endpoint", func() {
 					IfName:     eth0IfName,
 					NICType:    cns.InfraNIC,
 				}
-				ep, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), NewMockEndpointClient(func(ep *EndpointInfo) error {
 						if ep.NICType == cns.InfraNIC {
 							return NewErrorMockEndpointClient("AddEndpoints Infra NIC failed")
@@ -315,7 +316,7 @@ var _ = Describe("Test Endpoint", func() {
 					}), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).To(HaveOccurred())
 				Expect(ep).To(BeNil())
-				ep, err = nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err = nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ep).NotTo(BeNil())
@@ -346,14 +347,14 @@ var _ = Describe("Test Endpoint", func() {
 
 			It("Should not add endpoint to the network when there is an error", func() {
 				secondaryEpInfo.MacAddress = netio.BadHwAddr // mock netlink will fail to set link state on bad eth
-				ep, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, secondaryEpInfo)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("SecondaryEndpointClient Error: " + netlink.ErrorMockNetlink.Error()))
 				Expect(ep).To(BeNil())
 				// should not panic or error when going through the unified endpoint impl flow with only the delegated nic type fields
 				secondaryEpInfo.MacAddress = netio.HwAddr
-				ep, err = nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err = nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, secondaryEpInfo)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(ep.Id).To(Equal(epInfo.EndpointID))
@@ -361,12 +362,12 @@ var _ = Describe("Test Endpoint", func() {
 
 			It("Should add endpoint when there are no errors", func() {
 				secondaryEpInfo.MacAddress = netio.HwAddr
-				ep, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, secondaryEpInfo)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(ep.Id).To(Equal(epInfo.EndpointID))
 
-				ep, err = nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+				ep, err = nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 					netio.NewMockNetIO(false, 0), nil, NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(ep.Id).To(Equal(epInfo.EndpointID))
@@ -143,6 +143,7 @@ func (nw *network) getEndpointWithVFDevice(plc platform.ExecClient, epInfo *Endp
 
 // newEndpointImpl creates a new endpoint in the network.
 func (nw *network) newEndpointImpl(
+	ctx context.Context,
 	cli apipaClient,
 	_ netlink.NetlinkInterface,
 	plc platform.ExecClient,
@@ -157,8 +158,13 @@ func (nw *network) newEndpointImpl(
 		return nw.getEndpointWithVFDevice(plc, epInfo)
 	}
 
+	var ep *endpoint
 	if epInfo.NICType == cns.ApipaNIC {
-		return nw.createHostNCApipaEndpoint(cli, epInfo)
+		err := step(ctx, "network.CreateHostNCApipaEndpoint", func() (err error) {
+			ep, err = nw.createHostNCApipaEndpoint(cli, epInfo)
+			return err
+		})
+		return ep, err
 	}
 
 	if useHnsV2, err := UseHnsV2(epInfo.NetNsPath); useHnsV2 {
@@ -166,11 +172,18 @@ func (nw *network) newEndpointImpl(
 			return nil, err
 		}
 
-		return nw.newEndpointImplHnsV2(cli, epInfo)
-
+		err = step(ctx, "network.CreateHNSEndpoint", func() (err error) {
+			ep, err = nw.newEndpointImplHnsV2(cli, epInfo)
+			return err
+		})
+		return ep, err
 	}
 
-	return nw.newEndpointImplHnsV1(epInfo, plc)
+	err := step(ctx, "network.CreateHNSEndpoint", func() (err error) {
+		ep, err = nw.newEndpointImplHnsV1(epInfo, plc)
+		return err
+	})
+	return ep, err
 }
 
 // newEndpointImplHnsV1 creates a new endpoint in the network using HnsV1
@@ -7,6 +7,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -493,7 +494,7 @@ func TestNewEndpointImplHnsv2ForIBHappyPath(t *testing.T) {
 	}
 
 	// Happy Path
-	endpoint, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
+	endpoint, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(false),
 		netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 
 	if endpoint != nil || err != nil {
@@ -523,7 +524,7 @@ func TestNewEndpointImplHnsv2ForIBUnHappyPath(t *testing.T) {
 	}
 
 	// Set UnHappy Path
-	_, err := nw.newEndpointImpl(nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(true),
+	_, err := nw.newEndpointImpl(context.Background(), nil, netlink.NewMockNetlink(false, ""), platform.NewMockExecClient(true),
 		netio.NewMockNetIO(false, 0), NewMockEndpointClient(nil), NewMockNamespaceClient(), iptables.NewClient(), &mockDHCP{}, epInfo)
 
 	if err == nil {
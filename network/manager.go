@@ -106,7 +106,7 @@ type NetworkManager interface {
 	GetNumEndpointsByContainerID(containerID string) int
 
 	CreateEndpoint(client apipaClient, networkID string, epInfo *EndpointInfo) error
-	EndpointCreate(client apipaClient, epInfos []*EndpointInfo) error // TODO: change name
+	EndpointCreate(ctx context.Context, client apipaClient, epInfos []*EndpointInfo) error // TODO: change name
 	DeleteEndpoint(networkID string, endpointID string, epInfo *EndpointInfo, mode string) error
 	GetEndpointInfo(networkID string, endpointID string) (*EndpointInfo, error)
 	GetAllEndpoints(networkID string) (map[string]*EndpointInfo, error)
@@ -114,6 +114,10 @@ type NetworkManager interface {
 	AttachEndpoint(networkID string, endpointID string, sandboxKey string) (*endpoint, error)
 	DetachEndpoint(networkID string, endpointID string) error
 	UpdateEndpoint(networkID string, existingEpInfo *EndpointInfo, targetEpInfo *EndpointInfo) error
+	// ValidateEndpoint checks that the datapath of the endpoint is programmed as expected, ErrDatapathInvalid if it isn't
+	ValidateEndpoint(networkID string, epInfo *EndpointInfo) error
+	// ReconcileEndpoint re-applies the missing parts of the endpoint datapath and returns what was repaired
+	ReconcileEndpoint(networkID string, epInfo *EndpointInfo) ([]EndpointRepair, error)
 	GetNumberOfEndpoints(ifName string, networkID string) int
 	GetEndpointID(containerID, ifName string) string
 	IsStatelessCNIMode() bool
@@ -234,7 +238,8 @@ func (nm *networkManager) restore(isRehydrationRequired bool) error {
 						delete(nm.ExternalInterfaces, extIfName)
 					}
 
-					return nil
+					// Persist the cleared state, as the bolt store is not deleted with the json store
+					return nm.save()
 				}
 			}
 		}
@@ -374,7 +379,7 @@ func (nm *networkManager) GetNetworkInfo(networkID string) (EndpointInfo, error)
 	return nwInfo, nil
 }
 
-func (nm *networkManager) createEndpoint(cli apipaClient, networkID string, epInfo *EndpointInfo) (*endpoint, error) {
+func (nm *networkManager) createEndpoint(ctx context.Context, cli apipaClient, networkID string, epInfo *EndpointInfo) (*endpoint, error) {
 	nm.Lock()
 	defer nm.Unlock()
 
@@ -390,7 +395,7 @@ func (nm *networkManager) createEndpoint(cli apipaClient, networkID string, epIn
 		}
 	}
 
-	ep, err := nw.newEndpoint(cli, nm.netlink, nm.plClient, nm.netio, nm.nsClient, nm.iptablesClient, nm.dhcpClient, epInfo)
+	ep, err := nw.newEndpoint(ctx, cli, nm.netlink, nm.plClient, nm.netio, nm.nsClient, nm.iptablesClient, nm.dhcpClient, epInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -411,7 +416,7 @@ func (nm *networkManager) createEndpoint(cli apipaClient, networkID string, epIn
 
 // CreateEndpoint creates a new container endpoint (this is for compatibility-- add flow should no longer use this).
 func (nm *networkManager) CreateEndpoint(cli apipaClient, networkID string, epInfo *EndpointInfo) error {
-	_, err := nm.createEndpoint(cli, networkID, epInfo)
+	_, err := nm.createEndpoint(context.Background(), cli, networkID, epInfo)
 	return err
 }
 
@@ -512,6 +517,47 @@ func (nm *networkManager) DeleteEndpoint(networkID, endpointID string, epInfo *E
 	return nil
 }
 
+// ValidateEndpoint checks the host and container side of an existing endpoint.
+func (nm *networkManager) ValidateEndpoint(networkID string, epInfo *EndpointInfo) error {
+	nm.Lock()
+	defer nm.Unlock()
+
+	nw, err := nm.getDatapathNetwork(networkID)
+	if err != nil {
+		return err
+	}
+
+	return nm.validateEndpointImpl(nw, epInfo)
+}
+
+// ReconcileEndpoint re-applies the missing parts of the host and container side of an existing endpoint.
+func (nm *networkManager) ReconcileEndpoint(networkID string, epInfo *EndpointInfo) ([]EndpointRepair, error) {
+	nm.Lock()
+	defer nm.Unlock()
+
+	nw, err := nm.getDatapathNetwork(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nm.reconcileEndpointImpl(nw, epInfo)
+}
+
+// getDatapathNetwork returns the network which the datapath of its endpoints is checked against.
+func (nm *networkManager) getDatapathNetwork(networkID string) (*network, error) {
+	// stateless cni doesn't keep the network, and only supports the transparent mode
+	if nm.IsStatelessCNIMode() {
+		return &network{
+			Id:        networkID,
+			Mode:      opModeTransparent,
+			Endpoints: map[string]*endpoint{},
+			extIf:     &externalInterface{Name: InfraInterfaceName},
+		}, nil
+	}
+
+	return nm.getNetwork(networkID)
+}
+
 func (nm *networkManager) DeleteEndpointStateless(networkID string, epInfo *EndpointInfo, mode string) error {
 	// we want to always use hnsv2 in stateless
 	// hnsv2 is only enabled if NetNs has a valid guid and the hnsv2 api is supported
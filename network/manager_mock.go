@@ -1,6 +1,8 @@
 package network
 
 import (
+	"context"
+
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/common"
 )
@@ -11,6 +13,9 @@ type MockNetworkManager struct {
 	TestEndpointInfoMap map[string]*EndpointInfo
 	TestEndpointClient  *MockEndpointClient
 	SaveStateMap        map[string]*endpoint
+	ValidateEndpointErr error
+	// ReconcileEndpointFn is called by ReconcileEndpoint when set
+	ReconcileEndpointFn func(networkID string, epInfo *EndpointInfo) ([]EndpointRepair, error)
 }
 
 // NewMockNetworkmanager returns a new mock
@@ -127,6 +132,19 @@ func (nm *MockNetworkManager) UpdateEndpoint(networkID string, existingEpInfo *E
 	return nil
 }
 
+// ValidateEndpoint mock
+func (nm *MockNetworkManager) ValidateEndpoint(_ string, _ *EndpointInfo) error {
+	return nm.ValidateEndpointErr
+}
+
+// ReconcileEndpoint mock
+func (nm *MockNetworkManager) ReconcileEndpoint(networkID string, epInfo *EndpointInfo) ([]EndpointRepair, error) {
+	if nm.ReconcileEndpointFn != nil {
+		return nm.ReconcileEndpointFn(networkID, epInfo)
+	}
+	return nil, nil
+}
+
 // GetNumberOfEndpoints mock
 func (nm *MockNetworkManager) GetNumberOfEndpoints(ifName string, networkID string) int {
 	return 0
@@ -165,7 +183,7 @@ func (nm *MockNetworkManager) SaveState(eps []*endpoint) error {
 	return nil
 }
 
-func (nm *MockNetworkManager) EndpointCreate(client apipaClient, epInfos []*EndpointInfo) error {
+func (nm *MockNetworkManager) EndpointCreate(_ context.Context, client apipaClient, epInfos []*EndpointInfo) error {
 	eps := []*endpoint{}
 	for _, epInfo := range epInfos {
 		_, nwGetErr := nm.GetNetworkInfo(epInfo.NetworkID)
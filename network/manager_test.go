@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"net"
 	"sort"
@@ -272,7 +273,7 @@ var _ = Describe("Test Manager", func() {
 		Context("When no endpoints provided", func() {
 			It("Should return 0", func() {
 				nm := &networkManager{}
-				err := nm.EndpointCreate(nil, []*EndpointInfo{})
+				err := nm.EndpointCreate(context.Background(), nil, []*EndpointInfo{})
 				Expect(err).NotTo(HaveOccurred())
 				num := nm.GetNumberOfEndpoints("", "")
 				Expect(num).To(Equal(0))
@@ -1,5 +1,7 @@
 package network
 
+import "github.com/Azure/azure-container-networking/iptables"
+
 // mockIPTablesClient is a mock for the ipTablesClient interface that tracks calls.
 type mockIPTablesClient struct {
 	insertCalls []iptablesCall
@@ -13,12 +15,15 @@ type iptablesCall struct {
 	target    string
 }
 
-func (c *mockIPTablesClient) InsertIptableRule(version, tableName, chainName, match, target string) error {
-	c.insertCalls = append(c.insertCalls, iptablesCall{version, tableName, chainName, match, target})
+func (c *mockIPTablesClient) Apply(tx *iptables.Transaction) error {
+	for _, op := range tx.Operations() {
+		if op.Action == iptables.Insert {
+			c.insertCalls = append(c.insertCalls, iptablesCall{op.Version, op.Table, op.Chain, op.Match, op.Target})
+		}
+	}
 	return nil
 }
 
-func (c *mockIPTablesClient) AppendIptableRule(_, _, _, _, _ string) error { return nil }
-func (c *mockIPTablesClient) DeleteIptableRule(_, _, _, _, _ string) error { return nil }
-func (c *mockIPTablesClient) CreateChain(_, _, _ string) error             { return nil }
-func (c *mockIPTablesClient) RunCmd(_, _ string) error                     { return nil }
+func (c *mockIPTablesClient) Pending(_ *iptables.Transaction) (string, error) {
+	return "", nil
+}
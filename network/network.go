@@ -4,6 +4,7 @@
 package network
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
@@ -313,7 +314,7 @@ func (nm *networkManager) GetNumEndpointsByContainerID(containerID string) int {
 }
 
 // Creates the network and corresponding endpoint (should be called once during Add)
-func (nm *networkManager) EndpointCreate(cnsclient apipaClient, epInfos []*EndpointInfo) error {
+func (nm *networkManager) EndpointCreate(ctx context.Context, cnsclient apipaClient, epInfos []*EndpointInfo) error {
 	eps := []*endpoint{} // save endpoints for stateless
 
 	for _, epInfo := range epInfos {
@@ -332,12 +333,12 @@ func (nm *networkManager) EndpointCreate(cnsclient apipaClient, epInfos []*Endpo
 			}
 
 			// Create the network if it is not found
-			err = nm.CreateNetwork(epInfo)
+			err = step(ctx, "network.CreateNetwork", func() error { return nm.CreateNetwork(epInfo) })
 			if err != nil {
 				return err
 			}
 		}
-		ep, err := nm.createEndpoint(cnsclient, epInfo.NetworkID, epInfo)
+		ep, err := nm.createEndpoint(ctx, cnsclient, epInfo.NetworkID, epInfo)
 		if err != nil {
 			return err
 		}
@@ -106,7 +106,9 @@ func (nm *networkManager) newNetworkImpl(nwInfo *EndpointInfo, extIf *externalIn
 			}
 		}
 		// Blocks wireserver traffic from apipa nic (IPv4 only)
-		if err := nu.BlockEgressTrafficFromContainer(nm.iptablesClient, iptables.V4, networkutils.AzureDNS, iptables.TCP, iptables.HTTPPort); err != nil {
+		tx := iptables.NewTransaction()
+		nu.BlockEgressTrafficFromContainer(tx, iptables.V4, networkutils.AzureDNS, iptables.TCP, iptables.HTTPPort)
+		if err := nm.iptablesClient.Apply(tx); err != nil {
 			return nil, errors.Wrap(err, "unable to insert vm iptables rule drop wireserver packets")
 		}
 	default:
@@ -141,8 +143,8 @@ func (nm *networkManager) handleCommonOptions(ifName string, nwInfo *EndpointInf
 		}
 	}
 
-	if iptcmds, exists := nwInfo.Options[IPTablesKey]; exists {
-		err = nm.addToIptables(iptcmds.([]iptables.IPTableEntry))
+	if iptops, exists := nwInfo.Options[IPTablesKey]; exists {
+		err = nm.addToIptables(iptops.([]iptables.Operation))
 		if err != nil {
 			return err
 		}
@@ -610,15 +612,17 @@ func (nm *networkManager) connectExternalInterface(extIf *externalInterface, nwI
 			return err
 		}
 
-		if err = nm.addIpv6SnatRule(extIf, nwInfo); err != nil {
+		tx := iptables.NewTransaction()
+		if err = nm.addIpv6SnatRule(tx, extIf, nwInfo); err != nil {
 			logger.Error("Adding IPv6 Snat Rule failed with", zap.Error(err))
 			return err
 		}
 
 		// unmark packet if set by kube-proxy to skip kube-postrouting rule and processed
 		// by cni snat rule
-		if err = nm.iptablesClient.InsertIptableRule(iptables.V6, iptables.Mangle, iptables.Postrouting, "", "MARK --set-mark 0x0"); err != nil {
-			logger.Error("Adding Iptable mangle rule failed", zap.Error(err))
+		tx.InsertRule(iptables.V6, iptables.Mangle, iptables.Postrouting, "", "MARK --set-mark 0x0")
+		if err = nm.iptablesClient.Apply(tx); err != nil {
+			logger.Error("Adding IPv6 Snat and mangle iptables rules failed", zap.Error(err))
 			return err
 		}
 	}
@@ -655,15 +659,12 @@ func (nm *networkManager) disconnectExternalInterface(extIf *externalInterface,
 	logger.Info("Disconnected interface", zap.String("Name", extIf.Name))
 }
 
-func (nm *networkManager) addToIptables(cmds []iptables.IPTableEntry) error {
+func (nm *networkManager) addToIptables(ops []iptables.Operation) error {
 	logger.Info("Adding additional iptable rules...")
-	for _, cmd := range cmds {
-		err := nm.iptablesClient.RunCmd(cmd.Version, cmd.Params)
-		if err != nil {
-			return err
-		}
-		logger.Info("Successfully run iptables rule", zap.Any("cmd", cmd))
+	if err := nm.iptablesClient.Apply(iptables.NewTransaction().Add(ops...)); err != nil {
+		return err
 	}
+	logger.Info("Successfully applied iptables rules", zap.Any("ops", ops))
 	return nil
 }
 
@@ -688,7 +689,8 @@ func (nm *networkManager) addIpv6NatGateway(nwInfo *EndpointInfo) error {
 }
 
 // snat ipv6 traffic to secondary ipv6 ip before leaving VM
-func (nm *networkManager) addIpv6SnatRule(extIf *externalInterface, nwInfo *EndpointInfo) error {
+// addIpv6SnatRule adds the ipv6 snat rules for the pod subnet to the transaction.
+func (nm *networkManager) addIpv6SnatRule(tx *iptables.Transaction, extIf *externalInterface, nwInfo *EndpointInfo) error {
 	var (
 		ipv6SnatRuleSet  bool
 		ipv6SubnetPrefix net.IPNet
@@ -712,9 +714,7 @@ func (nm *networkManager) addIpv6SnatRule(extIf *externalInterface, nwInfo *Endp
 		logger.Info("Adding ipv6 snat rule")
 		matchSrcPrefix := fmt.Sprintf("-s %s", ipv6SubnetPrefix.String())
 		nu := networkutils.NewNetworkUtils(nm.netlink, nm.plClient)
-		if err := nu.AddSnatRule(nm.iptablesClient, matchSrcPrefix, ipAddr.IP); err != nil {
-			return fmt.Errorf("adding iptable snat rule failed:%w", err)
-		}
+		nu.AddSnatRule(tx, matchSrcPrefix, ipAddr.IP)
 		ipv6SnatRuleSet = true
 	}
 
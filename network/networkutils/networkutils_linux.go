@@ -40,12 +40,6 @@ const (
 
 var logger = log.CNILogger.With(zap.String("component", "net-utils"))
 
-type ipTablesClient interface {
-	InsertIptableRule(version, tableName, chainName, match, target string) error
-	AppendIptableRule(version, tableName, chainName, match, target string) error
-	DeleteIptableRule(version, tableName, chainName, match, target string) error
-}
-
 var errorNetworkUtils = errors.New("NetworkUtils Error")
 
 func newErrorNetworkUtils(errStr string) error {
@@ -132,8 +126,7 @@ func (nu NetworkUtils) AssignIPToInterface(interfaceName string, ipAddresses []n
 	return nil
 }
 
-func (nu NetworkUtils) addOrDeleteFilterRule(iptablesClient ipTablesClient, bridgeName, action, ipAddress, chainName, target string) error {
-	var err error
+func (nu NetworkUtils) addOrDeleteFilterRule(tx *iptables.Transaction, bridgeName, action, ipAddress, chainName, target string) {
 	option := "i"
 
 	if chainName == iptables.Output {
@@ -144,68 +137,48 @@ func (nu NetworkUtils) addOrDeleteFilterRule(iptablesClient ipTablesClient, brid
 
 	switch action {
 	case iptables.Insert:
-		err = iptablesClient.InsertIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
+		tx.InsertRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
 	case iptables.Append:
-		err = iptablesClient.AppendIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
+		tx.AppendRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
 	case iptables.Delete:
-		err = iptablesClient.DeleteIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
+		tx.DeleteRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
 	}
-
-	return err
 }
 
-func (nu NetworkUtils) AllowIPAddresses(iptablesClient ipTablesClient, bridgeName string, skipAddresses []string, action string) error {
+// AllowIPAddresses adds rules to the transaction which allow the addresses via the bridge.
+func (nu NetworkUtils) AllowIPAddresses(tx *iptables.Transaction, bridgeName string, skipAddresses []string, action string) {
 	chains := getFilterChains()
 	target := getFilterchainTarget()
 
 	logger.Info("Addresses to allow", zap.Any("skipAddresses", skipAddresses))
 
 	for _, address := range skipAddresses {
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, address, chains[0], target[0]); err != nil {
-			return err
-		}
-
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, address, chains[1], target[0]); err != nil {
-			return err
-		}
-
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, address, chains[2], target[0]); err != nil {
-			return err
-		}
-
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, address, chains[0], target[0])
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, address, chains[1], target[0])
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, address, chains[2], target[0])
 	}
-
-	return nil
 }
 
-func (nu NetworkUtils) BlockEgressTrafficFromContainer(iptablesClient ipTablesClient, version, ipAddress, protocol string, port int) error {
+// BlockEgressTrafficFromContainer adds a rule to the transaction which drops traffic to the address and port.
+func (nu NetworkUtils) BlockEgressTrafficFromContainer(tx *iptables.Transaction, version, ipAddress, protocol string, port int) {
 	// iptables -t filter -I FORWARD -j DROP -d <ip> -p <protocol> -m <protocol> --dport <port>
 	dropTraffic := fmt.Sprintf("-d %s -p %s -m %s --dport %d", ipAddress, protocol, protocol, port)
-	return errors.Wrap(iptablesClient.InsertIptableRule(version, iptables.Filter, iptables.Forward, dropTraffic, iptables.Drop), "iptables block traffic failed")
+	tx.InsertRule(version, iptables.Filter, iptables.Forward, dropTraffic, iptables.Drop)
 }
 
-func (nu NetworkUtils) BlockIPAddresses(iptablesClient ipTablesClient, bridgeName, action string) error {
-	privateIPAddresses := getPrivateIPSpace()
+// BlockIPAddresses adds rules to the transaction which block private IPs via the bridge.
+func (nu NetworkUtils) BlockIPAddresses(tx *iptables.Transaction, bridgeName, action string) {
+	privateIPAddresses := GetPrivateIPSpace()
 	chains := getFilterChains()
 	target := getFilterchainTarget()
 
 	logger.Info("Addresses to block", zap.Any("privateIPAddresses", privateIPAddresses))
 
 	for _, ipAddress := range privateIPAddresses {
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, ipAddress, chains[0], target[1]); err != nil {
-			return err
-		}
-
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, ipAddress, chains[1], target[1]); err != nil {
-			return err
-		}
-
-		if err := nu.addOrDeleteFilterRule(iptablesClient, bridgeName, action, ipAddress, chains[2], target[1]); err != nil {
-			return err
-		}
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, ipAddress, chains[0], target[1])
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, ipAddress, chains[1], target[1])
+		nu.addOrDeleteFilterRule(tx, bridgeName, action, ipAddress, chains[2], target[1])
 	}
-
-	return nil
 }
 
 func (nu NetworkUtils) EnableIPV4Forwarding() error {
@@ -241,15 +214,15 @@ func (nu NetworkUtils) UpdateIPV6Setting(disable int) error {
 	return err
 }
 
-// This function adds rule which snat to ip passed filtered by match string.
-func (nu NetworkUtils) AddSnatRule(iptablesClient ipTablesClient, match string, ip net.IP) error {
+// This function adds a rule to the transaction which snats to ip passed filtered by match string.
+func (nu NetworkUtils) AddSnatRule(tx *iptables.Transaction, match string, ip net.IP) {
 	version := iptables.V4
 	if ip.To4() == nil {
 		version = iptables.V6
 	}
 
 	target := fmt.Sprintf("SNAT --to %s", ip.String())
-	return errors.Wrap(iptablesClient.InsertIptableRule(version, iptables.Nat, iptables.Postrouting, match, target), "failed to add snat rule")
+	tx.InsertRule(version, iptables.Nat, iptables.Postrouting, match, target)
 }
 
 func (nu NetworkUtils) DisableRAForInterface(ifName string) error {
@@ -275,7 +248,8 @@ func (nu NetworkUtils) SetProxyArp(ifName string) error {
 	return errors.Wrapf(err, "failed to set proxy arp for interface %v", ifName)
 }
 
-func getPrivateIPSpace() []string {
+// GetPrivateIPSpace returns the private address ranges which are blocked via the snat bridge.
+func GetPrivateIPSpace() []string {
 	privateIPAddresses := []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16"}
 	return privateIPAddresses
 }
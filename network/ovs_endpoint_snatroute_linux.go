@@ -34,6 +34,7 @@ func (client *OVSEndpointClient) NewSnatClient(snatBridgeIP, localIP string, epI
 			client.plClient,
 			client.iptablesClient,
 			client.netioshim,
+			epInfo.SNATBackend,
 		)
 	}
 }
@@ -0,0 +1,8 @@
+package snat
+
+// SNAT rule backends, which select how a snat client programs the host rules.
+// Any value other than BackendNftables uses iptables.
+const (
+	BackendIPTables = "iptables"
+	BackendNftables = "nftables"
+)
@@ -14,6 +14,7 @@ import (
 	"github.com/Azure/azure-container-networking/netio"
 	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/nftables"
 	"github.com/Azure/azure-container-networking/platform"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -33,10 +34,8 @@ const (
 var logger = log.CNILogger.With(zap.String("component", "net"))
 
 type ipTablesClient interface {
-	InsertIptableRule(version, tableName, chainName, match, target string) error
-	AppendIptableRule(version, tableName, chainName, match, target string) error
-	DeleteIptableRule(version, tableName, chainName, match, target string) error
-	CreateChain(version, tableName, chainName string) error
+	Apply(tx *iptables.Transaction) error
+	Pending(tx *iptables.Transaction) (string, error)
 }
 
 var errorSnatClient = errors.New("SnatClient Error")
@@ -56,6 +55,7 @@ type Client struct {
 	netlink                netlink.NetlinkInterface
 	plClient               platform.ExecClient
 	ipTablesClient         ipTablesClient
+	nftablesClient         nftablesClient
 	netioClient            netio.NetIOInterface
 }
 
@@ -70,6 +70,7 @@ func NewSnatClient(hostIfName string,
 	plClient platform.ExecClient,
 	iptc ipTablesClient,
 	nio netio.NetIOInterface,
+	backend string,
 ) Client {
 	snatClient := Client{
 		hostSnatVethName:       hostIfName,
@@ -86,6 +87,10 @@ func NewSnatClient(hostIfName string,
 
 	snatClient.SkipAddressesFromBlock = append(snatClient.SkipAddressesFromBlock, skipAddressesFromBlock...)
 
+	if backend == BackendNftables {
+		snatClient.nftablesClient = nftables.NewClient(nftables.Table)
+	}
+
 	logger.Info("Initialize new snat client", zap.Any("snatClient", snatClient))
 	return snatClient
 }
@@ -134,8 +139,11 @@ func (client *Client) CreateSnatEndpoint() error {
 
 // AllowIPAddressesOnSnatBridge adds iptables rules  that allows only specific Private IPs via linux bridge
 func (client *Client) AllowIPAddressesOnSnatBridge() error {
-	nu := networkutils.NewNetworkUtils(client.netlink, client.plClient)
-	if err := nu.AllowIPAddresses(client.ipTablesClient, SnatBridgeName, client.SkipAddressesFromBlock, iptables.Insert); err != nil {
+	if client.useNftables() {
+		return client.allowIPAddressesNftables()
+	}
+
+	if err := client.ipTablesClient.Apply(client.allowIPAddressRules(iptables.NewTransaction())); err != nil {
 		logger.Error("AllowIPAddresses failed with", zap.Error(err))
 		return newErrorSnatClient(err.Error())
 	}
@@ -145,9 +153,12 @@ func (client *Client) AllowIPAddressesOnSnatBridge() error {
 
 // BlockIPAddressesOnSnatBridge adds iptables rules  that blocks all private IPs flowing via linux bridge
 func (client *Client) BlockIPAddressesOnSnatBridge() error {
-	nu := networkutils.NewNetworkUtils(client.netlink, client.plClient)
-	if err := nu.BlockIPAddresses(client.ipTablesClient, SnatBridgeName, iptables.Append); err != nil {
-		logger.Error("AllowIPAddresses failed with", zap.Error(err))
+	if client.useNftables() {
+		return client.blockIPAddressesNftables()
+	}
+
+	if err := client.ipTablesClient.Apply(client.blockIPAddressRules(iptables.NewTransaction())); err != nil {
+		logger.Error("BlockIPAddresses failed with", zap.Error(err))
 		return newErrorSnatClient(err.Error())
 	}
 
@@ -187,44 +198,13 @@ func getNCLocalAndGatewayIP(client *Client) (brIP, contIP net.IP) {
 func (client *Client) AllowInboundFromHostToNC() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
-	// Create CNI Output chain
-	if err := client.ipTablesClient.CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Creating failed with", zap.Any("CNIOutputChain", iptables.CNIOutputChain), zap.Error(err))
-		return newErrorSnatClient(err.Error())
-	}
-
-	// Forward traffic from Ouptut chain to CNI Output chain
-	if err := client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Creating failed with", zap.Any("CNIOutputChain", iptables.CNIOutputChain), zap.Error(err))
-		return newErrorSnatClient(err.Error())
-	}
-
-	// Allow connection from Host to NC
-	matchCondition := fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
-	err := client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting output rule failed with ", zap.Error(err))
-		return newErrorSnatClient(err.Error())
-	}
-
-	// Create cniinput chain
-	if err = client.ipTablesClient.CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Creating failed with", zap.Any("CNIOutputChain", iptables.CNIOutputChain), zap.Error(err))
-		return newErrorSnatClient(err.Error())
-	}
-
-	// Forward from Input to cniinput chain
-	if err = client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting forward rule to failed with", zap.Any("CNIOutputChain", iptables.CNIOutputChain), zap.Error(err))
-		return newErrorSnatClient(err.Error())
-	}
-
-	// Accept packets from NC only if established connection
-	matchCondition = fmt.Sprintf(" -i %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
-	err = client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting input rule failed with", zap.Error(err))
-		return newErrorSnatClient(err.Error())
+	if client.useNftables() {
+		if err := client.addContainerIPNftables(nftHostToNCSet); err != nil {
+			logger.Error("AllowInboundFromHostToNC: Programming nftables failed with", zap.Error(err))
+			return err
+		}
+	} else if err := client.allowInboundFromHostToNCIPTables(bridgeIP, containerIP); err != nil {
+		return err
 	}
 
 	snatContainerVeth, err := client.netioClient.GetNetworkInterfaceByName(client.containerSnatVethName)
@@ -252,12 +232,44 @@ func (client *Client) AllowInboundFromHostToNC() error {
 	return nil
 }
 
+func (client *Client) allowInboundFromHostToNCIPTables(bridgeIP, containerIP net.IP) error {
+	if err := client.ipTablesClient.Apply(hostToNCRules(iptables.NewTransaction(), bridgeIP, containerIP)); err != nil {
+		logger.Error("AllowInboundFromHostToNC: Programming iptables failed with", zap.Error(err))
+		return newErrorSnatClient(err.Error())
+	}
+	return nil
+}
+
+// hostToNCRules adds the rules which allow only host to NC connections.
+func hostToNCRules(tx *iptables.Transaction, bridgeIP, containerIP net.IP) *iptables.Transaction {
+	return tx.
+		// Create CNI Output chain
+		CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain).
+		// Forward traffic from Ouptut chain to CNI Output chain
+		InsertRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain).
+		// Allow connection from Host to NC
+		InsertRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String()), iptables.Accept).
+		// Create cniinput chain
+		CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain).
+		// Forward from Input to cniinput chain
+		InsertRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain).
+		// Accept packets from NC only if established connection
+		InsertRule(iptables.V4, iptables.Filter, iptables.CNIInputChain,
+			fmt.Sprintf(" -i %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related), iptables.Accept)
+}
+
 func (client *Client) DeleteInboundFromHostToNC() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
 	// Delete allow connection from Host to NC
-	matchCondition := fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
-	err := client.ipTablesClient.DeleteIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
+	var err error
+	if client.useNftables() {
+		err = client.deleteContainerIPNftables(nftHostToNCSet)
+	} else {
+		matchCondition := fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
+		tx := iptables.NewTransaction().DeleteRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
+		err = client.ipTablesClient.Apply(tx)
+	}
 	if err != nil {
 		logger.Error("DeleteInboundFromHostToNC: Error removing output rule", zap.Error(err))
 	}
@@ -283,47 +295,12 @@ func (client *Client) DeleteInboundFromHostToNC() error {
 func (client *Client) AllowInboundFromNCToHost() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
-	// Create CNI Input chain
-	if err := client.ipTablesClient.CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Creating failed with", zap.String("CNIInputChain", iptables.CNIInputChain),
-			zap.Error(err))
-		return err
-	}
-
-	// Forward traffic from Input to cniinput chain
-	if err := client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting forward rule to failed with", zap.String("CNIInputChain", iptables.CNIInputChain),
-			zap.Error(err))
-		return err
-	}
-
-	// Allow NC to Host connection
-	matchCondition := fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
-	err := client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting output rule failed with", zap.Error(err))
-		return err
-	}
-
-	// Create CNI output chain
-	if err = client.ipTablesClient.CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Creating failed with", zap.String("CNIInputChain", iptables.CNIInputChain),
-			zap.Error(err))
-		return err
-	}
-
-	// Forward traffic from Output to CNI Output chain
-	if err = client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain); err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting forward rule to failed with", zap.String("CNIInputChain", iptables.CNIInputChain),
-			zap.Error(err))
-		return err
-	}
-
-	// Accept packets from Host only if established connection
-	matchCondition = fmt.Sprintf(" -o %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
-	err = client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		logger.Error("AllowInboundFromHostToNC: Inserting input rule failed with", zap.Error(err))
+	if client.useNftables() {
+		if err := client.addContainerIPNftables(nftNCToHostSet); err != nil {
+			logger.Error("AllowInboundFromNCToHost: Programming nftables failed with", zap.Error(err))
+			return err
+		}
+	} else if err := client.allowInboundFromNCToHostIPTables(bridgeIP, containerIP); err != nil {
 		return err
 	}
 
@@ -350,12 +327,44 @@ func (client *Client) AllowInboundFromNCToHost() error {
 	return err
 }
 
+func (client *Client) allowInboundFromNCToHostIPTables(bridgeIP, containerIP net.IP) error {
+	if err := client.ipTablesClient.Apply(ncToHostRules(iptables.NewTransaction(), bridgeIP, containerIP)); err != nil {
+		logger.Error("AllowInboundFromNCToHost: Programming iptables failed with", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ncToHostRules adds the rules which allow only NC to host connections.
+func ncToHostRules(tx *iptables.Transaction, bridgeIP, containerIP net.IP) *iptables.Transaction {
+	return tx.
+		// Create CNI Input chain
+		CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain).
+		// Forward traffic from Input to cniinput chain
+		InsertRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain).
+		// Allow NC to Host connection
+		InsertRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String()), iptables.Accept).
+		// Create CNI output chain
+		CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain).
+		// Forward traffic from Output to CNI Output chain
+		InsertRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain).
+		// Accept packets from Host only if established connection
+		InsertRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain,
+			fmt.Sprintf(" -o %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related), iptables.Accept)
+}
+
 func (client *Client) DeleteInboundFromNCToHost() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
 	// Delete allow NC to Host connection
-	matchCondition := fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
-	err := client.ipTablesClient.DeleteIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
+	var err error
+	if client.useNftables() {
+		err = client.deleteContainerIPNftables(nftNCToHostSet)
+	} else {
+		matchCondition := fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
+		tx := iptables.NewTransaction().DeleteRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
+		err = client.ipTablesClient.Apply(tx)
+	}
 	if err != nil {
 		logger.Error("DeleteInboundFromNCToHost: Error removing output rule", zap.Error(err))
 	}
@@ -473,10 +482,19 @@ func (client *Client) createSnatBridge(snatBridgeIP, hostPrimaryMac string) erro
 
 // This function adds iptable rules that will snat all traffic that has source ip in apipa range and coming via linux bridge
 func (client *Client) addMasqueradeRule(snatBridgeIPWithPrefix string) error {
+	if client.useNftables() {
+		// the masquerade rule is one of the static rules
+		return errors.Wrap(client.initNftables(), "failed to add masquerade rule")
+	}
+
+	tx := masqueradeRules(iptables.NewTransaction(), snatBridgeIPWithPrefix)
+	return errors.Wrap(client.ipTablesClient.Apply(tx), "failed to add masquerade rule")
+}
+
+func masqueradeRules(tx *iptables.Transaction, snatBridgeIPWithPrefix string) *iptables.Transaction {
 	_, ipNet, _ := net.ParseCIDR(snatBridgeIPWithPrefix)
 	matchCondition := fmt.Sprintf("-s %s", ipNet.String())
-	return errors.Wrap(client.ipTablesClient.InsertIptableRule(iptables.V4, iptables.Nat, iptables.Postrouting, matchCondition, iptables.Masquerade),
-		"failed to add masquerade rule")
+	return tx.InsertRule(iptables.V4, iptables.Nat, iptables.Postrouting, matchCondition, iptables.Masquerade)
 }
 
 // Drop all vlan traffic on linux bridge
@@ -507,10 +525,81 @@ func (client *Client) EnableIPForwarding() error {
 		return errors.Wrap(err, "enable ipforwarding command failed")
 	}
 
-	// Append a rule in forward chain to allow forwarding from bridge
-	if err := client.ipTablesClient.AppendIptableRule(iptables.V4, iptables.Filter, iptables.Forward, "", iptables.Accept); err != nil {
+	if client.useNftables() {
+		// the forward accept rule is one of the static rules
+		return errors.Wrap(client.initNftables(), "adding forward chain rule to allow traffic from snat bridge failed")
+	}
+
+	if err := client.ipTablesClient.Apply(forwardRules(iptables.NewTransaction())); err != nil {
 		return errors.Wrap(err, "appending forward chain rule to allow traffic from snat bridge failed")
 	}
 
 	return nil
 }
+
+// Append a rule in forward chain to allow forwarding from bridge
+func forwardRules(tx *iptables.Transaction) *iptables.Transaction {
+	return tx.AppendRule(iptables.V4, iptables.Filter, iptables.Forward, "", iptables.Accept)
+}
+
+func (client *Client) allowIPAddressRules(tx *iptables.Transaction) *iptables.Transaction {
+	nu := networkutils.NewNetworkUtils(client.netlink, client.plClient)
+	nu.AllowIPAddresses(tx, SnatBridgeName, client.SkipAddressesFromBlock, iptables.Insert)
+	return tx
+}
+
+func (client *Client) blockIPAddressRules(tx *iptables.Transaction) *iptables.Transaction {
+	nu := networkutils.NewNetworkUtils(client.netlink, client.plClient)
+	nu.BlockIPAddresses(tx, SnatBridgeName, iptables.Append)
+	return tx
+}
+
+// endpointRules returns the iptables rules of an endpoint, as CreateSnatEndpoint and AddSnatEndpointRules program them.
+func (client *Client) endpointRules(hostToNC, ncToHost bool) *iptables.Transaction {
+	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
+	tx := masqueradeRules(iptables.NewTransaction(), client.SnatBridgeIP)
+	client.allowIPAddressRules(tx)
+	client.blockIPAddressRules(tx)
+	forwardRules(tx)
+	if hostToNC {
+		hostToNCRules(tx, bridgeIP, containerIP)
+	}
+	if ncToHost {
+		ncToHostRules(tx, bridgeIP, containerIP)
+	}
+	return tx
+}
+
+// MissingRules returns the host rules of the endpoint which are missing e.g. because something else flushed them,
+// or nothing if they are all programmed. hostToNC and ncToHost are as for AddSnatEndpointRules.
+func (client *Client) MissingRules(hostToNC, ncToHost bool) ([]string, error) {
+	if client.useNftables() {
+		return client.missingRulesNftables(hostToNC, ncToHost)
+	}
+
+	pending, err := client.ipTablesClient.Pending(client.endpointRules(hostToNC, ncToHost))
+	if err != nil {
+		return nil, newErrorSnatClient(err.Error())
+	}
+	var missing []string
+	for _, line := range strings.Split(pending, "\n") {
+		// skip the table headers and commits of the restore input
+		if strings.HasPrefix(line, "-") || strings.HasPrefix(line, ":") {
+			missing = append(missing, "iptables "+line)
+		}
+	}
+	return missing, nil
+}
+
+// ReapplyRules programs the missing host rules of the endpoint again. Unlike CreateSnatEndpoint and
+// AddSnatEndpointRules, it doesn't touch the snat bridge, the snat veth, or the static arp entries.
+func (client *Client) ReapplyRules(hostToNC, ncToHost bool) error {
+	if client.useNftables() {
+		return client.reapplyRulesNftables(hostToNC, ncToHost)
+	}
+
+	if err := client.ipTablesClient.Apply(client.endpointRules(hostToNC, ncToHost)); err != nil {
+		return newErrorSnatClient(err.Error())
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/netio"
 	"github.com/Azure/azure-container-networking/netlink"
 )
@@ -12,20 +13,12 @@ var anyInterface = "dummy"
 
 type mockIPTablesClient struct{}
 
-func (c mockIPTablesClient) InsertIptableRule(_, _, _, _, _ string) error {
+func (c mockIPTablesClient) Apply(_ *iptables.Transaction) error {
 	return nil
 }
 
-func (c mockIPTablesClient) AppendIptableRule(_, _, _, _, _ string) error {
-	return nil
-}
-
-func (c mockIPTablesClient) DeleteIptableRule(_, _, _, _, _ string) error {
-	return nil
-}
-
-func (c mockIPTablesClient) CreateChain(_, _, _ string) error {
-	return nil
+func (c mockIPTablesClient) Pending(_ *iptables.Transaction) (string, error) {
+	return "", nil
 }
 
 func TestMain(m *testing.M) {
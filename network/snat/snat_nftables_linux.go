@@ -0,0 +1,282 @@
+package snat
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// chains and sets of the snat rules in the azure nftables table
+const (
+	nftForwardChain     = "snat-forward"
+	nftInputChain       = "snat-input"
+	nftOutputChain      = "snat-output"
+	nftPostroutingChain = "snat-postrouting"
+	// nftAllowedSet holds the addresses which are allowed via the snat bridge e.g. the DNS servers
+	nftAllowedSet = "snat-allowed"
+	// nftBlockedSet holds the private address space which is blocked via the snat bridge
+	nftBlockedSet = "snat-blocked"
+	// nftHostToNCSet holds the NC addresses which the host may connect to
+	nftHostToNCSet = "snat-host-to-nc"
+	// nftNCToHostSet holds the NC addresses which may connect to the host
+	nftNCToHostSet = "snat-nc-to-host"
+	// NF_IP_PRI_NAT_SRC, the priority of the iptables nat POSTROUTING chain
+	nftPrioritySrcNAT = 100
+)
+
+var nftChains = []string{nftForwardChain, nftInputChain, nftOutputChain, nftPostroutingChain}
+
+type nftablesClient interface {
+	Apply(b *nftables.Batch) error
+	HasChains(names ...string) (bool, error)
+	RuleCount(chainName string) (int, error)
+	Elements(setName string) ([]nftables.Element, error)
+}
+
+func (client *Client) useNftables() bool {
+	return client.nftablesClient != nil
+}
+
+// initNftables creates the snat chains, sets, and rules in the azure table if the chains don't exist yet.
+// The rules only depend on the bridge, so they are programmed once and the per endpoint state is kept in the sets,
+// which applyNftables updates without touching the rules.
+//
+// An accept in the azure table doesn't override a drop in another table on the same hook,
+// so the nftables backend expects that nothing else e.g. an iptables FORWARD policy drops the snat bridge traffic.
+func (client *Client) initNftables() error {
+	exists, err := client.nftablesClient.HasChains(nftChains...)
+	if err != nil {
+		return newErrorSnatClient(err.Error())
+	}
+	if exists {
+		return nil
+	}
+
+	rules, err := client.nftRules()
+	if err != nil {
+		return err
+	}
+	logger.Info("Creating snat nftables chains", zap.String("table", nftables.Table))
+	if err := client.nftablesClient.Apply(rules); err != nil {
+		return newErrorSnatClient(err.Error())
+	}
+	return nil
+}
+
+// nftRules returns the batch which creates the snat sets and chains, and replaces the rules of the chains.
+func (client *Client) nftRules() (*nftables.Batch, error) {
+	bridgeIP, bridgeSubnet, err := net.ParseCIDR(client.SnatBridgeIP)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid snat bridge ip %s", client.SnatBridgeIP)
+	}
+
+	// the chains are flushed in case a concurrent init created them after the check, since the batch is applied atomically
+	rules := nftables.NewBatch().
+		AddSet(nftables.Set{Name: nftAllowedSet, Interval: true}).
+		AddSet(nftables.Set{Name: nftBlockedSet, Interval: true}).
+		AddSet(nftables.Set{Name: nftHostToNCSet}).
+		AddSet(nftables.Set{Name: nftNCToHostSet}).
+		AddChain(nftables.Chain{Name: nftForwardChain, Hook: &nftables.Hook{Type: nftables.ChainTypeFilter, Hooknum: unix.NF_INET_FORWARD, Priority: nftables.PriorityFilter}}).
+		AddChain(nftables.Chain{Name: nftInputChain, Hook: &nftables.Hook{Type: nftables.ChainTypeFilter, Hooknum: unix.NF_INET_LOCAL_IN, Priority: nftables.PriorityFilter}}).
+		AddChain(nftables.Chain{Name: nftOutputChain, Hook: &nftables.Hook{Type: nftables.ChainTypeFilter, Hooknum: unix.NF_INET_LOCAL_OUT, Priority: nftables.PriorityFilter}}).
+		AddChain(nftables.Chain{Name: nftPostroutingChain, Hook: &nftables.Hook{Type: nftables.ChainTypeNAT, Hooknum: unix.NF_INET_POST_ROUTING, Priority: nftPrioritySrcNAT}}).
+		FlushChain(nftForwardChain).
+		FlushChain(nftInputChain).
+		FlushChain(nftOutputChain).
+		FlushChain(nftPostroutingChain)
+
+	established := nftables.CtState(nftables.CtStateEstablished | nftables.CtStateRelated)
+	fromBridge := nftables.IIFName(SnatBridgeName)
+	toBridge := nftables.OIFName(SnatBridgeName)
+
+	// allow only specific private IPs via the bridge, then allow forwarding everything else
+	rules.AddRule(nftForwardChain, nftables.Rule(fromBridge, nftables.DestInSet(nftAllowedSet), nftables.Accept())...).
+		AddRule(nftForwardChain, nftables.Rule(fromBridge, nftables.DestInSet(nftBlockedSet), nftables.Drop())...).
+		AddRule(nftForwardChain, nftables.Accept()...)
+
+	// NC to host connections, and replies to host to NC connections
+	rules.AddRule(nftInputChain, nftables.Rule(nftables.SourceInSet(nftNCToHostSet), nftables.DestIP(bridgeIP), nftables.Accept())...).
+		AddRule(nftInputChain, nftables.Rule(fromBridge, established, nftables.SourceInSet(nftHostToNCSet), nftables.Accept())...).
+		AddRule(nftInputChain, nftables.Rule(fromBridge, nftables.DestInSet(nftAllowedSet), nftables.Accept())...).
+		AddRule(nftInputChain, nftables.Rule(fromBridge, nftables.DestInSet(nftBlockedSet), nftables.Drop())...)
+
+	// host to NC connections, and replies to NC to host connections
+	rules.AddRule(nftOutputChain, nftables.Rule(nftables.SourceIP(bridgeIP), nftables.DestInSet(nftHostToNCSet), nftables.Accept())...).
+		AddRule(nftOutputChain, nftables.Rule(toBridge, established, nftables.DestInSet(nftNCToHostSet), nftables.Accept())...).
+		AddRule(nftOutputChain, nftables.Rule(toBridge, nftables.DestInSet(nftAllowedSet), nftables.Accept())...).
+		AddRule(nftOutputChain, nftables.Rule(toBridge, nftables.DestInSet(nftBlockedSet), nftables.Drop())...)
+
+	// masquerade the traffic from the bridge subnet
+	rules.AddRule(nftPostroutingChain, nftables.Rule(nftables.SourceCIDR(*bridgeSubnet), nftables.Masquerade())...)
+	return rules, nil
+}
+
+// applyNftables applies a batch of set element changes, after creating the chains and sets if they don't exist.
+func (client *Client) applyNftables(b *nftables.Batch) error {
+	if err := client.initNftables(); err != nil {
+		return err
+	}
+	if err := client.nftablesClient.Apply(b); err != nil {
+		return newErrorSnatClient(err.Error())
+	}
+	return nil
+}
+
+func (client *Client) allowIPAddressesNftables() error {
+	cidrs, err := parseCIDRs(client.SkipAddressesFromBlock)
+	if err != nil {
+		return err
+	}
+	logger.Info("Addresses to allow", zap.Any("skipAddresses", client.SkipAddressesFromBlock))
+	return client.applyNftables(nftables.NewBatch().AddElements(nftAllowedSet, nftables.CIDRElements(cidrs...)...))
+}
+
+func (client *Client) blockIPAddressesNftables() error {
+	cidrs, err := parseCIDRs(networkutils.GetPrivateIPSpace())
+	if err != nil {
+		return err
+	}
+	return client.applyNftables(nftables.NewBatch().AddElements(nftBlockedSet, nftables.CIDRElements(cidrs...)...))
+}
+
+// nftSetElements are the elements which an endpoint needs in a set.
+type nftSetElements struct {
+	set      string
+	elements []nftables.Element
+}
+
+// nftEndpointElements returns the set elements of an endpoint, as AddSnatEndpointRules adds them.
+func (client *Client) nftEndpointElements(hostToNC, ncToHost bool) ([]nftSetElements, error) {
+	allowed, err := parseCIDRs(client.SkipAddressesFromBlock)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := parseCIDRs(networkutils.GetPrivateIPSpace())
+	if err != nil {
+		return nil, err
+	}
+	_, containerIP := getNCLocalAndGatewayIP(client)
+
+	elements := []nftSetElements{
+		{set: nftAllowedSet, elements: nftables.CIDRElements(allowed...)},
+		{set: nftBlockedSet, elements: nftables.CIDRElements(blocked...)},
+	}
+	if hostToNC {
+		elements = append(elements, nftSetElements{set: nftHostToNCSet, elements: nftables.IPElements(containerIP)})
+	}
+	if ncToHost {
+		elements = append(elements, nftSetElements{set: nftNCToHostSet, elements: nftables.IPElements(containerIP)})
+	}
+	return elements, nil
+}
+
+// missingRulesNftables returns the snat chains whose rules don't match the expected rules,
+// and the sets which miss elements of the endpoint.
+func (client *Client) missingRulesNftables(hostToNC, ncToHost bool) ([]string, error) {
+	exists, err := client.nftablesClient.HasChains(nftChains...)
+	if err != nil {
+		return nil, newErrorSnatClient(err.Error())
+	}
+	if !exists {
+		return []string{"nftables chains of table " + nftables.Table}, nil
+	}
+
+	rules, err := client.nftRules()
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, chain := range nftChains {
+		n, err := client.nftablesClient.RuleCount(chain)
+		if err != nil {
+			return nil, newErrorSnatClient(err.Error())
+		}
+		if n != rules.RuleCount(chain) {
+			missing = append(missing, fmt.Sprintf("nftables rules of chain %s: %d of %d", chain, n, rules.RuleCount(chain)))
+		}
+	}
+
+	want, err := client.nftEndpointElements(hostToNC, ncToHost)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range want {
+		have, err := client.nftablesClient.Elements(w.set)
+		if err != nil {
+			return nil, newErrorSnatClient(err.Error())
+		}
+		if !containsElements(have, w.elements) {
+			missing = append(missing, "nftables elements of set "+w.set)
+		}
+	}
+	return missing, nil
+}
+
+// reapplyRulesNftables replaces the rules of the snat chains and adds the set elements of the endpoint in one batch.
+// The elements of other endpoints are kept.
+func (client *Client) reapplyRulesNftables(hostToNC, ncToHost bool) error {
+	b, err := client.nftRules()
+	if err != nil {
+		return err
+	}
+	elements, err := client.nftEndpointElements(hostToNC, ncToHost)
+	if err != nil {
+		return err
+	}
+	for _, e := range elements {
+		b.AddElements(e.set, e.elements...)
+	}
+	if err := client.nftablesClient.Apply(b); err != nil {
+		return newErrorSnatClient(err.Error())
+	}
+	return nil
+}
+
+func containsElements(have, want []nftables.Element) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if bytes.Equal(h.Key, w.Key) && h.IntervalEnd == w.IntervalEnd {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (client *Client) addContainerIPNftables(setName string) error {
+	_, containerIP := getNCLocalAndGatewayIP(client)
+	return client.applyNftables(nftables.NewBatch().AddElements(setName, nftables.IPElements(containerIP)...))
+}
+
+func (client *Client) deleteContainerIPNftables(setName string) error {
+	_, containerIP := getNCLocalAndGatewayIP(client)
+	return client.applyNftables(nftables.NewBatch().DeleteElements(setName, nftables.IPElements(containerIP)...))
+}
+
+// parseCIDRs parses addresses which are either CIDRs or single IPs.
+func parseCIDRs(addresses []string) ([]net.IPNet, error) {
+	cidrs := make([]net.IPNet, 0, len(addresses))
+	for _, address := range addresses {
+		if _, cidr, err := net.ParseCIDR(address); err == nil {
+			cidrs = append(cidrs, *cidr)
+			continue
+		}
+		ip := net.ParseIP(address).To4()
+		if ip == nil {
+			return nil, newErrorSnatClient("invalid address " + address)
+		}
+		cidrs = append(cidrs, net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)})
+	}
+	return cidrs, nil
+}
@@ -0,0 +1,349 @@
+package snat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/google/nftables/expr"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	parityBridgeIP    = "169.254.128.1/17"
+	parityHostToNCIP  = "169.254.128.4/17"
+	parityNCToHostIP  = "169.254.128.5/17"
+	parityDNSServer   = "168.63.129.16"
+	verdictAccept     = "ACCEPT"
+	verdictDrop       = "DROP"
+	verdictMasquerade = "MASQUERADE"
+)
+
+// packet is a probe which is evaluated against the rules of both backends.
+type packet struct {
+	hook        uint32
+	iif, oif    string
+	src, dst    string
+	established bool
+}
+
+type backend interface {
+	verdict(p packet) string
+}
+
+func TestNftablesParity(t *testing.T) {
+	fakeExecutor := iptables.NewFakeExecutor()
+	iptc := iptables.NewClientWithExecutor(fakeExecutor)
+	fakeConn := nftables.NewFakeConn()
+	nftc := nftables.NewClientWithConn(nftables.Table, fakeConn)
+
+	newClient := func(localIP string, useNftables bool) *Client {
+		client := &Client{
+			SnatBridgeIP:           parityBridgeIP,
+			localIP:                localIP,
+			containerSnatVethName:  anyInterface,
+			SkipAddressesFromBlock: []string{parityDNSServer},
+			netlink:                netlink.NewMockNetlink(false, ""),
+			plClient:               platform.NewMockExecClient(false),
+			ipTablesClient:         iptc,
+			netioClient:            netio.NewMockNetIO(false, 0),
+		}
+		if useNftables {
+			client.nftablesClient = nftc
+		}
+		return client
+	}
+
+	setup := func(useNftables bool) (hostToNC *Client) {
+		hostToNC = newClient(parityHostToNCIP, useNftables)
+		ncToHost := newClient(parityNCToHostIP, useNftables)
+		for _, client := range []*Client{hostToNC, ncToHost} {
+			require.NoError(t, client.addMasqueradeRule(client.SnatBridgeIP))
+			require.NoError(t, client.AllowIPAddressesOnSnatBridge())
+			require.NoError(t, client.BlockIPAddressesOnSnatBridge())
+			require.NoError(t, client.EnableIPForwarding())
+		}
+		require.NoError(t, hostToNC.AllowInboundFromHostToNC())
+		require.NoError(t, ncToHost.AllowInboundFromNCToHost())
+		return hostToNC
+	}
+	iptablesHostToNC := setup(false)
+	nftablesHostToNC := setup(true)
+
+	backends := map[string]backend{
+		"iptables": &iptablesBackend{fake: fakeExecutor},
+		"nftables": &nftablesBackend{fake: fakeConn},
+	}
+
+	bridgeIP, _, _ := net.ParseCIDR(parityBridgeIP)
+	hostToNCIP, _, _ := net.ParseCIDR(parityHostToNCIP)
+	ncToHostIP, _, _ := net.ParseCIDR(parityNCToHostIP)
+	hostToNCProbe := packet{hook: unix.NF_INET_LOCAL_OUT, oif: SnatBridgeName, src: bridgeIP.String(), dst: hostToNCIP.String()}
+
+	tests := []struct {
+		name   string
+		packet packet
+		want   string
+	}{
+		{"forward to dns server", packet{hook: unix.NF_INET_FORWARD, iif: SnatBridgeName, oif: "eth0", src: "169.254.128.4", dst: parityDNSServer}, verdictAccept},
+		{"forward to vnet", packet{hook: unix.NF_INET_FORWARD, iif: SnatBridgeName, oif: "eth0", src: "169.254.128.4", dst: "10.1.2.3"}, verdictDrop},
+		{"forward to imds", packet{hook: unix.NF_INET_FORWARD, iif: SnatBridgeName, oif: "eth0", src: "169.254.128.4", dst: "169.254.169.254"}, verdictDrop},
+		{"forward to internet", packet{hook: unix.NF_INET_FORWARD, iif: SnatBridgeName, oif: "eth0", src: "169.254.128.4", dst: "20.1.2.3"}, verdictAccept},
+		{"forward from other interface", packet{hook: unix.NF_INET_FORWARD, iif: "eth0", oif: "eth1", src: "20.1.2.3", dst: "10.1.2.3"}, verdictAccept},
+		{"nc to host", packet{hook: unix.NF_INET_LOCAL_IN, iif: SnatBridgeName, src: ncToHostIP.String(), dst: bridgeIP.String()}, verdictAccept},
+		{"nc to host not allowed", packet{hook: unix.NF_INET_LOCAL_IN, iif: SnatBridgeName, src: hostToNCIP.String(), dst: bridgeIP.String()}, verdictDrop},
+		{"nc to host dns", packet{hook: unix.NF_INET_LOCAL_IN, iif: SnatBridgeName, src: hostToNCIP.String(), dst: parityDNSServer}, verdictAccept},
+		{"nc reply to host", packet{hook: unix.NF_INET_LOCAL_IN, iif: SnatBridgeName, src: hostToNCIP.String(), dst: bridgeIP.String(), established: true}, verdictAccept},
+		{"host to nc", hostToNCProbe, verdictAccept},
+		{"host to nc not allowed", packet{hook: unix.NF_INET_LOCAL_OUT, oif: SnatBridgeName, src: bridgeIP.String(), dst: ncToHostIP.String()}, verdictDrop},
+		{"host reply to nc", packet{hook: unix.NF_INET_LOCAL_OUT, oif: SnatBridgeName, src: bridgeIP.String(), dst: ncToHostIP.String(), established: true}, verdictAccept},
+		{"host to other interface", packet{hook: unix.NF_INET_LOCAL_OUT, oif: "eth0", src: "10.0.0.4", dst: "10.1.2.3"}, verdictAccept},
+		{"masquerade from bridge", packet{hook: unix.NF_INET_POST_ROUTING, oif: "eth0", src: "169.254.128.4", dst: "20.1.2.3"}, verdictMasquerade},
+		{"no masquerade from host", packet{hook: unix.NF_INET_POST_ROUTING, oif: "eth0", src: "10.0.0.4", dst: "20.1.2.3"}, ""},
+	}
+	for _, tt := range tests {
+		for name, b := range backends {
+			require.Equal(t, tt.want, b.verdict(tt.packet), "%s: %s", name, tt.name)
+		}
+	}
+
+	// the rules are programmed once, and endpoints only change set elements
+	rules := fakeConn.Rules(nftables.Table, nftForwardChain)
+	require.NoError(t, nftablesHostToNC.AllowIPAddressesOnSnatBridge())
+	require.Same(t, rules[0][0], fakeConn.Rules(nftables.Table, nftForwardChain)[0][0])
+	require.Len(t, fakeConn.Elements(nftables.Table, nftAllowedSet), 2)
+
+	require.NoError(t, iptablesHostToNC.DeleteInboundFromHostToNC())
+	require.NoError(t, nftablesHostToNC.DeleteInboundFromHostToNC())
+	require.NoError(t, nftablesHostToNC.DeleteInboundFromHostToNC())
+	for name, b := range backends {
+		require.Equal(t, verdictDrop, b.verdict(hostToNCProbe), "%s: host to nc after delete", name)
+	}
+	require.Same(t, rules[0][0], fakeConn.Rules(nftables.Table, nftForwardChain)[0][0])
+}
+
+func TestMissingAndReapplyRules(t *testing.T) {
+	fakeExecutor := iptables.NewFakeExecutor()
+	fakeConn := nftables.NewFakeConn()
+	nftc := nftables.NewClientWithConn(nftables.Table, fakeConn)
+
+	newClient := func(useNftables bool) *Client {
+		client := &Client{
+			SnatBridgeIP:           parityBridgeIP,
+			localIP:                parityHostToNCIP,
+			containerSnatVethName:  anyInterface,
+			SkipAddressesFromBlock: []string{parityDNSServer},
+			netlink:                netlink.NewMockNetlink(false, ""),
+			plClient:               platform.NewMockExecClient(false),
+			ipTablesClient:         iptables.NewClientWithExecutor(fakeExecutor),
+			netioClient:            netio.NewMockNetIO(false, 0),
+		}
+		if useNftables {
+			client.nftablesClient = nftc
+		}
+		require.NoError(t, client.addMasqueradeRule(client.SnatBridgeIP))
+		require.NoError(t, client.AllowIPAddressesOnSnatBridge())
+		require.NoError(t, client.BlockIPAddressesOnSnatBridge())
+		require.NoError(t, client.EnableIPForwarding())
+		require.NoError(t, client.AllowInboundFromHostToNC())
+		return client
+	}
+
+	requireMissing := func(client *Client, want ...string) {
+		missing, err := client.MissingRules(true, false)
+		require.NoError(t, err)
+		require.Equal(t, want, missing)
+	}
+
+	iptc := newClient(false)
+	requireMissing(iptc)
+	require.NoError(t, fakeExecutor.Restore(iptables.V4, "*filter\n-D FORWARD -j ACCEPT\nCOMMIT\n"))
+	requireMissing(iptc, "iptables -A FORWARD -j ACCEPT")
+	require.NoError(t, iptc.ReapplyRules(true, false))
+	requireMissing(iptc)
+
+	nftClient := newClient(true)
+	requireMissing(nftClient)
+	fakeConn.DeleteRule(nftables.Table, nftForwardChain, 0)
+	_, containerIP := getNCLocalAndGatewayIP(nftClient)
+	require.NoError(t, nftc.Apply(nftables.NewBatch().DeleteElements(nftHostToNCSet, nftables.IPElements(containerIP)...)))
+	requireMissing(nftClient, "nftables rules of chain snat-forward: 2 of 3", "nftables elements of set snat-host-to-nc")
+	require.NoError(t, nftClient.ReapplyRules(true, false))
+	requireMissing(nftClient)
+	require.Len(t, fakeConn.Rules(nftables.Table, nftForwardChain), 3)
+
+	require.NoError(t, nftc.DeleteTable())
+	requireMissing(nftClient, "nftables chains of table azure")
+	require.NoError(t, nftClient.ReapplyRules(true, false))
+	requireMissing(nftClient)
+}
+
+// iptablesBackend evaluates the rules of the iptables fake. It understands the matches which the snat client uses.
+type iptablesBackend struct {
+	fake *iptables.FakeExecutor
+}
+
+func (b *iptablesBackend) verdict(p packet) string {
+	switch p.hook {
+	case unix.NF_INET_FORWARD:
+		return b.evaluate(iptables.Filter, iptables.Forward, p, verdictAccept)
+	case unix.NF_INET_LOCAL_IN:
+		return b.evaluate(iptables.Filter, iptables.Input, p, verdictAccept)
+	case unix.NF_INET_LOCAL_OUT:
+		return b.evaluate(iptables.Filter, iptables.Output, p, verdictAccept)
+	default:
+		return b.evaluate(iptables.Nat, iptables.Postrouting, p, "")
+	}
+}
+
+func (b *iptablesBackend) evaluate(table, chain string, p packet, policy string) string {
+	for _, rule := range b.fake.Rules(iptables.V4, table, chain) {
+		target, ok := matchIPTablesRule(rule, p)
+		if !ok {
+			continue
+		}
+		if b.fake.Rules(iptables.V4, table, target) != nil {
+			if v := b.evaluate(table, target, p, ""); v != "" {
+				return v
+			}
+			continue
+		}
+		return target
+	}
+	return policy
+}
+
+func matchIPTablesRule(rule string, p packet) (string, bool) {
+	fields := strings.Fields(rule)
+	var target string
+	for i := 0; i < len(fields); i++ {
+		value := ""
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		switch fields[i] {
+		case "-s":
+			if !cidrContains(value, p.src) {
+				return "", false
+			}
+		case "-d":
+			if !cidrContains(value, p.dst) {
+				return "", false
+			}
+		case "-i":
+			if value != p.iif {
+				return "", false
+			}
+		case "-o":
+			if value != p.oif {
+				return "", false
+			}
+		case "--state":
+			if p.established != (strings.Contains(value, iptables.Established) || strings.Contains(value, iptables.Related)) {
+				return "", false
+			}
+		case "-j":
+			target = value
+		default:
+			continue
+		}
+		i++
+	}
+	return target, true
+}
+
+func cidrContains(cidr, ip string) bool {
+	if !strings.Contains(cidr, "/") {
+		cidr += "/32"
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	return err == nil && ipNet.Contains(net.ParseIP(ip))
+}
+
+// nftablesBackend evaluates the expressions of the nftables fake with a register machine.
+type nftablesBackend struct {
+	fake *nftables.FakeConn
+}
+
+func (b *nftablesBackend) verdict(p packet) string {
+	for _, chain := range b.fake.Chains(nftables.Table) {
+		if chain.Hook == nil || chain.Hook.Hooknum != p.hook {
+			continue
+		}
+		for _, rule := range b.fake.Rules(nftables.Table, chain.Name) {
+			if v := b.evaluate(rule, p); v != "" {
+				return v
+			}
+		}
+	}
+	// base chains accept by default, and nat chains don't translate
+	if p.hook == unix.NF_INET_POST_ROUTING {
+		return ""
+	}
+	return verdictAccept
+}
+
+func (b *nftablesBackend) evaluate(rule []nftables.Expr, p packet) string {
+	registers := make(map[uint32][]byte)
+	for _, e := range rule {
+		switch e := e.(type) {
+		case *expr.Meta:
+			switch e.Key {
+			case expr.MetaKeyIIFNAME:
+				registers[e.Register] = ifnameBytes(p.iif)
+			case expr.MetaKeyOIFNAME:
+				registers[e.Register] = ifnameBytes(p.oif)
+			}
+		case *expr.Payload:
+			switch e.Offset {
+			case 12:
+				registers[e.DestRegister] = net.ParseIP(p.src).To4()
+			case 16:
+				registers[e.DestRegister] = net.ParseIP(p.dst).To4()
+			}
+		case *expr.Ct:
+			state := nftables.CtStateNew
+			if p.established {
+				state = nftables.CtStateEstablished
+			}
+			registers[e.Register] = binary.NativeEndian.AppendUint32(nil, state)
+		case *expr.Bitwise:
+			result := make([]byte, e.Len)
+			for i := range result {
+				result[i] = registers[e.SourceRegister][i]&e.Mask[i] ^ e.Xor[i]
+			}
+			registers[e.DestRegister] = result
+		case *expr.Cmp:
+			equal := bytes.Equal(registers[e.Register][:len(e.Data)], e.Data)
+			if equal != (e.Op == expr.CmpOpEq) {
+				return ""
+			}
+		case *expr.Lookup:
+			if b.fake.SetContains(nftables.Table, e.SetName, registers[e.SourceRegister]) == e.Invert {
+				return ""
+			}
+		case *expr.Verdict:
+			if e.Kind == expr.VerdictAccept {
+				return verdictAccept
+			}
+			return verdictDrop
+		case *expr.Masq:
+			return verdictMasquerade
+		}
+	}
+	return ""
+}
+
+func ifnameBytes(name string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, name)
+	return b
+}
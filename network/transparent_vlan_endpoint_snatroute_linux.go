@@ -22,6 +22,7 @@ func (client *TransparentVlanEndpointClient) NewSnatClient(snatBridgeIP, localIP
 			client.plClient,
 			client.iptablesClient,
 			client.netioshim,
+			epInfo.SNATBackend,
 		)
 	}
 }
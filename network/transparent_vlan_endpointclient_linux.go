@@ -472,7 +472,9 @@ func (client *TransparentVlanEndpointClient) AddVnetRules(epInfo *EndpointInfo)
 		return err
 	}
 	// Blocks wireserver traffic from customer vnet nic (IPv4 only)
-	if err := client.netUtilsClient.BlockEgressTrafficFromContainer(client.iptablesClient, iptables.V4, networkutils.AzureDNS, iptables.TCP, iptables.HTTPPort); err != nil {
+	tx := iptables.NewTransaction()
+	client.netUtilsClient.BlockEgressTrafficFromContainer(tx, iptables.V4, networkutils.AzureDNS, iptables.TCP, iptables.HTTPPort)
+	if err := client.iptablesClient.Apply(tx); err != nil {
 		return errors.Wrap(err, "unable to insert iptables rule to drop wireserver packets")
 	}
 
@@ -490,14 +492,14 @@ func (client *TransparentVlanEndpointClient) AddVnetRules(epInfo *EndpointInfo)
 // version is the iptables version string (iptables.V4 or iptables.V6).
 // family is the vishvananda/netlink address family (FAMILY_V4 or FAMILY_V6).
 func (client *TransparentVlanEndpointClient) addVnetMangleAndTunnelingRules(version string, family int) error {
+	// the accept rule is inserted last so that it ends up before the mark rule
 	markOption := fmt.Sprintf("MARK --set-mark %d", tunnelingMark)
-	if err := client.iptablesClient.InsertIptableRule(version, "mangle", "PREROUTING", "", markOption); err != nil {
-		return errors.Wrapf(err, "unable to insert %s mangle mark rule", version)
-	}
-
 	match := "-i " + client.vlanIfName
-	if err := client.iptablesClient.InsertIptableRule(version, "mangle", "PREROUTING", match, "ACCEPT"); err != nil {
-		return errors.Wrapf(err, "unable to insert %s mangle accept rule for vlan interface", version)
+	tx := iptables.NewTransaction().
+		InsertRule(version, iptables.Mangle, iptables.Prerouting, "", markOption).
+		InsertRule(version, iptables.Mangle, iptables.Prerouting, match, iptables.Accept)
+	if err := client.iptablesClient.Apply(tx); err != nil {
+		return errors.Wrapf(err, "unable to insert %s mangle mark and accept rules for vlan interface", version)
 	}
 
 	// Add ip rule: marked packets go to the tunneling table
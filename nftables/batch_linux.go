@@ -0,0 +1,214 @@
+package nftables
+
+import (
+	"encoding/hex"
+	"math/big"
+	"net"
+
+	gnft "github.com/google/nftables"
+)
+
+const ipv4AddrLen = 4
+
+// Chain types
+const (
+	ChainTypeFilter = string(gnft.ChainTypeFilter)
+	ChainTypeNAT    = string(gnft.ChainTypeNAT)
+)
+
+// Chain priorities
+const (
+	PriorityFilter = 0
+	// PrioritySNAT runs before the iptables nat POSTROUTING chain, so that our SNAT takes precedence
+	PrioritySNAT = 99
+)
+
+// Hook attaches a base chain to a netfilter hook.
+type Hook struct {
+	// Type is ChainTypeFilter or ChainTypeNAT
+	Type string
+	// Hooknum is the netfilter hook e.g. unix.NF_INET_FORWARD
+	Hooknum  uint32
+	Priority int32
+}
+
+// Chain is a chain in the table. Chains without a hook are regular chains.
+type Chain struct {
+	Name string
+	Hook *Hook
+}
+
+func (c Chain) nft(table *gnft.Table) *gnft.Chain {
+	chain := &gnft.Chain{Table: table, Name: c.Name}
+	if c.Hook != nil {
+		chain.Type = gnft.ChainType(c.Hook.Type)
+		chain.Hooknum = gnft.ChainHookRef(gnft.ChainHook(c.Hook.Hooknum))
+		chain.Priority = gnft.ChainPriorityRef(gnft.ChainPriority(c.Hook.Priority))
+	}
+	return chain
+}
+
+// Set is a named set of IPv4 addresses in the table.
+type Set struct {
+	Name string
+	// Interval sets hold CIDRs instead of single addresses
+	Interval bool
+}
+
+func (s Set) nft(table *gnft.Table) *gnft.Set {
+	return &gnft.Set{Table: table, Name: s.Name, KeyType: gnft.TypeIPAddr, Interval: s.Interval}
+}
+
+// Element is an element of a set. A CIDR in an interval set is a pair of elements:
+// the first address, and the address after the last one with IntervalEnd set.
+type Element = gnft.SetElement
+
+func elementID(e Element) string {
+	if e.IntervalEnd {
+		return hex.EncodeToString(e.Key) + "-end"
+	}
+	return hex.EncodeToString(e.Key)
+}
+
+// IPElements returns the elements for the addresses.
+func IPElements(ips ...net.IP) []Element {
+	elements := make([]Element, 0, len(ips))
+	for _, ip := range ips {
+		elements = append(elements, Element{Key: ip.To4()})
+	}
+	return elements
+}
+
+// CIDRElements returns the elements of an interval set for the CIDRs.
+func CIDRElements(cidrs ...net.IPNet) []Element {
+	elements := make([]Element, 0, 2*len(cidrs))
+	for _, cidr := range cidrs {
+		start := cidr.IP.Mask(cidr.Mask).To4()
+		ones, bits := cidr.Mask.Size()
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+		end := new(big.Int).Add(new(big.Int).SetBytes(start), size)
+		elements = append(elements, Element{Key: start})
+		// the end of 255.255.255.255/x is implicit
+		if end.BitLen() <= ipv4AddrLen*8 {
+			elements = append(elements, Element{Key: end.FillBytes(make([]byte, ipv4AddrLen)), IntervalEnd: true})
+		}
+	}
+	return elements
+}
+
+type opKind int
+
+const (
+	opAddChain opKind = iota
+	opFlushChain
+	opAddSet
+	opFlushSet
+	opAddElements
+	opDeleteElements
+	opAddRule
+)
+
+type operation struct {
+	kind     opKind
+	chain    Chain
+	set      Set
+	elements []Element
+	exprs    []Expr
+}
+
+// queue queues the operation on the connection, to be sent by its next Flush.
+func (op operation) queue(conn Conn, table *gnft.Table) error {
+	switch op.kind {
+	case opAddChain:
+		conn.AddChain(op.chain.nft(table))
+	case opFlushChain:
+		conn.FlushChain(op.chain.nft(table))
+	case opAddSet:
+		return conn.AddSet(op.set.nft(table), nil) //nolint:wrapcheck // wrapped by Apply
+	case opFlushSet:
+		conn.FlushSet(op.set.nft(table))
+	case opAddElements:
+		return conn.SetAddElements(op.set.nft(table), op.elements) //nolint:wrapcheck // wrapped by Apply
+	case opDeleteElements:
+		return conn.SetDeleteElements(op.set.nft(table), op.elements) //nolint:wrapcheck // wrapped by Apply
+	case opAddRule:
+		conn.AddRule(&gnft.Rule{Table: table, Chain: op.chain.nft(table), Exprs: op.exprs})
+	}
+	return nil
+}
+
+// Batch collects operations on a table so that the Client can apply them atomically.
+type Batch struct {
+	ops []operation
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// AddChain creates the chain if it doesn't exist.
+func (b *Batch) AddChain(chain Chain) *Batch {
+	b.ops = append(b.ops, operation{kind: opAddChain, chain: chain})
+	return b
+}
+
+// FlushChain deletes all rules in the chain.
+func (b *Batch) FlushChain(chainName string) *Batch {
+	b.ops = append(b.ops, operation{kind: opFlushChain, chain: Chain{Name: chainName}})
+	return b
+}
+
+// AddSet creates the set if it doesn't exist.
+func (b *Batch) AddSet(set Set) *Batch {
+	b.ops = append(b.ops, operation{kind: opAddSet, set: set})
+	return b
+}
+
+// FlushSet deletes all elements in the set.
+func (b *Batch) FlushSet(setName string) *Batch {
+	b.ops = append(b.ops, operation{kind: opFlushSet, set: Set{Name: setName}})
+	return b
+}
+
+// AddElements adds the elements to the set if they don't exist.
+func (b *Batch) AddElements(setName string, elements ...Element) *Batch {
+	if len(elements) > 0 {
+		b.ops = append(b.ops, operation{kind: opAddElements, set: Set{Name: setName}, elements: elements})
+	}
+	return b
+}
+
+// DeleteElements deletes the elements from the set if they exist.
+func (b *Batch) DeleteElements(setName string, elements ...Element) *Batch {
+	if len(elements) > 0 {
+		b.ops = append(b.ops, operation{kind: opDeleteElements, set: Set{Name: setName}, elements: elements})
+	}
+	return b
+}
+
+// AddRule appends a rule to the chain. Rules are not deduplicated, so chains with rules should be flushed first in the same batch.
+func (b *Batch) AddRule(chainName string, exprs ...Expr) *Batch {
+	b.ops = append(b.ops, operation{kind: opAddRule, chain: Chain{Name: chainName}, exprs: exprs})
+	return b
+}
+
+// Append adds the operations of other after the operations of b.
+func (b *Batch) Append(other *Batch) *Batch {
+	b.ops = append(b.ops, other.ops...)
+	return b
+}
+
+// RuleCount returns the number of rules which the batch adds to the chain.
+func (b *Batch) RuleCount(chainName string) int {
+	n := 0
+	for _, op := range b.ops {
+		if op.kind == opAddRule && op.chain.Name == chainName {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
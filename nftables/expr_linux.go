@@ -0,0 +1,166 @@
+package nftables
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+// Conntrack state bits, as loaded by Ct with expr.CtKeySTATE
+const (
+	CtStateEstablished = expr.CtStateBitESTABLISHED
+	CtStateRelated     = expr.CtStateBitRELATED
+	CtStateNew         = expr.CtStateBitNEW
+)
+
+// offsets in the IPv4 and transport headers
+const (
+	ipv4SourceOffset = 12
+	ipv4DestOffset   = 16
+	destPortOffset   = 2
+	portLen          = 2
+)
+
+// Expr is an expression of a rule. Rules are usually built from the match and statement helpers below.
+type Expr = expr.Any
+
+// Rule joins matches and statements into the expressions of one rule.
+func Rule(parts ...[]Expr) []Expr {
+	var exprs []Expr
+	for _, part := range parts {
+		exprs = append(exprs, part...)
+	}
+	return exprs
+}
+
+// IIFName matches the input interface name, like iptables -i.
+func IIFName(name string) []Expr {
+	return []Expr{
+		&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifname(name)},
+	}
+}
+
+// OIFName matches the output interface name, like iptables -o.
+func OIFName(name string) []Expr {
+	return []Expr{
+		&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifname(name)},
+	}
+}
+
+// SourceIP matches the source address, like iptables -s.
+func SourceIP(ip net.IP) []Expr {
+	return ipMatch(ipv4SourceOffset, ip)
+}
+
+// DestIP matches the destination address, like iptables -d.
+func DestIP(ip net.IP) []Expr {
+	return ipMatch(ipv4DestOffset, ip)
+}
+
+// SourceCIDR matches the source subnet, like iptables -s with a CIDR.
+func SourceCIDR(cidr net.IPNet) []Expr {
+	mask := []byte(cidr.Mask[len(cidr.Mask)-ipv4AddrLen:])
+	return []Expr{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: ipv4SourceOffset, Len: ipv4AddrLen},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: ipv4AddrLen, Mask: mask, Xor: make([]byte, ipv4AddrLen)},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: cidr.IP.Mask(cidr.Mask).To4()},
+	}
+}
+
+// SourceInSet matches if the source address is in the set.
+func SourceInSet(setName string) []Expr {
+	return setMatch(ipv4SourceOffset, setName)
+}
+
+// DestInSet matches if the destination address is in the set.
+func DestInSet(setName string) []Expr {
+	return setMatch(ipv4DestOffset, setName)
+}
+
+// L4Proto matches the transport protocol e.g. unix.IPPROTO_TCP, like iptables -p.
+func L4Proto(proto uint8) []Expr {
+	return []Expr{
+		&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{proto}},
+	}
+}
+
+// DestPort matches the TCP or UDP destination port, like iptables --dport. It should follow L4Proto.
+func DestPort(port uint16) []Expr {
+	data := make([]byte, portLen)
+	binary.BigEndian.PutUint16(data, port)
+	return []Expr{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: destPortOffset, Len: portLen},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: data},
+	}
+}
+
+// CtState matches if the conntrack state is any of the states, like iptables -m state --state.
+func CtState(states uint32) []Expr {
+	return []Expr{
+		&expr.Ct{Key: expr.CtKeySTATE, Register: 1},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: hostUint32(states), Xor: hostUint32(0)},
+		&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: hostUint32(0)},
+	}
+}
+
+// DestNotLocal matches if the destination isn't a local address, like iptables -m addrtype ! --dst-type LOCAL.
+func DestNotLocal() []Expr {
+	return []Expr{
+		&expr.Fib{Register: 1, ResultADDRTYPE: true, FlagDADDR: true},
+		&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: hostUint32(unix.RTN_LOCAL)},
+	}
+}
+
+// Accept accepts the packet.
+func Accept() []Expr {
+	return []Expr{&expr.Verdict{Kind: expr.VerdictAccept}}
+}
+
+// Drop drops the packet.
+func Drop() []Expr {
+	return []Expr{&expr.Verdict{Kind: expr.VerdictDrop}}
+}
+
+// SNAT translates the source address to ip, like iptables -j SNAT --to.
+func SNAT(ip net.IP) []Expr {
+	return []Expr{
+		&expr.Immediate{Register: 1, Data: ip.To4()},
+		&expr.NAT{Type: expr.NATTypeSourceNAT, Family: unix.NFPROTO_IPV4, RegAddrMin: 1},
+	}
+}
+
+// Masquerade masquerades the source address, like iptables -j MASQUERADE.
+func Masquerade() []Expr {
+	return []Expr{&expr.Masq{}}
+}
+
+func ipMatch(offset uint32, ip net.IP) []Expr {
+	return []Expr{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: ipv4AddrLen},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ip.To4()},
+	}
+}
+
+func setMatch(offset uint32, setName string) []Expr {
+	return []Expr{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: ipv4AddrLen},
+		&expr.Lookup{SourceRegister: 1, SetName: setName},
+	}
+}
+
+// ifname returns the interface name with its NUL terminator, so that it doesn't match longer names.
+func ifname(name string) []byte {
+	return append([]byte(name), 0)
+}
+
+// hostUint32 encodes a value which the kernel compares in host byte order e.g. conntrack state.
+func hostUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.NativeEndian.PutUint32(b, v)
+	return b
+}
@@ -0,0 +1,367 @@
+package nftables
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	gnft "github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+type fakeTable struct {
+	chains   []Chain
+	rules    map[string][][]Expr
+	sets     map[string]Set
+	elements map[string][]Element
+}
+
+func newFakeTable() *fakeTable {
+	return &fakeTable{
+		rules:    make(map[string][][]Expr),
+		sets:     make(map[string]Set),
+		elements: make(map[string][]Element),
+	}
+}
+
+func (t *fakeTable) copy() *fakeTable {
+	c := newFakeTable()
+	c.chains = append(c.chains, t.chains...)
+	for chain, rules := range t.rules {
+		c.rules[chain] = append([][]Expr{}, rules...)
+	}
+	for name, set := range t.sets {
+		c.sets[name] = set
+		c.elements[name] = append([]Element{}, t.elements[name]...)
+	}
+	return c
+}
+
+// FakeConn is a Conn for tests. It keeps the tables in memory and applies the changes queued
+// until each Flush atomically, like the kernel applies a batch.
+type FakeConn struct {
+	tables   map[string]*fakeTable
+	pending  []func(tables map[string]*fakeTable) error
+	batches  int
+	flushErr error
+}
+
+func NewFakeConn() *FakeConn {
+	return &FakeConn{tables: make(map[string]*fakeTable)}
+}
+
+// Batches returns the number of batches which were flushed.
+func (f *FakeConn) Batches() int {
+	return f.batches
+}
+
+// SetFlushError makes Flush fail with err without changing the tables.
+func (f *FakeConn) SetFlushError(err error) {
+	f.flushErr = err
+}
+
+// HasTable returns whether the table exists.
+func (f *FakeConn) HasTable(table string) bool {
+	_, ok := f.tables[table]
+	return ok
+}
+
+// Chains returns the chains of the table in the order they were created.
+func (f *FakeConn) Chains(table string) []Chain {
+	if t, ok := f.tables[table]; ok {
+		return t.chains
+	}
+	return nil
+}
+
+// Rules returns the expressions of each rule in the chain in order.
+func (f *FakeConn) Rules(table, chainName string) [][]Expr {
+	if t, ok := f.tables[table]; ok {
+		return t.rules[chainName]
+	}
+	return nil
+}
+
+// Elements returns the elements of the set in the order they were added.
+func (f *FakeConn) Elements(table, setName string) []Element {
+	if t, ok := f.tables[table]; ok {
+		return t.elements[setName]
+	}
+	return nil
+}
+
+// SetContains returns whether the key is in the set, taking intervals into account.
+func (f *FakeConn) SetContains(table, setName string, key []byte) bool {
+	t, ok := f.tables[table]
+	if !ok {
+		return false
+	}
+	elements := t.elements[setName]
+	if !t.sets[setName].Interval {
+		for _, e := range elements {
+			if bytes.Equal(e.Key, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	sorted := append([]Element{}, elements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := bytes.Compare(sorted[i].Key, sorted[j].Key); c != 0 {
+			return c < 0
+		}
+		// an interval which ends where the next one starts
+		return sorted[i].IntervalEnd && !sorted[j].IntervalEnd
+	})
+	in := false
+	for _, e := range sorted {
+		if bytes.Compare(e.Key, key) > 0 {
+			break
+		}
+		in = !e.IntervalEnd
+	}
+	return in
+}
+
+func (f *FakeConn) Flush() error {
+	pending := f.pending
+	f.pending = nil
+	f.batches++
+	if f.flushErr != nil {
+		return f.flushErr
+	}
+
+	tables := make(map[string]*fakeTable, len(f.tables))
+	for name, t := range f.tables {
+		tables[name] = t.copy()
+	}
+	for _, apply := range pending {
+		if err := apply(tables); err != nil {
+			return err
+		}
+	}
+	f.tables = tables
+	return nil
+}
+
+func (f *FakeConn) AddTable(t *gnft.Table) *gnft.Table {
+	f.queue(func(tables map[string]*fakeTable) error {
+		if t.Family != Family {
+			return fmt.Errorf("table %s of family %d: %w", t.Name, t.Family, unix.EAFNOSUPPORT)
+		}
+		if _, ok := tables[t.Name]; !ok {
+			tables[t.Name] = newFakeTable()
+		}
+		return nil
+	})
+	return t
+}
+
+func (f *FakeConn) DelTable(t *gnft.Table) {
+	f.queue(func(tables map[string]*fakeTable) error {
+		if _, ok := tables[t.Name]; !ok {
+			return fmt.Errorf("table %s: %w", t.Name, unix.ENOENT)
+		}
+		delete(tables, t.Name)
+		return nil
+	})
+}
+
+func (f *FakeConn) ListTablesOfFamily(family gnft.TableFamily) ([]*gnft.Table, error) {
+	tables := make([]*gnft.Table, 0, len(f.tables))
+	for name := range f.tables {
+		if family == Family {
+			tables = append(tables, &gnft.Table{Name: name, Family: Family})
+		}
+	}
+	return tables, nil
+}
+
+func (f *FakeConn) AddChain(c *gnft.Chain) *gnft.Chain {
+	f.queueTable(c.Table, func(t *fakeTable) error {
+		if _, ok := t.rules[c.Name]; ok {
+			return nil
+		}
+		chain := Chain{Name: c.Name}
+		if c.Hooknum != nil && c.Priority != nil {
+			chain.Hook = &Hook{Type: string(c.Type), Hooknum: uint32(*c.Hooknum), Priority: int32(*c.Priority)}
+		}
+		t.chains = append(t.chains, chain)
+		t.rules[c.Name] = make([][]Expr, 0)
+		return nil
+	})
+	return c
+}
+
+func (f *FakeConn) FlushChain(c *gnft.Chain) {
+	f.queueTable(c.Table, func(t *fakeTable) error {
+		if _, ok := t.rules[c.Name]; !ok {
+			return fmt.Errorf("chain %s: %w", c.Name, unix.ENOENT)
+		}
+		t.rules[c.Name] = make([][]Expr, 0)
+		return nil
+	})
+}
+
+func (f *FakeConn) ListChainsOfTableFamily(family gnft.TableFamily) ([]*gnft.Chain, error) {
+	var chains []*gnft.Chain
+	for name, t := range f.tables {
+		if family != Family {
+			continue
+		}
+		for _, chain := range t.chains {
+			chains = append(chains, chain.nft(&gnft.Table{Name: name, Family: Family}))
+		}
+	}
+	return chains, nil
+}
+
+func (f *FakeConn) AddSet(s *gnft.Set, vals []gnft.SetElement) error {
+	f.queueTable(s.Table, func(t *fakeTable) error {
+		if _, ok := t.sets[s.Name]; !ok {
+			t.sets[s.Name] = Set{Name: s.Name, Interval: s.Interval}
+			t.elements[s.Name] = make([]Element, 0)
+		}
+		return t.addElements(s.Name, vals)
+	})
+	return nil
+}
+
+func (f *FakeConn) FlushSet(s *gnft.Set) {
+	f.queueTable(s.Table, func(t *fakeTable) error {
+		if _, ok := t.sets[s.Name]; !ok {
+			return fmt.Errorf("set %s: %w", s.Name, unix.ENOENT)
+		}
+		t.elements[s.Name] = make([]Element, 0)
+		return nil
+	})
+}
+
+func (f *FakeConn) GetSets(table *gnft.Table) ([]*gnft.Set, error) {
+	t, ok := f.tables[table.Name]
+	if !ok {
+		return nil, nil
+	}
+	sets := make([]*gnft.Set, 0, len(t.sets))
+	for _, set := range t.sets {
+		sets = append(sets, set.nft(table))
+	}
+	return sets, nil
+}
+
+func (f *FakeConn) SetAddElements(s *gnft.Set, vals []gnft.SetElement) error {
+	f.queueTable(s.Table, func(t *fakeTable) error {
+		return t.addElements(s.Name, vals)
+	})
+	return nil
+}
+
+func (f *FakeConn) SetDeleteElements(s *gnft.Set, vals []gnft.SetElement) error {
+	f.queueTable(s.Table, func(t *fakeTable) error {
+		if _, ok := t.sets[s.Name]; !ok {
+			return fmt.Errorf("set %s: %w", s.Name, unix.ENOENT)
+		}
+		for _, e := range vals {
+			i := indexOf(t.elements[s.Name], e)
+			if i < 0 {
+				return fmt.Errorf("element %s of set %s: %w", elementID(e), s.Name, unix.ENOENT)
+			}
+			t.elements[s.Name] = append(t.elements[s.Name][:i], t.elements[s.Name][i+1:]...)
+		}
+		return nil
+	})
+	return nil
+}
+
+func (f *FakeConn) GetSetElements(s *gnft.Set) ([]gnft.SetElement, error) {
+	t, ok := f.tables[s.Table.Name]
+	if !ok {
+		return nil, fmt.Errorf("table %s: %w", s.Table.Name, unix.ENOENT)
+	}
+	if _, ok := t.sets[s.Name]; !ok {
+		return nil, fmt.Errorf("set %s: %w", s.Name, unix.ENOENT)
+	}
+	return append([]Element{}, t.elements[s.Name]...), nil
+}
+
+func (f *FakeConn) AddRule(r *gnft.Rule) *gnft.Rule {
+	f.queueTable(r.Table, func(t *fakeTable) error {
+		if _, ok := t.rules[r.Chain.Name]; !ok {
+			return fmt.Errorf("chain %s: %w", r.Chain.Name, unix.ENOENT)
+		}
+		for _, e := range r.Exprs {
+			if lookup, ok := e.(*expr.Lookup); ok {
+				if _, ok := t.sets[lookup.SetName]; !ok {
+					return fmt.Errorf("set %s: %w", lookup.SetName, unix.ENOENT)
+				}
+			}
+			if verdict, ok := e.(*expr.Verdict); ok && verdict.Chain != "" {
+				if _, ok := t.rules[verdict.Chain]; !ok {
+					return fmt.Errorf("chain %s: %w", verdict.Chain, unix.ENOENT)
+				}
+			}
+		}
+		t.rules[r.Chain.Name] = append(t.rules[r.Chain.Name], r.Exprs)
+		return nil
+	})
+	return r
+}
+
+func (f *FakeConn) GetRules(table *gnft.Table, chain *gnft.Chain) ([]*gnft.Rule, error) {
+	t, ok := f.tables[table.Name]
+	if !ok {
+		return nil, fmt.Errorf("table %s: %w", table.Name, unix.ENOENT)
+	}
+	exprs, ok := t.rules[chain.Name]
+	if !ok {
+		return nil, fmt.Errorf("chain %s: %w", chain.Name, unix.ENOENT)
+	}
+	rules := make([]*gnft.Rule, 0, len(exprs))
+	for _, e := range exprs {
+		rules = append(rules, &gnft.Rule{Table: table, Chain: chain, Exprs: e})
+	}
+	return rules, nil
+}
+
+// DeleteRule deletes the rule at the index of the chain, like a rule which is deleted behind the client's back.
+func (f *FakeConn) DeleteRule(table, chainName string, index int) {
+	t := f.tables[table]
+	t.rules[chainName] = append(t.rules[chainName][:index:index], t.rules[chainName][index+1:]...)
+}
+
+func (f *FakeConn) queue(apply func(tables map[string]*fakeTable) error) {
+	f.pending = append(f.pending, apply)
+}
+
+func (f *FakeConn) queueTable(table *gnft.Table, apply func(t *fakeTable) error) {
+	f.queue(func(tables map[string]*fakeTable) error {
+		t, ok := tables[table.Name]
+		if !ok {
+			return fmt.Errorf("table %s: %w", table.Name, unix.ENOENT)
+		}
+		return apply(t)
+	})
+}
+
+func (t *fakeTable) addElements(setName string, elements []Element) error {
+	if _, ok := t.sets[setName]; !ok {
+		return fmt.Errorf("set %s: %w", setName, unix.ENOENT)
+	}
+	for _, e := range elements {
+		if indexOf(t.elements[setName], e) < 0 {
+			t.elements[setName] = append(t.elements[setName], e)
+		}
+	}
+	return nil
+}
+
+func indexOf(elements []Element, e Element) int {
+	for i := range elements {
+		if elementID(elements[i]) == elementID(e) {
+			return i
+		}
+	}
+	return -1
+}
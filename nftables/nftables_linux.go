@@ -0,0 +1,215 @@
+// Package nftables programs host rules in a dedicated nftables table with github.com/google/nftables.
+// It is an alternative to the iptables package for the host SNAT and forwarding rules:
+// per-endpoint state lives in named sets, so adding or removing an endpoint updates set elements
+// instead of inserting or deleting rules.
+package nftables
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni/log"
+	gnft "github.com/google/nftables"
+	"go.uber.org/zap"
+)
+
+const (
+	// Table is the name of the table which holds the CNI host rules.
+	Table = "azure"
+	// Family is the family of the tables. The host SNAT rules are IPv4 only.
+	Family = gnft.TableFamilyIPv4
+)
+
+var logger = log.CNILogger.With(zap.String("component", "cni-nftables"))
+
+// Conn is the subset of *nftables.Conn which the Client uses. Changes are queued until Flush,
+// which sends them as one batch that the kernel applies atomically.
+type Conn interface {
+	AddTable(t *gnft.Table) *gnft.Table
+	DelTable(t *gnft.Table)
+	ListTablesOfFamily(family gnft.TableFamily) ([]*gnft.Table, error)
+	AddChain(c *gnft.Chain) *gnft.Chain
+	FlushChain(c *gnft.Chain)
+	ListChainsOfTableFamily(family gnft.TableFamily) ([]*gnft.Chain, error)
+	AddSet(s *gnft.Set, vals []gnft.SetElement) error
+	FlushSet(s *gnft.Set)
+	GetSets(t *gnft.Table) ([]*gnft.Set, error)
+	SetAddElements(s *gnft.Set, vals []gnft.SetElement) error
+	SetDeleteElements(s *gnft.Set, vals []gnft.SetElement) error
+	GetSetElements(s *gnft.Set) ([]gnft.SetElement, error)
+	AddRule(r *gnft.Rule) *gnft.Rule
+	GetRules(t *gnft.Table, c *gnft.Chain) ([]*gnft.Rule, error)
+	Flush() error
+}
+
+// Client applies batches to one table.
+type Client struct {
+	table *gnft.Table
+	// newConn returns the connection of one Apply, so that the changes of a failed Apply are never flushed by the next one
+	newConn func() Conn
+}
+
+// NewClient returns a client for the table.
+func NewClient(table string) *Client {
+	return &Client{
+		table:   &gnft.Table{Name: table, Family: Family},
+		newConn: func() Conn { return &gnft.Conn{} },
+	}
+}
+
+// NewClientWithConn returns a client for the table which uses conn, e.g. a FakeConn in tests.
+func NewClientWithConn(table string, conn Conn) *Client {
+	return &Client{
+		table:   &gnft.Table{Name: table, Family: Family},
+		newConn: func() Conn { return conn },
+	}
+}
+
+// Apply applies the batch atomically. The table is always created first if it doesn't exist.
+// Creating chains, sets, and elements which already exist is a no-op, and elements which don't exist aren't deleted.
+func (c *Client) Apply(b *Batch) error {
+	conn := c.newConn()
+	ops, err := c.skipMissingElements(conn, b.ops)
+	if err != nil {
+		return err
+	}
+
+	conn.AddTable(c.table)
+	for _, op := range ops {
+		if err := op.queue(conn, c.table); err != nil {
+			return fmt.Errorf("failed to queue nftables operation on table %s: %w", c.table.Name, err)
+		}
+	}
+
+	logger.Info("Applying nftables batch", zap.String("table", c.table.Name), zap.Int("operations", len(ops)))
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("failed to apply nftables batch to table %s: %w", c.table.Name, err)
+	}
+	return nil
+}
+
+// HasChains returns whether all of the chains exist in the table.
+func (c *Client) HasChains(names ...string) (bool, error) {
+	chains, err := c.newConn().ListChainsOfTableFamily(Family)
+	if err != nil {
+		return false, fmt.Errorf("failed to list chains of table %s: %w", c.table.Name, err)
+	}
+	existing := make(map[string]struct{}, len(chains))
+	for _, chain := range chains {
+		if chain.Table != nil && chain.Table.Name == c.table.Name {
+			existing[chain.Name] = struct{}{}
+		}
+	}
+	for _, name := range names {
+		if _, ok := existing[name]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// RuleCount returns the number of rules in the chain. The chain must exist, see HasChains.
+func (c *Client) RuleCount(chainName string) (int, error) {
+	rules, err := c.newConn().GetRules(c.table, &gnft.Chain{Table: c.table, Name: chainName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list rules of chain %s in table %s: %w", chainName, c.table.Name, err)
+	}
+	return len(rules), nil
+}
+
+// DeleteTable deletes the table with all of its chains and sets, if it exists.
+func (c *Client) DeleteTable() error {
+	conn := c.newConn()
+	exists, err := c.tableExists(conn)
+	if err != nil || !exists {
+		return err
+	}
+
+	conn.DelTable(c.table)
+	logger.Info("Deleting nftables table", zap.String("table", c.table.Name))
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("failed to delete nftables table %s: %w", c.table.Name, err)
+	}
+	return nil
+}
+
+// Elements returns the elements of the set, or nothing if the set doesn't exist.
+func (c *Client) Elements(setName string) ([]Element, error) {
+	return c.elements(c.newConn(), setName)
+}
+
+func (c *Client) elements(conn Conn, setName string) ([]Element, error) {
+	// the kernel fails to dump the elements of a missing set, so check that the set exists first
+	exists, err := c.tableExists(conn)
+	if err != nil || !exists {
+		return nil, err
+	}
+	sets, err := conn.GetSets(c.table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sets of table %s: %w", c.table.Name, err)
+	}
+	for _, set := range sets {
+		if set.Name != setName {
+			continue
+		}
+		elements, err := conn.GetSetElements(c.set(setName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list elements of set %s: %w", setName, err)
+		}
+		return elements, nil
+	}
+	return nil, nil
+}
+
+func (c *Client) tableExists(conn Conn) (bool, error) {
+	tables, err := conn.ListTablesOfFamily(Family)
+	if err != nil {
+		return false, fmt.Errorf("failed to list nftables tables: %w", err)
+	}
+	for _, table := range tables {
+		if table.Name == c.table.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *Client) set(name string) *gnft.Set {
+	return &gnft.Set{Table: c.table, Name: name, KeyType: gnft.TypeIPAddr}
+}
+
+// skipMissingElements returns the operations without the deletes of elements which don't exist,
+// since deleting a missing element fails the whole batch.
+func (c *Client) skipMissingElements(conn Conn, ops []operation) ([]operation, error) {
+	result := make([]operation, 0, len(ops))
+	existing := make(map[string]map[string]struct{})
+	for _, op := range ops {
+		if op.kind != opDeleteElements {
+			result = append(result, op)
+			continue
+		}
+		keys, ok := existing[op.set.Name]
+		if !ok {
+			elements, err := c.elements(conn, op.set.Name)
+			if err != nil {
+				return nil, err
+			}
+			keys = make(map[string]struct{}, len(elements))
+			for _, e := range elements {
+				keys[elementID(e)] = struct{}{}
+			}
+			existing[op.set.Name] = keys
+		}
+
+		present := make([]Element, 0, len(op.elements))
+		for _, e := range op.elements {
+			if _, ok := keys[elementID(e)]; ok {
+				present = append(present, e)
+			}
+		}
+		if len(present) > 0 {
+			op.elements = present
+			result = append(result, op)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package nftables
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	bridgeIP      = net.ParseIP("169.254.128.1")
+	podIP         = net.ParseIP("10.0.1.4")
+	_, podCIDR, _ = net.ParseCIDR("10.0.1.0/24")
+)
+
+func TestApplyBatch(t *testing.T) {
+	fake := NewFakeConn()
+	client := NewClientWithConn(Table, fake)
+
+	forward := Chain{Name: "forward", Hook: &Hook{Type: ChainTypeFilter, Hooknum: unix.NF_INET_FORWARD, Priority: PriorityFilter}}
+	rule := Rule(IIFName("azSnatbr"), DestInSet("vnet"), CtState(CtStateEstablished|CtStateRelated), Drop())
+	snat := Rule(SourceCIDR(*podCIDR), DestNotLocal(), L4Proto(unix.IPPROTO_UDP), DestPort(53), SNAT(bridgeIP))
+	b := NewBatch().
+		AddChain(forward).
+		AddSet(Set{Name: "vnet", Interval: true}).
+		AddSet(Set{Name: "pods"}).
+		AddElements("vnet", CIDRElements(*podCIDR)...).
+		AddElements("pods", IPElements(podIP)...).
+		FlushChain("forward").
+		AddRule("forward", rule...).
+		AddRule("forward", snat...)
+	require.NoError(t, client.Apply(b))
+
+	require.Equal(t, []Chain{forward}, fake.Chains(Table))
+	require.Equal(t, [][]Expr{rule, snat}, fake.Rules(Table, "forward"))
+	require.Equal(t, []Element{
+		{Key: []byte{10, 0, 1, 0}},
+		{Key: []byte{10, 0, 2, 0}, IntervalEnd: true},
+	}, fake.Elements(Table, "vnet"))
+	require.True(t, fake.SetContains(Table, "vnet", []byte{10, 0, 1, 255}))
+	require.False(t, fake.SetContains(Table, "vnet", []byte{10, 0, 2, 0}))
+	require.True(t, fake.SetContains(Table, "pods", podIP.To4()))
+
+	// applying again flushes the chain and doesn't duplicate anything
+	require.NoError(t, client.Apply(b))
+	require.Equal(t, [][]Expr{rule, snat}, fake.Rules(Table, "forward"))
+	require.Len(t, fake.Elements(Table, "vnet"), 2)
+	require.Len(t, fake.Elements(Table, "pods"), 1)
+	require.Equal(t, 2, fake.Batches())
+}
+
+func TestDeleteElements(t *testing.T) {
+	fake := NewFakeConn()
+	client := NewClientWithConn(Table, fake)
+
+	// the set doesn't exist yet
+	require.NoError(t, client.Apply(NewBatch().DeleteElements("pods", IPElements(podIP)...)))
+
+	other := net.ParseIP("10.0.1.5")
+	require.NoError(t, client.Apply(NewBatch().AddSet(Set{Name: "pods"}).AddElements("pods", IPElements(podIP, other)...)))
+	elements, err := client.Elements("pods")
+	require.NoError(t, err)
+	require.Equal(t, IPElements(podIP, other), elements)
+
+	// deleting an element twice is a no-op
+	require.NoError(t, client.Apply(NewBatch().DeleteElements("pods", IPElements(podIP)...)))
+	require.NoError(t, client.Apply(NewBatch().DeleteElements("pods", IPElements(podIP)...)))
+	require.Equal(t, IPElements(other), fake.Elements(Table, "pods"))
+
+	require.NoError(t, client.Apply(NewBatch().FlushSet("pods")))
+	require.Empty(t, fake.Elements(Table, "pods"))
+}
+
+func TestApplyBatchIsAtomic(t *testing.T) {
+	fake := NewFakeConn()
+	client := NewClientWithConn(Table, fake)
+
+	// the rule refers to a set which doesn't exist, so the chain isn't created either
+	b := NewBatch().
+		AddChain(Chain{Name: "input"}).
+		AddRule("input", Rule(SourceInSet("missing"), Accept())...)
+	err := client.Apply(b)
+	require.ErrorIs(t, err, unix.ENOENT)
+	require.Empty(t, fake.Chains(Table))
+
+	errFlush := errors.New("operation not permitted")
+	fake.SetFlushError(errFlush)
+	require.ErrorIs(t, client.Apply(NewBatch().AddChain(Chain{Name: "input"})), errFlush)
+	require.Empty(t, fake.Chains(Table))
+}
+
+func TestHasChainsRuleCountAndDeleteTable(t *testing.T) {
+	fake := NewFakeConn()
+	client := NewClientWithConn(Table, fake)
+	other := NewClientWithConn("other", fake)
+
+	// deleting a missing table is a no-op
+	require.NoError(t, client.DeleteTable())
+
+	require.NoError(t, client.Apply(NewBatch().AddChain(Chain{Name: "input"}).AddChain(Chain{Name: "output"})))
+	require.NoError(t, other.Apply(NewBatch().AddChain(Chain{Name: "forward"})))
+
+	ok, err := client.HasChains("input", "output")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = client.HasChains("input", "forward")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	rules := NewBatch().AddRule("input", Accept()...).AddRule("input", Drop()...)
+	require.Equal(t, 2, rules.RuleCount("input"))
+	require.NoError(t, client.Apply(rules))
+	fake.DeleteRule(Table, "input", 0)
+	n, err := client.RuleCount("input")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = client.RuleCount("missing")
+	require.Error(t, err)
+
+	require.NoError(t, client.DeleteTable())
+	require.False(t, fake.HasTable(Table))
+	require.True(t, fake.HasTable("other"))
+	ok, err = client.HasChains("input")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -12,9 +12,13 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/nmagent/internal"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/Azure/azure-container-networking/nmagent")
+
 // NewClient returns an initialized Client using the provided configuration.
 func NewClient(c Config) (*Client, error) {
 	if err := c.Validate(); err != nil {
@@ -54,7 +58,10 @@ type Client struct {
 }
 
 // JoinNetwork joins a node to a customer's virtual network.
-func (c *Client) JoinNetwork(ctx context.Context, jnr JoinNetworkRequest) error {
+func (c *Client) JoinNetwork(ctx context.Context, jnr JoinNetworkRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.JoinNetwork")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, jnr)
 	if err != nil {
 		return errors.Wrap(err, "building request")
@@ -77,7 +84,10 @@ func (c *Client) JoinNetwork(ctx context.Context, jnr JoinNetworkRequest) error
 }
 
 // DeleteNetwork deletes a customer network and it's associated subnets.
-func (c *Client) DeleteNetwork(ctx context.Context, dnr DeleteNetworkRequest) error {
+func (c *Client) DeleteNetwork(ctx context.Context, dnr DeleteNetworkRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.DeleteNetwork")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, dnr)
 	if err != nil {
 		return errors.Wrap(err, "building request")
@@ -97,7 +107,10 @@ func (c *Client) DeleteNetwork(ctx context.Context, dnr DeleteNetworkRequest) er
 
 // GetNetworkConfiguration retrieves the configuration of a customer's virtual
 // network. Only subnets which have been delegated will be returned.
-func (c *Client) GetNetworkConfiguration(ctx context.Context, gncr GetNetworkConfigRequest) (VirtualNetwork, error) {
+func (c *Client) GetNetworkConfiguration(ctx context.Context, gncr GetNetworkConfigRequest) (_ VirtualNetwork, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.GetNetworkConfiguration")
+	defer func() { tracing.End(span, err) }()
+
 	var out VirtualNetwork
 
 	req, err := c.buildRequest(ctx, gncr)
@@ -138,7 +151,10 @@ func (c *Client) GetNetworkConfiguration(ctx context.Context, gncr GetNetworkCon
 // request must originate from a VM network interface that has a Swift
 // Provisioning OwningServiceInstanceId property. The authentication token must
 // match the token on the subnet containing the Network Container address.
-func (c *Client) GetNCVersion(ctx context.Context, ncvr NCVersionRequest) (NCVersion, error) {
+func (c *Client) GetNCVersion(ctx context.Context, ncvr NCVersionRequest) (_ NCVersion, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.GetNCVersion")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, ncvr)
 	if err != nil {
 		return NCVersion{}, errors.Wrap(err, "building request")
@@ -165,7 +181,10 @@ func (c *Client) GetNCVersion(ctx context.Context, ncvr NCVersionRequest) (NCVer
 
 // PutNetworkContainer applies a Network Container goal state and publishes it
 // to PubSub.
-func (c *Client) PutNetworkContainer(ctx context.Context, pncr *PutNetworkContainerRequest) error {
+func (c *Client) PutNetworkContainer(ctx context.Context, pncr *PutNetworkContainerRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.PutNetworkContainer")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, pncr)
 	if err != nil {
 		return errors.Wrap(err, "building request")
@@ -185,7 +204,10 @@ func (c *Client) PutNetworkContainer(ctx context.Context, pncr *PutNetworkContai
 
 // SupportedAPIs retrieves the capabilities of the nmagent running on
 // the node. This is useful for detecting if GRE Keys are supported.
-func (c *Client) SupportedAPIs(ctx context.Context) ([]string, error) {
+func (c *Client) SupportedAPIs(ctx context.Context) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.SupportedAPIs")
+	defer func() { tracing.End(span, err) }()
+
 	sar := &SupportedAPIsRequest{}
 	req, err := c.buildRequest(ctx, sar)
 	if err != nil {
@@ -212,7 +234,10 @@ func (c *Client) SupportedAPIs(ctx context.Context) ([]string, error) {
 
 // DeleteNetworkContainer removes a Network Container, its associated IP
 // addresses, and network policies from an interface.
-func (c *Client) DeleteNetworkContainer(ctx context.Context, dcr DeleteContainerRequest) error {
+func (c *Client) DeleteNetworkContainer(ctx context.Context, dcr DeleteContainerRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.DeleteNetworkContainer")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, dcr)
 	if err != nil {
 		return errors.Wrap(err, "building request")
@@ -231,7 +256,10 @@ func (c *Client) DeleteNetworkContainer(ctx context.Context, dcr DeleteContainer
 	return nil
 }
 
-func (c *Client) GetNCVersionList(ctx context.Context) (NCVersionList, error) {
+func (c *Client) GetNCVersionList(ctx context.Context) (_ NCVersionList, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.GetNCVersionList")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, &NCVersionListRequest{})
 	if err != nil {
 		return NCVersionList{}, errors.Wrap(err, "building request")
@@ -257,7 +285,10 @@ func (c *Client) GetNCVersionList(ctx context.Context) (NCVersionList, error) {
 }
 
 // GetHomeAz gets node's home az from nmagent
-func (c *Client) GetHomeAz(ctx context.Context) (AzResponse, error) {
+func (c *Client) GetHomeAz(ctx context.Context) (_ AzResponse, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.GetHomeAz")
+	defer func() { tracing.End(span, err) }()
+
 	getHomeAzRequest := &GetHomeAzRequest{}
 	var homeAzResponse AzResponse
 	req, err := c.buildRequest(ctx, getHomeAzRequest)
@@ -284,7 +315,10 @@ func (c *Client) GetHomeAz(ctx context.Context) (AzResponse, error) {
 }
 
 // GetInterfaceIPInfo fetches the node's interface IP information from nmagent
-func (c *Client) GetInterfaceIPInfo(ctx context.Context) (Interfaces, error) {
+func (c *Client) GetInterfaceIPInfo(ctx context.Context) (_ Interfaces, err error) {
+	ctx, span := tracer.Start(ctx, "nmagent.GetInterfaceIPInfo")
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.buildRequest(ctx, &GetSecondaryIPsRequest{})
 	var out Interfaces
 
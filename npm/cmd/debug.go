@@ -31,6 +31,11 @@ func newDebugCmd() *cobra.Command {
 	debugCmd.AddCommand(newParseIPTableCmd())
 	debugCmd.AddCommand(newConvertIPTableCmd())
 	debugCmd.AddCommand(newGetTuples())
+	debugCmd.AddCommand(newWhatIf())
+	debugCmd.AddCommand(newGetIPSets())
+	debugCmd.AddCommand(newGetPolicies())
+	debugCmd.AddCommand(newGetSelectedPods())
+	debugCmd.AddCommand(newGetSelectingPolicies())
 
 	return debugCmd
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/http/client"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/debug"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errPolicyNotSpecified = errors.New("policy key not specified")
+	errPodNotSpecified    = errors.New("pod key not specified")
+	errInvalidStatus      = errors.New("status must be applied or pending")
+)
+
+func newLocalNPMHttpClient() *client.NPMHttpClient {
+	return client.NewNPMHttpClient("http://localhost:" + api.DefaultHttpPort)
+}
+
+// printResponse prints the response as JSON if requested, otherwise with the pretty printer.
+func printResponse(output string, resp any, prettyPrint func()) error {
+	if output != jsonOutput {
+		prettyPrint()
+		return nil
+	}
+
+	b, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+func newGetIPSets() *cobra.Command {
+	getIPSetsCmd := &cobra.Command{
+		Use:   "getipsets",
+		Short: "Get the ipsets in NPM's cache with their members and references",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _ := cmd.Flags().GetString("name")
+			setType, _ := cmd.Flags().GetString("type")
+			member, _ := cmd.Flags().GetString("member")
+			output, _ := cmd.Flags().GetString("output")
+
+			resp, err := newLocalNPMHttpClient().DescribeIPSets(&api.DescribeIPSetRequest{
+				Name:   name,
+				Type:   setType,
+				Member: member,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get ipsets from NPM: %w", err)
+			}
+
+			return printResponse(output, resp, func() { debug.PrettyPrintIPSets(resp) })
+		},
+	}
+
+	getIPSetsCmd.Flags().String("name", "", "Filter by the unprefixed, prefixed, or hashed set name")
+	getIPSetsCmd.Flags().String("type", "", "Filter by the set type (e.g. KeyValueLabelOfPod)")
+	getIPSetsCmd.Flags().String("member", "", "Filter by a member IP or member set name")
+	getIPSetsCmd.Flags().StringP("output", "o", "", "Set the output format (json or empty for text)")
+
+	return getIPSetsCmd
+}
+
+func newGetPolicies() *cobra.Command {
+	getPoliciesCmd := &cobra.Command{
+		Use:   "getpolicies",
+		Short: "Get the translated policies in NPM with their ACLs and status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _ := cmd.Flags().GetString("namespace")
+			status, _ := cmd.Flags().GetString("status")
+			output, _ := cmd.Flags().GetString("output")
+			if status != "" && status != string(api.PolicyApplied) && status != string(api.PolicyPending) {
+				return errInvalidStatus
+			}
+
+			resp, err := newLocalNPMHttpClient().DescribePolicies(&api.DescribePolicyRequest{
+				Namespace: namespace,
+				Status:    api.PolicyStatus(status),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get policies from NPM: %w", err)
+			}
+
+			return printResponse(output, resp, func() { debug.PrettyPrintPolicies(resp) })
+		},
+	}
+
+	getPoliciesCmd.Flags().StringP("namespace", "n", "", "Filter by the namespace, or by ADMIN or BASELINE for admin network policies")
+	getPoliciesCmd.Flags().String("status", "", "Filter by the status (applied or pending)")
+	getPoliciesCmd.Flags().StringP("output", "o", "", "Set the output format (json or empty for text)")
+
+	return getPoliciesCmd
+}
+
+func newGetSelectedPods() *cobra.Command {
+	getSelectedPodsCmd := &cobra.Command{
+		Use:   "getselectedpods",
+		Short: "Get the pods selected by a policy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyKey, _ := cmd.Flags().GetString("policy")
+			if policyKey == "" {
+				return errPolicyNotSpecified
+			}
+			output, _ := cmd.Flags().GetString("output")
+
+			resp, err := newLocalNPMHttpClient().GetPodsSelectedByPolicy(policyKey)
+			if err != nil {
+				return fmt.Errorf("failed to get selected pods from NPM: %w", err)
+			}
+
+			return printResponse(output, resp, func() { debug.PrettyPrintPolicyPods(resp) })
+		},
+	}
+
+	getSelectedPodsCmd.Flags().StringP("policy", "p", "", "Set the policy key (<namespace>/<name>, or ADMIN/<name> and BASELINE/<name> for admin network policies)")
+	getSelectedPodsCmd.Flags().StringP("output", "o", "", "Set the output format (json or empty for text)")
+
+	return getSelectedPodsCmd
+}
+
+func newGetSelectingPolicies() *cobra.Command {
+	getSelectingPoliciesCmd := &cobra.Command{
+		Use:   "getselectingpolicies",
+		Short: "Get the policies selecting a pod",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			podKey, _ := cmd.Flags().GetString("pod")
+			if podKey == "" {
+				return errPodNotSpecified
+			}
+			output, _ := cmd.Flags().GetString("output")
+
+			resp, err := newLocalNPMHttpClient().GetPoliciesSelectingPod(podKey)
+			if err != nil {
+				return fmt.Errorf("failed to get selecting policies from NPM: %w", err)
+			}
+
+			return printResponse(output, resp, func() { debug.PrettyPrintPodPolicies(resp) })
+		},
+	}
+
+	getSelectingPoliciesCmd.Flags().String("pod", "", "Set the pod key (<namespace>/<name>)")
+	getSelectingPoliciesCmd.Flags().StringP("output", "o", "", "Set the output format (json or empty for text)")
+
+	return getSelectingPoliciesCmd
+}
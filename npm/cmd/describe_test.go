@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+const (
+	getIPSetsCmdString            = "getipsets"
+	getPoliciesCmdString          = "getpolicies"
+	getSelectedPodsCmdString      = "getselectedpods"
+	getSelectingPoliciesCmdString = "getselectingpolicies"
+)
+
+// (TODO) test cases where HTTP requests are made to NPM
+func TestDescribeCmds(t *testing.T) {
+	tests := []*testCases{
+		{
+			name:    "getipsets unknown shorthand flag",
+			args:    []string{debugCmdString, getIPSetsCmdString, unknownShorthandFlag},
+			wantErr: true,
+		},
+		{
+			name:    "getpolicies invalid status",
+			args:    []string{debugCmdString, getPoliciesCmdString, "--status", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "getselectedpods no policy",
+			args:    []string{debugCmdString, getSelectedPodsCmdString},
+			wantErr: true,
+		},
+		{
+			name:    "getselectingpolicies no pod",
+			args:    []string{debugCmdString, getSelectingPoliciesCmdString},
+			wantErr: true,
+		},
+	}
+
+	testCommand(t, tests)
+}
@@ -4,7 +4,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/Azure/azure-container-networking/common"
@@ -14,21 +16,27 @@ import (
 	restserver "github.com/Azure/azure-container-networking/npm/http/server"
 	"github.com/Azure/azure-container-networking/npm/metrics"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/flowlog"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
 	"github.com/Azure/azure-container-networking/npm/pkg/models"
 	"github.com/Azure/azure-container-networking/npm/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 	"k8s.io/utils/exec"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	policyclientset "sigs.k8s.io/network-policy-api/pkg/client/clientset/versioned"
+	policyinformers "sigs.k8s.io/network-policy-api/pkg/client/informers/externalversions"
 )
 
 var npmV2DataplaneCfg = &dataplane.Config{
@@ -143,12 +151,32 @@ func start(config npmconfig.Config, flags npmconfig.Flags) error {
 	if err != nil {
 		klog.Infof("CreateTelemetryHandle failed with error %v. AITelemetry is not initialized.", err)
 	}
+	if config.OTLP != nil {
+		if err := metrics.CreateOTLPTelemetryHandle(config.NPMVersion(), version, *config.OTLP); err != nil {
+			klog.Infof("CreateOTLPTelemetryHandle failed with error %v. OTLP telemetry is not initialized.", err)
+		}
+	}
 
 	var dp dataplane.GenericDataplane
 	stopChannel := wait.NeverStop
 	if config.Toggles.EnableV2NPM {
 		// update the dataplane config
 		npmV2DataplaneCfg.EnableNPMLite = config.Toggles.EnableNPMLite
+		npmV2DataplaneCfg.EnableNftables = config.Toggles.EnableNftables
+		npmV2DataplaneCfg.EnableIPv6 = config.Toggles.EnableIPv6
+		npmV2DataplaneCfg.EnableAdminNetworkPolicy = config.Toggles.EnableAdminNetworkPolicy
+
+		npmV2DataplaneCfg.EnableDenyFlowLogging = config.Toggles.EnableDenyFlowLogging
+		if config.DenyFlowLogging.NflogGroup > 0 {
+			npmV2DataplaneCfg.DenyFlowLogGroup = uint16(config.DenyFlowLogging.NflogGroup)
+		} else {
+			npmV2DataplaneCfg.DenyFlowLogGroup = uint16(npmconfig.DefaultConfig.DenyFlowLogging.NflogGroup)
+		}
+		if config.DenyFlowLogging.RateLimitPerSecond > 0 {
+			npmV2DataplaneCfg.DenyFlowLogRatePerSecond = config.DenyFlowLogging.RateLimitPerSecond
+		} else {
+			npmV2DataplaneCfg.DenyFlowLogRatePerSecond = npmconfig.DefaultConfig.DenyFlowLogging.RateLimitPerSecond
+		}
 
 		npmV2DataplaneCfg.MaxBatchedACLsPerPod = config.MaxBatchedACLsPerPod
 
@@ -212,6 +240,37 @@ func start(config npmconfig.Config, flags npmconfig.Flags) error {
 	k8sServerVersion := k8sServerVersion(clientset)
 	npMgr := npm.NewNetworkPolicyManager(config, factory, podFactory, dp, exec.New(), version, k8sServerVersion)
 
+	// NewDataPlane turns off EnableAdminNetworkPolicy if the dataplane doesn't support it
+	if config.Toggles.EnableV2NPM && npmV2DataplaneCfg.EnableAdminNetworkPolicy {
+		installed, err := adminNetworkPolicyCRDsInstalled(clientset.Discovery())
+		if err != nil {
+			return fmt.Errorf("failed to check for the AdminNetworkPolicy CRDs: %w", err)
+		}
+		if installed {
+			policyClientset, err := policyclientset.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to generate AdminNetworkPolicy clientset with cluster config: %w", err)
+			}
+			npMgr.WatchAdminNetworkPolicies(policyinformers.NewSharedInformerFactory(policyClientset, resyncPeriod))
+		} else {
+			metrics.SendErrorLogAndMetric(util.NpmID, "error: AdminNetworkPolicy is enabled but the AdminNetworkPolicy and BaselineAdminNetworkPolicy CRDs of %s are not installed, not watching them", policyv1alpha1.GroupVersion)
+		}
+	}
+
+	// NewDataPlane turns off EnableDenyFlowLogging if the dataplane doesn't support it
+	if config.Toggles.EnableV2NPM && npmV2DataplaneCfg.EnableDenyFlowLogging {
+		var out io.Writer
+		if config.DenyFlowLogging.Output != npmconfig.DenyFlowLogOutputMetrics {
+			out = os.Stdout
+		}
+		logger := flowlog.NewLogger(npMgr.DeniedFlowResolver(), out)
+		go func() {
+			if err := logger.Run(npmV2DataplaneCfg.DenyFlowLogGroup, stopChannel); err != nil {
+				metrics.SendErrorLogAndMetric(util.NpmID, "error: stopped logging denied flows: %v", err)
+			}
+		}()
+	}
+
 	go restserver.NPMRestServerListenAndServe(config, npMgr)
 
 	metrics.SendLog(util.NpmID, "starting NPM", metrics.PrintLog)
@@ -234,6 +293,28 @@ func initLogging() error {
 	return nil
 }
 
+// adminNetworkPolicyCRDsInstalled checks that the API server serves AdminNetworkPolicies and
+// BaselineAdminNetworkPolicies. Their CRDs are installed separately, and without them the informers would never sync.
+func adminNetworkPolicyCRDsInstalled(d discovery.DiscoveryInterface) (bool, error) {
+	resources, err := d.ServerResourcesForGroupVersion(policyv1alpha1.GroupVersion.String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to discover the resources of %s: %w", policyv1alpha1.GroupVersion, err)
+	}
+	var anp, banp bool
+	for i := range resources.APIResources {
+		switch resources.APIResources[i].Name {
+		case "adminnetworkpolicies":
+			anp = true
+		case "baselineadminnetworkpolicies":
+			banp = true
+		}
+	}
+	return anp && banp, nil
+}
+
 func k8sServerVersion(kubeclientset kubernetes.Interface) *k8sversion.Info {
 	var err error
 	var serverVersion *k8sversion.Info
@@ -102,6 +102,11 @@ func startDaemon(config npmconfig.Config) error {
 	if err != nil {
 		klog.Infof("CreateTelemetryHandle failed with error %v. AITelemetry is not initialized.", err)
 	}
+	if config.OTLP != nil {
+		if err := metrics.CreateOTLPTelemetryHandle(config.NPMVersion(), version, *config.OTLP); err != nil {
+			klog.Infof("CreateOTLPTelemetryHandle failed with error %v. OTLP telemetry is not initialized.", err)
+		}
+	}
 
 	err = n.Start(config, wait.NeverStop)
 	if err != nil {
@@ -121,6 +121,11 @@ func startControlplane(config npmconfig.Config, flags npmconfig.Flags) error {
 	if err != nil {
 		klog.Infof("CreateTelemetryHandle failed with error %v. AITelemetry is not initialized.", err)
 	}
+	if config.OTLP != nil {
+		if err := metrics.CreateOTLPTelemetryHandle(config.NPMVersion(), version, *config.OTLP); err != nil {
+			klog.Infof("CreateOTLPTelemetryHandle failed with error %v. OTLP telemetry is not initialized.", err)
+		}
+	}
 
 	go restserver.NPMRestServerListenAndServe(config, npMgr)
 
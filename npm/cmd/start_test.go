@@ -5,6 +5,10 @@ import (
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
 )
 
 func TestInitLogging(t *testing.T) {
@@ -13,3 +17,39 @@ func TestInitLogging(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedLogPath, log.GetLogDirectory())
 }
+
+func TestAdminNetworkPolicyCRDsInstalled(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		want      bool
+	}{
+		{
+			name: "no crds",
+		},
+		{
+			name: "only anp crd",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: policyv1alpha1.GroupVersion.String(),
+				APIResources: []metav1.APIResource{{Name: "adminnetworkpolicies"}},
+			}},
+		},
+		{
+			name: "anp and banp crds",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: policyv1alpha1.GroupVersion.String(),
+				APIResources: []metav1.APIResource{{Name: "adminnetworkpolicies"}, {Name: "baselineadminnetworkpolicies"}},
+			}},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			d.Resources = tt.resources
+			got, err := adminNetworkPolicyCRDsInstalled(d)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
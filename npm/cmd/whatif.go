@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	npmconfig "github.com/Azure/azure-container-networking/npm/config"
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/debug"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	errPolicyFileNotSpecified   = errors.New("NetworkPolicy file not specified")
+	errPolicyFileHasManyObjects = errors.New("NetworkPolicy file must have exactly one NetworkPolicy")
+	errPoliciesFileWithoutCache = errors.New("must specify a cache file when specifying an existing NetworkPolicies file")
+	errUnexpectedKind           = errors.New("unexpected kind")
+)
+
+const jsonOutput = "json"
+
+func newWhatIf() *cobra.Command {
+	whatIfCmd := &cobra.Command{
+		Use:   "whatif",
+		Short: "Get the pods that would gain or lose connectivity if a NetworkPolicy was added, modified, or deleted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyF, _ := cmd.Flags().GetString("file")
+			if policyF == "" {
+				return errPolicyFileNotSpecified
+			}
+			deletePolicy, _ := cmd.Flags().GetBool("delete")
+			npmCacheF, _ := cmd.Flags().GetString("cache-file")
+			policiesF, _ := cmd.Flags().GetString("policies-file")
+			output, _ := cmd.Flags().GetString("output")
+
+			netPols, err := readNetworkPolicies(policyF)
+			if err != nil {
+				return err
+			}
+			if len(netPols) != 1 {
+				return fmt.Errorf("%w: found %d", errPolicyFileHasManyObjects, len(netPols))
+			}
+
+			req := &api.WhatIfRequest{
+				Operation:     api.WhatIfApply,
+				NetworkPolicy: netPols[0],
+			}
+			if deletePolicy {
+				req.Operation = api.WhatIfDelete
+			}
+
+			var resp *api.WhatIfResponse
+			switch {
+			case npmCacheF == "" && policiesF == "":
+				resp, err = newLocalNPMHttpClient().WhatIf(req)
+				if err != nil {
+					return fmt.Errorf("failed to get what-if response from NPM: %w", err)
+				}
+
+			case npmCacheF != "":
+				config := &npmconfig.Config{}
+				if err := viper.Unmarshal(config); err != nil {
+					return fmt.Errorf("failed to load config with err %w", err)
+				}
+
+				resp, err = whatIfFromFiles(req, npmCacheF, policiesF, config.Toggles.EnableNPMLite)
+				if err != nil {
+					return err
+				}
+
+			default:
+				return errPoliciesFileWithoutCache
+			}
+
+			return printResponse(output, resp, func() { debug.PrettyPrintWhatIf(req.Operation, resp) })
+		},
+	}
+
+	whatIfCmd.Flags().StringP("file", "f", "", "Set the file path of the proposed NetworkPolicy YAML")
+	whatIfCmd.Flags().Bool("delete", false, "Evaluate deleting the NetworkPolicy instead of adding or modifying it")
+	whatIfCmd.Flags().StringP("cache-file", "c", "", "Set the v2 NPM cache file path (optional, otherwise the local NPM is queried)")
+	whatIfCmd.Flags().StringP("policies-file", "p", "", "Set the file path of the existing NetworkPolicies YAML (optional, requires a cache file)")
+	whatIfCmd.Flags().StringP("output", "o", "", "Set the output format (json or empty for text)")
+
+	return whatIfCmd
+}
+
+func whatIfFromFiles(req *api.WhatIfRequest, npmCacheF, policiesF string, npmLiteToggle bool) (*api.WhatIfResponse, error) {
+	byteArray, err := os.ReadFile(npmCacheF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file : %w", npmCacheF, err)
+	}
+	npmCache, err := debug.NpmCacheV2FromBytes(byteArray)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache from file: %w", err)
+	}
+
+	var existingNetPols []*networkingv1.NetworkPolicy
+	if policiesF != "" {
+		existingNetPols, err = readNetworkPolicies(policiesF)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := debug.WhatIf(npmCache, existingNetPols, nil, req, npmLiteToggle, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate what-if: %w", err)
+	}
+	return resp, nil
+}
+
+// readNetworkPolicies reads NetworkPolicies from a YAML or JSON file with one or more documents.
+// Each document is a NetworkPolicy or a list of NetworkPolicies (e.g. from kubectl get networkpolicies -A -o yaml).
+func readNetworkPolicies(path string) ([]*networkingv1.NetworkPolicy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s file : %w", path, err)
+	}
+	defer f.Close()
+
+	netPols := make([]*networkingv1.NetworkPolicy, 0)
+	decoder := k8syaml.NewYAMLOrJSONDecoder(f, 4096) //nolint:gomnd // buffer size for finding the start of JSON
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return netPols, nil
+			}
+			return nil, fmt.Errorf("failed to decode %s file : %w", path, err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			// empty document
+			continue
+		}
+
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &typeMeta); err != nil {
+			return nil, fmt.Errorf("failed to decode kind in %s file : %w", path, err)
+		}
+
+		switch typeMeta.Kind {
+		case "NetworkPolicy":
+			netPol := &networkingv1.NetworkPolicy{}
+			if err := json.Unmarshal(raw, netPol); err != nil {
+				return nil, fmt.Errorf("failed to decode NetworkPolicy in %s file : %w", path, err)
+			}
+			netPols = append(netPols, netPol)
+		case "NetworkPolicyList", "List":
+			netPolList := &networkingv1.NetworkPolicyList{}
+			if err := json.Unmarshal(raw, netPolList); err != nil {
+				return nil, fmt.Errorf("failed to decode NetworkPolicies in %s file : %w", path, err)
+			}
+			for i := range netPolList.Items {
+				netPols = append(netPols, &netPolList.Items[i])
+			}
+		default:
+			return nil, fmt.Errorf("%w %q in %s file", errUnexpectedKind, typeMeta.Kind, path)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+const (
+	npmCacheV2File  = "../pkg/dataplane/testdata/npmcachev2.json"
+	netPolFile      = "../pkg/dataplane/testdata/netpol.yaml"
+	whatIfCmdString = "whatif"
+
+	policyFileFlag   = "-f"
+	policiesFileFlag = "-p"
+	deleteFlag       = "--delete"
+)
+
+// (TODO) test case where HTTP request made to NPM
+func TestWhatIfCmd(t *testing.T) {
+	baseArgs := []string{debugCmdString, whatIfCmdString}
+
+	tests := []*testCases{
+		{
+			name:    "no policy file",
+			args:    concatArgs(baseArgs, npmCacheFlag, npmCacheV2File),
+			wantErr: true,
+		},
+		{
+			name:    "bad policy file",
+			args:    concatArgs(baseArgs, policyFileFlag, nonExistingFile, npmCacheFlag, npmCacheV2File),
+			wantErr: true,
+		},
+		{
+			name:    "policy file isn't a NetworkPolicy",
+			args:    concatArgs(baseArgs, policyFileFlag, npmCacheV2File, npmCacheFlag, npmCacheV2File),
+			wantErr: true,
+		},
+		{
+			name:    "policies file but no cache file",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, policiesFileFlag, netPolFile),
+			wantErr: true,
+		},
+		{
+			name:    "bad cache file",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, npmCacheFlag, nonExistingFile),
+			wantErr: true,
+		},
+		{
+			name:    "delete policy that doesn't exist",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, npmCacheFlag, npmCacheV2File, deleteFlag),
+			wantErr: true,
+		},
+		{
+			name:    "add policy",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, npmCacheFlag, npmCacheV2File),
+			wantErr: false,
+		},
+		{
+			name:    "modify policy",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, npmCacheFlag, npmCacheV2File, policiesFileFlag, netPolFile),
+			wantErr: false,
+		},
+		{
+			name:    "delete policy with json output",
+			args:    concatArgs(baseArgs, policyFileFlag, netPolFile, npmCacheFlag, npmCacheV2File, policiesFileFlag, netPolFile, deleteFlag, "-o", "json"),
+			wantErr: false,
+		},
+	}
+
+	testCommand(t, tests)
+}
@@ -1,6 +1,9 @@
 package npmconfig
 
-import "github.com/Azure/azure-container-networking/npm/util"
+import (
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+)
 
 const (
 	defaultResyncPeriod         = 15
@@ -12,6 +15,8 @@ const (
 	defaultListeningPort        = 10091
 	defaultGrpcPort             = 10092
 	defaultGrpcServicePort      = 9002
+	defaultDenyFlowLogGroup     = 100
+	defaultDenyFlowLogRate      = 10
 	// ConfigEnvPath is what's used by viper to load config path
 	ConfigEnvPath = "NPM_CONFIG"
 
@@ -40,6 +45,12 @@ var DefaultConfig = Config{
 	MaxPendingNetPols:            defaultMaxPendingNetPols,
 	NetPolInvervalInMilliseconds: defaultNetPolInterval,
 
+	DenyFlowLogging: DenyFlowLoggingConfig{
+		NflogGroup:         defaultDenyFlowLogGroup,
+		RateLimitPerSecond: defaultDenyFlowLogRate,
+		Output:             DenyFlowLogOutputJSON,
+	},
+
 	Toggles: Toggles{
 		EnablePrometheusMetrics: true,
 		EnablePprof:             true,
@@ -52,6 +63,14 @@ var DefaultConfig = Config{
 		// NetPolInBackground is currently used in Linux to apply NetPol controller Add events in the background
 		NetPolInBackground: true,
 		EnableNPMLite:      false,
+		// EnableNftables is used in Linux to program policies with nftables instead of iptables and ipset
+		EnableNftables: false,
+		// EnableIPv6 is used in Linux to enforce policies for IPv6 (dual-stack) pods with ip6tables
+		EnableIPv6: false,
+		// EnableAdminNetworkPolicy is used to enforce AdminNetworkPolicies and BaselineAdminNetworkPolicies
+		EnableAdminNetworkPolicy: false,
+		// EnableDenyFlowLogging is used in Linux to log the flows dropped by NPM (see DenyFlowLogging)
+		EnableDenyFlowLogging: false,
 	},
 
 	// Setting LogLevel to "info" by default. Set to "debug" to get application insight logs (creates a listener that outputs diagnosticMessageWriter logs).
@@ -67,6 +86,24 @@ type GrpcServerConfig struct {
 	ServicePort int `json:"ServicePort,omitempty"`
 }
 
+type DenyFlowLogOutput string
+
+const (
+	// DenyFlowLogOutputJSON writes a JSON line for each denied flow to stdout and counts it in Prometheus.
+	DenyFlowLogOutputJSON DenyFlowLogOutput = "json"
+	// DenyFlowLogOutputMetrics only counts denied flows in Prometheus.
+	DenyFlowLogOutputMetrics DenyFlowLogOutput = "metrics"
+)
+
+// DenyFlowLoggingConfig configures the logging of flows dropped by NPM when EnableDenyFlowLogging is true.
+type DenyFlowLoggingConfig struct {
+	// NflogGroup is the NFLOG group which the dropped packets are sent to
+	NflogGroup int `json:"NflogGroup,omitempty"`
+	// RateLimitPerSecond limits the packets logged by each drop rule. Zero means no limit.
+	RateLimitPerSecond int               `json:"RateLimitPerSecond,omitempty"`
+	Output             DenyFlowLogOutput `json:"Output,omitempty"`
+}
+
 type Config struct {
 	ResyncPeriodInMinutes int              `json:"ResyncPeriodInMinutes,omitempty"`
 	ListeningPort         int              `json:"ListeningPort,omitempty"`
@@ -86,6 +123,10 @@ type Config struct {
 	NetPolInvervalInMilliseconds int     `json:"NetPolInvervalInMilliseconds,omitempty"`
 	Toggles                      Toggles `json:"Toggles,omitempty"`
 	LogLevel                     string  `json:"LogLevel,omitempty"`
+	// DenyFlowLogging applies for Linux only
+	DenyFlowLogging DenyFlowLoggingConfig `json:"DenyFlowLogging,omitempty"`
+	// OTLP exports the NPM telemetry to an OTLP receiver, alongside Application Insights when it is initialized.
+	OTLP *otlptelemetry.Config `json:"OTLP,omitempty"`
 }
 
 type Toggles struct {
@@ -100,6 +141,14 @@ type Toggles struct {
 	// NetPolInBackground
 	NetPolInBackground bool
 	EnableNPMLite      bool
+	// EnableNftables applies for Linux only, and cannot be combined with EnableIPv6 or EnableAdminNetworkPolicy
+	EnableNftables bool
+	// EnableIPv6 applies for Linux only
+	EnableIPv6 bool
+	// EnableAdminNetworkPolicy applies for NPM v2 only
+	EnableAdminNetworkPolicy bool
+	// EnableDenyFlowLogging applies for NPM v2 in Linux only
+	EnableDenyFlowLogging bool
 }
 
 type Flags struct {
@@ -1,13 +1,168 @@
 package api
 
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
 const (
 	DefaultListeningIP = "0.0.0.0"
 	DefaultHttpPort    = "10091"
 	NodeMetricsPath    = "/node-metrics"
 	ClusterMetricsPath = "/cluster-metrics"
 	NPMMgrPath         = "/npm/v1/debug/manager"
+	NPMWhatIfPath      = "/npm/v1/debug/whatif"
+	NPMIPSetsPath      = "/npm/v1/debug/ipsets"
+	NPMPoliciesPath    = "/npm/v1/debug/policies"
+	// NPMPolicyPodsPath lists the pods selected by the policy in the "policy" query parameter
+	NPMPolicyPodsPath = "/npm/v1/debug/policies/pods"
+	// NPMPodPoliciesPath lists the policies selecting the pod in the "pod" query parameter
+	NPMPodPoliciesPath = "/npm/v1/debug/pods/policies"
 )
 
-type DescribeIPSetRequest struct{}
+// query parameters for the debug API
+const (
+	NameParam      = "name"
+	TypeParam      = "type"
+	MemberParam    = "member"
+	NamespaceParam = "namespace"
+	StatusParam    = "status"
+	PolicyParam    = "policy"
+	PodParam       = "pod"
+)
+
+// PolicyStatus is whether a policy is in the kernel.
+type PolicyStatus string
+
+const (
+	PolicyApplied PolicyStatus = "applied"
+	// PolicyPending policies are queued to be added in the background
+	PolicyPending PolicyStatus = "pending"
+)
+
+// DescribeIPSetRequest filters the ipsets in the NPM cache. Empty fields match all ipsets.
+type DescribeIPSetRequest struct {
+	// Name matches the unprefixed, prefixed, or hashed name of the set
+	Name string `json:"name,omitempty"`
+	// Type matches the set type e.g. KeyValueLabelOfPod
+	Type string `json:"type,omitempty"`
+	// Member matches hash sets with the IP and list sets with the set (any name)
+	Member string `json:"member,omitempty"`
+}
+
+type DescribeIPSetResponse struct {
+	IPSets []IPSet `json:"ipsets"`
+}
+
+type IPSet struct {
+	Name           string `json:"name"`
+	UnprefixedName string `json:"unprefixedName"`
+	HashedName     string `json:"hashedName"`
+	Type           string `json:"type"`
+	Kind           string `json:"kind"`
+	// Members maps IPs to pod keys for hash sets, and hashed names to names of member sets for list sets
+	Members            map[string]string `json:"members"`
+	SelectorReferences []string          `json:"selectorReferences"`
+	NetPolReferences   []string          `json:"netPolReferences"`
+	// ReferCount is the number of lists referring to the set
+	ReferCount int `json:"referCount"`
+}
+
+// DescribePolicyRequest filters the policies in the dataplane. Empty fields match all policies.
+type DescribePolicyRequest struct {
+	// Namespace matches the namespace of a NetworkPolicy, or the tier of an AdminNetworkPolicy or BaselineAdminNetworkPolicy
+	Namespace string       `json:"namespace,omitempty"`
+	Status    PolicyStatus `json:"status,omitempty"`
+}
+
+type DescribePolicyResponse struct {
+	Policies []Policy `json:"policies"`
+}
+
+type Policy struct {
+	PolicyKey string       `json:"policyKey"`
+	Tier      string       `json:"tier,omitempty"`
+	Priority  int32        `json:"priority,omitempty"`
+	Status    PolicyStatus `json:"status"`
+	// PodSelector must all match for a pod to be selected
+	PodSelector []SetInfo `json:"podSelector"`
+	ACLs        []ACL     `json:"acls"`
+	// Endpoints maps pod IPs to endpoint IDs. Only used in Windows.
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+}
+
+type ACL struct {
+	Target    string    `json:"target"`
+	Direction string    `json:"direction"`
+	Protocol  string    `json:"protocol,omitempty"`
+	Port      int32     `json:"port,omitempty"`
+	EndPort   int32     `json:"endPort,omitempty"`
+	SrcList   []SetInfo `json:"srcList,omitempty"`
+	DstList   []SetInfo `json:"dstList,omitempty"`
+}
+
+// SetInfo is an ipset referenced by a policy.
+type SetInfo struct {
+	// Name is the prefixed name
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Included bool   `json:"included"`
+	// MatchType is "src", "dst", "dst,dst", or "either"
+	MatchType string `json:"matchType"`
+}
+
+// PolicyPodsResponse lists the pods selected by a policy.
+type PolicyPodsResponse struct {
+	PolicyKey string `json:"policyKey"`
+	// Pods are in <namespace>/<name> format
+	Pods []string `json:"pods"`
+}
+
+// PodPoliciesResponse lists the policies selecting a pod.
+type PodPoliciesResponse struct {
+	Pod      string           `json:"pod"`
+	Policies []SelectedPolicy `json:"policies"`
+}
+
+// SelectedPolicy is a policy selecting a pod, and whether it has ingress and/or egress rules.
+type SelectedPolicy struct {
+	PolicyKey string `json:"policyKey"`
+	Ingress   bool   `json:"ingress"`
+	Egress    bool   `json:"egress"`
+}
+
+// WhatIfOperation is the proposed change to a NetworkPolicy.
+type WhatIfOperation string
+
+const (
+	// WhatIfApply adds the NetworkPolicy, or modifies it if a NetworkPolicy with the same namespace and name exists.
+	WhatIfApply WhatIfOperation = "apply"
+	// WhatIfDelete deletes the NetworkPolicy with the same namespace and name.
+	WhatIfDelete WhatIfOperation = "delete"
+)
+
+// WhatIfRequest is a proposed change to a NetworkPolicy which is evaluated against the current NPM cache
+// without changing the dataplane.
+type WhatIfRequest struct {
+	Operation     WhatIfOperation             `json:"operation"`
+	NetworkPolicy *networkingv1.NetworkPolicy `json:"networkPolicy"`
+}
+
+// ConnectivityChange indicates whether traffic from Src to Dst on Protocol and the port range is gained or lost.
+type ConnectivityChange struct {
+	// Src and Dst are pods in <namespace>/<name> format
+	Src      string `json:"src"`
+	Dst      string `json:"dst"`
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+	EndPort  int32  `json:"endPort"`
+	// Allowed is true if connectivity is gained and false if it's lost
+	Allowed bool `json:"allowed"`
+}
 
-type DescribeIPSetResponse struct{}
+// WhatIfResponse lists the connectivity changes between pods that would happen if the WhatIfRequest was applied.
+type WhatIfResponse struct {
+	PolicyKey string `json:"policyKey"`
+	// Existed is true if the NetworkPolicy already exists (i.e. it would be modified or deleted)
+	Existed bool                 `json:"existed"`
+	Changes []ConnectivityChange `json:"changes"`
+}
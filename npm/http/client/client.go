@@ -1,8 +1,12 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Azure/azure-container-networking/npm/http/api"
@@ -44,3 +48,110 @@ func (n *NPMHttpClient) GetNpmMgr() (*npm.NetworkPolicyManager, error) {
 
 	return &ns, nil
 }
+
+// WhatIf asks NPM which pods would gain or lose connectivity if the NetworkPolicy change in the request was applied.
+func (n *NPMHttpClient) WhatIf(whatIfReq *api.WhatIfRequest) (*api.WhatIfResponse, error) {
+	body, err := json.Marshal(whatIfReq)
+	if err != nil {
+		return nil, err
+	}
+
+	url := n.endpoint + api.NPMWhatIfPath
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("what-if request failed with status %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	var resp api.WhatIfResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DescribeIPSets lists the ipsets in NPM's cache which match the request.
+func (n *NPMHttpClient) DescribeIPSets(describeReq *api.DescribeIPSetRequest) (*api.DescribeIPSetResponse, error) {
+	query := url.Values{}
+	setQuery(query, api.NameParam, describeReq.Name)
+	setQuery(query, api.TypeParam, describeReq.Type)
+	setQuery(query, api.MemberParam, describeReq.Member)
+
+	var resp api.DescribeIPSetResponse
+	if err := n.getJSON(api.NPMIPSetsPath, query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DescribePolicies lists the translated policies in NPM which match the request.
+func (n *NPMHttpClient) DescribePolicies(describeReq *api.DescribePolicyRequest) (*api.DescribePolicyResponse, error) {
+	query := url.Values{}
+	setQuery(query, api.NamespaceParam, describeReq.Namespace)
+	setQuery(query, api.StatusParam, string(describeReq.Status))
+
+	var resp api.DescribePolicyResponse
+	if err := n.getJSON(api.NPMPoliciesPath, query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPodsSelectedByPolicy lists the pods selected by the policy with the key.
+func (n *NPMHttpClient) GetPodsSelectedByPolicy(policyKey string) (*api.PolicyPodsResponse, error) {
+	var resp api.PolicyPodsResponse
+	if err := n.getJSON(api.NPMPolicyPodsPath, url.Values{api.PolicyParam: []string{policyKey}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPoliciesSelectingPod lists the policies selecting the pod with the <namespace>/<name> key.
+func (n *NPMHttpClient) GetPoliciesSelectingPod(podKey string) (*api.PodPoliciesResponse, error) {
+	var resp api.PodPoliciesResponse
+	if err := n.getJSON(api.NPMPodPoliciesPath, url.Values{api.PodParam: []string{podKey}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (n *NPMHttpClient) getJSON(path string, query url.Values, v any) error {
+	u := n.endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("request to %s failed with status %d: %s", path, res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
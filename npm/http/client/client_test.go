@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case api.NPMIPSetsPath:
+			require.Equal(t, "x", query.Get(api.NameParam))
+			require.False(t, query.Has(api.TypeParam))
+			_ = json.NewEncoder(w).Encode(&api.DescribeIPSetResponse{IPSets: []api.IPSet{{Name: "ns-x"}}})
+		case api.NPMPoliciesPath:
+			require.Equal(t, string(api.PolicyPending), query.Get(api.StatusParam))
+			_ = json.NewEncoder(w).Encode(&api.DescribePolicyResponse{Policies: []api.Policy{{PolicyKey: "x/deny-all"}}})
+		case api.NPMPolicyPodsPath:
+			require.Equal(t, "x/deny-all", query.Get(api.PolicyParam))
+			_ = json.NewEncoder(w).Encode(&api.PolicyPodsResponse{PolicyKey: "x/deny-all", Pods: []string{"x/a"}})
+		case api.NPMPodPoliciesPath:
+			http.Error(w, "pod not found: x/missing", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := NewNPMHttpClient(srv.URL)
+
+	ipsetsResp, err := c.DescribeIPSets(&api.DescribeIPSetRequest{Name: "x"})
+	require.NoError(t, err)
+	require.Equal(t, "ns-x", ipsetsResp.IPSets[0].Name)
+
+	policiesResp, err := c.DescribePolicies(&api.DescribePolicyRequest{Status: api.PolicyPending})
+	require.NoError(t, err)
+	require.Equal(t, "x/deny-all", policiesResp.Policies[0].PolicyKey)
+
+	policyPodsResp, err := c.GetPodsSelectedByPolicy("x/deny-all")
+	require.NoError(t, err)
+	require.Equal(t, []string{"x/a"}, policyPodsResp.Pods)
+
+	_, err = c.GetPoliciesSelectingPod("x/missing")
+	require.ErrorContains(t, err, "pod not found: x/missing")
+	require.ErrorContains(t, err, "404")
+}
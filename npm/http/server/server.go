@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
@@ -11,11 +12,25 @@ import (
 	npmconfig "github.com/Azure/azure-container-networking/npm/config"
 	"github.com/Azure/azure-container-networking/npm/http/api"
 	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/debug"
 	"k8s.io/klog"
 
 	"github.com/gorilla/mux"
 )
 
+// WhatIfEvaluator evaluates proposed NetworkPolicy changes. It's implemented by the NetworkPolicyManager.
+type WhatIfEvaluator interface {
+	WhatIf(req *api.WhatIfRequest) (*api.WhatIfResponse, error)
+}
+
+// Describer describes the ipsets and policies in the dataplane. It's implemented by the NetworkPolicyManager.
+type Describer interface {
+	DescribeIPSets(req *api.DescribeIPSetRequest) (*api.DescribeIPSetResponse, error)
+	DescribePolicies(req *api.DescribePolicyRequest) (*api.DescribePolicyResponse, error)
+	GetPodsSelectedByPolicy(policyKey string) (*api.PolicyPodsResponse, error)
+	GetPoliciesSelectingPod(podKey string) (*api.PodPoliciesResponse, error)
+}
+
 type NPMRestServer struct {
 	listeningAddress string
 	router           *mux.Router
@@ -36,6 +51,17 @@ func NPMRestServerListenAndServe(config npmconfig.Config, npmEncoder json.Marsha
 	if config.Toggles.EnableHTTPDebugAPI && npmEncoder != nil {
 		// ACN CLI debug handlers
 		rs.router.Handle(api.NPMMgrPath, rs.npmCacheHandler(npmEncoder)).Methods(http.MethodGet)
+
+		if evaluator, ok := npmEncoder.(WhatIfEvaluator); ok && config.Toggles.EnableV2NPM {
+			rs.router.Handle(api.NPMWhatIfPath, rs.whatIfHandler(evaluator)).Methods(http.MethodPost)
+		}
+
+		if describer, ok := npmEncoder.(Describer); ok && config.Toggles.EnableV2NPM {
+			rs.router.Handle(api.NPMIPSetsPath, rs.ipsetsHandler(describer)).Methods(http.MethodGet)
+			rs.router.Handle(api.NPMPoliciesPath, rs.policiesHandler(describer)).Methods(http.MethodGet)
+			rs.router.Handle(api.NPMPolicyPodsPath, rs.policyPodsHandler(describer)).Methods(http.MethodGet)
+			rs.router.Handle(api.NPMPodPoliciesPath, rs.podPoliciesHandler(describer)).Methods(http.MethodGet)
+		}
 	}
 
 	if config.Toggles.EnablePprof {
@@ -74,3 +100,99 @@ func (n *NPMRestServer) npmCacheHandler(npmCacheEncoder json.Marshaler) http.Han
 		}
 	})
 }
+
+func (n *NPMRestServer) whatIfHandler(evaluator WhatIfEvaluator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.WhatIfRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode what-if request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := evaluator.WhatIf(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		b, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(b)
+		if err != nil {
+			log.Errorf("failed to write resp: %v", err)
+		}
+	})
+}
+
+func (n *NPMRestServer) ipsetsHandler(describer Describer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		resp, err := describer.DescribeIPSets(&api.DescribeIPSetRequest{
+			Name:   query.Get(api.NameParam),
+			Type:   query.Get(api.TypeParam),
+			Member: query.Get(api.MemberParam),
+		})
+		writeDebugResponse(w, resp, err)
+	})
+}
+
+func (n *NPMRestServer) policiesHandler(describer Describer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		resp, err := describer.DescribePolicies(&api.DescribePolicyRequest{
+			Namespace: query.Get(api.NamespaceParam),
+			Status:    api.PolicyStatus(query.Get(api.StatusParam)),
+		})
+		writeDebugResponse(w, resp, err)
+	})
+}
+
+func (n *NPMRestServer) policyPodsHandler(describer Describer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policyKey := r.URL.Query().Get(api.PolicyParam)
+		if policyKey == "" {
+			http.Error(w, fmt.Sprintf("missing %q query parameter", api.PolicyParam), http.StatusBadRequest)
+			return
+		}
+		resp, err := describer.GetPodsSelectedByPolicy(policyKey)
+		writeDebugResponse(w, resp, err)
+	})
+}
+
+func (n *NPMRestServer) podPoliciesHandler(describer Describer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		podKey := r.URL.Query().Get(api.PodParam)
+		if podKey == "" {
+			http.Error(w, fmt.Sprintf("missing %q query parameter", api.PodParam), http.StatusBadRequest)
+			return
+		}
+		resp, err := describer.GetPoliciesSelectingPod(podKey)
+		writeDebugResponse(w, resp, err)
+	})
+}
+
+func writeDebugResponse(w http.ResponseWriter, resp any, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, debug.ErrPolicyNotFound) || errors.Is(err, debug.ErrPodNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	if err != nil {
+		log.Errorf("failed to write resp: %v", err)
+	}
+}
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +13,10 @@ import (
 	"github.com/Azure/azure-container-networking/npm"
 	"github.com/Azure/azure-container-networking/npm/http/api"
 	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/debug"
 	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetNPMCacheHandler(t *testing.T) {
@@ -55,3 +61,136 @@ func TestGetNPMCacheHandler(t *testing.T) {
 
 	assert.Exactly(expected, actual)
 }
+
+type fakeWhatIfEvaluator struct {
+	resp *api.WhatIfResponse
+	err  error
+}
+
+func (f *fakeWhatIfEvaluator) WhatIf(req *api.WhatIfRequest) (*api.WhatIfResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.resp.PolicyKey = req.NetworkPolicy.Namespace + "/" + req.NetworkPolicy.Name
+	return f.resp, nil
+}
+
+func TestWhatIfHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	whatIfReq := &api.WhatIfRequest{
+		Operation:     api.WhatIfApply,
+		NetworkPolicy: &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "x"}},
+	}
+	body, err := json.Marshal(whatIfReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := api.ConnectivityChange{Src: "x/a", Dst: "x/b", Protocol: "TCP", Port: 1, EndPort: 65535, Allowed: false}
+	n := &NPMRestServer{}
+	handler := n.whatIfHandler(&fakeWhatIfEvaluator{resp: &api.WhatIfResponse{Changes: []api.ConnectivityChange{change}}})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, api.NPMWhatIfPath, bytes.NewReader(body)))
+	assert.Equal(http.StatusOK, rr.Code)
+
+	actual := &api.WhatIfResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), actual); err != nil {
+		t.Fatalf("failed to unmarshal %s due to %v", rr.Body.String(), err)
+	}
+	assert.Exactly(&api.WhatIfResponse{PolicyKey: "x/deny-all", Changes: []api.ConnectivityChange{change}}, actual)
+
+	// invalid body
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, api.NPMWhatIfPath, bytes.NewReader([]byte("{"))))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+
+	// evaluation error
+	handler = n.whatIfHandler(&fakeWhatIfEvaluator{err: errors.New("untranslatable")})
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, api.NPMWhatIfPath, bytes.NewReader(body)))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+	assert.Contains(rr.Body.String(), "untranslatable")
+}
+
+type fakeDescriber struct {
+	ipsetsReq   *api.DescribeIPSetRequest
+	policiesReq *api.DescribePolicyRequest
+}
+
+func (f *fakeDescriber) DescribeIPSets(req *api.DescribeIPSetRequest) (*api.DescribeIPSetResponse, error) {
+	f.ipsetsReq = req
+	return &api.DescribeIPSetResponse{IPSets: []api.IPSet{{Name: "ns-x"}}}, nil
+}
+
+func (f *fakeDescriber) DescribePolicies(req *api.DescribePolicyRequest) (*api.DescribePolicyResponse, error) {
+	f.policiesReq = req
+	return &api.DescribePolicyResponse{Policies: []api.Policy{{PolicyKey: "x/deny-all", Status: api.PolicyApplied}}}, nil
+}
+
+func (f *fakeDescriber) GetPodsSelectedByPolicy(policyKey string) (*api.PolicyPodsResponse, error) {
+	if policyKey != "x/deny-all" {
+		return nil, fmt.Errorf("%w: %s", debug.ErrPolicyNotFound, policyKey)
+	}
+	return &api.PolicyPodsResponse{PolicyKey: policyKey, Pods: []string{"x/a"}}, nil
+}
+
+func (f *fakeDescriber) GetPoliciesSelectingPod(podKey string) (*api.PodPoliciesResponse, error) {
+	if podKey != "x/a" {
+		return nil, fmt.Errorf("%w: %s", debug.ErrPodNotFound, podKey)
+	}
+	return &api.PodPoliciesResponse{Pod: podKey, Policies: []api.SelectedPolicy{{PolicyKey: "x/deny-all", Ingress: true}}}, nil
+}
+
+func TestDescribeHandlers(t *testing.T) {
+	assert := assert.New(t)
+
+	describer := &fakeDescriber{}
+	n := &NPMRestServer{}
+
+	rr := httptest.NewRecorder()
+	n.ipsetsHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMIPSetsPath+"?name=x&type=Namespace&member=10.0.0.1", nil))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal(&api.DescribeIPSetRequest{Name: "x", Type: "Namespace", Member: "10.0.0.1"}, describer.ipsetsReq)
+	ipsetsResp := &api.DescribeIPSetResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), ipsetsResp))
+	assert.Equal("ns-x", ipsetsResp.IPSets[0].Name)
+
+	rr = httptest.NewRecorder()
+	n.policiesHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPoliciesPath+"?namespace=x&status=applied", nil))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal(&api.DescribePolicyRequest{Namespace: "x", Status: api.PolicyApplied}, describer.policiesReq)
+
+	rr = httptest.NewRecorder()
+	n.policyPodsHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPolicyPodsPath+"?policy=x%2Fdeny-all", nil))
+	assert.Equal(http.StatusOK, rr.Code)
+	policyPodsResp := &api.PolicyPodsResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), policyPodsResp))
+	assert.Equal(&api.PolicyPodsResponse{PolicyKey: "x/deny-all", Pods: []string{"x/a"}}, policyPodsResp)
+
+	rr = httptest.NewRecorder()
+	n.podPoliciesHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPodPoliciesPath+"?pod=x%2Fa", nil))
+	assert.Equal(http.StatusOK, rr.Code)
+	podPoliciesResp := &api.PodPoliciesResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), podPoliciesResp))
+	assert.Equal("x/deny-all", podPoliciesResp.Policies[0].PolicyKey)
+
+	// not found
+	rr = httptest.NewRecorder()
+	n.policyPodsHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPolicyPodsPath+"?policy=x%2Fmissing", nil))
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	n.podPoliciesHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPodPoliciesPath+"?pod=x%2Fmissing", nil))
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	// missing query parameters
+	rr = httptest.NewRecorder()
+	n.policyPodsHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPolicyPodsPath, nil))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	n.podPoliciesHandler(describer).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.NPMPodPoliciesPath, nil))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
 	"k8s.io/klog"
 )
 
@@ -60,6 +61,27 @@ func CreateTelemetryHandle(npmVersionNum int, imageVersion, aiMetadata, logLevel
 	return nil
 }
 
+// CreateOTLPTelemetryHandle creates a handler to export the telemetry to an OTLP receiver.
+// If the AI telemetry is already initialized, the telemetry is sent to both.
+func CreateOTLPTelemetryHandle(npmVersionNum int, imageVersion string, otlpConfig otlptelemetry.Config) error {
+	npmVersion = npmVersionNum
+	aiConfig := aitelemetry.AIConfig{
+		AppName:    util.AzureNpmFlag,
+		AppVersion: imageVersion,
+	}
+	otlpHandle, err := otlptelemetry.NewOTLPTelemetry(otlpConfig, aiConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP telemetry handle: %w", err)
+	}
+	if th != nil {
+		th = aitelemetry.NewTee(th, otlpHandle)
+	} else {
+		th = otlpHandle
+	}
+	log.Logf("Initialized OTLP handle")
+	return nil
+}
+
 // Close cleans up the telemetry handle, which effectively waits for all telemetry data to be sent
 func Close() {
 	if th == nil {
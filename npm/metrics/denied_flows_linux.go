@@ -0,0 +1,19 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// IncDeniedFlows counts a packet dropped by the policy in the direction (ingress or egress).
+func IncDeniedFlows(policyKey, direction string) {
+	deniedFlows.With(prometheus.Labels{
+		policyLabel:    policyKey,
+		directionLabel: direction,
+	}).Inc()
+}
+
+// TotalDeniedFlows returns the number of times IncDeniedFlows has been called for the policy and direction.
+func TotalDeniedFlows(policyKey, direction string) (int, error) {
+	return counterValue(deniedFlows.With(prometheus.Labels{
+		policyLabel:    policyKey,
+		directionLabel: direction,
+	}))
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncDeniedFlows(t *testing.T) {
+	IncDeniedFlows("x/allow-b", "ingress")
+	IncDeniedFlows("x/allow-b", "ingress")
+	IncDeniedFlows("x/allow-b", "egress")
+
+	count, err := TotalDeniedFlows("x/allow-b", "ingress")
+	require.Nil(t, err, "failed to get metric")
+	require.Equal(t, 2, count, "should have denied two ingress flows")
+
+	count, err = TotalDeniedFlows("x/allow-b", "egress")
+	require.Nil(t, err, "failed to get metric")
+	require.Equal(t, 1, count, "should have denied one egress flow")
+}
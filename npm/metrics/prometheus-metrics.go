@@ -5,6 +5,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/npm/util"
+	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog"
@@ -127,6 +128,14 @@ var (
 	iptablesRestoreFailures *prometheus.CounterVec
 )
 
+const (
+	policyLabel    = "policy"
+	directionLabel = "direction"
+)
+
+// linux metrics for deny flow logging
+var deniedFlows *prometheus.CounterVec
+
 type RegistryType string
 
 const (
@@ -175,6 +184,11 @@ func InitializeAll() {
 	)
 	register(podsWatched, "pods_watched", ClusterMetrics)
 
+	// expiration of the certificate of the controller's transport server
+	for _, collector := range localtls.Collectors() {
+		register(collector, "tls_certificate", ClusterMetrics)
+	}
+
 	if util.IsWindowsDP() {
 		InitializeWindowsMetrics()
 
@@ -196,6 +210,7 @@ func InitializeAll() {
 		register(itpablesRestoreLatency, "iptables_restore_latency_seconds", NodeMetrics)
 		register(iptablesDeleteLatency, "iptables_delete_latency_seconds", NodeMetrics)
 		register(iptablesRestoreFailures, "iptables_restore_failure_total", NodeMetrics)
+		register(deniedFlows, "denied_flows_total", NodeMetrics)
 	}
 
 	log.Logf("Finished initializing all Prometheus metrics")
@@ -354,6 +369,16 @@ func InitializeLinuxMetrics() {
 		},
 		[]string{operationLabel},
 	)
+
+	deniedFlows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "denied_flows_total",
+			Subsystem: linuxPrefix,
+			Help:      "Number of logged packets dropped by NPM by policy & direction label. Only counted when deny flow logging is enabled",
+		},
+		[]string{policyLabel, directionLabel},
+	)
 }
 
 // GetHandler returns the HTTP handler for the metrics endpoint
@@ -8,19 +8,24 @@ import (
 	"time"
 
 	npmconfig "github.com/Azure/azure-container-networking/npm/config"
+	"github.com/Azure/azure-container-networking/npm/http/api"
 	"github.com/Azure/azure-container-networking/npm/ipsm"
 	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
 	controllersv1 "github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/v1"
 	controllersv2 "github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/v2"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/debug"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
 	"github.com/Azure/azure-container-networking/npm/pkg/models"
 	"github.com/Azure/azure-container-networking/npm/util"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
 	utilexec "k8s.io/utils/exec"
+	policyinformers "sigs.k8s.io/network-policy-api/pkg/client/informers/externalversions"
 )
 
 var aiMetadata string //nolint // aiMetadata is set in Makefile
@@ -30,6 +35,15 @@ var aiMetadata string //nolint // aiMetadata is set in Makefile
 // So with a 3 minute wait, the dataplane can process about 600 (6*maxBatches) NetworkPolicies before starting the Pod controller
 var waitDurationAfterStartingNetPolController = 3 * time.Minute
 
+// deniedFlowResolverRefreshInterval bounds how often denied flow logging copies the cache and policies
+var deniedFlowResolverRefreshInterval = 10 * time.Second
+
+// whatIfMaxPodPairs bounds the pairs of pods a what-if evaluates in NPM.
+// Larger changes can be evaluated offline with npm debug whatif and the NPM cache.
+const whatIfMaxPodPairs = 250000
+
+var errDebugAPIV1 = errors.New("this debug API is only supported in v2 NPM")
+
 // NetworkPolicyManager contains informers for pod, namespace and networkpolicy.
 type NetworkPolicyManager struct {
 	config npmconfig.Config
@@ -106,6 +120,15 @@ func NewNetworkPolicyManager(config npmconfig.Config,
 	return npMgr
 }
 
+// WatchAdminNetworkPolicies creates the controller for AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+// It must be called before Start, and only for v2 NPM.
+func (npMgr *NetworkPolicyManager) WatchAdminNetworkPolicies(adminPolicyInformerFactory policyinformers.SharedInformerFactory) {
+	npMgr.AdminPolicyInformerFactory = adminPolicyInformerFactory
+	npMgr.AnpInformer = adminPolicyInformerFactory.Policy().V1alpha1().AdminNetworkPolicies()
+	npMgr.BanpInformer = adminPolicyInformerFactory.Policy().V1alpha1().BaselineAdminNetworkPolicies()
+	npMgr.AdminNetPolControllerV2 = controllersv2.NewAdminNetworkPolicyController(npMgr.AnpInformer, npMgr.BanpInformer, npMgr.Dataplane)
+}
+
 // Dear Time Traveler:
 // This is the server end of the debug dragons den. Several of these properties of the
 // npMgr struct have overridden methods which override the MarshalJson, just as this one
@@ -175,6 +198,94 @@ func (npMgr *NetworkPolicyManager) MarshalJSON() ([]byte, error) {
 	return npmCacheRaw, nil
 }
 
+// cacheV2 copies the pods and namespaces of the v2 controllers.
+// Going through MarshalJSON makes sure that the controllers' locks are held while copying.
+func (npMgr *NetworkPolicyManager) cacheV2() (*common.Cache, error) {
+	npmCacheRaw, err := npMgr.MarshalJSON()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal cache")
+	}
+	npmCache, err := debug.NpmCacheV2FromBytes(npmCacheRaw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode cache")
+	}
+	return npmCache, nil
+}
+
+// WhatIf evaluates a proposed change to a NetworkPolicy against the current cache and NetworkPolicies without changing the dataplane.
+func (npMgr *NetworkPolicyManager) WhatIf(req *api.WhatIfRequest) (*api.WhatIfResponse, error) {
+	if !npMgr.config.Toggles.EnableV2NPM {
+		return nil, errDebugAPIV1
+	}
+
+	npmCache, err := npMgr.cacheV2()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get cache for what-if")
+	}
+
+	netPols, err := npMgr.NpInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list NetworkPolicies for what-if")
+	}
+
+	var tieredPolicies []*policies.NPMNetworkPolicy
+	for _, snapshot := range npMgr.Dataplane.GetAllPolicySnapshots() {
+		if snapshot.Policy.IsTiered() {
+			tieredPolicies = append(tieredPolicies, snapshot.Policy)
+		}
+	}
+
+	//nolint:wrapcheck // errors are already descriptive
+	return debug.WhatIf(npmCache, netPols, tieredPolicies, req, npMgr.NpmLiteToggle, whatIfMaxPodPairs)
+}
+
+// DescribeIPSets returns the ipsets in the dataplane's cache which match the request.
+func (npMgr *NetworkPolicyManager) DescribeIPSets(req *api.DescribeIPSetRequest) (*api.DescribeIPSetResponse, error) {
+	if !npMgr.config.Toggles.EnableV2NPM {
+		return nil, errDebugAPIV1
+	}
+	return debug.DescribeIPSets(npMgr.Dataplane.GetAllIPSetSnapshots(), req), nil
+}
+
+// DescribePolicies returns the translated policies in the dataplane which match the request.
+func (npMgr *NetworkPolicyManager) DescribePolicies(req *api.DescribePolicyRequest) (*api.DescribePolicyResponse, error) {
+	if !npMgr.config.Toggles.EnableV2NPM {
+		return nil, errDebugAPIV1
+	}
+	return debug.DescribePolicies(npMgr.Dataplane.GetAllPolicySnapshots(), req), nil
+}
+
+// GetPodsSelectedByPolicy returns the pods selected by the policy in the dataplane with the policy key.
+func (npMgr *NetworkPolicyManager) GetPodsSelectedByPolicy(policyKey string) (*api.PolicyPodsResponse, error) {
+	if !npMgr.config.Toggles.EnableV2NPM {
+		return nil, errDebugAPIV1
+	}
+
+	npmCache, err := npMgr.cacheV2()
+	if err != nil {
+		return nil, err
+	}
+	return debug.PodsSelectedByPolicy(npmCache, npMgr.Dataplane.GetAllPolicySnapshots(), policyKey) //nolint:wrapcheck // errors are already descriptive
+}
+
+// GetPoliciesSelectingPod returns the policies in the dataplane which select the pod with the <namespace>/<name> key.
+func (npMgr *NetworkPolicyManager) GetPoliciesSelectingPod(podKey string) (*api.PodPoliciesResponse, error) {
+	if !npMgr.config.Toggles.EnableV2NPM {
+		return nil, errDebugAPIV1
+	}
+
+	npmCache, err := npMgr.cacheV2()
+	if err != nil {
+		return nil, err
+	}
+	return debug.PoliciesSelectingPod(npmCache, npMgr.Dataplane.GetAllPolicySnapshots(), podKey) //nolint:wrapcheck // errors are already descriptive
+}
+
+// DeniedFlowResolver returns a resolver for the flows denied by the v2 dataplane.
+func (npMgr *NetworkPolicyManager) DeniedFlowResolver() *debug.DeniedFlowResolver {
+	return debug.NewDeniedFlowResolver(npMgr.cacheV2, npMgr.Dataplane.GetAllPolicySnapshots, deniedFlowResolverRefreshInterval)
+}
+
 // GetAppVersion returns network policy manager app version
 func (npMgr *NetworkPolicyManager) GetAppVersion() string {
 	return npMgr.Version
@@ -192,6 +303,10 @@ func (npMgr *NetworkPolicyManager) Start(config npmconfig.Config, stopCh <-chan
 	// Starts all informers manufactured by npMgr's informerFactory.
 	npMgr.InformerFactory.Start(stopCh)
 
+	if npMgr.AdminPolicyInformerFactory != nil {
+		npMgr.AdminPolicyInformerFactory.Start(stopCh)
+	}
+
 	// npm lite
 	if npMgr.NpmLiteToggle {
 		npMgr.PodInformerFactory.Start(stopCh)
@@ -210,9 +325,18 @@ func (npMgr *NetworkPolicyManager) Start(config npmconfig.Config, stopCh <-chan
 		return fmt.Errorf("NetworkPolicy informer error: %w", models.ErrInformerSyncFailure)
 	}
 
+	if npMgr.AdminPolicyInformerFactory != nil {
+		if !cache.WaitForCacheSync(stopCh, npMgr.AnpInformer.Informer().HasSynced, npMgr.BanpInformer.Informer().HasSynced) {
+			return fmt.Errorf("AdminNetworkPolicy informer error: %w", models.ErrInformerSyncFailure)
+		}
+	}
+
 	// start v2 NPM controllers after synced
 	if config.Toggles.EnableV2NPM {
 		go npMgr.NetPolControllerV2.Run(stopCh)
+		if npMgr.AdminNetPolControllerV2 != nil {
+			go npMgr.AdminNetPolControllerV2.Run(stopCh)
+		}
 
 		if util.IsWindowsDP() && config.Toggles.ApplyInBackground {
 			klog.Infof("optimizing NPM bootup by letting NetPol controller process changes first. waiting %v before starting pod and namespace controllers", waitDurationAfterStartingNetPolController)
@@ -3,6 +3,7 @@ package common
 import (
 	"errors"
 	"net"
+	"slices"
 
 	"github.com/Azure/azure-container-networking/npm/util"
 )
@@ -70,6 +71,12 @@ func (c *Cache) GetPod(input *Input) (*NpmPod, error) {
 			if pod.PodIP == input.Content {
 				return pod, nil
 			}
+			if slices.Contains(pod.PodIPs, input.Content) {
+				// matched another IP of a dual-stack pod. Return a copy with the matched IP as the PodIP
+				podWithIP := *pod
+				podWithIP.PodIP = input.Content
+				return &podWithIP, nil
+			}
 		}
 		return nil, ErrInvalidIPAddress
 	case EXTERNAL:
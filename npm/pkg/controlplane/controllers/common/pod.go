@@ -2,15 +2,19 @@ package common
 
 import (
 	"reflect"
+	"slices"
 
+	"github.com/Azure/azure-container-networking/npm/util"
 	corev1 "k8s.io/api/core/v1"
 	k8slabels "k8s.io/apimachinery/pkg/labels"
 )
 
 type NpmPod struct {
-	Name           string
-	Namespace      string
-	PodIP          string
+	Name      string
+	Namespace string
+	PodIP     string
+	// PodIPs has the valid IPs of the pod. Dual-stack pods have an IPv4 and an IPv6 IP (IPv6 is only supported in Linux).
+	PodIPs         []string
 	Labels         map[string]string
 	ContainerPorts []corev1.ContainerPort
 	Phase          corev1.PodPhase
@@ -36,6 +40,7 @@ func NewNpmPod(podObj *corev1.Pod) *NpmPod {
 		Name:           podObj.ObjectMeta.Name,
 		Namespace:      podObj.ObjectMeta.Namespace,
 		PodIP:          podObj.Status.PodIP,
+		PodIPs:         PodIPs(podObj),
 		Labels:         make(map[string]string),
 		ContainerPorts: []corev1.ContainerPort{},
 		Phase:          podObj.Status.Phase,
@@ -76,12 +81,33 @@ func (n *NpmPod) NoUpdate(podObj *corev1.Pod) bool {
 		n.Name == podObj.ObjectMeta.Name &&
 		n.Phase == podObj.Status.Phase &&
 		n.PodIP == podObj.Status.PodIP &&
+		slices.Equal(n.PodIPs, PodIPs(podObj)) &&
 		k8slabels.Equals(n.Labels, podObj.ObjectMeta.Labels) &&
 		// TODO(jungukcho) to avoid using DeepEqual for ContainerPorts,
 		// it needs a precise sorting. Will optimize it later if needed.
 		reflect.DeepEqual(n.ContainerPorts, GetContainerPortList(podObj))
 }
 
+// PodIPs returns the valid IPv4 and IPv6 IPs of the pod in the order of Status.PodIPs.
+// IPv6 IPs are left out in Windows.
+func PodIPs(podObj *corev1.Pod) []string {
+	ips := make([]string, 0, len(podObj.Status.PodIPs))
+	for _, podIP := range podObj.Status.PodIPs {
+		ips = append(ips, podIP.IP)
+	}
+	if len(ips) == 0 && podObj.Status.PodIP != "" {
+		ips = append(ips, podObj.Status.PodIP)
+	}
+
+	validIPs := ips[:0]
+	for _, ip := range ips {
+		if util.IsIPV4(ip) || (!util.IsWindowsDP() && util.IsIPV6(ip)) {
+			validIPs = append(validIPs, ip)
+		}
+	}
+	return validIPs
+}
+
 func GetContainerPortList(podObj *corev1.Pod) []corev1.ContainerPort {
 	portList := []corev1.ContainerPort{}
 	for _, container := range podObj.Spec.Containers { //nolint:gocritic // intentionally copying full struct :(
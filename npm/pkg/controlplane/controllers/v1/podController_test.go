@@ -721,7 +721,7 @@ func TestPodMapMarshalJSON(t *testing.T) {
 	npMapRaw, err := json.Marshal(f.podController)
 	assert.NoError(t, err)
 
-	expect := []byte(`{"test-namespace/test-pod":{"Name":"test-pod","Namespace":"test-namespace","PodIP":"1.2.3.4","Labels":{},"ContainerPorts":[],"Phase":"Running"}}`)
+	expect := []byte(`{"test-namespace/test-pod":{"Name":"test-pod","Namespace":"test-namespace","PodIP":"1.2.3.4","PodIPs":["1.2.3.4"],"Labels":{},"ContainerPorts":[],"Phase":"Running"}}`)
 	fmt.Printf("%s\n", string(npMapRaw))
 	assert.ElementsMatch(t, expect, npMapRaw)
 }
@@ -0,0 +1,302 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/translation"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	policyinformers "sigs.k8s.io/network-policy-api/pkg/client/informers/externalversions/apis/v1alpha1"
+	policylisters "sigs.k8s.io/network-policy-api/pkg/client/listers/apis/v1alpha1"
+)
+
+var errAdminPolicyKeyFormat = errors.New("invalid admin network policy key format")
+
+// AdminNetworkPolicyController handles both AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+// Both are cluster-scoped, so the workqueue key is the PolicyKey of the translated policy: <tier>/<name>.
+type AdminNetworkPolicyController struct {
+	sync.RWMutex
+	anpLister  policylisters.AdminNetworkPolicyLister
+	banpLister policylisters.BaselineAdminNetworkPolicyLister
+	workqueue  workqueue.RateLimitingInterface
+	// rawSpecMap holds the last applied *AdminNetworkPolicySpec or *BaselineAdminNetworkPolicySpec. Key is <tier>/<name>
+	rawSpecMap map[string]interface{}
+	dp         dataplane.GenericDataplane
+}
+
+func NewAdminNetworkPolicyController(
+	anpInformer policyinformers.AdminNetworkPolicyInformer,
+	banpInformer policyinformers.BaselineAdminNetworkPolicyInformer,
+	dp dataplane.GenericDataplane,
+) *AdminNetworkPolicyController {
+	anpController := &AdminNetworkPolicyController{
+		anpLister:  anpInformer.Lister(),
+		banpLister: banpInformer.Lister(),
+		workqueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AdminNetworkPolicy"),
+		rawSpecMap: make(map[string]interface{}),
+		dp:         dp,
+	}
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    anpController.addPolicy,
+		UpdateFunc: anpController.updatePolicy,
+		DeleteFunc: anpController.deletePolicy,
+	}
+	anpInformer.Informer().AddEventHandler(handlers)
+	banpInformer.Informer().AddEventHandler(handlers)
+	return anpController
+}
+
+func (c *AdminNetworkPolicyController) LengthOfRawSpecMap() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.rawSpecMap)
+}
+
+// getAdminPolicyKey returns <tier>/<name> if obj is an AdminNetworkPolicy or BaselineAdminNetworkPolicy.
+func getAdminPolicyKey(obj interface{}) (string, error) {
+	switch policy := obj.(type) {
+	case *policyv1alpha1.AdminNetworkPolicy:
+		return adminPolicyKey(policies.AdminTier, policy.Name), nil
+	case *policyv1alpha1.BaselineAdminNetworkPolicy:
+		return adminPolicyKey(policies.BaselineTier, policy.Name), nil
+	}
+	return "", fmt.Errorf("cannot cast obj (%v) to admin network policy obj err: %w", obj, errAdminPolicyKeyFormat)
+}
+
+// adminPolicyKey must match the PolicyKey from policies.NewNPMTieredPolicy.
+func adminPolicyKey(tier policies.Tier, name string) string {
+	return fmt.Sprintf("%s/%s", tier, name)
+}
+
+func (c *AdminNetworkPolicyController) addPolicy(obj interface{}) {
+	key, err := getAdminPolicyKey(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	c.workqueue.Add(key)
+}
+
+func (c *AdminNetworkPolicyController) updatePolicy(old, newObj interface{}) {
+	key, err := getAdminPolicyKey(newObj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	oldMeta, oldOk := old.(interface{ GetResourceVersion() string })
+	newMeta, newOk := newObj.(interface{ GetResourceVersion() string })
+	if oldOk && newOk && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+		// Periodic resync will send update events for all known policies.
+		// Two different versions of the same policy will always have different RVs.
+		return
+	}
+
+	c.workqueue.Add(key)
+}
+
+func (c *AdminNetworkPolicyController) deletePolicy(obj interface{}) {
+	// DeleteFunc gets the final state of the resource (if it is known).
+	// Otherwise, it gets an object of type DeletedFinalStateUnknown.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	key, err := getAdminPolicyKey(obj)
+	if err != nil {
+		metrics.SendErrorLogAndMetric(util.NetpolID, "[ADMIN NETPOL DELETE EVENT] Received unexpected object type: %v", obj)
+		return
+	}
+
+	c.workqueue.Add(key)
+}
+
+func (c *AdminNetworkPolicyController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	go wait.Until(c.runWorker, time.Second, stopCh)
+
+	<-stopCh
+}
+
+func (c *AdminNetworkPolicyController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *AdminNetworkPolicyController) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v, err %w", obj, errWorkqueueFormatting))
+			return nil
+		}
+		if err := c.syncPolicy(key); err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %w, requeuing", key, err)
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		return nil
+	}(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		metrics.SendErrorLogAndMetric(util.NetpolID, "syncAdminNetPol error due to %v", err)
+		return true
+	}
+
+	return true
+}
+
+// syncPolicy compares the actual state with the desired, and attempts to converge the two.
+func (c *AdminNetworkPolicyController) syncPolicy(key string) error {
+	timer := metrics.StartNewTimer()
+
+	c.Lock()
+	defer c.Unlock()
+
+	tier, name, found := strings.Cut(key, "/")
+	if !found {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s err: %w", key, errAdminPolicyKeyFormat))
+		return nil //nolint HandleError  is used instead of returning error to caller
+	}
+
+	// record exec time after syncing
+	var err error
+	operationKind := metrics.NoOp
+	defer func() {
+		metrics.RecordControllerPolicyExecTime(timer, operationKind, err != nil)
+	}()
+
+	var spec interface{}
+	var deleting bool
+	var npmNetPol *policies.NPMNetworkPolicy
+	var translateErr error
+	switch policies.Tier(tier) {
+	case policies.AdminTier:
+		var anpObj *policyv1alpha1.AdminNetworkPolicy
+		anpObj, err = c.anpLister.Get(name)
+		if err == nil {
+			spec = &anpObj.Spec
+			deleting = anpObj.DeletionTimestamp != nil || anpObj.DeletionGracePeriodSeconds != nil
+			npmNetPol, translateErr = translation.TranslateAdminNetworkPolicy(anpObj)
+		}
+	case policies.BaselineTier:
+		var banpObj *policyv1alpha1.BaselineAdminNetworkPolicy
+		banpObj, err = c.banpLister.Get(name)
+		if err == nil {
+			spec = &banpObj.Spec
+			deleting = banpObj.DeletionTimestamp != nil || banpObj.DeletionGracePeriodSeconds != nil
+			npmNetPol, translateErr = translation.TranslateBaselineAdminNetworkPolicy(banpObj)
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s err: %w", key, errAdminPolicyKeyFormat))
+		return nil //nolint HandleError  is used instead of returning error to caller
+	}
+
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		klog.Infof("Admin Network Policy %s is not found, may be it is deleted", key)
+		deleting = true
+	}
+
+	// clean up lastly applied states if the policy is deleted or being deleted
+	if deleting {
+		if _, ok := c.rawSpecMap[key]; ok {
+			operationKind = metrics.DeleteOp
+		}
+		err = c.cleanUpPolicy(key)
+		if err != nil {
+			return fmt.Errorf("[syncAdminNetPol] error: %w when cleaning up policy", err)
+		}
+		return nil
+	}
+
+	cachedSpec, policyExisted := c.rawSpecMap[key]
+	if policyExisted && reflect.DeepEqual(cachedSpec, spec) {
+		return nil
+	}
+
+	if translateErr != nil {
+		klog.Warningf("Admin Network Policy %s is not translated: %s", key, translateErr.Error())
+		// Re-queuing will result in the same error, so the policy is ignored until it's updated.
+		// A previously applied version of the policy is removed so that stale rules don't stay in the dataplane.
+		err = c.cleanUpPolicy(key)
+		if err != nil {
+			return fmt.Errorf("[syncAdminNetPol] error: %w when cleaning up untranslatable policy", err)
+		}
+		return nil
+	}
+
+	if policyExisted {
+		operationKind = metrics.UpdateOp
+	} else {
+		operationKind = metrics.CreateOp
+	}
+
+	// DP update policy call will check if this policy already exists in kernel
+	// if yes: then will delete old rules and program new rules
+	// if no: then will program add new rules
+	err = c.dp.UpdatePolicy(npmNetPol)
+	if err != nil {
+		return fmt.Errorf("[syncAdminNetPol] Error: failed to update translated NPMNetworkPolicy into Dataplane due to %w", err)
+	}
+
+	if !policyExisted {
+		metrics.IncNumPolicies()
+	}
+
+	c.rawSpecMap[key] = spec
+	return nil
+}
+
+// cleanUpPolicy removes the policy with the key from the dataplane if it was applied.
+func (c *AdminNetworkPolicyController) cleanUpPolicy(key string) error {
+	if _, ok := c.rawSpecMap[key]; !ok {
+		return nil
+	}
+
+	err := c.dp.RemovePolicy(key)
+	if err != nil {
+		return fmt.Errorf("[cleanUpAdminNetworkPolicy] Error: failed to remove policy due to %w", err)
+	}
+
+	delete(c.rawSpecMap, key)
+	metrics.DecNumPolicies()
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+package controllers
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	dpmocks "github.com/Azure/azure-container-networking/npm/pkg/dataplane/mocks"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	policyfake "sigs.k8s.io/network-policy-api/pkg/client/clientset/versioned/fake"
+	policyinformers "sigs.k8s.io/network-policy-api/pkg/client/informers/externalversions"
+)
+
+type adminNetPolFixture struct {
+	t *testing.T
+
+	anpController  *AdminNetworkPolicyController
+	policyInformer policyinformers.SharedInformerFactory
+}
+
+func newAdminNetPolFixture(t *testing.T, dp dataplane.GenericDataplane) *adminNetPolFixture {
+	policyInformer := policyinformers.NewSharedInformerFactory(policyfake.NewSimpleClientset(), noResyncPeriodFunc())
+	f := &adminNetPolFixture{
+		t:              t,
+		policyInformer: policyInformer,
+		anpController: NewAdminNetworkPolicyController(
+			policyInformer.Policy().V1alpha1().AdminNetworkPolicies(),
+			policyInformer.Policy().V1alpha1().BaselineAdminNetworkPolicies(),
+			dp,
+		),
+	}
+	metrics.ReinitializeAll()
+	// Do not start informer to avoid unnecessary event triggers
+	return f
+}
+
+func (f *adminNetPolFixture) anpIndexer() cache.Indexer {
+	return f.policyInformer.Policy().V1alpha1().AdminNetworkPolicies().Informer().GetIndexer()
+}
+
+func (f *adminNetPolFixture) banpIndexer() cache.Indexer {
+	return f.policyInformer.Policy().V1alpha1().BaselineAdminNetworkPolicies().Informer().GetIndexer()
+}
+
+func (f *adminNetPolFixture) processAll() {
+	for f.anpController.workqueue.Len() > 0 {
+		f.anpController.processNextWorkItem()
+	}
+}
+
+func createANP(priority int32) *policyv1alpha1.AdminNetworkPolicy {
+	return &policyv1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "deny-dev",
+			ResourceVersion: "1",
+		},
+		Spec: policyv1alpha1.AdminNetworkPolicySpec{
+			Priority: priority,
+			Subject:  policyv1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+			Ingress: []policyv1alpha1.AdminNetworkPolicyIngressRule{
+				{
+					Action: policyv1alpha1.AdminNetworkPolicyRuleActionDeny,
+					From: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{
+							Namespaces: &policyv1alpha1.NamespacedPeer{
+								NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createBANP() *policyv1alpha1.BaselineAdminNetworkPolicy {
+	return &policyv1alpha1.BaselineAdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "default",
+			ResourceVersion: "1",
+		},
+		Spec: policyv1alpha1.BaselineAdminNetworkPolicySpec{
+			Subject: policyv1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+			Egress: []policyv1alpha1.BaselineAdminNetworkPolicyEgressRule{
+				{
+					Action: policyv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
+					To: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAddAdminNetworkPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dp := dpmocks.NewMockGenericDataplane(ctrl)
+	f := newAdminNetPolFixture(t, dp)
+
+	anpObj := createANP(10)
+	banpObj := createBANP()
+	require.NoError(t, f.anpIndexer().Add(anpObj))
+	require.NoError(t, f.banpIndexer().Add(banpObj))
+
+	dp.EXPECT().UpdatePolicy(gomock.Any()).DoAndReturn(func(policy *policies.NPMNetworkPolicy) error {
+		require.Equal(t, policies.AdminTier, policy.Tier)
+		require.Equal(t, "ADMIN/deny-dev", policy.PolicyKey)
+		require.Equal(t, int32(10), policy.Priority)
+		return nil
+	}).Times(1)
+	dp.EXPECT().UpdatePolicy(gomock.Any()).DoAndReturn(func(policy *policies.NPMNetworkPolicy) error {
+		require.Equal(t, policies.BaselineTier, policy.Tier)
+		require.Equal(t, "BASELINE/default", policy.PolicyKey)
+		return nil
+	}).Times(1)
+
+	f.anpController.addPolicy(anpObj)
+	f.processAll()
+	f.anpController.addPolicy(banpObj)
+	f.processAll()
+
+	require.Equal(t, 2, f.anpController.LengthOfRawSpecMap())
+	(&netPolPromVals{2, 2, 0, 0}).testPrometheusMetrics(t)
+}
+
+func TestUpdateAdminNetworkPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dp := dpmocks.NewMockGenericDataplane(ctrl)
+	f := newAdminNetPolFixture(t, dp)
+
+	oldObj := createANP(10)
+	require.NoError(t, f.anpIndexer().Add(oldObj))
+	dp.EXPECT().UpdatePolicy(gomock.Any()).Times(2)
+	f.anpController.addPolicy(oldObj)
+	f.processAll()
+
+	// same resource version is ignored
+	f.anpController.updatePolicy(oldObj, oldObj)
+	require.Equal(t, 0, f.anpController.workqueue.Len())
+
+	newObj := createANP(20)
+	newObj.ResourceVersion = "2"
+	require.NoError(t, f.anpIndexer().Update(newObj))
+	f.anpController.updatePolicy(oldObj, newObj)
+	f.processAll()
+
+	require.Equal(t, 1, f.anpController.LengthOfRawSpecMap())
+	(&netPolPromVals{1, 1, 1, 0}).testPrometheusMetrics(t)
+}
+
+func TestDeleteAdminNetworkPolicyWithTombstone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dp := dpmocks.NewMockGenericDataplane(ctrl)
+	f := newAdminNetPolFixture(t, dp)
+
+	anpObj := createANP(10)
+	require.NoError(t, f.anpIndexer().Add(anpObj))
+	dp.EXPECT().UpdatePolicy(gomock.Any()).Times(1)
+	dp.EXPECT().RemovePolicy("ADMIN/deny-dev").Times(1)
+	f.anpController.addPolicy(anpObj)
+	f.processAll()
+
+	require.NoError(t, f.anpIndexer().Delete(anpObj))
+	f.anpController.deletePolicy(cache.DeletedFinalStateUnknown{Key: anpObj.Name, Obj: anpObj})
+	f.processAll()
+
+	require.Equal(t, 0, f.anpController.LengthOfRawSpecMap())
+	(&netPolPromVals{0, 1, 0, 1}).testPrometheusMetrics(t)
+}
+
+func TestUntranslatableAdminNetworkPolicyIsRemoved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dp := dpmocks.NewMockGenericDataplane(ctrl)
+	f := newAdminNetPolFixture(t, dp)
+
+	oldObj := createANP(10)
+	require.NoError(t, f.anpIndexer().Add(oldObj))
+	dp.EXPECT().UpdatePolicy(gomock.Any()).Times(1)
+	dp.EXPECT().RemovePolicy("ADMIN/deny-dev").Times(1)
+	f.anpController.addPolicy(oldObj)
+	f.processAll()
+
+	// sameLabels isn't supported, so the previous version is removed and the policy isn't requeued
+	newObj := createANP(10)
+	newObj.ResourceVersion = "2"
+	newObj.Spec.Ingress[0].From[0].Namespaces.SameLabels = []string{"team"}
+	require.NoError(t, f.anpIndexer().Update(newObj))
+	f.anpController.updatePolicy(oldObj, newObj)
+	f.processAll()
+
+	require.Equal(t, 0, f.anpController.LengthOfRawSpecMap())
+	require.Equal(t, 0, f.anpController.workqueue.Len())
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"sync"
 	"time"
 
@@ -356,9 +357,10 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 	// klog.Infof("POD CREATING: [%s/%s/%s/%s/%+v/%s]", string(podObj.GetUID()), podObj.Namespace,
 	// 	podObj.Name, podObj.Spec.NodeName, podObj.Labels, podObj.Status.PodIP)
 
-	if !util.IsIPV4(podObj.Status.PodIP) {
-		msg := fmt.Sprintf("[syncAddedPod] warning: ADD POD  [%s/%s/%s/%+v] ignored as the PodIP is not valid ipv4 address. ip: [%s]", podObj.Namespace,
-			podObj.Name, podObj.Spec.NodeName, podObj.Labels, podObj.Status.PodIP)
+	podIPs := common.PodIPs(podObj)
+	if len(podIPs) == 0 {
+		msg := fmt.Sprintf("[syncAddedPod] warning: ADD POD  [%s/%s/%s/%+v] ignored as the pod has no valid IP address. ips: [%+v]", podObj.Namespace,
+			podObj.Name, podObj.Spec.NodeName, podObj.Labels, podObj.Status.PodIPs)
 		metrics.SendLog(util.PodID, msg, metrics.PrintLog)
 		// return nil so that we don't requeue.
 		// Wait until an update event comes from API Server where the IP is valid e.g. if the IP is empty.
@@ -370,14 +372,12 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 	var err error
 	podKey, _ := cache.MetaNamespaceKeyFunc(podObj)
 
-	podMetadata := dataplane.NewPodMetadata(podKey, podObj.Status.PodIP, podObj.Spec.NodeName)
-
 	namespaceSet := []*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(podObj.Namespace, ipsets.Namespace)}
 
 	// Add the pod ip information into namespace's ipset.
 	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 	// klog.Infof("Adding pod %s (ip : %s) to ipset %s", podKey, podObj.Status.PodIP, podObj.Namespace)
-	if err = c.dp.AddToSets(namespaceSet, podMetadata); err != nil {
+	if err = c.addToSets(namespaceSet, podKey, podIPs, podObj.Spec.NodeName); err != nil {
 		return fmt.Errorf("[syncAddedPod] Error: failed to add pod to namespace ipset with err: %w", err)
 	}
 
@@ -397,7 +397,7 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 		// klog.Infof("Creating ipsets %+v and %+v if they do not exist", targetSetKey, targetSetKeyValue)
 		// klog.Infof("Adding pod %s (ip : %s) to ipset %s and %s", podKey, npmPodObj.PodIP, labelKey, labelKeyValue)
-		if err = c.dp.AddToSets(allSets, podMetadata); err != nil {
+		if err = c.addToSets(allSets, podKey, podIPs, podObj.Spec.NodeName); err != nil {
 			return fmt.Errorf("[syncAddedPod] Error: failed to add pod to label ipset with err: %w", err)
 		}
 		npmPodObj.AppendLabels(map[string]string{labelKey: labelVal}, common.AppendToExistingLabels)
@@ -407,7 +407,7 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 	// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 	// klog.Infof("Adding named port ipsets")
 	containerPorts := common.GetContainerPortList(podObj)
-	if err = c.manageNamedPortIpsets(containerPorts, podKey, npmPodObj.PodIP, podObj.Spec.NodeName, addNamedPort); err != nil {
+	if err = c.manageNamedPortIpsets(containerPorts, podKey, podIPs, podObj.Spec.NodeName, addNamedPort); err != nil {
 		return fmt.Errorf("[syncAddedPod] Error: failed to add pod to named port ipset with err: %w", err)
 	}
 	npmPodObj.AppendContainerPorts(podObj)
@@ -455,7 +455,8 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) (metrics.Oper
 	// Dealing with #2 pod update event, the IP addresses of cached npmPod and newPodObj are different
 	// NPM should clean up existing references of cached pod obj and its IP.
 	// then, re-add new pod obj.
-	if cachedNpmPod.PodIP != newPodObj.Status.PodIP {
+	newPodIPs := common.PodIPs(newPodObj)
+	if cachedNpmPod.PodIP != newPodObj.Status.PodIP || !slices.Equal(cachedNpmPod.PodIPs, newPodIPs) {
 		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 		// klog.Infof("Pod (Namespace:%s, Name:%s, newUid:%s), has cachedPodIp:%s which is different from PodIp:%s",
 		// 	newPodObj.Namespace, newPodObj.Name, string(newPodObj.UID), cachedNpmPod.PodIP, newPodObj.Status.PodIP)
@@ -476,9 +477,6 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) (metrics.Oper
 	// Otherwise it returns list of deleted PodIP from cached pod's labels and list of added PodIp from new pod's labels
 	addToIPSets, deleteFromIPSets := util.GetIPSetListCompareLabels(cachedNpmPod.Labels, newPodObj.Labels)
 
-	newPodMetadata := dataplane.NewPodMetadata(podKey, newPodObj.Status.PodIP, newPodObj.Spec.NodeName)
-	// should have newPodMetadata == cachedPodMetadata since from branch above, we have cachedNpmPod.PodIP == newPodObj.Status.PodIP
-	cachedPodMetadata := dataplane.NewPodMetadata(podKey, cachedNpmPod.PodIP, newPodMetadata.NodeName)
 	// Delete the pod from its label's ipset.
 	for _, removeIPSetName := range deleteFromIPSets {
 		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
@@ -490,7 +488,8 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) (metrics.Oper
 		} else {
 			toRemoveSet = ipsets.NewIPSetMetadata(removeIPSetName, ipsets.KeyLabelOfPod)
 		}
-		if err = c.dp.RemoveFromSets([]*ipsets.IPSetMetadata{toRemoveSet}, cachedPodMetadata); err != nil {
+		// from the branch above, we have cachedNpmPod.PodIPs == newPodIPs
+		if err = c.removeFromSets([]*ipsets.IPSetMetadata{toRemoveSet}, podKey, cachedNpmPod.PodIPs, newPodObj.Spec.NodeName); err != nil {
 			return metrics.UpdateOp, fmt.Errorf("[syncAddAndUpdatePod] Error: failed to delete pod from label ipset with err: %w", err)
 		}
 		// {IMPORTANT} The order of compared list will be key and then key+val. NPM should only append after both key
@@ -516,7 +515,7 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) (metrics.Oper
 
 		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 		// klog.Infof("Adding pod %s (ip : %s) to ipset %s", podKey, newPodObj.Status.PodIP, addIPSetName)
-		if err = c.dp.AddToSets([]*ipsets.IPSetMetadata{toAddSet}, newPodMetadata); err != nil {
+		if err = c.addToSets([]*ipsets.IPSetMetadata{toAddSet}, podKey, newPodIPs, newPodObj.Spec.NodeName); err != nil {
 			return metrics.UpdateOp, fmt.Errorf("[syncAddAndUpdatePod] Error: failed to add pod to label ipset with err: %w", err)
 		}
 		// {IMPORTANT} Same as above order is assumed to be key and then key+val. NPM should only append to existing labels
@@ -539,14 +538,14 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) (metrics.Oper
 	if !reflect.DeepEqual(cachedNpmPod.ContainerPorts, newPodPorts) {
 		// Delete cached pod's named ports from its ipset.
 		if err = c.manageNamedPortIpsets(
-			cachedNpmPod.ContainerPorts, podKey, cachedNpmPod.PodIP, "", deleteNamedPort); err != nil {
+			cachedNpmPod.ContainerPorts, podKey, cachedNpmPod.PodIPs, "", deleteNamedPort); err != nil {
 			return metrics.UpdateOp, fmt.Errorf("[syncAddAndUpdatePod] Error: failed to delete pod from named port ipset with err: %w", err)
 		}
 		// Since portList ipset deletion is successful, NPM can remove cachedContainerPorts
 		cachedNpmPod.RemoveContainerPorts()
 
 		// Add new pod's named ports from its ipset.
-		if err = c.manageNamedPortIpsets(newPodPorts, podKey, newPodObj.Status.PodIP, newPodObj.Spec.NodeName, addNamedPort); err != nil {
+		if err = c.manageNamedPortIpsets(newPodPorts, podKey, newPodIPs, newPodObj.Spec.NodeName, addNamedPort); err != nil {
 			return metrics.UpdateOp, fmt.Errorf("[syncAddAndUpdatePod] Error: failed to add pod to named port ipset with err: %w", err)
 		}
 		cachedNpmPod.AppendContainerPorts(newPodObj)
@@ -567,12 +566,11 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 	}
 
 	var err error
-	cachedPodMetadata := dataplane.NewPodMetadata(cachedNpmPodKey, cachedNpmPod.PodIP, "")
 	// Delete the pod from its namespace's ipset.
 	// note: NodeName empty is not going to call update pod
-	if err = c.dp.RemoveFromSets(
+	if err = c.removeFromSets(
 		[]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(cachedNpmPod.Namespace, ipsets.Namespace)},
-		cachedPodMetadata); err != nil {
+		cachedNpmPodKey, cachedNpmPod.PodIPs, ""); err != nil {
 		return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from namespace ipset with err: %w", err)
 	}
 
@@ -581,12 +579,12 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 		labelKeyValue := util.GetIpSetFromLabelKV(labelKey, labelVal)
 		// TODO: Refactor non-error/warning klogs with Zap and set the following logs to "debug" level
 		// klog.Infof("Deleting pod %s (ip : %s) from ipsets %s and %s", cachedNpmPodKey, cachedNpmPod.PodIP, labelKey, labelKeyValue)
-		if err = c.dp.RemoveFromSets(
+		if err = c.removeFromSets(
 			[]*ipsets.IPSetMetadata{
 				ipsets.NewIPSetMetadata(labelKey, ipsets.KeyLabelOfPod),
 				ipsets.NewIPSetMetadata(labelKeyValue, ipsets.KeyValueLabelOfPod),
 			},
-			cachedPodMetadata); err != nil {
+			cachedNpmPodKey, cachedNpmPod.PodIPs, ""); err != nil {
 			return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from label ipset with err: %w", err)
 		}
 		cachedNpmPod.RemoveLabelsWithKey(labelKey)
@@ -594,7 +592,7 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 
 	// Delete pod's named ports from its ipset. Need to pass true in the manageNamedPortIpsets function call
 	if err = c.manageNamedPortIpsets(
-		cachedNpmPod.ContainerPorts, cachedNpmPodKey, cachedNpmPod.PodIP, "", deleteNamedPort); err != nil {
+		cachedNpmPod.ContainerPorts, cachedNpmPodKey, cachedNpmPod.PodIPs, "", deleteNamedPort); err != nil {
 		return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from named port ipset with err: %w", err)
 	}
 
@@ -603,9 +601,29 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 	return nil
 }
 
+// addToSets adds each IP of a (possibly dual-stack) pod to the sets.
+func (c *PodController) addToSets(setNames []*ipsets.IPSetMetadata, podKey string, podIPs []string, nodeName string) error {
+	for _, podIP := range podIPs {
+		if err := c.dp.AddToSets(setNames, dataplane.NewPodMetadata(podKey, podIP, nodeName)); err != nil {
+			return err //nolint:wrapcheck // callers wrap the error
+		}
+	}
+	return nil
+}
+
+// removeFromSets removes each IP of a (possibly dual-stack) pod from the sets.
+func (c *PodController) removeFromSets(setNames []*ipsets.IPSetMetadata, podKey string, podIPs []string, nodeName string) error {
+	for _, podIP := range podIPs {
+		if err := c.dp.RemoveFromSets(setNames, dataplane.NewPodMetadata(podKey, podIP, nodeName)); err != nil {
+			return err //nolint:wrapcheck // callers wrap the error
+		}
+	}
+	return nil
+}
+
 // manageNamedPortIpsets helps with adding or deleting Pod namedPort IPsets.
-func (c *PodController) manageNamedPortIpsets(portList []corev1.ContainerPort, podKey,
-	podIP, nodeName string, namedPortOperation NamedPortOperation) error {
+func (c *PodController) manageNamedPortIpsets(portList []corev1.ContainerPort, podKey string,
+	podIPs []string, nodeName string, namedPortOperation NamedPortOperation) error {
 	if util.IsWindowsDP() {
 		// NOTE: if we support namedport operations, need to be careful of implications of including the node name in the pod metadata below
 		// since we say the node name is "" in cleanUpDeletedPod
@@ -626,18 +644,20 @@ func (c *PodController) manageNamedPortIpsets(portList []corev1.ContainerPort, p
 			protocol = fmt.Sprintf("%s:", port.Protocol)
 		}
 
-		namedPortIpsetEntry := fmt.Sprintf("%s,%s%d", podIP, protocol, port.ContainerPort)
-
-		// nodename in NewPodMetadata is nil so UpdatePod is ignored
-		podMetadata := dataplane.NewPodMetadata(podKey, namedPortIpsetEntry, nodeName)
-		switch namedPortOperation {
-		case deleteNamedPort:
-			if err := c.dp.RemoveFromSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(port.Name, ipsets.NamedPorts)}, podMetadata); err != nil {
-				return fmt.Errorf("failed to remove from set when deleting named port with err %w", err)
-			}
-		case addNamedPort:
-			if err := c.dp.AddToSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(port.Name, ipsets.NamedPorts)}, podMetadata); err != nil {
-				return fmt.Errorf("failed to add to set when deleting named port with err %w", err)
+		for _, podIP := range podIPs {
+			namedPortIpsetEntry := fmt.Sprintf("%s,%s%d", podIP, protocol, port.ContainerPort)
+
+			// nodename in NewPodMetadata is nil so UpdatePod is ignored
+			podMetadata := dataplane.NewPodMetadata(podKey, namedPortIpsetEntry, nodeName)
+			switch namedPortOperation {
+			case deleteNamedPort:
+				if err := c.dp.RemoveFromSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(port.Name, ipsets.NamedPorts)}, podMetadata); err != nil {
+					return fmt.Errorf("failed to remove from set when deleting named port with err %w", err)
+				}
+			case addNamedPort:
+				if err := c.dp.AddToSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(port.Name, ipsets.NamedPorts)}, podMetadata); err != nil {
+					return fmt.Errorf("failed to add to set when deleting named port with err %w", err)
+				}
 			}
 		}
 	}
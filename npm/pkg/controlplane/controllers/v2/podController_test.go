@@ -381,6 +381,62 @@ func TestAddPod(t *testing.T) {
 	checkNpmPodWithInput("TestAddPod", f, podObj)
 }
 
+func TestAddDualStackPod(t *testing.T) {
+	labels := map[string]string{
+		"app": "test-pod",
+	}
+	podObj := createPod("test-pod", "test-namespace", "0", "1.2.3.4", labels, NonHostNetwork, corev1.PodRunning)
+	podObj.Status.PodIPs = []corev1.PodIP{{IP: "1.2.3.4"}, {IP: "fd00::4"}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dp := dpmocks.NewMockGenericDataplane(ctrl)
+	f := newFixture(t, dp)
+	f.podLister = append(f.podLister, podObj)
+	f.kubeobjects = append(f.kubeobjects, podObj)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	f.newPodController(stopCh)
+
+	mockIPSets := []*ipsets.IPSetMetadata{
+		ipsets.NewIPSetMetadata("test-namespace", ipsets.Namespace),
+		ipsets.NewIPSetMetadata("app", ipsets.KeyLabelOfPod),
+		ipsets.NewIPSetMetadata("app:test-pod", ipsets.KeyValueLabelOfPod),
+	}
+	podIPs := []string{"1.2.3.4"}
+	if !util.IsWindowsDP() {
+		// IPv6 is only supported in Linux
+		podIPs = append(podIPs, "fd00::4")
+	}
+
+	dp.EXPECT().AddToLists([]*ipsets.IPSetMetadata{kubeAllNamespaces}, mockIPSets[:1]).Return(nil).Times(1)
+	for _, podIP := range podIPs {
+		podMetadata := dataplane.NewPodMetadata("test-namespace/test-pod", podIP, "")
+		dp.EXPECT().AddToSets(mockIPSets[:1], podMetadata).Return(nil).Times(1)
+		dp.EXPECT().AddToSets(mockIPSets[1:], podMetadata).Return(nil).Times(1)
+		if !util.IsWindowsDP() {
+			dp.EXPECT().
+				AddToSets(
+					[]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata("app:test-pod", ipsets.NamedPorts)},
+					dataplane.NewPodMetadata("test-namespace/test-pod", podIP+",8080", ""),
+				).
+				Return(nil).Times(1)
+		}
+	}
+	dp.EXPECT().ApplyDataPlane().Return(nil).Times(1)
+
+	addPod(t, f, podObj)
+	testCases := []expectedValues{
+		{1, 1, 0, podPromVals{1, 1, 0, 0, 0, 0, 0}},
+	}
+	// sleep in case rate limiter adds back to workqueue
+	time.Sleep(sleepDurationForRateLimiter)
+	checkPodTestResult("TestAddDualStackPod", f, testCases)
+	checkNpmPodWithInput("TestAddDualStackPod", f, podObj)
+	require.Equal(t, podIPs, f.podController.podMap[getKey(podObj, t)].PodIPs)
+}
+
 func TestAddHostNetworkPod(t *testing.T) {
 	labels := map[string]string{
 		"app": "test-pod",
@@ -1034,7 +1090,7 @@ func TestPodMapMarshalJSON(t *testing.T) {
 	npMapRaw, err := f.podController.MarshalJSON()
 	assert.NoError(t, err)
 
-	expect := []byte(`{"test-namespace/test-pod":{"Name":"test-pod","Namespace":"test-namespace","PodIP":"1.2.3.4","Labels":{},"ContainerPorts":[],"Phase":"Running"}}`)
+	expect := []byte(`{"test-namespace/test-pod":{"Name":"test-pod","Namespace":"test-namespace","PodIP":"1.2.3.4","PodIPs":["1.2.3.4"],"Labels":{},"ContainerPorts":[],"Phase":"Running"}}`)
 	fmt.Printf("%s\n", string(npMapRaw))
 	assert.ElementsMatch(t, expect, npMapRaw)
 }
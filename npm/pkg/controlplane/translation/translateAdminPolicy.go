@@ -0,0 +1,273 @@
+package translation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+var (
+	// ErrUnsupportedSameLabels is returned when a peer uses sameLabels or notSameLabels, which NPM doesn't support.
+	ErrUnsupportedSameLabels = errors.New("unsupported sameLabels or notSameLabels in AdminNetworkPolicy peer")
+	// ErrUnsupportedMultiValueSubject is returned when a subject's namespaceSelector has a matchExpression with multiple values.
+	ErrUnsupportedMultiValueSubject = errors.New("unsupported matchExpression with multiple values in namespaceSelector of AdminNetworkPolicy subject")
+	// ErrUnsupportedPassAction is returned when the Pass action is used in windows.
+	ErrUnsupportedPassAction = errors.New("unsupported Pass action used on windows")
+	// ErrInvalidAdminPolicy is returned when an AdminNetworkPolicy or BaselineAdminNetworkPolicy is missing a required field.
+	ErrInvalidAdminPolicy = errors.New("invalid AdminNetworkPolicy or BaselineAdminNetworkPolicy")
+)
+
+// tierRule is the common form of the ingress and egress rules of AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+type tierRule struct {
+	action policies.Verdict
+	peers  []policyv1alpha1.AdminNetworkPolicyPeer
+	ports  *[]policyv1alpha1.AdminNetworkPolicyPort
+}
+
+// TranslateAdminNetworkPolicy translates an AdminNetworkPolicy object to an NPMNetworkPolicy object in the AdminTier.
+// Rules are kept in order since the first matching rule decides the action.
+func TranslateAdminNetworkPolicy(anpObj *policyv1alpha1.AdminNetworkPolicy) (*policies.NPMNetworkPolicy, error) {
+	npmNetPol := policies.NewNPMTieredPolicy(policies.AdminTier, anpObj.Name, anpObj.Spec.Priority)
+
+	ingressRules := make([]tierRule, 0, len(anpObj.Spec.Ingress))
+	for _, rule := range anpObj.Spec.Ingress {
+		action, err := adminRuleAction(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+		ingressRules = append(ingressRules, tierRule{action: action, peers: rule.From, ports: rule.Ports})
+	}
+
+	egressRules := make([]tierRule, 0, len(anpObj.Spec.Egress))
+	for _, rule := range anpObj.Spec.Egress {
+		action, err := adminRuleAction(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+		egressRules = append(egressRules, tierRule{action: action, peers: rule.To, ports: rule.Ports})
+	}
+
+	if err := translateTieredPolicy(npmNetPol, &anpObj.Spec.Subject, ingressRules, egressRules); err != nil {
+		return nil, err
+	}
+	return npmNetPol, nil
+}
+
+// TranslateBaselineAdminNetworkPolicy translates a BaselineAdminNetworkPolicy object to an NPMNetworkPolicy object in the BaselineTier.
+func TranslateBaselineAdminNetworkPolicy(banpObj *policyv1alpha1.BaselineAdminNetworkPolicy) (*policies.NPMNetworkPolicy, error) {
+	npmNetPol := policies.NewNPMTieredPolicy(policies.BaselineTier, banpObj.Name, 0)
+
+	ingressRules := make([]tierRule, 0, len(banpObj.Spec.Ingress))
+	for _, rule := range banpObj.Spec.Ingress {
+		action, err := baselineRuleAction(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+		ingressRules = append(ingressRules, tierRule{action: action, peers: rule.From, ports: rule.Ports})
+	}
+
+	egressRules := make([]tierRule, 0, len(banpObj.Spec.Egress))
+	for _, rule := range banpObj.Spec.Egress {
+		action, err := baselineRuleAction(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+		egressRules = append(egressRules, tierRule{action: action, peers: rule.To, ports: rule.Ports})
+	}
+
+	if err := translateTieredPolicy(npmNetPol, &banpObj.Spec.Subject, ingressRules, egressRules); err != nil {
+		return nil, err
+	}
+	return npmNetPol, nil
+}
+
+func adminRuleAction(action policyv1alpha1.AdminNetworkPolicyRuleAction) (policies.Verdict, error) {
+	switch action {
+	case policyv1alpha1.AdminNetworkPolicyRuleActionAllow:
+		return policies.Allowed, nil
+	case policyv1alpha1.AdminNetworkPolicyRuleActionDeny:
+		return policies.Dropped, nil
+	case policyv1alpha1.AdminNetworkPolicyRuleActionPass:
+		if util.IsWindowsDP() {
+			return "", ErrUnsupportedPassAction
+		}
+		return policies.Passed, nil
+	}
+	return "", fmt.Errorf("unknown action %s: %w", action, ErrInvalidAdminPolicy)
+}
+
+func baselineRuleAction(action policyv1alpha1.BaselineAdminNetworkPolicyRuleAction) (policies.Verdict, error) {
+	switch action {
+	case policyv1alpha1.BaselineAdminNetworkPolicyRuleActionAllow:
+		return policies.Allowed, nil
+	case policyv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny:
+		return policies.Dropped, nil
+	}
+	return "", fmt.Errorf("unknown action %s: %w", action, ErrInvalidAdminPolicy)
+}
+
+func translateTieredPolicy(npmNetPol *policies.NPMNetworkPolicy, subject *policyv1alpha1.AdminNetworkPolicySubject, ingressRules, egressRules []tierRule) error {
+	if err := tierSubject(npmNetPol, subject); err != nil {
+		return err
+	}
+
+	for _, rule := range ingressRules {
+		if err := translateTierRule(npmNetPol, policies.Ingress, policies.SrcMatch, rule); err != nil {
+			return err
+		}
+	}
+	for _, rule := range egressRules {
+		if err := translateTierRule(npmNetPol, policies.Egress, policies.DstMatch, rule); err != nil {
+			return err
+		}
+	}
+
+	if util.IsWindowsDP() {
+		for _, acl := range npmNetPol.ACLs {
+			if acl.Protocol == policies.SCTP {
+				return ErrUnsupportedSCTP
+			}
+		}
+	}
+	return nil
+}
+
+// tierSubject translates the subject of an AdminNetworkPolicy or BaselineAdminNetworkPolicy to the pod selector fields of npmNetPol.
+// Unlike a NetworkPolicy, the subject can select pods in any namespace.
+func tierSubject(npmNetPol *policies.NPMNetworkPolicy, subject *policyv1alpha1.AdminNetworkPolicySubject) error {
+	var nsSelector *metav1.LabelSelector
+	switch {
+	case subject.Namespaces != nil:
+		nsSelector = subject.Namespaces
+	case subject.Pods != nil:
+		nsSelector = &subject.Pods.NamespaceSelector
+	default:
+		return fmt.Errorf("subject of policy %s must have namespaces or pods: %w", npmNetPol.PolicyKey, ErrInvalidAdminPolicy)
+	}
+
+	// the subject is a single intersection of ipsets, so it can't hold the union of namespaces that a flattened selector represents
+	flattenNSSelector, err := flattenNameSpaceSelector(nsSelector)
+	if err != nil {
+		return err
+	}
+	if len(flattenNSSelector) != 1 {
+		return ErrUnsupportedMultiValueSubject
+	}
+	nsSelectorIPSets, nsSelectorList := nameSpaceSelector(policies.EitherMatch, &flattenNSSelector[0])
+	npmNetPol.PodSelectorIPSets = append(npmNetPol.PodSelectorIPSets, nsSelectorIPSets...)
+	npmNetPol.PodSelectorList = append(npmNetPol.PodSelectorList, nsSelectorList...)
+
+	if subject.Pods == nil {
+		return nil
+	}
+
+	psResult, err := podSelector(npmNetPol.PolicyKey, policies.EitherMatch, &subject.Pods.PodSelector)
+	if err != nil {
+		return err
+	}
+	npmNetPol.PodSelectorIPSets = append(npmNetPol.PodSelectorIPSets, psResult.psSets...)
+	npmNetPol.ChildPodSelectorIPSets = append(npmNetPol.ChildPodSelectorIPSets, psResult.childPSSets...)
+	npmNetPol.PodSelectorList = append(npmNetPol.PodSelectorList, psResult.psList...)
+	return nil
+}
+
+// translateTierRule adds an ACL with the rule's action for each combination of peer and port.
+func translateTierRule(npmNetPol *policies.NPMNetworkPolicy, direction policies.Direction, matchType policies.MatchType, rule tierRule) error {
+	for _, peer := range rule.peers {
+		var namespaces *policyv1alpha1.NamespacedPeer
+		var psResult *podSelectorResult
+		switch {
+		case peer.Namespaces != nil:
+			namespaces = peer.Namespaces
+		case peer.Pods != nil:
+			namespaces = &peer.Pods.Namespaces
+			var err error
+			psResult, err = podSelector(npmNetPol.PolicyKey, matchType, &peer.Pods.PodSelector)
+			if err != nil {
+				return err
+			}
+			npmNetPol.RuleIPSets = append(npmNetPol.RuleIPSets, psResult.psSets...)
+			npmNetPol.RuleIPSets = append(npmNetPol.RuleIPSets, psResult.childPSSets...)
+		default:
+			return fmt.Errorf("peer in policy %s must have namespaces or pods: %w", npmNetPol.PolicyKey, ErrInvalidAdminPolicy)
+		}
+
+		if len(namespaces.SameLabels) > 0 || len(namespaces.NotSameLabels) > 0 {
+			return ErrUnsupportedSameLabels
+		}
+		if namespaces.NamespaceSelector == nil {
+			return fmt.Errorf("peer in policy %s must have a namespaceSelector: %w", npmNetPol.PolicyKey, ErrInvalidAdminPolicy)
+		}
+
+		// Before translating NamespaceSelector, flattenNameSpaceSelector function call should be called
+		// to handle multiple values in matchExpressions spec.
+		flattenNSSelector, err := flattenNameSpaceSelector(namespaces.NamespaceSelector)
+		if err != nil {
+			return err
+		}
+
+		for i := range flattenNSSelector {
+			nsSelectorIPSets, nsSelectorList := nameSpaceSelector(matchType, &flattenNSSelector[i])
+			npmNetPol.RuleIPSets = append(npmNetPol.RuleIPSets, nsSelectorIPSets...)
+			if psResult != nil {
+				nsSelectorList = append(nsSelectorList, psResult.psList...)
+			}
+			if err := tierPeerAndPortRule(npmNetPol, direction, rule.action, rule.ports, nsSelectorList); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tierPeerAndPortRule is like peerAndPortRule, but with the rule's action and AdminNetworkPolicy ports.
+func tierPeerAndPortRule(npmNetPol *policies.NPMNetworkPolicy, direction policies.Direction, action policies.Verdict,
+	ports *[]policyv1alpha1.AdminNetworkPolicyPort, setInfo []policies.SetInfo,
+) error {
+	if ports == nil || len(*ports) == 0 {
+		acl := policies.NewACLPolicy(action, direction)
+		acl.AddSetInfo(setInfo)
+		npmNetPol.ACLs = append(npmNetPol.ACLs, acl)
+		return nil
+	}
+
+	for i := range *ports {
+		port := &(*ports)[i]
+		acl := policies.NewACLPolicy(action, direction)
+		acl.AddSetInfo(setInfo)
+		switch {
+		case port.PortNumber != nil:
+			acl.DstPorts = policies.Ports{Port: port.PortNumber.Port}
+			acl.Protocol = tierProtocol(port.PortNumber.Protocol)
+		case port.PortRange != nil:
+			acl.DstPorts = policies.Ports{Port: port.PortRange.Start, EndPort: port.PortRange.End}
+			acl.Protocol = tierProtocol(port.PortRange.Protocol)
+		case port.NamedPort != nil:
+			if util.IsWindowsDP() {
+				return ErrUnsupportedNamedPort
+			}
+			// port rule is always applied to destination side.
+			// The protocol is left unspecified since the named port ipset holds the protocol of each container port.
+			npmNetPol.RuleIPSets = append(npmNetPol.RuleIPSets, ipsets.NewTranslatedIPSet(*port.NamedPort, ipsets.NamedPorts))
+			acl.AddSetInfo([]policies.SetInfo{policies.NewSetInfo(*port.NamedPort, ipsets.NamedPorts, included, policies.DstDstMatch)})
+		default:
+			return fmt.Errorf("port in policy %s must have portNumber, portRange, or namedPort: %w", npmNetPol.PolicyKey, ErrInvalidAdminPolicy)
+		}
+		npmNetPol.ACLs = append(npmNetPol.ACLs, acl)
+	}
+	return nil
+}
+
+// tierProtocol defaults to TCP like NetworkPolicy ports.
+func tierProtocol(protocol corev1.Protocol) policies.Protocol {
+	if protocol == "" {
+		return policies.TCP
+	}
+	return policies.Protocol(protocol)
+}
@@ -0,0 +1,197 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+func TestTranslateAdminNetworkPolicy(t *testing.T) {
+	namedPort := "serve-80"
+	anpObj := &policyv1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-control"},
+		Spec: policyv1alpha1.AdminNetworkPolicySpec{
+			Priority: 5,
+			Subject: policyv1alpha1.AdminNetworkPolicySubject{
+				Pods: &policyv1alpha1.NamespacedPodSubject{
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				},
+			},
+			Ingress: []policyv1alpha1.AdminNetworkPolicyIngressRule{
+				{
+					Action: policyv1alpha1.AdminNetworkPolicyRuleActionDeny,
+					From: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}}},
+					},
+					Ports: &[]policyv1alpha1.AdminNetworkPolicyPort{
+						{PortNumber: &policyv1alpha1.Port{Protocol: corev1.ProtocolUDP, Port: 53}},
+						{PortRange: &policyv1alpha1.PortRange{Start: 8000, End: 9000}},
+					},
+				},
+			},
+			Egress: []policyv1alpha1.AdminNetworkPolicyEgressRule{
+				{
+					Action: policyv1alpha1.AdminNetworkPolicyRuleActionAllow,
+					To: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{
+							Pods: &policyv1alpha1.NamespacedPodPeer{
+								Namespaces:  policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}},
+								PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "dns"}},
+							},
+						},
+					},
+					Ports: &[]policyv1alpha1.AdminNetworkPolicyPort{{NamedPort: &namedPort}},
+				},
+			},
+		},
+	}
+
+	if util.IsWindowsDP() {
+		_, err := TranslateAdminNetworkPolicy(anpObj)
+		require.ErrorIs(t, err, ErrUnsupportedNamedPort)
+		return
+	}
+
+	npmNetPol, err := TranslateAdminNetworkPolicy(anpObj)
+	require.NoError(t, err)
+	require.Equal(t, "ADMIN/cluster-control", npmNetPol.PolicyKey)
+	require.Equal(t, policies.AdminTier, npmNetPol.Tier)
+	require.Equal(t, int32(5), npmNetPol.Priority)
+	require.Empty(t, npmNetPol.Namespace)
+	require.Equal(t, []policies.SetInfo{
+		policies.NewSetInfo("team:a", ipsets.KeyValueLabelOfNamespace, included, policies.EitherMatch),
+		policies.NewSetInfo("app:web", ipsets.KeyValueLabelOfPod, included, policies.EitherMatch),
+	}, npmNetPol.PodSelectorList)
+
+	ingressPeer := []policies.SetInfo{policies.NewSetInfo("env:dev", ipsets.KeyValueLabelOfNamespace, included, policies.SrcMatch)}
+	udpACL := policies.NewACLPolicy(policies.Dropped, policies.Ingress)
+	udpACL.AddSetInfo(ingressPeer)
+	udpACL.DstPorts = policies.Ports{Port: 53}
+	udpACL.Protocol = policies.UDP
+	rangeACL := policies.NewACLPolicy(policies.Dropped, policies.Ingress)
+	rangeACL.AddSetInfo(ingressPeer)
+	rangeACL.DstPorts = policies.Ports{Port: 8000, EndPort: 9000}
+	rangeACL.Protocol = policies.TCP
+	egressACL := policies.NewACLPolicy(policies.Allowed, policies.Egress)
+	egressACL.AddSetInfo([]policies.SetInfo{
+		policies.NewSetInfo(util.KubeAllNamespacesFlag, ipsets.KeyLabelOfNamespace, included, policies.DstMatch),
+		policies.NewSetInfo("app:dns", ipsets.KeyValueLabelOfPod, included, policies.DstMatch),
+		policies.NewSetInfo(namedPort, ipsets.NamedPorts, included, policies.DstDstMatch),
+	})
+	require.Equal(t, []*policies.ACLPolicy{udpACL, rangeACL, egressACL}, npmNetPol.ACLs)
+}
+
+func TestTranslateAdminNetworkPolicyPass(t *testing.T) {
+	anpObj := &policyv1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "pass"},
+		Spec: policyv1alpha1.AdminNetworkPolicySpec{
+			Priority: 1,
+			Subject:  policyv1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+			Egress: []policyv1alpha1.AdminNetworkPolicyEgressRule{
+				{
+					Action: policyv1alpha1.AdminNetworkPolicyRuleActionPass,
+					To: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}}},
+					},
+				},
+			},
+		},
+	}
+
+	npmNetPol, err := TranslateAdminNetworkPolicy(anpObj)
+	if util.IsWindowsDP() {
+		require.ErrorIs(t, err, ErrUnsupportedPassAction)
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, npmNetPol.ACLs, 1)
+	require.Equal(t, policies.Passed, npmNetPol.ACLs[0].Target)
+}
+
+func TestTranslateAdminNetworkPolicyUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject policyv1alpha1.AdminNetworkPolicySubject
+		peer    policyv1alpha1.AdminNetworkPolicyPeer
+		wantErr error
+	}{
+		{
+			name:    "sameLabels peer",
+			subject: policyv1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+			peer:    policyv1alpha1.AdminNetworkPolicyPeer{Namespaces: &policyv1alpha1.NamespacedPeer{SameLabels: []string{"team"}}},
+			wantErr: ErrUnsupportedSameLabels,
+		},
+		{
+			name: "subject with multiple values",
+			subject: policyv1alpha1.AdminNetworkPolicySubject{
+				Namespaces: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+					},
+				},
+			},
+			peer:    policyv1alpha1.AdminNetworkPolicyPeer{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}}},
+			wantErr: ErrUnsupportedMultiValueSubject,
+		},
+		{
+			name:    "empty subject",
+			peer:    policyv1alpha1.AdminNetworkPolicyPeer{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}}},
+			wantErr: ErrInvalidAdminPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			anpObj := &policyv1alpha1.AdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "unsupported"},
+				Spec: policyv1alpha1.AdminNetworkPolicySpec{
+					Subject: tt.subject,
+					Ingress: []policyv1alpha1.AdminNetworkPolicyIngressRule{
+						{Action: policyv1alpha1.AdminNetworkPolicyRuleActionAllow, From: []policyv1alpha1.AdminNetworkPolicyPeer{tt.peer}},
+					},
+				},
+			}
+			_, err := TranslateAdminNetworkPolicy(anpObj)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTranslateBaselineAdminNetworkPolicy(t *testing.T) {
+	banpObj := &policyv1alpha1.BaselineAdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: policyv1alpha1.BaselineAdminNetworkPolicySpec{
+			Subject: policyv1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+			Ingress: []policyv1alpha1.BaselineAdminNetworkPolicyIngressRule{
+				{
+					Action: policyv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
+					From: []policyv1alpha1.AdminNetworkPolicyPeer{
+						{Namespaces: &policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}}},
+					},
+				},
+			},
+		},
+	}
+
+	npmNetPol, err := TranslateBaselineAdminNetworkPolicy(banpObj)
+	require.NoError(t, err)
+	require.Equal(t, "BASELINE/default", npmNetPol.PolicyKey)
+	require.Equal(t, policies.BaselineTier, npmNetPol.Tier)
+	require.Equal(t, []policies.SetInfo{
+		policies.NewSetInfo(util.KubeAllNamespacesFlag, ipsets.KeyLabelOfNamespace, included, policies.EitherMatch),
+	}, npmNetPol.PodSelectorList)
+
+	denyACL := policies.NewACLPolicy(policies.Dropped, policies.Ingress)
+	denyACL.AddSetInfo([]policies.SetInfo{
+		policies.NewSetInfo(util.KubeAllNamespacesFlag, ipsets.KeyLabelOfNamespace, included, policies.SrcMatch),
+	})
+	require.Equal(t, []*policies.ACLPolicy{denyACL}, npmNetPol.ACLs)
+}
@@ -32,7 +32,7 @@ var (
 	ErrInvalidMatchExpressionValues = errors.New(
 		"matchExpression label values must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character",
 	)
-	// ErrUnsupportedIPAddress is returned when an unsupported IP address, such as IPV6, is used
+	// ErrUnsupportedIPAddress is returned when an unsupported IP address, such as IPV6 in Windows, is used
 	ErrUnsupportedIPAddress = errors.New("unsupported IP address")
 	// ErrUnsupportedNonCIDR is returned when non-CIDR blocks are passed in with NPM Lite enabled. NPM Lite allows deny-all and allow-all policies
 	ErrUnsupportedNonCIDR = errors.New("Non-CIDR blocks, named ports, and ingress/egress namespace/pod selectors are not supported when NPM Lite is enabled, allowing only CIDR-based policies")
@@ -165,6 +165,12 @@ func deDuplicateExcept(exceptInIPBlock []string) []string {
 	return deDupExcepts
 }
 
+// splitAllCIDRs maps the CIDRs for all IPv4 and IPv6 addresses to their halves.
+var splitAllCIDRs = map[string][]string{
+	"0.0.0.0/0": {"0.0.0.0/1", "128.0.0.0/1"},
+	"::/0":      {"::/1", "8000::/1"},
+}
+
 // ipBlockIPSet return translatedIPSet based based on ipBlockRule.
 func ipBlockIPSet(policyName, ns string, direction policies.Direction, ipBlockSetIndex, ipBlockPeerIndex int, ipBlockRule *networkingv1.IPBlock) (*ipsets.TranslatedIPSet, error) {
 	if ipBlockRule == nil || ipBlockRule.CIDR == "" {
@@ -181,17 +187,16 @@ func ipBlockIPSet(policyName, ns string, direction policies.Direction, ipBlockSe
 
 	var members []string
 	indexOfMembers := 0
-	// Ipset doesn't allow 0.0.0.0/0 to be added.
-	// A solution is split 0.0.0.0/0 in half which convert to 0.0.0.0/1 and 128.0.0.0/1.
+	// Ipset doesn't allow 0.0.0.0/0 (or ::/0) to be added.
+	// A solution is split 0.0.0.0/0 in half which convert to 0.0.0.0/1 and 128.0.0.0/1 (or ::/1 and 8000::/1).
 	// splitCIDRSet is used to handle case where IPBlock has "0.0.0.0/0" in CIDR and "0.0.0.0/1" or "128.0.0.0/1"  in Except.
 	// splitCIDRSet has two entries ("0.0.0.0/1" and "128.0.0.0/1") as key.
 	splitCIDRLen := 2
 	splitCIDRSet := make(map[string]int, splitCIDRLen)
-	if ipBlockRule.CIDR == "0.0.0.0/0" {
+	if splitCIDRs, ok := splitAllCIDRs[ipBlockRule.CIDR]; ok {
 		// two cidrs (0.0.0.0/1 and 128.0.0.0/1) for 0.0.0.0/0 + except.
 		members = make([]string, lenOfDeDupExcepts+splitCIDRLen)
 		// in case of "0.0.0.0/0", "0.0.0.0/1" or "0.0.0.0/1 nomatch" comes eariler than "128.0.0.0/1" or "128.0.0.0/1 nomatch".
-		splitCIDRs := []string{"0.0.0.0/1", "128.0.0.0/1"}
 		for _, cidr := range splitCIDRs {
 			members[indexOfMembers] = cidr
 			splitCIDRSet[cidr] = indexOfMembers
@@ -230,7 +235,8 @@ func ipBlockRule(policyName, ns string, direction policies.Direction, matchType
 		return nil, policies.SetInfo{}, nil
 	}
 
-	if !util.IsIPV4(ipBlockRule.CIDR) {
+	// IPv6 is supported in Linux only. The dataplane ignores IPv6 members unless IPv6 is enabled.
+	if !util.IsIPV4(ipBlockRule.CIDR) && (util.IsWindowsDP() || !util.IsIPV6(ipBlockRule.CIDR)) {
 		return nil, policies.SetInfo{}, ErrUnsupportedIPAddress
 	}
 
@@ -541,11 +541,32 @@ func TestIPBlockRule(t *testing.T) {
 			setInfo:         policies.NewSetInfo("test-network-policy-in-ns-default-0-0IN", ipsets.CIDRBlocks, included, policies.SrcMatch),
 			skipWindows:     true,
 		},
+		{
+			name:        "ipv6",
+			ipBlockInfo: createIPBlockInfo("test", defaultNS, policies.Ingress, policies.SrcMatch, 0, 0),
+			ipBlockRule: &networkingv1.IPBlock{
+				CIDR:   "2002::1234:abcd:ffff:c0a8:101/64",
+				Except: []string{"2002::1234:abcd:ffff:c0a8:101/96"},
+			},
+			translatedIPSet: ipsets.NewTranslatedIPSet("test-in-ns-default-0-0IN", ipsets.CIDRBlocks, []string{"2002::1234:abcd:ffff:c0a8:101/64", "2002::1234:abcd:ffff:c0a8:101/96 nomatch"}...),
+			setInfo:         policies.NewSetInfo("test-in-ns-default-0-0IN", ipsets.CIDRBlocks, included, policies.SrcMatch),
+			skipWindows:     true,
+		},
+		{
+			name:        "cidr: ::/0",
+			ipBlockInfo: createIPBlockInfo("test", defaultNS, policies.Ingress, policies.SrcMatch, 0, 0),
+			ipBlockRule: &networkingv1.IPBlock{
+				CIDR: "::/0",
+			},
+			translatedIPSet: ipsets.NewTranslatedIPSet("test-in-ns-default-0-0IN", ipsets.CIDRBlocks, []string{"::/1", "8000::/1"}...),
+			setInfo:         policies.NewSetInfo("test-in-ns-default-0-0IN", ipsets.CIDRBlocks, included, policies.SrcMatch),
+			skipWindows:     true,
+		},
 		{
 			name:        "invalid ipv6",
 			ipBlockInfo: createIPBlockInfo("test", defaultNS, policies.Ingress, policies.SrcMatch, 0, 0),
 			ipBlockRule: &networkingv1.IPBlock{
-				CIDR: "2002::1234:abcd:ffff:c0a8:101/64",
+				CIDR: "2002::1234:abcd:ffff:c0a8:101/129",
 			},
 			translatedIPSet: nil,
 			setInfo:         policies.SetInfo{},
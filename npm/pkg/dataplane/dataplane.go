@@ -3,6 +3,7 @@ package dataplane
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +31,7 @@ const (
 var (
 	ErrInvalidApplyConfig       = errors.New("invalid apply config")
 	ErrIncorrectNumberOfNetPols = errors.New("expected to have exactly one netpol since dp.netPolInBackground == false")
+	ErrUnsupportedNftablesCfg   = errors.New("IPv6 and AdminNetworkPolicies are not supported with nftables")
 )
 
 type PolicyMode string
@@ -46,6 +48,17 @@ type Config struct {
 	MaxPendingNetPols  int
 	NetPolInterval     time.Duration
 	EnableNPMLite      bool
+	// EnableNftables is used in Linux to program IPSets and policies in an nftables table instead of with ipset and iptables
+	EnableNftables bool
+	// EnableIPv6 is used in Linux to program IPv6 members of IPSets and IPv6 policies (with ip6tables).
+	// Otherwise, IPv6 members are ignored. Not supported with nftables.
+	EnableIPv6 bool
+	// EnableAdminNetworkPolicy allows AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+	// Not supported with nftables.
+	EnableAdminNetworkPolicy bool
+	// EnableDenyFlowLogging is used in Linux to log packets dropped by NPM to an NFLOG group.
+	// The group and rate limit are set in the PolicyManagerCfg.
+	EnableDenyFlowLogging bool
 	*ipsets.IPSetManagerCfg
 	*policies.PolicyManagerCfg
 }
@@ -84,6 +97,36 @@ func NewDataPlane(nodeName string, ioShim *common.IOShim, cfg *Config, stopChann
 		klog.Infof("[DataPlane] enabling AddEmptySetToLists for Windows")
 		cfg.IPSetManagerCfg.AddEmptySetToLists = true
 	}
+	if cfg.EnableNftables && !util.IsWindowsDP() {
+		// the nftables sets only hold IPv4 addresses, and there are no nftables chains for the tiers
+		if cfg.EnableIPv6 || cfg.EnableAdminNetworkPolicy {
+			return nil, ErrUnsupportedNftablesCfg
+		}
+		klog.Infof("[DataPlane] using nftables for IPSets and policies")
+		cfg.IPSetManagerCfg.UseNftables = true
+		cfg.PolicyManagerCfg.UseNftables = true
+	}
+	if cfg.EnableIPv6 && !util.IsWindowsDP() {
+		klog.Infof("[DataPlane] enabling IPv6 for IPSets and policies")
+		cfg.IPSetManagerCfg.EnableIPv6 = true
+		cfg.PolicyManagerCfg.EnableIPv6 = true
+	} else {
+		cfg.EnableIPv6 = false
+	}
+	if cfg.EnableAdminNetworkPolicy {
+		klog.Infof("[DataPlane] enabling AdminNetworkPolicies and BaselineAdminNetworkPolicies")
+		cfg.PolicyManagerCfg.EnableAdminNetworkPolicy = true
+	}
+
+	if cfg.EnableDenyFlowLogging {
+		if util.IsWindowsDP() {
+			klog.Warningf("[DataPlane] deny flow logging is not supported in Windows")
+			cfg.EnableDenyFlowLogging = false
+		} else {
+			klog.Infof("[DataPlane] enabling deny flow logging to NFLOG group %d", cfg.DenyFlowLogGroup)
+			cfg.PolicyManagerCfg.EnableDenyFlowLogging = true
+		}
+	}
 
 	dp := &DataPlane{
 		Config:    cfg,
@@ -122,9 +165,9 @@ func NewDataPlane(nodeName string, ioShim *common.IOShim, cfg *Config, stopChann
 		return nil, err
 	}
 
-	// Prevent netpol in background unless we're in Linux and using nftables.
+	// Prevent netpol in background unless we're in Linux and using nftables (either natively or through iptables-nft).
 	// This step must be performed after bootupDataplane() because it calls util.DetectIptablesVersion(), which sets the proper value for util.Iptables
-	dp.netPolInBackground = cfg.NetPolInBackground && !util.IsWindowsDP() && (strings.Contains(util.Iptables, "nft") || dp.debug)
+	dp.netPolInBackground = cfg.NetPolInBackground && !util.IsWindowsDP() && (cfg.EnableNftables || strings.Contains(util.Iptables, "nft") || dp.debug)
 	if dp.netPolInBackground {
 		msg := fmt.Sprintf("[DataPlane] dataplane configured to add netpols in background every %v or every %d calls to AddPolicy()", dp.NetPolInterval, dp.MaxPendingNetPols)
 		metrics.SendLog(util.DaemonDataplaneID, msg, true)
@@ -251,6 +294,10 @@ func (dp *DataPlane) DeleteIPSet(setMetadata *ipsets.IPSetMetadata, forceDelete
 // AddToSets takes in a list of IPSet names along with IP member
 // and then updates it local cache
 func (dp *DataPlane) AddToSets(setNames []*ipsets.IPSetMetadata, podMetadata *PodMetadata) error {
+	if dp.ignoreMember(podMetadata.PodIP) {
+		return nil
+	}
+
 	err := dp.ipsetMgr.AddToSets(setNames, podMetadata.PodIP, podMetadata.PodKey)
 	if err != nil {
 		return fmt.Errorf("[DataPlane] error while adding to set: %w", err)
@@ -274,6 +321,10 @@ func (dp *DataPlane) AddToSets(setNames []*ipsets.IPSetMetadata, podMetadata *Po
 // RemoveFromSets takes in list of setnames from which a given IP member should be
 // removed and will update the local cache
 func (dp *DataPlane) RemoveFromSets(setNames []*ipsets.IPSetMetadata, podMetadata *PodMetadata) error {
+	if dp.ignoreMember(podMetadata.PodIP) {
+		return nil
+	}
+
 	err := dp.ipsetMgr.RemoveFromSets(setNames, podMetadata.PodIP, podMetadata.PodKey)
 	if err != nil {
 		return fmt.Errorf("[DataPlane] error while removing from set: %w", err)
@@ -534,6 +585,11 @@ func (dp *DataPlane) addPolicies(netPols []*policies.NPMNetworkPolicy) error {
 			return fmt.Errorf("[DataPlane] error while adding Rule IPSet references: %w", err)
 		}
 
+		if dp.PolicyManagerCfg.UseNftables {
+			// the PolicyManager applies the IPSets in the same nft transaction as the policies (see bootupDataPlane)
+			continue
+		}
+
 		if inBootupPhase {
 			// This branch can only be taken in Windows.
 			// During bootup phase, the Pod controller will not be running.
@@ -705,6 +761,40 @@ func (dp *DataPlane) GetAllPolicies() []string {
 	return nil
 }
 
+// GetAllIPSetSnapshots returns a copy of every ipset in the cache for the debug API.
+func (dp *DataPlane) GetAllIPSetSnapshots() []*ipsets.IPSetSnapshot {
+	return dp.ipsetMgr.GetAllIPSetSnapshots()
+}
+
+// GetAllPolicySnapshots returns a copy of every policy in the policy manager,
+// plus the policies waiting to be added in the background, sorted by policy key.
+func (dp *DataPlane) GetAllPolicySnapshots() []*PolicySnapshot {
+	snapshots := make([]*PolicySnapshot, 0)
+	pending := make(map[string]struct{})
+	if dp.netPolInBackground {
+		dp.netPolQueue.Lock()
+		for _, policy := range dp.netPolQueue.dump() {
+			policyCopy := *policy
+			snapshots = append(snapshots, &PolicySnapshot{Policy: &policyCopy, Pending: true})
+			pending[policy.PolicyKey] = struct{}{}
+		}
+		dp.netPolQueue.Unlock()
+	}
+
+	for _, policy := range dp.policyMgr.GetAllPolicies() {
+		if _, ok := pending[policy.PolicyKey]; ok {
+			// the policy is being updated, so the applied version is about to be replaced
+			continue
+		}
+		snapshots = append(snapshots, &PolicySnapshot{Policy: policy})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Policy.PolicyKey < snapshots[j].Policy.PolicyKey
+	})
+	return snapshots
+}
+
 func (dp *DataPlane) createIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, netpolName string, referenceType ipsets.ReferenceType) error {
 	// Create IPSets first along with reference updates
 	npmErrorString := npmerrors.AddSelectorReference
@@ -727,6 +817,9 @@ func (dp *DataPlane) createIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 			// ipblock can have either cidr (CIDR in IPBlock) or "cidr + " " (space) + nomatch" (Except in IPBlock)
 			// (TODO) need to revise it for windows
 			for _, ipblock := range set.Members {
+				if dp.ignoreMember(ipblock) {
+					continue
+				}
 				err := dp.ipsetMgr.AddToSets([]*ipsets.IPSetMetadata{set.Metadata}, ipblock, "")
 				if err != nil {
 					return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("[DataPlane] failed to AddToSet in addIPSetReferences with err: %s", err.Error()))
@@ -769,6 +862,9 @@ func (dp *DataPlane) deleteIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 			// ipblock can have either cidr (CIDR in IPBlock) or "cidr + " " (space) + nomatch" (Except in IPBlock)
 			// (TODO) need to revise it for windows
 			for _, ipblock := range set.Members {
+				if dp.ignoreMember(ipblock) {
+					continue
+				}
 				err := dp.ipsetMgr.RemoveFromSets([]*ipsets.IPSetMetadata{set.Metadata}, ipblock, "")
 				if err != nil {
 					return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("[DataPlane] failed to RemoveFromSet in deleteIPSetReferences with err: %s", err.Error()))
@@ -790,6 +886,12 @@ func (dp *DataPlane) deleteIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 	return nil
 }
 
+// ignoreMember is true for IPv6 members (pod IPs or CIDRs) when IPv6 isn't enabled.
+// Dual-stack pods and policies then only get IPv4 enforcement.
+func (dp *DataPlane) ignoreMember(member string) bool {
+	return !dp.EnableIPv6 && ipsets.IsIPv6Member(member)
+}
+
 func (dp *DataPlane) setRemovePolicyFailure(failed bool) {
 	if util.IsWindowsDP() {
 		return
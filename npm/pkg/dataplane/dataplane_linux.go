@@ -1,6 +1,7 @@
 package dataplane
 
 import (
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
 	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
 )
@@ -20,6 +21,11 @@ func (dp *DataPlane) updatePod(pod *updateNPMPod) error {
 }
 
 func (dp *DataPlane) bootupDataPlane() error {
+	if dp.PolicyManagerCfg.UseNftables {
+		// apply the policies in the same nft transaction as the sets they reference
+		dp.policyMgr.SetNftPolicyExecutor(nftables.ExecutorFunc(dp.ipsetMgr.ApplyIPSetsWithNftTransaction))
+	}
+
 	// It is important to keep order to clean-up ACLs before ipsets. Otherwise we won't be able to delete ipsets referenced by ACLs
 	if err := dp.policyMgr.Bootup(nil); err != nil {
 		return npmerrors.ErrorWrapper(npmerrors.BootupDataplane, false, "failed to reset policy dataplane", err)
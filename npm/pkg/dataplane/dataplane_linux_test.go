@@ -190,3 +190,79 @@ func TestNetPolInBackgroundFailureToAddFirstTime(t *testing.T) {
 
 	require.Equal(t, 1, dp.netPolQueue.len(), "expected one netpol to still be in the queue after it fails when adding one at a time")
 }
+
+func TestNetPolInBackgroundPolicySnapshots(t *testing.T) {
+	metrics.ReinitializeAll()
+
+	calls := append(getBootupTestCalls(), getAddPolicyTestCallsForDP(&testPolicyobj)...)
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	stopCh := make(chan struct{}, 1)
+	dp, err := NewDataPlane("testnode", ioshim, netpolInBackgroundCfg, stopCh)
+	require.NoError(t, err)
+	defer func() {
+		stopCh <- struct{}{}
+		time.Sleep(100 * time.Millisecond)
+	}()
+
+	require.NoError(t, dp.AddPolicy(&testPolicyobj))
+
+	snapshots := dp.GetAllPolicySnapshots()
+	require.Len(t, snapshots, 1)
+	require.True(t, snapshots[0].Pending)
+	require.Equal(t, testPolicyobj.PolicyKey, snapshots[0].Policy.PolicyKey)
+
+	dp.RunPeriodicTasks()
+	time.Sleep(100 * time.Millisecond)
+
+	snapshots = dp.GetAllPolicySnapshots()
+	require.Len(t, snapshots, 1)
+	require.False(t, snapshots[0].Pending)
+	require.Equal(t, testPolicyobj.PolicyKey, snapshots[0].Policy.PolicyKey)
+}
+
+func nftablesCfg() *Config {
+	return &Config{
+		EnableNftables: true,
+		IPSetManagerCfg: &ipsets.IPSetManagerCfg{
+			IPSetMode:   ipsets.ApplyAllIPSets,
+			NetworkName: "azure",
+		},
+		PolicyManagerCfg: &policies.PolicyManagerCfg{
+			NodeIP:               "6.7.8.9",
+			PolicyMode:           policies.IPSetPolicyMode,
+			PlaceAzureChainFirst: util.PlaceAzureChainFirst,
+		},
+	}
+}
+
+func TestNftablesUnsupportedConfig(t *testing.T) {
+	ipv6Cfg := nftablesCfg()
+	ipv6Cfg.EnableIPv6 = true
+	anpCfg := nftablesCfg()
+	anpCfg.EnableAdminNetworkPolicy = true
+
+	for _, cfg := range []*Config{ipv6Cfg, anpCfg} {
+		ioshim := common.NewMockIOShim(nil)
+		_, err := NewDataPlane("testnode", ioshim, cfg, make(chan struct{}))
+		require.ErrorIs(t, err, ErrUnsupportedNftablesCfg)
+	}
+}
+
+func TestNftablesAddPolicyInOneTransaction(t *testing.T) {
+	metrics.ReinitializeAll()
+
+	nftCall := testutils.TestCmd{Cmd: []string{"nft", "-f", "-"}}
+	calls := append(policies.GetNftablesBootupTestCalls(), nftCall)
+	calls = append(calls, ipsets.GetResetTestCalls()...)
+	// the IPSets and the policy's rules are applied in one transaction
+	calls = append(calls, nftCall)
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+
+	dp, err := NewDataPlane("testnode", ioshim, nftablesCfg(), make(chan struct{}))
+	require.NoError(t, err)
+	require.NoError(t, dp.AddPolicy(&testPolicyobj))
+	_, ok := dp.policyMgr.GetPolicy(testPolicyobj.PolicyKey)
+	require.True(t, ok)
+}
@@ -175,9 +175,13 @@ func TestAddToSet(t *testing.T) {
 	require.NoError(t, err)
 
 	v6PodMetadata := NewPodMetadata("testns/a", "2001:db8:0:0:0:0:2:1", nodeName)
-	// Test IPV6 addess it should error out
+	// IPv6 isn't enabled, so the IPv6 address should be ignored
 	err = dp.AddToSets(setsTocreate, v6PodMetadata)
-	require.Error(t, err)
+	require.NoError(t, err)
+	for _, v := range setsTocreate {
+		set := dp.ipsetMgr.GetIPSet(v.GetPrefixName())
+		require.NotContains(t, set.IPPodKey, v6PodMetadata.PodIP)
+	}
 
 	for _, v := range setsTocreate {
 		dp.DeleteIPSet(v, util.SoftDelete)
@@ -192,8 +196,9 @@ func TestAddToSet(t *testing.T) {
 	err = dp.RemoveFromSets(setsTocreate, podMetadata)
 	require.NoError(t, err)
 
+	// ignored as well
 	err = dp.RemoveFromSets(setsTocreate, v6PodMetadata)
-	require.Error(t, err)
+	require.NoError(t, err)
 
 	for _, v := range setsTocreate {
 		dp.DeleteIPSet(v, util.SoftDelete)
@@ -13,6 +13,11 @@ const (
 	Base int = 10
 	// Bitsize indicate the bitsize for ParseInt
 	Bitsize int = 32
+
+	// AdminTier is the Tuple tier for rules from AdminNetworkPolicies
+	AdminTier string = "ADMIN"
+	// BaselineTier is the Tuple tier for rules from BaselineAdminNetworkPolicies
+	BaselineTier string = "BASELINE"
 )
 
 // MembersBytes is the string "Members" in bytes array
@@ -61,6 +61,29 @@ func (c *Converter) NpmCacheFromFile(npmCacheJSONFile string) error {
 	return nil
 }
 
+// NpmCacheV2FromBytes decodes the v2 NPM cache from the debug http endpoint or a cache file.
+func NpmCacheV2FromBytes(byteArray []byte) (*npmcommon.Cache, error) {
+	m := map[models.CacheKey]json.RawMessage{}
+	cache := &npmcommon.Cache{}
+	if err := json.Unmarshal(byteArray, &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal into v2 cache map")
+	}
+
+	if err := json.Unmarshal(m[models.NsMap], &cache.NsMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal nsmap into v2 cache")
+	}
+
+	if err := json.Unmarshal(m[models.PodMap], &cache.PodMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal podmap into v2 cache")
+	}
+
+	if err := json.Unmarshal(m[models.SetMap], &cache.SetMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal setmap into v2 cache")
+	}
+
+	return cache, nil
+}
+
 // NpmCache initialize NPM cache from node.
 func (c *Converter) NpmCache() error {
 	req, err := http.NewRequestWithContext(
@@ -98,21 +121,9 @@ func (c *Converter) NpmCache() error {
 func (c *Converter) getCacheFromBytes(byteArray []byte) error {
 	m := map[models.CacheKey]json.RawMessage{}
 	if c.EnableV2NPM {
-		cache := &npmcommon.Cache{}
-		if err := json.Unmarshal(byteArray, &m); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal into v2 cache map")
-		}
-
-		if err := json.Unmarshal(m[models.NsMap], &cache.NsMap); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal nsmap into v2 cache")
-		}
-
-		if err := json.Unmarshal(m[models.PodMap], &cache.PodMap); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal podmap into v2 cache")
-		}
-
-		if err := json.Unmarshal(m[models.SetMap], &cache.SetMap); err != nil {
-			return errors.Wrapf(err, "failed to unmarshal setmap into v2 cache")
+		cache, err := NpmCacheV2FromBytes(byteArray)
+		if err != nil {
+			return err
 		}
 
 		c.NPMCache = cache
@@ -237,12 +248,24 @@ func (c *Converter) GetProtobufRulesFromIptableFile(
 
 // GetProtobufRulesFromIptable returns a list of protobuf rules from node.
 func (c *Converter) GetProtobufRulesFromIptable(tableName string) (map[*pb.RuleResponse]struct{}, error) {
+	return c.getProtobufRulesFromNode(tableName, parse.Iptables)
+}
+
+// GetProtobufRulesFromIp6table returns a list of protobuf rules from the node's ip6tables.
+func (c *Converter) GetProtobufRulesFromIp6table(tableName string) (map[*pb.RuleResponse]struct{}, error) {
+	return c.getProtobufRulesFromNode(tableName, parse.Ip6tables)
+}
+
+func (c *Converter) getProtobufRulesFromNode(
+	tableName string,
+	parseTable func(tableName string) (*NPMIPtable.Table, error),
+) (map[*pb.RuleResponse]struct{}, error) {
 	err := c.InitConverter()
 	if err != nil {
 		return nil, fmt.Errorf("error occurred during getting protobuf rules from iptables : %w", err)
 	}
 
-	ipTable, err := parse.Iptables(tableName)
+	ipTable, err := parseTable(tableName)
 	if err != nil {
 		return nil, fmt.Errorf("error occurred during parsing iptables : %w", err)
 	}
@@ -329,6 +352,12 @@ func (c *Converter) getRulesFromChain(iptableChain *NPMIPtable.Chain) ([]*pb.Rul
 		rule.Protocol = v.Protocol
 
 		if c.EnableV2NPM {
+			// jumps to the tier chains and Pass rules in the admin tier don't decide a verdict.
+			// Rules in the tier chains are evaluated on their own since they already match on the policy's subject.
+			if isTierChain(v.Target.Name) || v.Target.Name == util.IptablesReturn {
+				continue
+			}
+
 			// chain name has to end in hash np for it to determine if allow or drop
 			// ignore jumps from parent AZURE-NPM
 			switch v.Target.Name {
@@ -376,6 +405,10 @@ func (c *Converter) getRulesFromChain(iptableChain *NPMIPtable.Chain) ([]*pb.Rul
 	return rules, nil
 }
 
+func isTierChain(chainName string) bool {
+	return tierOfChain(chainName) != ""
+}
+
 func (c *Converter) getRuleDirection(iptableChainName string) pb.Direction {
 	if strings.Contains(iptableChainName, "EGRESS") {
 		return pb.Direction_EGRESS
@@ -508,7 +541,8 @@ func (c *Converter) populateSetInfo(setInfo *pb.RuleResponse_SetInfo, values []s
 	setInfo.HashedSetName = ipsetHashedName
 
 	if c.EnableV2NPM {
-		setInfo.Name = c.SetMap[ipsetHashedName]
+		// rules in ip6tables refer to the IPv6 twin of the set
+		setInfo.Name = c.SetMap[strings.TrimSuffix(ipsetHashedName, ipsets.IPv6SetSuffix)]
 		settype, _ := c.getSetTypeV2(setInfo.Name)
 		if settype == pb.SetType_UNKNOWN {
 			return errors.Wrapf(ErrUnknownSetType, "unknown set type for set: %s", setInfo.Name)
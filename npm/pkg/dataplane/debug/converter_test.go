@@ -324,3 +324,52 @@ func TestGetModulesFromRule(t *testing.T) {
 
 	require.Exactly(t, expectedRuleResponse, actualRuleResponse)
 }
+
+// rules in ip6tables match the IPv6 twins of the ipsets
+func TestGetModulesFromRuleWithIPv6Set(t *testing.T) {
+	m0 := &NPMIPtable.Module{
+		Verb:           "set",
+		OptionValueMap: map[string][]string{"match-set": {"azure-npm-2837910840-6", "dst"}},
+	} // ns-y - NAMESPACE
+
+	expectedDstList := []*pb.RuleResponse_SetInfo{
+		{
+			Type:          pb.SetType_NAMESPACE,
+			Name:          "ns-y",
+			HashedSetName: "azure-npm-2837910840-6",
+			Included:      true,
+		},
+	}
+
+	actualRuleResponse := &pb.RuleResponse{
+		Chain:     "TEST",
+		Allowed:   true,
+		Direction: pb.Direction_INGRESS,
+	}
+
+	c := &Converter{
+		EnableV2NPM: true,
+	}
+	require.NoError(t, c.initConverterFile(npmCacheFileV2))
+	require.NoError(t, c.getModulesFromRule([]*NPMIPtable.Module{m0}, actualRuleResponse))
+	require.Exactly(t, expectedDstList, actualRuleResponse.DstList)
+}
+
+func TestGetPodByIPv6(t *testing.T) {
+	pod := &common.NpmPod{
+		Name:      "a",
+		Namespace: "x",
+		PodIP:     "10.224.0.1",
+		PodIPs:    []string{"10.224.0.1", "fd00::1"},
+	}
+	c := &common.Cache{PodMap: map[string]*common.NpmPod{"x/a": pod}}
+
+	input := &common.Input{Content: "fd00::1", Type: common.GetInputType("fd00::1")}
+	actualPod, err := c.GetPod(input)
+	require.NoError(t, err)
+	require.Equal(t, "a", actualPod.Name)
+	require.Equal(t, "fd00::1", actualPod.PodIP)
+	// the cached pod isn't modified
+	require.Equal(t, "10.224.0.1", pod.PodIP)
+	require.True(t, isIPv6Input(input))
+}
@@ -0,0 +1,115 @@
+package debug
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/flowlog"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"k8s.io/klog"
+)
+
+// DeniedFlowResolver resolves denied flows against copies of the NPM cache and the dataplane's policies.
+// Copying is expensive, so the copies are refreshed at most once per refreshInterval.
+// It is not safe for concurrent use.
+type DeniedFlowResolver struct {
+	getCache        func() (*common.Cache, error)
+	getPolicies     func() []*dataplane.PolicySnapshot
+	refreshInterval time.Duration
+
+	npmCache  *common.Cache
+	snapshots []*dataplane.PolicySnapshot
+	refreshed time.Time
+}
+
+func NewDeniedFlowResolver(getCache func() (*common.Cache, error), getPolicies func() []*dataplane.PolicySnapshot,
+	refreshInterval time.Duration,
+) *DeniedFlowResolver {
+	return &DeniedFlowResolver{
+		getCache:        getCache,
+		getPolicies:     getPolicies,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// ResolveDeniedFlow implements flowlog.Resolver.
+func (r *DeniedFlowResolver) ResolveDeniedFlow(flow *flowlog.Flow, policyHash string) {
+	if r.npmCache == nil || time.Since(r.refreshed) >= r.refreshInterval {
+		npmCache, err := r.getCache()
+		if err != nil {
+			klog.Warningf("[DeniedFlowResolver] failed to copy NPM cache. err: %v", err)
+			if r.npmCache == nil {
+				return
+			}
+		} else {
+			r.npmCache = npmCache
+			r.snapshots = r.getPolicies()
+			r.refreshed = time.Now()
+		}
+	}
+	ResolveDeniedFlow(r.npmCache, r.snapshots, flow, policyHash)
+}
+
+// ResolveDeniedFlow sets the pods and policies of a flow denied by the dataplane.
+// A packet dropped by a policy in the AdminTier or BaselineTier has the hash of the policy's key.
+// Otherwise, the packet was dropped because no NetworkPolicy allowed it,
+// so it was denied by the NetworkPolicies selecting the destination pod for ingress, or the source pod for egress.
+func ResolveDeniedFlow(npmCache *common.Cache, snapshots []*dataplane.PolicySnapshot, flow *flowlog.Flow, policyHash string) {
+	e := newPolicyEvaluator(npmCache, nil)
+	var src, dst *common.NpmPod
+	flow.SrcPod, src = e.podWithIP(flow.SrcIP)
+	flow.DstPod, dst = e.podWithIP(flow.DstIP)
+
+	flow.Policies = make([]string, 0)
+	if policyHash != "" {
+		for _, snapshot := range snapshots {
+			if snapshot.Policy.IsTiered() && util.Hash(snapshot.Policy.PolicyKey) == policyHash {
+				flow.Policies = append(flow.Policies, snapshot.Policy.PolicyKey)
+				return
+			}
+		}
+		return
+	}
+
+	direction := policies.Ingress
+	subject := dst
+	if flow.Direction == flowlog.Egress {
+		direction = policies.Egress
+		subject = src
+	}
+	if subject == nil {
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		npmNetPol := snapshot.Policy
+		// pending policies aren't in the dataplane yet
+		if snapshot.Pending || npmNetPol.IsTiered() || !e.selects(npmNetPol, subject) {
+			continue
+		}
+		hasIngress, hasEgress := aclDirections(npmNetPol)
+		if (direction == policies.Ingress && hasIngress) || (direction == policies.Egress && hasEgress) {
+			flow.Policies = append(flow.Policies, npmNetPol.PolicyKey)
+		}
+	}
+	sort.Strings(flow.Policies)
+}
+
+// podWithIP returns the key of the pod with the IP, or an empty key if there is none.
+func (e *policyEvaluator) podWithIP(ip string) (string, *common.NpmPod) {
+	for _, podKey := range e.podKeys {
+		pod := e.cache.PodMap[podKey]
+		if pod.PodIP == ip {
+			return podKey, pod
+		}
+		for _, podIP := range pod.PodIPs {
+			if podIP == ip {
+				return podKey, pod
+			}
+		}
+	}
+	return "", nil
+}
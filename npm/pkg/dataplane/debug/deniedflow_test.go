@@ -0,0 +1,107 @@
+package debug
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/flowlog"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDeniedFlow(t *testing.T) {
+	snapshots := testPolicySnapshots(t)
+	applied := []*dataplane.PolicySnapshot{snapshots[0], {Policy: snapshots[1].Policy}}
+
+	tests := []struct {
+		name       string
+		snapshots  []*dataplane.PolicySnapshot
+		flow       *flowlog.Flow
+		policyHash string
+		expected   *flowlog.Flow
+	}{
+		{
+			name:      "ingress denied by policy selecting dst",
+			snapshots: applied,
+			flow:      &flowlog.Flow{Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1"},
+			expected: &flowlog.Flow{
+				Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1",
+				SrcPod: "y/c", DstPod: "x/a", Policies: []string{"x/allow-b"},
+			},
+		},
+		{
+			name:      "pending policies didn't deny the flow",
+			snapshots: snapshots,
+			flow:      &flowlog.Flow{Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1"},
+			expected: &flowlog.Flow{
+				Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1",
+				SrcPod: "y/c", DstPod: "x/a", Policies: []string{},
+			},
+		},
+		{
+			name:      "egress isn't denied by ingress policy",
+			snapshots: applied,
+			flow:      &flowlog.Flow{Direction: flowlog.Egress, SrcIP: "10.0.0.1", DstIP: "8.8.8.8"},
+			expected: &flowlog.Flow{
+				Direction: flowlog.Egress, SrcIP: "10.0.0.1", DstIP: "8.8.8.8",
+				SrcPod: "x/a", Policies: []string{},
+			},
+		},
+		{
+			name:       "denied by tiered policy",
+			snapshots:  applied,
+			flow:       &flowlog.Flow{Direction: flowlog.Egress, SrcIP: "10.0.1.3", DstIP: "10.0.0.2"},
+			policyHash: util.Hash("BASELINE/default"),
+			expected: &flowlog.Flow{
+				Direction: flowlog.Egress, SrcIP: "10.0.1.3", DstIP: "10.0.0.2",
+				SrcPod: "y/c", DstPod: "x/b", Policies: []string{"BASELINE/default"},
+			},
+		},
+		{
+			name:       "unknown policy hash",
+			snapshots:  applied,
+			flow:       &flowlog.Flow{Direction: flowlog.Egress, SrcIP: "10.0.1.3", DstIP: "10.0.0.2"},
+			policyHash: "123",
+			expected: &flowlog.Flow{
+				Direction: flowlog.Egress, SrcIP: "10.0.1.3", DstIP: "10.0.0.2",
+				SrcPod: "y/c", DstPod: "x/b", Policies: []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ResolveDeniedFlow(whatIfTestCache(), tt.snapshots, tt.flow, tt.policyHash)
+			require.Equal(t, tt.expected, tt.flow)
+		})
+	}
+}
+
+func TestDeniedFlowResolverRefresh(t *testing.T) {
+	copies := 0
+	var copyErr error
+	getCache := func() (*common.Cache, error) {
+		copies++
+		return whatIfTestCache(), copyErr
+	}
+	getPolicies := func() []*dataplane.PolicySnapshot { return nil }
+
+	r := NewDeniedFlowResolver(getCache, getPolicies, time.Hour)
+	flow := &flowlog.Flow{Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1"}
+	r.ResolveDeniedFlow(flow, "")
+	r.ResolveDeniedFlow(flow, "")
+	require.Equal(t, 1, copies)
+	require.Equal(t, "x/a", flow.DstPod)
+
+	// keep the previous copies if the cache can't be copied
+	r.refreshInterval = 0
+	copyErr = errors.New("failed to copy")
+	flow = &flowlog.Flow{Direction: flowlog.Ingress, SrcIP: "10.0.1.3", DstIP: "10.0.0.1"}
+	r.ResolveDeniedFlow(flow, "")
+	require.Equal(t, 2, copies)
+	require.Equal(t, "x/a", flow.DstPod)
+}
@@ -0,0 +1,284 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+)
+
+var (
+	ErrPolicyNotFound = errors.New("policy not found")
+	ErrPodNotFound    = errors.New("pod not found")
+)
+
+var matchTypeNames = map[policies.MatchType]string{
+	policies.SrcMatch:    "src",
+	policies.DstMatch:    "dst",
+	policies.DstDstMatch: "dst,dst",
+	policies.EitherMatch: "either",
+}
+
+// DescribeIPSets returns the ipsets which match the request.
+func DescribeIPSets(snapshots []*ipsets.IPSetSnapshot, req *api.DescribeIPSetRequest) *api.DescribeIPSetResponse {
+	resp := &api.DescribeIPSetResponse{IPSets: make([]api.IPSet, 0)}
+	for _, set := range snapshots {
+		if req.Name != "" && req.Name != set.Name && req.Name != set.UnprefixedName && req.Name != set.HashedName {
+			continue
+		}
+		if req.Type != "" && !strings.EqualFold(req.Type, set.Type.String()) {
+			continue
+		}
+		if req.Member != "" && !hasSnapshotMember(set, req.Member) {
+			continue
+		}
+
+		resp.IPSets = append(resp.IPSets, api.IPSet{
+			Name:               set.Name,
+			UnprefixedName:     set.UnprefixedName,
+			HashedName:         set.HashedName,
+			Type:               set.Type.String(),
+			Kind:               string(set.Kind),
+			Members:            set.Members,
+			SelectorReferences: set.SelectorReferences,
+			NetPolReferences:   set.NetPolReferences,
+			ReferCount:         set.ReferCount,
+		})
+	}
+	return resp
+}
+
+// hasSnapshotMember checks the IP of hash set members (ignoring any named port) and both names of list set members.
+func hasSnapshotMember(set *ipsets.IPSetSnapshot, member string) bool {
+	for key, value := range set.Members {
+		if set.Kind == ipsets.ListSet {
+			if key == member || value == member {
+				return true
+			}
+			continue
+		}
+		if ip, _, _ := strings.Cut(key, ","); ip == member || key == member {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribePolicies returns the policies which match the request.
+func DescribePolicies(snapshots []*dataplane.PolicySnapshot, req *api.DescribePolicyRequest) *api.DescribePolicyResponse {
+	resp := &api.DescribePolicyResponse{Policies: make([]api.Policy, 0)}
+	for _, snapshot := range snapshots {
+		status := policyStatus(snapshot)
+		if req.Status != "" && req.Status != status {
+			continue
+		}
+		npmNetPol := snapshot.Policy
+		if req.Namespace != "" && req.Namespace != policyNamespace(npmNetPol) {
+			continue
+		}
+
+		policy := api.Policy{
+			PolicyKey:   npmNetPol.PolicyKey,
+			Tier:        string(npmNetPol.Tier),
+			Priority:    npmNetPol.Priority,
+			Status:      status,
+			PodSelector: apiSetInfos(npmNetPol.PodSelectorList),
+			ACLs:        make([]api.ACL, 0, len(npmNetPol.ACLs)),
+			Endpoints:   npmNetPol.PodEndpoints,
+		}
+		for _, acl := range npmNetPol.ACLs {
+			protocol := string(acl.Protocol)
+			if acl.Protocol == policies.UnspecifiedProtocol {
+				protocol = ""
+			}
+			policy.ACLs = append(policy.ACLs, api.ACL{
+				Target:    string(acl.Target),
+				Direction: string(acl.Direction),
+				Protocol:  protocol,
+				Port:      acl.DstPorts.Port,
+				EndPort:   acl.DstPorts.EndPort,
+				SrcList:   apiSetInfos(acl.SrcList),
+				DstList:   apiSetInfos(acl.DstList),
+			})
+		}
+		resp.Policies = append(resp.Policies, policy)
+	}
+	return resp
+}
+
+func policyStatus(snapshot *dataplane.PolicySnapshot) api.PolicyStatus {
+	if snapshot.Pending {
+		return api.PolicyPending
+	}
+	return api.PolicyApplied
+}
+
+// policyNamespace returns the namespace of a NetworkPolicy, or the tier of a cluster-scoped policy.
+func policyNamespace(npmNetPol *policies.NPMNetworkPolicy) string {
+	if npmNetPol.IsTiered() {
+		return string(npmNetPol.Tier)
+	}
+	return npmNetPol.Namespace
+}
+
+func apiSetInfos(setInfos []policies.SetInfo) []api.SetInfo {
+	if len(setInfos) == 0 {
+		return nil
+	}
+	result := make([]api.SetInfo, 0, len(setInfos))
+	for _, setInfo := range setInfos {
+		result = append(result, api.SetInfo{
+			Name:      setInfo.IPSet.GetPrefixName(),
+			Type:      setInfo.IPSet.Type.String(),
+			Included:  setInfo.Included,
+			MatchType: matchTypeNames[setInfo.MatchType],
+		})
+	}
+	return result
+}
+
+// PodsSelectedByPolicy returns the pods in npmCache which are selected by the policy's pod selector.
+func PodsSelectedByPolicy(npmCache *common.Cache, snapshots []*dataplane.PolicySnapshot, policyKey string) (*api.PolicyPodsResponse, error) {
+	var npmNetPol *policies.NPMNetworkPolicy
+	for _, snapshot := range snapshots {
+		if snapshot.Policy.PolicyKey == policyKey {
+			npmNetPol = snapshot.Policy
+			break
+		}
+	}
+	if npmNetPol == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyNotFound, policyKey)
+	}
+
+	e := newPolicyEvaluator(npmCache, nil)
+	resp := &api.PolicyPodsResponse{
+		PolicyKey: policyKey,
+		Pods:      make([]string, 0),
+	}
+	for _, podKey := range e.podKeys {
+		if e.selects(npmNetPol, npmCache.PodMap[podKey]) {
+			resp.Pods = append(resp.Pods, podKey)
+		}
+	}
+	return resp, nil
+}
+
+// PoliciesSelectingPod returns the policies whose pod selector selects the pod with the <namespace>/<name> key.
+func PoliciesSelectingPod(npmCache *common.Cache, snapshots []*dataplane.PolicySnapshot, podKey string) (*api.PodPoliciesResponse, error) {
+	pod, ok := npmCache.PodMap[podKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPodNotFound, podKey)
+	}
+
+	e := newPolicyEvaluator(npmCache, nil)
+	resp := &api.PodPoliciesResponse{
+		Pod:      podKey,
+		Policies: make([]api.SelectedPolicy, 0),
+	}
+	for _, snapshot := range snapshots {
+		if !e.selects(snapshot.Policy, pod) {
+			continue
+		}
+		hasIngress, hasEgress := aclDirections(snapshot.Policy)
+		resp.Policies = append(resp.Policies, api.SelectedPolicy{
+			PolicyKey: snapshot.Policy.PolicyKey,
+			Ingress:   hasIngress,
+			Egress:    hasEgress,
+		})
+	}
+	return resp, nil
+}
+
+// PrettyPrintIPSets prints the ipsets from DescribeIPSets.
+func PrettyPrintIPSets(resp *api.DescribeIPSetResponse) {
+	for _, set := range resp.IPSets {
+		fmt.Printf("Name: %s HashedName: %s Type: %s Kind: %s ReferCount: %d\n", set.Name, set.HashedName, set.Type, set.Kind, set.ReferCount)
+		fmt.Printf("\tSelectorReferences: %v\n", set.SelectorReferences)
+		fmt.Printf("\tNetPolReferences: %v\n", set.NetPolReferences)
+		fmt.Printf("\tMembers:\n")
+		for _, member := range sortedMemberKeys(set.Members) {
+			fmt.Printf("\t\t%s (%s)\n", member, set.Members[member])
+		}
+	}
+}
+
+func sortedMemberKeys(members map[string]string) []string {
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrettyPrintPolicies prints the policies from DescribePolicies.
+func PrettyPrintPolicies(resp *api.DescribePolicyResponse) {
+	for i := range resp.Policies {
+		policy := &resp.Policies[i]
+		fmt.Printf("PolicyKey: %s Status: %s", policy.PolicyKey, policy.Status)
+		if policy.Tier != "" {
+			fmt.Printf(" Tier: %s Priority: %d", policy.Tier, policy.Priority)
+		}
+		fmt.Printf("\n\tPodSelector: %s\n", setInfosString(policy.PodSelector))
+		fmt.Printf("\tACLs:\n")
+		for _, acl := range policy.ACLs {
+			fmt.Printf("\t\tTarget: %s Direction: %s", acl.Target, acl.Direction)
+			if acl.Protocol != "" {
+				fmt.Printf(" Protocol: %s", acl.Protocol)
+			}
+			if acl.Port != 0 {
+				fmt.Printf(" Ports: %d", acl.Port)
+				if acl.EndPort != 0 {
+					fmt.Printf("-%d", acl.EndPort)
+				}
+			}
+			fmt.Printf(" Src: %s Dst: %s\n", setInfosString(acl.SrcList), setInfosString(acl.DstList))
+		}
+		if len(policy.Endpoints) > 0 {
+			fmt.Printf("\tEndpoints: %v\n", policy.Endpoints)
+		}
+	}
+}
+
+func setInfosString(setInfos []api.SetInfo) string {
+	if len(setInfos) == 0 {
+		return "[]"
+	}
+	names := make([]string, 0, len(setInfos))
+	for _, setInfo := range setInfos {
+		name := fmt.Sprintf("%s(%s)", setInfo.Name, setInfo.MatchType)
+		if !setInfo.Included {
+			name = "!" + name
+		}
+		names = append(names, name)
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// PrettyPrintPolicyPods prints the pods from PodsSelectedByPolicy.
+func PrettyPrintPolicyPods(resp *api.PolicyPodsResponse) {
+	fmt.Printf("Pods selected by %s:\n", resp.PolicyKey)
+	if len(resp.Pods) == 0 {
+		fmt.Printf("\tNone\n")
+	}
+	for _, pod := range resp.Pods {
+		fmt.Printf("\t%s\n", pod)
+	}
+}
+
+// PrettyPrintPodPolicies prints the policies from PoliciesSelectingPod.
+func PrettyPrintPodPolicies(resp *api.PodPoliciesResponse) {
+	fmt.Printf("Policies selecting %s:\n", resp.Pod)
+	if len(resp.Policies) == 0 {
+		fmt.Printf("\tNone\n")
+	}
+	for _, policy := range resp.Policies {
+		fmt.Printf("\t%s Ingress: %v Egress: %v\n", policy.PolicyKey, policy.Ingress, policy.Egress)
+	}
+}
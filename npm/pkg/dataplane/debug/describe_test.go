@@ -0,0 +1,212 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/translation"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/stretchr/testify/require"
+)
+
+func testIPSetSnapshots() []*ipsets.IPSetSnapshot {
+	podSet := ipsets.NewIPSetMetadata("app:a", ipsets.KeyValueLabelOfPod)
+	nsSet := ipsets.NewIPSetMetadata("x", ipsets.Namespace)
+	nsList := ipsets.NewIPSetMetadata("team:x", ipsets.KeyValueLabelOfNamespace)
+	return []*ipsets.IPSetSnapshot{
+		{
+			Name:               podSet.GetPrefixName(),
+			UnprefixedName:     podSet.Name,
+			HashedName:         podSet.GetHashedName(),
+			Type:               podSet.Type,
+			Kind:               ipsets.HashSet,
+			Members:            map[string]string{"10.0.0.1": "x/a"},
+			SelectorReferences: []string{"x/allow-b"},
+			NetPolReferences:   []string{},
+		},
+		{
+			Name:               nsSet.GetPrefixName(),
+			UnprefixedName:     nsSet.Name,
+			HashedName:         nsSet.GetHashedName(),
+			Type:               nsSet.Type,
+			Kind:               ipsets.HashSet,
+			Members:            map[string]string{"10.0.0.1": "x/a", "10.0.0.2": "x/b"},
+			SelectorReferences: []string{"x/allow-b"},
+			NetPolReferences:   []string{},
+			ReferCount:         1,
+		},
+		{
+			Name:               nsList.GetPrefixName(),
+			UnprefixedName:     nsList.Name,
+			HashedName:         nsList.GetHashedName(),
+			Type:               nsList.Type,
+			Kind:               ipsets.ListSet,
+			Members:            map[string]string{nsSet.GetHashedName(): nsSet.GetPrefixName()},
+			SelectorReferences: []string{},
+			NetPolReferences:   []string{},
+		},
+	}
+}
+
+func TestDescribeIPSets(t *testing.T) {
+	snapshots := testIPSetSnapshots()
+	tests := []struct {
+		name     string
+		req      *api.DescribeIPSetRequest
+		expected []string
+	}{
+		{
+			name:     "no filter",
+			req:      &api.DescribeIPSetRequest{},
+			expected: []string{snapshots[0].Name, snapshots[1].Name, snapshots[2].Name},
+		},
+		{
+			name:     "unprefixed name",
+			req:      &api.DescribeIPSetRequest{Name: "x"},
+			expected: []string{snapshots[1].Name},
+		},
+		{
+			name:     "hashed name",
+			req:      &api.DescribeIPSetRequest{Name: snapshots[2].HashedName},
+			expected: []string{snapshots[2].Name},
+		},
+		{
+			name:     "type",
+			req:      &api.DescribeIPSetRequest{Type: "namespace"},
+			expected: []string{snapshots[1].Name},
+		},
+		{
+			name:     "member IP",
+			req:      &api.DescribeIPSetRequest{Member: "10.0.0.1"},
+			expected: []string{snapshots[0].Name, snapshots[1].Name},
+		},
+		{
+			name:     "member set",
+			req:      &api.DescribeIPSetRequest{Member: snapshots[1].Name},
+			expected: []string{snapshots[2].Name},
+		},
+		{
+			name:     "no match",
+			req:      &api.DescribeIPSetRequest{Name: "x", Member: "10.0.0.3"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resp := DescribeIPSets(snapshots, tt.req)
+			actual := make([]string, 0, len(resp.IPSets))
+			for _, set := range resp.IPSets {
+				actual = append(actual, set.Name)
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+
+	resp := DescribeIPSets(snapshots, &api.DescribeIPSetRequest{Name: "x"})
+	require.Equal(t, api.IPSet{
+		Name:               snapshots[1].Name,
+		UnprefixedName:     "x",
+		HashedName:         snapshots[1].HashedName,
+		Type:               "Namespace",
+		Kind:               "set",
+		Members:            map[string]string{"10.0.0.1": "x/a", "10.0.0.2": "x/b"},
+		SelectorReferences: []string{"x/allow-b"},
+		NetPolReferences:   []string{},
+		ReferCount:         1,
+	}, resp.IPSets[0])
+}
+
+func testPolicySnapshots(t *testing.T) []*dataplane.PolicySnapshot {
+	allowB, err := translation.TranslatePolicy(allowFromBToA(tcpPort(80, nil)), false)
+	require.NoError(t, err)
+	baseline := policies.NewNPMTieredPolicy(policies.BaselineTier, "default", 0)
+	baseline.PodSelectorList = []policies.SetInfo{
+		policies.NewSetInfo("team:y", ipsets.KeyValueLabelOfNamespace, true, policies.EitherMatch),
+	}
+	baseline.ACLs = []*policies.ACLPolicy{policies.NewACLPolicy(policies.Dropped, policies.Egress)}
+	return []*dataplane.PolicySnapshot{
+		{Policy: baseline},
+		{Policy: allowB, Pending: true},
+	}
+}
+
+func TestDescribePolicies(t *testing.T) {
+	snapshots := testPolicySnapshots(t)
+
+	resp := DescribePolicies(snapshots, &api.DescribePolicyRequest{})
+	require.Len(t, resp.Policies, 2)
+
+	resp = DescribePolicies(snapshots, &api.DescribePolicyRequest{Namespace: "BASELINE"})
+	require.Equal(t, []api.Policy{
+		{
+			PolicyKey: "BASELINE/default",
+			Tier:      "BASELINE",
+			Status:    api.PolicyApplied,
+			PodSelector: []api.SetInfo{
+				{Name: "nslabel-team:y", Type: "KeyValueLabelOfNamespace", Included: true, MatchType: "either"},
+			},
+			ACLs: []api.ACL{{Target: "DROP", Direction: "OUT"}},
+		},
+	}, resp.Policies)
+
+	resp = DescribePolicies(snapshots, &api.DescribePolicyRequest{Namespace: "x", Status: api.PolicyPending})
+	require.Len(t, resp.Policies, 1)
+	policy := resp.Policies[0]
+	require.Equal(t, "x/allow-b", policy.PolicyKey)
+	require.Equal(t, api.PolicyPending, policy.Status)
+	require.Equal(t, api.ACL{
+		Target:    "ALLOW",
+		Direction: "IN",
+		Protocol:  "TCP",
+		Port:      80,
+		SrcList: []api.SetInfo{
+			{Name: "podlabel-app:b", Type: "KeyValueLabelOfPod", Included: true, MatchType: "src"},
+			{Name: "ns-x", Type: "Namespace", Included: true, MatchType: "src"},
+		},
+	}, policy.ACLs[0])
+
+	resp = DescribePolicies(snapshots, &api.DescribePolicyRequest{Namespace: "x", Status: api.PolicyApplied})
+	require.Empty(t, resp.Policies)
+}
+
+func TestPodsSelectedByPolicy(t *testing.T) {
+	snapshots := testPolicySnapshots(t)
+
+	resp, err := PodsSelectedByPolicy(whatIfTestCache(), snapshots, "x/allow-b")
+	require.NoError(t, err)
+	require.Equal(t, &api.PolicyPodsResponse{PolicyKey: "x/allow-b", Pods: []string{"x/a"}}, resp)
+
+	// pods without an IP aren't selected
+	resp, err = PodsSelectedByPolicy(whatIfTestCache(), snapshots, "BASELINE/default")
+	require.NoError(t, err)
+	require.Equal(t, []string{"y/c"}, resp.Pods)
+
+	_, err = PodsSelectedByPolicy(whatIfTestCache(), snapshots, "x/missing")
+	require.ErrorIs(t, err, ErrPolicyNotFound)
+}
+
+func TestPoliciesSelectingPod(t *testing.T) {
+	snapshots := testPolicySnapshots(t)
+
+	resp, err := PoliciesSelectingPod(whatIfTestCache(), snapshots, "x/a")
+	require.NoError(t, err)
+	require.Equal(t, &api.PodPoliciesResponse{
+		Pod:      "x/a",
+		Policies: []api.SelectedPolicy{{PolicyKey: "x/allow-b", Ingress: true}},
+	}, resp)
+
+	resp, err = PoliciesSelectingPod(whatIfTestCache(), snapshots, "y/c")
+	require.NoError(t, err)
+	require.Equal(t, []api.SelectedPolicy{{PolicyKey: "BASELINE/default", Egress: true}}, resp.Policies)
+
+	resp, err = PoliciesSelectingPod(whatIfTestCache(), snapshots, "x/b")
+	require.NoError(t, err)
+	require.Empty(t, resp.Policies)
+
+	_, err = PoliciesSelectingPod(whatIfTestCache(), snapshots, "x/missing")
+	require.ErrorIs(t, err, ErrPodNotFound)
+}
@@ -29,6 +29,8 @@ type Tuple struct {
 	DstIP     string `json:"dstIP"`
 	DstPort   string `json:"dstPort"`
 	Protocol  string `json:"protocol"`
+	// Tier is ADMIN or BASELINE if the rule is from an AdminNetworkPolicy or BaselineAdminNetworkPolicy
+	Tier string `json:"tier,omitempty"`
 }
 
 func PrettyPrintTuples(tuples []*TupleAndRule, srcList map[string]*pb.RuleResponse_SetInfo, dstList map[string]*pb.RuleResponse_SetInfo) { //nolint: gocritic
@@ -63,12 +65,12 @@ func PrettyPrintTuples(tuples []*TupleAndRule, srcList map[string]*pb.RuleRespon
 			// doesn't exist in map
 			if chain != t.Rule.Chain {
 				// we've seen this tuple before with a different chain, need to print
-				fmt.Printf("\t\tProtocol: %s, Port: %s, Chain: %v, Comment: %v\n", tuple.Tuple.Protocol, tuple.Tuple.DstPort, tuple.Rule.Chain, tuple.Rule.Comment)
+				printAllowedTuple(tuple)
 			}
 		} else {
 			// we haven't seen this tuple before, print everything
 			tuplechains[*t.Tuple] = t.Rule.Chain
-			fmt.Printf("\t\tProtocol: %s, Port: %s, Chain: %v, Comment: %v\n", tuple.Tuple.Protocol, tuple.Tuple.DstPort, tuple.Rule.Chain, tuple.Rule.Comment)
+			printAllowedTuple(tuple)
 
 		}
 
@@ -85,11 +87,24 @@ func PrettyPrintTuples(tuples []*TupleAndRule, srcList map[string]*pb.RuleRespon
 	}
 }
 
+func printAllowedTuple(tuple *TupleAndRule) {
+	if tuple.Tuple.Tier != "" {
+		fmt.Printf("\t\tTier: %s, Protocol: %s, Port: %s, Chain: %v, Comment: %v\n", tuple.Tuple.Tier, tuple.Tuple.Protocol, tuple.Tuple.DstPort, tuple.Rule.Chain, tuple.Rule.Comment)
+		return
+	}
+	fmt.Printf("\t\tProtocol: %s, Port: %s, Chain: %v, Comment: %v\n", tuple.Tuple.Protocol, tuple.Tuple.DstPort, tuple.Rule.Chain, tuple.Rule.Comment)
+}
+
 // GetNetworkTuple read from node's NPM cache and iptables-save and
 // returns a list of hit rules between the source and the destination in
 // JSON format and a list of tuples from those rules.
 func (c *Converter) GetNetworkTuple(src, dst *common.Input, config *npmconfig.Config) ([][]byte, []*TupleAndRule, map[string]*pb.RuleResponse_SetInfo, map[string]*pb.RuleResponse_SetInfo, error) { //nolint: gocritic,lll
-	allRules, err := c.GetProtobufRulesFromIptable("filter")
+	getRules := c.GetProtobufRulesFromIptable
+	if isIPv6Input(src) || isIPv6Input(dst) {
+		// policies for IPv6 (dual-stack) pods are in ip6tables
+		getRules = c.GetProtobufRulesFromIp6table
+	}
+	allRules, err := getRules("filter")
 	if err != nil {
 		return nil, nil, nil, nil, fmt.Errorf("error occurred during get network tuple : %w", err)
 	}
@@ -99,6 +114,10 @@ func (c *Converter) GetNetworkTuple(src, dst *common.Input, config *npmconfig.Co
 	return getNetworkTupleCommon(src, dst, c.NPMCache, allRules)
 }
 
+func isIPv6Input(input *common.Input) bool {
+	return input.Type == common.IPADDRS && util.IsIPV6(input.Content)
+}
+
 // GetNetworkTupleFile read from NPM cache and iptables-save files and
 // returns a list of hit rules between the source and the destination in
 // JSON format and a list of tuples from those rules.
@@ -210,12 +229,25 @@ func generateTuple(src, dst *common.NpmPod, rule *pb.RuleResponse) *TupleAndRule
 	} else {
 		tuple.Protocol = ANY
 	}
+	tuple.Tier = tierOfChain(rule.Chain)
 	return &TupleAndRule{
 		Tuple: tuple,
 		Rule:  rule,
 	}
 }
 
+// tierOfChain returns the tier of an AdminNetworkPolicy or BaselineAdminNetworkPolicy chain, or an empty string for other chains.
+func tierOfChain(chain string) string {
+	switch chain {
+	case util.IptablesAzureAdminIngressChain, util.IptablesAzureAdminEgressChain:
+		return AdminTier
+	case util.IptablesAzureBaselineIngressChain, util.IptablesAzureBaselineEgressChain:
+		return BaselineTier
+	default:
+		return ""
+	}
+}
+
 func getHitRules(
 	src, dst *common.NpmPod,
 	rules map[*pb.RuleResponse]struct{},
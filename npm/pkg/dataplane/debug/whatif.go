@@ -0,0 +1,563 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/translation"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+var (
+	ErrInvalidWhatIfRequest = errors.New("invalid what-if request")
+	ErrWhatIfPolicyNotFound = errors.New("NetworkPolicy to delete does not exist")
+	ErrWhatIfTooManyPairs   = errors.New("too many pairs of pods to evaluate")
+)
+
+const (
+	minPort = 1
+	maxPort = 65535
+	// nomatchSuffix is the suffix of CIDR members for ipBlock excepts
+	nomatchSuffix = " nomatch"
+)
+
+// protocols that are evaluated for each pair of pods
+var whatIfProtocols = []policies.Protocol{policies.TCP, policies.UDP, policies.SCTP}
+
+// WhatIf translates the existing NetworkPolicies and the proposed change like NPM would,
+// and returns the pairs of pods in npmCache that would gain or lose connectivity on each protocol and port range.
+// The translated AdminNetworkPolicies and BaselineAdminNetworkPolicies in tieredPolicies are evaluated around the
+// NetworkPolicies, as in the dataplane. If maxPodPairs is positive, a change which affects more pairs of pods is
+// rejected with ErrWhatIfTooManyPairs. The dataplane isn't changed.
+func WhatIf(npmCache *common.Cache, existingNetPols []*networkingv1.NetworkPolicy, tieredPolicies []*policies.NPMNetworkPolicy,
+	req *api.WhatIfRequest, npmLiteToggle bool, maxPodPairs int,
+) (*api.WhatIfResponse, error) {
+	if req == nil || req.NetworkPolicy == nil {
+		return nil, fmt.Errorf("%w: missing NetworkPolicy", ErrInvalidWhatIfRequest)
+	}
+	netPol := req.NetworkPolicy.DeepCopy()
+	if netPol.Namespace == "" {
+		// same as kubectl
+		netPol.Namespace = "default"
+	}
+	policyKey := fmt.Sprintf("%s/%s", netPol.Namespace, netPol.Name)
+
+	before := make(map[string]*policies.NPMNetworkPolicy, len(existingNetPols))
+	for _, existingNetPol := range existingNetPols {
+		npmNetPol, err := translation.TranslatePolicy(existingNetPol, npmLiteToggle)
+		if err != nil {
+			// NPM doesn't apply NetworkPolicies that it can't translate
+			continue
+		}
+		before[npmNetPol.PolicyKey] = npmNetPol
+	}
+
+	after := make(map[string]*policies.NPMNetworkPolicy, len(before)+1)
+	for key, npmNetPol := range before {
+		after[key] = npmNetPol
+	}
+
+	changedNetPols := make([]*policies.NPMNetworkPolicy, 0, 2)
+	oldNetPol, existed := before[policyKey]
+	if existed {
+		changedNetPols = append(changedNetPols, oldNetPol)
+	}
+
+	switch req.Operation {
+	case api.WhatIfApply:
+		newNetPol, err := translation.TranslatePolicy(netPol, npmLiteToggle)
+		if err != nil {
+			return nil, fmt.Errorf("NPM would not apply NetworkPolicy %s: %w", policyKey, err)
+		}
+		after[policyKey] = newNetPol
+		changedNetPols = append(changedNetPols, newNetPol)
+	case api.WhatIfDelete:
+		if !existed {
+			return nil, fmt.Errorf("%w: %s", ErrWhatIfPolicyNotFound, policyKey)
+		}
+		delete(after, policyKey)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidWhatIfRequest, req.Operation)
+	}
+
+	e := newPolicyEvaluator(npmCache, tieredPolicies)
+	changes, err := e.connectivityChanges(sortedPolicies(before), sortedPolicies(after), changedNetPols, maxPodPairs)
+	if err != nil {
+		return nil, err
+	}
+	return &api.WhatIfResponse{
+		PolicyKey: policyKey,
+		Existed:   existed,
+		Changes:   changes,
+	}, nil
+}
+
+func sortedPolicies(policyMap map[string]*policies.NPMNetworkPolicy) []*policies.NPMNetworkPolicy {
+	result := make([]*policies.NPMNetworkPolicy, 0, len(policyMap))
+	for _, npmNetPol := range policyMap {
+		result = append(result, npmNetPol)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PolicyKey < result[j].PolicyKey
+	})
+	return result
+}
+
+// policyEvaluator evaluates translated NetworkPolicies against the pods and namespaces in the NPM cache.
+type policyEvaluator struct {
+	cache *common.Cache
+	// pods with an IP, sorted by <namespace>/<name>
+	podKeys []string
+	// AdminNetworkPolicies in the order the dataplane evaluates them, and BaselineAdminNetworkPolicies
+	admin    []*policies.NPMNetworkPolicy
+	baseline []*policies.NPMNetworkPolicy
+	// selected caches whether a policy selects a pod, which is checked for every pair of pods
+	selected map[selection]bool
+}
+
+type selection struct {
+	npmNetPol *policies.NPMNetworkPolicy
+	pod       *common.NpmPod
+}
+
+func newPolicyEvaluator(npmCache *common.Cache, tieredPolicies []*policies.NPMNetworkPolicy) *policyEvaluator {
+	podKeys := make([]string, 0, len(npmCache.PodMap))
+	for key, pod := range npmCache.PodMap {
+		if pod.PodIP != "" {
+			podKeys = append(podKeys, key)
+		}
+	}
+	sort.Strings(podKeys)
+	e := &policyEvaluator{
+		cache:    npmCache,
+		podKeys:  podKeys,
+		selected: make(map[selection]bool),
+	}
+	for _, npmNetPol := range tieredPolicies {
+		switch npmNetPol.Tier {
+		case policies.AdminTier:
+			e.admin = append(e.admin, npmNetPol)
+		case policies.BaselineTier:
+			e.baseline = append(e.baseline, npmNetPol)
+		}
+	}
+	// same order as the tier chains
+	sort.Slice(e.admin, func(i, j int) bool {
+		if e.admin[i].Priority != e.admin[j].Priority {
+			return e.admin[i].Priority < e.admin[j].Priority
+		}
+		return e.admin[i].PolicyKey < e.admin[j].PolicyKey
+	})
+	sort.Slice(e.baseline, func(i, j int) bool {
+		return e.baseline[i].PolicyKey < e.baseline[j].PolicyKey
+	})
+	return e
+}
+
+type portRange struct {
+	start int32
+	end   int32
+}
+
+// connectivityChanges compares the connectivity between pods before and after the change.
+// Only traffic to pods selected for ingress or from pods selected for egress by a changed NetworkPolicy can change.
+func (e *policyEvaluator) connectivityChanges(before, after, changedNetPols []*policies.NPMNetworkPolicy, maxPodPairs int) ([]api.ConnectivityChange, error) {
+	ingressSubjects := make(map[string]struct{})
+	egressSubjects := make(map[string]struct{})
+	for _, npmNetPol := range changedNetPols {
+		hasIngress, hasEgress := aclDirections(npmNetPol)
+		for _, key := range e.podKeys {
+			if !e.selects(npmNetPol, e.cache.PodMap[key]) {
+				continue
+			}
+			if hasIngress {
+				ingressSubjects[key] = struct{}{}
+			}
+			if hasEgress {
+				egressSubjects[key] = struct{}{}
+			}
+		}
+	}
+
+	// every pair of pods with an egress subject as the source or an ingress subject as the destination is evaluated
+	if pairs := (len(egressSubjects) + len(ingressSubjects)) * len(e.podKeys); maxPodPairs > 0 && pairs > maxPodPairs {
+		return nil, fmt.Errorf("%w: the change selects %d of %d pods, evaluate it offline with the NPM cache",
+			ErrWhatIfTooManyPairs, max(len(egressSubjects), len(ingressSubjects)), len(e.podKeys))
+	}
+
+	allNetPols := make([]*policies.NPMNetworkPolicy, 0, len(before)+len(changedNetPols)+len(e.admin)+len(e.baseline))
+	allNetPols = append(allNetPols, before...)
+	allNetPols = append(allNetPols, changedNetPols...)
+	allNetPols = append(allNetPols, e.admin...)
+	allNetPols = append(allNetPols, e.baseline...)
+	portRangesByProtocol := make(map[policies.Protocol][]portRange, len(whatIfProtocols))
+	for _, protocol := range whatIfProtocols {
+		portRangesByProtocol[protocol] = e.portRanges(allNetPols, protocol)
+	}
+
+	changes := make([]api.ConnectivityChange, 0)
+	for _, srcKey := range e.podKeys {
+		_, srcIsSubject := egressSubjects[srcKey]
+		for _, dstKey := range e.podKeys {
+			if srcKey == dstKey {
+				continue
+			}
+			if _, dstIsSubject := ingressSubjects[dstKey]; !srcIsSubject && !dstIsSubject {
+				continue
+			}
+
+			src := e.cache.PodMap[srcKey]
+			dst := e.cache.PodMap[dstKey]
+			for _, protocol := range whatIfProtocols {
+				var lastChange *api.ConnectivityChange
+				for _, ports := range portRangesByProtocol[protocol] {
+					allowedBefore := e.allowed(before, src, dst, protocol, ports.start)
+					allowedAfter := e.allowed(after, src, dst, protocol, ports.start)
+					if allowedBefore == allowedAfter {
+						lastChange = nil
+						continue
+					}
+					if lastChange != nil && lastChange.Allowed == allowedAfter {
+						// the port ranges are contiguous
+						lastChange.EndPort = ports.end
+						continue
+					}
+					changes = append(changes, api.ConnectivityChange{
+						Src:      srcKey,
+						Dst:      dstKey,
+						Protocol: string(protocol),
+						Port:     ports.start,
+						EndPort:  ports.end,
+						Allowed:  allowedAfter,
+					})
+					lastChange = &changes[len(changes)-1]
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// portRanges splits all ports into ranges where every ACL for the protocol either matches all ports in the range or none of them.
+func (e *policyEvaluator) portRanges(npmNetPols []*policies.NPMNetworkPolicy, protocol policies.Protocol) []portRange {
+	boundaries := map[int32]struct{}{minPort: {}}
+	namedPorts := make(map[string]struct{})
+	for _, npmNetPol := range npmNetPols {
+		for _, acl := range npmNetPol.ACLs {
+			if !protocolMatches(acl.Protocol, protocol) {
+				continue
+			}
+			if acl.DstPorts.Port != 0 {
+				boundaries[acl.DstPorts.Port] = struct{}{}
+				boundaries[endPort(acl.DstPorts)+1] = struct{}{}
+			}
+			for _, setInfo := range aclSetInfos(acl) {
+				if setInfo.IPSet.Type == ipsets.NamedPorts {
+					namedPorts[setInfo.IPSet.Name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	// named ports are resolved to the container ports of each pod
+	for _, key := range e.podKeys {
+		for _, containerPort := range e.cache.PodMap[key].ContainerPorts {
+			if _, ok := namedPorts[containerPort.Name]; ok && protocolMatches(policies.Protocol(containerPort.Protocol), protocol) {
+				boundaries[containerPort.ContainerPort] = struct{}{}
+				boundaries[containerPort.ContainerPort+1] = struct{}{}
+			}
+		}
+	}
+
+	starts := make([]int32, 0, len(boundaries))
+	for port := range boundaries {
+		if port >= minPort && port <= maxPort {
+			starts = append(starts, port)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i] < starts[j]
+	})
+
+	ranges := make([]portRange, 0, len(starts))
+	for i, start := range starts {
+		end := int32(maxPort)
+		if i+1 < len(starts) {
+			end = starts[i+1] - 1
+		}
+		ranges = append(ranges, portRange{start: start, end: end})
+	}
+	return ranges
+}
+
+// allowed returns whether traffic from src to dst is allowed by both the egress rules of src and the ingress rules of dst.
+func (e *policyEvaluator) allowed(npmNetPols []*policies.NPMNetworkPolicy, src, dst *common.NpmPod, protocol policies.Protocol, port int32) bool {
+	return e.directionAllowed(npmNetPols, policies.Egress, src, dst, protocol, port) &&
+		e.directionAllowed(npmNetPols, policies.Ingress, src, dst, protocol, port)
+}
+
+// directionAllowed mirrors the dataplane, which evaluates the tiers in order:
+//   - the first matching rule of the AdminNetworkPolicies, in priority order, allows or denies the traffic, or passes
+//     it to the NetworkPolicies.
+//   - an allow rule in any NetworkPolicy selecting the pod allows the traffic. Otherwise, a matching drop rule
+//     (e.g. the default drop for the direction) denies it.
+//   - the first matching rule of the BaselineAdminNetworkPolicy allows or denies the traffic which no NetworkPolicy
+//     selects, and the rest is allowed.
+func (e *policyEvaluator) directionAllowed(npmNetPols []*policies.NPMNetworkPolicy, direction policies.Direction,
+	src, dst *common.NpmPod, protocol policies.Protocol, port int32,
+) bool {
+	subject := dst
+	if direction == policies.Egress {
+		subject = src
+	}
+
+	if target, ok := e.firstMatch(e.admin, direction, subject, src, dst, protocol, port); ok && target != policies.Passed {
+		return target == policies.Allowed
+	}
+
+	denied := false
+	for _, npmNetPol := range npmNetPols {
+		if !e.selects(npmNetPol, subject) {
+			continue
+		}
+		for _, acl := range npmNetPol.ACLs {
+			if !aclHasDirection(acl, direction) || !e.aclMatches(npmNetPol, acl, src, dst, protocol, port) {
+				continue
+			}
+			if acl.Target == policies.Allowed {
+				return true
+			}
+			denied = true
+		}
+	}
+	if denied {
+		return false
+	}
+
+	if target, ok := e.firstMatch(e.baseline, direction, subject, src, dst, protocol, port); ok {
+		return target == policies.Allowed
+	}
+	return true
+}
+
+// firstMatch returns the target of the first rule of the tiered policies which matches the traffic.
+func (e *policyEvaluator) firstMatch(tieredPolicies []*policies.NPMNetworkPolicy, direction policies.Direction,
+	subject, src, dst *common.NpmPod, protocol policies.Protocol, port int32,
+) (policies.Verdict, bool) {
+	for _, npmNetPol := range tieredPolicies {
+		if !e.selects(npmNetPol, subject) {
+			continue
+		}
+		for _, acl := range npmNetPol.ACLs {
+			if aclHasDirection(acl, direction) && e.aclMatches(npmNetPol, acl, src, dst, protocol, port) {
+				return acl.Target, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (e *policyEvaluator) selects(npmNetPol *policies.NPMNetworkPolicy, pod *common.NpmPod) bool {
+	key := selection{npmNetPol: npmNetPol, pod: pod}
+	if selected, ok := e.selected[key]; ok {
+		return selected
+	}
+	selected := true
+	for _, setInfo := range npmNetPol.PodSelectorList {
+		if e.isMember(npmNetPol, setInfo.IPSet, pod, "", 0) != setInfo.Included {
+			selected = false
+			break
+		}
+	}
+	e.selected[key] = selected
+	return selected
+}
+
+func (e *policyEvaluator) aclMatches(npmNetPol *policies.NPMNetworkPolicy, acl *policies.ACLPolicy,
+	src, dst *common.NpmPod, protocol policies.Protocol, port int32,
+) bool {
+	if !protocolMatches(acl.Protocol, protocol) {
+		return false
+	}
+	if acl.DstPorts.Port != 0 && (port < acl.DstPorts.Port || port > endPort(acl.DstPorts)) {
+		return false
+	}
+	for _, setInfo := range aclSetInfos(acl) {
+		pod := src
+		if setInfo.MatchType == policies.DstMatch || setInfo.MatchType == policies.DstDstMatch {
+			pod = dst
+		}
+		if e.isMember(npmNetPol, setInfo.IPSet, pod, protocol, port) != setInfo.Included {
+			return false
+		}
+	}
+	return true
+}
+
+// isMember returns whether the pod would be in the ipset. The protocol and port are only used for named ports.
+func (e *policyEvaluator) isMember(npmNetPol *policies.NPMNetworkPolicy, set *ipsets.IPSetMetadata, pod *common.NpmPod,
+	protocol policies.Protocol, port int32,
+) bool {
+	switch set.Type {
+	case ipsets.Namespace:
+		return pod.Namespace == set.Name
+	case ipsets.KeyLabelOfNamespace:
+		if set.Name == util.KubeAllNamespacesFlag {
+			return true
+		}
+		_, ok := e.namespaceLabels(pod.Namespace)[set.Name]
+		return ok
+	case ipsets.KeyValueLabelOfNamespace:
+		return hasLabel(e.namespaceLabels(pod.Namespace), set.Name)
+	case ipsets.KeyLabelOfPod:
+		_, ok := pod.Labels[set.Name]
+		return ok
+	case ipsets.KeyValueLabelOfPod:
+		return hasLabel(pod.Labels, set.Name)
+	case ipsets.NestedLabelOfPod:
+		for _, member := range setMembers(npmNetPol, set.Name) {
+			if hasLabel(pod.Labels, member) {
+				return true
+			}
+		}
+		return false
+	case ipsets.CIDRBlocks:
+		return cidrsContain(setMembers(npmNetPol, set.Name), pod.PodIP)
+	case ipsets.NamedPorts:
+		for _, containerPort := range pod.ContainerPorts {
+			if containerPort.Name == set.Name && containerPort.ContainerPort == port &&
+				protocolMatches(policies.Protocol(containerPort.Protocol), protocol) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func (e *policyEvaluator) namespaceLabels(namespace string) map[string]string {
+	if ns, ok := e.cache.NsMap[namespace]; ok {
+		return ns.LabelsMap
+	}
+	return nil
+}
+
+// hasLabel returns whether the labels have the "<key>:<value>" label.
+func hasLabel(labels map[string]string, keyValue string) bool {
+	key, value, _ := strings.Cut(keyValue, ":")
+	actualValue, ok := labels[key]
+	return ok && actualValue == value
+}
+
+// setMembers returns the members of the policy's ipset with the name (only NestedLabelOfPod and CIDRBlocks sets have members).
+func setMembers(npmNetPol *policies.NPMNetworkPolicy, name string) []string {
+	for _, setList := range [][]*ipsets.TranslatedIPSet{npmNetPol.PodSelectorIPSets, npmNetPol.ChildPodSelectorIPSets, npmNetPol.RuleIPSets} {
+		for _, set := range setList {
+			if set.Metadata.Name == name {
+				return set.Members
+			}
+		}
+	}
+	return nil
+}
+
+// cidrsContain mirrors hash:net ipsets, where the most specific CIDR containing the IP decides whether it's a member.
+func cidrsContain(members []string, ip string) bool {
+	podIP := net.ParseIP(ip)
+	if podIP == nil {
+		return false
+	}
+
+	bestPrefixLen := -1
+	matched := false
+	for _, member := range members {
+		cidr, nomatch := strings.CutSuffix(member, nomatchSuffix)
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil || !ipNet.Contains(podIP) {
+			continue
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		if prefixLen > bestPrefixLen {
+			bestPrefixLen = prefixLen
+			matched = !nomatch
+		}
+	}
+	return matched
+}
+
+// protocolMatches treats an empty protocol as unspecified, like the dataplane does when it normalizes ACLs.
+func protocolMatches(aclProtocol, protocol policies.Protocol) bool {
+	return aclProtocol == "" || aclProtocol == policies.UnspecifiedProtocol || strings.EqualFold(string(aclProtocol), string(protocol))
+}
+
+func endPort(ports policies.Ports) int32 {
+	if ports.EndPort == 0 {
+		return ports.Port
+	}
+	return ports.EndPort
+}
+
+func aclSetInfos(acl *policies.ACLPolicy) []policies.SetInfo {
+	setInfos := make([]policies.SetInfo, 0, len(acl.SrcList)+len(acl.DstList))
+	setInfos = append(setInfos, acl.SrcList...)
+	return append(setInfos, acl.DstList...)
+}
+
+func aclHasDirection(acl *policies.ACLPolicy, direction policies.Direction) bool {
+	return acl.Direction == direction || acl.Direction == policies.Both
+}
+
+func aclDirections(npmNetPol *policies.NPMNetworkPolicy) (hasIngress, hasEgress bool) {
+	for _, acl := range npmNetPol.ACLs {
+		hasIngress = hasIngress || aclHasDirection(acl, policies.Ingress)
+		hasEgress = hasEgress || aclHasDirection(acl, policies.Egress)
+	}
+	return hasIngress, hasEgress
+}
+
+// PrettyPrintWhatIf prints the connectivity changes from WhatIf.
+func PrettyPrintWhatIf(operation api.WhatIfOperation, resp *api.WhatIfResponse) {
+	action := "adding"
+	switch {
+	case operation == api.WhatIfDelete:
+		action = "deleting"
+	case resp.Existed:
+		action = "modifying"
+	}
+	fmt.Printf("Connectivity changes from %s NetworkPolicy %s:\n", action, resp.PolicyKey)
+	if len(resp.Changes) == 0 {
+		fmt.Printf("\tNone\n")
+		return
+	}
+
+	for _, allowed := range []bool{true, false} {
+		section := "Gained"
+		if !allowed {
+			section = "Lost"
+		}
+		printedSection := false
+		for _, change := range resp.Changes {
+			if change.Allowed != allowed {
+				continue
+			}
+			if !printedSection {
+				fmt.Printf("%s:\n", section)
+				printedSection = true
+			}
+			ports := fmt.Sprintf("%d", change.Port)
+			if change.EndPort != change.Port {
+				ports = fmt.Sprintf("%d-%d", change.Port, change.EndPort)
+			}
+			fmt.Printf("\t%s -> %s, Protocol: %s, Ports: %s\n", change.Src, change.Dst, change.Protocol, ports)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/npm/http/api"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/controllers/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/controlplane/translation"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	policyv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+func whatIfTestCache() *common.Cache {
+	return &common.Cache{
+		NsMap: map[string]*common.Namespace{
+			"x": {Name: "x", LabelsMap: map[string]string{"team": "x"}},
+			"y": {Name: "y", LabelsMap: map[string]string{"team": "y"}},
+		},
+		PodMap: map[string]*common.NpmPod{
+			"x/a": {Name: "a", Namespace: "x", PodIP: "10.0.0.1", Labels: map[string]string{"app": "a"}},
+			"x/b": {Name: "b", Namespace: "x", PodIP: "10.0.0.2", Labels: map[string]string{"app": "b"}},
+			"y/c": {Name: "c", Namespace: "y", PodIP: "10.0.1.3", Labels: map[string]string{"app": "c"}},
+			// pods without an IP are ignored
+			"y/pending": {Name: "pending", Namespace: "y", Labels: map[string]string{"app": "c"}},
+		},
+	}
+}
+
+func allowFromBToA(ports ...networkingv1.NetworkPolicyPort) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-b", Namespace: "x"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "b"}}}},
+					Ports: ports,
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+func tcpPort(port int, endPort *int32) networkingv1.NetworkPolicyPort {
+	tcp := corev1.ProtocolTCP
+	p := intstr.FromInt(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &p, EndPort: endPort}
+}
+
+func allPorts(src, dst string, allowed bool) []api.ConnectivityChange {
+	changes := make([]api.ConnectivityChange, 0, len(whatIfProtocols))
+	for _, protocol := range whatIfProtocols {
+		changes = append(changes, api.ConnectivityChange{Src: src, Dst: dst, Protocol: string(protocol), Port: 1, EndPort: 65535, Allowed: allowed})
+	}
+	return changes
+}
+
+func TestWhatIf(t *testing.T) {
+	endPort90 := int32(90)
+	tests := []struct {
+		name     string
+		existing []*networkingv1.NetworkPolicy
+		req      *api.WhatIfRequest
+		expected *api.WhatIfResponse
+	}{
+		{
+			name: "add policy isolating a pod",
+			req:  &api.WhatIfRequest{Operation: api.WhatIfApply, NetworkPolicy: allowFromBToA(tcpPort(80, nil))},
+			expected: &api.WhatIfResponse{
+				PolicyKey: "x/allow-b",
+				Changes: append(append(
+					[]api.ConnectivityChange{
+						{Src: "x/b", Dst: "x/a", Protocol: "TCP", Port: 1, EndPort: 79, Allowed: false},
+						{Src: "x/b", Dst: "x/a", Protocol: "TCP", Port: 81, EndPort: 65535, Allowed: false},
+					},
+					allPorts("x/b", "x/a", false)[1:]...),
+					allPorts("y/c", "x/a", false)...),
+			},
+		},
+		{
+			name:     "modify port to a port range",
+			existing: []*networkingv1.NetworkPolicy{allowFromBToA(tcpPort(80, nil))},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfApply, NetworkPolicy: allowFromBToA(tcpPort(80, &endPort90))},
+			expected: &api.WhatIfResponse{
+				PolicyKey: "x/allow-b",
+				Existed:   true,
+				Changes: []api.ConnectivityChange{
+					{Src: "x/b", Dst: "x/a", Protocol: "TCP", Port: 81, EndPort: 90, Allowed: true},
+				},
+			},
+		},
+		{
+			name:     "delete policy",
+			existing: []*networkingv1.NetworkPolicy{allowFromBToA()},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfDelete, NetworkPolicy: allowFromBToA()},
+			expected: &api.WhatIfResponse{
+				PolicyKey: "x/allow-b",
+				Existed:   true,
+				Changes:   allPorts("y/c", "x/a", true),
+			},
+		},
+		{
+			name: "egress to ipBlock with except",
+			req: &api.WhatIfRequest{
+				Operation: api.WhatIfApply,
+				NetworkPolicy: &networkingv1.NetworkPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "egress-cidr", Namespace: "y"},
+					Spec: networkingv1.NetworkPolicySpec{
+						Egress: []networkingv1.NetworkPolicyEgressRule{
+							{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/24", Except: []string{"10.0.0.2/32"}}}}},
+						},
+						PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+					},
+				},
+			},
+			expected: &api.WhatIfResponse{
+				PolicyKey: "y/egress-cidr",
+				Changes:   allPorts("y/c", "x/b", false),
+			},
+		},
+		{
+			name: "ingress from namespace selector",
+			req: &api.WhatIfRequest{
+				Operation: api.WhatIfApply,
+				NetworkPolicy: &networkingv1.NetworkPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "from-team-y", Namespace: "x"},
+					Spec: networkingv1.NetworkPolicySpec{
+						Ingress: []networkingv1.NetworkPolicyIngressRule{
+							{From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "y"}}}}},
+						},
+						PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+					},
+				},
+			},
+			expected: &api.WhatIfResponse{
+				PolicyKey: "x/from-team-y",
+				Changes:   append(allPorts("x/a", "x/b", false), allPorts("x/b", "x/a", false)...),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := WhatIf(whatIfTestCache(), tt.existing, nil, tt.req, false, 0)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestWhatIfNamedPort(t *testing.T) {
+	npmCache := whatIfTestCache()
+	npmCache.PodMap["x/a"].ContainerPorts = []corev1.ContainerPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}}
+	tcp := corev1.ProtocolTCP
+	namedPort := intstr.FromString("http")
+	req := &api.WhatIfRequest{
+		Operation:     api.WhatIfApply,
+		NetworkPolicy: allowFromBToA(networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &namedPort}),
+	}
+
+	actual, err := WhatIf(npmCache, nil, nil, req, false, 0)
+	if util.IsWindowsDP() {
+		require.ErrorIs(t, err, translation.ErrUnsupportedNamedPort)
+		return
+	}
+	require.NoError(t, err)
+	require.Contains(t, actual.Changes, api.ConnectivityChange{Src: "x/b", Dst: "x/a", Protocol: "TCP", Port: 1, EndPort: 8079, Allowed: false})
+	require.Contains(t, actual.Changes, api.ConnectivityChange{Src: "x/b", Dst: "x/a", Protocol: "TCP", Port: 8081, EndPort: 65535, Allowed: false})
+	require.Contains(t, actual.Changes, api.ConnectivityChange{Src: "y/c", Dst: "x/a", Protocol: "TCP", Port: 1, EndPort: 65535, Allowed: false})
+}
+
+// podsFrom is the subject of the rules from pods with the app label in any namespace.
+func podsFrom(app string) []policyv1alpha1.AdminNetworkPolicyPeer {
+	return []policyv1alpha1.AdminNetworkPolicyPeer{
+		{
+			Pods: &policyv1alpha1.NamespacedPodPeer{
+				Namespaces:  policyv1alpha1.NamespacedPeer{NamespaceSelector: &metav1.LabelSelector{}},
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+		},
+	}
+}
+
+var podA = policyv1alpha1.AdminNetworkPolicySubject{
+	Pods: &policyv1alpha1.NamespacedPodSubject{
+		NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}},
+		PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}},
+	},
+}
+
+func adminPolicy(t *testing.T, name string, priority int32, action policyv1alpha1.AdminNetworkPolicyRuleAction, from string) *policies.NPMNetworkPolicy {
+	npmNetPol, err := translation.TranslateAdminNetworkPolicy(&policyv1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: policyv1alpha1.AdminNetworkPolicySpec{
+			Priority: priority,
+			Subject:  podA,
+			Ingress:  []policyv1alpha1.AdminNetworkPolicyIngressRule{{Action: action, From: podsFrom(from)}},
+		},
+	})
+	require.NoError(t, err)
+	return npmNetPol
+}
+
+func TestWhatIfTiers(t *testing.T) {
+	banp, err := translation.TranslateBaselineAdminNetworkPolicy(&policyv1alpha1.BaselineAdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: policyv1alpha1.BaselineAdminNetworkPolicySpec{
+			Subject: podA,
+			Ingress: []policyv1alpha1.BaselineAdminNetworkPolicyIngressRule{
+				{Action: policyv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny, From: podsFrom("c")},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		tiered   func(t *testing.T) []*policies.NPMNetworkPolicy
+		existing []*networkingv1.NetworkPolicy
+		req      *api.WhatIfRequest
+		expected []api.ConnectivityChange
+	}{
+		{
+			name: "admin allow overrides the isolation of a NetworkPolicy",
+			tiered: func(t *testing.T) []*policies.NPMNetworkPolicy {
+				return []*policies.NPMNetworkPolicy{adminPolicy(t, "allow-c", 1, policyv1alpha1.AdminNetworkPolicyRuleActionAllow, "c")}
+			},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfApply, NetworkPolicy: allowFromBToA()},
+			expected: []api.ConnectivityChange{},
+		},
+		{
+			name: "admin deny overrides the allow of a NetworkPolicy",
+			tiered: func(t *testing.T) []*policies.NPMNetworkPolicy {
+				return []*policies.NPMNetworkPolicy{adminPolicy(t, "deny-b", 1, policyv1alpha1.AdminNetworkPolicyRuleActionDeny, "b")}
+			},
+			existing: []*networkingv1.NetworkPolicy{allowFromBToA()},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfDelete, NetworkPolicy: allowFromBToA()},
+			expected: allPorts("y/c", "x/a", true),
+		},
+		{
+			name: "admin pass skips the lower priority AdminNetworkPolicies",
+			tiered: func(t *testing.T) []*policies.NPMNetworkPolicy {
+				if util.IsWindowsDP() {
+					t.Skip("the Pass action isn't supported on windows")
+				}
+				return []*policies.NPMNetworkPolicy{
+					adminPolicy(t, "allow-c", 2, policyv1alpha1.AdminNetworkPolicyRuleActionAllow, "c"),
+					adminPolicy(t, "pass-c", 1, policyv1alpha1.AdminNetworkPolicyRuleActionPass, "c"),
+				}
+			},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfApply, NetworkPolicy: allowFromBToA()},
+			expected: allPorts("y/c", "x/a", false),
+		},
+		{
+			name: "baseline applies when no NetworkPolicy selects the pod",
+			tiered: func(*testing.T) []*policies.NPMNetworkPolicy {
+				return []*policies.NPMNetworkPolicy{banp}
+			},
+			existing: []*networkingv1.NetworkPolicy{allowFromBToA()},
+			req:      &api.WhatIfRequest{Operation: api.WhatIfDelete, NetworkPolicy: allowFromBToA()},
+			expected: []api.ConnectivityChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := WhatIf(whatIfTestCache(), tt.existing, tt.tiered(t), tt.req, false, 0)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual.Changes)
+		})
+	}
+}
+
+func TestWhatIfTooManyPairs(t *testing.T) {
+	req := &api.WhatIfRequest{Operation: api.WhatIfApply, NetworkPolicy: allowFromBToA()}
+
+	// x/a is the only subject, so each of the 3 pods is a source
+	_, err := WhatIf(whatIfTestCache(), nil, nil, req, false, 2)
+	require.ErrorIs(t, err, ErrWhatIfTooManyPairs)
+
+	_, err = WhatIf(whatIfTestCache(), nil, nil, req, false, 3)
+	require.NoError(t, err)
+}
+
+func TestWhatIfErrors(t *testing.T) {
+	_, err := WhatIf(whatIfTestCache(), nil, nil, &api.WhatIfRequest{Operation: api.WhatIfDelete, NetworkPolicy: allowFromBToA()}, false, 0)
+	require.ErrorIs(t, err, ErrWhatIfPolicyNotFound)
+
+	_, err = WhatIf(whatIfTestCache(), nil, nil, &api.WhatIfRequest{Operation: "replace", NetworkPolicy: allowFromBToA()}, false, 0)
+	require.ErrorIs(t, err, ErrInvalidWhatIfRequest)
+
+	_, err = WhatIf(whatIfTestCache(), nil, nil, &api.WhatIfRequest{Operation: api.WhatIfApply}, false, 0)
+	require.ErrorIs(t, err, ErrInvalidWhatIfRequest)
+}
@@ -464,6 +464,14 @@ func (dp *DPShim) GetAllPolicies() []string {
 	return nil
 }
 
+func (dp *DPShim) GetAllIPSetSnapshots() []*ipsets.IPSetSnapshot {
+	return nil
+}
+
+func (dp *DPShim) GetAllPolicySnapshots() []*dataplane.PolicySnapshot {
+	return nil
+}
+
 func (dp *DPShim) lock() {
 	dp.mu.Lock()
 }
@@ -0,0 +1,175 @@
+// Package flowlog reports the flows dropped by NPM when deny flow logging is enabled.
+// The dataplane logs dropped packets to an NFLOG group (see the policies package), and the Logger reads the group
+// and reports each packet with its pods and the policies which denied it.
+package flowlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/util"
+)
+
+type Direction string
+
+const (
+	Ingress Direction = "ingress"
+	Egress  Direction = "egress"
+
+	// UnknownPolicy is the policy label for flows which couldn't be attributed to a policy.
+	UnknownPolicy = "unknown"
+)
+
+const (
+	ipv4Version = 4
+	ipv6Version = 6
+
+	minIPv4HeaderLength = 20
+	ipv6HeaderLength    = 40
+	ipv4FragmentMask    = 0x1fff
+
+	protocolICMP   = 1
+	protocolTCP    = 6
+	protocolUDP    = 17
+	protocolICMPv6 = 58
+	protocolSCTP   = 132
+
+	// IPv6 extension headers which are skipped to find the transport header
+	ipv6HopByHop    = 0
+	ipv6Routing     = 43
+	ipv6Destination = 60
+)
+
+var (
+	ErrUnknownPrefix   = errors.New("unknown NFLOG prefix")
+	ErrMalformedPacket = errors.New("malformed packet")
+)
+
+var protocolNames = map[uint8]string{
+	protocolICMP:   "ICMP",
+	protocolTCP:    "TCP",
+	protocolUDP:    "UDP",
+	protocolICMPv6: "ICMPv6",
+	protocolSCTP:   "SCTP",
+}
+
+// Flow is a flow denied by NPM.
+type Flow struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Protocol  string    `json:"protocol"`
+	SrcIP     string    `json:"srcIP"`
+	// SrcPort and DstPort are zero for protocols without ports and for non-first fragments
+	SrcPort int    `json:"srcPort,omitempty"`
+	DstIP   string `json:"dstIP"`
+	DstPort int    `json:"dstPort,omitempty"`
+	// SrcPod and DstPod are <namespace>/<name> keys, or empty if the IP doesn't belong to a pod
+	SrcPod string `json:"srcPod,omitempty"`
+	DstPod string `json:"dstPod,omitempty"`
+	// Policies are the keys of the policies which denied the flow
+	Policies []string `json:"policies"`
+}
+
+// Resolver sets the pods and policies of a denied flow.
+type Resolver interface {
+	// ResolveDeniedFlow sets SrcPod, DstPod, and Policies.
+	// policyHash is the hash of the key of the policy which dropped the packet,
+	// or empty if the packet was dropped because no NetworkPolicy allowed it.
+	ResolveDeniedFlow(flow *Flow, policyHash string)
+}
+
+// parsePrefix returns the direction and policy hash from the NFLOG prefix of a drop rule.
+// The policy hash is empty for drops on the drop mark.
+func parsePrefix(prefix string) (Direction, string, error) {
+	var direction Direction
+	var rest string
+	switch {
+	case strings.HasPrefix(prefix, util.NflogIngressDropPrefix):
+		direction = Ingress
+		rest = strings.TrimPrefix(prefix, util.NflogIngressDropPrefix)
+	case strings.HasPrefix(prefix, util.NflogEgressDropPrefix):
+		direction = Egress
+		rest = strings.TrimPrefix(prefix, util.NflogEgressDropPrefix)
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)
+	}
+
+	if rest == "" {
+		return direction, "", nil
+	}
+	policyHash, ok := strings.CutPrefix(rest, "-")
+	if !ok || policyHash == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)
+	}
+	return direction, policyHash, nil
+}
+
+// parsePacket returns the flow of a packet which starts at the IP header.
+func parsePacket(packet []byte) (*Flow, error) {
+	if len(packet) == 0 {
+		return nil, fmt.Errorf("%w: empty packet", ErrMalformedPacket)
+	}
+
+	var protocol uint8
+	var transport []byte
+	flow := &Flow{}
+	switch packet[0] >> 4 {
+	case ipv4Version:
+		headerLength := int(packet[0]&0x0f) * 4
+		if headerLength < minIPv4HeaderLength || len(packet) < headerLength {
+			return nil, fmt.Errorf("%w: truncated IPv4 header", ErrMalformedPacket)
+		}
+		protocol = packet[9]
+		flow.SrcIP = net.IP(packet[12:16]).String()
+		flow.DstIP = net.IP(packet[16:20]).String()
+		// only the first fragment has the transport header
+		if binary.BigEndian.Uint16(packet[6:8])&ipv4FragmentMask == 0 {
+			transport = packet[headerLength:]
+		}
+	case ipv6Version:
+		if len(packet) < ipv6HeaderLength {
+			return nil, fmt.Errorf("%w: truncated IPv6 header", ErrMalformedPacket)
+		}
+		flow.SrcIP = net.IP(packet[8:24]).String()
+		flow.DstIP = net.IP(packet[24:40]).String()
+		protocol, transport = skipIPv6ExtensionHeaders(packet[6], packet[ipv6HeaderLength:])
+	default:
+		return nil, fmt.Errorf("%w: unknown IP version %d", ErrMalformedPacket, packet[0]>>4)
+	}
+
+	flow.Protocol = protocolName(protocol)
+	if (protocol == protocolTCP || protocol == protocolUDP || protocol == protocolSCTP) && len(transport) >= 4 {
+		flow.SrcPort = int(binary.BigEndian.Uint16(transport[0:2]))
+		flow.DstPort = int(binary.BigEndian.Uint16(transport[2:4]))
+	}
+	return flow, nil
+}
+
+// skipIPv6ExtensionHeaders returns the protocol and header after any hop-by-hop, routing, and destination options headers.
+// Other extension headers (e.g. fragments) are returned as the protocol.
+func skipIPv6ExtensionHeaders(nextHeader uint8, rest []byte) (uint8, []byte) {
+	for nextHeader == ipv6HopByHop || nextHeader == ipv6Routing || nextHeader == ipv6Destination {
+		if len(rest) < 2 {
+			return nextHeader, nil
+		}
+		headerLength := (int(rest[1]) + 1) * 8
+		if len(rest) < headerLength {
+			return nextHeader, nil
+		}
+		nextHeader = rest[0]
+		rest = rest[headerLength:]
+	}
+	return nextHeader, rest
+}
+
+func protocolName(protocol uint8) string {
+	if name, ok := protocolNames[protocol]; ok {
+		return name
+	}
+	return strconv.Itoa(int(protocol))
+}
@@ -0,0 +1,120 @@
+package flowlog
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ipv4Packet returns an IPv4 header without options followed by the transport header.
+func ipv4Packet(protocol uint8, fragmentOffset uint16, src, dst string, transport ...byte) []byte {
+	packet := make([]byte, minIPv4HeaderLength)
+	packet[0] = ipv4Version<<4 | 5
+	packet[6] = byte(fragmentOffset >> 8)
+	packet[7] = byte(fragmentOffset)
+	packet[9] = protocol
+	copy(packet[12:16], net.ParseIP(src).To4())
+	copy(packet[16:20], net.ParseIP(dst).To4())
+	return append(packet, transport...)
+}
+
+// ipv6Packet returns an IPv6 header followed by the extension and transport headers.
+func ipv6Packet(nextHeader uint8, src, dst string, rest ...byte) []byte {
+	packet := make([]byte, ipv6HeaderLength)
+	packet[0] = ipv6Version << 4
+	packet[6] = nextHeader
+	copy(packet[8:24], net.ParseIP(src))
+	copy(packet[24:40], net.ParseIP(dst))
+	return append(packet, rest...)
+}
+
+// ports 12345 -> 80
+var transportPorts = []byte{0x30, 0x39, 0x00, 0x50}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		prefix     string
+		direction  Direction
+		policyHash string
+		wantErr    bool
+	}{
+		{prefix: "AZURE-NPM-INGRESS-DROP", direction: Ingress},
+		{prefix: "AZURE-NPM-EGRESS-DROP", direction: Egress},
+		{prefix: "AZURE-NPM-EGRESS-DROP-1234", direction: Egress, policyHash: "1234"},
+		{prefix: "AZURE-NPM-EGRESS-DROP-", wantErr: true},
+		{prefix: "AZURE-NPM-INGRESS-DROPS", wantErr: true},
+		{prefix: "some-other-rule", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.prefix, func(t *testing.T) {
+			direction, policyHash, err := parsePrefix(tt.prefix)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnknownPrefix)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.direction, direction)
+			require.Equal(t, tt.policyHash, policyHash)
+		})
+	}
+}
+
+func TestParsePacket(t *testing.T) {
+	tests := []struct {
+		name     string
+		packet   []byte
+		expected *Flow
+	}{
+		{
+			name:     "IPv4 TCP",
+			packet:   ipv4Packet(protocolTCP, 0, "10.0.0.2", "10.0.0.1", transportPorts...),
+			expected: &Flow{Protocol: "TCP", SrcIP: "10.0.0.2", SrcPort: 12345, DstIP: "10.0.0.1", DstPort: 80},
+		},
+		{
+			name:     "IPv4 non-first fragment has no ports",
+			packet:   ipv4Packet(protocolUDP, 10, "10.0.0.2", "10.0.0.1", transportPorts...),
+			expected: &Flow{Protocol: "UDP", SrcIP: "10.0.0.2", DstIP: "10.0.0.1"},
+		},
+		{
+			name:     "IPv4 ICMP",
+			packet:   ipv4Packet(protocolICMP, 0, "10.0.0.2", "10.0.0.1", 8, 0, 0, 0),
+			expected: &Flow{Protocol: "ICMP", SrcIP: "10.0.0.2", DstIP: "10.0.0.1"},
+		},
+		{
+			name:     "IPv4 unknown protocol",
+			packet:   ipv4Packet(47, 0, "10.0.0.2", "10.0.0.1"),
+			expected: &Flow{Protocol: "47", SrcIP: "10.0.0.2", DstIP: "10.0.0.1"},
+		},
+		{
+			name: "IPv6 SCTP after hop-by-hop options",
+			packet: ipv6Packet(ipv6HopByHop, "fd00::2", "fd00::1",
+				append([]byte{protocolSCTP, 0, 0, 0, 0, 0, 0, 0}, transportPorts...)...),
+			expected: &Flow{Protocol: "SCTP", SrcIP: "fd00::2", SrcPort: 12345, DstIP: "fd00::1", DstPort: 80},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			flow, err := parsePacket(tt.packet)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, flow)
+		})
+	}
+}
+
+func TestParseMalformedPacket(t *testing.T) {
+	packets := [][]byte{
+		nil,
+		ipv4Packet(protocolTCP, 0, "10.0.0.2", "10.0.0.1")[:10],
+		ipv6Packet(protocolTCP, "fd00::2", "fd00::1")[:20],
+		{0x10},
+	}
+	for _, packet := range packets {
+		_, err := parsePacket(packet)
+		require.ErrorIs(t, err, ErrMalformedPacket)
+	}
+}
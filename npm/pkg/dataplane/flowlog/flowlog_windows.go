@@ -0,0 +1,19 @@
+package flowlog
+
+import (
+	"errors"
+	"io"
+)
+
+var errUnsupported = errors.New("deny flow logging is only supported in Linux")
+
+// Logger is not supported in Windows since the dataplane doesn't log denied flows.
+type Logger struct{}
+
+func NewLogger(_ Resolver, _ io.Writer) *Logger {
+	return &Logger{}
+}
+
+func (l *Logger) Run(_ uint16, _ <-chan struct{}) error {
+	return errUnsupported
+}
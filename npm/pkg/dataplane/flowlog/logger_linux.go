@@ -0,0 +1,74 @@
+package flowlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"k8s.io/klog"
+)
+
+// Logger reports the packets in an NFLOG group as denied flows.
+// Each flow is counted in Prometheus per policy, and written as a JSON line if the Logger has an output.
+// The drop rules rate limit the logged packets, so the Logger doesn't.
+type Logger struct {
+	resolver Resolver
+	encoder  *json.Encoder
+}
+
+// NewLogger creates a Logger which resolves flows with the resolver. A nil out means flows are only counted.
+func NewLogger(resolver Resolver, out io.Writer) *Logger {
+	l := &Logger{resolver: resolver}
+	if out != nil {
+		l.encoder = json.NewEncoder(out)
+	}
+	return l
+}
+
+// Run reports the packets logged to the NFLOG group until stopCh is closed.
+func (l *Logger) Run(group uint16, stopCh <-chan struct{}) error {
+	reader, err := newNflogReader(group)
+	if err != nil {
+		return err
+	}
+	defer reader.close()
+
+	klog.Infof("[flowlog] reading denied flows from NFLOG group %d", group)
+	return reader.read(stopCh, func(prefix string, packet []byte) {
+		if err := l.handle(prefix, packet, time.Now()); err != nil {
+			klog.Warningf("[flowlog] failed to report denied flow. err: %v", err)
+		}
+	})
+}
+
+// handle reports a packet which was logged with the prefix at time t.
+func (l *Logger) handle(prefix string, packet []byte, t time.Time) error {
+	direction, policyHash, err := parsePrefix(prefix)
+	if err != nil {
+		return err
+	}
+	flow, err := parsePacket(packet)
+	if err != nil {
+		return err
+	}
+	flow.Time = t.UTC()
+	flow.Direction = direction
+	l.resolver.ResolveDeniedFlow(flow, policyHash)
+	if len(flow.Policies) == 0 {
+		flow.Policies = []string{UnknownPolicy}
+	}
+
+	for _, policyKey := range flow.Policies {
+		metrics.IncDeniedFlows(policyKey, string(direction))
+	}
+
+	if l.encoder == nil {
+		return nil
+	}
+	if err := l.encoder.Encode(flow); err != nil {
+		return fmt.Errorf("failed to write flow: %w", err)
+	}
+	return nil
+}
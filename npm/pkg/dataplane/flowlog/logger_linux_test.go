@@ -0,0 +1,75 @@
+package flowlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink/nl"
+)
+
+type fakeResolver struct {
+	policyHashes []string
+}
+
+func (r *fakeResolver) ResolveDeniedFlow(flow *Flow, policyHash string) {
+	r.policyHashes = append(r.policyHashes, policyHash)
+	if flow.DstIP == "10.0.0.1" {
+		flow.SrcPod = "x/b"
+		flow.DstPod = "x/a"
+		flow.Policies = []string{"x/allow-b"}
+	}
+}
+
+func TestLoggerHandle(t *testing.T) {
+	metrics.ReinitializeAll()
+	resolver := &fakeResolver{}
+	out := &bytes.Buffer{}
+	l := NewLogger(resolver, out)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, l.handle("AZURE-NPM-INGRESS-DROP", ipv4Packet(protocolTCP, 0, "10.0.0.2", "10.0.0.1", transportPorts...), now))
+	require.NoError(t, l.handle("AZURE-NPM-EGRESS-DROP-1234", ipv4Packet(protocolUDP, 0, "10.0.0.2", "10.0.0.3", transportPorts...), now))
+	require.ErrorIs(t, l.handle("AZURE-NPM-EGRESS-DROP", nil, now), ErrMalformedPacket)
+	require.ErrorIs(t, l.handle("other", nil, now), ErrUnknownPrefix)
+
+	require.Equal(t, []string{"", "1234"}, resolver.policyHashes)
+	require.Equal(t,
+		`{"time":"2024-01-02T03:04:05Z","direction":"ingress","protocol":"TCP","srcIP":"10.0.0.2","srcPort":12345,"dstIP":"10.0.0.1","dstPort":80,"srcPod":"x/b","dstPod":"x/a","policies":["x/allow-b"]}`+"\n"+
+			`{"time":"2024-01-02T03:04:05Z","direction":"egress","protocol":"UDP","srcIP":"10.0.0.2","srcPort":12345,"dstIP":"10.0.0.3","dstPort":80,"policies":["unknown"]}`+"\n",
+		out.String())
+
+	count, err := metrics.TotalDeniedFlows("x/allow-b", "ingress")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	count, err = metrics.TotalDeniedFlows(UnknownPolicy, "egress")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestLoggerHandleWithoutOutput(t *testing.T) {
+	metrics.ReinitializeAll()
+	l := NewLogger(&fakeResolver{}, nil)
+	require.NoError(t, l.handle("AZURE-NPM-INGRESS-DROP", ipv4Packet(protocolTCP, 0, "10.0.0.2", "10.0.0.1", transportPorts...), time.Now()))
+
+	count, err := metrics.TotalDeniedFlows("x/allow-b", "ingress")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestParseNflogPacket(t *testing.T) {
+	packet := ipv4Packet(protocolTCP, 0, "10.0.0.2", "10.0.0.1", transportPorts...)
+	data := []byte{2, nl.NFNETLINK_V0, 0, 100}
+	data = append(data, nl.NewRtAttr(nfulaPrefix, nl.ZeroTerminated("AZURE-NPM-INGRESS-DROP")).Serialize()...)
+	data = append(data, nl.NewRtAttr(nfulaPayload, packet).Serialize()...)
+
+	prefix, payload, err := parseNflogPacket(data)
+	require.NoError(t, err)
+	require.Equal(t, "AZURE-NPM-INGRESS-DROP", prefix)
+	require.Equal(t, packet, payload)
+
+	_, _, err = parseNflogPacket(data[:len(data)-len(nl.NewRtAttr(nfulaPayload, packet).Serialize())])
+	require.ErrorIs(t, err, errNoPayload)
+}
@@ -0,0 +1,163 @@
+package flowlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+)
+
+// constants from linux/netfilter/nfnetlink.h and linux/netfilter/nfnetlink_log.h
+const (
+	nfnlSubsysULOG = 4
+
+	nfulnlMsgPacket = 0
+	nfulnlMsgConfig = 1
+
+	nfulaPayload = 9
+	nfulaPrefix  = 10
+
+	nfulaCfgCmd  = 1
+	nfulaCfgMode = 2
+
+	nfulnlCfgCmdBind = 1
+	nfulnlCopyPacket = 2
+	configModeLength = 6
+
+	// enough for the IP header with options or IPv6 extension headers, and the ports of the transport header
+	copyRange = 256
+
+	receiveBufferSize = 4 * 1024 * 1024
+)
+
+var errNoPayload = errors.New("NFLOG message has no payload")
+
+// nflogReader receives the packets logged to an NFLOG group over netfilter netlink.
+type nflogReader struct {
+	sock  *nl.NetlinkSocket
+	group uint16
+}
+
+func newNflogReader(group uint16) (*nflogReader, error) {
+	sock, err := nl.Subscribe(unix.NETLINK_NETFILTER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netfilter netlink socket: %w", err)
+	}
+	r := &nflogReader{sock: sock, group: group}
+
+	if err := sock.SetReceiveBufferSize(receiveBufferSize, false); err != nil {
+		klog.Warningf("[flowlog] failed to set receive buffer size. err: %v", err)
+	}
+	if err := r.configure(nl.NewRtAttr(nfulaCfgCmd, []byte{nfulnlCfgCmdBind})); err != nil {
+		r.close()
+		return nil, fmt.Errorf("failed to bind to NFLOG group %d: %w", group, err)
+	}
+	// struct nfulnl_msg_config_mode is a __be32 copy_range, a __u8 copy_mode, and padding
+	mode := make([]byte, configModeLength)
+	binary.BigEndian.PutUint32(mode, copyRange)
+	mode[4] = nfulnlCopyPacket
+	if err := r.configure(nl.NewRtAttr(nfulaCfgMode, mode)); err != nil {
+		r.close()
+		return nil, fmt.Errorf("failed to set copy mode for NFLOG group %d: %w", group, err)
+	}
+
+	// wake up periodically to check whether to stop
+	if err := sock.SetReceiveTimeout(&unix.Timeval{Sec: 1}); err != nil {
+		r.close()
+		return nil, fmt.Errorf("failed to set receive timeout: %w", err)
+	}
+	return r, nil
+}
+
+// configure sends a config message for the group and waits for the ack.
+func (r *nflogReader) configure(attr *nl.RtAttr) error {
+	req := nl.NewNetlinkRequest(nfnlSubsysULOG<<8|nfulnlMsgConfig, unix.NLM_F_ACK)
+	// struct nfgenmsg with the group as the big endian resource id
+	req.AddRawData([]byte{unix.AF_UNSPEC, nl.NFNETLINK_V0, byte(r.group >> 8), byte(r.group & 0xff)})
+	req.AddData(attr)
+	if err := r.sock.Send(req); err != nil {
+		return fmt.Errorf("failed to send config: %w", err)
+	}
+
+	msgs, _, err := r.sock.Receive()
+	if err != nil {
+		return fmt.Errorf("failed to receive ack: %w", err)
+	}
+	for _, m := range msgs {
+		if m.Header.Type != unix.NLMSG_ERROR || len(m.Data) < 4 {
+			continue
+		}
+		// an ack is an error message with errno 0
+		if errno := int32(nl.NativeEndian().Uint32(m.Data[0:4])); errno != 0 {
+			return syscall.Errno(-errno)
+		}
+	}
+	return nil
+}
+
+// read calls handle for each logged packet until stopCh is closed.
+func (r *nflogReader) read(stopCh <-chan struct{}, handle func(prefix string, packet []byte)) error {
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		default:
+		}
+
+		msgs, _, err := r.sock.Receive()
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			if errors.Is(err, unix.ENOBUFS) {
+				klog.Warningf("[flowlog] NFLOG group %d overflowed. some denied flows weren't reported", r.group)
+				continue
+			}
+			return fmt.Errorf("failed to receive from NFLOG group %d: %w", r.group, err)
+		}
+
+		for _, m := range msgs {
+			if m.Header.Type != nfnlSubsysULOG<<8|nfulnlMsgPacket {
+				continue
+			}
+			prefix, packet, err := parseNflogPacket(m.Data)
+			if err != nil {
+				klog.Warningf("[flowlog] failed to parse NFLOG message. err: %v", err)
+				continue
+			}
+			handle(prefix, packet)
+		}
+	}
+}
+
+func (r *nflogReader) close() {
+	r.sock.Close()
+}
+
+// parseNflogPacket returns the prefix and payload of an NFULNL_MSG_PACKET message.
+func parseNflogPacket(data []byte) (prefix string, packet []byte, err error) {
+	if len(data) < nl.SizeofNfgenmsg {
+		return "", nil, fmt.Errorf("NFLOG message is too short: %d bytes", len(data))
+	}
+	attrs, err := nl.ParseRouteAttr(data[nl.SizeofNfgenmsg:])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse NFLOG attributes: %w", err)
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type &^ (unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER) {
+		case nfulaPrefix:
+			prefix = strings.TrimRight(string(attr.Value), "\x00")
+		case nfulaPayload:
+			packet = attr.Value
+		}
+	}
+	if packet == nil {
+		return "", nil, errNoPayload
+	}
+	return prefix, packet, nil
+}
@@ -53,6 +53,8 @@ type dirtyCacheInterface interface {
 	numSetsToDelete() int
 	// isSetToAddOrUpdate returns true if the set is dirty and should be added or updated
 	isSetToAddOrUpdate(setName string) bool
+	// isSetToCreate returns true if the set is dirty and was not in the kernel before
+	isSetToCreate(setName string) bool
 	// isSetToDelete returns true if the set is dirty and should be deleted
 	isSetToDelete(setName string) bool
 	// printAddOrUpdateCache returns a string representation of the add/update cache
@@ -217,6 +219,11 @@ func (dc *dirtyCache) isSetToAddOrUpdate(setName string) bool {
 	return ok1 || ok2
 }
 
+func (dc *dirtyCache) isSetToCreate(setName string) bool {
+	_, ok := dc.toCreateCache[setName]
+	return ok
+}
+
 func (dc *dirtyCache) isSetToDelete(setName string) bool {
 	_, ok := dc.toDestroyCache[setName]
 	return ok
@@ -3,6 +3,8 @@ package ipsets
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/npm/metrics"
@@ -16,6 +18,9 @@ type IPSetMetadata struct {
 
 type SetKind string
 
+// IPv6SetSuffix is the suffix of the IPv6 twin of a set (see IPv6SetName).
+const IPv6SetSuffix = "-6"
+
 const (
 	// ListSet is of kind list with members as other IPSets
 	ListSet SetKind = "list"
@@ -42,6 +47,20 @@ func (setMetadata *IPSetMetadata) GetHashedName() string {
 	return util.GetHashedName(prefixedName)
 }
 
+// IPv6SetName returns the name of the set holding the IPv6 members of the set with the given hashed name.
+// In Linux, an ipset holds addresses of one family, so each set has an IPv6 twin when IPv6 is enabled.
+func IPv6SetName(hashedName string) string {
+	return hashedName + IPv6SetSuffix
+}
+
+// IsIPv6Member returns true if the member is an IPv6 address or CIDR.
+// The member can have a ",protocol:port" or " nomatch" suffix.
+func IsIPv6Member(member string) bool {
+	ip := strings.Split(member, ",")[0]
+	ip = strings.Split(ip, " ")[0]
+	return util.IsIPV6(ip)
+}
+
 // TODO join with colon instead of dash for easier readability?
 func (setMetadata *IPSetMetadata) GetPrefixName() string {
 	switch setMetadata.Type {
@@ -287,6 +306,53 @@ func (set *IPSet) GetSetContents() ([]string, error) {
 	}
 }
 
+// IPSetSnapshot is a copy of an IPSet's members and references, used for debugging.
+type IPSetSnapshot struct {
+	// Name is the prefixed name
+	Name           string
+	UnprefixedName string
+	HashedName     string
+	Type           SetType
+	Kind           SetKind
+	// Members maps IPs (or "IP,protocol:port" for named ports) to pod keys for hash sets,
+	// and hashed names to prefixed names of member sets for list sets
+	Members            map[string]string
+	SelectorReferences []string
+	NetPolReferences   []string
+	// ReferCount is the number of lists in the cache referring to the set
+	ReferCount int
+}
+
+func (set *IPSet) snapshot() *IPSetSnapshot {
+	s := &IPSetSnapshot{
+		Name:               set.Name,
+		UnprefixedName:     set.unprefixedName,
+		HashedName:         set.HashedName,
+		Type:               set.Type,
+		Kind:               set.Kind,
+		Members:            make(map[string]string, len(set.IPPodKey)+len(set.MemberIPSets)),
+		SelectorReferences: sortedKeys(set.SelectorReference),
+		NetPolReferences:   sortedKeys(set.NetPolReference),
+		ReferCount:         set.ipsetReferCount,
+	}
+	for ip, podKey := range set.IPPodKey {
+		s.Members[ip] = podKey
+	}
+	for _, memberSet := range set.MemberIPSets {
+		s.Members[memberSet.HashedName] = memberSet.Name
+	}
+	return s
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ShallowCompare check if the properties of IPSets are same
 func (set *IPSet) ShallowCompare(newSet *IPSet) bool {
 	if set.Name != newSet.Name {
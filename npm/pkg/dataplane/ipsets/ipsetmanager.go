@@ -2,11 +2,13 @@ package ipsets
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
 	"github.com/Azure/azure-container-networking/npm/util"
 	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
 	"k8s.io/klog"
@@ -51,6 +53,8 @@ type IPSetManager struct {
 	setMap     map[string]*IPSet
 	dirtyCache dirtyCacheInterface
 	ioShim     *common.IOShim
+	// nft applies the sets when iMgrCfg.UseNftables is true
+	nft nftables.Executor
 	// consecutiveApplyFailures is used in Linux to count the number of consecutive failures to apply ipsets
 	// if this count exceeds a threshold, we will panic
 	consecutiveApplyFailures int
@@ -65,6 +69,10 @@ type IPSetManagerCfg struct {
 	// This is necessary for HNS (Windows); otherwise, an allow ACL with a list condition
 	// allows all IPs if the list has no members.
 	AddEmptySetToLists bool
+	// UseNftables programs the IPSets as sets in the NPM nftables table instead of as ipsets. Only affects Linux.
+	UseNftables bool
+	// EnableIPv6 allows IPv6 members. In Linux, each set gets an IPv6 twin named by IPv6SetName. Only supported in Linux.
+	EnableIPv6 bool
 }
 
 func NewIPSetManager(iMgrCfg *IPSetManagerCfg, ioShim *common.IOShim) *IPSetManager {
@@ -74,6 +82,7 @@ func NewIPSetManager(iMgrCfg *IPSetManagerCfg, ioShim *common.IOShim) *IPSetMana
 		setMap:     make(map[string]*IPSet),
 		dirtyCache: newDirtyCache(),
 		ioShim:     ioShim,
+		nft:        nftables.NewExecutor(ioShim),
 		// set to 0 to avoid lint error for windows
 		consecutiveApplyFailures: 0,
 	}
@@ -244,7 +253,7 @@ func (iMgr *IPSetManager) AddToSets(addToSets []*IPSetMetadata, ip, podKey strin
 		return nil
 	}
 
-	if !validateIPSetMemberIP(ip) {
+	if !validateIPSetMemberIP(ip, iMgr.iMgrCfg.EnableIPv6) {
 		msg := fmt.Sprintf("error: failed to add to sets: invalid ip %s", ip)
 		metrics.SendErrorLogAndMetric(util.IpsmID, "%s", msg)
 		return npmerrors.Errorf(npmerrors.AppendIPSet, true, msg)
@@ -280,7 +289,7 @@ func (iMgr *IPSetManager) RemoveFromSets(removeFromSets []*IPSetMetadata, ip, po
 		return nil
 	}
 
-	if !validateIPSetMemberIP(ip) {
+	if !validateIPSetMemberIP(ip, iMgr.iMgrCfg.EnableIPv6) {
 		msg := fmt.Sprintf("error: failed to add to sets: invalid ip %s", ip)
 		metrics.SendErrorLogAndMetric(util.IpsmID, "%s", msg)
 		return npmerrors.Errorf(npmerrors.AppendIPSet, true, msg)
@@ -491,6 +500,20 @@ func (iMgr *IPSetManager) GetAllIPSets() map[string]string {
 	return setMap
 }
 
+// GetAllIPSetSnapshots returns a copy of every set in the cache, sorted by prefixed name.
+func (iMgr *IPSetManager) GetAllIPSetSnapshots() []*IPSetSnapshot {
+	iMgr.RLock()
+	defer iMgr.RUnlock()
+	snapshots := make([]*IPSetSnapshot, 0, len(iMgr.setMap))
+	for _, set := range iMgr.setMap {
+		snapshots = append(snapshots, set.snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots
+}
+
 func (iMgr *IPSetManager) exists(name string) bool {
 	_, ok := iMgr.setMap[name]
 	return ok
@@ -593,7 +616,7 @@ func (iMgr *IPSetManager) clearDirtyCache() {
 }
 
 // validateIPSetMemberIP helps valid if a member added to an HashSet has valid IP or CIDR
-func validateIPSetMemberIP(ip string) bool {
+func validateIPSetMemberIP(ip string, allowIPv6 bool) bool {
 	// possible formats
 	// 192.168.0.1
 	// 192.168.0.1,tcp:25227
@@ -601,9 +624,10 @@ func validateIPSetMemberIP(ip string) bool {
 	// 192.168.0.0/24
 	// 192.168.0.0/24,tcp:25227
 	// 192.168.0.0/24 nomatch
+	// and the same formats with IPv6 addresses if allowIPv6 is true
 	// always guaranteed to have ip, not guaranteed to have port + protocol
 	ipDetails := strings.Split(ip, ",")
 	ipField := strings.Split(ipDetails[0], " ")
 
-	return util.IsIPV4(ipField[0])
+	return util.IsIPV4(ipField[0]) || (allowIPv6 && util.IsIPV6(ipField[0]))
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/parse"
 	"github.com/Azure/azure-container-networking/npm/util"
 	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
@@ -39,6 +40,8 @@ const (
 	ipsetIPPortHashFlag = "hash:ip,port"
 	ipsetMaxelemName    = "maxelem"
 	ipsetMaxelemNum     = "4294967295"
+	ipsetFamilyName     = "family"
+	ipsetInet6Family    = "inet6"
 
 	// constants for parsing ipset save
 	createStringWithSpace = "create "
@@ -101,6 +104,13 @@ prometheus metrics:
 	If a flush fails, we could update the num entries for that set, but that would be a lot of overhead.
 */
 func (iMgr *IPSetManager) resetIPSets() error {
+	if iMgr.iMgrCfg.UseNftables {
+		return iMgr.resetNftSets()
+	}
+	return iMgr.resetKernelIPSets()
+}
+
+func (iMgr *IPSetManager) resetKernelIPSets() error {
 	if success := iMgr.resetWithoutRestore(); success {
 		return nil
 	}
@@ -421,8 +431,18 @@ example where:
 		-X set4
 */
 func (iMgr *IPSetManager) applyIPSets() error {
-	creator := iMgr.fileCreatorForApply(maxTryCount)
-	restoreError := creator.RunCommandWithFile(ipsetCommand, ipsetRestoreFlag)
+	return iMgr.applyIPSetsWithNftTransaction(nil)
+}
+
+// applyIPSetsWithNftTransaction applies the dirty cache. When using nftables, policyTx is applied in the same transaction.
+func (iMgr *IPSetManager) applyIPSetsWithNftTransaction(policyTx *nftables.Transaction) error {
+	var restoreError error
+	if iMgr.iMgrCfg.UseNftables {
+		restoreError = iMgr.nft.Apply(iMgr.nftTransactionForApply(policyTx))
+	} else {
+		creator := iMgr.fileCreatorForApply(maxTryCount)
+		restoreError = creator.RunCommandWithFile(ipsetCommand, ipsetRestoreFlag)
+	}
 	if restoreError != nil {
 		iMgr.consecutiveApplyFailures++
 		if iMgr.consecutiveApplyFailures >= maxConsecutiveFailures {
@@ -433,6 +453,9 @@ func (iMgr *IPSetManager) applyIPSets() error {
 			panic(msg)
 		}
 
+		if iMgr.iMgrCfg.UseNftables {
+			return npmerrors.SimpleErrorWrapper("nft transaction failed when applying ipsets", restoreError)
+		}
 		return npmerrors.SimpleErrorWrapper("ipset restore failed when applying ipsets", restoreError)
 	}
 
@@ -731,6 +754,9 @@ func (iMgr *IPSetManager) flushSetForApply(creator *ioutil.FileCreator, prefixed
 	sectionID := sectionID(destroySectionPrefix, prefixedName)
 	hashedName := util.GetHashedName(prefixedName)
 	creator.AddLine(sectionID, errorHandlers, ipsetFlushFlag, hashedName) // flush set
+	if iMgr.iMgrCfg.EnableIPv6 {
+		creator.AddLine(sectionID, errorHandlers, ipsetFlushFlag, IPv6SetName(hashedName)) // flush IPv6 set
+	}
 }
 
 func (iMgr *IPSetManager) destroySetForApply(creator *ioutil.FileCreator, prefixedName string) {
@@ -754,21 +780,12 @@ func (iMgr *IPSetManager) destroySetForApply(creator *ioutil.FileCreator, prefix
 	sectionID := sectionID(destroySectionPrefix, prefixedName)
 	hashedName := util.GetHashedName(prefixedName)
 	creator.AddLine(sectionID, errorHandlers, ipsetDestroyFlag, hashedName) // destroy set
+	if iMgr.iMgrCfg.EnableIPv6 {
+		creator.AddLine(sectionID, errorHandlers, ipsetDestroyFlag, IPv6SetName(hashedName)) // destroy IPv6 set
+	}
 }
 
 func (iMgr *IPSetManager) createSetForApply(creator *ioutil.FileCreator, set *IPSet) {
-	methodFlag := ipsetNetHashFlag
-	if set.Kind == ListSet {
-		methodFlag = ipsetSetListFlag
-	} else if set.Type == NamedPorts {
-		methodFlag = ipsetIPPortHashFlag
-	}
-
-	specs := []string{ipsetCreateFlag, set.HashedName, ipsetExistFlag, methodFlag}
-	if set.Type == CIDRBlocks {
-		specs = append(specs, ipsetMaxelemName, ipsetMaxelemNum)
-	}
-
 	prefixedName := set.Name // to appease golint complaints about function literal
 	errorHandlers := []*ioutil.LineErrorHandler{
 		{
@@ -789,7 +806,49 @@ func (iMgr *IPSetManager) createSetForApply(creator *ioutil.FileCreator, set *IP
 		},
 	}
 	sectionID := sectionID(addOrUpdateSectionPrefix, prefixedName)
-	creator.AddLine(sectionID, errorHandlers, specs...) // create set
+	creator.AddLine(sectionID, errorHandlers, createSpecs(set, false)...) // create set
+	if iMgr.iMgrCfg.EnableIPv6 {
+		creator.AddLine(sectionID, errorHandlers, createSpecs(set, true)...) // create IPv6 set
+	}
+}
+
+// createSpecs returns the specs to create the set, or to create its IPv6 twin if ipv6 is true.
+// Lists have no family since their members are sets.
+func createSpecs(set *IPSet, ipv6 bool) []string {
+	methodFlag := ipsetNetHashFlag
+	if set.Kind == ListSet {
+		methodFlag = ipsetSetListFlag
+	} else if set.Type == NamedPorts {
+		methodFlag = ipsetIPPortHashFlag
+	}
+
+	name := set.HashedName
+	if ipv6 {
+		name = IPv6SetName(set.HashedName)
+	}
+	specs := []string{ipsetCreateFlag, name, ipsetExistFlag, methodFlag}
+	if ipv6 && set.Kind == HashSet {
+		specs = append(specs, ipsetFamilyName, ipsetInet6Family)
+	}
+	if set.Type == CIDRBlocks {
+		specs = append(specs, ipsetMaxelemName, ipsetMaxelemNum)
+	}
+	return specs
+}
+
+// kernelMembers returns the (set name, member) pairs to add or delete for the member.
+// IPv6 members of hash sets belong in the IPv6 twin, and a list's IPv6 twin has the IPv6 twins of the list's members.
+func (iMgr *IPSetManager) kernelMembers(set *IPSet, member string) [][2]string {
+	if !iMgr.iMgrCfg.EnableIPv6 {
+		return [][2]string{{set.HashedName, member}}
+	}
+	if set.Kind == ListSet {
+		return [][2]string{{set.HashedName, member}, {IPv6SetName(set.HashedName), IPv6SetName(member)}}
+	}
+	if IsIPv6Member(member) {
+		return [][2]string{{IPv6SetName(set.HashedName), member}}
+	}
+	return [][2]string{{set.HashedName, member}}
 }
 
 func (iMgr *IPSetManager) deleteMemberForApply(creator *ioutil.FileCreator, set *IPSet, sectionID, member string) {
@@ -813,7 +872,9 @@ func (iMgr *IPSetManager) deleteMemberForApply(creator *ioutil.FileCreator, set
 		member = splitMember[0]
 	}
 
-	creator.AddLine(sectionID, errorHandlers, ipsetDeleteFlag, set.HashedName, member) // delete member
+	for _, setAndMember := range iMgr.kernelMembers(set, member) {
+		creator.AddLine(sectionID, errorHandlers, ipsetDeleteFlag, setAndMember[0], setAndMember[1]) // delete member
+	}
 }
 
 func (iMgr *IPSetManager) addMemberForApply(creator *ioutil.FileCreator, set *IPSet, sectionID, member string) {
@@ -847,7 +908,9 @@ func (iMgr *IPSetManager) addMemberForApply(creator *ioutil.FileCreator, set *IP
 			},
 		}
 	}
-	creator.AddLine(sectionID, errorHandlers, ipsetAddFlag, set.HashedName, member) // add member
+	for _, setAndMember := range iMgr.kernelMembers(set, member) {
+		creator.AddLine(sectionID, errorHandlers, ipsetAddFlag, setAndMember[0], setAndMember[1]) // add member
+	}
 }
 
 func sectionID(prefix, prefixedName string) string {
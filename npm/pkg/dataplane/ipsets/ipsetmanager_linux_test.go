@@ -1897,3 +1897,62 @@ func removeOperationsForSet(lines []string, hashedSetName, operation string) []s
 	}
 	return goodLines
 }
+
+func TestApplyIPSetsWithIPv6(t *testing.T) {
+	calls := []testutils.TestCmd{fakeRestoreSuccessCommand}
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	cfg := &IPSetManagerCfg{
+		IPSetMode:   ApplyAllIPSets,
+		NetworkName: "azure",
+		EnableIPv6:  true,
+	}
+	iMgr := NewIPSetManager(cfg, ioshim)
+
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.0", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "fd00::1", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNamedportSet.Metadata}, "fd00::1,TCP:8080", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestCIDRSet.Metadata}, "fd00::/64", ""))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestCIDRSet.Metadata}, "fd00::/96 nomatch", ""))
+	require.NoError(t, iMgr.AddToLists([]*IPSetMetadata{TestKeyNSList.Metadata}, []*IPSetMetadata{TestNSSet.Metadata}))
+
+	creator := iMgr.fileCreatorForApply(len(calls))
+	actualLines := testAndSortRestoreFileString(t, creator.ToString())
+
+	expectedLines := []string{
+		fmt.Sprintf("-N %s --exist nethash", TestNSSet.HashedName),
+		fmt.Sprintf("-N %s-6 --exist nethash family inet6", TestNSSet.HashedName),
+		fmt.Sprintf("-N %s --exist hash:ip,port", TestNamedportSet.HashedName),
+		fmt.Sprintf("-N %s-6 --exist hash:ip,port family inet6", TestNamedportSet.HashedName),
+		fmt.Sprintf("-N %s --exist nethash maxelem 4294967295", TestCIDRSet.HashedName),
+		fmt.Sprintf("-N %s-6 --exist nethash family inet6 maxelem 4294967295", TestCIDRSet.HashedName),
+		fmt.Sprintf("-N %s --exist setlist", TestKeyNSList.HashedName),
+		fmt.Sprintf("-N %s-6 --exist setlist", TestKeyNSList.HashedName),
+		fmt.Sprintf("-A %s 10.0.0.0", TestNSSet.HashedName),
+		fmt.Sprintf("-A %s-6 fd00::1", TestNSSet.HashedName),
+		fmt.Sprintf("-A %s-6 fd00::1,TCP:8080", TestNamedportSet.HashedName),
+		fmt.Sprintf("-A %s-6 fd00::/64", TestCIDRSet.HashedName),
+		fmt.Sprintf("-A %s-6 fd00::/96 nomatch", TestCIDRSet.HashedName),
+		fmt.Sprintf("-A %s %s", TestKeyNSList.HashedName, TestNSSet.HashedName),
+		fmt.Sprintf("-A %s-6 %s-6", TestKeyNSList.HashedName, TestNSSet.HashedName),
+		"",
+	}
+	sortedExpectedLines := testAndSortRestoreFileLines(t, expectedLines)
+	dptestutils.AssertEqualLines(t, sortedExpectedLines, actualLines)
+	wasFileAltered, err := creator.RunCommandOnceWithFile("ipset", "restore")
+	require.NoError(t, err, "ipset restore should be successful")
+	require.False(t, wasFileAltered, "file should not be altered")
+
+	// destroying a set destroys its IPv6 twin too
+	iMgr.clearDirtyCache()
+	iMgr.DeleteIPSet(TestCIDRSet.PrefixName, util.ForceDelete)
+	creator = iMgr.fileCreatorForApply(len(calls))
+	expectedLines = []string{
+		fmt.Sprintf("-F %s", TestCIDRSet.HashedName),
+		fmt.Sprintf("-F %s-6", TestCIDRSet.HashedName),
+		fmt.Sprintf("-X %s", TestCIDRSet.HashedName),
+		fmt.Sprintf("-X %s-6", TestCIDRSet.HashedName),
+		"",
+	}
+	require.Equal(t, strings.Join(expectedLines, "\n"), creator.ToString())
+}
@@ -0,0 +1,233 @@
+package ipsets
+
+// This file contains code for programming IPSets as sets in the NPM nftables table (IPSetManagerCfg.UseNftables).
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"k8s.io/klog"
+)
+
+const (
+	nftNomatchSuffix = "-nomatch"
+
+	nftIPSetSpec        = "{ type ipv4_addr; }"
+	nftCIDRSetSpec      = "{ type ipv4_addr; flags interval; }"
+	nftNamedPortSetSpec = "{ type ipv4_addr . inet_proto . inet_service; }"
+)
+
+// NftNomatchSetName returns the name of the nft set holding the "nomatch" CIDRs of a CIDRBlocks set.
+// nft sets have no equivalent to ipset's nomatch, so a rule matching a CIDRBlocks set must also check that
+// the IP isn't in this set.
+func NftNomatchSetName(hashedName string) string {
+	return hashedName + nftNomatchSuffix
+}
+
+func nftSetSpec(set *IPSet) string {
+	switch set.Type {
+	case CIDRBlocks:
+		return nftCIDRSetSpec
+	case NamedPorts:
+		return nftNamedPortSetSpec
+	default:
+		return nftIPSetSpec
+	}
+}
+
+// resetNftSets destroys the ipsets left over from running with iptables.
+// The nft sets are deleted along with the NPM table when the PolicyManager boots up,
+// and sets left in the table by a previous reset are flushed when they are created again.
+func (iMgr *IPSetManager) resetNftSets() error {
+	if err := iMgr.resetKernelIPSets(); err != nil {
+		// not fatal since nothing references these ipsets once the PolicyManager has cleaned up iptables
+		metrics.SendErrorLogAndMetric(util.IpsmID, "failed to destroy ipsets left over from iptables. err: %s", err.Error())
+	}
+	return nil
+}
+
+// ApplyIPSetsWithNftTransaction applies the dirty cache and the PolicyManager's transaction in a single nft transaction,
+// so that the rules are never applied without the sets they reference or vice versa.
+// If the transaction fails, the dirty cache is kept for the next apply.
+func (iMgr *IPSetManager) ApplyIPSetsWithNftTransaction(policyTx *nftables.Transaction) error {
+	iMgr.Lock()
+	defer iMgr.Unlock()
+
+	iMgr.sanitizeDirtyCache()
+
+	prometheusTimer := metrics.StartNewTimer()
+	defer metrics.RecordIPSetExecTime(prometheusTimer) // record execution time regardless of failure
+	if err := iMgr.applyIPSetsWithNftTransaction(policyTx); err != nil {
+		metrics.SendErrorLogAndMetric(util.IpsmID, "error: failed to apply ipsets with policies: %s", err.Error())
+		return err
+	}
+
+	iMgr.clearDirtyCache()
+	return nil
+}
+
+/*
+nftTransactionForApply writes the dirty cache into a single nft transaction.
+The commands of policyTx (if any) run after sets are created and updated, and before sets are deleted.
+
+nft has no equivalent to ipset's list:set, so a list is an nft set of the union of its members' IPs.
+A list is rewritten whenever it or one of its members is dirty.
+
+example:
+
+	add set inet azure-npm azure-npm-111 { type ipv4_addr; }
+	flush set inet azure-npm azure-npm-111
+	add set inet azure-npm azure-npm-222 { type ipv4_addr; }
+	add element inet azure-npm azure-npm-111 { 10.0.0.1, 10.0.0.2 }
+	delete element inet azure-npm azure-npm-222 { 10.0.0.3 }
+	flush set inet azure-npm azure-npm-333
+	add element inet azure-npm azure-npm-333 { 10.0.0.1, 10.0.0.2, 10.0.0.4 }
+	[policyTx]
+	delete set inet azure-npm azure-npm-444
+*/
+func (iMgr *IPSetManager) nftTransactionForApply(policyTx *nftables.Transaction) *nftables.Transaction {
+	tx := nftables.NewTransaction()
+	table := nftables.TableSpec()
+
+	// sorted for determinism in UTs
+	setsToAddOrUpdate := sortedSetNames(iMgr.dirtyCache.setsToAddOrUpdate())
+
+	// 1. create all sets first so that rules and lists can reference them
+	for _, prefixedName := range setsToAddOrUpdate {
+		set := iMgr.setMap[prefixedName]
+		names := []string{set.HashedName}
+		if set.Type == CIDRBlocks {
+			names = append(names, NftNomatchSetName(set.HashedName))
+		}
+		for _, name := range names {
+			tx.Add("add set %s %s %s", table, name, nftSetSpec(set))
+			if iMgr.dirtyCache.isSetToCreate(prefixedName) {
+				// the set may have been left in the table by ResetIPSets
+				tx.Add("flush set %s %s", table, name)
+			}
+		}
+	}
+
+	// 2. delete/add members of hash sets
+	dirtyHashSets := make(map[string]struct{})
+	for _, prefixedName := range setsToAddOrUpdate {
+		set := iMgr.setMap[prefixedName]
+		if set.Kind != HashSet {
+			continue
+		}
+		dirtyHashSets[prefixedName] = struct{}{}
+		diff := iMgr.dirtyCache.memberDiff(prefixedName)
+		writeNftElements(tx, "delete", set, diff.membersToDelete)
+		writeNftElements(tx, "add", set, diff.membersToAdd)
+	}
+
+	// 3. rewrite dirty lists and lists with dirty members
+	for _, prefixedName := range iMgr.nftListsToRewrite(setsToAddOrUpdate, dirtyHashSets) {
+		list := iMgr.setMap[prefixedName]
+		tx.Add("flush set %s %s", table, list.HashedName)
+		members := make(map[string]struct{})
+		for _, member := range list.MemberIPSets {
+			for ip := range member.IPPodKey {
+				members[ip] = struct{}{}
+			}
+		}
+		writeNftElements(tx, "add", list, members)
+	}
+
+	// 4. update rules, which may reference the sets above
+	if policyTx != nil {
+		tx.Append(policyTx)
+	}
+
+	// 5. delete sets in the delete cache. Rules and lists no longer reference them.
+	for _, prefixedName := range sortedSetNames(iMgr.dirtyCache.setsToDelete()) {
+		hashedName := util.GetHashedName(prefixedName)
+		tx.Add("delete set %s %s", table, hashedName)
+		if strings.HasPrefix(prefixedName, util.CIDRPrefix) {
+			tx.Add("delete set %s %s", table, NftNomatchSetName(hashedName))
+		}
+	}
+	return tx
+}
+
+// nftListsToRewrite returns the lists to apply which are dirty or have a dirty member.
+func (iMgr *IPSetManager) nftListsToRewrite(setsToAddOrUpdate []string, dirtyHashSets map[string]struct{}) []string {
+	lists := make(map[string]struct{})
+	for _, prefixedName := range setsToAddOrUpdate {
+		if iMgr.setMap[prefixedName].Kind == ListSet {
+			lists[prefixedName] = struct{}{}
+		}
+	}
+	if len(dirtyHashSets) > 0 {
+		for prefixedName, set := range iMgr.setMap {
+			if set.Kind != ListSet || !iMgr.shouldBeInKernel(set) || iMgr.dirtyCache.isSetToDelete(prefixedName) {
+				continue
+			}
+			for memberName := range set.MemberIPSets {
+				if _, ok := dirtyHashSets[memberName]; ok {
+					lists[prefixedName] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	return sortedSetNames(lists)
+}
+
+// writeNftElements adds or deletes the ipset-formatted members of the set.
+// "nomatch" CIDRs go in the set's nomatch set, and named ports are converted to "ip . protocol . port".
+func writeNftElements(tx *nftables.Transaction, verb string, set *IPSet, members map[string]struct{}) {
+	if len(members) == 0 {
+		return
+	}
+	elements := make([]string, 0, len(members))
+	nomatchElements := make([]string, 0)
+	for member := range members {
+		splitMember := strings.Split(member, space)
+		if len(splitMember) == 2 && splitMember[1] == util.IpsetNomatch {
+			nomatchElements = append(nomatchElements, splitMember[0])
+			continue
+		}
+		elements = append(elements, nftElement(set, member))
+	}
+
+	table := nftables.TableSpec()
+	if len(elements) > 0 {
+		sort.Strings(elements)
+		tx.Add("%s element %s %s { %s }", verb, table, set.HashedName, strings.Join(elements, ", "))
+	}
+	if len(nomatchElements) > 0 {
+		sort.Strings(nomatchElements)
+		tx.Add("%s element %s %s { %s }", verb, table, NftNomatchSetName(set.HashedName), strings.Join(nomatchElements, ", "))
+	}
+}
+
+// nftElement converts an ipset member to an nft set element e.g. "10.0.0.1,tcp:8080" to "10.0.0.1 . tcp . 8080".
+func nftElement(set *IPSet, member string) string {
+	if set.Type != NamedPorts {
+		return member
+	}
+	ipAndPort := strings.Split(member, ",")
+	if len(ipAndPort) != 2 {
+		klog.Warningf("unexpected named port member %s for set %s", member, set.Name)
+		return member
+	}
+	protocolAndPort := strings.Split(ipAndPort[1], ":")
+	if len(protocolAndPort) != 2 {
+		klog.Warningf("unexpected named port member %s for set %s", member, set.Name)
+		return member
+	}
+	return strings.Join([]string{ipAndPort[0], strings.ToLower(protocolAndPort[0]), protocolAndPort[1]}, " . ")
+}
+
+func sortedSetNames(sets map[string]struct{}) []string {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,160 @@
+package ipsets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	"github.com/Azure/azure-container-networking/npm/util"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var nftCfg = &IPSetManagerCfg{
+	IPSetMode:   ApplyAllIPSets,
+	NetworkName: "azure",
+	UseNftables: true,
+}
+
+func newNftIPSetManager(t *testing.T) (*IPSetManager, *nftables.FakeExecutor) {
+	t.Helper()
+	iMgr := NewIPSetManager(nftCfg, common.NewMockIOShim(nil))
+	fake := nftables.NewFakeExecutor()
+	iMgr.nft = fake
+	return iMgr, fake
+}
+
+func TestNftApplyIPSets(t *testing.T) {
+	iMgr, fake := newNftIPSetManager(t)
+
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata, TestKeyPodSet.Metadata}, "10.0.0.1", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.2", "b"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNamedportSet.Metadata}, "10.0.0.1,TCP:8080", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestCIDRSet.Metadata}, "10.0.0.0/16", ""))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestCIDRSet.Metadata}, "10.0.1.0/24 nomatch", ""))
+	require.NoError(t, iMgr.AddToLists([]*IPSetMetadata{TestKeyNSList.Metadata}, []*IPSetMetadata{TestNSSet.Metadata, TestKeyPodSet.Metadata}))
+	require.NoError(t, iMgr.ApplyIPSets())
+
+	// one transaction for all the changes
+	require.Len(t, fake.Transactions, 1)
+	lines := []string{}
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	// sets are applied in the order of their prefixed names
+	sets := []*TestSet{TestCIDRSet, TestKeyPodSet, TestKeyNSList, TestNamedportSet, TestNSSet}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].PrefixName < sets[j].PrefixName })
+	for _, set := range sets {
+		spec := nftIPSetSpec
+		if set == TestCIDRSet {
+			spec = nftCIDRSetSpec
+		} else if set == TestNamedportSet {
+			spec = nftNamedPortSetSpec
+		}
+		add("add set inet azure-npm %s %s", set.HashedName, spec)
+		add("flush set inet azure-npm %s", set.HashedName)
+		if set == TestCIDRSet {
+			add("add set inet azure-npm %s-nomatch %s", set.HashedName, spec)
+			add("flush set inet azure-npm %s-nomatch", set.HashedName)
+		}
+	}
+	for _, set := range sets {
+		switch set {
+		case TestCIDRSet:
+			add("add element inet azure-npm %s { 10.0.0.0/16 }", set.HashedName)
+			add("add element inet azure-npm %s-nomatch { 10.0.1.0/24 }", set.HashedName)
+		case TestKeyPodSet:
+			add("add element inet azure-npm %s { 10.0.0.1 }", set.HashedName)
+		case TestNamedportSet:
+			add("add element inet azure-npm %s { 10.0.0.1 . tcp . 8080 }", set.HashedName)
+		case TestNSSet:
+			add("add element inet azure-npm %s { 10.0.0.1, 10.0.0.2 }", set.HashedName)
+		}
+	}
+	add("flush set inet azure-npm %s", TestKeyNSList.HashedName)
+	add("add element inet azure-npm %s { 10.0.0.1, 10.0.0.2 }", TestKeyNSList.HashedName)
+	require.Equal(t, strings.Join(lines, "\n")+"\n", fake.LastTransaction())
+
+	// updating a member set rewrites the lists containing it
+	require.NoError(t, iMgr.RemoveFromSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.2", "b"))
+	require.NoError(t, iMgr.ApplyIPSets())
+	lines = nil
+	add("add set inet azure-npm %s %s", TestNSSet.HashedName, nftIPSetSpec)
+	add("delete element inet azure-npm %s { 10.0.0.2 }", TestNSSet.HashedName)
+	add("flush set inet azure-npm %s", TestKeyNSList.HashedName)
+	add("add element inet azure-npm %s { 10.0.0.1 }", TestKeyNSList.HashedName)
+	require.Equal(t, strings.Join(lines, "\n")+"\n", fake.LastTransaction())
+
+	// deleting a CIDR set deletes its nomatch set too
+	iMgr.DeleteIPSet(TestCIDRSet.PrefixName, util.ForceDelete)
+	require.NoError(t, iMgr.ApplyIPSets())
+	lines = nil
+	add("delete set inet azure-npm %s", TestCIDRSet.HashedName)
+	add("delete set inet azure-npm %s-nomatch", TestCIDRSet.HashedName)
+	require.Equal(t, strings.Join(lines, "\n")+"\n", fake.LastTransaction())
+}
+
+func TestNftApplyIPSetsFailure(t *testing.T) {
+	iMgr, fake := newNftIPSetManager(t)
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.1", "a"))
+
+	fake.FailNext(1)
+	require.Error(t, iMgr.ApplyIPSets())
+	require.Equal(t, 1, iMgr.consecutiveApplyFailures)
+
+	// the dirty cache is kept, so the next apply creates the set again
+	require.NoError(t, iMgr.ApplyIPSets())
+	require.Equal(t, 0, iMgr.consecutiveApplyFailures)
+	require.Contains(t, fake.LastTransaction(), fmt.Sprintf("add element inet azure-npm %s { 10.0.0.1 }", TestNSSet.HashedName))
+}
+
+func TestNftApplyIPSetsWithNftTransaction(t *testing.T) {
+	iMgr, fake := newNftIPSetManager(t)
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.1", "a"))
+	require.NoError(t, iMgr.ApplyIPSets())
+	iMgr.CreateIPSets([]*IPSetMetadata{TestKeyPodSet.Metadata})
+	iMgr.DeleteIPSet(TestNSSet.PrefixName, util.ForceDelete)
+
+	policyTx := nftables.NewTransaction()
+	policyTx.Add("add rule inet azure-npm AZURE-NPM-INGRESS ip daddr @%s accept", TestKeyPodSet.HashedName)
+
+	// the policy and the sets are applied together, and the dirty cache is kept on failure
+	fake.FailNext(1)
+	require.Error(t, iMgr.ApplyIPSetsWithNftTransaction(policyTx))
+	require.NoError(t, iMgr.ApplyIPSetsWithNftTransaction(policyTx))
+	require.Len(t, fake.Transactions, 2)
+
+	// the rule comes after the sets it references are created, and before unreferenced sets are deleted
+	lines := []string{
+		fmt.Sprintf("add set inet azure-npm %s %s", TestKeyPodSet.HashedName, nftIPSetSpec),
+		fmt.Sprintf("flush set inet azure-npm %s", TestKeyPodSet.HashedName),
+		fmt.Sprintf("add rule inet azure-npm AZURE-NPM-INGRESS ip daddr @%s accept", TestKeyPodSet.HashedName),
+		fmt.Sprintf("delete set inet azure-npm %s", TestNSSet.HashedName),
+	}
+	require.Equal(t, strings.Join(lines, "\n")+"\n", fake.LastTransaction())
+
+	// without dirty sets, only the policy is applied
+	require.NoError(t, iMgr.ApplyIPSetsWithNftTransaction(policyTx))
+	require.Equal(t, policyTx.String(), fake.LastTransaction())
+}
+
+func TestNftResetIPSets(t *testing.T) {
+	// only the ipsets left over from iptables are destroyed, and failing to do so isn't fatal
+	calls := []testutils.TestCmd{
+		{Cmd: []string{"ipset", "list", "--name"}, PipedToCommand: true, HasStartError: true, ExitCode: 1},
+		{Cmd: []string{"grep", "-q", "-v", "azure-npm-"}},
+		{Cmd: []string{"ipset", "list", "--name"}, PipedToCommand: true, HasStartError: true, ExitCode: 1},
+		{Cmd: []string{"grep", "azure-npm-"}},
+	}
+	ioShim := common.NewMockIOShim(calls)
+	defer ioShim.VerifyCalls(t, calls)
+	iMgr := NewIPSetManager(nftCfg, ioShim)
+	fake := nftables.NewFakeExecutor()
+	iMgr.nft = fake
+
+	require.NoError(t, iMgr.ResetIPSets())
+	require.Empty(t, fake.Transactions)
+}
@@ -1085,6 +1085,45 @@ func TestAddToList(t *testing.T) {
 	require.Equal(t, setMetadata.GetPrefixName(), set.MemberIPSets[setMetadata.GetPrefixName()].Name)
 }
 
+func TestGetAllIPSetSnapshots(t *testing.T) {
+	iMgr := NewIPSetManager(applyOnNeedCfg, common.NewMockIOShim([]testutils.TestCmd{}))
+	setMetadata := NewIPSetMetadata(testSetName, Namespace)
+	listMetadata := NewIPSetMetadata(testListName, KeyLabelOfNamespace)
+	iMgr.CreateIPSets([]*IPSetMetadata{setMetadata, listMetadata})
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{setMetadata}, testPodIP, testPodKey))
+	require.NoError(t, iMgr.AddToLists([]*IPSetMetadata{listMetadata}, []*IPSetMetadata{setMetadata}))
+	require.NoError(t, iMgr.AddReference(listMetadata, testNetPolKey, NetPolType))
+
+	snapshots := iMgr.GetAllIPSetSnapshots()
+	require.Equal(t, []*IPSetSnapshot{
+		{
+			Name:               setMetadata.GetPrefixName(),
+			UnprefixedName:     testSetName,
+			HashedName:         setMetadata.GetHashedName(),
+			Type:               Namespace,
+			Kind:               HashSet,
+			Members:            map[string]string{testPodIP: testPodKey},
+			SelectorReferences: []string{},
+			NetPolReferences:   []string{},
+			ReferCount:         1,
+		},
+		{
+			Name:               listMetadata.GetPrefixName(),
+			UnprefixedName:     testListName,
+			HashedName:         listMetadata.GetHashedName(),
+			Type:               KeyLabelOfNamespace,
+			Kind:               ListSet,
+			Members:            map[string]string{setMetadata.GetHashedName(): setMetadata.GetPrefixName()},
+			SelectorReferences: []string{},
+			NetPolReferences:   []string{testNetPolKey},
+		},
+	}, snapshots)
+
+	// snapshots are copies
+	snapshots[0].Members["10.0.0.1"] = "other-pod"
+	require.Len(t, iMgr.GetIPSet(setMetadata.GetPrefixName()).IPPodKey, 1)
+}
+
 func TestRemoveFromList(t *testing.T) {
 	iMgr := NewIPSetManager(applyOnNeedCfg, common.NewMockIOShim([]testutils.TestCmd{}))
 	setMetadata := NewIPSetMetadata(testSetName, Namespace)
@@ -1561,12 +1600,27 @@ func TestValidateIPSetMemberIP(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			got := validateIPSetMemberIP(tt.ipblock)
+			got := validateIPSetMemberIP(tt.ipblock, false)
 			require.Equal(t, tt.want, got)
 		})
 	}
 }
 
+func TestValidateIPSetMemberIPWithIPv6(t *testing.T) {
+	tests := map[string]bool{
+		"172.17.0.0/16":                     true,
+		"2001:db8::1":                       true,
+		"2001:db8::/64 nomatch":             true,
+		"2001:db8::1,tcp:25227":             true,
+		"2001:db8::/129":                    false,
+		"2001:db8::1::1":                    false,
+		"2345:0425:2CA1::0567:5673:23b5/24": true,
+	}
+	for ip, want := range tests {
+		require.Equal(t, want, validateIPSetMemberIP(ip, true), "unexpected result for %s", ip)
+	}
+}
+
 func assertExpectedInfo(t *testing.T, iMgr *IPSetManager, info *expectedInfo) {
 	// 1. assert cache contents
 	// 1.1. make sure the main cache is equal, including members and references
@@ -160,6 +160,20 @@ func (mr *MockGenericDataplaneMockRecorder) GetAllIPSets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllIPSets", reflect.TypeOf((*MockGenericDataplane)(nil).GetAllIPSets))
 }
 
+// GetAllIPSetSnapshots mocks base method.
+func (m *MockGenericDataplane) GetAllIPSetSnapshots() []*ipsets.IPSetSnapshot {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllIPSetSnapshots")
+	ret0, _ := ret[0].([]*ipsets.IPSetSnapshot)
+	return ret0
+}
+
+// GetAllIPSetSnapshots indicates an expected call of GetAllIPSetSnapshots.
+func (mr *MockGenericDataplaneMockRecorder) GetAllIPSetSnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllIPSetSnapshots", reflect.TypeOf((*MockGenericDataplane)(nil).GetAllIPSetSnapshots))
+}
+
 // GetAllPolicies mocks base method.
 func (m *MockGenericDataplane) GetAllPolicies() []string {
 	m.ctrl.T.Helper()
@@ -174,6 +188,20 @@ func (mr *MockGenericDataplaneMockRecorder) GetAllPolicies() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPolicies", reflect.TypeOf((*MockGenericDataplane)(nil).GetAllPolicies))
 }
 
+// GetAllPolicySnapshots mocks base method.
+func (m *MockGenericDataplane) GetAllPolicySnapshots() []*dataplane.PolicySnapshot {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllPolicySnapshots")
+	ret0, _ := ret[0].([]*dataplane.PolicySnapshot)
+	return ret0
+}
+
+// GetAllPolicySnapshots indicates an expected call of GetAllPolicySnapshots.
+func (mr *MockGenericDataplaneMockRecorder) GetAllPolicySnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPolicySnapshots", reflect.TypeOf((*MockGenericDataplane)(nil).GetAllPolicySnapshots))
+}
+
 // GetIPSet mocks base method.
 func (m *MockGenericDataplane) GetIPSet(setName string) *ipsets.IPSet {
 	m.ctrl.T.Helper()
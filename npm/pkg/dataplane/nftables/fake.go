@@ -0,0 +1,51 @@
+package nftables
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrFakeApplyFailure = errors.New("fake nft transaction failure")
+
+// FakeExecutor records the transactions it applies instead of running nft. It is meant for UTs.
+type FakeExecutor struct {
+	sync.Mutex
+	// Transactions are the scripts of the successfully applied transactions, in order.
+	Transactions []string
+	failures     int
+}
+
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{}
+}
+
+// FailNext makes the next n calls to Apply fail with ErrFakeApplyFailure without recording the transactions.
+func (f *FakeExecutor) FailNext(n int) {
+	f.Lock()
+	defer f.Unlock()
+	f.failures = n
+}
+
+func (f *FakeExecutor) Apply(tx *Transaction) error {
+	f.Lock()
+	defer f.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return ErrFakeApplyFailure
+	}
+	if tx.Len() == 0 {
+		return nil
+	}
+	f.Transactions = append(f.Transactions, tx.String())
+	return nil
+}
+
+// LastTransaction returns the script of the last successfully applied transaction, or the empty string.
+func (f *FakeExecutor) LastTransaction() string {
+	f.Lock()
+	defer f.Unlock()
+	if len(f.Transactions) == 0 {
+		return ""
+	}
+	return f.Transactions[len(f.Transactions)-1]
+}
@@ -0,0 +1,110 @@
+// Package nftables builds the nft scripts for NPM's nftables dataplane and applies each of them
+// atomically with a single call to nft.
+package nftables
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/common"
+)
+
+const (
+	// Family is the family of the NPM table. inet tables see both IPv4 and IPv6 traffic.
+	Family = "inet"
+	// Table is the name of the table holding all of NPM's sets, maps, and chains.
+	Table = "azure-npm"
+
+	nftCommand  = "nft"
+	nftFileFlag = "-f"
+	nftStdin    = "-"
+
+	// maxCommentLength is the kernel limit on the length of a rule comment (NFT_USERDATA_MAXLEN is 256, but nft
+	// rejects comments longer than 128 characters).
+	maxCommentLength = 128
+)
+
+// Transaction is an nft script. The kernel applies all of its commands, or none of them.
+type Transaction struct {
+	lines []string
+}
+
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+// Add appends a command to the transaction. Commands should reference the NPM table with TableSpec().
+func (tx *Transaction) Add(format string, args ...interface{}) {
+	tx.lines = append(tx.lines, fmt.Sprintf(format, args...))
+}
+
+// Append appends the commands of another transaction.
+func (tx *Transaction) Append(other *Transaction) {
+	tx.lines = append(tx.lines, other.lines...)
+}
+
+// Len returns the number of commands in the transaction.
+func (tx *Transaction) Len() int {
+	return len(tx.lines)
+}
+
+// String returns the script which is passed to nft.
+func (tx *Transaction) String() string {
+	if len(tx.lines) == 0 {
+		return ""
+	}
+	return strings.Join(tx.lines, "\n") + "\n"
+}
+
+// TableSpec is how commands reference the NPM table e.g. "add set inet azure-npm ...".
+func TableSpec() string {
+	return Family + " " + Table
+}
+
+// Comment returns a quoted rule comment, truncated to the length the kernel accepts.
+func Comment(comment string) string {
+	comment = strings.ReplaceAll(comment, `"`, "")
+	if len(comment) > maxCommentLength {
+		comment = comment[:maxCommentLength]
+	}
+	return `"` + comment + `"`
+}
+
+// Executor applies transactions.
+type Executor interface {
+	// Apply runs all commands in the transaction atomically. Empty transactions are a no-op.
+	Apply(tx *Transaction) error
+}
+
+// ExecutorFunc is a function which applies transactions, e.g. by adding other commands to them.
+type ExecutorFunc func(tx *Transaction) error
+
+func (f ExecutorFunc) Apply(tx *Transaction) error {
+	return f(tx)
+}
+
+type execExecutor struct {
+	ioShim *common.IOShim
+}
+
+// NewExecutor returns an Executor which pipes each transaction to "nft -f -".
+// In UTs, the ioShim can be a mock like for the iptables/ipset restore files.
+func NewExecutor(ioShim *common.IOShim) Executor {
+	return &execExecutor{ioShim: ioShim}
+}
+
+func (e *execExecutor) Apply(tx *Transaction) error {
+	script := tx.String()
+	if script == "" {
+		return nil
+	}
+	command := e.ioShim.Exec.Command(nftCommand, nftFileFlag, nftStdin)
+	command.SetStdin(bytes.NewBufferString(script))
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to apply nft transaction of %d commands. output: [%s]. err: %w",
+			tx.Len(), strings.TrimSuffix(string(output), "\n"), err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package nftables
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionString(t *testing.T) {
+	tx := NewTransaction()
+	require.Equal(t, "", tx.String())
+
+	tx.Add("add table %s", TableSpec())
+	tx.Add("add set %s %s { type ipv4_addr; }", TableSpec(), "azure-npm-123")
+	require.Equal(t, 2, tx.Len())
+	require.Equal(t, "add table inet azure-npm\nadd set inet azure-npm azure-npm-123 { type ipv4_addr; }\n", tx.String())
+
+	other := NewTransaction()
+	other.Add("delete table %s", TableSpec())
+	tx.Append(other)
+	require.Equal(t, 3, tx.Len())
+	require.Equal(t, 1, other.Len())
+}
+
+func TestComment(t *testing.T) {
+	require.Equal(t, `"ALLOW-ALL"`, Comment(`ALLOW-"ALL"`))
+	long := Comment(strings.Repeat("a", 200))
+	require.Len(t, long, maxCommentLength+2)
+}
+
+func TestExecutor(t *testing.T) {
+	calls := []testutils.TestCmd{
+		{Cmd: []string{"nft", "-f", "-"}},
+		{Cmd: []string{"nft", "-f", "-"}, ExitCode: 1, Stdout: "Error: No such file or directory"},
+	}
+	ioShim := common.NewMockIOShim(calls)
+	defer ioShim.VerifyCalls(t, calls)
+	e := NewExecutor(ioShim)
+
+	// an empty transaction doesn't run nft
+	require.NoError(t, e.Apply(NewTransaction()))
+
+	tx := NewTransaction()
+	tx.Add("add table %s", TableSpec())
+	require.NoError(t, e.Apply(tx))
+	err := e.Apply(tx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No such file or directory")
+}
+
+func TestFakeExecutor(t *testing.T) {
+	f := NewFakeExecutor()
+	tx := NewTransaction()
+	tx.Add("add table %s", TableSpec())
+
+	f.FailNext(1)
+	require.ErrorIs(t, f.Apply(tx), ErrFakeApplyFailure)
+	require.Equal(t, "", f.LastTransaction())
+
+	require.NoError(t, f.Apply(tx))
+	require.Equal(t, "add table inet azure-npm\n", f.LastTransaction())
+	require.Len(t, f.Transactions, 1)
+}
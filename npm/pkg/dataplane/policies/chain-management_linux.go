@@ -186,6 +186,10 @@ TODO: could use one grep call instead of separate calls for getting jump line nu
   - would use a grep pattern like so: <line num...AZURE-NPM>|<Chain AZURE-NPM>
 */
 func (pMgr *PolicyManager) bootup(_ []string) error {
+	if pMgr.UseNftables {
+		return pMgr.bootupNftables()
+	}
+
 	klog.Infof("booting up iptables Azure chains")
 
 	// 0.1. Detect iptables version
@@ -207,6 +211,14 @@ func (pMgr *PolicyManager) bootup(_ []string) error {
 		return err
 	}
 
+	if pMgr.EnableIPv6 {
+		// NPM has never programmed ip6tables with the other iptables version, so there is nothing to clean up
+		klog.Infof("booting up ip6tables Azure chains")
+		if err := pMgr.withIP6tables(pMgr.bootupAfterDetectAndCleanup); err != nil {
+			return npmerrors.SimpleErrorWrapper("failed to bootup ip6tables", err)
+		}
+	}
+
 	return nil
 }
 
@@ -221,7 +233,7 @@ func (pMgr *PolicyManager) bootupAfterDetectAndCleanup() error {
 			deprecatedErrCode, deprecatedErr.Error())
 	}
 
-	currentChains, err := ioutil.AllCurrentAzureChains(pMgr.ioShim.Exec, util.IptablesDefaultWaitTime)
+	currentChains, err := ioutil.AllCurrentAzureChains(pMgr.ioShim.Exec, pMgr.iptablesCmd(), util.IptablesDefaultWaitTime)
 	if err != nil {
 		return npmerrors.SimpleErrorWrapper("failed to get current chains for bootup", err)
 	}
@@ -230,7 +242,7 @@ func (pMgr *PolicyManager) bootupAfterDetectAndCleanup() error {
 
 	// 2. cleanup old NPM chains, and configure base chains and their rules.
 	creator := pMgr.creatorForBootup(currentChains)
-	if err := restore(creator); err != nil {
+	if err := pMgr.restore(creator); err != nil {
 		return npmerrors.SimpleErrorWrapper("failed to run iptables-restore for bootup", err)
 	}
 
@@ -267,20 +279,14 @@ func (pMgr *PolicyManager) detectIptablesVersion() error {
 
 func (pMgr *PolicyManager) hintOrCanaryChainExist(iptablesCmd string) bool {
 	// hint chain should exist since k8s 1.24 (see https://kubernetes.io/blog/2022/09/07/iptables-chains-not-api/#use-case-iptables-mode)
-	prevIptables := util.Iptables
-	util.Iptables = iptablesCmd
-	defer func() {
-		util.Iptables = prevIptables
-	}()
-
-	_, hintErr := pMgr.runIPTablesCommand(util.IptablesListFlag, listHintChainArgs...)
+	_, hintErr := pMgr.runIPTablesCommandWith(iptablesCmd, nil, util.IptablesListFlag, listHintChainArgs...)
 	if hintErr == nil {
 		metrics.SendLog(util.IptmID, "found hint chain. will use iptables version: %s"+iptablesCmd, metrics.DonotPrint)
 		return true
 	}
 
 	// check for canary chain
-	_, canaryErr := pMgr.runIPTablesCommand(util.IptablesListFlag, listCanaryChainArgs...)
+	_, canaryErr := pMgr.runIPTablesCommandWith(iptablesCmd, nil, util.IptablesListFlag, listCanaryChainArgs...)
 	if canaryErr != nil {
 		return false
 	}
@@ -340,7 +346,7 @@ func (pMgr *PolicyManager) cleanupOtherIptables() error {
 	}
 
 	// 2. get current chains
-	currentChains, err := ioutil.AllCurrentAzureChains(pMgr.ioShim.Exec, util.IptablesDefaultWaitTime)
+	currentChains, err := ioutil.AllCurrentAzureChains(pMgr.ioShim.Exec, pMgr.iptablesCmd(), util.IptablesDefaultWaitTime)
 	if err != nil {
 		return npmerrors.SimpleErrorWrapper("[cleanup] failed to get current chains for bootup", err)
 	}
@@ -368,7 +374,7 @@ func (pMgr *PolicyManager) cleanupOtherIptables() error {
 	}
 
 	creator := pMgr.creatorForCleanup(chains)
-	if err := restore(creator); err != nil {
+	if err := pMgr.restore(creator); err != nil {
 		msg := "[cleanup] failed to flush all chains with error: %s"
 		klog.Infof(msg, err.Error())
 		metrics.SendErrorLogAndMetric(util.IptmID, msg, err.Error())
@@ -455,14 +461,27 @@ func (pMgr *PolicyManager) creatorForCleanup(chains []string) *ioutil.FileCreato
 // - creates the jump rule from FORWARD chain to AZURE-NPM chain (if it does not exist) and makes sure it's after the jumps to KUBE-FORWARD & KUBE-SERVICES chains (if they exist).
 // - cleans up stale policy chains. It can be forced to stop this process if reconcileManager.forceLock() is called.
 func (pMgr *PolicyManager) reconcile() {
-	if err := pMgr.positionAzureChainJumpRule(); err != nil {
-		msg := fmt.Sprintf("failed to reconcile jump rule to Azure-NPM due to %s", err.Error())
-		metrics.SendErrorLogAndMetric(util.IptmID, "error: %s", msg)
-		klog.Error(msg)
+	if pMgr.UseNftables {
+		// policy chains are deleted in the same transaction which removes their policy, and the forward hook needs no repositioning
+		return
+	}
+
+	if !pMgr.EnableIPv6 {
+		pMgr.reconcileJumpRule()
 	}
 
 	pMgr.reconcileManager.Lock()
 	defer pMgr.reconcileManager.Unlock()
+
+	if pMgr.EnableIPv6 {
+		// reconcile the jumps while locked since programming ip6tables switches the PolicyManager's iptables commands
+		pMgr.reconcileJumpRule()
+		_ = pMgr.withIP6tables(func() error {
+			pMgr.reconcileJumpRule()
+			return nil
+		})
+	}
+
 	staleChains := pMgr.staleChains.emptyAndGetAll()
 
 	if len(staleChains) == 0 {
@@ -470,6 +489,24 @@ func (pMgr *PolicyManager) reconcile() {
 	}
 
 	klog.Infof("cleaning up these stale chains: %+v", staleChains)
+	pMgr.reconcileStaleChains(staleChains)
+	if pMgr.EnableIPv6 {
+		_ = pMgr.withIP6tables(func() error {
+			pMgr.reconcileStaleChains(staleChains)
+			return nil
+		})
+	}
+}
+
+func (pMgr *PolicyManager) reconcileJumpRule() {
+	if err := pMgr.positionAzureChainJumpRule(); err != nil {
+		msg := fmt.Sprintf("failed to reconcile jump rule to Azure-NPM due to %s", err.Error())
+		metrics.SendErrorLogAndMetric(util.IptmID, "error: %s", msg)
+		klog.Error(msg)
+	}
+}
+
+func (pMgr *PolicyManager) reconcileStaleChains(staleChains []string) {
 	if err := pMgr.cleanupChains(staleChains); err != nil {
 		msg := fmt.Sprintf("failed to clean up old policy chains with the following error: %s", err.Error())
 		metrics.SendErrorLogAndMetric(util.IptmID, "error: %s", msg)
@@ -517,12 +554,16 @@ func (pMgr *PolicyManager) runIPTablesCommand(operationFlag string, args ...stri
 }
 
 func (pMgr *PolicyManager) ignoreErrorsAndRunIPTablesCommand(ignored []*exitErrorInfo, operationFlag string, args ...string) (int, error) {
+	return pMgr.runIPTablesCommandWith(pMgr.iptablesCmd(), ignored, operationFlag, args...)
+}
+
+func (pMgr *PolicyManager) runIPTablesCommandWith(iptablesCmd string, ignored []*exitErrorInfo, operationFlag string, args ...string) (int, error) {
 	allArgs := []string{util.IptablesWaitFlag, util.IptablesDefaultWaitTime, operationFlag}
 	allArgs = append(allArgs, args...)
 
-	klog.Infof("executing iptables command [%s] with args %v", util.Iptables, allArgs)
+	klog.Infof("executing iptables command [%s] with args %v", iptablesCmd, allArgs)
 
-	command := pMgr.ioShim.Exec.Command(util.Iptables, allArgs...)
+	command := pMgr.ioShim.Exec.Command(iptablesCmd, allArgs...)
 	output, err := command.CombinedOutput()
 
 	var exitError utilexec.ExitError
@@ -532,14 +573,14 @@ func (pMgr *PolicyManager) ignoreErrorsAndRunIPTablesCommand(ignored []*exitErro
 		outputString := strings.TrimSuffix(string(output), "\n")
 		for _, info := range ignored {
 			if errCode == info.exitCode && strings.Contains(outputString, info.stdErr) {
-				klog.Infof("%s. not able to run iptables command [%s %s]. exit code: %d, output: %s", info.messageToLog, util.Iptables, allArgsString, errCode, outputString)
+				klog.Infof("%s. not able to run iptables command [%s %s]. exit code: %d, output: %s", info.messageToLog, iptablesCmd, allArgsString, errCode, outputString)
 				return errCode, nil
 			}
 		}
 		if errCode > 0 {
-			metrics.SendErrorLogAndMetric(util.IptmID, "error: There was an error running command: [%s %s] Stderr: [%v, %s]", util.Iptables, allArgsString, exitError, outputString)
+			metrics.SendErrorLogAndMetric(util.IptmID, "error: There was an error running command: [%s %s] Stderr: [%v, %s]", iptablesCmd, allArgsString, exitError, outputString)
 		}
-		return errCode, fmt.Errorf("failed to run iptables command [%s %s] Stderr: [%s]. err: [%w]", util.Iptables, allArgsString, outputString, exitError)
+		return errCode, fmt.Errorf("failed to run iptables command [%s %s] Stderr: [%s]. err: [%w]", iptablesCmd, allArgsString, outputString, exitError)
 	}
 	return 0, nil
 }
@@ -547,8 +588,9 @@ func (pMgr *PolicyManager) ignoreErrorsAndRunIPTablesCommand(ignored []*exitErro
 // Writes the restore file for bootup, and marks the following as stale: deprecated chains and old v2 policy chains.
 // This is a separate function to help with UTs.
 func (pMgr *PolicyManager) creatorForBootup(currentChains map[string]struct{}) *ioutil.FileCreator {
-	chainsToCreate := make([]string, 0, len(iptablesAzureChains))
-	for _, chain := range iptablesAzureChains {
+	baseChains := pMgr.baseChains()
+	chainsToCreate := make([]string, 0, len(baseChains))
+	for _, chain := range baseChains {
 		_, exists := currentChains[chain]
 		if !exists {
 			chainsToCreate = append(chainsToCreate, chain)
@@ -558,18 +600,36 @@ func (pMgr *PolicyManager) creatorForBootup(currentChains map[string]struct{}) *
 	// Step 2.1 in bootup() comment: cleanup old NPM chains, and configure base chains and their rules
 	// To leave NPM deactivated, don't specify any rules for AZURE-NPM chain.
 	creator := pMgr.newCreatorWithChains(chainsToCreate)
-	pMgr.staleChains.empty()
+	if !pMgr.ipv6 {
+		// stale chains are deleted from both iptables and ip6tables, so keep the ones found while booting up iptables
+		pMgr.staleChains.empty()
+	}
 	for chain := range currentChains {
 		creator.AddLine("", nil, fmt.Sprintf("-F %s", chain))
 		// Step 2.2 in bootup() comment: delete deprecated chains and old v2 policy chains in the background
 		pMgr.staleChains.add(chain) // won't add base chains
 	}
+	if pMgr.EnableAdminNetworkPolicy {
+		// the tier chains are only base chains when tiers are enabled, so they're added above
+		for _, chain := range iptablesTierChains {
+			pMgr.staleChains.remove(chain)
+		}
+	}
 
 	// add AZURE-NPM-INGRESS chain rules
+	if pMgr.EnableAdminNetworkPolicy {
+		creator.AddLine("", nil, util.IptablesAppendFlag, util.IptablesAzureIngressChain, util.IptablesJumpFlag, util.IptablesAzureAdminIngressChain)
+	}
+	if pMgr.EnableDenyFlowLogging {
+		creator.AddLine("", nil, pMgr.nflogOnMarkSpecs(util.IptablesAzureIngressChain, util.IptablesAzureIngressDropMarkHex, util.NflogIngressDropPrefix, "INGRESS")...)
+	}
 	ingressDropSpecs := []string{util.IptablesAppendFlag, util.IptablesAzureIngressChain, util.IptablesJumpFlag, util.IptablesDrop}
 	ingressDropSpecs = append(ingressDropSpecs, onMarkSpecs(util.IptablesAzureIngressDropMarkHex)...)
 	ingressDropSpecs = append(ingressDropSpecs, commentSpecs(fmt.Sprintf("DROP-ON-INGRESS-DROP-MARK-%s", util.IptablesAzureIngressDropMarkHex))...)
 	creator.AddLine("", nil, ingressDropSpecs...)
+	if pMgr.EnableAdminNetworkPolicy {
+		creator.AddLine("", nil, util.IptablesAppendFlag, util.IptablesAzureIngressChain, util.IptablesJumpFlag, util.IptablesAzureBaselineIngressChain)
+	}
 
 	// add AZURE-NPM-INGRESS-ALLOW-MARK chain
 	markIngressAllowSpecs := []string{util.IptablesAppendFlag, util.IptablesAzureIngressAllowMarkChain}
@@ -579,10 +639,19 @@ func (pMgr *PolicyManager) creatorForBootup(currentChains map[string]struct{}) *
 	creator.AddLine("", nil, util.IptablesAppendFlag, util.IptablesAzureIngressAllowMarkChain, util.IptablesJumpFlag, util.IptablesAzureEgressChain)
 
 	// add AZURE-NPM-EGRESS chain rules
+	if pMgr.EnableAdminNetworkPolicy {
+		creator.AddLine("", nil, util.IptablesAppendFlag, util.IptablesAzureEgressChain, util.IptablesJumpFlag, util.IptablesAzureAdminEgressChain)
+	}
+	if pMgr.EnableDenyFlowLogging {
+		creator.AddLine("", nil, pMgr.nflogOnMarkSpecs(util.IptablesAzureEgressChain, util.IptablesAzureEgressDropMarkHex, util.NflogEgressDropPrefix, "EGRESS")...)
+	}
 	egressDropSpecs := []string{util.IptablesAppendFlag, util.IptablesAzureEgressChain, util.IptablesJumpFlag, util.IptablesDrop}
 	egressDropSpecs = append(egressDropSpecs, onMarkSpecs(util.IptablesAzureEgressDropMarkHex)...)
 	egressDropSpecs = append(egressDropSpecs, commentSpecs(fmt.Sprintf("DROP-ON-EGRESS-DROP-MARK-%s", util.IptablesAzureEgressDropMarkHex))...)
 	creator.AddLine("", nil, egressDropSpecs...)
+	if pMgr.EnableAdminNetworkPolicy {
+		creator.AddLine("", nil, util.IptablesAppendFlag, util.IptablesAzureEgressChain, util.IptablesJumpFlag, util.IptablesAzureBaselineEgressChain)
+	}
 
 	jumpOnIngressMatchSpecs := []string{util.IptablesAppendFlag, util.IptablesAzureEgressChain, util.IptablesJumpFlag, util.IptablesAzureAcceptChain}
 	jumpOnIngressMatchSpecs = append(jumpOnIngressMatchSpecs, onMarkSpecs(util.IptablesAzureIngressAllowMarkHex)...)
@@ -672,7 +741,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 // returns 0 if the chain does not exist
 // this function has a direct comparison in NPM v1 iptables manager (iptm.go)
 func (pMgr *PolicyManager) chainLineNumber(chain string) (int, error) {
-	listForwardEntriesCommand := pMgr.ioShim.Exec.Command(util.Iptables, listForwardEntriesArgs...)
+	listForwardEntriesCommand := pMgr.ioShim.Exec.Command(pMgr.iptablesCmd(), listForwardEntriesArgs...)
 	grepCommand := pMgr.ioShim.Exec.Command(ioutil.Grep, chain)
 	searchResults, gotMatches, err := ioutil.PipeCommandToGrep(listForwardEntriesCommand, grepCommand)
 	if err != nil {
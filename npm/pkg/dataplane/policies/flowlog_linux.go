@@ -0,0 +1,68 @@
+package policies
+
+// This file contains code for logging denied flows to NFLOG (PolicyManagerCfg.EnableDenyFlowLogging).
+// The flowlog package reads the NFLOG group and attributes each packet to the policies which denied it.
+//
+// NetworkPolicies only set a drop mark, and the packet is dropped in AZURE-NPM-INGRESS/EGRESS once no policy chain allowed it,
+// so the log rule before the drop on mark only identifies the direction.
+// Policies in the AdminTier and BaselineTier drop in the tier chains, so the log rule before each of their drop rules also identifies the policy.
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	"github.com/Azure/azure-container-networking/npm/util"
+)
+
+// denyFlowLogPrefix returns the NFLOG prefix for packets dropped by a policy in the AdminTier or BaselineTier.
+func denyFlowLogPrefix(direction UniqueDirection, policyKey string) string {
+	prefix := util.NflogEgressDropPrefix
+	if direction == forIngress {
+		prefix = util.NflogIngressDropPrefix
+	}
+	return joinWithDash(prefix, util.Hash(policyKey))
+}
+
+// nflogOnMarkSpecs returns the rule logging packets with the drop mark before the chain drops them.
+func (pMgr *PolicyManager) nflogOnMarkSpecs(chainName, mark, prefix, direction string) []string {
+	specs := []string{util.IptablesAppendFlag, chainName}
+	specs = append(specs, pMgr.nflogSpecs(prefix)...)
+	specs = append(specs, onMarkSpecs(mark)...)
+	specs = append(specs, pMgr.nflogLimitSpecs()...)
+	specs = append(specs, commentSpecs(fmt.Sprintf("LOG-ON-%s-DROP-MARK-%s", direction, mark))...)
+	return specs
+}
+
+// nflogSpecs returns the target logging to the deny flow NFLOG group. Any matches must come after it.
+func (pMgr *PolicyManager) nflogSpecs(prefix string) []string {
+	return []string{
+		util.IptablesJumpFlag,
+		util.IptablesNflog,
+		util.IptablesNflogGroupFlag,
+		strconv.Itoa(int(pMgr.DenyFlowLogGroup)),
+		util.IptablesNflogPrefixFlag,
+		prefix,
+	}
+}
+
+func (pMgr *PolicyManager) nflogLimitSpecs() []string {
+	if pMgr.DenyFlowLogRatePerSecond <= 0 {
+		return []string{}
+	}
+	return []string{
+		util.IptablesModuleFlag,
+		util.IptablesLimitModuleFlag,
+		util.IptablesLimitFlag,
+		fmt.Sprintf("%d/second", pMgr.DenyFlowLogRatePerSecond),
+	}
+}
+
+// nftLogStatement returns the statement logging to the deny flow NFLOG group.
+func (pMgr *PolicyManager) nftLogStatement(prefix string) string {
+	statement := fmt.Sprintf("log prefix %s group %d", nftables.Comment(prefix), pMgr.DenyFlowLogGroup)
+	if pMgr.DenyFlowLogRatePerSecond <= 0 {
+		return statement
+	}
+	return fmt.Sprintf("limit rate %d/second %s", pMgr.DenyFlowLogRatePerSecond, statement)
+}
@@ -0,0 +1,82 @@
+package policies
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	dptestutils "github.com/Azure/azure-container-networking/npm/pkg/dataplane/testutils"
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/stretchr/testify/require"
+)
+
+func denyFlowLogConfig(cfg *PolicyManagerCfg) *PolicyManagerCfg {
+	withLogging := *cfg
+	withLogging.EnableDenyFlowLogging = true
+	withLogging.DenyFlowLogGroup = 100
+	withLogging.DenyFlowLogRatePerSecond = 10
+	return &withLogging
+}
+
+func TestCreatorForBootupWithDenyFlowLogging(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), denyFlowLogConfig(ipsetConfig))
+	creator := pMgr.creatorForBootup(map[string]struct{}{})
+	actualLines := strings.Split(creator.ToString(), "\n")
+	expectedLines := []string{
+		"*filter",
+		":AZURE-NPM - -",
+		":AZURE-NPM-INGRESS - -",
+		":AZURE-NPM-INGRESS-ALLOW-MARK - -",
+		":AZURE-NPM-EGRESS - -",
+		":AZURE-NPM-ACCEPT - -",
+		"-A AZURE-NPM-INGRESS -j NFLOG --nflog-group 100 --nflog-prefix AZURE-NPM-INGRESS-DROP -m mark --mark 0x400/0x400 -m limit --limit 10/second -m comment --comment LOG-ON-INGRESS-DROP-MARK-0x400/0x400",
+		"-A AZURE-NPM-INGRESS -j DROP -m mark --mark 0x400/0x400 -m comment --comment DROP-ON-INGRESS-DROP-MARK-0x400/0x400",
+		"-A AZURE-NPM-INGRESS-ALLOW-MARK -j MARK --set-mark 0x200/0x200 -m comment --comment SET-INGRESS-ALLOW-MARK-0x200/0x200",
+		"-A AZURE-NPM-INGRESS-ALLOW-MARK -j AZURE-NPM-EGRESS",
+		"-A AZURE-NPM-EGRESS -j NFLOG --nflog-group 100 --nflog-prefix AZURE-NPM-EGRESS-DROP -m mark --mark 0x800/0x800 -m limit --limit 10/second -m comment --comment LOG-ON-EGRESS-DROP-MARK-0x800/0x800",
+		"-A AZURE-NPM-EGRESS -j DROP -m mark --mark 0x800/0x800 -m comment --comment DROP-ON-EGRESS-DROP-MARK-0x800/0x800",
+		"-A AZURE-NPM-EGRESS -j AZURE-NPM-ACCEPT -m mark --mark 0x200/0x200 -m comment --comment ACCEPT-ON-INGRESS-ALLOW-MARK-0x200/0x200",
+		"-A AZURE-NPM-ACCEPT -j ACCEPT",
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+}
+
+func TestTierRulesWithDenyFlowLogging(t *testing.T) {
+	cfg := denyFlowLogConfig(tierConfig)
+	cfg.DenyFlowLogRatePerSecond = 0
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), cfg)
+	baselinePolicy := testBaselinePolicy(egressDeniedAllACL)
+
+	creator := pMgr.newCreatorWithChains(tierChainNames([]Tier{BaselineTier}))
+	pMgr.writeTierRules(creator, BaselineTier, []*NPMNetworkPolicy{baselinePolicy})
+	actualLines := strings.Split(creator.ToString(), "\n")
+	expectedLines := []string{
+		"*filter",
+		":AZURE-NPM-BASELINE-INGRESS - -",
+		":AZURE-NPM-BASELINE-EGRESS - -",
+		fmt.Sprintf("-A AZURE-NPM-BASELINE-EGRESS -j NFLOG --nflog-group 100 --nflog-prefix AZURE-NPM-EGRESS-DROP-%s -m comment --comment LOG-BASELINE/default-ON-all-DROP-ALL",
+			util.Hash(baselinePolicy.PolicyKey)),
+		"-A AZURE-NPM-BASELINE-EGRESS -j DROP -m comment --comment BASELINE/default-ON-all-DROP-ALL",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+}
+
+func TestNftDispatchChainsWithDenyFlowLogging(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), denyFlowLogConfig(nftConfig))
+	tx := nftables.NewTransaction()
+	pMgr.writeNftDispatchChains(tx, nil)
+
+	lines := append([]string{}, nftFlushDispatchRules...)
+	lines = append(lines,
+		`add rule inet azure-npm AZURE-NPM-INGRESS meta mark & 0x400 == 0x400 limit rate 10/second log prefix "AZURE-NPM-INGRESS-DROP" group 100 comment "LOG-ON-INGRESS-DROP-MARK-0x400/0x400"`,
+		nftIngressDropRule,
+		`add rule inet azure-npm AZURE-NPM-EGRESS meta mark & 0x800 == 0x800 limit rate 10/second log prefix "AZURE-NPM-EGRESS-DROP" group 100 comment "LOG-ON-EGRESS-DROP-MARK-0x800/0x800"`,
+		nftEgressVmapRule,
+	)
+	require.Equal(t, nftScript(lines...), tx.String())
+}
@@ -29,6 +29,11 @@ type NPMNetworkPolicy struct {
 	// and not from pod selector IPSets, including children of a NestedLabelOfPod ipset
 	RuleIPSets []*ipsets.TranslatedIPSet
 	ACLs       []*ACLPolicy
+	// Tier is NetworkPolicyTier for NetworkPolicies, or AdminTier/BaselineTier for cluster-scoped policies.
+	// ACLs are evaluated in the order AdminTier, NetworkPolicyTier, BaselineTier.
+	Tier Tier
+	// Priority orders the policies within AdminTier. Lower values are evaluated first.
+	Priority int32
 	// podIP is key and endpoint ID as value
 	// Will be populated by dataplane and policy manager
 	PodEndpoints map[string]string
@@ -42,6 +47,22 @@ func NewNPMNetworkPolicy(netPolName, netPolNamespace string) *NPMNetworkPolicy {
 	}
 }
 
+// NewNPMTieredPolicy creates a cluster-scoped policy in the AdminTier or BaselineTier.
+// The PolicyKey is "<tier>/<name>" so that it can't conflict with a NetworkPolicy's key.
+func NewNPMTieredPolicy(tier Tier, name string, priority int32) *NPMNetworkPolicy {
+	return &NPMNetworkPolicy{
+		PolicyKey:   fmt.Sprintf("%s/%s", tier, name),
+		ACLPolicyID: aclPolicyID(string(tier), name),
+		Tier:        tier,
+		Priority:    priority,
+	}
+}
+
+// IsTiered returns true for AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+func (netPol *NPMNetworkPolicy) IsTiered() bool {
+	return netPol.Tier != NetworkPolicyTier
+}
+
 func (netPol *NPMNetworkPolicy) HasCIDRRules() bool {
 	for _, set := range netPol.RuleIPSets {
 		if set.Metadata.Type == ipsets.CIDRBlocks {
@@ -98,7 +119,11 @@ PodSelectorIPSets: %s
 PodSelectorList: %s
 ACLs:
 %s`
-	return fmt.Sprintf(format, netPol.PolicyKey, podSelectorIPSetString, podSelectorListString, aclArrayString)
+	prettyString := fmt.Sprintf(format, netPol.PolicyKey, podSelectorIPSetString, podSelectorListString, aclArrayString)
+	if netPol.IsTiered() {
+		prettyString = fmt.Sprintf("Tier: %s  Priority: %d\n%s", netPol.Tier, netPol.Priority, prettyString)
+	}
+	return prettyString
 }
 
 // ACLPolicy equivalent to a single iptable rule in linux
@@ -145,10 +170,16 @@ func NormalizePolicy(networkPolicy *NPMNetworkPolicy) {
 }
 
 func ValidatePolicy(networkPolicy *NPMNetworkPolicy) error {
+	if !networkPolicy.hasKnownTier() {
+		return npmerrors.SimpleError(fmt.Sprintf("NetPol %s has unknown tier [%s]", networkPolicy.PolicyKey, networkPolicy.Tier))
+	}
 	for _, aclPolicy := range networkPolicy.ACLs {
 		if !aclPolicy.hasKnownTarget() {
 			return npmerrors.SimpleError(fmt.Sprintf("ACL policy for NetPol %s has unknown target [%s]", networkPolicy.PolicyKey, aclPolicy.Target))
 		}
+		if aclPolicy.Target == Passed && networkPolicy.Tier != AdminTier {
+			return npmerrors.SimpleError(fmt.Sprintf("ACL policy for NetPol %s has target [%s], which is only valid in tier [%s]", networkPolicy.PolicyKey, Passed, AdminTier))
+		}
 		if !aclPolicy.hasKnownDirection() {
 			return npmerrors.SimpleError(fmt.Sprintf("ACL policy for NetPol %s has unknown direction [%s]", networkPolicy.PolicyKey, aclPolicy.Direction))
 		}
@@ -237,7 +268,13 @@ func (aclPolicy *ACLPolicy) hasKnownProtocol() bool {
 }
 
 func (aclPolicy *ACLPolicy) hasKnownTarget() bool {
-	return aclPolicy.Target == Allowed || aclPolicy.Target == Dropped
+	return aclPolicy.Target == Allowed || aclPolicy.Target == Dropped || aclPolicy.Target == Passed
+}
+
+func (netPol *NPMNetworkPolicy) hasKnownTier() bool {
+	return netPol.Tier == NetworkPolicyTier ||
+		netPol.Tier == AdminTier ||
+		netPol.Tier == BaselineTier
 }
 
 func (aclPolicy *ACLPolicy) satisifiesPortAndProtocolConstraints() bool {
@@ -345,6 +382,21 @@ const (
 	Allowed Verdict = "ALLOW"
 	// Dropped is denying a flow
 	Dropped Verdict = "DROP"
+	// Passed skips the rest of the AdminTier so that the flow is evaluated by the lower tiers.
+	// It's only valid in AdminTier.
+	Passed Verdict = "PASS"
+)
+
+// Tier groups policies that are evaluated together.
+type Tier string
+
+const (
+	// NetworkPolicyTier holds namespace-scoped NetworkPolicies
+	NetworkPolicyTier Tier = ""
+	// AdminTier holds AdminNetworkPolicies, which are evaluated before NetworkPolicies
+	AdminTier Tier = "ADMIN"
+	// BaselineTier holds the BaselineAdminNetworkPolicy, which is evaluated after NetworkPolicies
+	BaselineTier Tier = "BASELINE"
 )
 
 // Protocol can be TCP, UDP, SCTP, or unspecified since they are currently supported in networkpolicy.
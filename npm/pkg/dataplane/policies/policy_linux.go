@@ -75,6 +75,19 @@ func (networkPolicy *NPMNetworkPolicy) commentForJump(direction UniqueDirection)
 	return fmt.Sprintf("%s-POLICY-%s-%s-%s-IN-ns-%s", prefix, networkPolicy.PolicyKey, toFrom, podSelectorComment, networkPolicy.Namespace)
 }
 
+// commentForTierRule returns the comment for an ACL of a policy in the AdminTier or BaselineTier,
+// which includes the policy and its subject since there is no jump to a policy chain.
+func (networkPolicy *NPMNetworkPolicy) commentForTierRule(aclPolicy *ACLPolicy) string {
+	subjectComment := "all"
+	if len(networkPolicy.PodSelectorList) > 0 {
+		subjectComment = commentForInfos(networkPolicy.PodSelectorList)
+	}
+	if networkPolicy.Tier == AdminTier {
+		return fmt.Sprintf("%s-PRIORITY-%d-ON-%s-%s", networkPolicy.PolicyKey, networkPolicy.Priority, subjectComment, aclPolicy.comment())
+	}
+	return fmt.Sprintf("%s-ON-%s-%s", networkPolicy.PolicyKey, subjectComment, aclPolicy.comment())
+}
+
 func commentForInfos(infos []SetInfo) string {
 	infoComments := make([]string, 0, len(infos))
 	for _, info := range infos {
@@ -91,13 +104,16 @@ func (info SetInfo) comment() string {
 	return "!" + name
 }
 
-func (info SetInfo) matchSetSpecs(matchString string) []string {
+func (info SetInfo) matchSetSpecs(matchString string, ipv6 bool) []string {
 	specs := make([]string, 0, maxLengthForMatchSetSpecs)
 	specs = append(specs, util.IptablesModuleFlag, util.IptablesSetModuleFlag)
 	if !info.Included {
 		specs = append(specs, util.IptablesNotFlag)
 	}
 	hashedSetName := info.IPSet.GetHashedName()
+	if ipv6 {
+		hashedSetName = ipsets.IPv6SetName(hashedSetName)
+	}
 	specs = append(specs, util.IptablesMatchSetFlag, hashedSetName, matchString)
 	return specs
 }
@@ -128,9 +144,12 @@ func (aclPolicy *ACLPolicy) comment() string {
 	}
 
 	builder := strings.Builder{}
-	if aclPolicy.Target == Allowed {
+	switch aclPolicy.Target {
+	case Allowed:
 		builder.WriteString("ALLOW")
-	} else {
+	case Passed:
+		builder.WriteString("PASS")
+	default:
 		builder.WriteString("DROP")
 	}
 
@@ -13,6 +13,15 @@ const (
 	blockRulePriotity = 3000
 	allowRulePriotity = 222
 	policyIDPrefix    = "azure-acl"
+
+	// AdminTier ACLs are evaluated before NetworkPolicy ACLs and the Calico wireserver ACL (see baseACLsForCalicoCNI).
+	// Each AdminNetworkPolicy gets a block of priorities based on its priority so that its rules are evaluated in order.
+	adminRulePriorityStart    = 1
+	adminRulePriorityEnd      = 199
+	maxAdminRulesPerDirection = 10
+	// BaselineTier ACLs are evaluated after NetworkPolicy ACLs and before the base allow ACLs.
+	baselineRulePriorityStart = 4000
+	baselineRulePriorityEnd   = 65000
 )
 
 var (
@@ -27,6 +36,8 @@ var (
 	ErrNamedPortsNotSupported     = errors.New("Named Port translation is not supported in windows dataplane")
 	ErrNegativeMatchsNotSupported = errors.New("Negative match types is not supported in windows dataplane")
 	ErrProtocolNotSupported       = errors.New("Protocol mentioned is not supported")
+	ErrPassActionNotSupported     = errors.New("Pass action is not supported in windows dataplane")
+	ErrTierPriorityNotSupported   = errors.New("AdminNetworkPolicy priority or number of rules is too large for windows dataplane")
 )
 
 // aclPolicyID returns azure-acl-<network policy namespace>-<network policy name> format
@@ -156,6 +167,27 @@ func (acl *ACLPolicy) convertToAclSettings(aclID string) (*NPMACLPolSettings, er
 	return policySettings, nil
 }
 
+// tierRulePriority returns the HNS priority for a policy in the AdminTier or BaselineTier,
+// where ruleIndex is the index of the ACL among the policy's ACLs with the same direction.
+func (netPol *NPMNetworkPolicy) tierRulePriority(ruleIndex int) (uint16, error) {
+	if netPol.Tier == BaselineTier {
+		priority := baselineRulePriorityStart + ruleIndex
+		if priority > baselineRulePriorityEnd {
+			return 0, ErrTierPriorityNotSupported
+		}
+		return uint16(priority), nil
+	}
+
+	if ruleIndex >= maxAdminRulesPerDirection {
+		return 0, ErrTierPriorityNotSupported
+	}
+	priority := adminRulePriorityStart + int(netPol.Priority)*maxAdminRulesPerDirection + ruleIndex
+	if priority > adminRulePriorityEnd {
+		return 0, ErrTierPriorityNotSupported
+	}
+	return uint16(priority), nil
+}
+
 func (acl *ACLPolicy) checkIPSets() bool {
 	for _, set := range acl.SrcList {
 		if set.IPSet.Type == ipsets.NamedPorts {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
 	"github.com/Azure/azure-container-networking/npm/util"
 	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
 	"k8s.io/klog"
@@ -26,6 +27,8 @@ const (
 	// it represents the number of rules unrelated to policies
 	// it's technically 3 off when there are no policies since we flush the AZURE-NPM chain then
 	numLinuxBaseACLRules = 11
+	// the number of jumps to the tier chains, which are added to the base rules when AdminNetworkPolicies are enabled
+	numLinuxTierBaseACLRules = 4
 )
 
 type PolicyManagerCfg struct {
@@ -35,6 +38,18 @@ type PolicyManagerCfg struct {
 	PolicyMode PolicyManagerMode
 	// PlaceAzureChainFirst only affects Linux
 	PlaceAzureChainFirst bool
+	// UseNftables programs the policies in the NPM nftables table instead of in iptables. Only affects Linux.
+	UseNftables bool
+	// EnableIPv6 programs each policy in ip6tables too, matching the IPv6 twins of the ipsets. Only affects Linux with iptables.
+	EnableIPv6 bool
+	// EnableAdminNetworkPolicy allows policies in the AdminTier and BaselineTier.
+	// In Linux, it also adds the tier chains to the base chains.
+	EnableAdminNetworkPolicy bool
+	// EnableDenyFlowLogging logs packets dropped by NPM to the NFLOG group DenyFlowLogGroup. Only affects Linux.
+	EnableDenyFlowLogging bool
+	DenyFlowLogGroup      uint16
+	// DenyFlowLogRatePerSecond limits the packets logged by each NFLOG rule. Zero means no limit.
+	DenyFlowLogRatePerSecond int
 	// MaxBatchedACLsPerPod is the maximum number of ACLs that can be added to a Pod at once in Windows.
 	// The zero value is valid.
 	// A NetworkPolicy's ACLs are always in the same batch, and there will be at least one NetworkPolicy per batch.
@@ -62,11 +77,17 @@ type PolicyManager struct {
 	ioShim           *common.IOShim
 	staleChains      *staleChains
 	reconcileManager *reconcileManager
+	// nft applies the NPM table at bootup when UseNftables is true
+	nft nftables.Executor
+	// nftPolicies applies the transactions which add and remove policies when UseNftables is true
+	nftPolicies nftables.Executor
+	// ipv6 is true while programming ip6tables (see withIP6tables)
+	ipv6 bool
 	*PolicyManagerCfg
 }
 
 func NewPolicyManager(ioShim *common.IOShim, cfg *PolicyManagerCfg) *PolicyManager {
-	return &PolicyManager{
+	pMgr := &PolicyManager{
 		policyMap: &PolicyMap{
 			cache: make(map[string]*NPMNetworkPolicy),
 		},
@@ -77,6 +98,9 @@ func NewPolicyManager(ioShim *common.IOShim, cfg *PolicyManagerCfg) *PolicyManag
 		},
 		PolicyManagerCfg: cfg,
 	}
+	pMgr.nft = nftables.NewExecutor(ioShim)
+	pMgr.nftPolicies = pMgr.nft
+	return pMgr
 }
 
 func (pMgr *PolicyManager) ResetEndpoint(epID string) error {
@@ -97,6 +121,9 @@ func (pMgr *PolicyManager) Bootup(epIDs []string) error {
 	if !util.IsWindowsDP() {
 		// update Prometheus metrics on success
 		metrics.IncNumACLRulesBy(numLinuxBaseACLRules)
+		if pMgr.EnableAdminNetworkPolicy {
+			metrics.IncNumACLRulesBy(numLinuxTierBaseACLRules)
+		}
 	}
 
 	if util.IsWindowsDP() && pMgr.NodeIP == "" {
@@ -126,6 +153,24 @@ func (pMgr *PolicyManager) GetPolicy(policyKey string) (*NPMNetworkPolicy, bool)
 	return policy, ok
 }
 
+// GetAllPolicies returns shallow copies of the policies in the cache.
+// The PodEndpoints are copied since they're modified when Pods are updated in Windows.
+func (pMgr *PolicyManager) GetAllPolicies() []*NPMNetworkPolicy {
+	pMgr.policyMap.RLock()
+	defer pMgr.policyMap.RUnlock()
+
+	result := make([]*NPMNetworkPolicy, 0, len(pMgr.policyMap.cache))
+	for _, policy := range pMgr.policyMap.cache {
+		policyCopy := *policy
+		policyCopy.PodEndpoints = make(map[string]string, len(policy.PodEndpoints))
+		for podIP, endpointID := range policy.PodEndpoints {
+			policyCopy.PodEndpoints[podIP] = endpointID
+		}
+		result = append(result, &policyCopy)
+	}
+	return result
+}
+
 func (pMgr *PolicyManager) AddPolicies(policies []*NPMNetworkPolicy, endpointList map[string]string) error {
 	nonEmptyPolicies := make([]*NPMNetworkPolicy, 0, len(policies))
 	for _, policy := range policies {
@@ -142,6 +187,12 @@ func (pMgr *PolicyManager) AddPolicies(policies []*NPMNetworkPolicy, endpointLis
 			metrics.SendErrorLogAndMetric(util.IptmID, "error: %s", msg)
 			return npmerrors.Errorf(npmerrors.AddPolicy, false, msg)
 		}
+
+		if policy.IsTiered() && !pMgr.EnableAdminNetworkPolicy {
+			msg := fmt.Sprintf("failed to validate policy: policy %s is in tier [%s] but AdminNetworkPolicies are disabled", policy.PolicyKey, policy.Tier)
+			metrics.SendErrorLogAndMetric(util.IptmID, "error: %s", msg)
+			return npmerrors.Errorf(npmerrors.AddPolicy, false, msg)
+		}
 	}
 
 	if len(nonEmptyPolicies) == 0 {
@@ -0,0 +1,32 @@
+package policies
+
+// This file contains code for programming policies in ip6tables as well as iptables (PolicyManagerCfg.EnableIPv6).
+
+import "github.com/Azure/azure-container-networking/npm/util"
+
+// withIP6tables runs f with the PolicyManager's iptables commands set to their ip6tables equivalents.
+// While f runs, policy rules match the IPv6 twins of the ipsets (see ipsets.IPv6SetName).
+// The caller must hold the reconcileManager's lock so that no other PolicyManager operation runs at the same time.
+func (pMgr *PolicyManager) withIP6tables(f func() error) error {
+	pMgr.ipv6 = true
+	defer func() {
+		pMgr.ipv6 = false
+	}()
+	return f()
+}
+
+// iptablesCmd returns the iptables binary for the address family being programmed.
+func (pMgr *PolicyManager) iptablesCmd() string {
+	if pMgr.ipv6 {
+		return util.Ip6tables
+	}
+	return util.Iptables
+}
+
+// iptablesRestoreCmd returns the iptables-restore binary for the address family being programmed.
+func (pMgr *PolicyManager) iptablesRestoreCmd() string {
+	if pMgr.ipv6 {
+		return util.Ip6tablesRestore
+	}
+	return util.IptablesRestore
+}
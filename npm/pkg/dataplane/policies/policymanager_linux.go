@@ -42,22 +42,37 @@ Known errors that we should retry on:
 */
 
 func (pMgr *PolicyManager) addPolicies(networkPolicies []*NPMNetworkPolicy, _ map[string]string) error {
+	if pMgr.UseNftables {
+		return pMgr.addNftPolicies(networkPolicies)
+	}
+
 	// 1. Add rules for the network policies and activate NPM (if necessary).
 	chainsToCreate := chainNames(networkPolicies)
-	creator := pMgr.creatorForNewNetworkPolicies(chainsToCreate, networkPolicies)
 
 	// Stop reconciling so we don't contend for iptables, and so reconcile doesn't delete chainsToCreate.
 	pMgr.reconcileManager.forceLock()
 	defer pMgr.reconcileManager.forceUnlock()
 
-	timer := metrics.StartNewTimer()
-	err := restore(creator)
-	metrics.RecordIPTablesRestoreLatency(timer, metrics.CreateOp)
-	if err != nil {
-		metrics.IncIPTablesRestoreFailures(metrics.CreateOp)
+	if err := pMgr.restoreNewNetworkPolicies(chainsToCreate, networkPolicies); err != nil {
 		return fmt.Errorf("failed to restore iptables with updated policies. err: %w", err)
 	}
 
+	if pMgr.EnableIPv6 {
+		err := pMgr.withIP6tables(func() error {
+			return pMgr.restoreNewNetworkPolicies(chainsToCreate, networkPolicies)
+		})
+		if err != nil {
+			// Delete the iptables jumps to the new policy chains so that retrying doesn't add duplicate jumps.
+			// The policy chains are flushed when they're declared in the next restore file.
+			for _, networkPolicy := range networkPolicies {
+				if deleteErr := pMgr.deleteOldJumpRulesOnRemove(networkPolicy); deleteErr != nil {
+					klog.Errorf("failed to delete iptables jumps for policy %s after failing to restore ip6tables. err: %s", networkPolicy.PolicyKey, deleteErr.Error())
+				}
+			}
+			return fmt.Errorf("failed to restore ip6tables with updated policies. err: %w", err)
+		}
+	}
+
 	// 2. Make sure the new chains don't get deleted in the background
 	for _, chain := range chainsToCreate {
 		pMgr.staleChains.remove(chain)
@@ -66,13 +81,51 @@ func (pMgr *PolicyManager) addPolicies(networkPolicies []*NPMNetworkPolicy, _ ma
 }
 
 func (pMgr *PolicyManager) removePolicy(networkPolicy *NPMNetworkPolicy, _ map[string]string) error {
+	if pMgr.UseNftables {
+		return pMgr.removeNftPolicy(networkPolicy)
+	}
+
 	chainsToDelete := chainNames([]*NPMNetworkPolicy{networkPolicy})
-	creator := pMgr.creatorForRemovingPolicies(chainsToDelete)
 
 	// Stop reconciling so we don't contend for iptables, and so we don't update the staleChains at the same time as reconcile()
 	pMgr.reconcileManager.forceLock()
 	defer pMgr.reconcileManager.forceUnlock()
 
+	if err := pMgr.removePolicyRules(networkPolicy, chainsToDelete); err != nil {
+		return err
+	}
+
+	if pMgr.EnableIPv6 {
+		err := pMgr.withIP6tables(func() error {
+			return pMgr.removePolicyRules(networkPolicy, chainsToDelete)
+		})
+		if err != nil {
+			// retrying is safe since the iptables rules are already removed, and deleting missing jumps is ignored
+			return fmt.Errorf("failed to remove policy from ip6tables. err: %w", err)
+		}
+	}
+
+	// 3. Delete policy chains in the background.
+	for _, chain := range chainsToDelete {
+		pMgr.staleChains.add(chain)
+	}
+	return nil
+}
+
+func (pMgr *PolicyManager) restoreNewNetworkPolicies(chainsToCreate []string, networkPolicies []*NPMNetworkPolicy) error {
+	creator := pMgr.creatorForNewNetworkPolicies(chainsToCreate, networkPolicies)
+	timer := metrics.StartNewTimer()
+	err := pMgr.restore(creator)
+	metrics.RecordIPTablesRestoreLatency(timer, metrics.CreateOp)
+	if err != nil {
+		metrics.IncIPTablesRestoreFailures(metrics.CreateOp)
+		return err
+	}
+	return nil
+}
+
+// removePolicyRules does steps 1 and 2 of removePolicy().
+func (pMgr *PolicyManager) removePolicyRules(networkPolicy *NPMNetworkPolicy, chainsToDelete []string) error {
 	// 1. Delete jump rules from ingress/egress chains to ingress/egress policy chains.
 	// We ought to delete these jump rules here in the foreground since if we add an NP back after deleting, iptables-restore --noflush can add duplicate jump rules.
 	deleteErr := pMgr.deleteOldJumpRulesOnRemove(networkPolicy)
@@ -81,23 +134,23 @@ func (pMgr *PolicyManager) removePolicy(networkPolicy *NPMNetworkPolicy, _ map[s
 	}
 
 	// 2. Flush the policy chains and deactivate NPM (if necessary).
+	// Policies in a tier don't have their own chains, so the tier's chains are rewritten instead.
+	creator := pMgr.creatorForRemovingPolicies(chainsToDelete)
+	if networkPolicy.IsTiered() {
+		creator = pMgr.creatorForRemovingTieredPolicy(networkPolicy)
+	}
 	timer := metrics.StartNewTimer()
-	restoreErr := restore(creator)
+	restoreErr := pMgr.restore(creator)
 	metrics.RecordIPTablesRestoreLatency(timer, metrics.DeleteOp)
 	if restoreErr != nil {
 		metrics.IncIPTablesRestoreFailures(metrics.DeleteOp)
 		return fmt.Errorf("failed to flush policies. err: %w", restoreErr)
 	}
-
-	// 3. Delete policy chains in the background.
-	for _, chain := range chainsToDelete {
-		pMgr.staleChains.add(chain)
-	}
 	return nil
 }
 
-func restore(creator *ioutil.FileCreator) error {
-	err := creator.RunCommandWithFile(util.IptablesRestore, util.IptablesWaitFlag, util.IptablesDefaultWaitTime, util.IptablesRestoreTableFlag, util.IptablesFilterTable, util.IptablesRestoreNoFlushFlag)
+func (pMgr *PolicyManager) restore(creator *ioutil.FileCreator) error {
+	err := creator.RunCommandWithFile(pMgr.iptablesRestoreCmd(), util.IptablesWaitFlag, util.IptablesDefaultWaitTime, util.IptablesRestoreTableFlag, util.IptablesFilterTable, util.IptablesRestoreNoFlushFlag)
 	if err != nil {
 		return fmt.Errorf("failed to restore iptables file. err: %w", err)
 	}
@@ -120,10 +173,14 @@ func (pMgr *PolicyManager) creatorForRemovingPolicies(allChainNames []string) *i
 	return creator
 }
 
-// returns ingress and egress chain names for the policies
+// returns ingress and egress chain names for the policies.
+// Policies in the AdminTier and BaselineTier don't have their own chains.
 func chainNames(networkPolicies []*NPMNetworkPolicy) []string {
 	chainNames := make([]string, 0)
 	for _, networkPolicy := range networkPolicies {
+		if networkPolicy.IsTiered() {
+			continue
+		}
 		hasIngress, hasEgress := networkPolicy.hasIngressAndEgress()
 
 		if hasIngress {
@@ -152,6 +209,10 @@ func (pMgr *PolicyManager) newCreatorWithChains(chainNames []string) *ioutil.Fil
 
 // will make a similar func for on update eventually
 func (pMgr *PolicyManager) deleteOldJumpRulesOnRemove(policy *NPMNetworkPolicy) error {
+	if policy.IsTiered() {
+		// there are no jumps to policies in a tier
+		return nil
+	}
 	shouldDeleteIngress, shouldDeleteEgress := policy.hasIngressAndEgress()
 	if shouldDeleteIngress {
 		if err := pMgr.deleteJumpRule(policy, true); err != nil {
@@ -171,11 +232,11 @@ func (pMgr *PolicyManager) deleteJumpRule(policy *NPMNetworkPolicy, direction Un
 	var baseChainName string
 	var chainName string
 	if direction == forIngress {
-		specs = ingressJumpSpecs(policy)
+		specs = ingressJumpSpecs(policy, pMgr.ipv6)
 		baseChainName = util.IptablesAzureIngressChain
 		chainName = policy.ingressChainName()
 	} else {
-		specs = egressJumpSpecs(policy)
+		specs = egressJumpSpecs(policy, pMgr.ipv6)
 		baseChainName = util.IptablesAzureEgressChain
 		chainName = policy.egressChainName()
 	}
@@ -193,24 +254,28 @@ func (pMgr *PolicyManager) deleteJumpRule(policy *NPMNetworkPolicy, direction Un
 	return nil
 }
 
-func ingressJumpSpecs(networkPolicy *NPMNetworkPolicy) []string {
+func ingressJumpSpecs(networkPolicy *NPMNetworkPolicy, ipv6 bool) []string {
 	chainName := networkPolicy.ingressChainName()
 	specs := []string{util.IptablesJumpFlag, chainName}
-	specs = append(specs, matchSetSpecsForNetworkPolicy(networkPolicy, DstMatch)...)
+	specs = append(specs, matchSetSpecsForNetworkPolicy(networkPolicy, DstMatch, ipv6)...)
 	specs = append(specs, commentSpecs(networkPolicy.commentForJumpToIngress())...)
 	return specs
 }
 
-func egressJumpSpecs(networkPolicy *NPMNetworkPolicy) []string {
+func egressJumpSpecs(networkPolicy *NPMNetworkPolicy, ipv6 bool) []string {
 	chainName := networkPolicy.egressChainName()
 	specs := []string{util.IptablesJumpFlag, chainName}
-	specs = append(specs, matchSetSpecsForNetworkPolicy(networkPolicy, SrcMatch)...)
+	specs = append(specs, matchSetSpecsForNetworkPolicy(networkPolicy, SrcMatch, ipv6)...)
 	specs = append(specs, commentSpecs(networkPolicy.commentForJumpToEgress())...)
 	return specs
 }
 
 func (pMgr *PolicyManager) creatorForNewNetworkPolicies(policyChains []string, networkPolicies []*NPMNetworkPolicy) *ioutil.FileCreator {
-	creator := pMgr.newCreatorWithChains(policyChains)
+	tiers := tiersOf(networkPolicies)
+	allChains := make([]string, 0, len(policyChains)+2*len(tiers))
+	allChains = append(allChains, policyChains...)
+	allChains = append(allChains, tierChainNames(tiers)...)
+	creator := pMgr.newCreatorWithChains(allChains)
 
 	// 1. Activate NPM if necessary
 	if pMgr.isFirstPolicy() {
@@ -221,31 +286,40 @@ func (pMgr *PolicyManager) creatorForNewNetworkPolicies(policyChains []string, n
 	}
 
 	// 2. Add all rules for the network policies
-	ingressJumpLineNumber := 1
-	egressJumpLineNumber := 1
+	ingressJumpLineNumber := pMgr.firstJumpLineNumber()
+	egressJumpLineNumber := pMgr.firstJumpLineNumber()
 	for _, networkPolicy := range networkPolicies {
+		if networkPolicy.IsTiered() {
+			continue
+		}
+
 		// 2.1 add all rules for the policy chain(s)
-		writeNetworkPolicyRules(creator, networkPolicy)
+		writeNetworkPolicyRules(creator, networkPolicy, pMgr.ipv6)
 
 		// 2.2 add jump rule(s) to the policy chain(s)
 		hasIngress, hasEgress := networkPolicy.hasIngressAndEgress()
 		if hasIngress {
-			ingressJumpSpecs := insertSpecs(util.IptablesAzureIngressChain, ingressJumpLineNumber, ingressJumpSpecs(networkPolicy))
+			ingressJumpSpecs := insertSpecs(util.IptablesAzureIngressChain, ingressJumpLineNumber, ingressJumpSpecs(networkPolicy, pMgr.ipv6))
 			creator.AddLine("", nil, ingressJumpSpecs...) // TODO error handler
 			ingressJumpLineNumber++
 		}
 		if hasEgress {
-			egressJumpSpecs := insertSpecs(util.IptablesAzureEgressChain, egressJumpLineNumber, egressJumpSpecs(networkPolicy))
+			egressJumpSpecs := insertSpecs(util.IptablesAzureEgressChain, egressJumpLineNumber, egressJumpSpecs(networkPolicy, pMgr.ipv6))
 			creator.AddLine("", nil, egressJumpSpecs...) // TODO error handler
 			egressJumpLineNumber++
 		}
 	}
+
+	// 3. Rewrite the chains of any tier with a new policy
+	for _, tier := range tiers {
+		pMgr.writeTierRules(creator, tier, pMgr.tierPolicies(tier, networkPolicies, ""))
+	}
 	creator.AddLine("", nil, util.IptablesRestoreCommit)
 	return creator
 }
 
 // write rules for the policy chain(s)
-func writeNetworkPolicyRules(creator *ioutil.FileCreator, networkPolicy *NPMNetworkPolicy) {
+func writeNetworkPolicyRules(creator *ioutil.FileCreator, networkPolicy *NPMNetworkPolicy, ipv6 bool) {
 	for _, aclPolicy := range networkPolicy.ACLs {
 		var chainName string
 		var actionSpecs []string
@@ -266,20 +340,25 @@ func writeNetworkPolicyRules(creator *ioutil.FileCreator, networkPolicy *NPMNetw
 		}
 		line := []string{"-A", chainName}
 		line = append(line, actionSpecs...)
-		line = append(line, iptablesRuleSpecs(aclPolicy)...)
+		line = append(line, iptablesRuleSpecs(aclPolicy, ipv6)...)
 		creator.AddLine("", nil, line...) // TODO add error handler
 	}
 }
 
-func iptablesRuleSpecs(aclPolicy *ACLPolicy) []string {
+func iptablesRuleSpecs(aclPolicy *ACLPolicy, ipv6 bool) []string {
+	specs := iptablesMatchSpecs(aclPolicy, ipv6)
+	specs = append(specs, commentSpecs(aclPolicy.comment())...)
+	return specs
+}
+
+func iptablesMatchSpecs(aclPolicy *ACLPolicy, ipv6 bool) []string {
 	specs := make([]string, 0)
 	if aclPolicy.Protocol != UnspecifiedProtocol {
 		specs = append(specs, util.IptablesProtFlag, string(aclPolicy.Protocol))
 	}
 	specs = append(specs, dstPortSpecs(aclPolicy.DstPorts)...)
-	specs = append(specs, matchSetSpecsFromSetInfo(aclPolicy.SrcList)...)
-	specs = append(specs, matchSetSpecsFromSetInfo(aclPolicy.DstList)...)
-	specs = append(specs, commentSpecs(aclPolicy.comment())...)
+	specs = append(specs, matchSetSpecsFromSetInfo(aclPolicy.SrcList, ipv6)...)
+	specs = append(specs, matchSetSpecsFromSetInfo(aclPolicy.DstList, ipv6)...)
 	return specs
 }
 
@@ -290,20 +369,20 @@ func dstPortSpecs(portRange Ports) []string {
 	return []string{util.IptablesDstPortFlag, portRange.toIPTablesString()}
 }
 
-func matchSetSpecsForNetworkPolicy(networkPolicy *NPMNetworkPolicy, matchType MatchType) []string {
+func matchSetSpecsForNetworkPolicy(networkPolicy *NPMNetworkPolicy, matchType MatchType, ipv6 bool) []string {
 	specs := make([]string, 0, maxLengthForMatchSetSpecs*len(networkPolicy.PodSelectorList))
 	matchString := matchType.toIPTablesString()
 	for _, setInfo := range networkPolicy.PodSelectorList {
-		specs = append(specs, setInfo.matchSetSpecs(matchString)...)
+		specs = append(specs, setInfo.matchSetSpecs(matchString, ipv6)...)
 	}
 	return specs
 }
 
-func matchSetSpecsFromSetInfo(setInfoList []SetInfo) []string {
+func matchSetSpecsFromSetInfo(setInfoList []SetInfo, ipv6 bool) []string {
 	specs := make([]string, 0, maxLengthForMatchSetSpecs*len(setInfoList))
 	for _, setInfo := range setInfoList {
 		matchString := setInfo.MatchType.toIPTablesString()
-		specs = append(specs, setInfo.matchSetSpecs(matchString)...)
+		specs = append(specs, setInfo.matchSetSpecs(matchString, ipv6)...)
 	}
 	return specs
 }
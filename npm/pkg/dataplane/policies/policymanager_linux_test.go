@@ -435,3 +435,106 @@ func TestUpdatingStaleChains(t *testing.T) {
 	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{bothDirectionsNetPol}, nil))
 	assertStaleChainsContain(t, pMgr.staleChains, egressNetPolChain)
 }
+
+var (
+	fakeIP6TablesRestoreCommand        = testutils.TestCmd{Cmd: []string{"ip6tables-nft-restore", "-w", "60", "-T", "filter", "--noflush"}}
+	fakeIP6TablesRestoreFailureCommand = testutils.TestCmd{Cmd: []string{"ip6tables-nft-restore", "-w", "60", "-T", "filter", "--noflush"}, ExitCode: 1}
+
+	ipv6Config = &PolicyManagerCfg{
+		NodeIP:               "6.7.8.9",
+		PolicyMode:           IPSetPolicyMode,
+		PlaceAzureChainFirst: util.PlaceAzureChainFirst,
+		EnableIPv6:           true,
+	}
+)
+
+func TestCreatorForAddPoliciesWithIPv6(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), ipv6Config)
+	policies := []*NPMNetworkPolicy{egressNetPol}
+
+	var actualLines []string
+	require.NoError(t, pMgr.withIP6tables(func() error {
+		creator := pMgr.creatorForNewNetworkPolicies(chainNames(policies), policies)
+		actualLines = strings.Split(creator.ToString(), "\n")
+		return nil
+	}))
+	require.False(t, pMgr.ipv6)
+	require.Equal(t, util.IptablesRestoreNft, util.IptablesRestore)
+
+	expectedLines := []string{
+		"*filter",
+		fmt.Sprintf(":%s - -", egressNetPolChain),
+		"-F AZURE-NPM",
+		"-A AZURE-NPM -j AZURE-NPM-INGRESS",
+		"-A AZURE-NPM -j AZURE-NPM-EGRESS",
+		"-A AZURE-NPM -j AZURE-NPM-ACCEPT",
+		fmt.Sprintf(
+			"-A %s -j AZURE-NPM-ACCEPT -m set --match-set %s dst -m comment --comment %s",
+			egressNetPolChain,
+			ipsets.IPv6SetName(ipsets.TestNamedportSet.HashedName),
+			egressAllowComment,
+		),
+		fmt.Sprintf("-I AZURE-NPM-EGRESS 1 %s", egressNetPolJump),
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+}
+
+// the ip6tables commands are picked per PolicyManager so that other readers of the global iptables commands are unaffected
+func TestWithIP6tablesLeavesGlobalCommands(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), ipv6Config)
+	require.NoError(t, pMgr.withIP6tables(func() error {
+		require.Equal(t, util.Ip6tablesNft, pMgr.iptablesCmd())
+		require.Equal(t, util.Ip6tablesRestoreNft, pMgr.iptablesRestoreCmd())
+		require.Equal(t, util.IptablesNft, util.Iptables)
+		require.Equal(t, util.IptablesRestoreNft, util.IptablesRestore)
+		return nil
+	}))
+	require.Equal(t, util.IptablesNft, pMgr.iptablesCmd())
+	require.Equal(t, util.IptablesRestoreNft, pMgr.iptablesRestoreCmd())
+}
+
+func TestAddAndRemovePolicyWithIPv6(t *testing.T) {
+	metrics.ReinitializeAll()
+	calls := []testutils.TestCmd{
+		fakeIPTablesRestoreCommand,
+		fakeIP6TablesRestoreCommand,
+		getFakeDeleteJumpCommand("AZURE-NPM-EGRESS", egressNetPolJump),
+		fakeIPTablesRestoreCommand,
+		{Cmd: append([]string{"ip6tables-nft", "-w", "60", "-D", "AZURE-NPM-EGRESS"}, strings.Split(egressNetPolJump, " ")...)},
+		fakeIP6TablesRestoreCommand,
+	}
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	pMgr := NewPolicyManager(ioshim, ipv6Config)
+
+	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{egressNetPol}, nil))
+	_, ok := pMgr.GetPolicy(egressNetPol.PolicyKey)
+	require.True(t, ok)
+
+	require.NoError(t, pMgr.RemovePolicy(egressNetPol.PolicyKey))
+	_, ok = pMgr.GetPolicy(egressNetPol.PolicyKey)
+	require.False(t, ok)
+	assertStaleChainsContain(t, pMgr.staleChains, egressNetPolChain)
+}
+
+// if ip6tables fails, the iptables jumps should be removed so that a retry doesn't add duplicate jumps
+func TestAddPolicyWithIPv6Failure(t *testing.T) {
+	metrics.ReinitializeAll()
+	calls := []testutils.TestCmd{
+		fakeIPTablesRestoreCommand,
+		fakeIP6TablesRestoreFailureCommand,
+		fakeIP6TablesRestoreFailureCommand,
+		getFakeDeleteJumpCommand("AZURE-NPM-EGRESS", egressNetPolJump),
+	}
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	pMgr := NewPolicyManager(ioshim, ipv6Config)
+
+	require.Error(t, pMgr.AddPolicies([]*NPMNetworkPolicy{egressNetPol}, nil))
+	_, ok := pMgr.GetPolicy(egressNetPol.PolicyKey)
+	require.False(t, ok)
+	require.False(t, pMgr.ipv6)
+	require.Equal(t, util.IptablesNft, util.Iptables)
+}
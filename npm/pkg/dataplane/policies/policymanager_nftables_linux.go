@@ -0,0 +1,338 @@
+package policies
+
+// This file contains code for the nftables implementation of booting up and adding/removing policies (PolicyManagerCfg.UseNftables).
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	"github.com/Azure/azure-container-networking/npm/util"
+	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
+	"k8s.io/klog"
+)
+
+const (
+	nftForwardChain = "forward"
+	// the forward chain is placed relative to iptables' FORWARD chain, which has the filter priority
+	nftPriorityBeforeIptables = "filter - 1"
+	nftPriorityAfterIptables  = "filter + 1"
+)
+
+/*
+bootupNftables cleans up NPM's iptables chains and recreates the NPM table with its base chains. The table looks like so:
+
+	table inet azure-npm {
+		chain forward {
+			type filter hook forward priority filter + 1; policy accept;
+			ct state new jump AZURE-NPM
+		}
+		chain AZURE-NPM {
+			# empty until there is a policy, like for iptables
+			jump AZURE-NPM-INGRESS
+			jump AZURE-NPM-EGRESS
+			jump AZURE-NPM-ACCEPT
+		}
+		chain AZURE-NPM-INGRESS {
+			ip daddr @azure-npm-111 ip daddr @azure-npm-222 jump AZURE-NPM-INGRESS-123 comment "..."
+			meta mark & 0x400 == 0x400 drop comment "DROP-ON-INGRESS-DROP-MARK-0x400/0x400"
+		}
+		chain AZURE-NPM-INGRESS-ALLOW-MARK {
+			meta mark set meta mark | 0x200 comment "SET-INGRESS-ALLOW-MARK-0x200/0x200"
+			jump AZURE-NPM-EGRESS
+		}
+		chain AZURE-NPM-EGRESS {
+			ip saddr @azure-npm-111 jump AZURE-NPM-EGRESS-456 comment "..."
+			meta mark & 0xa00 vmap { 0x800 : drop, 0xa00 : drop, 0x200 : jump AZURE-NPM-ACCEPT }
+		}
+		chain AZURE-NPM-ACCEPT {
+			accept
+		}
+	}
+
+The sets of the IPSetManager live in the same table since rules can only reference sets in their own table.
+*/
+func (pMgr *PolicyManager) bootupNftables() error {
+	klog.Infof("booting up nftables table %s", nftables.TableSpec())
+
+	// NPM may have run with iptables before
+	if err := pMgr.cleanupIptables(); err != nil {
+		return npmerrors.SimpleErrorWrapper("failed to cleanup iptables chains", err)
+	}
+
+	tx := nftables.NewTransaction()
+	table := nftables.TableSpec()
+	// adding the table first makes deleting it succeed even if it doesn't exist
+	tx.Add("add table %s", table)
+	tx.Add("delete table %s", table)
+	tx.Add("add table %s", table)
+
+	priority := nftPriorityAfterIptables
+	if pMgr.PlaceAzureChainFirst == util.PlaceAzureChainFirst {
+		priority = nftPriorityBeforeIptables
+	}
+	tx.Add("add chain %s %s { type filter hook forward priority %s; policy accept; }", table, nftForwardChain, priority)
+	for _, chain := range iptablesAzureChains {
+		tx.Add("add chain %s %s", table, chain)
+	}
+	tx.Add("add rule %s %s ct state new jump %s", table, nftForwardChain, util.IptablesAzureChain)
+
+	tx.Add("add rule %s %s meta mark set meta mark | %s comment %s", table, util.IptablesAzureIngressAllowMarkChain,
+		nftMark(util.IptablesAzureIngressAllowMarkHex),
+		nftables.Comment("SET-INGRESS-ALLOW-MARK-"+util.IptablesAzureIngressAllowMarkHex))
+	tx.Add("add rule %s %s jump %s", table, util.IptablesAzureIngressAllowMarkChain, util.IptablesAzureEgressChain)
+	tx.Add("add rule %s %s accept", table, util.IptablesAzureAcceptChain)
+
+	pMgr.writeNftDispatchChains(tx, nil)
+
+	if err := pMgr.nft.Apply(tx); err != nil {
+		return npmerrors.SimpleErrorWrapper("failed to create nftables table for bootup", err)
+	}
+	return nil
+}
+
+// SetNftPolicyExecutor sets the Executor for the transactions which add and remove policies,
+// e.g. to apply them together with the IPSetManager's dirty sets. Bootup still uses the PolicyManager's own Executor.
+func (pMgr *PolicyManager) SetNftPolicyExecutor(e nftables.Executor) {
+	pMgr.nftPolicies = e
+}
+
+// cleanupIptables deletes NPM's chains in both legacy and nft iptables.
+func (pMgr *PolicyManager) cleanupIptables() error {
+	hadNFT := util.Iptables == util.IptablesNft
+	defer func() {
+		if hadNFT {
+			util.SetIptablesToNft()
+		} else {
+			util.SetIptablesToLegacy()
+		}
+	}()
+
+	// cleanupOtherIptables() cleans up the iptables version which util.Iptables isn't set to
+	util.SetIptablesToLegacy()
+	if err := pMgr.cleanupOtherIptables(); err != nil {
+		return err
+	}
+	util.SetIptablesToNft()
+	return pMgr.cleanupOtherIptables()
+}
+
+func (pMgr *PolicyManager) addNftPolicies(networkPolicies []*NPMNetworkPolicy) error {
+	// policies in the cache keep their rules. AddPolicies updates the cache after this call succeeds.
+	activePolicies := make(map[string]*NPMNetworkPolicy, len(pMgr.policyMap.cache)+len(networkPolicies))
+	for key, policy := range pMgr.policyMap.cache {
+		activePolicies[key] = policy
+	}
+
+	tx := nftables.NewTransaction()
+	table := nftables.TableSpec()
+	for _, networkPolicy := range networkPolicies {
+		activePolicies[networkPolicy.PolicyKey] = networkPolicy
+		for _, chain := range chainNames([]*NPMNetworkPolicy{networkPolicy}) {
+			tx.Add("add chain %s %s", table, chain)
+			tx.Add("flush chain %s %s", table, chain)
+		}
+		writeNftNetworkPolicyRules(tx, networkPolicy)
+	}
+	pMgr.writeNftDispatchChains(tx, activePolicies)
+
+	timer := metrics.StartNewTimer()
+	err := pMgr.nftPolicies.Apply(tx)
+	metrics.RecordIPTablesRestoreLatency(timer, metrics.CreateOp)
+	if err != nil {
+		metrics.IncIPTablesRestoreFailures(metrics.CreateOp)
+		return fmt.Errorf("failed to apply nft transaction with updated policies. err: %w", err)
+	}
+	return nil
+}
+
+func (pMgr *PolicyManager) removeNftPolicy(networkPolicy *NPMNetworkPolicy) error {
+	activePolicies := make(map[string]*NPMNetworkPolicy, len(pMgr.policyMap.cache))
+	for key, policy := range pMgr.policyMap.cache {
+		if key != networkPolicy.PolicyKey {
+			activePolicies[key] = policy
+		}
+	}
+
+	// remove the jumps to the policy chains before deleting them
+	tx := nftables.NewTransaction()
+	table := nftables.TableSpec()
+	pMgr.writeNftDispatchChains(tx, activePolicies)
+	for _, chain := range chainNames([]*NPMNetworkPolicy{networkPolicy}) {
+		// adding the chain first makes deleting it succeed even if it doesn't exist
+		tx.Add("add chain %s %s", table, chain)
+		tx.Add("flush chain %s %s", table, chain)
+		tx.Add("delete chain %s %s", table, chain)
+	}
+
+	timer := metrics.StartNewTimer()
+	err := pMgr.nftPolicies.Apply(tx)
+	metrics.RecordIPTablesRestoreLatency(timer, metrics.DeleteOp)
+	if err != nil {
+		metrics.IncIPTablesRestoreFailures(metrics.DeleteOp)
+		return fmt.Errorf("failed to apply nft transaction to remove policy. err: %w", err)
+	}
+	return nil
+}
+
+// writeNftDispatchChains rewrites the AZURE-NPM, AZURE-NPM-INGRESS, and AZURE-NPM-EGRESS chains for the policies.
+// NPM is deactivated (AZURE-NPM is empty) if there are no policies.
+func (pMgr *PolicyManager) writeNftDispatchChains(tx *nftables.Transaction, policies map[string]*NPMNetworkPolicy) {
+	table := nftables.TableSpec()
+	tx.Add("flush chain %s %s", table, util.IptablesAzureChain)
+	tx.Add("flush chain %s %s", table, util.IptablesAzureIngressChain)
+	tx.Add("flush chain %s %s", table, util.IptablesAzureEgressChain)
+
+	if len(policies) > 0 {
+		tx.Add("add rule %s %s jump %s", table, util.IptablesAzureChain, util.IptablesAzureIngressChain)
+		tx.Add("add rule %s %s jump %s", table, util.IptablesAzureChain, util.IptablesAzureEgressChain)
+		tx.Add("add rule %s %s jump %s", table, util.IptablesAzureChain, util.IptablesAzureAcceptChain)
+	}
+
+	// sorted for determinism in UTs. The order doesn't matter since any allow wins.
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		networkPolicy := policies[key]
+		hasIngress, hasEgress := networkPolicy.hasIngressAndEgress()
+		if hasIngress {
+			tx.Add("add rule %s %s %s", table, util.IptablesAzureIngressChain, nftRule(
+				nftMatchSetsForNetworkPolicy(networkPolicy, DstMatch),
+				"jump "+networkPolicy.ingressChainName(),
+				networkPolicy.commentForJumpToIngress(),
+			))
+		}
+		if hasEgress {
+			tx.Add("add rule %s %s %s", table, util.IptablesAzureEgressChain, nftRule(
+				nftMatchSetsForNetworkPolicy(networkPolicy, SrcMatch),
+				"jump "+networkPolicy.egressChainName(),
+				networkPolicy.commentForJumpToEgress(),
+			))
+		}
+	}
+
+	ingressDropMark := nftMark(util.IptablesAzureIngressDropMarkHex)
+	if pMgr.EnableDenyFlowLogging {
+		tx.Add("add rule %s %s meta mark & %s == %s %s comment %s", table, util.IptablesAzureIngressChain, ingressDropMark, ingressDropMark,
+			pMgr.nftLogStatement(util.NflogIngressDropPrefix), nftables.Comment("LOG-ON-INGRESS-DROP-MARK-"+util.IptablesAzureIngressDropMarkHex))
+	}
+	tx.Add("add rule %s %s meta mark & %s == %s drop comment %s", table, util.IptablesAzureIngressChain, ingressDropMark, ingressDropMark,
+		nftables.Comment("DROP-ON-INGRESS-DROP-MARK-"+util.IptablesAzureIngressDropMarkHex))
+
+	// one lookup for the egress verdict: drop on the egress drop mark, otherwise accept on the ingress allow mark
+	egressDropMark := nftMark(util.IptablesAzureEgressDropMarkHex)
+	ingressAllowMark := nftMark(util.IptablesAzureIngressAllowMarkHex)
+	if pMgr.EnableDenyFlowLogging {
+		tx.Add("add rule %s %s meta mark & %s == %s %s comment %s", table, util.IptablesAzureEgressChain, egressDropMark, egressDropMark,
+			pMgr.nftLogStatement(util.NflogEgressDropPrefix), nftables.Comment("LOG-ON-EGRESS-DROP-MARK-"+util.IptablesAzureEgressDropMarkHex))
+	}
+	bothMarks := nftMarkOr(egressDropMark, ingressAllowMark)
+	tx.Add("add rule %s %s meta mark & %s vmap { %s : drop, %s : drop, %s : jump %s }", table, util.IptablesAzureEgressChain,
+		bothMarks, egressDropMark, bothMarks, ingressAllowMark, util.IptablesAzureAcceptChain)
+}
+
+// write rules for the policy chain(s)
+func writeNftNetworkPolicyRules(tx *nftables.Transaction, networkPolicy *NPMNetworkPolicy) {
+	table := nftables.TableSpec()
+	for _, aclPolicy := range networkPolicy.ACLs {
+		var chainName string
+		var verdict string
+		if aclPolicy.hasIngress() {
+			chainName = networkPolicy.ingressChainName()
+			if aclPolicy.Target == Allowed {
+				verdict = "jump " + util.IptablesAzureIngressAllowMarkChain
+			} else {
+				verdict = "meta mark set meta mark | " + nftMark(util.IptablesAzureIngressDropMarkHex)
+			}
+		} else {
+			chainName = networkPolicy.egressChainName()
+			if aclPolicy.Target == Allowed {
+				verdict = "jump " + util.IptablesAzureAcceptChain
+			} else {
+				verdict = "meta mark set meta mark | " + nftMark(util.IptablesAzureEgressDropMarkHex)
+			}
+		}
+		tx.Add("add rule %s %s %s", table, chainName, nftRule(nftACLMatches(aclPolicy), verdict, aclPolicy.comment()))
+	}
+}
+
+func nftRule(matches []string, verdict, comment string) string {
+	parts := make([]string, 0, len(matches)+3)
+	parts = append(parts, matches...)
+	parts = append(parts, verdict, "comment", nftables.Comment(comment))
+	return strings.Join(parts, " ")
+}
+
+func nftACLMatches(aclPolicy *ACLPolicy) []string {
+	matches := make([]string, 0)
+	if aclPolicy.Protocol != UnspecifiedProtocol {
+		matches = append(matches, "meta l4proto "+strings.ToLower(string(aclPolicy.Protocol)))
+	}
+	if !aclPolicy.DstPorts.isUnspecified() {
+		matches = append(matches, "th dport "+aclPolicy.DstPorts.toNftString())
+	}
+	for _, setInfo := range aclPolicy.SrcList {
+		matches = append(matches, setInfo.nftMatch(setInfo.MatchType)...)
+	}
+	for _, setInfo := range aclPolicy.DstList {
+		matches = append(matches, setInfo.nftMatch(setInfo.MatchType)...)
+	}
+	return matches
+}
+
+func nftMatchSetsForNetworkPolicy(networkPolicy *NPMNetworkPolicy, matchType MatchType) []string {
+	matches := make([]string, 0, len(networkPolicy.PodSelectorList))
+	for _, setInfo := range networkPolicy.PodSelectorList {
+		matches = append(matches, setInfo.nftMatch(matchType)...)
+	}
+	return matches
+}
+
+// nftMatch returns the expressions matching the set.
+// IPs in a CIDRBlocks set must also be outside of its nomatch set.
+func (info SetInfo) nftMatch(matchType MatchType) []string {
+	var selector string
+	switch matchType {
+	case SrcMatch:
+		selector = "ip saddr"
+	case DstDstMatch:
+		selector = "ip daddr . meta l4proto . th dport"
+	default:
+		selector = "ip daddr"
+	}
+	hashedName := info.IPSet.GetHashedName()
+	if !info.Included {
+		return []string{fmt.Sprintf("%s != @%s", selector, hashedName)}
+	}
+	matches := []string{fmt.Sprintf("%s @%s", selector, hashedName)}
+	if info.IPSet.Type == ipsets.CIDRBlocks {
+		matches = append(matches, fmt.Sprintf("%s != @%s", selector, ipsets.NftNomatchSetName(hashedName)))
+	}
+	return matches
+}
+
+func (portRange *Ports) toNftString() string {
+	start := strconv.Itoa(int(portRange.Port))
+	if portRange.Port == portRange.EndPort {
+		return start
+	}
+	return start + "-" + strconv.Itoa(int(portRange.EndPort))
+}
+
+// nftMark returns the bits of an iptables mark like "0x400/0x400".
+func nftMark(iptablesMark string) string {
+	return strings.Split(iptablesMark, "/")[0]
+}
+
+func nftMarkOr(a, b string) string {
+	aBits, _ := strconv.ParseUint(a, 0, 32)
+	bBits, _ := strconv.ParseUint(b, 0, 32)
+	return fmt.Sprintf("0x%x", aBits|bBits)
+}
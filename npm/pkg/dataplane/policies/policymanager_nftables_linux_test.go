@@ -0,0 +1,174 @@
+package policies
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
+	"github.com/Azure/azure-container-networking/npm/util"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var nftConfig = &PolicyManagerCfg{
+	PolicyMode:           IPSetPolicyMode,
+	PlaceAzureChainFirst: util.PlaceAzureChainFirst,
+	UseNftables:          true,
+}
+
+// nft dispatch rules for the base chains
+var (
+	nftActivateRules = []string{
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-INGRESS",
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-EGRESS",
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-ACCEPT",
+	}
+	nftFlushDispatchRules = []string{
+		"flush chain inet azure-npm AZURE-NPM",
+		"flush chain inet azure-npm AZURE-NPM-INGRESS",
+		"flush chain inet azure-npm AZURE-NPM-EGRESS",
+	}
+	nftIngressDropRule = `add rule inet azure-npm AZURE-NPM-INGRESS meta mark & 0x400 == 0x400 drop comment "DROP-ON-INGRESS-DROP-MARK-0x400/0x400"`
+	nftEgressVmapRule  = "add rule inet azure-npm AZURE-NPM-EGRESS meta mark & 0xa00 vmap { 0x800 : drop, 0xa00 : drop, 0x200 : jump AZURE-NPM-ACCEPT }"
+)
+
+func newNftPolicyManager(t *testing.T, calls []testutils.TestCmd) (*PolicyManager, *nftables.FakeExecutor) {
+	t.Helper()
+	ioShim := common.NewMockIOShim(calls)
+	t.Cleanup(func() { ioShim.VerifyCalls(t, calls) })
+	pMgr := NewPolicyManager(ioShim, nftConfig)
+	fake := nftables.NewFakeExecutor()
+	pMgr.nft = fake
+	pMgr.nftPolicies = fake
+	return pMgr, fake
+}
+
+func nftScript(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestNftBootup(t *testing.T) {
+	pMgr, fake := newNftPolicyManager(t, GetNftablesBootupTestCalls())
+	require.NoError(t, pMgr.Bootup(nil))
+	require.Equal(t, util.IptablesNft, util.Iptables, "cleaning up iptables shouldn't change the iptables version")
+
+	lines := []string{
+		"add table inet azure-npm",
+		"delete table inet azure-npm",
+		"add table inet azure-npm",
+		"add chain inet azure-npm forward { type filter hook forward priority filter - 1; policy accept; }",
+		"add chain inet azure-npm AZURE-NPM",
+		"add chain inet azure-npm AZURE-NPM-INGRESS",
+		"add chain inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK",
+		"add chain inet azure-npm AZURE-NPM-EGRESS",
+		"add chain inet azure-npm AZURE-NPM-ACCEPT",
+		"add rule inet azure-npm forward ct state new jump AZURE-NPM",
+		`add rule inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK meta mark set meta mark | 0x200 comment "SET-INGRESS-ALLOW-MARK-0x200/0x200"`,
+		"add rule inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK jump AZURE-NPM-EGRESS",
+		"add rule inet azure-npm AZURE-NPM-ACCEPT accept",
+	}
+	lines = append(lines, nftFlushDispatchRules...)
+	lines = append(lines, nftIngressDropRule, nftEgressVmapRule)
+	require.Equal(t, []string{nftScript(lines...)}, fake.Transactions)
+}
+
+func TestNftBootupFailure(t *testing.T) {
+	pMgr, fake := newNftPolicyManager(t, GetNftablesBootupTestCalls())
+	fake.FailNext(1)
+	require.Error(t, pMgr.Bootup(nil))
+}
+
+func TestNftAddAndRemovePolicies(t *testing.T) {
+	pMgr, fake := newNftPolicyManager(t, nil)
+
+	// both policies are added in one transaction
+	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{bothDirectionsNetPol, ingressNetPol}, nil))
+	require.Len(t, fake.Transactions, 1)
+
+	cidr := ipsets.TestCIDRSet.HashedName
+	keyPod := ipsets.TestKeyPodSet.HashedName
+	ns := ipsets.TestNSSet.HashedName
+	namedPort := ipsets.TestNamedportSet.HashedName
+	ingressDropACLRule := func(chain string) string {
+		return fmt.Sprintf(`add rule inet azure-npm %s meta l4proto tcp th dport 222-333 ip saddr @%s ip saddr != @%s-nomatch ip daddr != @%s meta mark set meta mark | 0x400 comment "%s"`,
+			chain, cidr, cidr, keyPod, ingressDropComment)
+	}
+	lines := []string{
+		"add chain inet azure-npm " + bothDirectionsNetPolIngressChain,
+		"flush chain inet azure-npm " + bothDirectionsNetPolIngressChain,
+		"add chain inet azure-npm " + bothDirectionsNetPolEgressChain,
+		"flush chain inet azure-npm " + bothDirectionsNetPolEgressChain,
+		ingressDropACLRule(bothDirectionsNetPolIngressChain),
+		fmt.Sprintf(`add rule inet azure-npm %s ip saddr @%s ip saddr != @%s-nomatch jump AZURE-NPM-INGRESS-ALLOW-MARK comment "%s"`,
+			bothDirectionsNetPolIngressChain, cidr, cidr, ingressAllowComment),
+		fmt.Sprintf(`add rule inet azure-npm %s meta l4proto udp th dport 144 ip daddr @%s ip daddr != @%s-nomatch meta mark set meta mark | 0x800 comment "%s"`,
+			bothDirectionsNetPolEgressChain, cidr, cidr, egressDropComment),
+		fmt.Sprintf(`add rule inet azure-npm %s ip daddr @%s jump AZURE-NPM-ACCEPT comment "%s"`,
+			bothDirectionsNetPolEgressChain, namedPort, egressAllowComment),
+		"add chain inet azure-npm " + ingressNetPolChain,
+		"flush chain inet azure-npm " + ingressNetPolChain,
+		ingressDropACLRule(ingressNetPolChain),
+	}
+	lines = append(lines, nftFlushDispatchRules...)
+	lines = append(lines, nftActivateRules...)
+	bothDirectionsIngressJump := fmt.Sprintf(`add rule inet azure-npm AZURE-NPM-INGRESS ip daddr @%s jump %s comment "%s"`,
+		keyPod, bothDirectionsNetPolIngressChain, bothDirectionsNetPolIngressJumpComment)
+	lines = append(lines,
+		bothDirectionsIngressJump,
+		fmt.Sprintf(`add rule inet azure-npm AZURE-NPM-EGRESS ip saddr @%s jump %s comment "%s"`,
+			keyPod, bothDirectionsNetPolEgressChain, bothDirectionsNetPolEgressJumpComment),
+		fmt.Sprintf(`add rule inet azure-npm AZURE-NPM-INGRESS ip daddr @%s ip daddr @%s jump %s comment "%s"`,
+			keyPod, ns, ingressNetPolChain, ingressNetPolJumpComment),
+		nftIngressDropRule, nftEgressVmapRule,
+	)
+	require.Equal(t, nftScript(lines...), fake.LastTransaction())
+
+	// removing a policy removes its jumps and deletes its chain in one transaction
+	require.NoError(t, pMgr.RemovePolicy(ingressNetPol.PolicyKey))
+	lines = append([]string{}, nftFlushDispatchRules...)
+	lines = append(lines, nftActivateRules...)
+	lines = append(lines,
+		bothDirectionsIngressJump,
+		fmt.Sprintf(`add rule inet azure-npm AZURE-NPM-EGRESS ip saddr @%s jump %s comment "%s"`,
+			keyPod, bothDirectionsNetPolEgressChain, bothDirectionsNetPolEgressJumpComment),
+		nftIngressDropRule, nftEgressVmapRule,
+		"add chain inet azure-npm "+ingressNetPolChain,
+		"flush chain inet azure-npm "+ingressNetPolChain,
+		"delete chain inet azure-npm "+ingressNetPolChain,
+	)
+	require.Equal(t, nftScript(lines...), fake.LastTransaction())
+
+	// removing the last policy deactivates NPM
+	require.NoError(t, pMgr.RemovePolicy(bothDirectionsNetPol.PolicyKey))
+	lines = append([]string{}, nftFlushDispatchRules...)
+	lines = append(lines,
+		nftIngressDropRule, nftEgressVmapRule,
+		"add chain inet azure-npm "+bothDirectionsNetPolIngressChain,
+		"flush chain inet azure-npm "+bothDirectionsNetPolIngressChain,
+		"delete chain inet azure-npm "+bothDirectionsNetPolIngressChain,
+		"add chain inet azure-npm "+bothDirectionsNetPolEgressChain,
+		"flush chain inet azure-npm "+bothDirectionsNetPolEgressChain,
+		"delete chain inet azure-npm "+bothDirectionsNetPolEgressChain,
+	)
+	require.Equal(t, nftScript(lines...), fake.LastTransaction())
+	require.Len(t, fake.Transactions, 3)
+}
+
+func TestNftAddPolicyFailure(t *testing.T) {
+	pMgr, fake := newNftPolicyManager(t, nil)
+	fake.FailNext(1)
+	require.Error(t, pMgr.AddPolicies([]*NPMNetworkPolicy{egressNetPol}, nil))
+	require.False(t, pMgr.PolicyExists(egressNetPol.PolicyKey))
+
+	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{egressNetPol}, nil))
+	require.True(t, pMgr.PolicyExists(egressNetPol.PolicyKey))
+	require.Contains(t, fake.LastTransaction(), fmt.Sprintf(`add rule inet azure-npm AZURE-NPM-EGRESS jump %s comment "%s"`, egressNetPolChain, egressNetPolJumpComment))
+}
+
+func TestNftNamedPortMatch(t *testing.T) {
+	info := SetInfo{IPSet: ipsets.TestNamedportSet.Metadata, Included: true, MatchType: DstDstMatch}
+	require.Equal(t, []string{"ip daddr . meta l4proto . th dport @" + ipsets.TestNamedportSet.HashedName}, info.nftMatch(info.MatchType))
+}
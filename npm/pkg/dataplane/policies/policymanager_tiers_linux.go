@@ -0,0 +1,180 @@
+package policies
+
+// This file contains code for the iptables implementation of the AdminTier and BaselineTier (PolicyManagerCfg.EnableAdminNetworkPolicy).
+// Policies in these tiers don't get their own chains. Instead, each tier has an ingress and egress chain,
+// which are rewritten in priority order whenever a policy in the tier is added or removed.
+//
+// The base chains evaluate the tiers like so:
+//
+//	AZURE-NPM-INGRESS: jump to AZURE-NPM-ADMIN-INGRESS, jumps to NetworkPolicy chains, drop on ingress drop mark, jump to AZURE-NPM-BASELINE-INGRESS
+//	AZURE-NPM-EGRESS:  jump to AZURE-NPM-ADMIN-EGRESS,  jumps to NetworkPolicy chains, drop on egress drop mark,  jump to AZURE-NPM-BASELINE-EGRESS, accept on ingress allow mark
+//
+// A Pass rule returns from the AdminTier chain so that the NetworkPolicies are evaluated next.
+
+import (
+	"sort"
+
+	"github.com/Azure/azure-container-networking/npm/util"
+	"github.com/Azure/azure-container-networking/npm/util/ioutil"
+)
+
+var (
+	// tiers in the order they're evaluated
+	iptablesTiers = []Tier{AdminTier, BaselineTier}
+
+	// Must loop through a slice because we need a deterministic order for fexec commands for UTs.
+	iptablesTierChains = []string{
+		util.IptablesAzureAdminIngressChain,
+		util.IptablesAzureAdminEgressChain,
+		util.IptablesAzureBaselineIngressChain,
+		util.IptablesAzureBaselineEgressChain,
+	}
+)
+
+func (tier Tier) ingressChainName() string {
+	if tier == AdminTier {
+		return util.IptablesAzureAdminIngressChain
+	}
+	return util.IptablesAzureBaselineIngressChain
+}
+
+func (tier Tier) egressChainName() string {
+	if tier == AdminTier {
+		return util.IptablesAzureAdminEgressChain
+	}
+	return util.IptablesAzureBaselineEgressChain
+}
+
+// baseChains returns the chains created at bootup.
+func (pMgr *PolicyManager) baseChains() []string {
+	if !pMgr.EnableAdminNetworkPolicy {
+		return iptablesAzureChains
+	}
+	chains := make([]string, 0, len(iptablesAzureChains)+len(iptablesTierChains))
+	chains = append(chains, iptablesAzureChains...)
+	return append(chains, iptablesTierChains...)
+}
+
+// firstJumpLineNumber returns the line number in AZURE-NPM-INGRESS/EGRESS for the first jump to a NetworkPolicy chain.
+// When tiers are enabled, the jump to the AdminTier chain comes first.
+func (pMgr *PolicyManager) firstJumpLineNumber() int {
+	if pMgr.EnableAdminNetworkPolicy {
+		return 2
+	}
+	return 1
+}
+
+// tiersOf returns the tiers of the AdminNetworkPolicies and BaselineAdminNetworkPolicies in evaluation order.
+func tiersOf(networkPolicies []*NPMNetworkPolicy) []Tier {
+	tiers := make([]Tier, 0, len(iptablesTiers))
+	for _, tier := range iptablesTiers {
+		for _, networkPolicy := range networkPolicies {
+			if networkPolicy.Tier == tier {
+				tiers = append(tiers, tier)
+				break
+			}
+		}
+	}
+	return tiers
+}
+
+// returns ingress and egress chain names for the tiers
+func tierChainNames(tiers []Tier) []string {
+	chainNames := make([]string, 0, 2*len(tiers))
+	for _, tier := range tiers {
+		chainNames = append(chainNames, tier.ingressChainName(), tier.egressChainName())
+	}
+	return chainNames
+}
+
+// tierPolicies returns the cached policies in the tier plus policiesToAdd, excluding policyKeyToRemove.
+// Policies are sorted by priority, and policies with the same priority are sorted by key so that the order is deterministic.
+// The caller must hold the policyMap lock.
+func (pMgr *PolicyManager) tierPolicies(tier Tier, policiesToAdd []*NPMNetworkPolicy, policyKeyToRemove string) []*NPMNetworkPolicy {
+	policiesByKey := make(map[string]*NPMNetworkPolicy)
+	for key, networkPolicy := range pMgr.policyMap.cache {
+		if networkPolicy.Tier == tier {
+			policiesByKey[key] = networkPolicy
+		}
+	}
+	for _, networkPolicy := range policiesToAdd {
+		if networkPolicy.Tier == tier {
+			policiesByKey[networkPolicy.PolicyKey] = networkPolicy
+		}
+	}
+	delete(policiesByKey, policyKeyToRemove)
+
+	result := make([]*NPMNetworkPolicy, 0, len(policiesByKey))
+	for _, networkPolicy := range policiesByKey {
+		result = append(result, networkPolicy)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority < result[j].Priority
+		}
+		return result[i].PolicyKey < result[j].PolicyKey
+	})
+	return result
+}
+
+// writeTierRules writes the rules for all policies in the tier. The tier's chains must be declared in the creator so that they're flushed first.
+func (pMgr *PolicyManager) writeTierRules(creator *ioutil.FileCreator, tier Tier, networkPolicies []*NPMNetworkPolicy) {
+	ipv6 := pMgr.ipv6
+	for _, networkPolicy := range networkPolicies {
+		for _, aclPolicy := range networkPolicy.ACLs {
+			var chainName string
+			var direction UniqueDirection
+			var subjectSpecs []string
+			var actionSpecs []string
+			if aclPolicy.hasIngress() {
+				chainName = tier.ingressChainName()
+				direction = forIngress
+				subjectSpecs = matchSetSpecsForNetworkPolicy(networkPolicy, DstMatch, ipv6)
+				actionSpecs = []string{util.IptablesJumpFlag, util.IptablesAzureIngressAllowMarkChain}
+			} else {
+				chainName = tier.egressChainName()
+				direction = forEgress
+				subjectSpecs = matchSetSpecsForNetworkPolicy(networkPolicy, SrcMatch, ipv6)
+				actionSpecs = []string{util.IptablesJumpFlag, util.IptablesAzureAcceptChain}
+			}
+			switch aclPolicy.Target {
+			case Dropped:
+				actionSpecs = []string{util.IptablesJumpFlag, util.IptablesDrop}
+			case Passed:
+				actionSpecs = []string{util.IptablesJumpFlag, util.IptablesReturn}
+			}
+
+			if aclPolicy.Target == Dropped && pMgr.EnableDenyFlowLogging {
+				logLine := []string{"-A", chainName}
+				logLine = append(logLine, pMgr.nflogSpecs(denyFlowLogPrefix(direction, networkPolicy.PolicyKey))...)
+				logLine = append(logLine, subjectSpecs...)
+				logLine = append(logLine, iptablesMatchSpecs(aclPolicy, ipv6)...)
+				logLine = append(logLine, pMgr.nflogLimitSpecs()...)
+				logLine = append(logLine, commentSpecs("LOG-"+networkPolicy.commentForTierRule(aclPolicy))...)
+				creator.AddLine("", nil, logLine...) // TODO add error handler
+			}
+
+			line := []string{"-A", chainName}
+			line = append(line, actionSpecs...)
+			line = append(line, subjectSpecs...)
+			line = append(line, iptablesMatchSpecs(aclPolicy, ipv6)...)
+			line = append(line, commentSpecs(networkPolicy.commentForTierRule(aclPolicy))...)
+			creator.AddLine("", nil, line...) // TODO add error handler
+		}
+	}
+}
+
+// NOTE: if removing multiple policies, would need to add a isLastPolicy argument instead
+func (pMgr *PolicyManager) creatorForRemovingTieredPolicy(networkPolicy *NPMNetworkPolicy) *ioutil.FileCreator {
+	tier := networkPolicy.Tier
+	creator := pMgr.newCreatorWithChains(tierChainNames([]Tier{tier}))
+	// 1. Deactivate NPM (if necessary).
+	if pMgr.isLastPolicy() {
+		creator.AddLine("", nil, util.IptablesFlushFlag, util.IptablesAzureChain)
+	}
+
+	// 2. Rewrite the tier's chains without the policy.
+	pMgr.writeTierRules(creator, tier, pMgr.tierPolicies(tier, nil, networkPolicy.PolicyKey))
+	creator.AddLine("", nil, util.IptablesRestoreCommit)
+	return creator
+}
@@ -0,0 +1,188 @@
+package policies
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	dptestutils "github.com/Azure/azure-container-networking/npm/pkg/dataplane/testutils"
+	"github.com/Azure/azure-container-networking/npm/util"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var tierConfig = &PolicyManagerCfg{
+	NodeIP:                   "6.7.8.9",
+	PolicyMode:               IPSetPolicyMode,
+	PlaceAzureChainFirst:     util.PlaceAzureChainFirst,
+	EnableAdminNetworkPolicy: true,
+}
+
+func testAdminPolicy(name string, priority int32, acls ...*ACLPolicy) *NPMNetworkPolicy {
+	networkPolicy := NewNPMTieredPolicy(AdminTier, name, priority)
+	networkPolicy.PodSelectorIPSets = []*ipsets.TranslatedIPSet{
+		{Metadata: ipsets.TestKeyPodSet.Metadata},
+	}
+	networkPolicy.PodSelectorList = []SetInfo{
+		{
+			IPSet:     ipsets.TestKeyPodSet.Metadata,
+			Included:  true,
+			MatchType: EitherMatch,
+		},
+	}
+	networkPolicy.ACLs = acls
+	return networkPolicy
+}
+
+func testBaselinePolicy(acls ...*ACLPolicy) *NPMNetworkPolicy {
+	networkPolicy := NewNPMTieredPolicy(BaselineTier, "default", 0)
+	networkPolicy.ACLs = acls
+	return networkPolicy
+}
+
+var (
+	ingressPassedACL = &ACLPolicy{
+		SrcList: []SetInfo{
+			{
+				ipsets.TestCIDRSet.Metadata,
+				true,
+				SrcMatch,
+			},
+		},
+		Target:    Passed,
+		Direction: Ingress,
+		Protocol:  UnspecifiedProtocol,
+	}
+	egressDeniedAllACL = &ACLPolicy{
+		Target:    Dropped,
+		Direction: Egress,
+		Protocol:  UnspecifiedProtocol,
+	}
+)
+
+func TestCreatorForBootupWithTiers(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), tierConfig)
+	creator := pMgr.creatorForBootup(map[string]struct{}{util.IptablesAzureAdminIngressChain: {}})
+	actualLines := strings.Split(creator.ToString(), "\n")
+	expectedLines := []string{
+		"*filter",
+		":AZURE-NPM - -",
+		":AZURE-NPM-INGRESS - -",
+		":AZURE-NPM-INGRESS-ALLOW-MARK - -",
+		":AZURE-NPM-EGRESS - -",
+		":AZURE-NPM-ACCEPT - -",
+		":AZURE-NPM-ADMIN-EGRESS - -",
+		":AZURE-NPM-BASELINE-INGRESS - -",
+		":AZURE-NPM-BASELINE-EGRESS - -",
+		"-F AZURE-NPM-ADMIN-INGRESS",
+		"-A AZURE-NPM-INGRESS -j AZURE-NPM-ADMIN-INGRESS",
+		fmt.Sprintf("-A AZURE-NPM-INGRESS -j DROP -m mark --mark %[1]s -m comment --comment DROP-ON-INGRESS-DROP-MARK-%[1]s", util.IptablesAzureIngressDropMarkHex),
+		"-A AZURE-NPM-INGRESS -j AZURE-NPM-BASELINE-INGRESS",
+		fmt.Sprintf("-A AZURE-NPM-INGRESS-ALLOW-MARK -j MARK --set-mark %[1]s -m comment --comment SET-INGRESS-ALLOW-MARK-%[1]s", util.IptablesAzureIngressAllowMarkHex),
+		"-A AZURE-NPM-INGRESS-ALLOW-MARK -j AZURE-NPM-EGRESS",
+		"-A AZURE-NPM-EGRESS -j AZURE-NPM-ADMIN-EGRESS",
+		fmt.Sprintf("-A AZURE-NPM-EGRESS -j DROP -m mark --mark %[1]s -m comment --comment DROP-ON-EGRESS-DROP-MARK-%[1]s", util.IptablesAzureEgressDropMarkHex),
+		"-A AZURE-NPM-EGRESS -j AZURE-NPM-BASELINE-EGRESS",
+		fmt.Sprintf("-A AZURE-NPM-EGRESS -j AZURE-NPM-ACCEPT -m mark --mark %[1]s -m comment --comment ACCEPT-ON-INGRESS-ALLOW-MARK-%[1]s", util.IptablesAzureIngressAllowMarkHex),
+		"-A AZURE-NPM-ACCEPT -j ACCEPT",
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+	// the tier chains are base chains, so they shouldn't be cleaned up
+	require.Empty(t, pMgr.staleChains.chainsToCleanup)
+}
+
+func TestCreatorForAddTieredPolicies(t *testing.T) {
+	calls := []testutils.TestCmd{fakeIPTablesRestoreCommand}
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	pMgr := NewPolicyManager(ioshim, tierConfig)
+
+	lowPriorityPolicy := testAdminPolicy("low", 20, ingressAllowedACL)
+	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{lowPriorityPolicy}, nil))
+
+	highPriorityPolicy := testAdminPolicy("high", 10, ingressPassedACL, egressDeniedAllACL)
+	baselinePolicy := testBaselinePolicy(egressDeniedAllACL)
+	newPolicies := []*NPMNetworkPolicy{bothDirectionsNetPol, baselinePolicy, highPriorityPolicy}
+	creator := pMgr.creatorForNewNetworkPolicies(chainNames(newPolicies), newPolicies)
+	actualLines := strings.Split(creator.ToString(), "\n")
+	keyPodSet := ipsets.TestKeyPodSet.HashedName
+	cidrSet := ipsets.TestCIDRSet.HashedName
+	expectedLines := []string{
+		"*filter",
+		fmt.Sprintf(":%s - -", bothDirectionsNetPolIngressChain),
+		fmt.Sprintf(":%s - -", bothDirectionsNetPolEgressChain),
+		":AZURE-NPM-ADMIN-INGRESS - -",
+		":AZURE-NPM-ADMIN-EGRESS - -",
+		":AZURE-NPM-BASELINE-INGRESS - -",
+		":AZURE-NPM-BASELINE-EGRESS - -",
+		// NetworkPolicy jumps come after the jumps to the admin tier chains
+		fmt.Sprintf("-A %s %s", bothDirectionsNetPolIngressChain, ingressDropRule),
+		fmt.Sprintf("-A %s %s", bothDirectionsNetPolIngressChain, ingressAllowRule),
+		fmt.Sprintf("-A %s %s", bothDirectionsNetPolEgressChain, egressDropRule),
+		fmt.Sprintf("-A %s %s", bothDirectionsNetPolEgressChain, egressAllowRule),
+		fmt.Sprintf("-I AZURE-NPM-INGRESS 2 %s", ingressEgressNetPolIngressJump),
+		fmt.Sprintf("-I AZURE-NPM-EGRESS 2 %s", ingressEgressNetPolEgressJump),
+		// admin tier in priority order, including the cached policy
+		fmt.Sprintf("-A AZURE-NPM-ADMIN-INGRESS -j RETURN -m set --match-set %s dst -m set --match-set %s src -m comment --comment ADMIN/high-PRIORITY-10-ON-podlabel-test-keyPod-set-PASS-FROM-cidr-test-cidr-set",
+			keyPodSet, cidrSet),
+		fmt.Sprintf("-A AZURE-NPM-ADMIN-EGRESS -j DROP -m set --match-set %s src -m comment --comment ADMIN/high-PRIORITY-10-ON-podlabel-test-keyPod-set-DROP-ALL",
+			keyPodSet),
+		fmt.Sprintf("-A AZURE-NPM-ADMIN-INGRESS -j AZURE-NPM-INGRESS-ALLOW-MARK -m set --match-set %s dst -m set --match-set %s src -m comment --comment ADMIN/low-PRIORITY-20-ON-podlabel-test-keyPod-set-ALLOW-FROM-cidr-test-cidr-set",
+			keyPodSet, cidrSet),
+		// baseline tier
+		"-A AZURE-NPM-BASELINE-EGRESS -j DROP -m comment --comment BASELINE/default-ON-all-DROP-ALL",
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+}
+
+func TestCreatorForRemoveTieredPolicy(t *testing.T) {
+	calls := []testutils.TestCmd{fakeIPTablesRestoreCommand, fakeIPTablesRestoreCommand}
+	ioshim := common.NewMockIOShim(calls)
+	defer ioshim.VerifyCalls(t, calls)
+	pMgr := NewPolicyManager(ioshim, tierConfig)
+
+	lowPriorityPolicy := testAdminPolicy("low", 20, ingressAllowedACL)
+	highPriorityPolicy := testAdminPolicy("high", 10, egressDeniedAllACL)
+	require.NoError(t, pMgr.AddPolicies([]*NPMNetworkPolicy{lowPriorityPolicy, highPriorityPolicy}, nil))
+
+	// 1. test without deactivation
+	creator := pMgr.creatorForRemovingTieredPolicy(highPriorityPolicy)
+	actualLines := strings.Split(creator.ToString(), "\n")
+	expectedLines := []string{
+		"*filter",
+		":AZURE-NPM-ADMIN-INGRESS - -",
+		":AZURE-NPM-ADMIN-EGRESS - -",
+		fmt.Sprintf("-A AZURE-NPM-ADMIN-INGRESS -j AZURE-NPM-INGRESS-ALLOW-MARK -m set --match-set %s dst -m set --match-set %s src -m comment --comment ADMIN/low-PRIORITY-20-ON-podlabel-test-keyPod-set-ALLOW-FROM-cidr-test-cidr-set",
+			ipsets.TestKeyPodSet.HashedName, ipsets.TestCIDRSet.HashedName),
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+
+	// 2. test with deactivation
+	require.NoError(t, pMgr.RemovePolicy(highPriorityPolicy.PolicyKey))
+	creator = pMgr.creatorForRemovingTieredPolicy(lowPriorityPolicy)
+	actualLines = strings.Split(creator.ToString(), "\n")
+	expectedLines = []string{
+		"*filter",
+		":AZURE-NPM-ADMIN-INGRESS - -",
+		":AZURE-NPM-ADMIN-EGRESS - -",
+		"-F AZURE-NPM",
+		"COMMIT",
+		"",
+	}
+	dptestutils.AssertEqualLines(t, expectedLines, actualLines)
+}
+
+func TestAddTieredPolicyDisabled(t *testing.T) {
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), ipsetConfig)
+	require.Error(t, pMgr.AddPolicies([]*NPMNetworkPolicy{testBaselinePolicy(egressDeniedAllACL)}, nil))
+	_, ok := pMgr.GetPolicy("BASELINE/default")
+	require.False(t, ok)
+}
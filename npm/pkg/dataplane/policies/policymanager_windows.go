@@ -489,12 +489,33 @@ func getEPPolicyReqFromACLSettings(settings []*NPMACLPolSettings) (hcn.PolicyEnd
 func (pMgr *PolicyManager) getSettingsFromACL(policy *NPMNetworkPolicy) ([]*NPMACLPolSettings, error) {
 	// +1 for readiness probe ACL
 	hnsRules := make([]*NPMACLPolSettings, len(policy.ACLs)+1)
+	numIngressRules := 0
+	numEgressRules := 0
 	for i, acl := range policy.ACLs {
 		rule, err := acl.convertToAclSettings(policy.ACLPolicyID)
 		if err != nil {
 			// TODO need some retry mechanism to check why the translations failed
 			return hnsRules, err
 		}
+
+		if policy.IsTiered() {
+			if acl.Target == Passed {
+				return hnsRules, ErrPassActionNotSupported
+			}
+
+			ruleIndex := numIngressRules
+			if acl.Direction == Egress {
+				ruleIndex = numEgressRules
+				numEgressRules++
+			} else {
+				numIngressRules++
+			}
+
+			rule.Priority, err = policy.tierRulePriority(ruleIndex)
+			if err != nil {
+				return hnsRules, fmt.Errorf("failed to get priority for ACL %d of policy %s: %w", i, policy.PolicyKey, err)
+			}
+		}
 		hnsRules[i] = rule
 	}
 
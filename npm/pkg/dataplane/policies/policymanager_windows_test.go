@@ -338,3 +338,24 @@ func getPortStr(start, end int32) string {
 
 	return portStr
 }
+
+func TestTierRulePriority(t *testing.T) {
+	adminPolicy := NewNPMTieredPolicy(AdminTier, "anp", 3)
+	priority, err := adminPolicy.tierRulePriority(0)
+	require.NoError(t, err)
+	require.Equal(t, uint16(31), priority)
+	priority, err = adminPolicy.tierRulePriority(9)
+	require.NoError(t, err)
+	require.Equal(t, uint16(40), priority)
+	_, err = adminPolicy.tierRulePriority(10)
+	require.ErrorIs(t, err, ErrTierPriorityNotSupported)
+
+	// admin priorities have to fit before the NetworkPolicy ACLs
+	_, err = NewNPMTieredPolicy(AdminTier, "anp", 20).tierRulePriority(0)
+	require.ErrorIs(t, err, ErrTierPriorityNotSupported)
+
+	baselinePolicy := NewNPMTieredPolicy(BaselineTier, "default", 0)
+	priority, err = baselinePolicy.tierRulePriority(2)
+	require.NoError(t, err)
+	require.Equal(t, uint16(4002), priority)
+}
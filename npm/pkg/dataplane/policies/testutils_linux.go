@@ -27,12 +27,12 @@ func GetRemovePolicyTestCalls(policy *NPMNetworkPolicy) []testutils.TestCmd {
 	hasIngress, hasEgress := policy.hasIngressAndEgress()
 	if hasIngress {
 		deleteIngressJumpSpecs := []string{"iptables-nft", "-w", "60", "-D", util.IptablesAzureIngressChain}
-		deleteIngressJumpSpecs = append(deleteIngressJumpSpecs, ingressJumpSpecs(policy)...)
+		deleteIngressJumpSpecs = append(deleteIngressJumpSpecs, ingressJumpSpecs(policy, false)...)
 		calls = append(calls, testutils.TestCmd{Cmd: deleteIngressJumpSpecs})
 	}
 	if hasEgress {
 		deleteEgressJumpSpecs := []string{"iptables-nft", "-w", "60", "-D", util.IptablesAzureEgressChain}
-		deleteEgressJumpSpecs = append(deleteEgressJumpSpecs, egressJumpSpecs(policy)...)
+		deleteEgressJumpSpecs = append(deleteEgressJumpSpecs, egressJumpSpecs(policy, false)...)
 		calls = append(calls, testutils.TestCmd{Cmd: deleteEgressJumpSpecs})
 	}
 
@@ -83,6 +83,21 @@ func GetBootupTestCalls() []testutils.TestCmd {
 	return bootUp
 }
 
+// GetNftablesBootupTestCalls returns the calls for cleaning up NPM's iptables chains when booting up with nftables.
+// The nftables transaction itself goes through the PolicyManager's nft Executor.
+func GetNftablesBootupTestCalls() []testutils.TestCmd {
+	calls := []testutils.TestCmd{}
+	for _, iptables := range []string{util.IptablesNft, util.IptablesLegacy} {
+		calls = append(calls,
+			testutils.TestCmd{Cmd: []string{iptables, "-w", "60", "-D", "FORWARD", "-j", "AZURE-NPM"}, ExitCode: 2},                                        //nolint // AZURE-NPM chain didn't exist
+			testutils.TestCmd{Cmd: []string{iptables, "-w", "60", "-D", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}, ExitCode: 2}, //nolint // AZURE-NPM chain didn't exist
+			testutils.TestCmd{Cmd: []string{iptables, "-w", "60", "-t", "filter", "-n", "-L"}, PipedToCommand: true},
+			testutils.TestCmd{Cmd: []string{"grep", "Chain AZURE-NPM"}, ExitCode: 1},
+		)
+	}
+	return calls
+}
+
 func getFakeDeleteJumpCommand(chainName, jumpRule string) testutils.TestCmd {
 	args := []string{"iptables-nft", "-w", "60", "-D", chainName}
 	args = append(args, strings.Split(jumpRule, " ")...)
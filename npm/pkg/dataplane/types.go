@@ -25,11 +25,21 @@ type GenericDataplane interface {
 	ApplyDataPlane() error
 	// GetAllPolicies is deprecated and only used in the goalstateprocessor, which is deprecated
 	GetAllPolicies() []string
+	// GetAllIPSetSnapshots and GetAllPolicySnapshots are used by the debug API
+	GetAllIPSetSnapshots() []*ipsets.IPSetSnapshot
+	GetAllPolicySnapshots() []*PolicySnapshot
 	AddPolicy(policies *policies.NPMNetworkPolicy) error
 	RemovePolicy(PolicyKey string) error
 	UpdatePolicy(policies *policies.NPMNetworkPolicy) error
 }
 
+// PolicySnapshot is a copy of a policy in the dataplane.
+// A pending policy is queued to be added in the background and isn't in the kernel yet.
+type PolicySnapshot struct {
+	Policy  *policies.NPMNetworkPolicy
+	Pending bool
+}
+
 type endpointCache struct {
 	sync.Mutex
 	cache map[string]*npmEndpoint
@@ -158,7 +158,7 @@ func (m *EventsServer) handle() error {
 	}
 
 	// load the server certificates
-	creds, err := serverTLSCreds()
+	creds, err := serverTLSCreds(m.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load TLS certificates: %w", err)
 	}
@@ -1,12 +1,17 @@
 package transport
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
 
+	localtls "github.com/Azure/azure-container-networking/server/tls"
+	"go.uber.org/zap"
 	"google.golang.org/grpc/credentials"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -16,17 +21,36 @@ const (
 	path                  = "/usr/local/npm"
 )
 
-func serverTLSCreds() (credentials.TransportCredentials, error) {
+// serverTLSCreds serves the certificate of the cert/key files, reloaded whenever they change until the context is done
+// so that a rotated certificate is served without restarting the controller.
+func serverTLSCreds(ctx context.Context) (credentials.TransportCredentials, error) {
 	certFilepath := path + "/" + serverCertPEMFilename
 	keyFilepath := path + "/" + serverKeyPEMFilename
 
-	creds, err := credentials.NewServerTLSFromFile(certFilepath, keyFilepath)
+	cr, err := localtls.NewKeyPairCertRefresher(certFilepath, keyFilepath, klogLogger{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create creds from cert/key files : %w", err)
 	}
-	return creds, nil
+	go func() {
+		// the reloads are logged with klog by the refresher
+		if err := cr.Watch(ctx, zap.NewNop()); err != nil && !errors.Is(err, context.Canceled) {
+			klog.Errorf("stopped reloading the TLS certificate: %v", err)
+		}
+	}()
+
+	return credentials.NewTLS(&tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cr.GetCertificate,
+	}), nil
 }
 
+// klogLogger logs the certificate refreshes with klog.
+type klogLogger struct{}
+
+func (klogLogger) Printf(format string, args ...any) { klog.Infof(format, args...) }
+func (klogLogger) Warnf(format string, args ...any)  { klog.Warningf(format, args...) }
+func (klogLogger) Errorf(format string, args ...any) { klog.Errorf(format, args...) }
+
 func clientTLSConfig() (*tls.Config, error) {
 	caCertFilepath := path + "/" + caCertPEMFilename
 	// Load certificate of the CA who signed server's certificate
@@ -23,9 +23,13 @@ const (
 
 var (
 	Iptables        = IptablesNft
-	Ip6tables       = Ip6tablesLegacy //nolint (avoid warning to capitalize this p)
 	IptablesSave    = IptablesSaveNft
 	IptablesRestore = IptablesRestoreNft
+
+	// Ip6tables, Ip6tablesSave, and Ip6tablesRestore are the IPv6 equivalents of the above and always use the same version (nft or legacy).
+	Ip6tables        = Ip6tablesNft        //nolint (avoid warning to capitalize this p)
+	Ip6tablesSave    = Ip6tablesSaveNft    //nolint (avoid warning to capitalize this p)
+	Ip6tablesRestore = Ip6tablesRestoreNft //nolint (avoid warning to capitalize this p)
 )
 
 // iptables related constants.
@@ -34,12 +38,17 @@ const (
 	PlaceAzureChainFirst             = true
 
 	IptablesNft                string = "iptables-nft"
-	Ip6tablesLegacy            string = "ip6tables" //nolint (avoid warning to capitalize this p)
 	IptablesSaveNft            string = "iptables-nft-save"
 	IptablesRestoreNft         string = "iptables-nft-restore"
 	IptablesLegacy             string = "iptables-legacy"
 	IptablesSaveLegacy         string = "iptables-legacy-save"
 	IptablesRestoreLegacy      string = "iptables-legacy-restore"
+	Ip6tablesNft               string = "ip6tables-nft"            //nolint (avoid warning to capitalize this p)
+	Ip6tablesSaveNft           string = "ip6tables-nft-save"       //nolint (avoid warning to capitalize this p)
+	Ip6tablesRestoreNft        string = "ip6tables-nft-restore"    //nolint (avoid warning to capitalize this p)
+	Ip6tablesLegacy            string = "ip6tables-legacy"         //nolint (avoid warning to capitalize this p)
+	Ip6tablesSaveLegacy        string = "ip6tables-legacy-save"    //nolint (avoid warning to capitalize this p)
+	Ip6tablesRestoreLegacy     string = "ip6tables-legacy-restore" //nolint (avoid warning to capitalize this p)
 	IptablesRestoreNoFlushFlag string = "--noflush"
 	IptablesRestoreTableFlag   string = "-T"
 	IptablesRestoreCommit      string = "COMMIT"
@@ -88,6 +97,11 @@ const (
 	IptablesMangleTable        string = "mangle"
 	IptablesCommentModuleFlag  string = "comment"
 	IptablesCommentFlag        string = "--comment"
+	IptablesNflog              string = "NFLOG"
+	IptablesNflogGroupFlag     string = "--nflog-group"
+	IptablesNflogPrefixFlag    string = "--nflog-prefix"
+	IptablesLimitModuleFlag    string = "limit"
+	IptablesLimitFlag          string = "--limit"
 	IptablesAddCommentFlag
 
 	IptablesTableFlag       string = "-t"
@@ -105,6 +119,12 @@ const (
 	IptablesAzureIngressAllowMarkChain string = "AZURE-NPM-INGRESS-ALLOW-MARK"
 	IptablesAzureEgressChain           string = "AZURE-NPM-EGRESS"
 
+	// Chains for AdminNetworkPolicies and BaselineAdminNetworkPolicies in NPM v2
+	IptablesAzureAdminIngressChain    string = "AZURE-NPM-ADMIN-INGRESS"
+	IptablesAzureAdminEgressChain     string = "AZURE-NPM-ADMIN-EGRESS"
+	IptablesAzureBaselineIngressChain string = "AZURE-NPM-BASELINE-INGRESS"
+	IptablesAzureBaselineEgressChain  string = "AZURE-NPM-BASELINE-EGRESS"
+
 	// Chains used in NPM v1
 	IptablesAzureIngressPortChain  string = "AZURE-NPM-INGRESS-PORT"
 	IptablesAzureIngressFromChain  string = "AZURE-NPM-INGRESS-FROM"
@@ -142,6 +162,11 @@ const (
 	IptablesAzureIngressDropMarkHex  string = "0x400/0x400"
 	IptablesAzureEgressDropMarkHex   string = "0x800/0x800"
 
+	// NFLOG prefixes for packets dropped in NPM v2 when deny flow logging is enabled.
+	// Drops by a policy in the AdminTier or BaselineTier have the prefix followed by "-" and the hash of the policy key.
+	NflogIngressDropPrefix string = "AZURE-NPM-INGRESS-DROP"
+	NflogEgressDropPrefix  string = "AZURE-NPM-EGRESS-DROP"
+
 	// marks in NPM v1
 	IptablesAzureIngressMarkHex string = "0x2000"
 	// IptablesAzureEgressXMarkHex is used for us to not override but append to the existing MARK
@@ -270,6 +295,9 @@ func SetIptablesToNft() {
 	Iptables = IptablesNft
 	IptablesSave = IptablesSaveNft
 	IptablesRestore = IptablesRestoreNft
+	Ip6tables = Ip6tablesNft
+	Ip6tablesSave = Ip6tablesSaveNft
+	Ip6tablesRestore = Ip6tablesRestoreNft
 }
 
 func SetIptablesToLegacy() {
@@ -277,4 +305,7 @@ func SetIptablesToLegacy() {
 	Iptables = IptablesLegacy
 	IptablesSave = IptablesSaveLegacy
 	IptablesRestore = IptablesRestoreLegacy
+	Ip6tables = Ip6tablesLegacy
+	Ip6tablesSave = Ip6tablesSaveLegacy
+	Ip6tablesRestore = Ip6tablesRestoreLegacy
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/util"
 	testutils "github.com/Azure/azure-container-networking/test/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -116,7 +117,7 @@ Chain AZURE-NPM-INGRESS (1 references)
 		t.Run(tt.name, func(t *testing.T) {
 			ioshim := common.NewMockIOShim(tt.calls)
 			defer ioshim.VerifyCalls(t, tt.calls)
-			chains, err := AllCurrentAzureChains(ioshim.Exec, "60")
+			chains, err := AllCurrentAzureChains(ioshim.Exec, util.Iptables, "60")
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -342,6 +342,17 @@ func IsIPV4(ip string) bool {
 	return address.Is4()
 }
 
+// IsIPV6 returns true for an IPv6 address or CIDR.
+func IsIPV6(ip string) bool {
+	if strings.Contains(ip, "/") {
+		prefix, err := netip.ParsePrefix(ip)
+		return err == nil && prefix.Addr().Is6() && !prefix.Addr().Is4In6()
+	}
+
+	address, err := netip.ParseAddr(ip)
+	return err == nil && address.Is6() && !address.Is4In6()
+}
+
 // Get preferred outbound ip of this machine
 // source: https://stackoverflow.com/questions/23558425/how-do-i-get-the-local-ip-address-in-go
 func NodeIP() (string, error) {
@@ -451,3 +451,21 @@ func TestNodeIP(t *testing.T) {
 	_, err := NodeIP()
 	require.Nil(t, err, "NodeIP() returned error")
 }
+
+func TestIsIPV6(t *testing.T) {
+	tests := map[string]bool{
+		"2001:db8::1":        true,
+		"2001:db8::/64":      true,
+		"::/0":               true,
+		"10.0.0.1":           false,
+		"10.0.0.0/16":        false,
+		"::ffff:10.0.0.1":    false,
+		"2001:db8::1/129":    false,
+		"2001:db8::1,tcp:80": false,
+		"":                   false,
+		"not an ip address":  false,
+	}
+	for ip, want := range tests {
+		require.Equal(t, want, IsIPV6(ip), "unexpected result for %s", ip)
+	}
+}
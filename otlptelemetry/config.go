@@ -0,0 +1,84 @@
+package otlptelemetry
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Protocols of the OTLP receiver, named as in OTEL_EXPORTER_OTLP_PROTOCOL.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+const (
+	defaultTimeoutInSecs       = 10
+	defaultMaxBatchSize        = 512
+	defaultBatchIntervalInSecs = 5
+	// the queue holds this many batches of log records while an export is in flight, the oldest records are dropped
+	maxQueuedBatches = 4
+)
+
+var (
+	ErrMissingEndpoint = errors.New("otlp endpoint is not set")
+	ErrInvalidEndpoint = errors.New("invalid otlp endpoint")
+	ErrInvalidProtocol = errors.New("invalid otlp protocol")
+)
+
+// Config is the OTLP exporter configuration, shared by the logs, metrics and traces of a component.
+type Config struct {
+	// Endpoint is the host:port of a gRPC receiver, or the base URL of an HTTP receiver e.g. http://collector:4318.
+	Endpoint string
+	// Protocol is either grpc (default) or http/protobuf.
+	Protocol string
+	// Insecure disables TLS to a gRPC receiver. An HTTP receiver uses TLS per the scheme of its URL.
+	Insecure bool
+	// Headers are sent with every export e.g. to authenticate to the receiver.
+	Headers map[string]string
+	// TimeoutInSecs bounds a single export.
+	TimeoutInSecs int
+	// MaxBatchSize is the number of log records or spans which triggers an export.
+	MaxBatchSize int
+	// BatchIntervalInSecs is the maximum delay before queued telemetry is exported.
+	BatchIntervalInSecs int
+	// ResourceAttributes are added to the resource which all telemetry is reported for.
+	ResourceAttributes map[string]string
+}
+
+// SetDefaults fills in the unset protocol, timeout and batching.
+func (c *Config) SetDefaults() {
+	if c.Protocol == "" {
+		c.Protocol = ProtocolGRPC
+	}
+	if c.TimeoutInSecs == 0 {
+		c.TimeoutInSecs = defaultTimeoutInSecs
+	}
+	if c.MaxBatchSize == 0 {
+		c.MaxBatchSize = defaultMaxBatchSize
+	}
+	if c.BatchIntervalInSecs == 0 {
+		c.BatchIntervalInSecs = defaultBatchIntervalInSecs
+	}
+}
+
+// Validate checks the endpoint and protocol.
+func (c *Config) Validate() error {
+	if c.Endpoint == "" {
+		return ErrMissingEndpoint
+	}
+	switch c.Protocol {
+	case ProtocolGRPC:
+	case ProtocolHTTPProtobuf:
+		u, err := url.Parse(c.Endpoint)
+		if err != nil {
+			return errors.Wrapf(err, "invalid otlp http endpoint %s", c.Endpoint)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return errors.Wrapf(ErrInvalidEndpoint, "%s is not an http or https url", c.Endpoint)
+		}
+	default:
+		return errors.Wrap(ErrInvalidProtocol, c.Protocol)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package otlptelemetry
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// attribute keys of the zap entry metadata
+const (
+	loggerNameKey = "logger"
+	callerKey     = "caller"
+	stacktraceKey = "stacktrace"
+)
+
+type core struct {
+	zapcore.LevelEnabler
+	t      *Telemetry
+	fields []zapcore.Field
+}
+
+// NewCore returns a zapcore.Core which emits the log entries as OpenTelemetry log records, with the fields as attributes.
+func NewCore(enab zapcore.LevelEnabler, t *Telemetry) zapcore.Core {
+	return &core{LevelEnabler: enab, t: t}
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	var record otellog.Record
+	record.SetTimestamp(ent.Time)
+	severity, text := zapSeverity(ent.Level)
+	record.SetSeverity(severity)
+	record.SetSeverityText(text)
+	record.SetBody(otellog.StringValue(ent.Message))
+	for k, v := range enc.Fields {
+		record.AddAttributes(otellog.KeyValue{Key: k, Value: logValue(v)})
+	}
+	if ent.LoggerName != "" {
+		record.AddAttributes(otellog.String(loggerNameKey, ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		record.AddAttributes(otellog.String(callerKey, ent.Caller.TrimmedPath()))
+	}
+	if ent.Stack != "" {
+		record.AddAttributes(otellog.String(stacktraceKey, ent.Stack))
+	}
+	c.t.EmitLog(record)
+	return nil
+}
+
+// Sync exports the queued log records.
+func (c *core) Sync() error {
+	c.t.Flush()
+	return nil
+}
+
+func zapSeverity(level zapcore.Level) (otellog.Severity, string) {
+	switch level {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug, "DEBUG"
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo, "INFO"
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn, "WARN"
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError, "ERROR"
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return otellog.SeverityFatal, "PANIC"
+	case zapcore.FatalLevel:
+		return otellog.SeverityFatal2, "FATAL"
+	default:
+		return otellog.SeverityUndefined, level.CapitalString()
+	}
+}
+
+// logValue converts a value of the zap map encoder. Nested objects and arrays are formatted as strings.
+func logValue(v any) otellog.Value {
+	switch val := v.(type) {
+	case string:
+		return otellog.StringValue(val)
+	case bool:
+		return otellog.BoolValue(val)
+	case int:
+		return otellog.IntValue(val)
+	case int8:
+		return otellog.Int64Value(int64(val))
+	case int16:
+		return otellog.Int64Value(int64(val))
+	case int32:
+		return otellog.Int64Value(int64(val))
+	case int64:
+		return otellog.Int64Value(val)
+	case uint8:
+		return otellog.Int64Value(int64(val))
+	case uint16:
+		return otellog.Int64Value(int64(val))
+	case uint32:
+		return otellog.Int64Value(int64(val))
+	case uint64:
+		if val > math.MaxInt64 {
+			return otellog.StringValue(fmt.Sprint(val))
+		}
+		return otellog.Int64Value(int64(val))
+	case float32:
+		return otellog.Float64Value(float64(val))
+	case float64:
+		return otellog.Float64Value(val)
+	case time.Duration:
+		return otellog.StringValue(val.String())
+	case time.Time:
+		return otellog.StringValue(val.Format(time.RFC3339Nano))
+	case []byte:
+		return otellog.BytesValue(val)
+	default:
+		return otellog.StringValue(fmt.Sprint(val))
+	}
+}
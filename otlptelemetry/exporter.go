@@ -0,0 +1,104 @@
+package otlptelemetry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// the signal paths of an OTLP/HTTP receiver
+const (
+	logsPath    = "/v1/logs"
+	metricsPath = "/v1/metrics"
+	tracesPath  = "/v1/traces"
+)
+
+func (c *Config) timeout() time.Duration {
+	return time.Duration(c.TimeoutInSecs) * time.Second
+}
+
+// signalURL is the URL of the signal path under the base URL of an HTTP receiver.
+func (c *Config) signalURL(path string) string {
+	return strings.TrimSuffix(c.Endpoint, "/") + path
+}
+
+func newLogExporter(ctx context.Context, cfg *Config) (sdklog.Exporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		exp, err := otlploghttp.New(ctx,
+			otlploghttp.WithEndpointURL(cfg.signalURL(logsPath)),
+			otlploghttp.WithHeaders(cfg.Headers),
+			otlploghttp.WithTimeout(cfg.timeout()))
+		return exp, errors.Wrap(err, "failed to create otlp http log exporter")
+	}
+	opts := []otlploggrpc.Option{
+		otlploggrpc.WithEndpoint(cfg.Endpoint),
+		otlploggrpc.WithHeaders(cfg.Headers),
+		otlploggrpc.WithTimeout(cfg.timeout()),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	exp, err := otlploggrpc.New(ctx, opts...)
+	return exp, errors.Wrap(err, "failed to create otlp grpc log exporter")
+}
+
+// newMetricExporter creates an exporter with delta temporality, so that a metric tracked once is exported once
+// as it is to AI, rather than on every collection.
+func newMetricExporter(ctx context.Context, cfg *Config) (sdkmetric.Exporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		exp, err := otlpmetrichttp.New(ctx,
+			otlpmetrichttp.WithEndpointURL(cfg.signalURL(metricsPath)),
+			otlpmetrichttp.WithHeaders(cfg.Headers),
+			otlpmetrichttp.WithTimeout(cfg.timeout()),
+			otlpmetrichttp.WithTemporalitySelector(deltaTemporality))
+		return exp, errors.Wrap(err, "failed to create otlp http metric exporter")
+	}
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+		otlpmetricgrpc.WithHeaders(cfg.Headers),
+		otlpmetricgrpc.WithTimeout(cfg.timeout()),
+		otlpmetricgrpc.WithTemporalitySelector(deltaTemporality),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	exp, err := otlpmetricgrpc.New(ctx, opts...)
+	return exp, errors.Wrap(err, "failed to create otlp grpc metric exporter")
+}
+
+func deltaTemporality(sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+// NewSpanExporter creates an exporter of spans to the receiver of the config, which must be validated.
+func NewSpanExporter(ctx context.Context, cfg *Config) (sdktrace.SpanExporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		exp, err := otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpointURL(cfg.signalURL(tracesPath)),
+			otlptracehttp.WithHeaders(cfg.Headers),
+			otlptracehttp.WithTimeout(cfg.timeout()))
+		return exp, errors.Wrap(err, "failed to create otlp http trace exporter")
+	}
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+		otlptracegrpc.WithHeaders(cfg.Headers),
+		otlptracegrpc.WithTimeout(cfg.timeout()),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	return exp, errors.Wrap(err, "failed to create otlp grpc trace exporter")
+}
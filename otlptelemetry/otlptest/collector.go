@@ -0,0 +1,242 @@
+// Package otlptest provides an in-process stand-in for an OTLP collector, which records the telemetry it receives.
+package otlptest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Collector receives OTLP logs, metrics and traces over gRPC and HTTP.
+type Collector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	// GRPCEndpoint is the host:port of the gRPC receiver.
+	GRPCEndpoint string
+	// HTTPEndpoint is the base URL of the HTTP receiver.
+	HTTPEndpoint string
+
+	mu      sync.Mutex
+	logs    []*logspb.ResourceLogs
+	metrics []*metricspb.ResourceMetrics
+	spans   []*tracepb.ResourceSpans
+	headers []map[string]string
+}
+
+// NewCollector starts a Collector which is stopped when the test ends.
+func NewCollector(t *testing.T) *Collector {
+	t.Helper()
+	c := &Collector{}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, c)
+	colmetricspb.RegisterMetricsServiceServer(srv, metricsService{c: c})
+	coltracepb.RegisterTraceServiceServer(srv, traceService{c: c})
+	go srv.Serve(lis) //nolint:errcheck // stopped by the cleanup
+	t.Cleanup(srv.Stop)
+	c.GRPCEndpoint = lis.Addr().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/logs", c.handle(&collogspb.ExportLogsServiceRequest{}, &collogspb.ExportLogsServiceResponse{}))
+	mux.HandleFunc("POST /v1/metrics", c.handle(&colmetricspb.ExportMetricsServiceRequest{}, &colmetricspb.ExportMetricsServiceResponse{}))
+	mux.HandleFunc("POST /v1/traces", c.handle(&coltracepb.ExportTraceServiceRequest{}, &coltracepb.ExportTraceServiceResponse{}))
+	hs := httptest.NewServer(mux)
+	t.Cleanup(hs.Close)
+	c.HTTPEndpoint = hs.URL
+
+	return c
+}
+
+// Export implements the gRPC LogsService.
+func (c *Collector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.record(req, incomingHeaders(ctx))
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// metricsService adapts the MetricsService, whose Export method has the same name as the LogsService's.
+type metricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	c *Collector
+}
+
+func (s metricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	s.c.record(req, incomingHeaders(ctx))
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// traceService adapts the TraceService, whose Export method has the same name as the LogsService's.
+type traceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	c *Collector
+}
+
+func (s traceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	s.c.record(req, incomingHeaders(ctx))
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func incomingHeaders(ctx context.Context) map[string]string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	headers := map[string]string{}
+	for k, v := range md {
+		headers[k] = v[0]
+	}
+	return headers
+}
+
+func (c *Collector) handle(req, resp proto.Message) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := proto.Clone(req)
+		proto.Reset(msg)
+		if err := proto.Unmarshal(body, msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		headers := map[string]string{}
+		for k := range r.Header {
+			headers[k] = r.Header.Get(k)
+		}
+		c.record(msg, headers)
+		b, _ := proto.Marshal(resp)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(b)
+	}
+}
+
+func (c *Collector) record(msg proto.Message, headers map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch req := msg.(type) {
+	case *collogspb.ExportLogsServiceRequest:
+		c.logs = append(c.logs, req.GetResourceLogs()...)
+	case *colmetricspb.ExportMetricsServiceRequest:
+		c.metrics = append(c.metrics, req.GetResourceMetrics()...)
+	case *coltracepb.ExportTraceServiceRequest:
+		c.spans = append(c.spans, req.GetResourceSpans()...)
+	}
+	c.headers = append(c.headers, headers)
+}
+
+// Logs returns the resource logs received so far.
+func (c *Collector) Logs() []*logspb.ResourceLogs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*logspb.ResourceLogs(nil), c.logs...)
+}
+
+// LogRecords returns the log records received so far.
+func (c *Collector) LogRecords() []*logspb.LogRecord {
+	var records []*logspb.LogRecord
+	for _, rl := range c.Logs() {
+		for _, sl := range rl.GetScopeLogs() {
+			records = append(records, sl.GetLogRecords()...)
+		}
+	}
+	return records
+}
+
+// Metrics returns the metrics received so far.
+func (c *Collector) Metrics() []*metricspb.Metric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var metrics []*metricspb.Metric
+	for _, rm := range c.metrics {
+		for _, sm := range rm.GetScopeMetrics() {
+			metrics = append(metrics, sm.GetMetrics()...)
+		}
+	}
+	return metrics
+}
+
+// ResourceSpans returns the resource spans received so far.
+func (c *Collector) ResourceSpans() []*tracepb.ResourceSpans {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.ResourceSpans(nil), c.spans...)
+}
+
+// Spans returns the spans received so far.
+func (c *Collector) Spans() []*tracepb.Span {
+	var spans []*tracepb.Span
+	for _, rs := range c.ResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			spans = append(spans, ss.GetSpans()...)
+		}
+	}
+	return spans
+}
+
+// Headers returns the headers or gRPC metadata of every export received so far.
+func (c *Collector) Headers() []map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]map[string]string(nil), c.headers...)
+}
+
+// WaitForLogRecords waits until at least n log records were received.
+func (c *Collector) WaitForLogRecords(t *testing.T, n int) []*logspb.LogRecord {
+	t.Helper()
+	var records []*logspb.LogRecord
+	waitFor(t, func() bool {
+		records = c.LogRecords()
+		return len(records) >= n
+	})
+	return records
+}
+
+// WaitForMetrics waits until at least n metrics were received.
+func (c *Collector) WaitForMetrics(t *testing.T, n int) []*metricspb.Metric {
+	t.Helper()
+	var metrics []*metricspb.Metric
+	waitFor(t, func() bool {
+		metrics = c.Metrics()
+		return len(metrics) >= n
+	})
+	return metrics
+}
+
+// WaitForSpans waits until at least n spans were received.
+func (c *Collector) WaitForSpans(t *testing.T, n int) []*tracepb.Span {
+	t.Helper()
+	var spans []*tracepb.Span
+	waitFor(t, func() bool {
+		spans = c.Spans()
+		return len(spans) >= n
+	})
+	return spans
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second) //nolint:gomnd // test timeout
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for telemetry")
+		}
+		time.Sleep(10 * time.Millisecond) //nolint:gomnd // poll interval
+	}
+}
@@ -0,0 +1,251 @@
+// Package otlptelemetry exports the telemetry of the ACN components to an OpenTelemetry (OTLP) receiver,
+// in place of or alongside Application Insights.
+//
+// Telemetry implements aitelemetry.TelemetryHandle, so it can be used wherever an AI handle is: traces are
+// exported as log records, events as log records with an event name, and metrics as gauges. The export is done by
+// the OpenTelemetry SDK, whose exporters the tracing package shares.
+package otlptelemetry
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/microsoft/ApplicationInsights-Go/appinsights/contracts"
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// attribute keys of the OpenTelemetry semantic conventions
+const (
+	serviceNameKey    = "service.name"
+	serviceVersionKey = "service.version"
+	hostNameKey       = "host.name"
+	hostIDKey         = "host.id"
+	hostTypeKey       = "host.type"
+	osTypeKey         = "os.type"
+	cloudProviderKey  = "cloud.provider"
+	cloudRegionKey    = "cloud.region"
+	cloudAccountKey   = "cloud.account.id"
+	resourceGroupKey  = "azure.resource_group"
+	contextKey        = "context"
+	resourceIDKey     = "resource.id"
+	scopeName         = "github.com/Azure/azure-container-networking/otlptelemetry"
+)
+
+var _ aitelemetry.TelemetryHandle = (*Telemetry)(nil)
+
+// Telemetry exports log records and metrics to an OTLP receiver with the OpenTelemetry SDK.
+type Telemetry struct {
+	loggerProvider *sdklog.LoggerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	logger         otellog.Logger
+	meter          metric.Meter
+	appVersion     string
+	timeout        time.Duration
+
+	mu     sync.Mutex
+	gauges map[string]metric.Float64Gauge
+	once   sync.Once
+}
+
+// NewOTLPTelemetry creates a Telemetry which reports for the app of the AI config. Log records are exported in
+// batches, and metrics every batch interval. Close must be called to export the remaining telemetry.
+func NewOTLPTelemetry(cfg Config, aiConfig aitelemetry.AIConfig) (*Telemetry, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	logExporter, err := newLogExporter(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := newMetricExporter(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	res := NewResource(&cfg, aiConfig.AppName, aiConfig.AppVersion)
+	interval := time.Duration(cfg.BatchIntervalInSecs) * time.Second
+	loggerProvider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter,
+			sdklog.WithExportMaxBatchSize(cfg.MaxBatchSize),
+			sdklog.WithMaxQueueSize(cfg.MaxBatchSize*maxQueuedBatches),
+			sdklog.WithExportInterval(interval),
+			sdklog.WithExportTimeout(cfg.timeout()))),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter,
+			sdkmetric.WithInterval(interval),
+			sdkmetric.WithTimeout(cfg.timeout()))),
+	)
+	return &Telemetry{
+		loggerProvider: loggerProvider,
+		meterProvider:  meterProvider,
+		logger:         loggerProvider.Logger(scopeName, otellog.WithInstrumentationVersion(aiConfig.AppVersion)),
+		meter:          meterProvider.Meter(scopeName, metric.WithInstrumentationVersion(aiConfig.AppVersion)),
+		appVersion:     aiConfig.AppVersion,
+		timeout:        cfg.timeout(),
+		gauges:         map[string]metric.Float64Gauge{},
+	}, nil
+}
+
+// NewResource describes the service and the node, with the VM metadata which the AI telemetry also reports.
+func NewResource(cfg *Config, serviceName, serviceVersion string) *resource.Resource {
+	attrs := map[string]string{
+		serviceNameKey:    serviceName,
+		serviceVersionKey: serviceVersion,
+		osTypeKey:         runtime.GOOS,
+	}
+	if host, err := os.Hostname(); err == nil {
+		attrs[hostNameKey] = host
+	}
+	if metadata, err := common.GetHostMetadata(aitelemetry.MetadataFile); err == nil && metadata.VMID != "" {
+		attrs[cloudProviderKey] = "azure"
+		attrs[cloudRegionKey] = metadata.Location
+		attrs[cloudAccountKey] = metadata.SubscriptionID
+		attrs[resourceGroupKey] = metadata.ResourceGroupName
+		attrs[hostIDKey] = metadata.VMID
+		attrs[hostTypeKey] = metadata.VMSize
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	return resource.NewSchemaless(kvs...)
+}
+
+// TrackLog exports the report as a log record, with the custom dimensions as attributes.
+func (t *Telemetry) TrackLog(report aitelemetry.Report) {
+	var record otellog.Record
+	record.SetTimestamp(time.Now())
+	severity, text := aiSeverity(report.Level)
+	record.SetSeverity(severity)
+	record.SetSeverityText(text)
+	record.SetBody(otellog.StringValue(report.Message))
+	record.AddAttributes(stringAttributes(report.CustomDimensions)...)
+	if report.Context != "" {
+		record.AddAttributes(otellog.String(contextKey, report.Context))
+	}
+	if report.AppVersion != "" && report.AppVersion != t.appVersion {
+		record.AddAttributes(otellog.String(serviceVersionKey, report.AppVersion))
+	}
+	t.EmitLog(record)
+}
+
+// TrackEvent exports the event as a log record with the event name, with the properties as attributes.
+func (t *Telemetry) TrackEvent(event aitelemetry.Event) {
+	var record otellog.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(otellog.SeverityInfo)
+	record.SetEventName(event.EventName)
+	record.SetBody(otellog.StringValue(event.EventName))
+	record.AddAttributes(stringAttributes(event.Properties)...)
+	if event.ResourceID != "" {
+		record.AddAttributes(otellog.String(resourceIDKey, event.ResourceID))
+	}
+	t.EmitLog(record)
+}
+
+// TrackMetric records the metric on a gauge of its name, with the custom dimensions as attributes.
+func (t *Telemetry) TrackMetric(m aitelemetry.Metric) {
+	gauge, err := t.gauge(m.Name)
+	if err != nil {
+		log.Errorf("[otlp] Dropped metric %s: %v", m.Name, err)
+		return
+	}
+	attrs := make([]attribute.KeyValue, 0, len(m.CustomDimensions)+1)
+	for k, v := range m.CustomDimensions {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	if m.AppVersion != "" && m.AppVersion != t.appVersion {
+		attrs = append(attrs, attribute.String(serviceVersionKey, m.AppVersion))
+	}
+	gauge.Record(context.Background(), m.Value, metric.WithAttributes(attrs...))
+}
+
+func (t *Telemetry) gauge(name string) (metric.Float64Gauge, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g, ok := t.gauges[name]; ok {
+		return g, nil
+	}
+	g, err := t.meter.Float64Gauge(name)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the error names the instrument
+	}
+	t.gauges[name] = g
+	return g, nil
+}
+
+// EmitLog queues a log record for export.
+func (t *Telemetry) EmitLog(record otellog.Record) {
+	if record.ObservedTimestamp().IsZero() {
+		record.SetObservedTimestamp(time.Now())
+	}
+	t.logger.Emit(context.Background(), record)
+}
+
+// Flush exports the queued log records and the metrics recorded since the last export.
+func (t *Telemetry) Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+	if err := t.loggerProvider.ForceFlush(ctx); err != nil {
+		log.Errorf("[otlp] Failed to export log records: %v", err)
+	}
+	if err := t.meterProvider.ForceFlush(ctx); err != nil {
+		log.Errorf("[otlp] Failed to export metrics: %v", err)
+	}
+}
+
+// Close exports the remaining telemetry within the timeout in seconds, and stops the export.
+func (t *Telemetry) Close(timeout int) {
+	t.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+		defer cancel()
+		if err := t.loggerProvider.Shutdown(ctx); err != nil {
+			log.Errorf("[otlp] Failed to export log records: %v", err)
+		}
+		if err := t.meterProvider.Shutdown(ctx); err != nil {
+			log.Errorf("[otlp] Failed to export metrics: %v", err)
+		}
+	})
+}
+
+// aiSeverity maps the AI severity levels to the OpenTelemetry severity numbers.
+func aiSeverity(level aitelemetry.Level) (otellog.Severity, string) {
+	switch level {
+	case contracts.Verbose:
+		return otellog.SeverityDebug, "DEBUG"
+	case contracts.Warning:
+		return otellog.SeverityWarn, "WARN"
+	case contracts.Error:
+		return otellog.SeverityError, "ERROR"
+	case contracts.Critical:
+		return otellog.SeverityFatal, "FATAL"
+	default:
+		return otellog.SeverityInfo, "INFO"
+	}
+}
+
+func stringAttributes(m map[string]string) []otellog.KeyValue {
+	attrs := make([]otellog.KeyValue, 0, len(m))
+	for k, v := range m {
+		attrs = append(attrs, otellog.String(k, v))
+	}
+	return attrs
+}
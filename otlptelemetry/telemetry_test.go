@@ -0,0 +1,181 @@
+package otlptelemetry
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/otlptelemetry/otlptest"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+)
+
+var testAIConfig = aitelemetry.AIConfig{AppName: "azure-cns", AppVersion: "v1.6.0"}
+
+func attributes(kvs []*commonpb.KeyValue) map[string]any {
+	m := map[string]any{}
+	for _, kv := range kvs {
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			m[kv.GetKey()] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			m[kv.GetKey()] = v.IntValue
+		case *commonpb.AnyValue_BoolValue:
+			m[kv.GetKey()] = v.BoolValue
+		case *commonpb.AnyValue_DoubleValue:
+			m[kv.GetKey()] = v.DoubleValue
+		}
+	}
+	return m
+}
+
+func TestTelemetry(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		authHeader string
+	}{
+		{
+			name:       "grpc",
+			cfg:        Config{Endpoint: collector.GRPCEndpoint, Insecure: true, Headers: map[string]string{"authorization": "Bearer token"}},
+			authHeader: "authorization",
+		},
+		{
+			name:       "http",
+			cfg:        Config{Endpoint: collector.HTTPEndpoint, Protocol: ProtocolHTTPProtobuf, Headers: map[string]string{"Authorization": "Bearer token"}},
+			authHeader: "Authorization",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, metrics, headers := len(collector.LogRecords()), len(collector.Metrics()), len(collector.Headers())
+
+			th, err := NewOTLPTelemetry(tt.cfg, testAIConfig)
+			require.NoError(t, err)
+			th.TrackLog(aitelemetry.Report{
+				Message:          "failed to allocate ip",
+				Level:            aitelemetry.ErrorLevel,
+				Context:          "node-1",
+				CustomDimensions: map[string]string{"OperationType": "ADD"},
+			})
+			th.TrackEvent(aitelemetry.Event{
+				EventName:  "EndpointDatapathRepair",
+				ResourceID: "3f813b02-eth0",
+				Properties: map[string]string{"RepairKind": "neighbor"},
+			})
+			th.TrackMetric(aitelemetry.Metric{
+				Name:             "NumberOfAllocatedIPs",
+				Value:            12,
+				CustomDimensions: map[string]string{"Subnet": "10.240.0.0/16"},
+			})
+			th.Flush()
+			defer th.Close(1)
+
+			records := collector.WaitForLogRecords(t, logs+2)[logs:]
+			require.Equal(t, "failed to allocate ip", records[0].GetBody().GetStringValue())
+			require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[0].GetSeverityNumber())
+			require.Equal(t, map[string]any{"OperationType": "ADD", "context": "node-1"}, attributes(records[0].GetAttributes()))
+
+			require.Equal(t, "EndpointDatapathRepair", records[1].GetEventName())
+			require.Equal(t, map[string]any{"RepairKind": "neighbor", "resource.id": "3f813b02-eth0"}, attributes(records[1].GetAttributes()))
+
+			received := collector.WaitForMetrics(t, metrics+1)[metrics:]
+			require.Equal(t, "NumberOfAllocatedIPs", received[0].GetName())
+			points := received[0].GetGauge().GetDataPoints()
+			require.Len(t, points, 1)
+			require.InDelta(t, 12, points[0].GetAsDouble(), 0)
+			require.Equal(t, map[string]any{"Subnet": "10.240.0.0/16"}, attributes(points[0].GetAttributes()))
+
+			resource := attributes(collector.Logs()[len(collector.Logs())-1].GetResource().GetAttributes())
+			require.Equal(t, "azure-cns", resource[serviceNameKey])
+			require.Equal(t, "v1.6.0", resource[serviceVersionKey])
+
+			for _, h := range collector.Headers()[headers:] {
+				require.Equal(t, "Bearer token", h[tt.authHeader])
+			}
+		})
+	}
+}
+
+func TestTelemetryExportsFullBatch(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+	th, err := NewOTLPTelemetry(Config{
+		Endpoint:            collector.GRPCEndpoint,
+		Insecure:            true,
+		MaxBatchSize:        2,
+		BatchIntervalInSecs: 3600,
+	}, testAIConfig)
+	require.NoError(t, err)
+	defer th.Close(1)
+
+	th.TrackLog(aitelemetry.Report{Message: "first"})
+	th.TrackLog(aitelemetry.Report{Message: "second"})
+	require.Len(t, collector.WaitForLogRecords(t, 2), 2)
+}
+
+func TestTelemetryCloseExportsQueued(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+	th, err := NewOTLPTelemetry(Config{
+		Endpoint:            collector.HTTPEndpoint,
+		Protocol:            ProtocolHTTPProtobuf,
+		BatchIntervalInSecs: 3600,
+	}, testAIConfig)
+	require.NoError(t, err)
+
+	th.TrackMetric(aitelemetry.Metric{Name: "HeartBeat", Value: 1})
+	th.Close(1)
+	require.Len(t, collector.Metrics(), 1)
+	// closing twice is a no-op
+	th.Close(1)
+}
+
+func TestTelemetryConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name:    "missing endpoint",
+			cfg:     Config{},
+			wantErr: ErrMissingEndpoint,
+		},
+		{
+			name:    "invalid protocol",
+			cfg:     Config{Endpoint: "localhost:4317", Protocol: "http/json"},
+			wantErr: ErrInvalidProtocol,
+		},
+		{
+			name:    "http endpoint without scheme",
+			cfg:     Config{Endpoint: "collector:4318", Protocol: ProtocolHTTPProtobuf},
+			wantErr: ErrInvalidEndpoint,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOTLPTelemetry(tt.cfg, testAIConfig)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCore(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+	th, err := NewOTLPTelemetry(Config{Endpoint: collector.GRPCEndpoint, Insecure: true}, testAIConfig)
+	require.NoError(t, err)
+	defer th.Close(1)
+
+	z := zap.New(NewCore(zap.InfoLevel, th)).Named("ipam").With(zap.String("nc", "nc-1"))
+	z.Debug("not exported")
+	z.Warn("pool is scaling up", zap.Int("requested", 16), zap.Bool("scarce", true))
+	require.NoError(t, z.Sync())
+
+	records := collector.WaitForLogRecords(t, 1)
+	require.Len(t, records, 1)
+	require.Equal(t, "pool is scaling up", records[0].GetBody().GetStringValue())
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, records[0].GetSeverityNumber())
+	require.Equal(t, map[string]any{"nc": "nc-1", "requested": int64(16), "scarce": true, "logger": "ipam"}, attributes(records[0].GetAttributes()))
+}
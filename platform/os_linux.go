@@ -28,6 +28,10 @@ const (
 	CNIStateFilePath = "/var/run/azure-vnet.json"
 	// CNIIpamStatePath is the name of IPAM state file
 	CNIIpamStatePath = "/var/run/azure-vnet-ipam.json"
+	// CNIBoltStateFilePath is the path to the CNI state file of the bolt store backend
+	CNIBoltStateFilePath = "/var/run/azure-vnet.db"
+	// CNIIpamBoltStatePath is the name of IPAM state file of the bolt store backend
+	CNIIpamBoltStatePath = "/var/run/azure-vnet-ipam.db"
 	// CNIBinaryPath is the path to the CNI binary
 	CNIBinaryPath = "/opt/cni/bin/azure-vnet"
 	// CNSRuntimePath is the path where CNS state files are stored.
@@ -42,6 +42,12 @@ const (
 	// CNIIpamStatePath is the name of IPAM state file
 	CNIIpamStatePath = "C:\\k\\azure-vnet-ipam.json"
 
+	// CNIBoltStateFilePath is the path to the CNI state file of the bolt store backend
+	CNIBoltStateFilePath = "C:\\k\\azure-vnet.db"
+
+	// CNIIpamBoltStatePath is the name of IPAM state file of the bolt store backend
+	CNIIpamBoltStatePath = "C:\\k\\azure-vnet-ipam.db"
+
 	// CNIBinaryPath is the path to the CNI binary
 	CNIBinaryPath = "C:\\k\\azurecni\\bin\\azure-vnet.exe"
 
@@ -170,6 +176,7 @@ func SetOutboundSNAT(subnet string) error {
 
 // ClearNetworkConfiguration clears the azure-vnet.json contents.
 // This will be called only when reboot is detected - This is windows specific
+// The bolt store (azure-vnet.db) is held open by the network manager, which clears it by saving its emptied state.
 func (p *execClient) ClearNetworkConfiguration() (bool, error) {
 	jsonStore := CNIRuntimePath + "azure-vnet.json"
 	p.logger.Info("Deleting the json", zap.String("store", jsonStore))
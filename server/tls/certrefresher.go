@@ -0,0 +1,309 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	//nolint:gosec // sha1 only used to display cert thumbprint in logs.
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	acnfs "github.com/Azure/azure-container-networking/internal/fs"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// expiryWarningFraction is the fraction of the validity of a certificate under which its expiry is warned about.
+// Certificates are usually renewed well before, e.g. cert-manager renews them with a third of their validity left, so
+// a certificate this close to its expiry was not rotated.
+const expiryWarningFraction = 0.1
+
+// expiryCheckInterval is the interval at which the expiry of a certificate which isn't reloaded is checked.
+const expiryCheckInterval = time.Hour
+
+type logger interface {
+	Printf(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// CertRefresher serves the latest version of a tls.Certificate loaded from local files, e.g. a Kubernetes secret
+// volume, reloaded when the files change so that a rotated certificate is served without restarting the server.
+type CertRefresher struct {
+	name   string
+	paths  []string
+	load   func() (*tls.Certificate, error)
+	logger logger
+
+	m    sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertRefresher returns a CertRefresher of the certificate returned by load from the files at paths. When there's
+// no error, the CertRefresher's GetCertificate method is ready for use, returning the certificate loaded during
+// construction.
+func NewCertRefresher(name string, paths []string, load func() (*tls.Certificate, error), l logger) (*CertRefresher, error) {
+	c := CertRefresher{
+		name:   name,
+		paths:  paths,
+		load:   load,
+		logger: l,
+	}
+
+	cert, err := c.load()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load initial cert %s", name)
+	}
+
+	c.cert = cert
+	observeExpiry(name, cert.Leaf)
+	c.logger.Printf("initial certificate loaded: %s", &c)
+	return &c, nil
+}
+
+// NewFileCertRefresher returns a CertRefresher of the PEM file at settings.TLSCertificatePath.
+func NewFileCertRefresher(settings TlsSettings, l logger) (*CertRefresher, error) {
+	return NewCertRefresher(settings.TLSCertificatePath, []string{settings.TLSCertificatePath}, func() (*tls.Certificate, error) {
+		return loadCertificateFile(settings)
+	}, l)
+}
+
+// NewKeyPairCertRefresher returns a CertRefresher of the PEM encoded certificate and key files, as in a Kubernetes
+// TLS secret.
+func NewKeyPairCertRefresher(certFile, keyFile string, l logger) (*CertRefresher, error) {
+	return NewCertRefresher(certFile, []string{certFile, keyFile}, func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load key pair")
+		}
+		return &cert, nil
+	}, l)
+}
+
+func (c *CertRefresher) String() string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return fmt.Sprintf("cert name: %s, sha1 thumbprint: %s, expiration: %s", c.name, sha1String(c.cert.Leaf.Raw), c.cert.Leaf.NotAfter.String())
+}
+
+// Certificate returns the latest certificate loaded.
+func (c *CertRefresher) Certificate() *tls.Certificate {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.cert
+}
+
+// GetCertificate returns the latest certificate loaded. It is the tls.Config.GetCertificate of a server.
+func (c *CertRefresher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.Certificate(), nil
+}
+
+// Watch reloads the certificate whenever one of its files changes, and warns when it is about to expire.
+// A certificate which fails to load, e.g. while the files are being replaced, is logged and the current certificate
+// is kept. It blocks until context is done.
+func (c *CertRefresher) Watch(ctx context.Context, z *zap.Logger) error {
+	return watch(ctx, z, c.paths, func() {
+		if err := c.Reload(); err != nil {
+			reloadFailures.WithLabelValues(c.name).Inc()
+			c.logger.Errorf("could not reload certificate %s: %v", c.name, err)
+		}
+	}, func() {
+		warnExpiry(c.logger, c.name, c.Certificate().Leaf)
+	})
+}
+
+// Reload loads the certificate, and serves it if it changed.
+func (c *CertRefresher) Reload() error {
+	latestCert, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	if latestCert.Leaf.Equal(c.cert.Leaf) {
+		c.m.Unlock()
+		return nil
+	}
+	oldThumbprint := sha1String(c.cert.Leaf.Raw)
+	c.cert = latestCert
+	c.m.Unlock()
+
+	observeExpiry(c.name, latestCert.Leaf)
+	c.logger.Printf("certificate reloaded. old sha1 thumbprint: %s, certificate: %s", oldThumbprint, c)
+	return nil
+}
+
+// CertPoolRefresher serves the latest version of a pool of CA certificates loaded from a PEM bundle file, e.g. of the
+// CAs of the mTLS clients, reloaded when the file changes so that a rotated CA is trusted without restarting the server.
+type CertPoolRefresher struct {
+	path   string
+	logger logger
+
+	m       sync.RWMutex
+	content []byte
+	pool    *x509.CertPool
+	expiry  *x509.Certificate
+}
+
+// NewCertPoolRefresher returns a CertPoolRefresher of the PEM bundle at path. When there's no error, the
+// CertPoolRefresher's CertPool method is ready for use, returning the pool loaded during construction.
+func NewCertPoolRefresher(path string, l logger) (*CertPoolRefresher, error) {
+	c := CertPoolRefresher{
+		path:   path,
+		logger: l,
+	}
+	if err := c.Reload(); err != nil {
+		return nil, errors.Wrapf(err, "could not load initial cert pool %s", path)
+	}
+	return &c, nil
+}
+
+// CertPool returns the latest pool loaded.
+func (c *CertPoolRefresher) CertPool() *x509.CertPool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.pool
+}
+
+// Watch reloads the pool whenever the bundle changes, and warns when the first of its CAs to expire is about to.
+// A bundle which fails to load is logged and the current pool is kept. It blocks until context is done.
+func (c *CertPoolRefresher) Watch(ctx context.Context, z *zap.Logger) error {
+	return watch(ctx, z, []string{c.path}, func() {
+		if err := c.Reload(); err != nil {
+			reloadFailures.WithLabelValues(c.path).Inc()
+			c.logger.Errorf("could not reload cert pool %s: %v", c.path, err)
+		}
+	}, func() {
+		c.m.RLock()
+		expiry := c.expiry
+		c.m.RUnlock()
+		warnExpiry(c.logger, c.path, expiry)
+	})
+}
+
+// Reload loads the PEM bundle, and serves its pool if the bundle changed.
+func (c *CertPoolRefresher) Reload() error {
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		return errors.Wrapf(err, "could not read %s", c.path)
+	}
+
+	c.m.RLock()
+	unchanged := bytes.Equal(content, c.content)
+	c.m.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	pool := x509.NewCertPool()
+	var expiry *x509.Certificate
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != CertLabel {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse certificate in %s", c.path)
+		}
+		pool.AddCert(cert)
+		if expiry == nil || cert.NotAfter.Before(expiry.NotAfter) {
+			expiry = cert
+		}
+	}
+	if expiry == nil {
+		return errors.Errorf("no certificate found in %s", c.path)
+	}
+
+	c.m.Lock()
+	c.content, c.pool, c.expiry = content, pool, expiry
+	c.m.Unlock()
+
+	observeExpiry(c.path, expiry)
+	c.logger.Printf("cert pool loaded: %s, first expiration: %s", c.path, expiry.NotAfter.String())
+	return nil
+}
+
+// watch calls reload whenever one of the files at paths changes, and checkExpiry after each reload and every
+// expiryCheckInterval, until the context is done.
+func watch(ctx context.Context, z *zap.Logger, paths []string, reload, checkExpiry func()) error {
+	// the watchers of files in the same directory see the same change, a reload mustn't overtake another one
+	var m sync.Mutex
+	onChange := func() {
+		m.Lock()
+		defer m.Unlock()
+		reload()
+		checkExpiry()
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, path := range paths {
+		g.Go(func() error {
+			return acnfs.WatchFile(ctx, path, z, onChange) //nolint:wrapcheck // wrapped below
+		})
+	}
+	g.Go(func() error {
+		ticker := time.NewTicker(expiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			m.Lock()
+			checkExpiry()
+			m.Unlock()
+			select {
+			case <-ctx.Done():
+				return ctx.Err() //nolint:wrapcheck // wrapped below
+			case <-ticker.C:
+			}
+		}
+	})
+	return errors.Wrap(g.Wait(), "watch canceled")
+}
+
+// warnExpiry logs the certificate if it expired, or is within expiryWarningFraction of its validity of expiring.
+func warnExpiry(l logger, name string, cert *x509.Certificate) {
+	remaining := time.Until(cert.NotAfter)
+	switch {
+	case remaining <= 0:
+		l.Errorf("certificate %s expired on %s", name, cert.NotAfter.String())
+	case remaining < time.Duration(float64(cert.NotAfter.Sub(cert.NotBefore))*expiryWarningFraction):
+		l.Warnf("certificate %s expires on %s and was not rotated", name, cert.NotAfter.String())
+	}
+}
+
+// loadCertificateFile loads the certificate and private key of the PEM file at settings.TLSCertificatePath.
+func loadCertificateFile(settings TlsSettings) (*tls.Certificate, error) {
+	tlsCertRetriever, err := GetTlsCertificateRetriever(settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get certificate retriever")
+	}
+
+	leafCertificate, err := tlsCertRetriever.GetCertificate()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get certificate")
+	}
+
+	if leafCertificate == nil {
+		return nil, errors.New("certificate retrieval returned empty")
+	}
+
+	privateKey, err := tlsCertRetriever.GetPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get certificate private key")
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{leafCertificate.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        leafCertificate,
+	}, nil
+}
+
+func sha1String(bs []byte) string {
+	//nolint:gosec // sha1 only used to display cert thumbprint in logs.
+	return fmt.Sprintf("%X", sha1.Sum(bs))
+}
@@ -0,0 +1,185 @@
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testLogger struct {
+	t        *testing.T
+	warnings int
+	errors   int
+}
+
+func (l *testLogger) Printf(format string, args ...any) { l.t.Logf(format, args...) }
+
+func (l *testLogger) Warnf(format string, args ...any) {
+	l.warnings++
+	l.t.Logf(format, args...)
+}
+
+func (l *testLogger) Errorf(format string, args ...any) {
+	l.errors++
+	l.t.Logf(format, args...)
+}
+
+// writeKeyPair writes a self-signed certificate with the common name and validity, and its key, to the files.
+func writeKeyPair(t *testing.T, certFile, keyFile, cn string, notBefore, notAfter time.Time) *x509.Certificate {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	key, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: CertLabel, Bytes: der}), 0o600))
+	if keyFile != "" {
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: PrivateKeyLabel, Bytes: key}), 0o600))
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestKeyPairCertRefresherReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	now := time.Now()
+	l := &testLogger{t: t}
+
+	first := writeKeyPair(t, certFile, keyFile, "first", now.Add(-time.Hour), now.Add(time.Hour))
+	cr, err := NewKeyPairCertRefresher(certFile, keyFile, l)
+	require.NoError(t, err)
+
+	cert, err := cr.GetCertificate(nil)
+	require.NoError(t, err)
+	require.True(t, first.Equal(cert.Leaf))
+
+	// an unchanged certificate is kept
+	require.NoError(t, cr.Reload())
+	require.Same(t, cert, cr.Certificate())
+
+	// a half written key pair fails to load and the current certificate is still served
+	require.NoError(t, os.WriteFile(keyFile, []byte("partial"), 0o600))
+	require.Error(t, cr.Reload())
+	require.Same(t, cert, cr.Certificate())
+
+	second := writeKeyPair(t, certFile, keyFile, "second", now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, cr.Reload())
+	require.True(t, second.Equal(cr.Certificate().Leaf))
+}
+
+func TestCertRefresherWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	now := time.Now()
+
+	writeKeyPair(t, certFile, keyFile, "first", now.Add(-time.Hour), now.Add(time.Hour))
+	cr, err := NewKeyPairCertRefresher(certFile, keyFile, &testLogger{t: t})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- cr.Watch(ctx, zap.NewNop())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	second := writeKeyPair(t, certFile, keyFile, "second", now.Add(-time.Hour), now.Add(time.Hour))
+	require.Eventually(t, func() bool {
+		return second.Equal(cr.Certificate().Leaf)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestCertPoolRefresherReload(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	now := time.Now()
+
+	first := writeKeyPair(t, caFile, "", "first", now.Add(-time.Hour), now.Add(time.Hour))
+	cp, err := NewCertPoolRefresher(caFile, &testLogger{t: t})
+	require.NoError(t, err)
+	pool := cp.CertPool()
+	_, err = first.Verify(x509.VerifyOptions{Roots: pool})
+	require.NoError(t, err)
+
+	// an unchanged bundle keeps its pool
+	require.NoError(t, cp.Reload())
+	require.Same(t, pool, cp.CertPool())
+
+	// a bundle without certificates is rejected and the current pool is kept
+	require.NoError(t, os.WriteFile(caFile, []byte("not a bundle"), 0o600))
+	require.Error(t, cp.Reload())
+	require.Same(t, pool, cp.CertPool())
+
+	second := writeKeyPair(t, caFile, "", "second", now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, cp.Reload())
+	_, err = second.Verify(x509.VerifyOptions{Roots: cp.CertPool()})
+	require.NoError(t, err)
+	_, err = first.Verify(x509.VerifyOptions{Roots: cp.CertPool()})
+	require.Error(t, err)
+}
+
+func TestWarnExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		notBefore    time.Time
+		notAfter     time.Time
+		wantWarnings int
+		wantErrors   int
+	}{
+		{
+			name:      "valid",
+			notBefore: now.Add(-time.Hour),
+			notAfter:  now.Add(time.Hour),
+		},
+		{
+			name:         "about to expire",
+			notBefore:    now.Add(-100 * time.Hour),
+			notAfter:     now.Add(time.Hour),
+			wantWarnings: 1,
+		},
+		{
+			name:       "expired",
+			notBefore:  now.Add(-2 * time.Hour),
+			notAfter:   now.Add(-time.Hour),
+			wantErrors: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &testLogger{t: t}
+			warnExpiry(l, tt.name, &x509.Certificate{NotBefore: tt.notBefore, NotAfter: tt.notAfter})
+			require.Equal(t, tt.wantWarnings, l.warnings)
+			require.Equal(t, tt.wantErrors, l.errors)
+		})
+	}
+}
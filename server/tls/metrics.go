@@ -0,0 +1,37 @@
+package tls
+
+import (
+	"crypto/x509"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// certificateExpiration is a gauge of the expiration of the certificates served by the refreshers. To alert on a
+	// certificate which is not rotated, compare it to the current time e.g. with time().
+	certificateExpiration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tls_certificate_expiration_timestamp_seconds",
+			Help: "Expiration of the latest certificate loaded, in seconds since the epoch. For a CA bundle, of its first CA to expire.",
+		},
+		[]string{"name"},
+	)
+	// reloadFailures is a monotonic counter of the failures to reload a certificate. The current certificate is still
+	// served after a failure, but a positive rate of change means that a rotated certificate is not picked up.
+	reloadFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tls_certificate_reload_failures_total",
+			Help: "Number of times reloading a certificate has failed.",
+		},
+		[]string{"name"},
+	)
+)
+
+// Collectors returns the certificate metrics, for the server to register with its registry.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{certificateExpiration, reloadFailures}
+}
+
+func observeExpiry(name string, cert *x509.Certificate) {
+	certificateExpiration.WithLabelValues(name).Set(float64(cert.NotAfter.Unix()))
+}
@@ -16,6 +16,12 @@ type TlsSettings struct {
 	UseMTLS                            bool
 	MinTLSVersion                      string
 	MtlsClientCertSubjectName          string
+	// MtlsClientCAPath is a PEM bundle of the CAs of the mTLS clients. If unset, the clients are verified with the CAs
+	// of the chain of the server certificate.
+	MtlsClientCAPath string
+	// GetMtlsClientCertSubjectName, if set, is called on each mTLS handshake for the expected client subject name, in
+	// place of MtlsClientCertSubjectName, so that the subject name can change without restarting the server.
+	GetMtlsClientCertSubjectName func() string
 }
 
 func GetTlsCertificateRetriever(settings TlsSettings) (TlsCertificateRetriever, error) {
@@ -0,0 +1,319 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package store
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/processlock"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+const (
+	// BoltExtension - Extension of the bolt database files.
+	BoltExtension = ".db"
+
+	// boltOpenTimeout is how long to wait for the bolt file lock held by another process.
+	boltOpenTimeout = 5 * time.Second
+)
+
+// boltBucket is the bucket holding the key value pairs.
+var boltBucket = []byte("kvs")
+
+// boltStore is an implementation of KeyValueStore using a local bolt database.
+// Every key is stored separately, so a write only touches the pages of that key instead of rewriting the whole state.
+// The database is opened on first use and held open for the lifetime of the store. bolt locks the file while it is
+// open, so the database is closed when the process lock is released, for the next process which holds it.
+type boltStore struct {
+	fileName     string
+	jsonFileName string
+	processLock  processlock.Interface
+	db           *bolt.DB
+	sync.Mutex
+	logger *zap.Logger
+}
+
+// NewBoltStore creates a new boltStore object, accessed as a KeyValueStore.
+// If the bolt database does not exist yet, it is initialized on first use from the JSON file store of the same
+// name with a .json extension, if there is one. The JSON file is left in place, so that a rollback to the JSON backend
+// still finds the state as of the migration.
+func NewBoltStore(fileName string, lockclient processlock.Interface, logger *zap.Logger) (KeyValueStore, error) {
+	if fileName == "" {
+		return &boltStore{}, errors.New("need to pass in a bolt file path")
+	}
+	kvs := &boltStore{
+		fileName:     fileName,
+		jsonFileName: strings.TrimSuffix(fileName, BoltExtension) + ".json",
+		processLock:  lockclient,
+		logger:       logger,
+	}
+
+	return kvs, nil
+}
+
+// Exists returns true if the bolt database exists, or if there is a JSON file store to migrate to it.
+func (kvs *boltStore) Exists() bool {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	return kvs.exists()
+}
+
+// exists is Exists for callers which hold the mutex.
+func (kvs *boltStore) exists() bool {
+	if kvs.db != nil {
+		return true
+	}
+	if _, err := os.Stat(kvs.fileName); err == nil {
+		return true
+	}
+	if _, err := os.Stat(kvs.jsonFileName); err == nil {
+		return true
+	}
+	return false
+}
+
+// open returns the bolt database, opening it on first use and creating it from the JSON file store if it does not
+// exist. It must be called with the mutex held.
+func (kvs *boltStore) open() (*bolt.DB, error) {
+	if kvs.db != nil {
+		return kvs.db, nil
+	}
+
+	_, err := os.Stat(kvs.fileName)
+	create := os.IsNotExist(err)
+
+	db, err := bolt.Open(kvs.fileName, 0o644, &bolt.Options{Timeout: boltOpenTimeout}) //nolint:gomnd // same mode as the json store
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bolt store %s", kvs.fileName)
+	}
+
+	if create {
+		if err := kvs.migrateFromJSON(db); err != nil {
+			db.Close()
+			// leave the JSON file store in place so that the migration is retried next time.
+			_ = os.Remove(kvs.fileName)
+			return nil, err
+		}
+	}
+
+	kvs.db = db
+	return db, nil
+}
+
+// close closes the bolt database if it is open. It must be called with the mutex held.
+func (kvs *boltStore) close() error {
+	if kvs.db == nil {
+		return nil
+	}
+	err := kvs.db.Close()
+	kvs.db = nil
+	return errors.Wrapf(err, "failed to close bolt store %s", kvs.fileName)
+}
+
+// migrateFromJSON imports all the key value pairs of the JSON file store in a single transaction.
+func (kvs *boltStore) migrateFromJSON(db *bolt.DB) error {
+	file, err := os.Open(kvs.jsonFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to open json store %s", kvs.jsonFileName)
+	}
+	defer file.Close()
+
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read json store %s", kvs.jsonFileName)
+	}
+
+	data := map[string]json.RawMessage{}
+	if len(b) != 0 {
+		if err := json.Unmarshal(b, &data); err != nil {
+			return errors.Wrapf(err, "failed to decode json store %s", kvs.jsonFileName)
+		}
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+		for key, raw := range data {
+			if err := bucket.Put([]byte(key), raw); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate json store %s", kvs.jsonFileName)
+	}
+
+	if kvs.logger != nil {
+		kvs.logger.Info("Migrated json store", zap.String("from", kvs.jsonFileName), zap.String("to", kvs.fileName), zap.Int("keys", len(data)))
+	} else {
+		log.Printf("Migrated %d keys from json store %s to %s", len(data), kvs.jsonFileName, kvs.fileName)
+	}
+
+	return nil
+}
+
+// Read restores the value for the given key from persistent store.
+func (kvs *boltStore) Read(key string, value interface{}) error {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	if !kvs.exists() {
+		return ErrKeyNotFound
+	}
+
+	db, err := kvs.open()
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if bucket == nil {
+			return ErrStoreEmpty
+		}
+		v := bucket.Get([]byte(key))
+		if v == nil {
+			if k, _ := bucket.Cursor().First(); k == nil {
+				return ErrStoreEmpty
+			}
+			return ErrKeyNotFound
+		}
+		// the value is only valid for the life of the transaction.
+		raw = append([]byte(nil), v...)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrStoreEmpty) {
+			if kvs.logger != nil {
+				kvs.logger.Info("Unable to read empty store", zap.String("fileName", kvs.fileName))
+			} else {
+				log.Printf("Unable to read store %s, was empty", kvs.fileName)
+			}
+		}
+		return err
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// Write saves the given key value pair to persistent store.
+func (kvs *boltStore) Write(key string, value interface{}) error {
+	return kvs.WriteMulti(map[string]interface{}{key: value})
+}
+
+// WriteMulti saves the given key value pairs to persistent store in a single transaction.
+func (kvs *boltStore) WriteMulti(values map[string]interface{}) error {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode key %s", key)
+		}
+		encoded[key] = raw
+	}
+
+	db, err := kvs.open()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+		for key, raw := range encoded {
+			if err := bucket.Put([]byte(key), raw); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "failed to write to bolt store %s", kvs.fileName)
+}
+
+// Flush is a no-op, as every write is committed to the persistent store.
+func (kvs *boltStore) Flush() error {
+	return nil
+}
+
+// Lock locks the store for exclusive access.
+func (kvs *boltStore) Lock(timeout time.Duration) error {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	return acquireProcessLock(kvs.processLock, timeout, kvs.logger)
+}
+
+// Unlock closes the bolt database, so that the next process which locks the store can open it, and unlocks the store.
+func (kvs *boltStore) Unlock() error {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	if err := kvs.close(); err != nil {
+		return err
+	}
+
+	err := kvs.processLock.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "unlock error")
+	}
+
+	if kvs.logger != nil {
+		kvs.logger.Info("Released process lock")
+	} else {
+		log.Printf("Released process lock")
+	}
+
+	return nil
+}
+
+// GetModificationTime returns the modification time of the persistent store.
+func (kvs *boltStore) GetModificationTime() (time.Time, error) {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	info, err := os.Stat(kvs.fileName)
+	if err != nil {
+		if kvs.logger != nil {
+			kvs.logger.Info("os.stat() for file", zap.String("fileName", kvs.fileName), zap.Error(err))
+		} else {
+			log.Printf("os.stat() for file %v failed: %v", kvs.fileName, err)
+		}
+
+		return time.Time{}.UTC(), err
+	}
+
+	return info.ModTime().UTC(), nil
+}
+
+func (kvs *boltStore) Remove() {
+	kvs.Mutex.Lock()
+	if err := kvs.close(); err != nil {
+		log.Errorf("could not close bolt store %s. Error: %v", kvs.fileName, err)
+	}
+	if err := os.Remove(kvs.fileName); err != nil {
+		log.Errorf("could not remove file %s. Error: %v", kvs.fileName, err)
+	}
+	kvs.Mutex.Unlock()
+}
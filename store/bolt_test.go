@@ -0,0 +1,164 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/processlock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBoltStore(t *testing.T) (KeyValueStore, string) {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "test"+BoltExtension)
+	kvs, err := NewBoltStore(fileName, processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	return kvs, fileName
+}
+
+func TestBoltStoreReadWrite(t *testing.T) {
+	kvs, _ := newTestBoltStore(t)
+	assert.False(t, kvs.Exists())
+
+	var value testType1
+	require.ErrorIs(t, kvs.Read(testKey1, &value), ErrKeyNotFound)
+
+	require.NoError(t, kvs.Write(testKey1, testType1{"test", 42}))
+	assert.True(t, kvs.Exists())
+	require.NoError(t, kvs.Read(testKey1, &value))
+	assert.Equal(t, testType1{"test", 42}, value)
+	require.ErrorIs(t, kvs.Read(testKey2, &value), ErrKeyNotFound)
+
+	// writing a key leaves the other keys untouched
+	require.NoError(t, kvs.Write(testKey2, testType1{"other", 1}))
+	require.NoError(t, kvs.Write(testKey1, testType1{"updated", 43}))
+	require.NoError(t, kvs.Read(testKey1, &value))
+	assert.Equal(t, testType1{"updated", 43}, value)
+	require.NoError(t, kvs.Read(testKey2, &value))
+	assert.Equal(t, testType1{"other", 1}, value)
+
+	_, err := kvs.GetModificationTime()
+	require.NoError(t, err)
+
+	kvs.Remove()
+	assert.False(t, kvs.Exists())
+}
+
+func TestBoltStoreWriteMulti(t *testing.T) {
+	kvs, fileName := newTestBoltStore(t)
+
+	require.NoError(t, kvs.Lock(DefaultLockTimeoutLinux))
+	require.NoError(t, kvs.WriteMulti(map[string]interface{}{
+		testKey1: testType1{"one", 1},
+		testKey2: testType1{"two", 2},
+	}))
+	// unlocking the store closes the database for the next process
+	require.NoError(t, kvs.Unlock())
+
+	// a new store on the same file sees both keys
+	reopened, err := NewBoltStore(fileName, processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Lock(DefaultLockTimeoutLinux))
+	var value testType1
+	require.NoError(t, reopened.Read(testKey1, &value))
+	assert.Equal(t, testType1{"one", 1}, value)
+	require.NoError(t, reopened.Read(testKey2, &value))
+	assert.Equal(t, testType1{"two", 2}, value)
+
+	// a value which can't be encoded fails the whole write
+	err = reopened.WriteMulti(map[string]interface{}{
+		testKey1: testType1{"three", 3},
+		testKey2: make(chan int),
+	})
+	require.Error(t, err)
+	require.NoError(t, reopened.Read(testKey1, &value))
+	assert.Equal(t, testType1{"one", 1}, value)
+	require.NoError(t, reopened.Unlock())
+}
+
+func TestBoltStoreEmpty(t *testing.T) {
+	kvs, fileName := newTestBoltStore(t)
+	require.NoError(t, kvs.WriteMulti(map[string]interface{}{}))
+	require.FileExists(t, fileName)
+
+	var value testType1
+	require.ErrorIs(t, kvs.Read(testKey1, &value), ErrStoreEmpty)
+	require.NoError(t, kvs.Write(testKey2, testType1{"two", 2}))
+	require.ErrorIs(t, kvs.Read(testKey1, &value), ErrKeyNotFound)
+}
+
+func TestBoltStoreMigratesJSONStore(t *testing.T) {
+	dir := t.TempDir()
+	jsonFileName := filepath.Join(dir, "test.json")
+	require.NoError(t, os.WriteFile(jsonFileName, []byte(`{"key1":{"Field1":"test","Field2":42},"key2":{"Field1":"other","Field2":1}}`), 0o600))
+
+	kvs, err := New(BoltBackend, filepath.Join(dir, "test"), processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	assert.True(t, kvs.Exists(), "a store with a JSON file to migrate exists")
+
+	var value testType1
+	require.NoError(t, kvs.Read(testKey1, &value))
+	assert.Equal(t, testType1{"test", 42}, value)
+	require.NoError(t, kvs.Read(testKey2, &value))
+	assert.Equal(t, testType1{"other", 1}, value)
+
+	// the JSON file is left in place for a rollback to the JSON backend
+	assert.FileExists(t, jsonFileName)
+	assert.FileExists(t, filepath.Join(dir, "test"+BoltExtension))
+
+	// it is not migrated again over the writes to the bolt database
+	require.NoError(t, kvs.Write(testKey1, testType1{"updated", 43}))
+	require.NoError(t, kvs.Unlock())
+
+	// once migrated, the bolt backend is selected by default
+	kvs, err = New("", filepath.Join(dir, "test"), processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	assert.IsType(t, &boltStore{}, kvs)
+	require.NoError(t, kvs.Read(testKey1, &value))
+	assert.Equal(t, testType1{"updated", 43}, value)
+
+	// while the JSON backend still reads the state as of the migration
+	kvs, err = New(JSONBackend, filepath.Join(dir, "test"), processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	require.NoError(t, kvs.Read(testKey1, &value))
+	assert.Equal(t, testType1{"test", 42}, value)
+}
+
+func TestBoltStoreMigrationFailureKeepsJSONStore(t *testing.T) {
+	dir := t.TempDir()
+	jsonFileName := filepath.Join(dir, "test.json")
+	require.NoError(t, os.WriteFile(jsonFileName, []byte(`{"key1":`), 0o600))
+
+	kvs, err := NewBoltStore(filepath.Join(dir, "test"+BoltExtension), processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+
+	var value testType1
+	require.Error(t, kvs.Read(testKey1, &value))
+	assert.FileExists(t, jsonFileName)
+	assert.NoFileExists(t, filepath.Join(dir, "test"+BoltExtension))
+}
+
+func TestNewStoreBackend(t *testing.T) {
+	dir := t.TempDir()
+	lock := processlock.NewMockFileLock(false)
+
+	kvs, err := New("", filepath.Join(dir, "test"), lock, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &jsonFileStore{}, kvs)
+
+	kvs, err = New(JSONBackend, filepath.Join(dir, "test"), lock, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &jsonFileStore{}, kvs)
+
+	kvs, err = New(BoltBackend, filepath.Join(dir, "test"), lock, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &boltStore{}, kvs)
+
+	_, err = New("etcd", filepath.Join(dir, "test"), lock, nil)
+	require.Error(t, err)
+}
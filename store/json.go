@@ -127,6 +127,44 @@ func (kvs *jsonFileStore) Write(key string, value interface{}) error {
 	return kvs.flush()
 }
 
+// WriteMulti saves the given key value pairs to persistent store in a single flush.
+func (kvs *jsonFileStore) WriteMulti(values map[string]interface{}) error {
+	kvs.Mutex.Lock()
+	defer kvs.Mutex.Unlock()
+
+	encoded := make(map[string]*json.RawMessage, len(values))
+	for key, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode key %s", key)
+		}
+		msg := json.RawMessage(raw)
+		encoded[key] = &msg
+	}
+
+	// keep the in-memory state consistent with the file if the flush fails.
+	previous := make(map[string]*json.RawMessage, len(encoded))
+	for key, raw := range encoded {
+		if old, ok := kvs.data[key]; ok {
+			previous[key] = old
+		}
+		kvs.data[key] = raw
+	}
+
+	if err := kvs.flush(); err != nil {
+		for key := range encoded {
+			if old, ok := previous[key]; ok {
+				kvs.data[key] = old
+			} else {
+				delete(kvs.data, key)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
 // Flush commits in-memory state to persistent store.
 func (kvs *jsonFileStore) Flush() error {
 	kvs.Mutex.Lock()
@@ -179,45 +217,12 @@ func (kvs *jsonFileStore) flush() error {
 	return nil
 }
 
-func (kvs *jsonFileStore) lockUtil(status chan error) {
-	err := kvs.processLock.Lock()
-	status <- err
-}
-
 // Lock locks the store for exclusive access.
 func (kvs *jsonFileStore) Lock(timeout time.Duration) error {
 	kvs.Mutex.Lock()
 	defer kvs.Mutex.Unlock()
 
-	afterTime := time.After(timeout)
-	status := make(chan error)
-
-	if kvs.logger != nil {
-		kvs.logger.Info("Acquiring process lock")
-	} else {
-		log.Printf("Acquiring process lock")
-	}
-
-	go kvs.lockUtil(status)
-
-	var err error
-	select {
-	case <-afterTime:
-		return ErrTimeoutLockingStore
-	case err = <-status:
-	}
-
-	if err != nil {
-		return errors.Wrap(err, "processLock acquire error")
-	}
-
-	if kvs.logger != nil {
-		kvs.logger.Info("Acquired process lock with timeout value of", zap.Any("timeout", timeout))
-	} else {
-		log.Printf("Acquired process lock with timeout value of %v", timeout)
-	}
-
-	return nil
+	return acquireProcessLock(kvs.processLock, timeout, kvs.logger)
 }
 
 // Unlock unlocks the store.
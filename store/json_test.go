@@ -5,6 +5,7 @@ package store
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -237,3 +238,30 @@ func TestFileName(t *testing.T) {
 		t.Fatalf("This should not fail for a non-empty file %v", err)
 	}
 }
+
+func TestJSONFileStoreWriteMulti(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), testFileName)
+	kvs, err := NewJsonFileStore(fileName, processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, kvs.WriteMulti(map[string]interface{}{
+		testKey1: testType1{"one", 1},
+		testKey2: testType1{"two", 2},
+	}))
+
+	reopened, err := NewJsonFileStore(fileName, processlock.NewMockFileLock(false), nil)
+	require.NoError(t, err)
+	var value testType1
+	require.NoError(t, reopened.Read(testKey1, &value))
+	require.Equal(t, testType1{"one", 1}, value)
+	require.NoError(t, reopened.Read(testKey2, &value))
+	require.Equal(t, testType1{"two", 2}, value)
+
+	// a value which can't be encoded fails the whole write
+	require.Error(t, kvs.WriteMulti(map[string]interface{}{
+		testKey1: testType1{"three", 3},
+		testKey2: make(chan int),
+	}))
+	require.NoError(t, kvs.Read(testKey1, &value))
+	require.Equal(t, testType1{"one", 1}, value)
+}
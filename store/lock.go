@@ -0,0 +1,48 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package store
+
+import (
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/processlock"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// acquireProcessLock acquires the process lock of a store, giving up after timeout.
+func acquireProcessLock(processLock processlock.Interface, timeout time.Duration, logger *zap.Logger) error {
+	afterTime := time.After(timeout)
+	status := make(chan error, 1)
+
+	if logger != nil {
+		logger.Info("Acquiring process lock")
+	} else {
+		log.Printf("Acquiring process lock")
+	}
+
+	go func() {
+		status <- processLock.Lock()
+	}()
+
+	var err error
+	select {
+	case <-afterTime:
+		return ErrTimeoutLockingStore
+	case err = <-status:
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "processLock acquire error")
+	}
+
+	if logger != nil {
+		logger.Info("Acquired process lock with timeout value of", zap.Any("timeout", timeout))
+	} else {
+		log.Printf("Acquired process lock with timeout value of %v", timeout)
+	}
+
+	return nil
+}
@@ -46,6 +46,15 @@ func (ms *mockStore) Write(key string, value interface{}) error {
 	return nil
 }
 
+func (ms *mockStore) WriteMulti(values map[string]interface{}) error {
+	for key, value := range values {
+		if err := ms.Write(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (ms *mockStore) Flush() error {
 	return nil
 }
@@ -5,7 +5,11 @@ package store
 
 import (
 	"fmt"
+	"os"
 	"time"
+
+	"github.com/Azure/azure-container-networking/processlock"
+	"go.uber.org/zap"
 )
 
 // KeyValueStore represents a persistent store of (key,value) pairs.
@@ -13,6 +17,9 @@ type KeyValueStore interface {
 	Exists() bool
 	Read(key string, value interface{}) error
 	Write(key string, value interface{}) error
+	// WriteMulti saves all the given key value pairs to persistent store atomically:
+	// either all of them are persisted or none of them are.
+	WriteMulti(values map[string]interface{}) error
 	Flush() error
 	Lock(timeout time.Duration) error
 	Unlock() error
@@ -29,3 +36,34 @@ var (
 	ErrTimeoutLockingStore            = fmt.Errorf("timed out locking store")
 	ErrNonBlockingLockIsAlreadyLocked = fmt.Errorf("attempted to perform non-blocking lock on an already locked store")
 )
+
+// Backend is the persistence backend of a KeyValueStore.
+type Backend string
+
+const (
+	// JSONBackend persists the store as a single JSON file, rewritten on every write.
+	JSONBackend Backend = "json"
+	// BoltBackend persists the store in a bolt database, with a record per key.
+	BoltBackend Backend = "bolt"
+)
+
+// New creates a KeyValueStore persisted by the given backend in a file named baseName with the extension of the
+// backend. If backend is empty, the bolt backend is used if its database already exists and the JSON backend otherwise,
+// so that a store migrated to bolt keeps being used as such.
+func New(backend Backend, baseName string, lockclient processlock.Interface, logger *zap.Logger) (KeyValueStore, error) {
+	if backend == "" {
+		backend = JSONBackend
+		if _, err := os.Stat(baseName + BoltExtension); err == nil {
+			backend = BoltBackend
+		}
+	}
+
+	switch backend {
+	case JSONBackend:
+		return NewJsonFileStore(baseName+".json", lockclient, logger)
+	case BoltBackend:
+		return NewBoltStore(baseName+BoltExtension, lockclient, logger)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", backend) //nolint:goerr113 // configuration error
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright Microsoft. All rights reserved.
+package telemetry
+
+import (
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+)
+
+// CreateOTLPTelemetryHandle exports the CNI telemetry to an OTLP receiver, alongside Application Insights if its
+// handle was created first.
+func (tb *TelemetryBuffer) CreateOTLPTelemetryHandle(otlpConfig otlptelemetry.Config, aiConfig aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error {
+	if disableAll {
+		if tb.logger != nil {
+			tb.logger.Info("Telemetry is disabled")
+		} else {
+			log.Printf("Telemetry is disabled")
+		}
+		return ErrTelemetryDisabled
+	}
+
+	oth, err := otlptelemetry.NewOTLPTelemetry(otlpConfig, aiConfig)
+	if err != nil {
+		return err
+	}
+
+	if th != nil {
+		th = aitelemetry.NewTee(th, oth)
+	} else {
+		th = oth
+	}
+
+	gDisableMetric = disableMetric
+	gDisableTrace = disableTrace
+	return nil
+}
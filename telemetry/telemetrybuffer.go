@@ -16,6 +16,7 @@ import (
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/otlptelemetry"
 	"github.com/Azure/azure-container-networking/platform"
 	"go.uber.org/zap"
 )
@@ -34,6 +35,10 @@ type TelemetryConfig struct {
 	BatchSizeInBytes              int
 	GetEnvRetryCount              int
 	GetEnvRetryWaitTimeInSecs     int
+	// DisableAppInsights stops sending the telemetry to Application Insights e.g. when it is only sent to OTLP
+	DisableAppInsights bool
+	// OTLP exports the telemetry to an OpenTelemetry receiver when set
+	OTLP *otlptelemetry.Config `json:",omitempty"`
 }
 
 // FdName - file descriptor name
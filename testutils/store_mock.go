@@ -34,6 +34,10 @@ func (mockst *KeyValueStoreMock) Write(key string, value interface{}) error {
 	return mockst.WriteError
 }
 
+func (mockst *KeyValueStoreMock) WriteMulti(map[string]interface{}) error {
+	return mockst.WriteError
+}
+
 func (mockst *KeyValueStoreMock) Flush() error {
 	return mockst.FlushError
 }
@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewTransport wraps the transport to start a client span for each request, and to propagate the trace context to
+// the server in the traceparent header.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + r.URL.Path
+	}))
+}
+
+// NewHandler wraps the handler to start a server span for each request, as a child of the trace context propagated
+// by the client. The span is named by the route of the request, e.g. its pattern in a ServeMux.
+func NewHandler(h http.Handler, route func(*http.Request) string) http.Handler {
+	return otelhttp.NewHandler(h, "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + route(r)
+	}))
+}
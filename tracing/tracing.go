@@ -0,0 +1,73 @@
+// Package tracing exports OpenTelemetry traces of the ACN components to an OTLP receiver, so that a pod network
+// operation can be followed from the CNI plugin through CNS to NMAgent.
+//
+// The components start spans with otel.Tracer whether or not tracing is initialized: until Init is called the global
+// TracerProvider is a no-op and the spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultSampleRatio samples all the traces started by the component.
+const defaultSampleRatio = 1.0
+
+var ErrInvalidSampleRatio = errors.New("tracing sample ratio must be between 0 and 1")
+
+// Config is the trace exporter configuration: the OTLP configuration which the logs and metrics of the component
+// share, and the sampling.
+type Config struct {
+	otlptelemetry.Config
+	// SampleRatio is the fraction of the traces started by the component which are sampled, all of them if unset.
+	// A trace which was started by a caller, e.g. by the CNI plugin for CNS, is sampled if the caller sampled it.
+	SampleRatio *float64
+}
+
+// Init sets the global TracerProvider to export the spans of the service to the receiver of the config, and the
+// global propagator to W3C Trace Context. The returned func exports the remaining spans and stops the export.
+func Init(ctx context.Context, cfg Config, serviceName, serviceVersion string) (func(context.Context) error, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err //nolint:wrapcheck // the otlp errors describe the config
+	}
+	ratio := defaultSampleRatio
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, errors.Wrapf(ErrInvalidSampleRatio, "%v", ratio)
+	}
+	exp, err := otlptelemetry.NewSpanExporter(ctx, &cfg.Config)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp,
+			sdktrace.WithMaxExportBatchSize(cfg.MaxBatchSize),
+			sdktrace.WithBatchTimeout(time.Duration(cfg.BatchIntervalInSecs)*time.Second),
+			sdktrace.WithExportTimeout(time.Duration(cfg.TimeoutInSecs)*time.Second)),
+		sdktrace.WithResource(otlptelemetry.NewResource(&cfg.Config, serviceName, serviceVersion)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp.Shutdown, nil
+}
+
+// End records the error, if any, as the status of the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/otlptelemetry"
+	"github.com/Azure/azure-container-networking/otlptelemetry/otlptest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// initForTest initializes tracing and restores the global TracerProvider and propagator when the test ends.
+func initForTest(t *testing.T, cfg Config) func(context.Context) error {
+	t.Helper()
+	tp, prop := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(prop)
+	})
+	shutdown, err := Init(context.Background(), cfg, "azure-cns", "v1.6.0")
+	require.NoError(t, err)
+	return shutdown
+}
+
+func findSpan(spans []*tracepb.Span, name string, kind tracepb.Span_SpanKind) *tracepb.Span {
+	for _, s := range spans {
+		if s.GetName() == name && s.GetKind() == kind {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTracePropagation(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "grpc",
+			cfg:  Config{Config: otlptelemetry.Config{Endpoint: collector.GRPCEndpoint, Insecure: true}},
+		},
+		{
+			name: "http",
+			cfg:  Config{Config: otlptelemetry.Config{Endpoint: collector.HTTPEndpoint, Protocol: otlptelemetry.ProtocolHTTPProtobuf}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := len(collector.Spans())
+			shutdown := initForTest(t, tt.cfg)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/network/requestipconfigs", func(w http.ResponseWriter, r *http.Request) {
+				_, span := otel.Tracer("test").Start(r.Context(), "requestIPConfigHandlerHelper")
+				span.End()
+				w.WriteHeader(http.StatusOK)
+			})
+			srv := httptest.NewServer(NewHandler(mux, func(r *http.Request) string {
+				_, pattern := mux.Handler(r)
+				return pattern
+			}))
+			defer srv.Close()
+
+			ctx, span := otel.Tracer("test").Start(context.Background(), "cni.Add")
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/network/requestipconfigs", http.NoBody)
+			require.NoError(t, err)
+			resp, err := (&http.Client{Transport: NewTransport(http.DefaultTransport)}).Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			End(span, nil)
+
+			require.NoError(t, shutdown(context.Background()))
+
+			received := collector.WaitForSpans(t, spans+4)[spans:]
+			root := findSpan(received, "cni.Add", tracepb.Span_SPAN_KIND_INTERNAL)
+			client := findSpan(received, "POST /network/requestipconfigs", tracepb.Span_SPAN_KIND_CLIENT)
+			server := findSpan(received, "POST /network/requestipconfigs", tracepb.Span_SPAN_KIND_SERVER)
+			handler := findSpan(received, "requestIPConfigHandlerHelper", tracepb.Span_SPAN_KIND_INTERNAL)
+			require.NotNil(t, root)
+			require.NotNil(t, client)
+			require.NotNil(t, server)
+			require.NotNil(t, handler)
+			for _, s := range received {
+				require.Equal(t, root.GetTraceId(), s.GetTraceId(), "span %s is not in the trace", s.GetName())
+			}
+			require.Equal(t, root.GetSpanId(), client.GetParentSpanId())
+			require.Equal(t, client.GetSpanId(), server.GetParentSpanId())
+			require.Equal(t, server.GetSpanId(), handler.GetParentSpanId())
+		})
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	collector := otlptest.NewCollector(t)
+	shutdown := initForTest(t, Config{Config: otlptelemetry.Config{Endpoint: collector.GRPCEndpoint, Insecure: true}})
+
+	_, span := otel.Tracer("test").Start(context.Background(), "nmagent.GetNCVersionList")
+	End(span, context.DeadlineExceeded)
+	require.NoError(t, shutdown(context.Background()))
+
+	spans := collector.WaitForSpans(t, 1)
+	require.Equal(t, tracepb.Status_STATUS_CODE_ERROR, spans[0].GetStatus().GetCode())
+	require.Equal(t, context.DeadlineExceeded.Error(), spans[0].GetStatus().GetMessage())
+}
+
+func TestInitConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name:    "missing endpoint",
+			cfg:     Config{},
+			wantErr: otlptelemetry.ErrMissingEndpoint,
+		},
+		{
+			name:    "invalid protocol",
+			cfg:     Config{Config: otlptelemetry.Config{Endpoint: "localhost:4317", Protocol: "http/json"}},
+			wantErr: otlptelemetry.ErrInvalidProtocol,
+		},
+		{
+			name:    "http endpoint without scheme",
+			cfg:     Config{Config: otlptelemetry.Config{Endpoint: "collector:4318", Protocol: otlptelemetry.ProtocolHTTPProtobuf}},
+			wantErr: otlptelemetry.ErrInvalidEndpoint,
+		},
+		{
+			name:    "invalid sample ratio",
+			cfg:     Config{Config: otlptelemetry.Config{Endpoint: "localhost:4317"}, SampleRatio: sampleRatio(2.0)},
+			wantErr: ErrInvalidSampleRatio,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Init(context.Background(), tt.cfg, "azure-cns", "v1.6.0")
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func sampleRatio(r float64) *float64 {
+	return &r
+}
+
+func TestInitSampleRatio(t *testing.T) {
+	tests := []struct {
+		name        string
+		ratio       *float64
+		wantSampled bool
+	}{
+		{
+			name:        "unset samples all",
+			wantSampled: true,
+		},
+		{
+			name:        "one samples all",
+			ratio:       sampleRatio(1.0),
+			wantSampled: true,
+		},
+		{
+			name:        "zero samples none",
+			ratio:       sampleRatio(0.0),
+			wantSampled: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown := initForTest(t, Config{Config: otlptelemetry.Config{Endpoint: "localhost:4317"}, SampleRatio: tt.ratio})
+			t.Cleanup(func() {
+				// nothing listens on the endpoint, so drop the span instead of waiting on the export.
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				_ = shutdown(ctx)
+			})
+			_, span := otel.Tracer("test").Start(context.Background(), "span")
+			defer span.End()
+			require.Equal(t, tt.wantSampled, span.SpanContext().IsSampled())
+		})
+	}
+}